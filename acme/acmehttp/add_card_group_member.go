@@ -0,0 +1,42 @@
+package acmehttp
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/x/xhttp"
+)
+
+// makeAddCardGroupMemberHandler adds the card to the group, a card is in at
+// most one group
+func makeAddCardGroupMemberHandler(groupSvc acme.CardGroupService) http.Handler {
+	return xhttp.WrapXHTTP(xhttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		groupID, err := strconv.ParseInt(chi.URLParam(r, "groupID"), 10, 64)
+		if err != nil {
+			return xhttp.NewError(http.StatusBadRequest, fmt.Errorf("parse group id: %w", err))
+		}
+
+		err = groupSvc.AddCardGroupMember(r.Context(), groupID, chi.URLParam(r, "cardID"))
+		if err != nil {
+			switch {
+			case errors.Is(err, acme.ErrCardGroupNotFound), errors.Is(err, acme.ErrCardNotFound):
+				return xhttp.NewError(http.StatusNotFound, err)
+			case errors.Is(err, acme.ErrCardGroupMemberExists), errors.Is(err, acme.ErrCardGroupBudgetExceeded):
+				return xhttp.NewError(http.StatusConflict, err)
+			}
+			return fmt.Errorf("add card group member: %w", err)
+		}
+
+		err = renderResponse(http.StatusNoContent, w, nil)
+		if err != nil {
+			return fmt.Errorf("render response: %w", err)
+		}
+
+		return nil
+	}))
+}
@@ -0,0 +1,31 @@
+package acmehttp
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/x/xhttp"
+)
+
+func makeClearTransactionFlagHandler(fraudSvc acme.FraudService) http.Handler {
+	return xhttp.WrapXHTTP(xhttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		err := fraudSvc.ClearTransactionFlag(r.Context(), chi.URLParam(r, "transactionID"))
+		if err != nil {
+			if errors.Is(err, acme.ErrTransactionFlagNotFound) {
+				return xhttp.NewError(http.StatusNotFound, err)
+			}
+			return fmt.Errorf("clear transaction flag: %w", err)
+		}
+
+		err = renderResponse(http.StatusNoContent, w, nil)
+		if err != nil {
+			return fmt.Errorf("render response: %w", err)
+		}
+
+		return nil
+	}))
+}
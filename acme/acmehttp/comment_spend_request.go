@@ -0,0 +1,54 @@
+package acmehttp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/x/xhttp"
+)
+
+func makeCommentSpendRequestHandler(reqSvc acme.SpendRequestService) http.Handler {
+	return xhttp.WrapXHTTP(xhttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		requestID, err := strconv.ParseInt(chi.URLParam(r, "requestID"), 10, 64)
+		if err != nil {
+			return xhttp.NewError(http.StatusBadRequest, fmt.Errorf("parse request id: %w", err))
+		}
+
+		var req commentSpendRequestRequest
+		err = json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return xhttp.NewError(http.StatusBadRequest, fmt.Errorf("decode request: %w", err))
+		}
+
+		activity, err := reqSvc.CommentSpendRequest(r.Context(), requestID, req.Author, req.Comment)
+		if err != nil {
+			switch {
+			case errors.Is(err, acme.ErrInvalidSpendRequest):
+				return xhttp.NewError(http.StatusBadRequest, err)
+			case errors.Is(err, acme.ErrSpendRequestNotFound):
+				return xhttp.NewError(http.StatusNotFound, err)
+			}
+			return fmt.Errorf("comment spend request: %w", err)
+		}
+
+		err = renderResponse(http.StatusCreated, w, spendRequestActivity{
+			ID:        activity.ID,
+			Kind:      activity.Kind,
+			Actor:     activity.Actor,
+			Comment:   activity.Comment,
+			CreatedAt: activity.CreatedAt.UTC().Format(time.RFC3339),
+		})
+		if err != nil {
+			return fmt.Errorf("render response: %w", err)
+		}
+
+		return nil
+	}))
+}
@@ -0,0 +1,91 @@
+package acmehttp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/x/xhttp"
+)
+
+func makeCreateAllowanceScheduleHandler(allowanceSvc acme.AllowanceService) http.Handler {
+	return xhttp.WrapXHTTP(xhttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		var req createAllowanceScheduleRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return xhttp.NewError(http.StatusBadRequest, fmt.Errorf("decode request: %w", err))
+		}
+
+		schedule, err := toAllowanceSchedule(req)
+		if err != nil {
+			return xhttp.NewError(http.StatusBadRequest, err)
+		}
+
+		created, err := allowanceSvc.CreateAllowanceSchedule(r.Context(), schedule)
+		if err != nil {
+			switch {
+			case errors.Is(err, acme.ErrInvalidAllowanceSchedule):
+				return xhttp.NewError(http.StatusBadRequest, err)
+			case errors.Is(err, acme.ErrCardNotFound):
+				return xhttp.NewError(http.StatusNotFound, err)
+			}
+			return fmt.Errorf("create allowance schedule: %w", err)
+		}
+
+		err = renderResponse(http.StatusCreated, w, toAllowanceScheduleResponse(*created))
+		if err != nil {
+			return fmt.Errorf("render response: %w", err)
+		}
+
+		return nil
+	}))
+}
+
+func toAllowanceSchedule(req createAllowanceScheduleRequest) (acme.AllowanceSchedule, error) {
+	schedule := acme.AllowanceSchedule{
+		CardID:  req.CardID,
+		Amount:  req.Amount,
+		Cadence: req.Cadence,
+		Mode:    req.Mode,
+	}
+
+	var err error
+	if req.StartDate != "" {
+		schedule.StartDate, err = time.Parse(dateLayout, req.StartDate)
+		if err != nil {
+			return schedule, fmt.Errorf("parse start date: %w", err)
+		}
+	}
+
+	if req.EndDate != "" {
+		schedule.EndDate, err = time.Parse(dateLayout, req.EndDate)
+		if err != nil {
+			return schedule, fmt.Errorf("parse end date: %w", err)
+		}
+	}
+
+	return schedule, nil
+}
+
+func toAllowanceScheduleResponse(s acme.AllowanceSchedule) allowanceSchedule {
+	resp := allowanceSchedule{
+		ID:        s.ID,
+		CardID:    s.CardID,
+		Amount:    s.Amount,
+		Cadence:   s.Cadence,
+		Mode:      s.Mode,
+		StartDate: s.StartDate.UTC().Format(dateLayout),
+		CreatedAt: s.CreatedAt.UTC().Format(time.RFC3339),
+	}
+	if !s.EndDate.IsZero() {
+		resp.EndDate = s.EndDate.UTC().Format(dateLayout)
+	}
+	if !s.NextRunAt.IsZero() {
+		resp.NextRunAt = s.NextRunAt.UTC().Format(time.RFC3339)
+	}
+
+	return resp
+}
@@ -0,0 +1,65 @@
+package acmehttp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/x/xhttp"
+)
+
+func makeCreateBalanceRuleHandler(ruleSvc acme.BalanceRuleService) http.Handler {
+	return xhttp.WrapXHTTP(xhttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		var req createBalanceRuleRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return xhttp.NewError(http.StatusBadRequest, fmt.Errorf("decode request: %w", err))
+		}
+
+		rule, err := ruleSvc.CreateBalanceRule(r.Context(), acme.BalanceRule{
+			CardID:       req.CardID,
+			Kind:         req.Kind,
+			Threshold:    req.Threshold,
+			TopUpTo:      req.TopUpTo,
+			MonthlyLimit: req.MonthlyLimit,
+		})
+		if err != nil {
+			switch {
+			case errors.Is(err, acme.ErrInvalidBalanceRule):
+				return xhttp.NewError(http.StatusBadRequest, err)
+			case errors.Is(err, acme.ErrCardNotFound):
+				return xhttp.NewError(http.StatusNotFound, err)
+			}
+			return fmt.Errorf("create balance rule: %w", err)
+		}
+
+		err = renderResponse(http.StatusCreated, w, toBalanceRule(*rule))
+		if err != nil {
+			return fmt.Errorf("render response: %w", err)
+		}
+
+		return nil
+	}))
+}
+
+func toBalanceRule(rule acme.BalanceRule) balanceRule {
+	resp := balanceRule{
+		ID:        rule.ID,
+		CardID:    rule.CardID,
+		Kind:      rule.Kind,
+		Threshold: rule.Threshold,
+		CreatedAt: rule.CreatedAt.UTC().Format(time.RFC3339),
+	}
+	if rule.Kind == acme.BalanceRuleTopUp {
+		resp.TopUpTo = &rule.TopUpTo
+		resp.MonthlyLimit = &rule.MonthlyLimit
+	}
+	if !rule.TriggeredAt.IsZero() {
+		resp.TriggeredAt = rule.TriggeredAt.UTC().Format(time.RFC3339)
+	}
+
+	return resp
+}
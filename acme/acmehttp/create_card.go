@@ -2,14 +2,18 @@ package acmehttp
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/stevenferrer/acme-cards-api/acme"
 	"github.com/stevenferrer/acme-cards-api/x/xhttp"
 )
 
-func makeCreateCardHandler(cardSvc acme.CardService) http.Handler {
+// makeCreateCardHandler creates the card, or submits a card application
+// for a checker to approve when appSvc is non-nil
+func makeCreateCardHandler(cardSvc acme.CardService, appSvc acme.CardApplicationService) http.Handler {
 	return xhttp.WrapXHTTP(xhttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
 		var c createCardRequest
 		err := json.NewDecoder(r.Body).Decode(&c)
@@ -17,8 +21,35 @@ func makeCreateCardHandler(cardSvc acme.CardService) http.Handler {
 			return fmt.Errorf("decode request: %w", err)
 		}
 
-		createCardResp, err := cardSvc.CreateCard(r.Context(), toCreateCardParams(c))
+		params, err := toCreateCardParams(c)
 		if err != nil {
+			return xhttp.NewError(http.StatusBadRequest, err)
+		}
+
+		if appSvc != nil {
+			app, err := appSvc.SubmitCardApplication(r.Context(), c.Maker, params)
+			if err != nil {
+				if errors.Is(err, acme.ErrInvalidCardApplication) || errors.Is(err, acme.ErrInvalidCardMode) ||
+					errors.Is(err, acme.ErrInvalidCardExpiry) {
+					return xhttp.NewError(http.StatusBadRequest, err)
+				}
+				return fmt.Errorf("submit card application: %w", err)
+			}
+
+			err = renderResponse(http.StatusAccepted, w, toCardApplication(*app))
+			if err != nil {
+				return fmt.Errorf("render response: %w", err)
+			}
+
+			return nil
+		}
+
+		createCardResp, err := cardSvc.CreateCard(r.Context(), params)
+		if err != nil {
+			if errors.Is(err, acme.ErrInvalidCardParams) || errors.Is(err, acme.ErrInvalidCardMode) ||
+				errors.Is(err, acme.ErrInvalidCardExpiry) {
+				return xhttp.NewError(http.StatusBadRequest, err)
+			}
 			return fmt.Errorf("create card: %w", err)
 		}
 
@@ -33,11 +64,11 @@ func makeCreateCardHandler(cardSvc acme.CardService) http.Handler {
 	}))
 }
 
-func toCreateCardParams(p createCardRequest) acme.CreateCardParams {
+func toCreateCardParams(p createCardRequest) (acme.CreateCardParams, error) {
 	addr := p.Address
 	otp := p.OTP
 	idDoc := p.IDDocument
-	return acme.CreateCardParams{
+	params := acme.CreateCardParams{
 		FirstName: p.FirstName,
 		LastName:  p.LastName,
 		DOB:       p.DOB,
@@ -57,5 +88,18 @@ func toCreateCardParams(p createCardRequest) acme.CreateCardParams {
 			Type:   idDoc.IDType,
 			Number: idDoc.IDNumber,
 		},
+		Mode:         p.Mode,
+		MerchantID:   p.MerchantID,
+		ExpiryAction: p.ExpiryAction,
+	}
+
+	if p.ValidUntil != "" {
+		var err error
+		params.ValidUntil, err = time.Parse(time.RFC3339, p.ValidUntil)
+		if err != nil {
+			return params, fmt.Errorf("parse valid until: %w", err)
+		}
 	}
+
+	return params, nil
 }
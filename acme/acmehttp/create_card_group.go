@@ -0,0 +1,40 @@
+package acmehttp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/x/xhttp"
+)
+
+func makeCreateCardGroupHandler(groupSvc acme.CardGroupService) http.Handler {
+	return xhttp.WrapXHTTP(xhttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		var req saveCardGroupRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return xhttp.NewError(http.StatusBadRequest, fmt.Errorf("decode request: %w", err))
+		}
+
+		group, err := groupSvc.CreateCardGroup(r.Context(), acme.CardGroup{
+			Name:   req.Name,
+			Budget: req.Budget,
+			Period: req.Period,
+		})
+		if err != nil {
+			if errors.Is(err, acme.ErrInvalidCardGroup) {
+				return xhttp.NewError(http.StatusBadRequest, err)
+			}
+			return fmt.Errorf("create card group: %w", err)
+		}
+
+		err = renderResponse(http.StatusCreated, w, toCardGroup(*group))
+		if err != nil {
+			return fmt.Errorf("render response: %w", err)
+		}
+
+		return nil
+	}))
+}
@@ -0,0 +1,79 @@
+package acmehttp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/x/xhttp"
+)
+
+func makeCreateJournalExportHandler(journalSvc acme.JournalService) http.Handler {
+	return xhttp.WrapXHTTP(xhttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		// an empty body continues from the previous export
+		var req createJournalExportRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return xhttp.NewError(http.StatusBadRequest, fmt.Errorf("decode request: %w", err))
+		}
+
+		params, err := toCreateJournalExportParams(req)
+		if err != nil {
+			return xhttp.NewError(http.StatusBadRequest, err)
+		}
+
+		export, err := journalSvc.CreateJournalExport(r.Context(), params)
+		if err != nil {
+			if errors.Is(err, acme.ErrInvalidJournalExportParams) ||
+				errors.Is(err, acme.ErrJournalAccountMissing) {
+				return xhttp.NewError(http.StatusBadRequest, err)
+			}
+			if errors.Is(err, acme.ErrTransactionAlreadyBooked) {
+				return xhttp.NewError(http.StatusConflict, err)
+			}
+			return fmt.Errorf("create journal export: %w", err)
+		}
+
+		err = renderResponse(http.StatusCreated, w, toJournalExport(*export))
+		if err != nil {
+			return fmt.Errorf("render response: %w", err)
+		}
+
+		return nil
+	}))
+}
+
+func toCreateJournalExportParams(req createJournalExportRequest) (acme.CreateJournalExportParams, error) {
+	var params acme.CreateJournalExportParams
+
+	var err error
+	if req.From != "" {
+		params.From, err = time.Parse(dateLayout, req.From)
+		if err != nil {
+			return params, fmt.Errorf("parse from: %w", err)
+		}
+	}
+
+	if req.Until != "" {
+		params.Until, err = time.Parse(dateLayout, req.Until)
+		if err != nil {
+			return params, fmt.Errorf("parse until: %w", err)
+		}
+	}
+
+	return params, nil
+}
+
+func toJournalExport(e acme.JournalExport) journalExport {
+	return journalExport{
+		ID:        e.ID,
+		From:      e.From.UTC().Format(time.RFC3339),
+		Until:     e.Until.UTC().Format(time.RFC3339),
+		CreatedAt: e.CreatedAt.UTC().Format(time.RFC3339),
+		LineCount: len(e.Lines),
+	}
+}
@@ -0,0 +1,58 @@
+package acmehttp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/x/xhttp"
+)
+
+func makeCreateMerchantControlHandler(controlSvc acme.MerchantControlService) http.Handler {
+	return xhttp.WrapXHTTP(xhttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		var req createMerchantControlRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return xhttp.NewError(http.StatusBadRequest, fmt.Errorf("decode request: %w", err))
+		}
+
+		control, err := controlSvc.CreateMerchantControl(r.Context(), acme.MerchantControl{
+			CardID:  req.CardID,
+			GroupID: req.GroupID,
+			Kind:    req.Kind,
+			Target:  req.Target,
+			Value:   req.Value,
+		})
+		if err != nil {
+			if errors.Is(err, acme.ErrInvalidMerchantControl) {
+				return xhttp.NewError(http.StatusBadRequest, err)
+			}
+			if errors.Is(err, acme.ErrCardNotFound) || errors.Is(err, acme.ErrCardGroupNotFound) {
+				return xhttp.NewError(http.StatusNotFound, err)
+			}
+			return fmt.Errorf("create merchant control: %w", err)
+		}
+
+		err = renderResponse(http.StatusCreated, w, toMerchantControl(*control))
+		if err != nil {
+			return fmt.Errorf("render response: %w", err)
+		}
+
+		return nil
+	}))
+}
+
+func toMerchantControl(c acme.MerchantControl) merchantControl {
+	return merchantControl{
+		ID:        c.ID,
+		CardID:    c.CardID,
+		GroupID:   c.GroupID,
+		Kind:      c.Kind,
+		Target:    c.Target,
+		Value:     c.Value,
+		CreatedAt: c.CreatedAt.UTC().Format(time.RFC3339),
+	}
+}
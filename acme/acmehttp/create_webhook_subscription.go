@@ -0,0 +1,59 @@
+package acmehttp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/x/xhttp"
+)
+
+func makeCreateWebhookSubscriptionHandler(webhookSvc acme.WebhookService) http.Handler {
+	return xhttp.WrapXHTTP(xhttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		var req createWebhookSubscriptionRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return xhttp.NewError(http.StatusBadRequest, fmt.Errorf("decode request: %w", err))
+		}
+
+		sub, err := webhookSvc.CreateWebhookSubscription(r.Context(), acme.WebhookSubscription{
+			URL:        req.URL,
+			Secret:     req.Secret,
+			EventTypes: req.EventTypes,
+		})
+		if err != nil {
+			if errors.Is(err, acme.ErrInvalidWebhookSubscription) {
+				return xhttp.NewError(http.StatusBadRequest, err)
+			}
+			return fmt.Errorf("create webhook subscription: %w", err)
+		}
+
+		resp := toWebhookSubscription(*sub)
+		resp.Secret = sub.Secret
+
+		err = renderResponse(http.StatusCreated, w, resp)
+		if err != nil {
+			return fmt.Errorf("render response: %w", err)
+		}
+
+		return nil
+	}))
+}
+
+// toWebhookSubscription omits the secret
+func toWebhookSubscription(sub acme.WebhookSubscription) webhookSubscription {
+	eventTypes := sub.EventTypes
+	if eventTypes == nil {
+		eventTypes = []string{}
+	}
+
+	return webhookSubscription{
+		ID:         sub.ID,
+		URL:        sub.URL,
+		EventTypes: eventTypes,
+		CreatedAt:  sub.CreatedAt.UTC().Format(time.RFC3339),
+	}
+}
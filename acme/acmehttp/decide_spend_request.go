@@ -0,0 +1,60 @@
+package acmehttp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/x/xhttp"
+)
+
+type decideSpendRequestFunc func(ctx context.Context, id int64, decision acme.SpendRequestDecision) (*acme.SpendRequest, error)
+
+// makeDecideSpendRequestHandler approves or rejects the request with
+// decide, the approved requests are funded once they have their required
+// approvals
+func makeDecideSpendRequestHandler(decide decideSpendRequestFunc) http.Handler {
+	return xhttp.WrapXHTTP(xhttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		requestID, err := strconv.ParseInt(chi.URLParam(r, "requestID"), 10, 64)
+		if err != nil {
+			return xhttp.NewError(http.StatusBadRequest, fmt.Errorf("parse request id: %w", err))
+		}
+
+		var req decideSpendRequestRequest
+		err = json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return xhttp.NewError(http.StatusBadRequest, fmt.Errorf("decode request: %w", err))
+		}
+
+		spendReq, err := decide(r.Context(), requestID, acme.SpendRequestDecision{
+			Approver: req.Approver,
+			Comment:  req.Comment,
+		})
+		if err != nil {
+			switch {
+			case errors.Is(err, acme.ErrInvalidSpendRequest):
+				return xhttp.NewError(http.StatusBadRequest, err)
+			case errors.Is(err, acme.ErrSpendRequestNotAllowed):
+				return xhttp.NewError(http.StatusForbidden, err)
+			case errors.Is(err, acme.ErrSpendRequestNotFound):
+				return xhttp.NewError(http.StatusNotFound, err)
+			case errors.Is(err, acme.ErrSpendRequestStatus):
+				return xhttp.NewError(http.StatusConflict, err)
+			}
+			return fmt.Errorf("decide spend request: %w", err)
+		}
+
+		err = renderResponse(http.StatusOK, w, toSpendRequest(*spendReq))
+		if err != nil {
+			return fmt.Errorf("render response: %w", err)
+		}
+
+		return nil
+	}))
+}
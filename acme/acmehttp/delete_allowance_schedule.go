@@ -0,0 +1,37 @@
+package acmehttp
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/x/xhttp"
+)
+
+func makeDeleteAllowanceScheduleHandler(allowanceSvc acme.AllowanceService) http.Handler {
+	return xhttp.WrapXHTTP(xhttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		scheduleID, err := strconv.ParseInt(chi.URLParam(r, "scheduleID"), 10, 64)
+		if err != nil {
+			return xhttp.NewError(http.StatusBadRequest, fmt.Errorf("parse schedule id: %w", err))
+		}
+
+		err = allowanceSvc.DeleteAllowanceSchedule(r.Context(), scheduleID)
+		if err != nil {
+			if errors.Is(err, acme.ErrAllowanceScheduleNotFound) {
+				return xhttp.NewError(http.StatusNotFound, err)
+			}
+			return fmt.Errorf("delete allowance schedule: %w", err)
+		}
+
+		err = renderResponse(http.StatusNoContent, w, nil)
+		if err != nil {
+			return fmt.Errorf("render response: %w", err)
+		}
+
+		return nil
+	}))
+}
@@ -0,0 +1,39 @@
+package acmehttp
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/x/xhttp"
+)
+
+// makeDeleteCardGroupHandler deletes the group, its member cards are no
+// longer limited by a budget
+func makeDeleteCardGroupHandler(groupSvc acme.CardGroupService) http.Handler {
+	return xhttp.WrapXHTTP(xhttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		groupID, err := strconv.ParseInt(chi.URLParam(r, "groupID"), 10, 64)
+		if err != nil {
+			return xhttp.NewError(http.StatusBadRequest, fmt.Errorf("parse group id: %w", err))
+		}
+
+		err = groupSvc.DeleteCardGroup(r.Context(), groupID)
+		if err != nil {
+			if errors.Is(err, acme.ErrCardGroupNotFound) {
+				return xhttp.NewError(http.StatusNotFound, err)
+			}
+			return fmt.Errorf("delete card group: %w", err)
+		}
+
+		err = renderResponse(http.StatusNoContent, w, nil)
+		if err != nil {
+			return fmt.Errorf("render response: %w", err)
+		}
+
+		return nil
+	}))
+}
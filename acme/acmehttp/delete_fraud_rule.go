@@ -0,0 +1,37 @@
+package acmehttp
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/x/xhttp"
+)
+
+func makeDeleteFraudRuleHandler(fraudSvc acme.FraudService) http.Handler {
+	return xhttp.WrapXHTTP(xhttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		ruleID, err := strconv.ParseInt(chi.URLParam(r, "ruleID"), 10, 64)
+		if err != nil {
+			return xhttp.NewError(http.StatusBadRequest, fmt.Errorf("parse rule id: %w", err))
+		}
+
+		err = fraudSvc.DeleteFraudRule(r.Context(), ruleID)
+		if err != nil {
+			if errors.Is(err, acme.ErrFraudRuleNotFound) {
+				return xhttp.NewError(http.StatusNotFound, err)
+			}
+			return fmt.Errorf("delete fraud rule: %w", err)
+		}
+
+		err = renderResponse(http.StatusNoContent, w, nil)
+		if err != nil {
+			return fmt.Errorf("render response: %w", err)
+		}
+
+		return nil
+	}))
+}
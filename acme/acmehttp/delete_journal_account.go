@@ -0,0 +1,36 @@
+package acmehttp
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/x/xhttp"
+)
+
+// makeDeleteJournalAccountHandler takes the role from the query since MCC
+// categories may contain slashes
+func makeDeleteJournalAccountHandler(journalSvc acme.JournalService) http.Handler {
+	return xhttp.WrapXHTTP(xhttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		role := r.URL.Query().Get("role")
+		if role == "" {
+			return xhttp.NewError(http.StatusBadRequest, errors.New("role is required"))
+		}
+
+		err := journalSvc.DeleteJournalAccount(r.Context(), role)
+		if err != nil {
+			if errors.Is(err, acme.ErrJournalAccountMissing) {
+				return xhttp.NewError(http.StatusNotFound, err)
+			}
+			return fmt.Errorf("delete journal account: %w", err)
+		}
+
+		err = renderResponse(http.StatusNoContent, w, nil)
+		if err != nil {
+			return fmt.Errorf("render response: %w", err)
+		}
+
+		return nil
+	}))
+}
@@ -0,0 +1,31 @@
+package acmehttp
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/x/xhttp"
+)
+
+func makeDeleteSpendRequestApproverHandler(reqSvc acme.SpendRequestService) http.Handler {
+	return xhttp.WrapXHTTP(xhttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		err := reqSvc.DeleteSpendRequestApprover(r.Context(), chi.URLParam(r, "approver"))
+		if err != nil {
+			if errors.Is(err, acme.ErrSpendRequestApproverNotFound) {
+				return xhttp.NewError(http.StatusNotFound, err)
+			}
+			return fmt.Errorf("delete spend request approver: %w", err)
+		}
+
+		err = renderResponse(http.StatusNoContent, w, nil)
+		if err != nil {
+			return fmt.Errorf("render response: %w", err)
+		}
+
+		return nil
+	}))
+}
@@ -0,0 +1,126 @@
+package acmehttp
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/acme/export"
+	"github.com/stevenferrer/acme-cards-api/x/xhttp"
+)
+
+// exportWriteTimeout replaces the server write timeout for exports, they
+// stream every page of transactions and take longer than regular requests.
+const exportWriteTimeout = 10 * time.Minute
+
+func makeExportTransactionsHandler(txSource acme.TransactionSource) http.Handler {
+	return makeExportHandler(func(r *http.Request, dateRange acme.DateRange, fn func(acme.Transaction) error) error {
+		return txSource.EachTransaction(r.Context(), dateRange, fn)
+	})
+}
+
+func makeExportCardTransactionsHandler(txSource acme.TransactionSource) http.Handler {
+	return makeExportHandler(func(r *http.Request, dateRange acme.DateRange, fn func(acme.Transaction) error) error {
+		cardID := chi.URLParam(r, "cardID")
+		return txSource.EachCardTransaction(r.Context(), cardID, dateRange, fn)
+	})
+}
+
+type eachTransactionFunc func(r *http.Request, dateRange acme.DateRange, fn func(acme.Transaction) error) error
+
+func makeExportHandler(each eachTransactionFunc) http.Handler {
+	return xhttp.WrapXHTTP(xhttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		params, err := toExportParams(r)
+		if err != nil {
+			return xhttp.NewError(http.StatusBadRequest, err)
+		}
+
+		tw, err := export.NewTransactionWriter(w, params.format, params.columns)
+		if err != nil {
+			return xhttp.NewError(http.StatusBadRequest, err)
+		}
+
+		err = http.NewResponseController(w).SetWriteDeadline(time.Now().Add(exportWriteTimeout))
+		if err != nil && !errors.Is(err, http.ErrNotSupported) {
+			return fmt.Errorf("set write deadline: %w", err)
+		}
+
+		w.Header().Set("content-type", export.ContentType(params.format))
+		w.Header().Set("content-disposition", fmt.Sprintf(`attachment; filename="transactions.%s"`, params.format))
+
+		// the rows are streamed, errors after the first page leave a
+		// truncated file
+		err = each(r, params.dateRange, tw.Write)
+		if err != nil {
+			if errors.Is(err, acme.ErrCardNotFound) {
+				return xhttp.NewError(http.StatusNotFound, err)
+			}
+			return fmt.Errorf("each transaction: %w", err)
+		}
+
+		err = tw.Close()
+		if err != nil {
+			return fmt.Errorf("close transaction writer: %w", err)
+		}
+
+		return nil
+	}))
+}
+
+type exportParams struct {
+	format    string
+	columns   []string
+	dateRange acme.DateRange
+}
+
+// toExportParams reads the format (csv by default), the comma separated
+// columns and the date range from the query.
+func toExportParams(r *http.Request) (exportParams, error) {
+	query := r.URL.Query()
+
+	params := exportParams{format: export.FormatCSV}
+	if format := query.Get("format"); format != "" {
+		params.format = format
+	}
+
+	var err error
+	params.columns, err = export.ParseTransactionColumns(query.Get("columns"))
+	if err != nil {
+		return params, fmt.Errorf("parse columns: %w", err)
+	}
+
+	params.dateRange, err = toDateRange(r)
+	if err != nil {
+		return params, err
+	}
+
+	return params, nil
+}
+
+// toDateRange parses the from and to query dates, both are optional.
+func toDateRange(r *http.Request) (acme.DateRange, error) {
+	var dateRange acme.DateRange
+	query := r.URL.Query()
+
+	if from := query.Get("from"); from != "" {
+		var err error
+		dateRange.From, err = time.Parse(dateLayout, from)
+		if err != nil {
+			return dateRange, fmt.Errorf("parse from: %w", err)
+		}
+	}
+
+	if to := query.Get("to"); to != "" {
+		var err error
+		dateRange.To, err = time.Parse(dateLayout, to)
+		if err != nil {
+			return dateRange, fmt.Errorf("parse to: %w", err)
+		}
+	}
+
+	return dateRange, nil
+}
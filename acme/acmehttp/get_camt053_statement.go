@@ -0,0 +1,43 @@
+package acmehttp
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/stevenferrer/acme-cards-api/acme/export"
+	"github.com/stevenferrer/acme-cards-api/x/xhttp"
+)
+
+func makeGetCamt053StatementHandler(generator *export.Camt053Generator) http.Handler {
+	return xhttp.WrapXHTTP(xhttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		// defaults to the last complete day
+		date := time.Now().UTC().AddDate(0, 0, -1)
+		if d := r.URL.Query().Get("date"); d != "" {
+			var err error
+			date, err = time.Parse(dateLayout, d)
+			if err != nil {
+				return xhttp.NewError(http.StatusBadRequest, fmt.Errorf("parse date: %w", err))
+			}
+		}
+
+		if date.After(time.Now()) {
+			return xhttp.NewError(http.StatusBadRequest, fmt.Errorf("date %s is in the future", date.Format(dateLayout)))
+		}
+
+		statement, err := generator.GenerateAccountStatement(r.Context(), date)
+		if err != nil {
+			return fmt.Errorf("generate account statement: %w", err)
+		}
+
+		w.Header().Set("content-type", "application/xml")
+		w.Header().Set("content-disposition", fmt.Sprintf(`attachment; filename="camt053-%s.xml"`, date.Format(dateLayout)))
+
+		err = export.WriteCamt053(w, *statement)
+		if err != nil {
+			return fmt.Errorf("write camt053: %w", err)
+		}
+
+		return nil
+	}))
+}
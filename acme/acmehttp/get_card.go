@@ -3,6 +3,7 @@ package acmehttp
 import (
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 
@@ -29,13 +30,27 @@ func makeGetCardHandler(cardSvc acme.CardService) http.Handler {
 }
 
 func toAcmeCardResponse(c acme.Card) card {
-	return card{
+	resp := card{
 		ID:              c.ID,
 		Name:            c.Name,
 		Last4:           c.Last4,
+		Status:          c.Status,
 		AvailableCredit: c.AvailableCredit,
-		ContactInfo:     toAcmeContactDetailsResponse(c.ContactInfo),
+		SpendUsage: spendUsage{
+			Daily:   c.SpendUsage.Daily,
+			Weekly:  c.SpendUsage.Weekly,
+			Monthly: c.SpendUsage.Monthly,
+			Yearly:  c.SpendUsage.Yearly,
+			AllTime: c.SpendUsage.AllTime,
+		},
+		ContactInfo: toAcmeContactDetailsResponse(c.ContactInfo),
 	}
+	if !c.ValidUntil.IsZero() {
+		resp.ValidUntil = c.ValidUntil.UTC().Format(time.RFC3339)
+		resp.ExpiryAction = c.ExpiryAction
+	}
+
+	return resp
 }
 
 func toAcmeContactDetailsResponse(c acme.ContactInfo) contactDetails {
@@ -0,0 +1,89 @@
+package acmehttp
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/x/xhttp"
+)
+
+// makeGetCardApplicationHandler returns the application with its KYC and
+// audit trail
+func makeGetCardApplicationHandler(appSvc acme.CardApplicationService) http.Handler {
+	return xhttp.WrapXHTTP(xhttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		applicationID, err := strconv.ParseInt(chi.URLParam(r, "applicationID"), 10, 64)
+		if err != nil {
+			return xhttp.NewError(http.StatusBadRequest, fmt.Errorf("parse application id: %w", err))
+		}
+
+		app, err := appSvc.GetCardApplication(r.Context(), applicationID)
+		if err != nil {
+			if errors.Is(err, acme.ErrCardApplicationNotFound) {
+				return xhttp.NewError(http.StatusNotFound, err)
+			}
+			return fmt.Errorf("get card application: %w", err)
+		}
+
+		err = renderResponse(http.StatusOK, w, toCardApplication(*app))
+		if err != nil {
+			return fmt.Errorf("render response: %w", err)
+		}
+
+		return nil
+	}))
+}
+
+func toCardApplication(app acme.CardApplication) cardApplication {
+	p := app.Params
+	resp := cardApplication{
+		ID:              app.ID,
+		Status:          app.Status,
+		Maker:           app.Maker,
+		Checker:         app.Checker,
+		RejectionReason: app.RejectionReason,
+		CardID:          app.CardID,
+		Error:           app.Error,
+		Applicant: cardApplicant{
+			FirstName: p.FirstName,
+			LastName:  p.LastName,
+			DOB:       p.DOB,
+			Address: addressInfo{
+				Line1:   p.Address.Line1,
+				Line2:   p.Address.Line2,
+				City:    p.Address.City,
+				Country: p.Address.CountryCode,
+			},
+			IDDocument: idDocument{
+				IDType:   p.IDDocument.Type,
+				IDNumber: p.IDDocument.Number,
+			},
+			OTP:          toAcmeContactDetailsResponse(p.ContactInfo),
+			Mode:         p.Mode,
+			MerchantID:   p.MerchantID,
+			ExpiryAction: p.ExpiryAction,
+		},
+		ExpiresAt: app.ExpiresAt.UTC().Format(time.RFC3339),
+		CreatedAt: app.CreatedAt.UTC().Format(time.RFC3339),
+		UpdatedAt: app.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+	if !p.ValidUntil.IsZero() {
+		resp.Applicant.ValidUntil = p.ValidUntil.UTC().Format(time.RFC3339)
+	}
+	for _, e := range app.Events {
+		resp.Events = append(resp.Events, cardApplicationEvent{
+			ID:        e.ID,
+			Action:    e.Action,
+			Actor:     e.Actor,
+			Reason:    e.Reason,
+			CreatedAt: e.CreatedAt.UTC().Format(time.RFC3339),
+		})
+	}
+
+	return resp
+}
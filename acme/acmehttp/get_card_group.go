@@ -0,0 +1,50 @@
+package acmehttp
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/x/xhttp"
+)
+
+// makeGetCardGroupHandler returns the group with its member cards
+func makeGetCardGroupHandler(groupSvc acme.CardGroupService) http.Handler {
+	return xhttp.WrapXHTTP(xhttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		groupID, err := strconv.ParseInt(chi.URLParam(r, "groupID"), 10, 64)
+		if err != nil {
+			return xhttp.NewError(http.StatusBadRequest, fmt.Errorf("parse group id: %w", err))
+		}
+
+		group, err := groupSvc.GetCardGroup(r.Context(), groupID)
+		if err != nil {
+			if errors.Is(err, acme.ErrCardGroupNotFound) {
+				return xhttp.NewError(http.StatusNotFound, err)
+			}
+			return fmt.Errorf("get card group: %w", err)
+		}
+
+		err = renderResponse(http.StatusOK, w, toCardGroup(*group))
+		if err != nil {
+			return fmt.Errorf("render response: %w", err)
+		}
+
+		return nil
+	}))
+}
+
+func toCardGroup(group acme.CardGroup) cardGroup {
+	return cardGroup{
+		ID:        group.ID,
+		Name:      group.Name,
+		Budget:    group.Budget,
+		Period:    group.Period,
+		CardIDs:   group.CardIDs,
+		CreatedAt: group.CreatedAt.UTC().Format(time.RFC3339),
+	}
+}
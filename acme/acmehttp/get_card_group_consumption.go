@@ -0,0 +1,59 @@
+package acmehttp
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/x/xhttp"
+)
+
+// makeGetCardGroupConsumptionHandler returns the spend and the remaining
+// budget of the group in the current period
+func makeGetCardGroupConsumptionHandler(groupSvc acme.CardGroupService) http.Handler {
+	return xhttp.WrapXHTTP(xhttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		groupID, err := strconv.ParseInt(chi.URLParam(r, "groupID"), 10, 64)
+		if err != nil {
+			return xhttp.NewError(http.StatusBadRequest, fmt.Errorf("parse group id: %w", err))
+		}
+
+		consumption, err := groupSvc.GetCardGroupConsumption(r.Context(), groupID)
+		if err != nil {
+			if errors.Is(err, acme.ErrCardGroupNotFound) {
+				return xhttp.NewError(http.StatusNotFound, err)
+			}
+			return fmt.Errorf("get card group consumption: %w", err)
+		}
+
+		resp := cardGroupConsumption{
+			GroupID:     consumption.GroupID,
+			Period:      consumption.Period,
+			PeriodStart: consumption.PeriodStart.UTC().Format(time.RFC3339),
+			PeriodEnd:   consumption.PeriodEnd.UTC().Format(time.RFC3339),
+			Budget:      consumption.Budget,
+			Spent:       consumption.Spent,
+			Allocated:   consumption.Allocated,
+			Remaining:   consumption.Remaining,
+			Cards:       make([]cardGroupCardConsumption, 0, len(consumption.Cards)),
+		}
+		for _, card := range consumption.Cards {
+			resp.Cards = append(resp.Cards, cardGroupCardConsumption{
+				CardID:          card.CardID,
+				Spent:           card.Spent,
+				AvailableCredit: card.AvailableCredit,
+			})
+		}
+
+		err = renderResponse(http.StatusOK, w, resp)
+		if err != nil {
+			return fmt.Errorf("render response: %w", err)
+		}
+
+		return nil
+	}))
+}
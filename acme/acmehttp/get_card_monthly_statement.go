@@ -0,0 +1,90 @@
+package acmehttp
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/acme/export"
+	"github.com/stevenferrer/acme-cards-api/x/xhttp"
+)
+
+// monthLayout is the statement month in the path
+const monthLayout = "2006-01"
+
+// makeGetCardMonthlyStatementHandler renders the statement as JSON or as
+// printable HTML with format=html or an Accept header of text/html.
+func makeGetCardMonthlyStatementHandler(generator *export.MonthlyStatementGenerator) http.Handler {
+	return xhttp.WrapXHTTP(xhttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		cardID := chi.URLParam(r, "cardID")
+
+		month, err := time.Parse(monthLayout, chi.URLParam(r, "month"))
+		if err != nil {
+			return xhttp.NewError(http.StatusBadRequest, fmt.Errorf("parse month: %w", err))
+		}
+
+		statement, err := generator.GetMonthlyStatement(r.Context(), cardID, month)
+		if err != nil {
+			if errors.Is(err, acme.ErrCardNotFound) {
+				return xhttp.NewError(http.StatusNotFound, err)
+			}
+			if errors.Is(err, export.ErrInvalidStatementMonth) {
+				return xhttp.NewError(http.StatusBadRequest, err)
+			}
+			return fmt.Errorf("get monthly statement: %w", err)
+		}
+
+		if r.URL.Query().Get("format") == "html" || strings.Contains(r.Header.Get("accept"), "text/html") {
+			w.Header().Set("content-type", "text/html; charset=utf-8")
+			err = export.WriteMonthlyStatementHTML(w, *statement)
+			if err != nil {
+				return fmt.Errorf("write monthly statement html: %w", err)
+			}
+
+			return nil
+		}
+
+		err = renderResponse(http.StatusOK, w, toMonthlyStatementResponse(*statement))
+		if err != nil {
+			return fmt.Errorf("render response: %w", err)
+		}
+
+		return nil
+	}))
+}
+
+func toMonthlyStatementResponse(s export.MonthlyStatement) monthlyStatementResponse {
+	entries := make([]statementEntry, 0, len(s.Entries))
+	for _, e := range s.Entries {
+		entries = append(entries, statementEntry{
+			ID:       e.ID,
+			Type:     e.Type,
+			Date:     e.Date.UTC().Format(time.RFC3339),
+			Amount:   e.Amount,
+			Payee:    e.Payee,
+			Category: e.Category,
+			Memo:     e.Memo,
+		})
+	}
+
+	return monthlyStatementResponse{
+		CardID:         s.Card.ID,
+		CardName:       s.Card.Name,
+		Last4:          s.Card.Last4,
+		Month:          s.Month.Format(monthLayout),
+		OpeningBalance: s.OpeningBalance,
+		ClosingBalance: s.ClosingBalance,
+		TopUps:         s.TopUps,
+		Withdrawals:    s.Withdrawals,
+		Purchases:      s.Purchases,
+		Refunds:        s.Refunds,
+		Fees:           s.Fees,
+		Entries:        entries,
+		GeneratedAt:    s.GeneratedAt.UTC().Format(time.RFC3339),
+	}
+}
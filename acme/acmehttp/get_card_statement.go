@@ -0,0 +1,62 @@
+package acmehttp
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/acme/export"
+	"github.com/stevenferrer/acme-cards-api/x/xhttp"
+)
+
+// statementWriter writes a statement in a personal finance format
+type statementWriter struct {
+	contentType string
+	extension   string
+	write       func(io.Writer, export.Statement) error
+}
+
+var (
+	ofxStatementWriter = statementWriter{"application/x-ofx", "ofx", export.WriteOFX}
+	qifStatementWriter = statementWriter{"application/qif", "qif", export.WriteQIF}
+)
+
+func makeGetCardStatementHandler(loader *export.StatementLoader, sw statementWriter) http.Handler {
+	return xhttp.WrapXHTTP(xhttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		cardID := chi.URLParam(r, "cardID")
+
+		dateRange, err := toDateRange(r)
+		if err != nil {
+			return xhttp.NewError(http.StatusBadRequest, err)
+		}
+
+		// statements default to the current month
+		if dateRange.From.IsZero() {
+			now := time.Now().UTC()
+			dateRange.From = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+		}
+
+		statement, err := loader.LoadStatement(r.Context(), cardID, dateRange)
+		if err != nil {
+			if errors.Is(err, acme.ErrCardNotFound) {
+				return xhttp.NewError(http.StatusNotFound, err)
+			}
+			return fmt.Errorf("load statement: %w", err)
+		}
+
+		w.Header().Set("content-type", sw.contentType)
+		w.Header().Set("content-disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, cardID, sw.extension))
+
+		err = sw.write(w, *statement)
+		if err != nil {
+			return fmt.Errorf("write statement: %w", err)
+		}
+
+		return nil
+	}))
+}
@@ -0,0 +1,57 @@
+package acmehttp
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/acme/export"
+	"github.com/stevenferrer/acme-cards-api/x/xhttp"
+)
+
+// journalWriters are the journal file formats by the format query
+var journalWriters = map[string]func(io.Writer, acme.JournalExport) error{
+	"csv":  export.WriteJournalCSV,
+	"xero": export.WriteXeroJournal,
+}
+
+func makeGetJournalExportHandler(journalSvc acme.JournalService) http.Handler {
+	return xhttp.WrapXHTTP(xhttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		exportID, err := strconv.ParseInt(chi.URLParam(r, "exportID"), 10, 64)
+		if err != nil {
+			return xhttp.NewError(http.StatusBadRequest, fmt.Errorf("parse export id: %w", err))
+		}
+
+		format := r.URL.Query().Get("format")
+		if format == "" {
+			format = "csv"
+		}
+		write, ok := journalWriters[format]
+		if !ok {
+			return xhttp.NewError(http.StatusBadRequest, fmt.Errorf("unsupported format %q", format))
+		}
+
+		je, err := journalSvc.GetJournalExport(r.Context(), exportID)
+		if err != nil {
+			if errors.Is(err, acme.ErrJournalExportNotFound) {
+				return xhttp.NewError(http.StatusNotFound, err)
+			}
+			return fmt.Errorf("get journal export: %w", err)
+		}
+
+		w.Header().Set("content-type", "text/csv")
+		w.Header().Set("content-disposition", fmt.Sprintf(`attachment; filename="journal-%d-%s.csv"`, je.ID, format))
+
+		err = write(w, *je)
+		if err != nil {
+			return fmt.Errorf("write journal: %w", err)
+		}
+
+		return nil
+	}))
+}
@@ -0,0 +1,47 @@
+package acmehttp
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/x/xhttp"
+)
+
+func makeGetNotificationPreferenceHandler(notificationSvc acme.NotificationService) http.Handler {
+	return xhttp.WrapXHTTP(xhttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		pref, err := notificationSvc.GetNotificationPreference(r.Context(), chi.URLParam(r, "email"))
+		if err != nil {
+			if errors.Is(err, acme.ErrNotificationPreferenceNotFound) {
+				return xhttp.NewError(http.StatusNotFound, err)
+			}
+			return fmt.Errorf("get notification preference: %w", err)
+		}
+
+		err = renderResponse(http.StatusOK, w, toNotificationPreference(*pref))
+		if err != nil {
+			return fmt.Errorf("render response: %w", err)
+		}
+
+		return nil
+	}))
+}
+
+func toNotificationPreference(pref acme.NotificationPreference) notificationPreference {
+	optOutTypes := pref.OptOutTypes
+	if optOutTypes == nil {
+		optOutTypes = []string{}
+	}
+
+	return notificationPreference{
+		Email:       pref.Email,
+		Locale:      pref.Locale,
+		OptOutAll:   pref.OptOutAll,
+		OptOutTypes: optOutTypes,
+		UpdatedAt:   pref.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,92 @@
+package acmehttp
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/x/xhttp"
+)
+
+const dateLayout = "2006-01-02"
+
+func makeGetSpendAnalyticsHandler(analyticsSvc acme.SpendAnalyticsService) http.Handler {
+	return xhttp.WrapXHTTP(xhttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		params, err := toSpendAnalyticsParams(r, time.Now())
+		if err != nil {
+			return xhttp.NewError(http.StatusBadRequest, err)
+		}
+
+		resp, err := analyticsSvc.GetSpendAnalytics(r.Context(), params)
+		if err != nil {
+			if errors.Is(err, acme.ErrInvalidSpendAnalyticsParams) {
+				return xhttp.NewError(http.StatusBadRequest, err)
+			}
+			return fmt.Errorf("get spend analytics: %w", err)
+		}
+
+		err = renderResponse(http.StatusOK, w, toAcmeSpendAnalyticsResponse(*resp))
+		if err != nil {
+			return fmt.Errorf("render response: %w", err)
+		}
+
+		return nil
+	}))
+}
+
+// toSpendAnalyticsParams defaults to the spend by category per month
+// since the start of the current month.
+func toSpendAnalyticsParams(r *http.Request, now time.Time) (acme.SpendAnalyticsParams, error) {
+	query := r.URL.Query()
+
+	params := acme.SpendAnalyticsParams{
+		GroupBy: acme.SpendGroupByCategory,
+		Period:  acme.SpendPeriodMonth,
+	}
+	if groupBy := query.Get("groupBy"); groupBy != "" {
+		params.GroupBy = groupBy
+	}
+	if period := query.Get("period"); period != "" {
+		params.Period = period
+	}
+
+	var err error
+	params.DateRange, err = toDateRange(r)
+	if err != nil {
+		return params, err
+	}
+
+	if params.From.IsZero() {
+		params.From = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	return params, nil
+}
+
+func toAcmeSpendAnalyticsResponse(a acme.SpendAnalytics) spendAnalyticsResponse {
+	buckets := make([]spendBucket, 0, len(a.Buckets))
+	for _, b := range a.Buckets {
+		buckets = append(buckets, spendBucket{
+			PeriodStart: b.PeriodStart.Format(dateLayout),
+			Key:         b.Key,
+			Label:       b.Label,
+			Count:       b.Count,
+			Total:       b.Total,
+			Average:     b.Average,
+		})
+	}
+
+	resp := spendAnalyticsResponse{
+		GroupBy: a.GroupBy,
+		Period:  a.Period,
+		From:    a.From.Format(dateLayout),
+		Buckets: buckets,
+	}
+	if !a.To.IsZero() {
+		resp.To = a.To.Format(dateLayout)
+	}
+
+	return resp
+}
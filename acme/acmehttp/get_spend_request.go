@@ -0,0 +1,39 @@
+package acmehttp
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/x/xhttp"
+)
+
+// makeGetSpendRequestHandler returns the request with its approvals,
+// comments and status changes
+func makeGetSpendRequestHandler(reqSvc acme.SpendRequestService) http.Handler {
+	return xhttp.WrapXHTTP(xhttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		requestID, err := strconv.ParseInt(chi.URLParam(r, "requestID"), 10, 64)
+		if err != nil {
+			return xhttp.NewError(http.StatusBadRequest, fmt.Errorf("parse request id: %w", err))
+		}
+
+		req, err := reqSvc.GetSpendRequest(r.Context(), requestID)
+		if err != nil {
+			if errors.Is(err, acme.ErrSpendRequestNotFound) {
+				return xhttp.NewError(http.StatusNotFound, err)
+			}
+			return fmt.Errorf("get spend request: %w", err)
+		}
+
+		err = renderResponse(http.StatusOK, w, toSpendRequest(*req))
+		if err != nil {
+			return fmt.Errorf("render response: %w", err)
+		}
+
+		return nil
+	}))
+}
@@ -0,0 +1,55 @@
+package acmehttp
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/x/xhttp"
+)
+
+// makeGetWebhookDeliveryHandler returns the delivery with its payload and
+// attempt log
+func makeGetWebhookDeliveryHandler(webhookSvc acme.WebhookService) http.Handler {
+	return xhttp.WrapXHTTP(xhttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		subscriptionID, deliveryID, err := parseWebhookDeliveryIDs(r)
+		if err != nil {
+			return err
+		}
+
+		d, err := webhookSvc.GetWebhookDelivery(r.Context(), subscriptionID, deliveryID)
+		if err != nil {
+			if errors.Is(err, acme.ErrWebhookDeliveryNotFound) {
+				return xhttp.NewError(http.StatusNotFound, err)
+			}
+			return fmt.Errorf("get webhook delivery: %w", err)
+		}
+
+		attempts, err := webhookSvc.ListWebhookDeliveryAttempts(r.Context(), subscriptionID, deliveryID)
+		if err != nil {
+			return fmt.Errorf("list webhook delivery attempts: %w", err)
+		}
+
+		resp := toWebhookDelivery(*d)
+		resp.Payload = d.Payload
+		resp.AttemptLog = make([]webhookDeliveryAttempt, 0, len(attempts))
+		for _, a := range attempts {
+			resp.AttemptLog = append(resp.AttemptLog, webhookDeliveryAttempt{
+				Attempt:      a.Attempt,
+				ResponseCode: a.ResponseCode,
+				Error:        a.Error,
+				DurationMS:   a.Duration.Milliseconds(),
+				CreatedAt:    a.CreatedAt.UTC().Format(time.RFC3339),
+			})
+		}
+
+		err = renderResponse(http.StatusOK, w, resp)
+		if err != nil {
+			return fmt.Errorf("render response: %w", err)
+		}
+
+		return nil
+	}))
+}
@@ -0,0 +1,37 @@
+package acmehttp
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/x/xhttp"
+)
+
+func makeGetWebhookSubscriptionHandler(webhookSvc acme.WebhookService) http.Handler {
+	return xhttp.WrapXHTTP(xhttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		subscriptionID, err := strconv.ParseInt(chi.URLParam(r, "subscriptionID"), 10, 64)
+		if err != nil {
+			return xhttp.NewError(http.StatusBadRequest, fmt.Errorf("parse subscription id: %w", err))
+		}
+
+		sub, err := webhookSvc.GetWebhookSubscription(r.Context(), subscriptionID)
+		if err != nil {
+			if errors.Is(err, acme.ErrWebhookSubscriptionNotFound) {
+				return xhttp.NewError(http.StatusNotFound, err)
+			}
+			return fmt.Errorf("get webhook subscription: %w", err)
+		}
+
+		err = renderResponse(http.StatusOK, w, toWebhookSubscription(*sub))
+		if err != nil {
+			return fmt.Errorf("render response: %w", err)
+		}
+
+		return nil
+	}))
+}
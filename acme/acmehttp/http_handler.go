@@ -8,25 +8,199 @@ import (
 	"github.com/go-chi/chi/v5"
 
 	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/acme/export"
 )
 
-func NewAccountHTTPHandler(cardSvc acme.CardService) http.Handler {
+func NewAccountHTTPHandler(
+	cardSvc acme.CardService,
+	txSource acme.TransactionSource,
+	camt053Generator *export.Camt053Generator,
+) http.Handler {
 	mux := chi.NewMux()
 
 	mux.Method(http.MethodGet, "/balance", makeGetAccountBalHandler(cardSvc))
 	mux.Method(http.MethodGet, "/transactions", makeListTransactionsHandler(cardSvc))
+	mux.Method(http.MethodGet, "/transactions/export", makeExportTransactionsHandler(txSource))
+	mux.Method(http.MethodGet, "/statements/camt053", makeGetCamt053StatementHandler(camt053Generator))
 
 	return mux
 }
 
-func NewHTTPHandler(cardSvc acme.CardService) http.Handler {
+func NewAnalyticsHTTPHandler(analyticsSvc acme.SpendAnalyticsService) http.Handler {
 	mux := chi.NewMux()
 
-	mux.Method(http.MethodPost, "/", makeCreateCardHandler(cardSvc))
+	mux.Method(http.MethodGet, "/spend", makeGetSpendAnalyticsHandler(analyticsSvc))
+
+	return mux
+}
+
+func NewEventStreamHTTPHandler(hub *acme.EventHub) http.Handler {
+	mux := chi.NewMux()
+
+	mux.Method(http.MethodGet, "/stream", makeStreamEventsHandler(hub))
+
+	return mux
+}
+
+func NewFraudHTTPHandler(fraudSvc acme.FraudService) http.Handler {
+	mux := chi.NewMux()
+
+	mux.Method(http.MethodGet, "/rules", makeListFraudRulesHandler(fraudSvc))
+	mux.Method(http.MethodPost, "/rules", makeSaveFraudRuleHandler(fraudSvc, false))
+	mux.Method(http.MethodPut, "/rules/{ruleID}", makeSaveFraudRuleHandler(fraudSvc, true))
+	mux.Method(http.MethodDelete, "/rules/{ruleID}", makeDeleteFraudRuleHandler(fraudSvc))
+	mux.Method(http.MethodGet, "/decisions", makeListFraudDecisionsHandler(fraudSvc))
+	mux.Method(http.MethodGet, "/flags", makeListTransactionFlagsHandler(fraudSvc))
+	mux.Method(http.MethodPost, "/flags/{transactionID}/clear", makeClearTransactionFlagHandler(fraudSvc))
+
+	return mux
+}
+
+func NewJournalHTTPHandler(journalSvc acme.JournalService) http.Handler {
+	mux := chi.NewMux()
+
+	mux.Method(http.MethodGet, "/accounts", makeListJournalAccountsHandler(journalSvc))
+	mux.Method(http.MethodPut, "/accounts", makeSaveJournalAccountHandler(journalSvc))
+	mux.Method(http.MethodDelete, "/accounts", makeDeleteJournalAccountHandler(journalSvc))
+	mux.Method(http.MethodPost, "/exports", makeCreateJournalExportHandler(journalSvc))
+	mux.Method(http.MethodGet, "/exports", makeListJournalExportsHandler(journalSvc))
+	mux.Method(http.MethodGet, "/exports/{exportID}", makeGetJournalExportHandler(journalSvc))
+
+	return mux
+}
+
+func NewMerchantControlHTTPHandler(controlSvc acme.MerchantControlService) http.Handler {
+	mux := chi.NewMux()
+
+	mux.Method(http.MethodGet, "/", makeListMerchantControlsHandler(controlSvc))
+	mux.Method(http.MethodPost, "/", makeCreateMerchantControlHandler(controlSvc))
+	mux.Method(http.MethodGet, "/violations", makeListMerchantControlViolationsHandler(controlSvc))
+	mux.Method(http.MethodDelete, "/{controlID}", makeDeleteMerchantControlHandler(controlSvc))
+
+	return mux
+}
+
+func NewWebhookHTTPHandler(webhookSvc acme.WebhookService) http.Handler {
+	mux := chi.NewMux()
+
+	mux.Method(http.MethodGet, "/", makeListWebhookSubscriptionsHandler(webhookSvc))
+	mux.Method(http.MethodPost, "/", makeCreateWebhookSubscriptionHandler(webhookSvc))
+	mux.Method(http.MethodGet, "/{subscriptionID}", makeGetWebhookSubscriptionHandler(webhookSvc))
+	mux.Method(http.MethodDelete, "/{subscriptionID}", makeDeleteWebhookSubscriptionHandler(webhookSvc))
+	mux.Method(http.MethodGet, "/{subscriptionID}/deliveries", makeListWebhookDeliveriesHandler(webhookSvc))
+	mux.Method(http.MethodGet, "/{subscriptionID}/deliveries/{deliveryID}", makeGetWebhookDeliveryHandler(webhookSvc))
+	mux.Method(http.MethodPost, "/{subscriptionID}/deliveries/{deliveryID}/redeliver", makeRedeliverWebhookHandler(webhookSvc))
+
+	return mux
+}
+
+func NewNotificationHTTPHandler(notificationSvc acme.NotificationService) http.Handler {
+	mux := chi.NewMux()
+
+	mux.Method(http.MethodGet, "/", makeListNotificationsHandler(notificationSvc))
+	mux.Method(http.MethodGet, "/preferences/{email}", makeGetNotificationPreferenceHandler(notificationSvc))
+	mux.Method(http.MethodPut, "/preferences/{email}", makeSaveNotificationPreferenceHandler(notificationSvc))
+
+	return mux
+}
+
+func NewBalanceRuleHTTPHandler(ruleSvc acme.BalanceRuleService) http.Handler {
+	mux := chi.NewMux()
+
+	mux.Method(http.MethodGet, "/", makeListBalanceRulesHandler(ruleSvc))
+	mux.Method(http.MethodPost, "/", makeCreateBalanceRuleHandler(ruleSvc))
+	mux.Method(http.MethodGet, "/executions", makeListBalanceRuleExecutionsHandler(ruleSvc))
+	mux.Method(http.MethodDelete, "/{ruleID}", makeDeleteBalanceRuleHandler(ruleSvc))
+
+	return mux
+}
+
+func NewAllowanceHTTPHandler(allowanceSvc acme.AllowanceService) http.Handler {
+	mux := chi.NewMux()
+
+	mux.Method(http.MethodGet, "/", makeListAllowanceSchedulesHandler(allowanceSvc))
+	mux.Method(http.MethodPost, "/", makeCreateAllowanceScheduleHandler(allowanceSvc))
+	mux.Method(http.MethodGet, "/runs", makeListAllowanceRunsHandler(allowanceSvc))
+	mux.Method(http.MethodDelete, "/{scheduleID}", makeDeleteAllowanceScheduleHandler(allowanceSvc))
+	mux.Method(http.MethodGet, "/{scheduleID}/runs", makeListAllowanceRunsHandler(allowanceSvc))
+
+	return mux
+}
+
+func NewBurnerCardHTTPHandler(burnerSvc acme.BurnerCardService) http.Handler {
+	mux := chi.NewMux()
+
+	mux.Method(http.MethodGet, "/", makeListBurnerCardsHandler(burnerSvc))
+
+	return mux
+}
+
+func NewSpendRequestHTTPHandler(reqSvc acme.SpendRequestService) http.Handler {
+	mux := chi.NewMux()
+
+	mux.Method(http.MethodGet, "/", makeListSpendRequestsHandler(reqSvc))
+	mux.Method(http.MethodPost, "/", makeSubmitSpendRequestHandler(reqSvc))
+	mux.Method(http.MethodGet, "/approvers", makeListSpendRequestApproversHandler(reqSvc))
+	mux.Method(http.MethodPut, "/approvers/{approver}", makeSaveSpendRequestApproverHandler(reqSvc))
+	mux.Method(http.MethodDelete, "/approvers/{approver}", makeDeleteSpendRequestApproverHandler(reqSvc))
+	mux.Method(http.MethodGet, "/{requestID}", makeGetSpendRequestHandler(reqSvc))
+	mux.Method(http.MethodPost, "/{requestID}/approve", makeDecideSpendRequestHandler(reqSvc.ApproveSpendRequest))
+	mux.Method(http.MethodPost, "/{requestID}/reject", makeDecideSpendRequestHandler(reqSvc.RejectSpendRequest))
+	mux.Method(http.MethodPost, "/{requestID}/comments", makeCommentSpendRequestHandler(reqSvc))
+	mux.Method(http.MethodPost, "/{requestID}/retry", makeRetrySpendRequestFundingHandler(reqSvc))
+
+	return mux
+}
+
+func NewCardApplicationHTTPHandler(appSvc acme.CardApplicationService) http.Handler {
+	mux := chi.NewMux()
+
+	mux.Method(http.MethodGet, "/", makeListCardApplicationsHandler(appSvc))
+	mux.Method(http.MethodGet, "/{applicationID}", makeGetCardApplicationHandler(appSvc))
+	mux.Method(http.MethodPost, "/{applicationID}/approve", makeApproveCardApplicationHandler(appSvc))
+	mux.Method(http.MethodPost, "/{applicationID}/reject", makeRejectCardApplicationHandler(appSvc))
+
+	return mux
+}
+
+func NewCardGroupHTTPHandler(groupSvc acme.CardGroupService) http.Handler {
+	mux := chi.NewMux()
+
+	mux.Method(http.MethodGet, "/", makeListCardGroupsHandler(groupSvc))
+	mux.Method(http.MethodPost, "/", makeCreateCardGroupHandler(groupSvc))
+	mux.Method(http.MethodGet, "/{groupID}", makeGetCardGroupHandler(groupSvc))
+	mux.Method(http.MethodPut, "/{groupID}", makeUpdateCardGroupHandler(groupSvc))
+	mux.Method(http.MethodDelete, "/{groupID}", makeDeleteCardGroupHandler(groupSvc))
+	mux.Method(http.MethodGet, "/{groupID}/consumption", makeGetCardGroupConsumptionHandler(groupSvc))
+	mux.Method(http.MethodPut, "/{groupID}/cards/{cardID}", makeAddCardGroupMemberHandler(groupSvc))
+	mux.Method(http.MethodDelete, "/{groupID}/cards/{cardID}", makeRemoveCardGroupMemberHandler(groupSvc))
+
+	return mux
+}
+
+func NewHTTPHandler(
+	cardSvc acme.CardService,
+	txSource acme.TransactionSource,
+	balanceSrc acme.BalanceChangeSource,
+	statementRepo export.MonthlyStatementRepository,
+	// appSvc turns the card creations into card applications when non-nil
+	appSvc acme.CardApplicationService,
+) http.Handler {
+	mux := chi.NewMux()
+
+	statementLoader := export.NewStatementLoader(cardSvc, txSource, balanceSrc)
+	monthlyStatementGenerator := export.NewMonthlyStatementGenerator(statementLoader, statementRepo)
+
+	mux.Method(http.MethodPost, "/", makeCreateCardHandler(cardSvc, appSvc))
 	mux.Method(http.MethodGet, "/", makeListCardsHandler(cardSvc))
 	mux.Method(http.MethodGet, "/{cardID}", makeGetCardHandler(cardSvc))
+	mux.Method(http.MethodPut, "/{cardID}/expiry", makeUpdateCardExpiryHandler(cardSvc))
 	mux.Method(http.MethodGet, "/{cardID}/transactions", makeListCardTransactionsHandler(cardSvc))
+	mux.Method(http.MethodGet, "/{cardID}/transactions/export", makeExportCardTransactionsHandler(txSource))
 	mux.Method(http.MethodGet, "/{cardID}/balance-history", makeListBalanceHistoryHandler(cardSvc))
+	mux.Method(http.MethodGet, "/{cardID}/statement.ofx", makeGetCardStatementHandler(statementLoader, ofxStatementWriter))
+	mux.Method(http.MethodGet, "/{cardID}/statement.qif", makeGetCardStatementHandler(statementLoader, qifStatementWriter))
+	mux.Method(http.MethodGet, "/{cardID}/statements/{month}", makeGetCardMonthlyStatementHandler(monthlyStatementGenerator))
 
 	return mux
 }
@@ -0,0 +1,65 @@
+package acmehttp
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/x/xhttp"
+)
+
+// makeListAllowanceRunsHandler lists the runs of the schedule in the path,
+// latest first, or of every schedule filtered by the cardId query param
+func makeListAllowanceRunsHandler(allowanceSvc acme.AllowanceService) http.Handler {
+	return xhttp.WrapXHTTP(xhttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		filter := acme.AllowanceRunFilter{CardID: r.URL.Query().Get("cardId")}
+		if scheduleID := chi.URLParam(r, "scheduleID"); scheduleID != "" {
+			var err error
+			filter.ScheduleID, err = strconv.ParseInt(scheduleID, 10, 64)
+			if err != nil {
+				return xhttp.NewError(http.StatusBadRequest, fmt.Errorf("parse schedule id: %w", err))
+			}
+		}
+
+		runs, err := allowanceSvc.ListAllowanceRuns(r.Context(), filter)
+		if err != nil {
+			return fmt.Errorf("list allowance runs: %w", err)
+		}
+
+		resp := listAllowanceRunsResponse{Runs: make([]allowanceRun, 0, len(runs))}
+		for _, run := range runs {
+			item := allowanceRun{
+				ID:             run.ID,
+				ScheduleID:     run.ScheduleID,
+				CardID:         run.CardID,
+				ScheduledAt:    run.ScheduledAt.UTC().Format(time.RFC3339),
+				Mode:           run.Mode,
+				Status:         run.Status,
+				AdjustmentType: run.AdjustmentType,
+				AdjustmentID:   run.AdjustmentID,
+				Attempts:       run.Attempts,
+				Error:          run.Error,
+				CreatedAt:      run.CreatedAt.UTC().Format(time.RFC3339),
+			}
+			if !run.Amount.IsZero() {
+				item.Amount = &run.Amount
+			}
+			if !run.AvailableCreditBefore.IsZero() || !run.AvailableCreditAfter.IsZero() {
+				item.AvailableCreditBefore = &run.AvailableCreditBefore
+				item.AvailableCreditAfter = &run.AvailableCreditAfter
+			}
+			resp.Runs = append(resp.Runs, item)
+		}
+
+		err = renderResponse(http.StatusOK, w, resp)
+		if err != nil {
+			return fmt.Errorf("render response: %w", err)
+		}
+
+		return nil
+	}))
+}
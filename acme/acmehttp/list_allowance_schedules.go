@@ -0,0 +1,32 @@
+package acmehttp
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/x/xhttp"
+)
+
+// makeListAllowanceSchedulesHandler lists the schedules of the card in the
+// cardId query, or every schedule without it
+func makeListAllowanceSchedulesHandler(allowanceSvc acme.AllowanceService) http.Handler {
+	return xhttp.WrapXHTTP(xhttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		schedules, err := allowanceSvc.ListAllowanceSchedules(r.Context(), r.URL.Query().Get("cardId"))
+		if err != nil {
+			return fmt.Errorf("list allowance schedules: %w", err)
+		}
+
+		resp := listAllowanceSchedulesResponse{Schedules: make([]allowanceSchedule, 0, len(schedules))}
+		for _, s := range schedules {
+			resp.Schedules = append(resp.Schedules, toAllowanceScheduleResponse(s))
+		}
+
+		err = renderResponse(http.StatusOK, w, resp)
+		if err != nil {
+			return fmt.Errorf("render response: %w", err)
+		}
+
+		return nil
+	}))
+}
@@ -13,7 +13,7 @@ func makeListBalanceHistoryHandler(cardSvc acme.CardService) http.Handler {
 	return xhttp.WrapXHTTP(xhttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
 		cardID := chi.URLParam(r, "cardID")
 
-		resp, err := cardSvc.ListCardBalanceHistory(r.Context(), cardID)
+		resp, err := cardSvc.ListCardBalanceHistory(r.Context(), cardID, acme.ListCardBalanceHistoryParams{})
 		if err != nil {
 			return fmt.Errorf("list card transactions: %w", err)
 		}
@@ -36,11 +36,10 @@ func makeListBalanceHistoryHandler(cardSvc acme.CardService) http.Handler {
 
 func toAcmeBalanceChangeResponse(bc acme.BalanceChange) balanceChange {
 	return balanceChange{
-		ID:       bc.ID,
-		Date:     bc.Date.Format("2006-01-02 15:04:05"),
-		Type:     bc.Type,
-		Status:   bc.Status,
-		Amount:   bc.Amount,
-		Currency: bc.Currency,
+		ID:     bc.ID,
+		Date:   bc.Date.Format("2006-01-02 15:04:05"),
+		Type:   bc.Type,
+		Status: bc.Status,
+		Amount: bc.Amount,
 	}
 }
@@ -0,0 +1,58 @@
+package acmehttp
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/x/xhttp"
+)
+
+// makeListBalanceRuleExecutionsHandler lists the triggered rules, latest
+// first, optionally filtered by the cardId and ruleId query params
+func makeListBalanceRuleExecutionsHandler(ruleSvc acme.BalanceRuleService) http.Handler {
+	return xhttp.WrapXHTTP(xhttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		query := r.URL.Query()
+		filter := acme.BalanceRuleExecutionFilter{CardID: query.Get("cardId")}
+		if ruleID := query.Get("ruleId"); ruleID != "" {
+			var err error
+			filter.RuleID, err = strconv.ParseInt(ruleID, 10, 64)
+			if err != nil {
+				return xhttp.NewError(http.StatusBadRequest, fmt.Errorf("parse rule id: %w", err))
+			}
+		}
+
+		executions, err := ruleSvc.ListBalanceRuleExecutions(r.Context(), filter)
+		if err != nil {
+			return fmt.Errorf("list balance rule executions: %w", err)
+		}
+
+		resp := listBalanceRuleExecutionsResponse{Executions: make([]balanceRuleExecution, 0, len(executions))}
+		for _, e := range executions {
+			execution := balanceRuleExecution{
+				ID:              e.ID,
+				RuleID:          e.RuleID,
+				CardID:          e.CardID,
+				Kind:            e.Kind,
+				Status:          e.Status,
+				AvailableCredit: e.AvailableCredit,
+				AdjustmentID:    e.AdjustmentID,
+				Reason:          e.Reason,
+				CreatedAt:       e.CreatedAt.UTC().Format(time.RFC3339),
+			}
+			if !e.Amount.IsZero() {
+				execution.Amount = &e.Amount
+			}
+			resp.Executions = append(resp.Executions, execution)
+		}
+
+		err = renderResponse(http.StatusOK, w, resp)
+		if err != nil {
+			return fmt.Errorf("render response: %w", err)
+		}
+
+		return nil
+	}))
+}
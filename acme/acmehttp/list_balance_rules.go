@@ -0,0 +1,32 @@
+package acmehttp
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/x/xhttp"
+)
+
+// makeListBalanceRulesHandler lists the rules of the card in the cardId
+// query, or every rule without it
+func makeListBalanceRulesHandler(ruleSvc acme.BalanceRuleService) http.Handler {
+	return xhttp.WrapXHTTP(xhttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		rules, err := ruleSvc.ListBalanceRules(r.Context(), r.URL.Query().Get("cardId"))
+		if err != nil {
+			return fmt.Errorf("list balance rules: %w", err)
+		}
+
+		resp := listBalanceRulesResponse{Rules: make([]balanceRule, 0, len(rules))}
+		for _, rule := range rules {
+			resp.Rules = append(resp.Rules, toBalanceRule(rule))
+		}
+
+		err = renderResponse(http.StatusOK, w, resp)
+		if err != nil {
+			return fmt.Errorf("render response: %w", err)
+		}
+
+		return nil
+	}))
+}
@@ -0,0 +1,47 @@
+package acmehttp
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/x/xhttp"
+)
+
+// makeListBurnerCardsHandler lists the burner cards, latest first, only the
+// cards still monitored are listed with the active=true query param
+func makeListBurnerCardsHandler(burnerSvc acme.BurnerCardService) http.Handler {
+	return xhttp.WrapXHTTP(xhttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		active := r.URL.Query().Get("active") == "true"
+		cards, err := burnerSvc.ListBurnerCards(r.Context(), active)
+		if err != nil {
+			return fmt.Errorf("list burner cards: %w", err)
+		}
+
+		resp := listBurnerCardsResponse{Cards: make([]burnerCard, 0, len(cards))}
+		for _, c := range cards {
+			card := burnerCard{
+				CardID:     c.CardID,
+				Mode:       c.Mode,
+				MerchantID: c.MerchantID,
+				EndReason:  c.EndReason,
+				CreatedAt:  c.CreatedAt.UTC().Format(time.RFC3339),
+			}
+			if !c.FrozenAt.IsZero() {
+				card.FrozenAt = c.FrozenAt.UTC().Format(time.RFC3339)
+			}
+			if !c.EndedAt.IsZero() {
+				card.EndedAt = c.EndedAt.UTC().Format(time.RFC3339)
+			}
+			resp.Cards = append(resp.Cards, card)
+		}
+
+		err = renderResponse(http.StatusOK, w, resp)
+		if err != nil {
+			return fmt.Errorf("render response: %w", err)
+		}
+
+		return nil
+	}))
+}
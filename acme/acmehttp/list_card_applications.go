@@ -0,0 +1,36 @@
+package acmehttp
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/x/xhttp"
+)
+
+// makeListCardApplicationsHandler lists the applications, latest first,
+// optionally filtered by the status and maker query params
+func makeListCardApplicationsHandler(appSvc acme.CardApplicationService) http.Handler {
+	return xhttp.WrapXHTTP(xhttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		query := r.URL.Query()
+		apps, err := appSvc.ListCardApplications(r.Context(), acme.CardApplicationFilter{
+			Status: query.Get("status"),
+			Maker:  query.Get("maker"),
+		})
+		if err != nil {
+			return fmt.Errorf("list card applications: %w", err)
+		}
+
+		resp := listCardApplicationsResponse{Applications: make([]cardApplication, 0, len(apps))}
+		for _, app := range apps {
+			resp.Applications = append(resp.Applications, toCardApplication(app))
+		}
+
+		err = renderResponse(http.StatusOK, w, resp)
+		if err != nil {
+			return fmt.Errorf("render response: %w", err)
+		}
+
+		return nil
+	}))
+}
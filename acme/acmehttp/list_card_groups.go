@@ -0,0 +1,30 @@
+package acmehttp
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/x/xhttp"
+)
+
+func makeListCardGroupsHandler(groupSvc acme.CardGroupService) http.Handler {
+	return xhttp.WrapXHTTP(xhttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		groups, err := groupSvc.ListCardGroups(r.Context())
+		if err != nil {
+			return fmt.Errorf("list card groups: %w", err)
+		}
+
+		resp := listCardGroupsResponse{Groups: make([]cardGroup, 0, len(groups))}
+		for _, group := range groups {
+			resp.Groups = append(resp.Groups, toCardGroup(group))
+		}
+
+		err = renderResponse(http.StatusOK, w, resp)
+		if err != nil {
+			return fmt.Errorf("render response: %w", err)
+		}
+
+		return nil
+	}))
+}
@@ -3,6 +3,7 @@ package acmehttp
 import (
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/stevenferrer/acme-cards-api/acme"
@@ -13,7 +14,7 @@ func makeListCardTransactionsHandler(cardSvc acme.CardService) http.Handler {
 	return xhttp.WrapXHTTP(xhttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
 		cardID := chi.URLParam(r, "cardID")
 
-		resp, err := cardSvc.ListCardTransactions(r.Context(), cardID)
+		resp, err := cardSvc.ListCardTransactions(r.Context(), cardID, acme.ListCardTransactionsParams{})
 		if err != nil {
 			return fmt.Errorf("list card transactions: %w", err)
 		}
@@ -42,12 +43,19 @@ func toAcmeTransactionResponse(t acme.Transaction) transaction {
 		Status:   t.Status,
 		Channel:  t.Channel,
 		Amount:   t.Amount,
-		Currency: t.Currency,
+
+		TransactionAmount: t.TransactionAmount,
+		ConversionRate:    t.ConversionRate,
 
 		Merchant: merchantDetails{
-			Name:    t.Merchant.Name,
-			City:    t.Merchant.City,
-			Country: t.Merchant.Country,
+			ID:          t.Merchant.ID,
+			Name:        t.Merchant.Name,
+			City:        t.Merchant.City,
+			PostCode:    t.Merchant.PostCode,
+			State:       t.Merchant.State,
+			Country:     t.Merchant.Country,
+			MCCCode:     t.Merchant.MCCCode,
+			MCCCategory: t.Merchant.MCCCategory,
 		},
 
 		Fees: feeDetails{
@@ -55,6 +63,6 @@ func toAcmeTransactionResponse(t acme.Transaction) transaction {
 			FXFees:  t.Fees.FXFees,
 		},
 
-		Date: t.CreatedAt.Format("2006-01-02"),
+		Date: t.CreatedAt.Format(time.RFC3339),
 	}
 }
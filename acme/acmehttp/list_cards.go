@@ -3,6 +3,8 @@ package acmehttp
 import (
 	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/stevenferrer/acme-cards-api/acme"
 	"github.com/stevenferrer/acme-cards-api/x/xhttp"
@@ -10,7 +12,12 @@ import (
 
 func makeListCardsHandler(cardSvc acme.CardService) http.Handler {
 	return xhttp.WrapXHTTP(xhttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
-		resp, err := cardSvc.ListCards(r.Context(), acme.ListCardsParams{})
+		params, err := toListCardsParams(r)
+		if err != nil {
+			return xhttp.NewError(http.StatusBadRequest, err)
+		}
+
+		resp, err := cardSvc.ListCards(r.Context(), params)
 		if err != nil {
 			return fmt.Errorf("list cards: %w", err)
 		}
@@ -30,3 +37,25 @@ func makeListCardsHandler(cardSvc acme.CardService) http.Handler {
 		return nil
 	}))
 }
+
+func toListCardsParams(r *http.Request) (acme.ListCardsParams, error) {
+	var params acme.ListCardsParams
+
+	if fresh := r.URL.Query().Get("fresh"); fresh != "" {
+		var err error
+		params.Fresh, err = strconv.ParseBool(fresh)
+		if err != nil {
+			return params, fmt.Errorf("parse fresh: %w", err)
+		}
+	}
+
+	if before := r.URL.Query().Get("validUntilBefore"); before != "" {
+		var err error
+		params.ValidUntilBefore, err = time.Parse(time.RFC3339, before)
+		if err != nil {
+			return params, fmt.Errorf("parse valid until before: %w", err)
+		}
+	}
+
+	return params, nil
+}
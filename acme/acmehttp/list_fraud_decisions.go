@@ -0,0 +1,48 @@
+package acmehttp
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/x/xhttp"
+)
+
+// makeListFraudDecisionsHandler lists the decision log, filtered by the
+// cardId, transactionId and action queries
+func makeListFraudDecisionsHandler(fraudSvc acme.FraudService) http.Handler {
+	return xhttp.WrapXHTTP(xhttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		q := r.URL.Query()
+		decisions, err := fraudSvc.ListFraudDecisions(r.Context(), acme.FraudDecisionFilter{
+			CardID:        q.Get("cardId"),
+			TransactionID: q.Get("transactionId"),
+			Action:        q.Get("action"),
+		})
+		if err != nil {
+			return fmt.Errorf("list fraud decisions: %w", err)
+		}
+
+		resp := listFraudDecisionsResponse{Decisions: make([]fraudDecision, 0, len(decisions))}
+		for _, d := range decisions {
+			resp.Decisions = append(resp.Decisions, fraudDecision{
+				ID:            d.ID,
+				RuleID:        d.RuleID,
+				RuleName:      d.RuleName,
+				TransactionID: d.TransactionID,
+				CardID:        d.CardID,
+				Action:        d.Action,
+				DryRun:        d.DryRun,
+				Reason:        d.Reason,
+				CreatedAt:     d.CreatedAt.UTC().Format(time.RFC3339),
+			})
+		}
+
+		err = renderResponse(http.StatusOK, w, resp)
+		if err != nil {
+			return fmt.Errorf("render response: %w", err)
+		}
+
+		return nil
+	}))
+}
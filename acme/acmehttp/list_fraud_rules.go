@@ -0,0 +1,30 @@
+package acmehttp
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/x/xhttp"
+)
+
+func makeListFraudRulesHandler(fraudSvc acme.FraudService) http.Handler {
+	return xhttp.WrapXHTTP(xhttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		rules, err := fraudSvc.ListFraudRules(r.Context())
+		if err != nil {
+			return fmt.Errorf("list fraud rules: %w", err)
+		}
+
+		resp := listFraudRulesResponse{Rules: make([]fraudRule, 0, len(rules))}
+		for _, rule := range rules {
+			resp.Rules = append(resp.Rules, toFraudRuleResponse(rule))
+		}
+
+		err = renderResponse(http.StatusOK, w, resp)
+		if err != nil {
+			return fmt.Errorf("render response: %w", err)
+		}
+
+		return nil
+	}))
+}
@@ -0,0 +1,30 @@
+package acmehttp
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/x/xhttp"
+)
+
+func makeListJournalAccountsHandler(journalSvc acme.JournalService) http.Handler {
+	return xhttp.WrapXHTTP(xhttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		accounts, err := journalSvc.ListJournalAccounts(r.Context())
+		if err != nil {
+			return fmt.Errorf("list journal accounts: %w", err)
+		}
+
+		resp := listJournalAccountsResponse{Accounts: make([]journalAccount, 0, len(accounts))}
+		for _, a := range accounts {
+			resp.Accounts = append(resp.Accounts, journalAccount{Role: a.Role, Account: a.Account})
+		}
+
+		err = renderResponse(http.StatusOK, w, resp)
+		if err != nil {
+			return fmt.Errorf("render response: %w", err)
+		}
+
+		return nil
+	}))
+}
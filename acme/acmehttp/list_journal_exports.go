@@ -0,0 +1,30 @@
+package acmehttp
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/x/xhttp"
+)
+
+func makeListJournalExportsHandler(journalSvc acme.JournalService) http.Handler {
+	return xhttp.WrapXHTTP(xhttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		exports, err := journalSvc.ListJournalExports(r.Context())
+		if err != nil {
+			return fmt.Errorf("list journal exports: %w", err)
+		}
+
+		resp := listJournalExportsResponse{Exports: make([]journalExport, 0, len(exports))}
+		for _, e := range exports {
+			resp.Exports = append(resp.Exports, toJournalExport(e))
+		}
+
+		err = renderResponse(http.StatusOK, w, resp)
+		if err != nil {
+			return fmt.Errorf("render response: %w", err)
+		}
+
+		return nil
+	}))
+}
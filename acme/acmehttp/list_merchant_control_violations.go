@@ -0,0 +1,42 @@
+package acmehttp
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/x/xhttp"
+)
+
+func makeListMerchantControlViolationsHandler(controlSvc acme.MerchantControlService) http.Handler {
+	return xhttp.WrapXHTTP(xhttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		violations, err := controlSvc.ListMerchantControlViolations(r.Context())
+		if err != nil {
+			return fmt.Errorf("list merchant control violations: %w", err)
+		}
+
+		resp := listMerchantControlViolationsResponse{Violations: make([]merchantControlViolation, 0, len(violations))}
+		for _, v := range violations {
+			resp.Violations = append(resp.Violations, merchantControlViolation{
+				TransactionID: v.TransactionID,
+				CardID:        v.CardID,
+				ControlID:     v.ControlID,
+				Reason:        v.Reason,
+				MCCCode:       v.MCCCode,
+				MerchantID:    v.MerchantID,
+				MerchantName:  v.MerchantName,
+				Amount:        v.Amount,
+				CardFrozen:    v.CardFrozen,
+				CreatedAt:     v.CreatedAt.UTC().Format(time.RFC3339),
+			})
+		}
+
+		err = renderResponse(http.StatusOK, w, resp)
+		if err != nil {
+			return fmt.Errorf("render response: %w", err)
+		}
+
+		return nil
+	}))
+}
@@ -0,0 +1,32 @@
+package acmehttp
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/x/xhttp"
+)
+
+// makeListMerchantControlsHandler lists the controls applied to the card in
+// the cardId query, or every control without it
+func makeListMerchantControlsHandler(controlSvc acme.MerchantControlService) http.Handler {
+	return xhttp.WrapXHTTP(xhttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		controls, err := controlSvc.ListMerchantControls(r.Context(), r.URL.Query().Get("cardId"))
+		if err != nil {
+			return fmt.Errorf("list merchant controls: %w", err)
+		}
+
+		resp := listMerchantControlsResponse{Controls: make([]merchantControl, 0, len(controls))}
+		for _, c := range controls {
+			resp.Controls = append(resp.Controls, toMerchantControl(c))
+		}
+
+		err = renderResponse(http.StatusOK, w, resp)
+		if err != nil {
+			return fmt.Errorf("render response: %w", err)
+		}
+
+		return nil
+	}))
+}
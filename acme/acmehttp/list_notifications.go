@@ -0,0 +1,64 @@
+package acmehttp
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/x/xhttp"
+)
+
+// makeListNotificationsHandler lists the notifications, latest first,
+// optionally filtered by the cardId and status query params
+func makeListNotificationsHandler(notificationSvc acme.NotificationService) http.Handler {
+	return xhttp.WrapXHTTP(xhttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		query := r.URL.Query()
+		notifications, err := notificationSvc.ListNotifications(r.Context(), acme.NotificationFilter{
+			CardID: query.Get("cardId"),
+			Status: query.Get("status"),
+		})
+		if err != nil {
+			return fmt.Errorf("list notifications: %w", err)
+		}
+
+		resp := listNotificationsResponse{Notifications: make([]notification, 0, len(notifications))}
+		for _, n := range notifications {
+			resp.Notifications = append(resp.Notifications, toNotification(n))
+		}
+
+		err = renderResponse(http.StatusOK, w, resp)
+		if err != nil {
+			return fmt.Errorf("render response: %w", err)
+		}
+
+		return nil
+	}))
+}
+
+func toNotification(n acme.Notification) notification {
+	resp := notification{
+		ID:        n.ID,
+		EventID:   n.EventID,
+		Type:      n.Type,
+		CardID:    n.CardID,
+		Email:     n.Email,
+		Subject:   n.Subject,
+		Status:    n.Status,
+		Attempts:  n.Attempts,
+		Error:     n.Error,
+		CreatedAt: n.CreatedAt.UTC().Format(time.RFC3339),
+	}
+	if n.Amount.Currency() != "" {
+		resp.Amount = &n.Amount
+		resp.AvailableCredit = &n.AvailableCredit
+	}
+	if n.Status == acme.NotificationPending {
+		resp.NextAttemptAt = n.NextAttemptAt.UTC().Format(time.RFC3339)
+	}
+	if !n.SentAt.IsZero() {
+		resp.SentAt = n.SentAt.UTC().Format(time.RFC3339)
+	}
+
+	return resp
+}
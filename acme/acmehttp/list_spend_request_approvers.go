@@ -0,0 +1,30 @@
+package acmehttp
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/x/xhttp"
+)
+
+func makeListSpendRequestApproversHandler(reqSvc acme.SpendRequestService) http.Handler {
+	return xhttp.WrapXHTTP(xhttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		approvers, err := reqSvc.ListSpendRequestApprovers(r.Context())
+		if err != nil {
+			return fmt.Errorf("list spend request approvers: %w", err)
+		}
+
+		resp := listSpendRequestApproversResponse{Approvers: make([]spendRequestApprover, 0, len(approvers))}
+		for _, approver := range approvers {
+			resp.Approvers = append(resp.Approvers, toSpendRequestApprover(approver))
+		}
+
+		err = renderResponse(http.StatusOK, w, resp)
+		if err != nil {
+			return fmt.Errorf("render response: %w", err)
+		}
+
+		return nil
+	}))
+}
@@ -0,0 +1,37 @@
+package acmehttp
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/x/xhttp"
+)
+
+// makeListSpendRequestsHandler lists the requests, latest first, optionally
+// filtered by the cardId, requester and status query params
+func makeListSpendRequestsHandler(reqSvc acme.SpendRequestService) http.Handler {
+	return xhttp.WrapXHTTP(xhttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		query := r.URL.Query()
+		reqs, err := reqSvc.ListSpendRequests(r.Context(), acme.SpendRequestFilter{
+			CardID:    query.Get("cardId"),
+			Requester: query.Get("requester"),
+			Status:    query.Get("status"),
+		})
+		if err != nil {
+			return fmt.Errorf("list spend requests: %w", err)
+		}
+
+		resp := listSpendRequestsResponse{Requests: make([]spendRequest, 0, len(reqs))}
+		for _, req := range reqs {
+			resp.Requests = append(resp.Requests, toSpendRequest(req))
+		}
+
+		err = renderResponse(http.StatusOK, w, resp)
+		if err != nil {
+			return fmt.Errorf("render response: %w", err)
+		}
+
+		return nil
+	}))
+}
@@ -0,0 +1,47 @@
+package acmehttp
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/x/xhttp"
+)
+
+// makeListTransactionFlagsHandler lists the flagged transactions, filtered
+// by the cardId query, the cleared flags are omitted with open=true
+func makeListTransactionFlagsHandler(fraudSvc acme.FraudService) http.Handler {
+	return xhttp.WrapXHTTP(xhttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		q := r.URL.Query()
+		flags, err := fraudSvc.ListTransactionFlags(r.Context(), acme.TransactionFlagFilter{
+			CardID: q.Get("cardId"),
+			Open:   q.Get("open") == "true",
+		})
+		if err != nil {
+			return fmt.Errorf("list transaction flags: %w", err)
+		}
+
+		resp := listTransactionFlagsResponse{Flags: make([]transactionFlag, 0, len(flags))}
+		for _, f := range flags {
+			flag := transactionFlag{
+				TransactionID: f.TransactionID,
+				CardID:        f.CardID,
+				RuleID:        f.RuleID,
+				Reason:        f.Reason,
+				CreatedAt:     f.CreatedAt.UTC().Format(time.RFC3339),
+			}
+			if !f.ClearedAt.IsZero() {
+				flag.ClearedAt = f.ClearedAt.UTC().Format(time.RFC3339)
+			}
+			resp.Flags = append(resp.Flags, flag)
+		}
+
+		err = renderResponse(http.StatusOK, w, resp)
+		if err != nil {
+			return fmt.Errorf("render response: %w", err)
+		}
+
+		return nil
+	}))
+}
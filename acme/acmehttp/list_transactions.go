@@ -10,7 +10,7 @@ import (
 
 func makeListTransactionsHandler(cardSvc acme.CardService) http.Handler {
 	return xhttp.WrapXHTTP(xhttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
-		resp, err := cardSvc.ListTransactions(r.Context())
+		resp, err := cardSvc.ListTransactions(r.Context(), acme.ListTransactionsParams{})
 		if err != nil {
 			return fmt.Errorf("list transactions: %w", err)
 		}
@@ -0,0 +1,81 @@
+package acmehttp
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/x/xhttp"
+)
+
+// makeListWebhookDeliveriesHandler lists the deliveries of the subscription,
+// latest first
+func makeListWebhookDeliveriesHandler(webhookSvc acme.WebhookService) http.Handler {
+	return xhttp.WrapXHTTP(xhttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		subscriptionID, err := strconv.ParseInt(chi.URLParam(r, "subscriptionID"), 10, 64)
+		if err != nil {
+			return xhttp.NewError(http.StatusBadRequest, fmt.Errorf("parse subscription id: %w", err))
+		}
+
+		deliveries, err := webhookSvc.ListWebhookDeliveries(r.Context(), subscriptionID)
+		if err != nil {
+			if errors.Is(err, acme.ErrWebhookSubscriptionNotFound) {
+				return xhttp.NewError(http.StatusNotFound, err)
+			}
+			return fmt.Errorf("list webhook deliveries: %w", err)
+		}
+
+		resp := listWebhookDeliveriesResponse{Deliveries: make([]webhookDelivery, 0, len(deliveries))}
+		for _, d := range deliveries {
+			resp.Deliveries = append(resp.Deliveries, toWebhookDelivery(d))
+		}
+
+		err = renderResponse(http.StatusOK, w, resp)
+		if err != nil {
+			return fmt.Errorf("render response: %w", err)
+		}
+
+		return nil
+	}))
+}
+
+func toWebhookDelivery(d acme.WebhookDelivery) webhookDelivery {
+	resp := webhookDelivery{
+		ID:             d.ID,
+		SubscriptionID: d.SubscriptionID,
+		EventID:        d.EventID,
+		EventType:      d.EventType,
+		Status:         d.Status,
+		Attempts:       d.Attempts,
+		ResponseCode:   d.ResponseCode,
+		Error:          d.Error,
+		CreatedAt:      d.CreatedAt.UTC().Format(time.RFC3339),
+		UpdatedAt:      d.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+	if d.Status == acme.WebhookDeliveryPending {
+		resp.NextAttemptAt = d.NextAttemptAt.UTC().Format(time.RFC3339)
+	}
+
+	return resp
+}
+
+// parseWebhookDeliveryIDs parses the subscription and delivery IDs of the
+// delivery routes
+func parseWebhookDeliveryIDs(r *http.Request) (subscriptionID, deliveryID int64, err error) {
+	subscriptionID, err = strconv.ParseInt(chi.URLParam(r, "subscriptionID"), 10, 64)
+	if err != nil {
+		return 0, 0, xhttp.NewError(http.StatusBadRequest, fmt.Errorf("parse subscription id: %w", err))
+	}
+
+	deliveryID, err = strconv.ParseInt(chi.URLParam(r, "deliveryID"), 10, 64)
+	if err != nil {
+		return 0, 0, xhttp.NewError(http.StatusBadRequest, fmt.Errorf("parse delivery id: %w", err))
+	}
+
+	return subscriptionID, deliveryID, nil
+}
@@ -0,0 +1,30 @@
+package acmehttp
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/x/xhttp"
+)
+
+func makeListWebhookSubscriptionsHandler(webhookSvc acme.WebhookService) http.Handler {
+	return xhttp.WrapXHTTP(xhttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		subs, err := webhookSvc.ListWebhookSubscriptions(r.Context())
+		if err != nil {
+			return fmt.Errorf("list webhook subscriptions: %w", err)
+		}
+
+		resp := listWebhookSubscriptionsResponse{Subscriptions: make([]webhookSubscription, 0, len(subs))}
+		for _, sub := range subs {
+			resp.Subscriptions = append(resp.Subscriptions, toWebhookSubscription(sub))
+		}
+
+		err = renderResponse(http.StatusOK, w, resp)
+		if err != nil {
+			return fmt.Errorf("render response: %w", err)
+		}
+
+		return nil
+	}))
+}
@@ -0,0 +1,80 @@
+package acmehttp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/x/xhttp"
+)
+
+// reapSignatureHeader is the signature of the webhook in the format of
+// acme.SignWebhookPayload, t=<unix time>,v1=<hex encoded HMAC-SHA256 of the
+// timestamp, a dot and the body>
+const reapSignatureHeader = "x-reap-signature"
+
+// reapSignatureTolerance is how old a webhook signature may be, older
+// webhooks are rejected as replays
+const reapSignatureTolerance = 5 * time.Minute
+
+// maxReapWebhookBytes limits the webhook body size
+const maxReapWebhookBytes = 1 << 20
+
+// NewReapWebhookHTTPHandler handles the webhooks sent by Reap, every
+// request is rejected when secret is empty since the body signature cannot
+// be verified.
+func NewReapWebhookHTTPHandler(snapshotSvc acme.CardSnapshotService, secret string) http.Handler {
+	mux := chi.NewMux()
+
+	mux.Method(http.MethodPost, "/", makeReapWebhookHandler(snapshotSvc, secret))
+
+	return mux
+}
+
+func makeReapWebhookHandler(snapshotSvc acme.CardSnapshotService, secret string) http.Handler {
+	return xhttp.WrapXHTTP(xhttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxReapWebhookBytes))
+		if err != nil {
+			return fmt.Errorf("read body: %w", err)
+		}
+
+		if !validReapSignature(secret, body, r.Header.Get(reapSignatureHeader), time.Now()) {
+			return xhttp.NewError(http.StatusUnauthorized, errors.New("invalid reap webhook signature"))
+		}
+
+		var event reapWebhookEvent
+		err = json.Unmarshal(body, &event)
+		if err != nil {
+			return xhttp.NewError(http.StatusBadRequest, fmt.Errorf("decode request: %w", err))
+		}
+
+		// card and transaction events both affect the card snapshot
+		if externalCardID := event.cardID(); externalCardID != "" {
+			err = snapshotSvc.RefreshCardSnapshot(r.Context(), externalCardID)
+			if err != nil {
+				return fmt.Errorf("refresh card snapshot: %w", err)
+			}
+		}
+
+		err = renderResponse(http.StatusNoContent, w, nil)
+		if err != nil {
+			return fmt.Errorf("render response: %w", err)
+		}
+
+		return nil
+	}))
+}
+
+func validReapSignature(secret string, body []byte, signature string, now time.Time) bool {
+	if secret == "" {
+		return false
+	}
+
+	return acme.VerifyWebhookSignature(secret, signature, body, now, reapSignatureTolerance) == nil
+}
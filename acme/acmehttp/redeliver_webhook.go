@@ -0,0 +1,36 @@
+package acmehttp
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/x/xhttp"
+)
+
+// makeRedeliverWebhookHandler attempts the delivery again and returns the
+// outcome, a failed attempt is retried as usual
+func makeRedeliverWebhookHandler(webhookSvc acme.WebhookService) http.Handler {
+	return xhttp.WrapXHTTP(xhttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		subscriptionID, deliveryID, err := parseWebhookDeliveryIDs(r)
+		if err != nil {
+			return err
+		}
+
+		d, err := webhookSvc.RedeliverWebhook(r.Context(), subscriptionID, deliveryID)
+		if err != nil {
+			if errors.Is(err, acme.ErrWebhookDeliveryNotFound) {
+				return xhttp.NewError(http.StatusNotFound, err)
+			}
+			return fmt.Errorf("redeliver webhook: %w", err)
+		}
+
+		err = renderResponse(http.StatusOK, w, toWebhookDelivery(*d))
+		if err != nil {
+			return fmt.Errorf("render response: %w", err)
+		}
+
+		return nil
+	}))
+}
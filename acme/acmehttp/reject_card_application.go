@@ -0,0 +1,52 @@
+package acmehttp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/x/xhttp"
+)
+
+// makeRejectCardApplicationHandler rejects the application with a reason
+func makeRejectCardApplicationHandler(appSvc acme.CardApplicationService) http.Handler {
+	return xhttp.WrapXHTTP(xhttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		applicationID, err := strconv.ParseInt(chi.URLParam(r, "applicationID"), 10, 64)
+		if err != nil {
+			return xhttp.NewError(http.StatusBadRequest, fmt.Errorf("parse application id: %w", err))
+		}
+
+		var req rejectCardApplicationRequest
+		err = json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return xhttp.NewError(http.StatusBadRequest, fmt.Errorf("decode request: %w", err))
+		}
+
+		app, err := appSvc.RejectCardApplication(r.Context(), applicationID, req.Checker, req.Reason)
+		if err != nil {
+			switch {
+			case errors.Is(err, acme.ErrInvalidCardApplication):
+				return xhttp.NewError(http.StatusBadRequest, err)
+			case errors.Is(err, acme.ErrCardApplicationNotAllowed):
+				return xhttp.NewError(http.StatusForbidden, err)
+			case errors.Is(err, acme.ErrCardApplicationNotFound):
+				return xhttp.NewError(http.StatusNotFound, err)
+			case errors.Is(err, acme.ErrCardApplicationStatus):
+				return xhttp.NewError(http.StatusConflict, err)
+			}
+			return fmt.Errorf("reject card application: %w", err)
+		}
+
+		err = renderResponse(http.StatusOK, w, toCardApplication(*app))
+		if err != nil {
+			return fmt.Errorf("render response: %w", err)
+		}
+
+		return nil
+	}))
+}
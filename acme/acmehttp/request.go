@@ -1,5 +1,7 @@
 package acmehttp
 
+import "github.com/stevenferrer/acme-cards-api/acme"
+
 type createCardRequest struct {
 	FirstName  string         `json:"firstName"`
 	LastName   string         `json:"lastName"`
@@ -7,6 +9,15 @@ type createCardRequest struct {
 	Address    addressInfo    `json:"address"`
 	IDDocument idDocument     `json:"idDocument"`
 	OTP        contactDetails `json:"otp"`
+	// Mode is standard, single_use or merchant_locked
+	Mode       string `json:"mode"`
+	MerchantID string `json:"merchantId"`
+	// ValidUntil is an RFC3339 time, see updateCardExpiryRequest
+	ValidUntil   string `json:"validUntil"`
+	ExpiryAction string `json:"expiryAction"`
+	// Maker is the user submitting the card application, it is only
+	// required when the card issuance needs an approval
+	Maker string `json:"maker"`
 }
 
 type addressInfo struct {
@@ -20,3 +31,129 @@ type idDocument struct {
 	IDType   string `json:"idType"`
 	IDNumber string `json:"idNumber"`
 }
+
+type reapWebhookEvent struct {
+	EventType string `json:"eventType"`
+	Data      struct {
+		CardID string `json:"cardId"`
+		// transaction events use snake case
+		CardIDSnake string `json:"card_id"`
+	} `json:"data"`
+}
+
+func (e reapWebhookEvent) cardID() string {
+	if e.Data.CardID != "" {
+		return e.Data.CardID
+	}
+
+	return e.Data.CardIDSnake
+}
+
+type saveJournalAccountRequest struct {
+	Role    string `json:"role"`
+	Account string `json:"account"`
+}
+
+type createJournalExportRequest struct {
+	// From and Until are dates, Until is exclusive
+	From  string `json:"from"`
+	Until string `json:"until"`
+}
+
+type createMerchantControlRequest struct {
+	CardID  string `json:"cardId"`
+	GroupID int64  `json:"groupId"`
+	Kind    string `json:"kind"`
+	Target  string `json:"target"`
+	Value   string `json:"value"`
+}
+
+type fraudRuleRequest struct {
+	Name   string          `json:"name"`
+	Type   string          `json:"type"`
+	Params fraudRuleParams `json:"params"`
+	Action string          `json:"action"`
+	DryRun bool            `json:"dryRun"`
+	// Enabled defaults to true
+	Enabled *bool `json:"enabled"`
+}
+
+type createWebhookSubscriptionRequest struct {
+	URL string `json:"url"`
+	// Secret is generated when empty
+	Secret     string   `json:"secret"`
+	EventTypes []string `json:"eventTypes"`
+}
+
+type saveNotificationPreferenceRequest struct {
+	// Locale defaults to en
+	Locale      string   `json:"locale"`
+	OptOutAll   bool     `json:"optOutAll"`
+	OptOutTypes []string `json:"optOutTypes"`
+}
+
+type createBalanceRuleRequest struct {
+	CardID    string     `json:"cardId"`
+	Kind      string     `json:"kind"`
+	Threshold acme.Money `json:"threshold"`
+	// TopUpTo and MonthlyLimit are required by the top-up rules
+	TopUpTo      acme.Money `json:"topUpTo"`
+	MonthlyLimit acme.Money `json:"monthlyLimit"`
+}
+
+type createAllowanceScheduleRequest struct {
+	CardID  string     `json:"cardId"`
+	Amount  acme.Money `json:"amount"`
+	Cadence string     `json:"cadence"`
+	Mode    string     `json:"mode"`
+	// StartDate and EndDate are YYYY-MM-DD dates, StartDate defaults to
+	// today and EndDate to no end
+	StartDate string `json:"startDate"`
+	EndDate   string `json:"endDate"`
+}
+
+type updateCardExpiryRequest struct {
+	// ValidUntil is an RFC3339 time, empty clears the expiry
+	ValidUntil string `json:"validUntil"`
+	// ExpiryAction is FROZEN or TERMINATED, it defaults to FROZEN
+	ExpiryAction string `json:"expiryAction"`
+}
+
+type submitSpendRequestRequest struct {
+	CardID        string     `json:"cardId"`
+	Requester     string     `json:"requester"`
+	Amount        acme.Money `json:"amount"`
+	Justification string     `json:"justification"`
+}
+
+type decideSpendRequestRequest struct {
+	Approver string `json:"approver"`
+	Comment  string `json:"comment"`
+}
+
+type commentSpendRequestRequest struct {
+	Author  string `json:"author"`
+	Comment string `json:"comment"`
+}
+
+type saveSpendRequestApproverRequest struct {
+	// Limit is the largest amount the approver may approve, no limit when
+	// omitted
+	Limit acme.Money `json:"limit"`
+}
+
+type approveCardApplicationRequest struct {
+	Checker string `json:"checker"`
+}
+
+type rejectCardApplicationRequest struct {
+	Checker string `json:"checker"`
+	Reason  string `json:"reason"`
+}
+
+type saveCardGroupRequest struct {
+	Name   string     `json:"name"`
+	Budget acme.Money `json:"budget"`
+	// Period is week, month, quarter or year
+	Period string `json:"period"`
+}
@@ -1,11 +1,30 @@
 package acmehttp
 
+import (
+	"encoding/json"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+)
+
 type card struct {
 	ID              string         `json:"id"`
 	Name            string         `json:"name"`
 	Last4           string         `json:"last4"`
-	AvailableCredit string         `json:"availableCredit"`
+	Status          string         `json:"status"`
+	AvailableCredit acme.Money     `json:"availableCredit"`
+	SpendUsage      spendUsage     `json:"spendUsage"`
 	ContactInfo     contactDetails `json:"contactInfo"`
+	// ValidUntil and ExpiryAction are omitted on the cards without expiry
+	ValidUntil   string `json:"validUntil,omitempty"`
+	ExpiryAction string `json:"expiryAction,omitempty"`
+}
+
+type spendUsage struct {
+	Daily   acme.Money `json:"daily"`
+	Weekly  acme.Money `json:"weekly"`
+	Monthly acme.Money `json:"monthly"`
+	Yearly  acme.Money `json:"yearly"`
+	AllTime acme.Money `json:"allTime"`
 }
 
 type contactDetails struct {
@@ -23,34 +42,42 @@ type createCardResponse struct {
 }
 
 type accountBalance struct {
-	Balance   string `json:"balance"`
-	Available string `json:"available"`
+	Balance   acme.Money `json:"balance"`
+	Available acme.Money `json:"available"`
 }
 
 type transaction struct {
-	ID       string `json:"id"`
-	CardID   string `json:"cardId"`
-	Category string `json:"category"`
-	Status   string `json:"status"`
-	Channel  string `json:"channel"`
-	Amount   string `json:"amount"`
-	Currency string `json:"currency"`
+	ID       string     `json:"id"`
+	CardID   string     `json:"cardId"`
+	Category string     `json:"category"`
+	Status   string     `json:"status"`
+	Channel  string     `json:"channel"`
+	Amount   acme.Money `json:"amount"`
+
+	TransactionAmount acme.Money `json:"transactionAmount"`
+	ConversionRate    string     `json:"conversionRate"`
 
 	Fees     feeDetails      `json:"fees"`
 	Merchant merchantDetails `json:"merchant"`
 
+	// Date is an RFC 3339 timestamp
 	Date string `json:"date"`
 }
 
 type feeDetails struct {
-	ATMFees string `json:"atmFees"`
-	FXFees  string `json:"fxFees"`
+	ATMFees acme.Money `json:"atmFees"`
+	FXFees  acme.Money `json:"fxFees"`
 }
 
 type merchantDetails struct {
-	Name    string `json:"name"`
-	City    string `json:"city"`
-	Country string `json:"country"`
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	City        string `json:"city"`
+	PostCode    string `json:"postCode"`
+	State       string `json:"state"`
+	Country     string `json:"country"`
+	MCCCode     string `json:"mccCode"`
+	MCCCategory string `json:"mccCategory"`
 }
 
 type listTransactionsResponse struct {
@@ -58,14 +85,441 @@ type listTransactionsResponse struct {
 }
 
 type balanceChange struct {
-	ID       string `json:"id"`
-	Date     string `json:"date"`
-	Type     string `json:"type"`
-	Status   string `json:"status"`
-	Amount   string `json:"amount"`
-	Currency string `json:"currency"`
+	ID     string     `json:"id"`
+	Date   string     `json:"date"`
+	Type   string     `json:"type"`
+	Status string     `json:"status"`
+	Amount acme.Money `json:"amount"`
 }
 
 type listBalanceChangesResponse struct {
 	BalanceChanges []balanceChange `json:"balanceChanges"`
 }
+
+type spendBucket struct {
+	PeriodStart string     `json:"periodStart"`
+	Key         string     `json:"key"`
+	Label       string     `json:"label"`
+	Count       int64      `json:"count"`
+	Total       acme.Money `json:"total"`
+	Average     acme.Money `json:"average"`
+}
+
+type spendAnalyticsResponse struct {
+	GroupBy string        `json:"groupBy"`
+	Period  string        `json:"period"`
+	From    string        `json:"from"`
+	To      string        `json:"to,omitempty"`
+	Buckets []spendBucket `json:"buckets"`
+}
+
+type journalAccount struct {
+	Role    string `json:"role"`
+	Account string `json:"account"`
+}
+
+type listJournalAccountsResponse struct {
+	Accounts []journalAccount `json:"accounts"`
+}
+
+type journalExport struct {
+	ID        int64  `json:"id"`
+	From      string `json:"from"`
+	Until     string `json:"until"`
+	CreatedAt string `json:"createdAt"`
+	LineCount int    `json:"lineCount,omitempty"`
+}
+
+type listJournalExportsResponse struct {
+	Exports []journalExport `json:"exports"`
+}
+
+type statementEntry struct {
+	ID       string     `json:"id"`
+	Type     string     `json:"type"`
+	Date     string     `json:"date"`
+	Amount   acme.Money `json:"amount"`
+	Payee    string     `json:"payee"`
+	Category string     `json:"category"`
+	Memo     string     `json:"memo"`
+}
+
+type monthlyStatementResponse struct {
+	CardID         string           `json:"cardId"`
+	CardName       string           `json:"cardName"`
+	Last4          string           `json:"last4"`
+	Month          string           `json:"month"`
+	OpeningBalance acme.Money       `json:"openingBalance"`
+	ClosingBalance acme.Money       `json:"closingBalance"`
+	TopUps         acme.Money       `json:"topUps"`
+	Withdrawals    acme.Money       `json:"withdrawals"`
+	Purchases      acme.Money       `json:"purchases"`
+	Refunds        acme.Money       `json:"refunds"`
+	Fees           acme.Money       `json:"fees"`
+	Entries        []statementEntry `json:"entries"`
+	GeneratedAt    string           `json:"generatedAt"`
+}
+
+type merchantControl struct {
+	ID        int64  `json:"id"`
+	CardID    string `json:"cardId,omitempty"`
+	GroupID   int64  `json:"groupId,omitempty"`
+	Kind      string `json:"kind"`
+	Target    string `json:"target"`
+	Value     string `json:"value"`
+	CreatedAt string `json:"createdAt"`
+}
+
+type listMerchantControlsResponse struct {
+	Controls []merchantControl `json:"controls"`
+}
+
+type merchantControlViolation struct {
+	TransactionID string     `json:"transactionId"`
+	CardID        string     `json:"cardId"`
+	ControlID     int64      `json:"controlId,omitempty"`
+	Reason        string     `json:"reason"`
+	MCCCode       string     `json:"mccCode"`
+	MerchantID    string     `json:"merchantId"`
+	MerchantName  string     `json:"merchantName"`
+	Amount        acme.Money `json:"amount"`
+	CardFrozen    bool       `json:"cardFrozen"`
+	CreatedAt     string     `json:"createdAt"`
+}
+
+type listMerchantControlViolationsResponse struct {
+	Violations []merchantControlViolation `json:"violations"`
+}
+
+type fraudRuleParams struct {
+	MaxCount int `json:"maxCount,omitempty"`
+	// Window is a duration e.g. 1h or 10m
+	Window     string   `json:"window,omitempty"`
+	Multiplier float64  `json:"multiplier,omitempty"`
+	MinHistory int      `json:"minHistory,omitempty"`
+	Countries  []string `json:"countries,omitempty"`
+	MCCCodes   []string `json:"mccCodes,omitempty"`
+}
+
+type fraudRule struct {
+	ID        int64           `json:"id"`
+	Name      string          `json:"name"`
+	Type      string          `json:"type"`
+	Params    fraudRuleParams `json:"params"`
+	Action    string          `json:"action"`
+	DryRun    bool            `json:"dryRun"`
+	Enabled   bool            `json:"enabled"`
+	CreatedAt string          `json:"createdAt"`
+}
+
+type listFraudRulesResponse struct {
+	Rules []fraudRule `json:"rules"`
+}
+
+type fraudDecision struct {
+	ID            int64  `json:"id"`
+	RuleID        int64  `json:"ruleId"`
+	RuleName      string `json:"ruleName"`
+	TransactionID string `json:"transactionId"`
+	CardID        string `json:"cardId"`
+	Action        string `json:"action"`
+	DryRun        bool   `json:"dryRun"`
+	Reason        string `json:"reason"`
+	CreatedAt     string `json:"createdAt"`
+}
+
+type listFraudDecisionsResponse struct {
+	Decisions []fraudDecision `json:"decisions"`
+}
+
+type transactionFlag struct {
+	TransactionID string `json:"transactionId"`
+	CardID        string `json:"cardId"`
+	RuleID        int64  `json:"ruleId"`
+	Reason        string `json:"reason"`
+	ClearedAt     string `json:"clearedAt,omitempty"`
+	CreatedAt     string `json:"createdAt"`
+}
+
+type listTransactionFlagsResponse struct {
+	Flags []transactionFlag `json:"flags"`
+}
+
+type webhookSubscription struct {
+	ID  int64  `json:"id"`
+	URL string `json:"url"`
+	// Secret is only returned on creation
+	Secret     string   `json:"secret,omitempty"`
+	EventTypes []string `json:"eventTypes"`
+	CreatedAt  string   `json:"createdAt"`
+}
+
+type listWebhookSubscriptionsResponse struct {
+	Subscriptions []webhookSubscription `json:"subscriptions"`
+}
+
+type webhookDelivery struct {
+	ID             int64  `json:"id"`
+	SubscriptionID int64  `json:"subscriptionId"`
+	EventID        string `json:"eventId"`
+	EventType      string `json:"eventType"`
+	Status         string `json:"status"`
+	Attempts       int    `json:"attempts"`
+	// NextAttemptAt is only set on pending deliveries
+	NextAttemptAt string `json:"nextAttemptAt,omitempty"`
+	ResponseCode  int    `json:"responseCode,omitempty"`
+	Error         string `json:"error,omitempty"`
+	CreatedAt     string `json:"createdAt"`
+	UpdatedAt     string `json:"updatedAt"`
+	// Payload and AttemptLog are only returned by the delivery details
+	Payload    json.RawMessage          `json:"payload,omitempty"`
+	AttemptLog []webhookDeliveryAttempt `json:"attemptLog,omitempty"`
+}
+
+type webhookDeliveryAttempt struct {
+	Attempt      int    `json:"attempt"`
+	ResponseCode int    `json:"responseCode,omitempty"`
+	Error        string `json:"error,omitempty"`
+	DurationMS   int64  `json:"durationMs"`
+	CreatedAt    string `json:"createdAt"`
+}
+
+type listWebhookDeliveriesResponse struct {
+	Deliveries []webhookDelivery `json:"deliveries"`
+}
+
+type notification struct {
+	ID      int64  `json:"id"`
+	EventID string `json:"eventId"`
+	Type    string `json:"type"`
+	CardID  string `json:"cardId"`
+	// Amount and AvailableCredit are only set on the funded notifications
+	Amount          *acme.Money `json:"amount,omitempty"`
+	AvailableCredit *acme.Money `json:"availableCredit,omitempty"`
+	Email           string      `json:"email,omitempty"`
+	Subject         string      `json:"subject,omitempty"`
+	Status          string      `json:"status"`
+	Attempts        int         `json:"attempts"`
+	// NextAttemptAt is only set on pending notifications
+	NextAttemptAt string `json:"nextAttemptAt,omitempty"`
+	Error         string `json:"error,omitempty"`
+	CreatedAt     string `json:"createdAt"`
+	SentAt        string `json:"sentAt,omitempty"`
+}
+
+type listNotificationsResponse struct {
+	Notifications []notification `json:"notifications"`
+}
+
+type notificationPreference struct {
+	Email       string   `json:"email"`
+	Locale      string   `json:"locale"`
+	OptOutAll   bool     `json:"optOutAll"`
+	OptOutTypes []string `json:"optOutTypes"`
+	UpdatedAt   string   `json:"updatedAt"`
+}
+
+type balanceRule struct {
+	ID        int64      `json:"id"`
+	CardID    string     `json:"cardId"`
+	Kind      string     `json:"kind"`
+	Threshold acme.Money `json:"threshold"`
+	// TopUpTo and MonthlyLimit are only set on the top-up rules
+	TopUpTo      *acme.Money `json:"topUpTo,omitempty"`
+	MonthlyLimit *acme.Money `json:"monthlyLimit,omitempty"`
+	// TriggeredAt is set while the card is below the threshold
+	TriggeredAt string `json:"triggeredAt,omitempty"`
+	CreatedAt   string `json:"createdAt"`
+}
+
+type listBalanceRulesResponse struct {
+	Rules []balanceRule `json:"rules"`
+}
+
+type balanceRuleExecution struct {
+	ID              int64      `json:"id"`
+	RuleID          int64      `json:"ruleId"`
+	CardID          string     `json:"cardId"`
+	Kind            string     `json:"kind"`
+	Status          string     `json:"status"`
+	AvailableCredit acme.Money `json:"availableCredit"`
+	// Amount and AdjustmentID are only set on the top-ups
+	Amount       *acme.Money `json:"amount,omitempty"`
+	AdjustmentID string      `json:"adjustmentId,omitempty"`
+	Reason       string      `json:"reason,omitempty"`
+	CreatedAt    string      `json:"createdAt"`
+}
+
+type listBalanceRuleExecutionsResponse struct {
+	Executions []balanceRuleExecution `json:"executions"`
+}
+
+type allowanceSchedule struct {
+	ID        int64      `json:"id"`
+	CardID    string     `json:"cardId"`
+	Amount    acme.Money `json:"amount"`
+	Cadence   string     `json:"cadence"`
+	Mode      string     `json:"mode"`
+	StartDate string     `json:"startDate"`
+	EndDate   string     `json:"endDate,omitempty"`
+	// NextRunAt is omitted once the schedule ended
+	NextRunAt string `json:"nextRunAt,omitempty"`
+	CreatedAt string `json:"createdAt"`
+}
+
+type listAllowanceSchedulesResponse struct {
+	Schedules []allowanceSchedule `json:"schedules"`
+}
+
+type allowanceRun struct {
+	ID          int64  `json:"id"`
+	ScheduleID  int64  `json:"scheduleId"`
+	CardID      string `json:"cardId"`
+	ScheduledAt string `json:"scheduledAt"`
+	Mode        string `json:"mode"`
+	Status      string `json:"status"`
+	// the adjustment fields are only set on the runs that adjusted the
+	// balance
+	AdjustmentType        string      `json:"adjustmentType,omitempty"`
+	Amount                *acme.Money `json:"amount,omitempty"`
+	AvailableCreditBefore *acme.Money `json:"availableCreditBefore,omitempty"`
+	AvailableCreditAfter  *acme.Money `json:"availableCreditAfter,omitempty"`
+	AdjustmentID          string      `json:"adjustmentId,omitempty"`
+	Attempts              int         `json:"attempts"`
+	Error                 string      `json:"error,omitempty"`
+	CreatedAt             string      `json:"createdAt"`
+}
+
+type listAllowanceRunsResponse struct {
+	Runs []allowanceRun `json:"runs"`
+}
+
+type burnerCard struct {
+	CardID string `json:"cardId"`
+	Mode   string `json:"mode"`
+	// MerchantID is the merchant of a merchant-locked card
+	MerchantID string `json:"merchantId,omitempty"`
+	FrozenAt   string `json:"frozenAt,omitempty"`
+	EndedAt    string `json:"endedAt,omitempty"`
+	EndReason  string `json:"endReason,omitempty"`
+	CreatedAt  string `json:"createdAt"`
+}
+
+type listBurnerCardsResponse struct {
+	Cards []burnerCard `json:"cards"`
+}
+
+type spendRequest struct {
+	ID                int64      `json:"id"`
+	CardID            string     `json:"cardId"`
+	Requester         string     `json:"requester"`
+	Amount            acme.Money `json:"amount"`
+	Justification     string     `json:"justification"`
+	Status            string     `json:"status"`
+	RequiredApprovals int        `json:"requiredApprovals"`
+	AdjustmentID      string     `json:"adjustmentId,omitempty"`
+	Error             string     `json:"error,omitempty"`
+	CreatedAt         string     `json:"createdAt"`
+	UpdatedAt         string     `json:"updatedAt"`
+	// Approvals and Activities are only set on a single request
+	Approvals  *int                   `json:"approvals,omitempty"`
+	Activities []spendRequestActivity `json:"activities,omitempty"`
+}
+
+type listSpendRequestsResponse struct {
+	Requests []spendRequest `json:"requests"`
+}
+
+type spendRequestActivity struct {
+	ID        int64  `json:"id"`
+	Kind      string `json:"kind"`
+	Actor     string `json:"actor,omitempty"`
+	Comment   string `json:"comment,omitempty"`
+	CreatedAt string `json:"createdAt"`
+}
+
+type spendRequestApprover struct {
+	Name string `json:"name"`
+	// Limit is omitted on the approvers without limit
+	Limit     *acme.Money `json:"limit,omitempty"`
+	CreatedAt string      `json:"createdAt"`
+	UpdatedAt string      `json:"updatedAt"`
+}
+
+type listSpendRequestApproversResponse struct {
+	Approvers []spendRequestApprover `json:"approvers"`
+}
+
+type cardApplication struct {
+	ID              int64                  `json:"id"`
+	Status          string                 `json:"status"`
+	Maker           string                 `json:"maker"`
+	Checker         string                 `json:"checker,omitempty"`
+	RejectionReason string                 `json:"rejectionReason,omitempty"`
+	CardID          string                 `json:"cardId,omitempty"`
+	Error           string                 `json:"error,omitempty"`
+	Applicant       cardApplicant          `json:"applicant"`
+	ExpiresAt       string                 `json:"expiresAt"`
+	CreatedAt       string                 `json:"createdAt"`
+	UpdatedAt       string                 `json:"updatedAt"`
+	Events          []cardApplicationEvent `json:"events,omitempty"`
+}
+
+// cardApplicant is the KYC and the options of the card to create, in the
+// shape of createCardRequest
+type cardApplicant struct {
+	FirstName    string         `json:"firstName"`
+	LastName     string         `json:"lastName"`
+	DOB          string         `json:"dob"`
+	Address      addressInfo    `json:"address"`
+	IDDocument   idDocument     `json:"idDocument"`
+	OTP          contactDetails `json:"otp"`
+	Mode         string         `json:"mode"`
+	MerchantID   string         `json:"merchantId,omitempty"`
+	ValidUntil   string         `json:"validUntil,omitempty"`
+	ExpiryAction string         `json:"expiryAction,omitempty"`
+}
+
+type cardApplicationEvent struct {
+	ID        int64  `json:"id"`
+	Action    string `json:"action"`
+	Actor     string `json:"actor,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+	CreatedAt string `json:"createdAt"`
+}
+
+type listCardApplicationsResponse struct {
+	Applications []cardApplication `json:"applications"`
+}
+
+type cardGroup struct {
+	ID     int64      `json:"id"`
+	Name   string     `json:"name"`
+	Budget acme.Money `json:"budget"`
+	Period string     `json:"period"`
+	// CardIDs are only set on a single group
+	CardIDs   []string `json:"cardIds,omitempty"`
+	CreatedAt string   `json:"createdAt"`
+}
+
+type listCardGroupsResponse struct {
+	Groups []cardGroup `json:"groups"`
+}
+
+type cardGroupConsumption struct {
+	GroupID     int64                      `json:"groupId"`
+	Period      string                     `json:"period"`
+	PeriodStart string                     `json:"periodStart"`
+	PeriodEnd   string                     `json:"periodEnd"`
+	Budget      acme.Money                 `json:"budget"`
+	Spent       acme.Money                 `json:"spent"`
+	Allocated   acme.Money                 `json:"allocated"`
+	Remaining   acme.Money                 `json:"remaining"`
+	Cards       []cardGroupCardConsumption `json:"cards"`
+}
+
+type cardGroupCardConsumption struct {
+	CardID          string     `json:"cardId"`
+	Spent           acme.Money `json:"spent"`
+	AvailableCredit acme.Money `json:"availableCredit"`
+}
@@ -0,0 +1,42 @@
+package acmehttp
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/x/xhttp"
+)
+
+// makeRetrySpendRequestFundingHandler tops up the card of a request whose
+// funding failed
+func makeRetrySpendRequestFundingHandler(reqSvc acme.SpendRequestService) http.Handler {
+	return xhttp.WrapXHTTP(xhttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		requestID, err := strconv.ParseInt(chi.URLParam(r, "requestID"), 10, 64)
+		if err != nil {
+			return xhttp.NewError(http.StatusBadRequest, fmt.Errorf("parse request id: %w", err))
+		}
+
+		req, err := reqSvc.RetrySpendRequestFunding(r.Context(), requestID)
+		if err != nil {
+			switch {
+			case errors.Is(err, acme.ErrSpendRequestNotFound):
+				return xhttp.NewError(http.StatusNotFound, err)
+			case errors.Is(err, acme.ErrSpendRequestStatus):
+				return xhttp.NewError(http.StatusConflict, err)
+			}
+			return fmt.Errorf("retry spend request funding: %w", err)
+		}
+
+		err = renderResponse(http.StatusOK, w, toSpendRequest(*req))
+		if err != nil {
+			return fmt.Errorf("render response: %w", err)
+		}
+
+		return nil
+	}))
+}
@@ -0,0 +1,113 @@
+package acmehttp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/x/xhttp"
+)
+
+// makeSaveFraudRuleHandler creates a rule, or replaces the rule in the
+// path when update is set
+func makeSaveFraudRuleHandler(fraudSvc acme.FraudService, update bool) http.Handler {
+	return xhttp.WrapXHTTP(xhttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		var req fraudRuleRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return xhttp.NewError(http.StatusBadRequest, fmt.Errorf("decode request: %w", err))
+		}
+
+		rule, err := toFraudRule(req)
+		if err != nil {
+			return xhttp.NewError(http.StatusBadRequest, err)
+		}
+
+		status := http.StatusCreated
+		save := fraudSvc.CreateFraudRule
+		if update {
+			rule.ID, err = strconv.ParseInt(chi.URLParam(r, "ruleID"), 10, 64)
+			if err != nil {
+				return xhttp.NewError(http.StatusBadRequest, fmt.Errorf("parse rule id: %w", err))
+			}
+			status = http.StatusOK
+			save = fraudSvc.UpdateFraudRule
+		}
+
+		saved, err := save(r.Context(), rule)
+		if err != nil {
+			if errors.Is(err, acme.ErrInvalidFraudRule) {
+				return xhttp.NewError(http.StatusBadRequest, err)
+			}
+			if errors.Is(err, acme.ErrFraudRuleNotFound) {
+				return xhttp.NewError(http.StatusNotFound, err)
+			}
+			return fmt.Errorf("save fraud rule: %w", err)
+		}
+
+		err = renderResponse(status, w, toFraudRuleResponse(*saved))
+		if err != nil {
+			return fmt.Errorf("render response: %w", err)
+		}
+
+		return nil
+	}))
+}
+
+func toFraudRule(req fraudRuleRequest) (acme.FraudRule, error) {
+	rule := acme.FraudRule{
+		Name:    req.Name,
+		Type:    req.Type,
+		Action:  req.Action,
+		DryRun:  req.DryRun,
+		Enabled: req.Enabled == nil || *req.Enabled,
+		Params: acme.FraudRuleParams{
+			MaxCount:   req.Params.MaxCount,
+			Multiplier: req.Params.Multiplier,
+			MinHistory: req.Params.MinHistory,
+			Countries:  req.Params.Countries,
+			MCCCodes:   req.Params.MCCCodes,
+		},
+	}
+
+	if req.Params.Window != "" {
+		window, err := time.ParseDuration(req.Params.Window)
+		if err != nil {
+			return rule, fmt.Errorf("parse window: %w", err)
+		}
+		rule.Params.Window = window
+	}
+
+	return rule, nil
+}
+
+func toFraudRuleResponse(rule acme.FraudRule) fraudRule {
+	p := rule.Params
+	params := fraudRuleParams{
+		MaxCount:   p.MaxCount,
+		Multiplier: p.Multiplier,
+		MinHistory: p.MinHistory,
+		Countries:  p.Countries,
+		MCCCodes:   p.MCCCodes,
+	}
+	if p.Window > 0 {
+		params.Window = p.Window.String()
+	}
+
+	return fraudRule{
+		ID:        rule.ID,
+		Name:      rule.Name,
+		Type:      rule.Type,
+		Params:    params,
+		Action:    rule.Action,
+		DryRun:    rule.DryRun,
+		Enabled:   rule.Enabled,
+		CreatedAt: rule.CreatedAt.UTC().Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,37 @@
+package acmehttp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/x/xhttp"
+)
+
+func makeSaveJournalAccountHandler(journalSvc acme.JournalService) http.Handler {
+	return xhttp.WrapXHTTP(xhttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		var req saveJournalAccountRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return xhttp.NewError(http.StatusBadRequest, fmt.Errorf("decode request: %w", err))
+		}
+
+		account := acme.JournalAccount{Role: req.Role, Account: req.Account}
+		err = journalSvc.SetJournalAccount(r.Context(), account)
+		if err != nil {
+			if errors.Is(err, acme.ErrInvalidJournalExportParams) {
+				return xhttp.NewError(http.StatusBadRequest, err)
+			}
+			return fmt.Errorf("set journal account: %w", err)
+		}
+
+		err = renderResponse(http.StatusOK, w, journalAccount(req))
+		if err != nil {
+			return fmt.Errorf("render response: %w", err)
+		}
+
+		return nil
+	}))
+}
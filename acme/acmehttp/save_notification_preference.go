@@ -0,0 +1,45 @@
+package acmehttp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/x/xhttp"
+)
+
+// makeSaveNotificationPreferenceHandler creates or replaces the locale and
+// the opt-outs of the cardholder
+func makeSaveNotificationPreferenceHandler(notificationSvc acme.NotificationService) http.Handler {
+	return xhttp.WrapXHTTP(xhttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		var req saveNotificationPreferenceRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return xhttp.NewError(http.StatusBadRequest, fmt.Errorf("decode request: %w", err))
+		}
+
+		pref, err := notificationSvc.SaveNotificationPreference(r.Context(), acme.NotificationPreference{
+			Email:       chi.URLParam(r, "email"),
+			Locale:      req.Locale,
+			OptOutAll:   req.OptOutAll,
+			OptOutTypes: req.OptOutTypes,
+		})
+		if err != nil {
+			if errors.Is(err, acme.ErrInvalidNotificationPreference) {
+				return xhttp.NewError(http.StatusBadRequest, err)
+			}
+			return fmt.Errorf("save notification preference: %w", err)
+		}
+
+		err = renderResponse(http.StatusOK, w, toNotificationPreference(*pref))
+		if err != nil {
+			return fmt.Errorf("render response: %w", err)
+		}
+
+		return nil
+	}))
+}
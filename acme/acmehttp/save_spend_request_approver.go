@@ -0,0 +1,57 @@
+package acmehttp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/x/xhttp"
+)
+
+// makeSaveSpendRequestApproverHandler creates or replaces the limit of the
+// approver
+func makeSaveSpendRequestApproverHandler(reqSvc acme.SpendRequestService) http.Handler {
+	return xhttp.WrapXHTTP(xhttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		var req saveSpendRequestApproverRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return xhttp.NewError(http.StatusBadRequest, fmt.Errorf("decode request: %w", err))
+		}
+
+		approver, err := reqSvc.SaveSpendRequestApprover(r.Context(), acme.SpendRequestApprover{
+			Name:  chi.URLParam(r, "approver"),
+			Limit: req.Limit,
+		})
+		if err != nil {
+			if errors.Is(err, acme.ErrInvalidSpendRequest) {
+				return xhttp.NewError(http.StatusBadRequest, err)
+			}
+			return fmt.Errorf("save spend request approver: %w", err)
+		}
+
+		err = renderResponse(http.StatusOK, w, toSpendRequestApprover(*approver))
+		if err != nil {
+			return fmt.Errorf("render response: %w", err)
+		}
+
+		return nil
+	}))
+}
+
+func toSpendRequestApprover(approver acme.SpendRequestApprover) spendRequestApprover {
+	resp := spendRequestApprover{
+		Name:      approver.Name,
+		CreatedAt: approver.CreatedAt.UTC().Format(time.RFC3339),
+		UpdatedAt: approver.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+	if !approver.Limit.IsZero() {
+		resp.Limit = &approver.Limit
+	}
+
+	return resp
+}
@@ -0,0 +1,94 @@
+package acmehttp
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/x/xhttp"
+)
+
+const (
+	// streamHeartbeatInterval keeps idle streams open through proxies
+	streamHeartbeatInterval = 15 * time.Second
+	// streamRetry is the reconnect delay suggested to the clients
+	streamRetry = 3 * time.Second
+)
+
+// makeStreamEventsHandler streams the events as server-sent events, filtered
+// by the cardId query and resumed after the Last-Event-ID header
+func makeStreamEventsHandler(hub *acme.EventHub) http.Handler {
+	return xhttp.WrapXHTTP(xhttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		var lastSeq int64
+		if lastEventID := r.Header.Get("Last-Event-ID"); lastEventID != "" {
+			var err error
+			lastSeq, err = strconv.ParseInt(lastEventID, 10, 64)
+			if err != nil {
+				return xhttp.NewError(http.StatusBadRequest, fmt.Errorf("parse last event id: %w", err))
+			}
+		}
+
+		// the server write timeout would end the stream
+		rc := http.NewResponseController(w)
+		err := rc.SetWriteDeadline(time.Time{})
+		if err != nil {
+			return fmt.Errorf("clear write deadline: %w", err)
+		}
+
+		sub, backlog := hub.Subscribe(r.URL.Query().Get("cardId"), lastSeq)
+		defer hub.Unsubscribe(sub)
+
+		w.Header().Set("content-type", "text/event-stream")
+		w.Header().Set("cache-control", "no-cache")
+		w.Header().Set("x-accel-buffering", "no")
+		w.WriteHeader(http.StatusOK)
+
+		_, err = fmt.Fprintf(w, "retry: %d\n\n", streamRetry.Milliseconds())
+		if err != nil {
+			return nil
+		}
+
+		for _, e := range backlog {
+			err = writeStreamEvent(w, e)
+			if err != nil {
+				return nil
+			}
+		}
+
+		heartbeat := time.NewTicker(streamHeartbeatInterval)
+		defer heartbeat.Stop()
+
+		// write errors mean the client is gone
+		for {
+			err = rc.Flush()
+			if err != nil {
+				return nil
+			}
+
+			select {
+			case <-r.Context().Done():
+				return nil
+			case e, ok := <-sub.Events():
+				if !ok {
+					// the client fell behind and resumes on reconnect
+					return nil
+				}
+				err = writeStreamEvent(w, e)
+			case <-heartbeat.C:
+				_, err = io.WriteString(w, ": heartbeat\n\n")
+			}
+			if err != nil {
+				return nil
+			}
+		}
+	}))
+}
+
+// writeStreamEvent writes the event, the payload is single line JSON
+func writeStreamEvent(w io.Writer, e acme.StreamEvent) error {
+	_, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.Seq, e.Type, e.Payload)
+	return err
+}
@@ -0,0 +1,77 @@
+package acmehttp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/x/xhttp"
+)
+
+func makeSubmitSpendRequestHandler(reqSvc acme.SpendRequestService) http.Handler {
+	return xhttp.WrapXHTTP(xhttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		var req submitSpendRequestRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return xhttp.NewError(http.StatusBadRequest, fmt.Errorf("decode request: %w", err))
+		}
+
+		spendReq, err := reqSvc.SubmitSpendRequest(r.Context(), acme.SpendRequest{
+			CardID:        req.CardID,
+			Requester:     req.Requester,
+			Amount:        req.Amount,
+			Justification: req.Justification,
+		})
+		if err != nil {
+			switch {
+			case errors.Is(err, acme.ErrInvalidSpendRequest):
+				return xhttp.NewError(http.StatusBadRequest, err)
+			case errors.Is(err, acme.ErrCardNotFound):
+				return xhttp.NewError(http.StatusNotFound, err)
+			}
+			return fmt.Errorf("submit spend request: %w", err)
+		}
+
+		err = renderResponse(http.StatusCreated, w, toSpendRequest(*spendReq))
+		if err != nil {
+			return fmt.Errorf("render response: %w", err)
+		}
+
+		return nil
+	}))
+}
+
+func toSpendRequest(req acme.SpendRequest) spendRequest {
+	resp := spendRequest{
+		ID:                req.ID,
+		CardID:            req.CardID,
+		Requester:         req.Requester,
+		Amount:            req.Amount,
+		Justification:     req.Justification,
+		Status:            req.Status,
+		RequiredApprovals: req.RequiredApprovals,
+		AdjustmentID:      req.AdjustmentID,
+		Error:             req.Error,
+		CreatedAt:         req.CreatedAt.UTC().Format(time.RFC3339),
+		UpdatedAt:         req.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+	if req.Activities != nil {
+		approvals := req.Approvals()
+		resp.Approvals = &approvals
+		resp.Activities = make([]spendRequestActivity, 0, len(req.Activities))
+		for _, a := range req.Activities {
+			resp.Activities = append(resp.Activities, spendRequestActivity{
+				ID:        a.ID,
+				Kind:      a.Kind,
+				Actor:     a.Actor,
+				Comment:   a.Comment,
+				CreatedAt: a.CreatedAt.UTC().Format(time.RFC3339),
+			})
+		}
+	}
+
+	return resp
+}
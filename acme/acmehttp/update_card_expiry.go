@@ -0,0 +1,52 @@
+package acmehttp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/x/xhttp"
+)
+
+// makeUpdateCardExpiryHandler sets the expiry of the card, an empty
+// validUntil clears it
+func makeUpdateCardExpiryHandler(cardSvc acme.CardService) http.Handler {
+	return xhttp.WrapXHTTP(xhttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		var req updateCardExpiryRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return xhttp.NewError(http.StatusBadRequest, fmt.Errorf("decode request: %w", err))
+		}
+
+		params := acme.UpdateCardExpiryParams{ExpiryAction: req.ExpiryAction}
+		if req.ValidUntil != "" {
+			params.ValidUntil, err = time.Parse(time.RFC3339, req.ValidUntil)
+			if err != nil {
+				return xhttp.NewError(http.StatusBadRequest, fmt.Errorf("parse valid until: %w", err))
+			}
+		}
+
+		err = cardSvc.UpdateCardExpiry(r.Context(), chi.URLParam(r, "cardID"), params)
+		if err != nil {
+			switch {
+			case errors.Is(err, acme.ErrInvalidCardExpiry):
+				return xhttp.NewError(http.StatusBadRequest, err)
+			case errors.Is(err, acme.ErrCardNotFound):
+				return xhttp.NewError(http.StatusNotFound, err)
+			}
+			return fmt.Errorf("update card expiry: %w", err)
+		}
+
+		err = renderResponse(http.StatusNoContent, w, nil)
+		if err != nil {
+			return fmt.Errorf("render response: %w", err)
+		}
+
+		return nil
+	}))
+}
@@ -0,0 +1,54 @@
+package acmehttp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/x/xhttp"
+)
+
+// makeUpdateCardGroupHandler replaces the name, the budget and the period of
+// the group, the members are kept
+func makeUpdateCardGroupHandler(groupSvc acme.CardGroupService) http.Handler {
+	return xhttp.WrapXHTTP(xhttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		groupID, err := strconv.ParseInt(chi.URLParam(r, "groupID"), 10, 64)
+		if err != nil {
+			return xhttp.NewError(http.StatusBadRequest, fmt.Errorf("parse group id: %w", err))
+		}
+
+		var req saveCardGroupRequest
+		err = json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return xhttp.NewError(http.StatusBadRequest, fmt.Errorf("decode request: %w", err))
+		}
+
+		group, err := groupSvc.UpdateCardGroup(r.Context(), acme.CardGroup{
+			ID:     groupID,
+			Name:   req.Name,
+			Budget: req.Budget,
+			Period: req.Period,
+		})
+		if err != nil {
+			switch {
+			case errors.Is(err, acme.ErrInvalidCardGroup):
+				return xhttp.NewError(http.StatusBadRequest, err)
+			case errors.Is(err, acme.ErrCardGroupNotFound):
+				return xhttp.NewError(http.StatusNotFound, err)
+			}
+			return fmt.Errorf("update card group: %w", err)
+		}
+
+		err = renderResponse(http.StatusOK, w, toCardGroup(*group))
+		if err != nil {
+			return fmt.Errorf("render response: %w", err)
+		}
+
+		return nil
+	}))
+}
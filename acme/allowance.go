@@ -0,0 +1,471 @@
+package acme
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/stevenferrer/acme-cards-api/reap"
+	"github.com/stevenferrer/acme-cards-api/x/xcron"
+)
+
+var (
+	// ErrInvalidAllowanceSchedule is returned for invalid cadences, modes,
+	// amounts or dates
+	ErrInvalidAllowanceSchedule = errors.New("invalid allowance schedule")
+	// ErrAllowanceScheduleNotFound is returned when the schedule does not
+	// exist
+	ErrAllowanceScheduleNotFound = errors.New("allowance schedule not found")
+)
+
+// Allowance modes
+const (
+	// AllowanceResetTo tops up or withdraws from the card so its available
+	// credit is the allowance amount
+	AllowanceResetTo = "reset_to"
+	// AllowanceAddTo tops up the card with the allowance amount
+	AllowanceAddTo = "add_to"
+)
+
+// Allowance run statuses
+const (
+	AllowanceRunSucceeded = "succeeded"
+	// AllowanceRunSkipped is a run of an inactive card or of a card that
+	// already has the allowance amount
+	AllowanceRunSkipped = "skipped"
+	AllowanceRunFailed  = "failed"
+	// AllowanceRunPending is a run sent to Reap whose outcome is not saved
+	// yet
+	AllowanceRunPending = "pending"
+)
+
+// maxAllowanceAttempts is the number of attempts of a run before the
+// schedule moves on to the next run
+const maxAllowanceAttempts = 3
+
+// AllowanceSchedule adjusts the card balance on a cron cadence, the cadence
+// is evaluated in UTC
+type AllowanceSchedule struct {
+	ID      int64
+	CardID  string
+	Amount  Money
+	Cadence string
+	Mode    string
+	// StartDate is the first day of the runs, EndDate is the last day of
+	// the runs or zero for no end
+	StartDate time.Time
+	EndDate   time.Time
+	// NextRunAt is zero once the schedule ended
+	NextRunAt time.Time
+	CreatedAt time.Time
+}
+
+// AllowanceRun is the balance adjustment of a scheduled run
+type AllowanceRun struct {
+	ID          int64
+	ScheduleID  int64
+	CardID      string
+	ScheduledAt time.Time
+	Mode        string
+	Status      string
+	// AdjustmentType and Amount are the balance adjustment of the run
+	AdjustmentType string
+	Amount         Money
+	// AvailableCreditAfter is only set on the succeeded runs
+	AvailableCreditBefore Money
+	AvailableCreditAfter  Money
+	AdjustmentID          string
+	Attempts              int
+	// Error is the error of the last attempt or the reason it was skipped
+	Error     string
+	CreatedAt time.Time
+}
+
+type AllowanceRunFilter struct {
+	ScheduleID int64
+	CardID     string
+	// ScheduledAt only returns the run of the scheduled time when not zero
+	ScheduledAt time.Time
+}
+
+type AllowanceRepository interface {
+	// SaveAllowanceSchedule saves the schedule and sets its ID and creation
+	// time
+	SaveAllowanceSchedule(context.Context, *AllowanceSchedule) error
+	GetAllowanceSchedule(ctx context.Context, id int64) (*AllowanceSchedule, error)
+	// FindAllowanceSchedules returns the schedules of the card, every
+	// schedule is returned when cardID is empty
+	FindAllowanceSchedules(ctx context.Context, cardID string) ([]AllowanceSchedule, error)
+	// FindDueAllowanceSchedules returns the schedules with a run due at now
+	FindDueAllowanceSchedules(ctx context.Context, now time.Time) ([]AllowanceSchedule, error)
+	// UpdateAllowanceScheduleNextRun sets the next run, zero ends the schedule
+	UpdateAllowanceScheduleNextRun(ctx context.Context, id int64, nextRunAt time.Time) error
+	DeleteAllowanceSchedule(ctx context.Context, id int64) error
+	// LockAllowanceSchedule calls fn while holding the lock of the schedule
+	// shared by the server instances, it returns false without calling fn
+	// when the schedule is locked
+	LockAllowanceSchedule(ctx context.Context, id int64, fn func(context.Context) error) (bool, error)
+
+	// SaveAllowanceRun saves the run of the scheduled time and sets its ID,
+	// saving the run again records another attempt and sets Attempts
+	SaveAllowanceRun(context.Context, *AllowanceRun) error
+	// UpdateAllowanceRun saves the outcome of the last attempt of the run,
+	// it does not record another attempt
+	UpdateAllowanceRun(context.Context, AllowanceRun) error
+	// FindAllowanceRuns returns the runs, latest first
+	FindAllowanceRuns(context.Context, AllowanceRunFilter) ([]AllowanceRun, error)
+}
+
+type AllowanceService interface {
+	CreateAllowanceSchedule(context.Context, AllowanceSchedule) (*AllowanceSchedule, error)
+	ListAllowanceSchedules(ctx context.Context, cardID string) ([]AllowanceSchedule, error)
+	DeleteAllowanceSchedule(ctx context.Context, id int64) error
+	ListAllowanceRuns(context.Context, AllowanceRunFilter) ([]AllowanceRun, error)
+
+	// RunAllowances runs the due schedules
+	RunAllowances(context.Context) error
+}
+
+// CardAllowanceService implements AllowanceService
+type CardAllowanceService struct {
+	cardSvc       CardService
+	balanceSrc    BalanceChangeSource
+	allowanceRepo AllowanceRepository
+}
+
+var _ AllowanceService = (*CardAllowanceService)(nil)
+
+func NewCardAllowanceService(cardSvc CardService, balanceSrc BalanceChangeSource, allowanceRepo AllowanceRepository) *CardAllowanceService {
+	return &CardAllowanceService{
+		cardSvc:       cardSvc,
+		balanceSrc:    balanceSrc,
+		allowanceRepo: allowanceRepo,
+	}
+}
+
+func (s *CardAllowanceService) CreateAllowanceSchedule(ctx context.Context, schedule AllowanceSchedule) (*AllowanceSchedule, error) {
+	if schedule.CardID == "" {
+		return nil, fmt.Errorf("%w: card id is required", ErrInvalidAllowanceSchedule)
+	}
+
+	if schedule.Amount.Sign() <= 0 {
+		return nil, fmt.Errorf("%w: amount must be positive", ErrInvalidAllowanceSchedule)
+	}
+
+	if schedule.Amount.Currency() != reap.AccountCurrency {
+		return nil, fmt.Errorf("%w: amount must be in %s", ErrInvalidAllowanceSchedule, reap.AccountCurrency)
+	}
+
+	switch schedule.Mode {
+	case AllowanceResetTo, AllowanceAddTo:
+	default:
+		return nil, fmt.Errorf("%w: mode %q", ErrInvalidAllowanceSchedule, schedule.Mode)
+	}
+
+	cadence, err := xcron.Parse(schedule.Cadence)
+	if err != nil {
+		return nil, fmt.Errorf("%w: cadence: %w", ErrInvalidAllowanceSchedule, err)
+	}
+
+	now := time.Now().UTC()
+	if schedule.StartDate.IsZero() {
+		schedule.StartDate = now
+	}
+	schedule.StartDate = truncateDay(schedule.StartDate)
+
+	if !schedule.EndDate.IsZero() {
+		schedule.EndDate = truncateDay(schedule.EndDate)
+		if schedule.EndDate.Before(schedule.StartDate) {
+			return nil, fmt.Errorf("%w: end date is before the start date", ErrInvalidAllowanceSchedule)
+		}
+	}
+
+	schedule.NextRunAt = nextAllowanceRun(cadence, schedule, now)
+	if schedule.NextRunAt.IsZero() {
+		return nil, fmt.Errorf("%w: cadence has no run between the start and end dates", ErrInvalidAllowanceSchedule)
+	}
+
+	_, err = s.cardSvc.GetCard(ctx, schedule.CardID)
+	if err != nil {
+		return nil, fmt.Errorf("get card: %w", err)
+	}
+
+	err = s.allowanceRepo.SaveAllowanceSchedule(ctx, &schedule)
+	if err != nil {
+		return nil, fmt.Errorf("save allowance schedule: %w", err)
+	}
+
+	return &schedule, nil
+}
+
+// truncateDay returns the UTC midnight of the day
+func truncateDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// nextAllowanceRun returns the first run of the schedule at or after from,
+// or the zero time when the schedule ended.
+func nextAllowanceRun(cadence *xcron.Schedule, schedule AllowanceSchedule, from time.Time) time.Time {
+	if schedule.StartDate.After(from) {
+		from = schedule.StartDate
+	}
+
+	// the cadence matches the minutes strictly after the time
+	next := cadence.Next(from.Add(-time.Nanosecond))
+	if next.IsZero() {
+		return next
+	}
+
+	if !schedule.EndDate.IsZero() && !next.Before(schedule.EndDate.AddDate(0, 0, 1)) {
+		return time.Time{}
+	}
+
+	return next
+}
+
+func (s *CardAllowanceService) ListAllowanceSchedules(ctx context.Context, cardID string) ([]AllowanceSchedule, error) {
+	return s.allowanceRepo.FindAllowanceSchedules(ctx, cardID)
+}
+
+func (s *CardAllowanceService) DeleteAllowanceSchedule(ctx context.Context, id int64) error {
+	return s.allowanceRepo.DeleteAllowanceSchedule(ctx, id)
+}
+
+func (s *CardAllowanceService) ListAllowanceRuns(ctx context.Context, filter AllowanceRunFilter) ([]AllowanceRun, error) {
+	return s.allowanceRepo.FindAllowanceRuns(ctx, filter)
+}
+
+func (s *CardAllowanceService) RunAllowances(ctx context.Context) error {
+	schedules, err := s.allowanceRepo.FindDueAllowanceSchedules(ctx, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("find due allowance schedules: %w", err)
+	}
+
+	var errs []error
+	for _, schedule := range schedules {
+		// the schedules locked by another instance are run by it
+		_, err = s.allowanceRepo.LockAllowanceSchedule(ctx, schedule.ID, func(ctx context.Context) error {
+			return s.run(ctx, schedule.ID)
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("allowance schedule %d: %w", schedule.ID, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// run adjusts the card balance and moves the schedule to its next run,
+// failed runs are retried until they run out of attempts. The run is saved
+// as pending before the adjustment, a pending or failed run is reconciled
+// with the Reap balance history before it is retried.
+func (s *CardAllowanceService) run(ctx context.Context, scheduleID int64) error {
+	// another instance may have run the schedule before the lock
+	schedule, err := s.allowanceRepo.GetAllowanceSchedule(ctx, scheduleID)
+	if err != nil {
+		if errors.Is(err, ErrAllowanceScheduleNotFound) {
+			return nil
+		}
+		return fmt.Errorf("get allowance schedule: %w", err)
+	}
+
+	now := time.Now().UTC()
+	if schedule.NextRunAt.IsZero() || schedule.NextRunAt.After(now) {
+		return nil
+	}
+
+	cadence, err := xcron.Parse(schedule.Cadence)
+	if err != nil {
+		return fmt.Errorf("parse cadence: %w", err)
+	}
+
+	runs, err := s.allowanceRepo.FindAllowanceRuns(ctx, AllowanceRunFilter{ScheduleID: schedule.ID, ScheduledAt: schedule.NextRunAt})
+	if err != nil {
+		return fmt.Errorf("find allowance runs: %w", err)
+	}
+
+	var run AllowanceRun
+	if len(runs) > 0 {
+		run = runs[0]
+	}
+
+	switch run.Status {
+	case AllowanceRunSucceeded, AllowanceRunSkipped:
+		// the schedule was not moved on after the run
+		return s.moveOn(ctx, cadence, *schedule, now)
+	case AllowanceRunPending, AllowanceRunFailed:
+		// the last attempt may have been applied e.g. on a timeout
+		adjustmentID, err := s.reconcile(ctx, run)
+		if err != nil {
+			return err
+		}
+		if adjustmentID != "" {
+			run.Status, run.AdjustmentID, run.Error = AllowanceRunSucceeded, adjustmentID, ""
+			err = s.allowanceRepo.UpdateAllowanceRun(ctx, run)
+			if err != nil {
+				return fmt.Errorf("update allowance run: %w", err)
+			}
+			return s.moveOn(ctx, cadence, *schedule, now)
+		}
+	}
+
+	run = AllowanceRun{
+		ScheduleID:  schedule.ID,
+		CardID:      schedule.CardID,
+		ScheduledAt: schedule.NextRunAt,
+		Mode:        schedule.Mode,
+		CreatedAt:   now,
+	}
+
+	err = s.adjust(ctx, *schedule, &run)
+	if err != nil {
+		return err
+	}
+
+	if run.Status == AllowanceRunFailed && run.Attempts < maxAllowanceAttempts {
+		return nil
+	}
+
+	return s.moveOn(ctx, cadence, *schedule, now)
+}
+
+// moveOn moves the schedule to its next run, the runs missed while no
+// instance was running are skipped
+func (s *CardAllowanceService) moveOn(ctx context.Context, cadence *xcron.Schedule, schedule AllowanceSchedule, now time.Time) error {
+	from := schedule.NextRunAt.Add(time.Minute)
+	if now.After(from) {
+		from = now
+	}
+
+	err := s.allowanceRepo.UpdateAllowanceScheduleNextRun(ctx, schedule.ID, nextAllowanceRun(cadence, schedule, from))
+	if err != nil {
+		return fmt.Errorf("update allowance schedule next run: %w", err)
+	}
+
+	return nil
+}
+
+// reconcile returns the adjustment ID of the adjustment of a run whose
+// outcome is unknown, empty when Reap has no such adjustment. The
+// adjustment is an adjustment of the run type and amount on its card since
+// the first attempt that no other succeeded run claimed, Reap takes no
+// reference on the adjustments so an unrelated adjustment of the same
+// amount is matched as well.
+func (s *CardAllowanceService) reconcile(ctx context.Context, run AllowanceRun) (string, error) {
+	if run.AdjustmentType == "" {
+		return "", nil
+	}
+
+	runs, err := s.allowanceRepo.FindAllowanceRuns(ctx, AllowanceRunFilter{CardID: run.CardID})
+	if err != nil {
+		return "", fmt.Errorf("find allowance runs: %w", err)
+	}
+
+	claimed := make(map[string]bool, len(runs))
+	for _, r := range runs {
+		if r.Status == AllowanceRunSucceeded {
+			claimed[r.AdjustmentID] = true
+		}
+	}
+
+	from := run.CreatedAt.Add(-spendRequestClockSkew)
+	var adjustmentID string
+	err = s.balanceSrc.EachCardBalanceChange(ctx, run.CardID, DateRange{From: from}, func(bc BalanceChange) error {
+		if adjustmentID != "" || claimed[bc.ID] || bc.Date.Before(from) ||
+			!strings.EqualFold(bc.Type, run.AdjustmentType) {
+			return nil
+		}
+
+		// the balance change of an adjustment has the ID of the adjustment
+		cmp, err := bc.Amount.Abs().Cmp(run.Amount)
+		if err == nil && cmp == 0 {
+			adjustmentID = bc.ID
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("each card balance change: %w", err)
+	}
+
+	return adjustmentID, nil
+}
+
+// adjust tops up or withdraws from the card and saves the run with its
+// outcome, the run is saved as pending before the adjustment so that an
+// unsaved outcome is reconciled rather than adjusted again
+func (s *CardAllowanceService) adjust(ctx context.Context, schedule AllowanceSchedule, run *AllowanceRun) error {
+	card, err := s.cardSvc.GetCard(ctx, schedule.CardID)
+	if err != nil {
+		if errors.Is(err, ErrCardNotFound) {
+			run.Status = AllowanceRunSkipped
+			run.Error = "card not found"
+			return s.saveRun(ctx, run)
+		}
+		return fmt.Errorf("get card: %w", err)
+	}
+	run.AvailableCreditBefore = card.AvailableCredit
+
+	if card.Status != CardStatusActive {
+		run.Status = AllowanceRunSkipped
+		run.Error = fmt.Sprintf("card is %s", card.Status)
+		return s.saveRun(ctx, run)
+	}
+
+	run.AdjustmentType = BalanceAdjustmentTopUp
+	run.Amount = schedule.Amount
+	if schedule.Mode == AllowanceResetTo {
+		diff, err := schedule.Amount.Sub(card.AvailableCredit)
+		if err != nil {
+			return fmt.Errorf("allowance amount: %w", err)
+		}
+
+		switch diff.Sign() {
+		case 0:
+			run.Status = AllowanceRunSkipped
+			run.AdjustmentType, run.Amount = "", Money{}
+			run.Error = fmt.Sprintf("available credit is already %s", schedule.Amount)
+			return s.saveRun(ctx, run)
+		case -1:
+			run.AdjustmentType = BalanceAdjustmentWithdraw
+		}
+		run.Amount = diff.Abs()
+	}
+
+	run.Status = AllowanceRunPending
+	err = s.saveRun(ctx, run)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.cardSvc.AdjustCardBalance(ctx, schedule.CardID, AdjustCardBalanceParams{
+		Type:   run.AdjustmentType,
+		Amount: run.Amount,
+	})
+	if err != nil {
+		run.Status = AllowanceRunFailed
+		run.Error = err.Error()
+	} else {
+		run.Status = AllowanceRunSucceeded
+		run.AdjustmentID = resp.ID
+		run.AvailableCreditAfter = resp.AvailableCredit
+	}
+
+	err = s.allowanceRepo.UpdateAllowanceRun(ctx, *run)
+	if err != nil {
+		return fmt.Errorf("update allowance run: %w", err)
+	}
+
+	return nil
+}
+
+func (s *CardAllowanceService) saveRun(ctx context.Context, run *AllowanceRun) error {
+	err := s.allowanceRepo.SaveAllowanceRun(ctx, run)
+	if err != nil {
+		return fmt.Errorf("save allowance run: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,320 @@
+package acme_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/acme/memory"
+)
+
+// failingAllowanceRepository fails to update the runs while updateErr is
+// set and to move the schedules on while nextRunErr is set
+type failingAllowanceRepository struct {
+	*memory.AllowanceRepository
+	updateErr  error
+	nextRunErr error
+}
+
+func (r *failingAllowanceRepository) UpdateAllowanceRun(ctx context.Context, run acme.AllowanceRun) error {
+	if r.updateErr != nil {
+		return r.updateErr
+	}
+	return r.AllowanceRepository.UpdateAllowanceRun(ctx, run)
+}
+
+func (r *failingAllowanceRepository) UpdateAllowanceScheduleNextRun(ctx context.Context, id int64, nextRunAt time.Time) error {
+	if r.nextRunErr != nil {
+		return r.nextRunErr
+	}
+	return r.AllowanceRepository.UpdateAllowanceScheduleNextRun(ctx, id, nextRunAt)
+}
+
+// allowanceRuns returns the runs, oldest first
+func allowanceRuns(t *testing.T, allowanceRepo acme.AllowanceRepository) []acme.AllowanceRun {
+	t.Helper()
+
+	runs, err := allowanceRepo.FindAllowanceRuns(context.Background(), acme.AllowanceRunFilter{})
+	require.NoError(t, err)
+	slices.Reverse(runs)
+	return runs
+}
+
+func allowanceSchedule(t *testing.T, allowanceRepo acme.AllowanceRepository, id int64) acme.AllowanceSchedule {
+	t.Helper()
+
+	schedule, err := allowanceRepo.GetAllowanceSchedule(context.Background(), id)
+	require.NoError(t, err)
+	return *schedule
+}
+
+func TestAllowanceService(t *testing.T) {
+	ctx := context.Background()
+	usd := func(amount string) acme.Money { return acme.MustParseMoney(amount, "USD") }
+
+	newService := func() (*acme.CardAllowanceService, *fakeCardService, *failingAllowanceRepository) {
+		cardSvc := newCardService(acme.Card{ID: "card1", Status: acme.CardStatusActive, AvailableCredit: usd("20.00")})
+		allowanceRepo := &failingAllowanceRepository{AllowanceRepository: memory.NewAllowanceRepository()}
+		return acme.NewCardAllowanceService(cardSvc, cardSvc, allowanceRepo), cardSvc, allowanceRepo
+	}
+
+	// due makes the next run of the schedule due within the last hour, later
+	// than the runs so far so that each run has its own scheduled time
+	due := func(t *testing.T, repo acme.AllowanceRepository, id int64) {
+		later := time.Duration(len(allowanceRuns(t, repo))) * time.Minute
+		nextRunAt := time.Now().UTC().Truncate(time.Minute).Add(-time.Hour + later)
+		require.NoError(t, repo.UpdateAllowanceScheduleNextRun(ctx, id, nextRunAt))
+	}
+
+	t.Run("invalid schedules", func(t *testing.T) {
+		allowanceSvc, _, _ := newService()
+		start := time.Now().UTC().AddDate(0, 0, 1)
+		for _, s := range []acme.AllowanceSchedule{
+			{Amount: usd("50.00"), Cadence: "@monthly", Mode: acme.AllowanceAddTo},
+			{CardID: "card1", Cadence: "@monthly", Mode: acme.AllowanceAddTo},
+			{CardID: "card1", Amount: acme.MustParseMoney("50.00", "EUR"), Cadence: "@monthly", Mode: acme.AllowanceAddTo},
+			{CardID: "card1", Amount: usd("50.00"), Cadence: "@monthly", Mode: "set_to"},
+			{CardID: "card1", Amount: usd("50.00"), Cadence: "every month", Mode: acme.AllowanceAddTo},
+			{CardID: "card1", Amount: usd("50.00"), Cadence: "@daily", Mode: acme.AllowanceAddTo, StartDate: start, EndDate: start.AddDate(0, 0, -1)},
+			// the 1st of the month is not within the dates
+			{CardID: "card1", Amount: usd("50.00"), Cadence: "0 0 1 * *", Mode: acme.AllowanceAddTo,
+				StartDate: time.Date(2027, 3, 2, 0, 0, 0, 0, time.UTC), EndDate: time.Date(2027, 3, 20, 0, 0, 0, 0, time.UTC)},
+		} {
+			_, err := allowanceSvc.CreateAllowanceSchedule(ctx, s)
+			assert.ErrorIs(t, err, acme.ErrInvalidAllowanceSchedule)
+		}
+
+		_, err := allowanceSvc.CreateAllowanceSchedule(ctx, acme.AllowanceSchedule{
+			CardID: "card2", Amount: usd("50.00"), Cadence: "@monthly", Mode: acme.AllowanceAddTo,
+		})
+		assert.ErrorIs(t, err, acme.ErrCardNotFound)
+	})
+
+	t.Run("first run", func(t *testing.T) {
+		allowanceSvc, _, _ := newService()
+		schedule, err := allowanceSvc.CreateAllowanceSchedule(ctx, acme.AllowanceSchedule{
+			CardID:    "card1",
+			Amount:    usd("50.00"),
+			Cadence:   "0 9 * * 1",
+			Mode:      acme.AllowanceAddTo,
+			StartDate: time.Date(2027, 3, 2, 15, 0, 0, 0, time.UTC),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, time.Date(2027, 3, 2, 0, 0, 0, 0, time.UTC), schedule.StartDate)
+		// the first monday on or after the start date
+		assert.Equal(t, time.Date(2027, 3, 8, 9, 0, 0, 0, time.UTC), schedule.NextRunAt)
+	})
+
+	t.Run("reset to", func(t *testing.T) {
+		allowanceSvc, cardSvc, repo := newService()
+		schedule, err := allowanceSvc.CreateAllowanceSchedule(ctx, acme.AllowanceSchedule{
+			CardID: "card1", Amount: usd("50.00"), Cadence: "@monthly", Mode: acme.AllowanceResetTo,
+		})
+		require.NoError(t, err)
+
+		// not due yet
+		require.NoError(t, allowanceSvc.RunAllowances(ctx))
+		assert.Empty(t, allowanceRuns(t, repo))
+
+		due(t, repo, schedule.ID)
+		require.NoError(t, allowanceSvc.RunAllowances(ctx))
+		require.Len(t, balanceChanges(t, cardSvc, "card1"), 1)
+		assert.Equal(t, acme.BalanceAdjustmentTopUp, balanceChanges(t, cardSvc, "card1")[0].Type)
+		assert.Equal(t, usd("30.00"), balanceChanges(t, cardSvc, "card1")[0].Amount)
+
+		runs := allowanceRuns(t, repo)
+		require.Len(t, runs, 1)
+		run := runs[0]
+		assert.Equal(t, acme.AllowanceRunSucceeded, run.Status)
+		assert.Equal(t, usd("20.00"), run.AvailableCreditBefore)
+		assert.Equal(t, usd("50.00"), run.AvailableCreditAfter)
+		assert.Equal(t, "adjustment1", run.AdjustmentID)
+
+		now := time.Now().UTC()
+		assert.Equal(t, time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, time.UTC), allowanceSchedule(t, repo, schedule.ID).NextRunAt)
+
+		// the unspent balance above the allowance is withdrawn
+		setAvailableCredit(t, cardSvc, "card1", usd("80.00"))
+		due(t, repo, schedule.ID)
+		require.NoError(t, allowanceSvc.RunAllowances(ctx))
+		require.Len(t, balanceChanges(t, cardSvc, "card1"), 2)
+		assert.Equal(t, acme.BalanceAdjustmentWithdraw, balanceChanges(t, cardSvc, "card1")[1].Type)
+		assert.Equal(t, usd("30.00"), balanceChanges(t, cardSvc, "card1")[1].Amount)
+
+		due(t, repo, schedule.ID)
+		require.NoError(t, allowanceSvc.RunAllowances(ctx))
+		assert.Len(t, balanceChanges(t, cardSvc, "card1"), 2)
+		runs = allowanceRuns(t, repo)
+		require.Len(t, runs, 3)
+		assert.Equal(t, acme.AllowanceRunSkipped, runs[2].Status)
+		assert.Equal(t, "available credit is already 50.00 USD", runs[2].Error)
+	})
+
+	t.Run("add to", func(t *testing.T) {
+		allowanceSvc, cardSvc, repo := newService()
+		schedule, err := allowanceSvc.CreateAllowanceSchedule(ctx, acme.AllowanceSchedule{
+			CardID: "card1", Amount: usd("50.00"), Cadence: "@daily", Mode: acme.AllowanceAddTo,
+		})
+		require.NoError(t, err)
+
+		due(t, repo, schedule.ID)
+		require.NoError(t, allowanceSvc.RunAllowances(ctx))
+		assert.Equal(t, usd("70.00"), getCard(t, cardSvc, "card1").AvailableCredit)
+	})
+
+	t.Run("inactive card", func(t *testing.T) {
+		allowanceSvc, cardSvc, repo := newService()
+		schedule, err := allowanceSvc.CreateAllowanceSchedule(ctx, acme.AllowanceSchedule{
+			CardID: "card1", Amount: usd("50.00"), Cadence: "@daily", Mode: acme.AllowanceAddTo,
+		})
+		require.NoError(t, err)
+
+		require.NoError(t, cardSvc.UpdateCardStatus(ctx, "card1", acme.CardStatusFrozen))
+		due(t, repo, schedule.ID)
+		require.NoError(t, allowanceSvc.RunAllowances(ctx))
+		assert.Empty(t, balanceChanges(t, cardSvc, "card1"))
+		runs := allowanceRuns(t, repo)
+		require.Len(t, runs, 1)
+		assert.Equal(t, acme.AllowanceRunSkipped, runs[0].Status)
+		assert.Equal(t, "card is FROZEN", runs[0].Error)
+		assert.True(t, allowanceSchedule(t, repo, schedule.ID).NextRunAt.After(time.Now()))
+	})
+
+	t.Run("retried", func(t *testing.T) {
+		allowanceSvc, cardSvc, repo := newService()
+		schedule, err := allowanceSvc.CreateAllowanceSchedule(ctx, acme.AllowanceSchedule{
+			CardID: "card1", Amount: usd("50.00"), Cadence: "@daily", Mode: acme.AllowanceAddTo,
+		})
+		require.NoError(t, err)
+
+		cardSvc.adjustErr = errors.New("reap unavailable")
+		due(t, repo, schedule.ID)
+		scheduledAt := allowanceSchedule(t, repo, schedule.ID).NextRunAt
+		for i := 1; i <= 2; i++ {
+			require.NoError(t, allowanceSvc.RunAllowances(ctx))
+			runs := allowanceRuns(t, repo)
+			require.Len(t, runs, 1)
+			assert.Equal(t, acme.AllowanceRunFailed, runs[0].Status)
+			assert.Equal(t, i, runs[0].Attempts)
+			assert.Equal(t, scheduledAt, allowanceSchedule(t, repo, schedule.ID).NextRunAt)
+		}
+
+		// the last attempt moves on to the next run
+		require.NoError(t, allowanceSvc.RunAllowances(ctx))
+		assert.Equal(t, 3, allowanceRuns(t, repo)[0].Attempts)
+		assert.True(t, allowanceSchedule(t, repo, schedule.ID).NextRunAt.After(time.Now()))
+	})
+
+	t.Run("unrecorded run", func(t *testing.T) {
+		allowanceSvc, cardSvc, repo := newService()
+		schedule, err := allowanceSvc.CreateAllowanceSchedule(ctx, acme.AllowanceSchedule{
+			CardID: "card1", Amount: usd("50.00"), Cadence: "@daily", Mode: acme.AllowanceAddTo,
+		})
+		require.NoError(t, err)
+
+		// the card is topped up but the outcome is not saved
+		repo.updateErr = errors.New("database is down")
+		due(t, repo, schedule.ID)
+		scheduledAt := allowanceSchedule(t, repo, schedule.ID).NextRunAt
+		assert.ErrorIs(t, allowanceSvc.RunAllowances(ctx), repo.updateErr)
+		require.Len(t, balanceChanges(t, cardSvc, "card1"), 1)
+		assert.Equal(t, acme.AllowanceRunPending, allowanceRuns(t, repo)[0].Status)
+		assert.Equal(t, scheduledAt, allowanceSchedule(t, repo, schedule.ID).NextRunAt)
+
+		// the pending run is reconciled with the balance history
+		repo.updateErr = nil
+		require.NoError(t, allowanceSvc.RunAllowances(ctx))
+		assert.Len(t, balanceChanges(t, cardSvc, "card1"), 1)
+		assert.Equal(t, usd("70.00"), getCard(t, cardSvc, "card1").AvailableCredit)
+		runs := allowanceRuns(t, repo)
+		require.Len(t, runs, 1)
+		assert.Equal(t, acme.AllowanceRunSucceeded, runs[0].Status)
+		assert.Equal(t, balanceChanges(t, cardSvc, "card1")[0].ID, runs[0].AdjustmentID)
+		assert.Equal(t, 1, runs[0].Attempts)
+		assert.True(t, allowanceSchedule(t, repo, schedule.ID).NextRunAt.After(time.Now()))
+	})
+
+	t.Run("applied failure", func(t *testing.T) {
+		allowanceSvc, cardSvc, repo := newService()
+		schedule, err := allowanceSvc.CreateAllowanceSchedule(ctx, acme.AllowanceSchedule{
+			CardID: "card1", Amount: usd("50.00"), Cadence: "@daily", Mode: acme.AllowanceAddTo,
+		})
+		require.NoError(t, err)
+
+		// the top-up times out after Reap applied it
+		cardSvc.appliedErr = errors.New("context deadline exceeded")
+		due(t, repo, schedule.ID)
+		require.NoError(t, allowanceSvc.RunAllowances(ctx))
+		assert.Equal(t, acme.AllowanceRunFailed, allowanceRuns(t, repo)[0].Status)
+
+		cardSvc.appliedErr = nil
+		require.NoError(t, allowanceSvc.RunAllowances(ctx))
+		assert.Len(t, balanceChanges(t, cardSvc, "card1"), 1)
+		runs := allowanceRuns(t, repo)
+		require.Len(t, runs, 1)
+		assert.Equal(t, acme.AllowanceRunSucceeded, runs[0].Status)
+		assert.Empty(t, runs[0].Error)
+		assert.True(t, allowanceSchedule(t, repo, schedule.ID).NextRunAt.After(time.Now()))
+	})
+
+	t.Run("succeeded not run again", func(t *testing.T) {
+		allowanceSvc, cardSvc, repo := newService()
+		schedule, err := allowanceSvc.CreateAllowanceSchedule(ctx, acme.AllowanceSchedule{
+			CardID: "card1", Amount: usd("50.00"), Cadence: "@daily", Mode: acme.AllowanceAddTo,
+		})
+		require.NoError(t, err)
+
+		// the run succeeds but the schedule is not moved on
+		due(t, repo, schedule.ID)
+		repo.nextRunErr = errors.New("database is down")
+		assert.ErrorIs(t, allowanceSvc.RunAllowances(ctx), repo.nextRunErr)
+		assert.Equal(t, acme.AllowanceRunSucceeded, allowanceRuns(t, repo)[0].Status)
+
+		repo.nextRunErr = nil
+		require.NoError(t, allowanceSvc.RunAllowances(ctx))
+		assert.Len(t, balanceChanges(t, cardSvc, "card1"), 1)
+		assert.Equal(t, 1, allowanceRuns(t, repo)[0].Attempts)
+		assert.True(t, allowanceSchedule(t, repo, schedule.ID).NextRunAt.After(time.Now()))
+	})
+
+	t.Run("locked", func(t *testing.T) {
+		allowanceSvc, cardSvc, repo := newService()
+		schedule, err := allowanceSvc.CreateAllowanceSchedule(ctx, acme.AllowanceSchedule{
+			CardID: "card1", Amount: usd("50.00"), Cadence: "@daily", Mode: acme.AllowanceAddTo,
+		})
+		require.NoError(t, err)
+
+		// the schedule is run by another instance
+		due(t, repo, schedule.ID)
+		locked, err := repo.LockAllowanceSchedule(ctx, schedule.ID, func(context.Context) error {
+			return allowanceSvc.RunAllowances(ctx)
+		})
+		require.NoError(t, err)
+		assert.True(t, locked)
+		assert.Empty(t, balanceChanges(t, cardSvc, "card1"))
+		assert.Empty(t, allowanceRuns(t, repo))
+	})
+
+	t.Run("ended", func(t *testing.T) {
+		allowanceSvc, _, repo := newService()
+		today := time.Now().UTC().Truncate(24 * time.Hour)
+
+		// the schedule ends after the last run of the end date
+		schedule := &acme.AllowanceSchedule{
+			CardID: "card1", Amount: usd("50.00"), Cadence: "* * * * *", Mode: acme.AllowanceAddTo,
+			StartDate: today.AddDate(0, 0, -7),
+			EndDate:   today.AddDate(0, 0, -1),
+		}
+		require.NoError(t, repo.SaveAllowanceSchedule(ctx, schedule))
+		due(t, repo, schedule.ID)
+		require.NoError(t, allowanceSvc.RunAllowances(ctx))
+		require.Len(t, allowanceRuns(t, repo), 1)
+		assert.True(t, allowanceSchedule(t, repo, schedule.ID).NextRunAt.IsZero())
+	})
+}
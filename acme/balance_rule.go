@@ -0,0 +1,430 @@
+package acme
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/stevenferrer/acme-cards-api/reap"
+)
+
+var (
+	// ErrInvalidBalanceRule is returned for unknown kinds or invalid amounts
+	ErrInvalidBalanceRule = errors.New("invalid balance rule")
+	// ErrBalanceRuleNotFound is returned when the rule does not exist
+	ErrBalanceRuleNotFound = errors.New("balance rule not found")
+)
+
+// Balance rule kinds
+const (
+	// BalanceRuleAlert alerts the admins when the available credit drops
+	// below the threshold
+	BalanceRuleAlert = "alert"
+	// BalanceRuleTopUp tops up the card to the target balance when the
+	// available credit drops below the threshold
+	BalanceRuleTopUp = "top_up"
+)
+
+// Balance rule execution statuses
+const (
+	BalanceRuleExecutionAlerted = "alerted"
+	// BalanceRuleExecutionPending is a top-up sent to Reap whose outcome is
+	// not recorded yet, e.g. the process stopped, it counts toward the
+	// monthly limit since it may have been applied
+	BalanceRuleExecutionPending  = "pending"
+	BalanceRuleExecutionToppedUp = "topped_up"
+	// BalanceRuleExecutionSkipped is a top-up over the monthly limit or the
+	// amount available to allocate
+	BalanceRuleExecutionSkipped = "skipped"
+	BalanceRuleExecutionFailed  = "failed"
+)
+
+// BalanceRule is triggered when the available credit of the card drops
+// below the threshold, it triggers again once the card is above the
+// threshold.
+type BalanceRule struct {
+	ID        int64
+	CardID    string
+	Kind      string
+	Threshold Money
+	// TopUpTo and MonthlyLimit are only set on the top-up rules, the top-ups
+	// of a calendar month (UTC) add up to at most MonthlyLimit
+	TopUpTo      Money
+	MonthlyLimit Money
+	// TriggeredAt is set while the card is below the threshold
+	TriggeredAt time.Time
+	CreatedAt   time.Time
+}
+
+// BalanceRuleExecution is a triggered balance rule
+type BalanceRuleExecution struct {
+	ID     int64
+	RuleID int64
+	CardID string
+	Kind   string
+	Status string
+	// AvailableCredit is the card balance that triggered the rule
+	AvailableCredit Money
+	// Amount and AdjustmentID are only set on the top-ups
+	Amount       Money
+	AdjustmentID string
+	// Reason is why the top-up was skipped or failed
+	Reason    string
+	CreatedAt time.Time
+}
+
+type BalanceRuleExecutionFilter struct {
+	CardID string
+	RuleID int64
+	// Since excludes the older executions when non-zero
+	Since time.Time
+}
+
+type BalanceRuleRepository interface {
+	// SaveBalanceRule saves the rule and sets its ID and creation time
+	SaveBalanceRule(context.Context, *BalanceRule) error
+	GetBalanceRule(ctx context.Context, id int64) (*BalanceRule, error)
+	// FindBalanceRules returns the rules of the card, every rule is returned
+	// when cardID is empty
+	FindBalanceRules(ctx context.Context, cardID string) ([]BalanceRule, error)
+	DeleteBalanceRule(ctx context.Context, id int64) error
+
+	// ClaimBalanceRules returns the rules that are not leased and leases
+	// them so a rule is evaluated by one instance at a time
+	ClaimBalanceRules(ctx context.Context, now time.Time, lease time.Duration) ([]BalanceRule, error)
+	// ReleaseBalanceRule saves the trigger time of the rule and releases
+	// its lease
+	ReleaseBalanceRule(context.Context, BalanceRule) error
+
+	// SaveBalanceRuleExecution saves the execution and sets its ID
+	SaveBalanceRuleExecution(context.Context, *BalanceRuleExecution) error
+	// UpdateBalanceRuleExecution saves the status, the adjustment ID and the
+	// reason of the execution
+	UpdateBalanceRuleExecution(context.Context, BalanceRuleExecution) error
+	// FindBalanceRuleExecutions returns the executions, latest first
+	FindBalanceRuleExecutions(context.Context, BalanceRuleExecutionFilter) ([]BalanceRuleExecution, error)
+}
+
+type BalanceRuleService interface {
+	CreateBalanceRule(context.Context, BalanceRule) (*BalanceRule, error)
+	ListBalanceRules(ctx context.Context, cardID string) ([]BalanceRule, error)
+	DeleteBalanceRule(ctx context.Context, id int64) error
+	ListBalanceRuleExecutions(context.Context, BalanceRuleExecutionFilter) ([]BalanceRuleExecution, error)
+
+	// EvaluateBalanceRules checks the available credit of the cards with
+	// rules, alerts the admins and tops up the cards
+	EvaluateBalanceRules(context.Context) error
+}
+
+// balanceRuleLease must outlast the evaluation of every rule
+const balanceRuleLease = 5 * time.Minute
+
+// CardBalanceRuleService implements BalanceRuleService
+type CardBalanceRuleService struct {
+	cardSvc  CardService
+	ruleRepo BalanceRuleRepository
+	alerter  Alerter
+}
+
+var _ BalanceRuleService = (*CardBalanceRuleService)(nil)
+
+func NewCardBalanceRuleService(
+	cardSvc CardService,
+	ruleRepo BalanceRuleRepository,
+	alerter Alerter,
+) *CardBalanceRuleService {
+	return &CardBalanceRuleService{
+		cardSvc:  cardSvc,
+		ruleRepo: ruleRepo,
+		alerter:  alerter,
+	}
+}
+
+func (s *CardBalanceRuleService) CreateBalanceRule(ctx context.Context, rule BalanceRule) (*BalanceRule, error) {
+	if rule.CardID == "" {
+		return nil, fmt.Errorf("%w: card id is required", ErrInvalidBalanceRule)
+	}
+
+	err := validateBalanceRuleAmount("threshold", rule.Threshold)
+	if err != nil {
+		return nil, err
+	}
+
+	switch rule.Kind {
+	case BalanceRuleAlert:
+		rule.TopUpTo, rule.MonthlyLimit = Money{}, Money{}
+	case BalanceRuleTopUp:
+		err = validateBalanceRuleAmount("top up to", rule.TopUpTo)
+		if err != nil {
+			return nil, err
+		}
+		cmp, err := rule.TopUpTo.Cmp(rule.Threshold)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidBalanceRule, err)
+		}
+		if cmp <= 0 {
+			return nil, fmt.Errorf("%w: top up to must be above the threshold", ErrInvalidBalanceRule)
+		}
+
+		err = validateBalanceRuleAmount("monthly limit", rule.MonthlyLimit)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("%w: kind %q", ErrInvalidBalanceRule, rule.Kind)
+	}
+
+	_, err = s.cardSvc.GetCard(ctx, rule.CardID)
+	if err != nil {
+		return nil, fmt.Errorf("get card: %w", err)
+	}
+
+	rule.TriggeredAt = time.Time{}
+	err = s.ruleRepo.SaveBalanceRule(ctx, &rule)
+	if err != nil {
+		return nil, fmt.Errorf("save balance rule: %w", err)
+	}
+
+	return &rule, nil
+}
+
+// validateBalanceRuleAmount checks that the amount is positive and in the
+// currency of the card balances
+func validateBalanceRuleAmount(name string, amount Money) error {
+	if amount.Sign() <= 0 {
+		return fmt.Errorf("%w: %s must be positive", ErrInvalidBalanceRule, name)
+	}
+
+	if amount.Currency() != reap.AccountCurrency {
+		return fmt.Errorf("%w: %s must be in %s", ErrInvalidBalanceRule, name, reap.AccountCurrency)
+	}
+
+	return nil
+}
+
+func (s *CardBalanceRuleService) ListBalanceRules(ctx context.Context, cardID string) ([]BalanceRule, error) {
+	return s.ruleRepo.FindBalanceRules(ctx, cardID)
+}
+
+func (s *CardBalanceRuleService) DeleteBalanceRule(ctx context.Context, id int64) error {
+	return s.ruleRepo.DeleteBalanceRule(ctx, id)
+}
+
+func (s *CardBalanceRuleService) ListBalanceRuleExecutions(ctx context.Context, filter BalanceRuleExecutionFilter) ([]BalanceRuleExecution, error) {
+	return s.ruleRepo.FindBalanceRuleExecutions(ctx, filter)
+}
+
+func (s *CardBalanceRuleService) EvaluateBalanceRules(ctx context.Context) error {
+	now := time.Now().UTC()
+	rules, err := s.ruleRepo.ClaimBalanceRules(ctx, now, balanceRuleLease)
+	if err != nil {
+		return fmt.Errorf("claim balance rules: %w", err)
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+
+	// the amount available to allocate is shared by the top-ups
+	var account *AccountBalance
+	if slices.ContainsFunc(rules, func(rule BalanceRule) bool { return rule.Kind == BalanceRuleTopUp }) {
+		account, err = s.cardSvc.GetAccountBalance(ctx)
+		if err != nil {
+			// the rules are released for the next evaluation
+			err = fmt.Errorf("get account balance: %w", err)
+			for _, rule := range rules {
+				err = errors.Join(err, s.ruleRepo.ReleaseBalanceRule(ctx, rule))
+			}
+			return err
+		}
+	}
+
+	var errs []error
+	for _, rule := range rules {
+		err = s.evaluate(ctx, &rule, account)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("balance rule %d: %w", rule.ID, err))
+		}
+
+		err = s.ruleRepo.ReleaseBalanceRule(ctx, rule)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("release balance rule %d: %w", rule.ID, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// evaluate alerts or tops up the card once per drop below the threshold,
+// top-ups that were skipped or failed are retried until they succeed. A
+// skipped top-up is recorded once, every top-up sent to Reap is recorded.
+func (s *CardBalanceRuleService) evaluate(ctx context.Context, rule *BalanceRule, account *AccountBalance) error {
+	card, err := s.cardSvc.GetCard(ctx, rule.CardID)
+	if err != nil {
+		return fmt.Errorf("get card: %w", err)
+	}
+
+	if card.Status != CardStatusActive {
+		return nil
+	}
+
+	cmp, err := card.AvailableCredit.Cmp(rule.Threshold)
+	if err != nil {
+		return fmt.Errorf("compare threshold: %w", err)
+	}
+	if cmp >= 0 {
+		rule.TriggeredAt = time.Time{}
+		return nil
+	}
+
+	triggered := !rule.TriggeredAt.IsZero()
+	now := time.Now().UTC()
+	execution := BalanceRuleExecution{
+		RuleID:          rule.ID,
+		CardID:          rule.CardID,
+		Kind:            rule.Kind,
+		AvailableCredit: card.AvailableCredit,
+		CreatedAt:       now,
+	}
+
+	switch rule.Kind {
+	case BalanceRuleAlert:
+		if triggered {
+			return nil
+		}
+
+		execution.Status = BalanceRuleExecutionAlerted
+		err = s.saveExecution(ctx, rule, &execution)
+		if err != nil {
+			return err
+		}
+
+		err = s.alerter.AlertLowBalance(ctx, execution)
+		if err != nil {
+			return fmt.Errorf("alert low balance: %w", err)
+		}
+
+		return nil
+	case BalanceRuleTopUp:
+		err = s.topUp(ctx, *rule, card, account, &execution)
+		if err != nil {
+			return err
+		}
+
+		if execution.Status == BalanceRuleExecutionSkipped && triggered {
+			return nil
+		}
+
+		return s.saveExecution(ctx, rule, &execution)
+	default:
+		return fmt.Errorf("unknown balance rule kind %q", rule.Kind)
+	}
+}
+
+// topUp tops up the card to the target balance within the monthly limit
+// and the amount available to allocate, the outcome is set on the
+// execution. The execution is saved pending before the card is topped up so
+// that the monthly limit counts the top-up when its outcome is not saved.
+func (s *CardBalanceRuleService) topUp(ctx context.Context, rule BalanceRule, card *Card, account *AccountBalance, execution *BalanceRuleExecution) error {
+	now := execution.CreatedAt
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	executions, err := s.ruleRepo.FindBalanceRuleExecutions(ctx, BalanceRuleExecutionFilter{
+		RuleID: rule.ID,
+		Since:  monthStart,
+	})
+	if err != nil {
+		return fmt.Errorf("find balance rule executions: %w", err)
+	}
+
+	toppedUp := NewMoney(0, rule.MonthlyLimit.Currency())
+	for _, e := range executions {
+		if e.Status == BalanceRuleExecutionToppedUp || e.Status == BalanceRuleExecutionPending {
+			toppedUp, err = toppedUp.Add(e.Amount)
+			if err != nil {
+				return fmt.Errorf("execution %d amount: %w", e.ID, err)
+			}
+		}
+	}
+
+	amount, err := rule.TopUpTo.Sub(card.AvailableCredit)
+	if err != nil {
+		return fmt.Errorf("top up to: %w", err)
+	}
+
+	remaining, err := rule.MonthlyLimit.Sub(toppedUp)
+	if err != nil {
+		return fmt.Errorf("monthly limit: %w", err)
+	}
+
+	cmp, err := remaining.Cmp(amount)
+	if err != nil {
+		return fmt.Errorf("compare monthly limit: %w", err)
+	}
+	if cmp < 0 {
+		amount = remaining
+	}
+
+	if amount.Sign() <= 0 {
+		execution.Status = BalanceRuleExecutionSkipped
+		execution.Reason = fmt.Sprintf("monthly limit of %s reached", rule.MonthlyLimit)
+		return nil
+	}
+	execution.Amount = amount
+
+	available, err := account.AvailableToAllocate.Sub(amount)
+	if err != nil {
+		return fmt.Errorf("available to allocate: %w", err)
+	}
+	if available.Sign() < 0 {
+		execution.Status = BalanceRuleExecutionSkipped
+		execution.Reason = fmt.Sprintf("top-up of %s exceeds the %s available to allocate", execution.Amount, account.AvailableToAllocate)
+		return nil
+	}
+
+	execution.Status = BalanceRuleExecutionPending
+	err = s.ruleRepo.SaveBalanceRuleExecution(ctx, execution)
+	if err != nil {
+		return fmt.Errorf("save balance rule execution: %w", err)
+	}
+
+	resp, err := s.cardSvc.AdjustCardBalance(ctx, rule.CardID, AdjustCardBalanceParams{
+		Type:   BalanceAdjustmentTopUp,
+		Amount: execution.Amount,
+	})
+	if err != nil {
+		execution.Status = BalanceRuleExecutionFailed
+		execution.Reason = err.Error()
+		return nil
+	}
+
+	execution.Status = BalanceRuleExecutionToppedUp
+	execution.AdjustmentID = resp.ID
+	account.AvailableToAllocate = available
+
+	return nil
+}
+
+// saveExecution records the execution, or the outcome of a pending top-up,
+// and marks the rule triggered, rules that topped up the card are above the
+// threshold again
+func (s *CardBalanceRuleService) saveExecution(ctx context.Context, rule *BalanceRule, execution *BalanceRuleExecution) error {
+	if execution.ID == 0 {
+		err := s.ruleRepo.SaveBalanceRuleExecution(ctx, execution)
+		if err != nil {
+			return fmt.Errorf("save balance rule execution: %w", err)
+		}
+	} else {
+		err := s.ruleRepo.UpdateBalanceRuleExecution(ctx, *execution)
+		if err != nil {
+			return fmt.Errorf("update balance rule execution: %w", err)
+		}
+	}
+
+	rule.TriggeredAt = execution.CreatedAt
+	if execution.Status == BalanceRuleExecutionToppedUp {
+		rule.TriggeredAt = time.Time{}
+	}
+
+	return nil
+}
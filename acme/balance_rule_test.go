@@ -0,0 +1,300 @@
+package acme_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/acme/memory"
+)
+
+// failingBalanceRuleRepository fails to update the executions while
+// updateErr is set
+type failingBalanceRuleRepository struct {
+	*memory.BalanceRuleRepository
+	updateErr error
+}
+
+func (r *failingBalanceRuleRepository) UpdateBalanceRuleExecution(ctx context.Context, e acme.BalanceRuleExecution) error {
+	if r.updateErr != nil {
+		return r.updateErr
+	}
+	return r.BalanceRuleRepository.UpdateBalanceRuleExecution(ctx, e)
+}
+
+func balanceRules(t *testing.T, ruleRepo acme.BalanceRuleRepository) []acme.BalanceRule {
+	t.Helper()
+
+	rules, err := ruleRepo.FindBalanceRules(context.Background(), "")
+	require.NoError(t, err)
+	return rules
+}
+
+// balanceRuleExecutions returns the executions, oldest first
+func balanceRuleExecutions(t *testing.T, ruleRepo acme.BalanceRuleRepository) []acme.BalanceRuleExecution {
+	t.Helper()
+
+	executions, err := ruleRepo.FindBalanceRuleExecutions(context.Background(), acme.BalanceRuleExecutionFilter{})
+	require.NoError(t, err)
+	slices.Reverse(executions)
+	return executions
+}
+
+func TestBalanceRuleService(t *testing.T) {
+	ctx := context.Background()
+	usd := func(amount string) acme.Money { return acme.MustParseMoney(amount, "USD") }
+
+	newService := func() (*acme.CardBalanceRuleService, *fakeCardService, *memory.BalanceRuleRepository, *recordingAlerter) {
+		cardSvc := newCardService(acme.Card{ID: "card1", Status: acme.CardStatusActive, AvailableCredit: usd("50.00")})
+		ruleRepo := memory.NewBalanceRuleRepository()
+		alerter := &recordingAlerter{}
+		return acme.NewCardBalanceRuleService(cardSvc, ruleRepo, alerter), cardSvc, ruleRepo, alerter
+	}
+
+	t.Run("invalid rules", func(t *testing.T) {
+		ruleSvc, _, _, _ := newService()
+		for _, rule := range []acme.BalanceRule{
+			{Kind: acme.BalanceRuleAlert, Threshold: usd("20.00")},
+			{CardID: "card1", Kind: "notify", Threshold: usd("20.00")},
+			{CardID: "card1", Kind: acme.BalanceRuleAlert, Threshold: acme.MustParseMoney("20.00", "EUR")},
+			{CardID: "card1", Kind: acme.BalanceRuleAlert},
+			{CardID: "card1", Kind: acme.BalanceRuleTopUp, Threshold: usd("20.00"), TopUpTo: usd("10.00"), MonthlyLimit: usd("100.00")},
+			{CardID: "card1", Kind: acme.BalanceRuleTopUp, Threshold: usd("20.00"), TopUpTo: usd("100.00")},
+		} {
+			_, err := ruleSvc.CreateBalanceRule(ctx, rule)
+			assert.ErrorIs(t, err, acme.ErrInvalidBalanceRule)
+		}
+
+		_, err := ruleSvc.CreateBalanceRule(ctx, acme.BalanceRule{CardID: "card2", Kind: acme.BalanceRuleAlert, Threshold: usd("20.00")})
+		assert.ErrorIs(t, err, acme.ErrCardNotFound)
+	})
+
+	t.Run("alert", func(t *testing.T) {
+		ruleSvc, cardSvc, ruleRepo, alerter := newService()
+		_, err := ruleSvc.CreateBalanceRule(ctx, acme.BalanceRule{CardID: "card1", Kind: acme.BalanceRuleAlert, Threshold: usd("20.00")})
+		require.NoError(t, err)
+
+		require.NoError(t, ruleSvc.EvaluateBalanceRules(ctx))
+		assert.Empty(t, alerter.lowBalances)
+
+		setAvailableCredit(t, cardSvc, "card1", usd("15.00"))
+		require.NoError(t, ruleSvc.EvaluateBalanceRules(ctx))
+		require.Len(t, alerter.lowBalances, 1)
+		assert.Equal(t, usd("15.00"), alerter.lowBalances[0].AvailableCredit)
+
+		// alerted once per drop below the threshold
+		require.NoError(t, ruleSvc.EvaluateBalanceRules(ctx))
+		assert.Len(t, alerter.lowBalances, 1)
+
+		setAvailableCredit(t, cardSvc, "card1", usd("30.00"))
+		require.NoError(t, ruleSvc.EvaluateBalanceRules(ctx))
+		assert.True(t, balanceRules(t, ruleRepo)[0].TriggeredAt.IsZero())
+
+		setAvailableCredit(t, cardSvc, "card1", usd("10.00"))
+		require.NoError(t, ruleSvc.EvaluateBalanceRules(ctx))
+		assert.Len(t, alerter.lowBalances, 2)
+		assert.Len(t, balanceRuleExecutions(t, ruleRepo), 2)
+	})
+
+	t.Run("top up", func(t *testing.T) {
+		ruleSvc, cardSvc, ruleRepo, _ := newService()
+		_, err := ruleSvc.CreateBalanceRule(ctx, acme.BalanceRule{
+			CardID:       "card1",
+			Kind:         acme.BalanceRuleTopUp,
+			Threshold:    usd("20.00"),
+			TopUpTo:      usd("100.00"),
+			MonthlyLimit: usd("150.00"),
+		})
+		require.NoError(t, err)
+
+		setAvailableCredit(t, cardSvc, "card1", usd("10.00"))
+		require.NoError(t, ruleSvc.EvaluateBalanceRules(ctx))
+		require.Len(t, balanceChanges(t, cardSvc, "card1"), 1)
+		assert.Equal(t, acme.BalanceAdjustmentTopUp, balanceChanges(t, cardSvc, "card1")[0].Type)
+		assert.Equal(t, usd("90.00"), balanceChanges(t, cardSvc, "card1")[0].Amount)
+		assert.Equal(t, usd("100.00"), getCard(t, cardSvc, "card1").AvailableCredit)
+
+		require.Len(t, balanceRuleExecutions(t, ruleRepo), 1)
+		assert.Equal(t, acme.BalanceRuleExecutionToppedUp, balanceRuleExecutions(t, ruleRepo)[0].Status)
+		assert.Equal(t, "adjustment1", balanceRuleExecutions(t, ruleRepo)[0].AdjustmentID)
+		assert.True(t, balanceRules(t, ruleRepo)[0].TriggeredAt.IsZero())
+
+		// the monthly limit caps the second top-up
+		setAvailableCredit(t, cardSvc, "card1", usd("5.00"))
+		require.NoError(t, ruleSvc.EvaluateBalanceRules(ctx))
+		require.Len(t, balanceChanges(t, cardSvc, "card1"), 2)
+		assert.Equal(t, usd("60.00"), balanceChanges(t, cardSvc, "card1")[1].Amount)
+
+		// the limit is reached
+		setAvailableCredit(t, cardSvc, "card1", usd("5.00"))
+		require.NoError(t, ruleSvc.EvaluateBalanceRules(ctx))
+		assert.Len(t, balanceChanges(t, cardSvc, "card1"), 2)
+		require.Len(t, balanceRuleExecutions(t, ruleRepo), 3)
+		assert.Equal(t, acme.BalanceRuleExecutionSkipped, balanceRuleExecutions(t, ruleRepo)[2].Status)
+		assert.Equal(t, "monthly limit of 150.00 USD reached", balanceRuleExecutions(t, ruleRepo)[2].Reason)
+
+		// skipped top-ups are recorded once
+		require.NoError(t, ruleSvc.EvaluateBalanceRules(ctx))
+		assert.Len(t, balanceRuleExecutions(t, ruleRepo), 3)
+	})
+
+	t.Run("insufficient funds", func(t *testing.T) {
+		ruleSvc, cardSvc, ruleRepo, _ := newService()
+		_, err := ruleSvc.CreateBalanceRule(ctx, acme.BalanceRule{
+			CardID:       "card1",
+			Kind:         acme.BalanceRuleTopUp,
+			Threshold:    usd("20.00"),
+			TopUpTo:      usd("100.00"),
+			MonthlyLimit: usd("500.00"),
+		})
+		require.NoError(t, err)
+
+		setAvailableCredit(t, cardSvc, "card1", usd("10.00"))
+		cardSvc.setAccountBalance(acme.AccountBalance{AvailableToAllocate: usd("50.00")})
+		require.NoError(t, ruleSvc.EvaluateBalanceRules(ctx))
+		assert.Empty(t, balanceChanges(t, cardSvc, "card1"))
+		require.Len(t, balanceRuleExecutions(t, ruleRepo), 1)
+		assert.Equal(t, acme.BalanceRuleExecutionSkipped, balanceRuleExecutions(t, ruleRepo)[0].Status)
+		assert.Equal(t, "top-up of 90.00 USD exceeds the 50.00 USD available to allocate", balanceRuleExecutions(t, ruleRepo)[0].Reason)
+
+		// the top-up is retried once the account is funded
+		cardSvc.setAccountBalance(acme.AccountBalance{AvailableToAllocate: usd("1000.00")})
+		require.NoError(t, ruleSvc.EvaluateBalanceRules(ctx))
+		assert.Len(t, balanceChanges(t, cardSvc, "card1"), 1)
+		require.Len(t, balanceRuleExecutions(t, ruleRepo), 2)
+		assert.Equal(t, acme.BalanceRuleExecutionToppedUp, balanceRuleExecutions(t, ruleRepo)[1].Status)
+	})
+
+	t.Run("failed top up", func(t *testing.T) {
+		ruleSvc, cardSvc, ruleRepo, _ := newService()
+		_, err := ruleSvc.CreateBalanceRule(ctx, acme.BalanceRule{
+			CardID:       "card1",
+			Kind:         acme.BalanceRuleTopUp,
+			Threshold:    usd("20.00"),
+			TopUpTo:      usd("100.00"),
+			MonthlyLimit: usd("500.00"),
+		})
+		require.NoError(t, err)
+
+		setAvailableCredit(t, cardSvc, "card1", usd("10.00"))
+		cardSvc.adjustErr = errors.New("reap unavailable")
+		require.NoError(t, ruleSvc.EvaluateBalanceRules(ctx))
+		require.Len(t, balanceRuleExecutions(t, ruleRepo), 1)
+		assert.Equal(t, acme.BalanceRuleExecutionFailed, balanceRuleExecutions(t, ruleRepo)[0].Status)
+		assert.Equal(t, "reap unavailable", balanceRuleExecutions(t, ruleRepo)[0].Reason)
+		assert.Empty(t, balanceChanges(t, cardSvc, "card1"))
+
+		// every attempt sent to Reap is recorded
+		require.NoError(t, ruleSvc.EvaluateBalanceRules(ctx))
+		require.Len(t, balanceRuleExecutions(t, ruleRepo), 2)
+		assert.Equal(t, acme.BalanceRuleExecutionFailed, balanceRuleExecutions(t, ruleRepo)[1].Status)
+
+		// the top-up is retried while the card is below the threshold
+		cardSvc.adjustErr = nil
+		require.NoError(t, ruleSvc.EvaluateBalanceRules(ctx))
+		require.Len(t, balanceRuleExecutions(t, ruleRepo), 3)
+		assert.Equal(t, acme.BalanceRuleExecutionToppedUp, balanceRuleExecutions(t, ruleRepo)[2].Status)
+		assert.Equal(t, usd("100.00"), getCard(t, cardSvc, "card1").AvailableCredit)
+	})
+
+	t.Run("unrecorded top up", func(t *testing.T) {
+		cardSvc := newCardService(acme.Card{ID: "card1", Status: acme.CardStatusActive, AvailableCredit: usd("50.00")})
+		ruleRepo := &failingBalanceRuleRepository{BalanceRuleRepository: memory.NewBalanceRuleRepository()}
+		ruleSvc := acme.NewCardBalanceRuleService(cardSvc, ruleRepo, &recordingAlerter{})
+		_, err := ruleSvc.CreateBalanceRule(ctx, acme.BalanceRule{
+			CardID:       "card1",
+			Kind:         acme.BalanceRuleTopUp,
+			Threshold:    usd("20.00"),
+			TopUpTo:      usd("100.00"),
+			MonthlyLimit: usd("150.00"),
+		})
+		require.NoError(t, err)
+
+		// the card is topped up but its outcome is not saved
+		setAvailableCredit(t, cardSvc, "card1", usd("10.00"))
+		ruleRepo.updateErr = errors.New("database is down")
+		err = ruleSvc.EvaluateBalanceRules(ctx)
+		assert.ErrorIs(t, err, ruleRepo.updateErr)
+		require.Len(t, balanceChanges(t, cardSvc, "card1"), 1)
+		require.Len(t, balanceRuleExecutions(t, ruleRepo), 1)
+		assert.Equal(t, acme.BalanceRuleExecutionPending, balanceRuleExecutions(t, ruleRepo)[0].Status)
+
+		// the pending top-up counts toward the monthly limit
+		ruleRepo.updateErr = nil
+		setAvailableCredit(t, cardSvc, "card1", usd("5.00"))
+		require.NoError(t, ruleSvc.EvaluateBalanceRules(ctx))
+		require.Len(t, balanceChanges(t, cardSvc, "card1"), 2)
+		assert.Equal(t, usd("60.00"), balanceChanges(t, cardSvc, "card1")[1].Amount)
+		require.Len(t, balanceRuleExecutions(t, ruleRepo), 2)
+		assert.Equal(t, acme.BalanceRuleExecutionToppedUp, balanceRuleExecutions(t, ruleRepo)[1].Status)
+	})
+
+	t.Run("refund above the threshold", func(t *testing.T) {
+		ruleSvc, cardSvc, ruleRepo, _ := newService()
+		_, err := ruleSvc.CreateBalanceRule(ctx, acme.BalanceRule{
+			CardID:       "card1",
+			Kind:         acme.BalanceRuleTopUp,
+			Threshold:    usd("20.00"),
+			TopUpTo:      usd("100.00"),
+			MonthlyLimit: usd("500.00"),
+		})
+		require.NoError(t, err)
+
+		setAvailableCredit(t, cardSvc, "card1", usd("10.00"))
+		cardSvc.adjustErr = errors.New("reap unavailable")
+		require.NoError(t, ruleSvc.EvaluateBalanceRules(ctx))
+		assert.False(t, balanceRules(t, ruleRepo)[0].TriggeredAt.IsZero())
+
+		// a refund brought the card back above the threshold, the failed
+		// top-up is not retried
+		cardSvc.adjustErr = nil
+		setAvailableCredit(t, cardSvc, "card1", usd("25.00"))
+		require.NoError(t, ruleSvc.EvaluateBalanceRules(ctx))
+		assert.Empty(t, balanceChanges(t, cardSvc, "card1"))
+		assert.Len(t, balanceRuleExecutions(t, ruleRepo), 1)
+		assert.True(t, balanceRules(t, ruleRepo)[0].TriggeredAt.IsZero())
+
+		// and the rule triggers again on the next drop
+		setAvailableCredit(t, cardSvc, "card1", usd("15.00"))
+		require.NoError(t, ruleSvc.EvaluateBalanceRules(ctx))
+		require.Len(t, balanceChanges(t, cardSvc, "card1"), 1)
+		assert.Equal(t, usd("85.00"), balanceChanges(t, cardSvc, "card1")[0].Amount)
+	})
+
+	t.Run("failed alert", func(t *testing.T) {
+		ruleSvc, cardSvc, ruleRepo, alerter := newService()
+		_, err := ruleSvc.CreateBalanceRule(ctx, acme.BalanceRule{CardID: "card1", Kind: acme.BalanceRuleAlert, Threshold: usd("20.00")})
+		require.NoError(t, err)
+
+		setAvailableCredit(t, cardSvc, "card1", usd("15.00"))
+		alerter.err = errors.New("smtp is down")
+		err = ruleSvc.EvaluateBalanceRules(ctx)
+		assert.ErrorContains(t, err, "smtp is down")
+
+		// the execution is recorded and the alert is not sent again
+		require.Len(t, balanceRuleExecutions(t, ruleRepo), 1)
+		assert.Equal(t, acme.BalanceRuleExecutionAlerted, balanceRuleExecutions(t, ruleRepo)[0].Status)
+
+		alerter.err = nil
+		require.NoError(t, ruleSvc.EvaluateBalanceRules(ctx))
+		assert.Empty(t, alerter.lowBalances)
+		assert.Len(t, balanceRuleExecutions(t, ruleRepo), 1)
+	})
+
+	t.Run("inactive cards", func(t *testing.T) {
+		ruleSvc, cardSvc, ruleRepo, alerter := newService()
+		_, err := ruleSvc.CreateBalanceRule(ctx, acme.BalanceRule{CardID: "card1", Kind: acme.BalanceRuleAlert, Threshold: usd("20.00")})
+		require.NoError(t, err)
+
+		require.NoError(t, cardSvc.UpdateCardStatus(ctx, "card1", acme.CardStatusFrozen))
+		setAvailableCredit(t, cardSvc, "card1", usd("10.00"))
+		require.NoError(t, ruleSvc.EvaluateBalanceRules(ctx))
+		assert.Empty(t, alerter.lowBalances)
+		assert.Empty(t, balanceRuleExecutions(t, ruleRepo))
+	})
+}
@@ -0,0 +1,262 @@
+package acme
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// ErrInvalidCardMode is returned for unknown card modes or invalid mode
+// options e.g. a merchant for a single-use card
+var ErrInvalidCardMode = errors.New("invalid card mode")
+
+// Card modes
+const (
+	CardModeStandard = "standard"
+	// CardModeSingleUse cards are terminated after their first settled
+	// transaction
+	CardModeSingleUse = "single_use"
+	// CardModeMerchantLocked cards are frozen when used at any other
+	// merchant than the locked one
+	CardModeMerchantLocked = "merchant_locked"
+)
+
+// BurnerCard is a single-use or merchant-locked card. Reap has no such
+// cards so the modes are enforced by monitoring the card transactions.
+type BurnerCard struct {
+	CardID string
+	Mode   string
+	// MerchantID is the merchant of a merchant-locked card, the expiry of
+	// the card is its CardExpiry
+	MerchantID string
+	// FrozenAt is the last time the card was frozen for a transaction at
+	// another merchant, earlier transactions are not checked again
+	FrozenAt time.Time
+	// CheckedAt is the last time the transactions of the card were checked,
+	// the next check starts burnerCardSettlementLookback before it
+	CheckedAt time.Time
+	// EndedAt is set once the card was terminated and is no longer
+	// monitored
+	EndedAt   time.Time
+	EndReason string
+	CreatedAt time.Time
+}
+
+// IsEnded reports whether the card is no longer monitored.
+func (c BurnerCard) IsEnded() bool {
+	return !c.EndedAt.IsZero()
+}
+
+type BurnerCardRepository interface {
+	// SaveBurnerCard saves the card and sets its creation time, saving a
+	// card again keeps the saved card so that an interrupted card creation
+	// can be completed
+	SaveBurnerCard(context.Context, *BurnerCard) error
+	// FindBurnerCards returns the cards, latest first, the ended cards are
+	// omitted when active is true
+	FindBurnerCards(ctx context.Context, active bool) ([]BurnerCard, error)
+	// UpdateBurnerCard saves the last check, the freeze and the end of the
+	// card
+	UpdateBurnerCard(context.Context, BurnerCard) error
+}
+
+type BurnerCardService interface {
+	ListBurnerCards(ctx context.Context, active bool) ([]BurnerCard, error)
+
+	// EnforceBurnerCards checks the transactions of the active burner
+	// cards, terminates the used single-use cards and freezes the
+	// merchant-locked cards used at another merchant, the terminated cards
+	// are no longer monitored
+	EnforceBurnerCards(context.Context) error
+}
+
+// validateCardMode checks the mode options of the card to create, the mode
+// defaults to standard and the expiry action of the single-use and
+// merchant-locked cards to terminating them.
+func validateCardMode(params *CreateCardParams) error {
+	switch params.Mode {
+	case "":
+		params.Mode = CardModeStandard
+	case CardModeStandard, CardModeSingleUse, CardModeMerchantLocked:
+	default:
+		return fmt.Errorf("%w: %q", ErrInvalidCardMode, params.Mode)
+	}
+
+	if params.Mode != CardModeMerchantLocked && params.MerchantID != "" {
+		return fmt.Errorf("%w: merchant id is only for merchant-locked cards", ErrInvalidCardMode)
+	}
+
+	if params.Mode == CardModeMerchantLocked && params.MerchantID == "" {
+		return fmt.Errorf("%w: merchant id is required for merchant-locked cards", ErrInvalidCardMode)
+	}
+
+	if params.Mode != CardModeStandard && !params.ValidUntil.IsZero() && params.ExpiryAction == "" {
+		params.ExpiryAction = CardStatusTerminated
+	}
+
+	return nil
+}
+
+// burnerCardSettlementLookback rescans the transactions before the last
+// check of a card for the transactions that settled after it
+const burnerCardSettlementLookback = 7 * 24 * time.Hour
+
+// TransactionBurnerCardService implements BurnerCardService
+type TransactionBurnerCardService struct {
+	txSource   TransactionSource
+	cardSvc    CardService
+	burnerRepo BurnerCardRepository
+}
+
+var _ BurnerCardService = (*TransactionBurnerCardService)(nil)
+
+func NewTransactionBurnerCardService(
+	txSource TransactionSource,
+	cardSvc CardService,
+	burnerRepo BurnerCardRepository,
+) *TransactionBurnerCardService {
+	return &TransactionBurnerCardService{
+		txSource:   txSource,
+		cardSvc:    cardSvc,
+		burnerRepo: burnerRepo,
+	}
+}
+
+func (s *TransactionBurnerCardService) ListBurnerCards(ctx context.Context, active bool) ([]BurnerCard, error) {
+	return s.burnerRepo.FindBurnerCards(ctx, active)
+}
+
+func (s *TransactionBurnerCardService) EnforceBurnerCards(ctx context.Context) error {
+	cards, err := s.burnerRepo.FindBurnerCards(ctx, true)
+	if err != nil {
+		return fmt.Errorf("find burner cards: %w", err)
+	}
+
+	var errs []error
+	for _, card := range cards {
+		err = s.enforce(ctx, card)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("card %q: %w", card.CardID, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// enforce terminates or freezes the card, the transactions are walked from
+// the last check of the card, or its creation, so that none is missed while
+// the server is down.
+func (s *TransactionBurnerCardService) enforce(ctx context.Context, card BurnerCard) error {
+	status, err := s.cardStatus(ctx, card.CardID)
+	if err != nil {
+		return err
+	}
+
+	from := card.CreatedAt
+	if rescan := card.CheckedAt.Add(-burnerCardSettlementLookback); rescan.After(from) {
+		from = rescan
+	}
+
+	var txs []Transaction
+	err = s.txSource.EachCardTransaction(ctx, card.CardID, DateRange{From: from}, func(t Transaction) error {
+		if t.IsPosted() {
+			txs = append(txs, t)
+		}
+		return nil
+	})
+	// the transactions of a card that no longer exists may be gone too
+	if err != nil && status != "" {
+		return fmt.Errorf("each card transaction: %w", err)
+	}
+
+	slices.SortStableFunc(txs, func(a, b Transaction) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+
+	// a single-use card terminated on an earlier run, whose end could not
+	// be saved, keeps the transaction that ended it as its reason
+	settled := slices.IndexFunc(txs, Transaction.IsSettled)
+	if card.Mode == CardModeSingleUse && settled >= 0 {
+		return s.terminate(ctx, card, status, fmt.Sprintf("transaction %s settled", txs[settled].ID))
+	}
+
+	// e.g. the card expired or was terminated by an admin
+	if status == "" || status == CardStatusTerminated {
+		return s.terminate(ctx, card, status, "card terminated")
+	}
+
+	// the check is only saved once the card was handled so that a failed
+	// termination or freeze is retried on the next run
+	now := time.Now().UTC()
+	card.CheckedAt = now
+	if card.Mode == CardModeMerchantLocked {
+		// a card without merchant, saved before it was required, is frozen
+		// on its first transaction
+		i := slices.IndexFunc(txs, func(t Transaction) bool {
+			// the card was already frozen for the earlier transactions
+			return t.Merchant.ID != card.MerchantID && t.CreatedAt.After(card.FrozenAt)
+		})
+		// the card is still frozen for an earlier transaction
+		if i >= 0 && (status != CardStatusFrozen || card.FrozenAt.IsZero()) {
+			return s.freeze(ctx, card, status, now)
+		}
+	}
+
+	err = s.burnerRepo.UpdateBurnerCard(ctx, card)
+	if err != nil {
+		return fmt.Errorf("update burner card: %w", err)
+	}
+
+	return nil
+}
+
+func (s *TransactionBurnerCardService) terminate(ctx context.Context, card BurnerCard, status, reason string) error {
+	if status != "" && status != CardStatusTerminated {
+		err := s.cardSvc.UpdateCardStatus(ctx, card.CardID, CardStatusTerminated)
+		if err != nil {
+			return fmt.Errorf("terminate card: %w", err)
+		}
+	}
+
+	card.EndedAt = time.Now().UTC()
+	card.EndReason = reason
+	err := s.burnerRepo.UpdateBurnerCard(ctx, card)
+	if err != nil {
+		return fmt.Errorf("update burner card: %w", err)
+	}
+
+	return nil
+}
+
+func (s *TransactionBurnerCardService) freeze(ctx context.Context, card BurnerCard, status string, now time.Time) error {
+	if status == CardStatusActive {
+		err := s.cardSvc.UpdateCardStatus(ctx, card.CardID, CardStatusFrozen)
+		if err != nil {
+			return fmt.Errorf("freeze card: %w", err)
+		}
+	}
+
+	card.FrozenAt = now
+	err := s.burnerRepo.UpdateBurnerCard(ctx, card)
+	if err != nil {
+		return fmt.Errorf("update burner card: %w", err)
+	}
+
+	return nil
+}
+
+// cardStatus returns the status of the card, empty when the card no longer
+// exists
+func (s *TransactionBurnerCardService) cardStatus(ctx context.Context, cardID string) (string, error) {
+	c, err := s.cardSvc.GetCard(ctx, cardID)
+	if err != nil {
+		if errors.Is(err, ErrCardNotFound) {
+			return "", nil
+		}
+		return "", fmt.Errorf("get card: %w", err)
+	}
+
+	return c.Status, nil
+}
@@ -0,0 +1,413 @@
+package acme_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/acme/memory"
+	"github.com/stevenferrer/acme-cards-api/reap"
+)
+
+// failingBurnerCardRepository fails to save the burner cards while saveErr is set
+type failingBurnerCardRepository struct {
+	*memory.BurnerCardRepository
+	saveErr error
+}
+
+func (r *failingBurnerCardRepository) SaveBurnerCard(ctx context.Context, c *acme.BurnerCard) error {
+	if r.saveErr != nil {
+		return r.saveErr
+	}
+	return r.BurnerCardRepository.SaveBurnerCard(ctx, c)
+}
+
+// backdatedBurnerCardRepository returns the burner cards as if they were
+// created age ago
+type backdatedBurnerCardRepository struct {
+	*memory.BurnerCardRepository
+	age time.Duration
+}
+
+func (r *backdatedBurnerCardRepository) FindBurnerCards(ctx context.Context, active bool) ([]acme.BurnerCard, error) {
+	cards, err := r.BurnerCardRepository.FindBurnerCards(ctx, active)
+	for i := range cards {
+		cards[i].CreatedAt = cards[i].CreatedAt.Add(-r.age)
+	}
+	return cards, err
+}
+
+// burnerCards returns the saved burner cards, including the ended ones
+func burnerCards(t *testing.T, repo acme.BurnerCardRepository) []acme.BurnerCard {
+	t.Helper()
+	cards, err := repo.FindBurnerCards(context.Background(), false)
+	require.NoError(t, err)
+	return cards
+}
+
+func TestReapCardServiceCreateBurnerCard(t *testing.T) {
+	ctx := context.Background()
+
+	var created int
+	reapClient := &stubReapClient{
+		createCard: func(params reap.CreateCardParams) (*reap.CreateCardResponse, error) {
+			created++
+			return &reap.CreateCardResponse{CardID: "external" + params.Meta.ID}, nil
+		},
+	}
+
+	expiresAt := time.Now().UTC().Add(24 * time.Hour)
+
+	// withMode returns the params of a valid cardholder with the given mode
+	withMode := func(mode, merchantID string) acme.CreateCardParams {
+		return acme.CreateCardParams{
+			FirstName:   "Jane",
+			LastName:    "Doe",
+			DOB:         "1990-01-01",
+			ContactInfo: acme.ContactInfo{Email: "jane@example.com"},
+			IDDocument:  acme.IDDocument{Type: "Passport", Number: "P1234567"},
+			Mode:        mode,
+			MerchantID:  merchantID,
+		}
+	}
+
+	t.Run("invalid params", func(t *testing.T) {
+		cardSvc := acme.NewReapCardService(reapClient, memory.NewCardRepository())
+
+		noName := withMode("", "")
+		noName.LastName = ""
+		noDOB := withMode("", "")
+		noDOB.DOB = ""
+		noID := withMode("", "")
+		noID.IDDocument.Number = ""
+		noEmail := withMode("", "")
+		noEmail.ContactInfo = acme.ContactInfo{}
+
+		for _, params := range []acme.CreateCardParams{{}, noName, noDOB, noID, noEmail} {
+			_, err := cardSvc.CreateCard(ctx, params)
+			assert.ErrorIs(t, err, acme.ErrInvalidCardParams)
+		}
+		assert.Zero(t, created)
+	})
+
+	t.Run("invalid modes", func(t *testing.T) {
+		cardSvc := acme.NewReapCardService(reapClient, memory.NewCardRepository(),
+			acme.WithBurnerCardRepository(memory.NewBurnerCardRepository()),
+		)
+		for _, params := range []acme.CreateCardParams{
+			withMode("disposable", ""),
+			withMode(acme.CardModeStandard, "m1"),
+			withMode(acme.CardModeSingleUse, "m1"),
+			withMode(acme.CardModeMerchantLocked, ""),
+		} {
+			_, err := cardSvc.CreateCard(ctx, params)
+			assert.ErrorIs(t, err, acme.ErrInvalidCardMode)
+		}
+		assert.Zero(t, created)
+	})
+
+	t.Run("not supported", func(t *testing.T) {
+		cardSvc := acme.NewReapCardService(reapClient, memory.NewCardRepository())
+		_, err := cardSvc.CreateCard(ctx, withMode(acme.CardModeSingleUse, ""))
+		assert.ErrorIs(t, err, acme.ErrInvalidCardMode)
+		assert.Zero(t, created)
+
+		_, err = cardSvc.CreateCard(ctx, withMode("", ""))
+		require.NoError(t, err)
+		assert.Equal(t, 1, created)
+	})
+
+	t.Run("burner cards", func(t *testing.T) {
+		burnerRepo := memory.NewBurnerCardRepository()
+		cardRepo := &failingCardRepository{CardRepository: memory.NewCardRepository()}
+		cardSvc := acme.NewReapCardService(reapClient, cardRepo,
+			acme.WithBurnerCardRepository(burnerRepo),
+		)
+
+		_, err := cardSvc.CreateCard(ctx, withMode(acme.CardModeStandard, ""))
+		require.NoError(t, err)
+		assert.Empty(t, burnerCards(t, burnerRepo))
+
+		params := withMode(acme.CardModeMerchantLocked, "m1")
+		params.ValidUntil = expiresAt
+		resp, err := cardSvc.CreateCard(ctx, params)
+		require.NoError(t, err)
+		cards := burnerCards(t, burnerRepo)
+		require.Len(t, cards, 1)
+		assert.Equal(t, resp.CardID, cards[0].CardID)
+		assert.Equal(t, acme.CardModeMerchantLocked, cards[0].Mode)
+		assert.Equal(t, "m1", cards[0].MerchantID)
+
+		// the burner cards are terminated at their expiry by default
+		expiry, err := cardRepo.GetCardExpiry(ctx, resp.CardID)
+		require.NoError(t, err)
+		assert.Equal(t, expiresAt, expiry.ValidUntil)
+		assert.Equal(t, acme.CardStatusTerminated, expiry.Action)
+
+		// the card is issued even when its expiry cannot be saved
+		cardRepo.expiryErr = errors.New("database is down")
+		params = withMode(acme.CardModeSingleUse, "")
+		params.ValidUntil = expiresAt
+		params.ExpiryAction = acme.CardStatusFrozen
+		resp, err = cardSvc.CreateCard(ctx, params)
+		require.NoError(t, err)
+		assert.Len(t, burnerCards(t, burnerRepo), 2)
+
+		expiry, err = cardRepo.GetCardExpiry(ctx, resp.CardID)
+		require.NoError(t, err)
+		assert.True(t, expiry.ValidUntil.IsZero())
+	})
+
+	t.Run("save failed", func(t *testing.T) {
+		var terminated []string
+		reapClient := &stubReapClient{
+			createCard: reapClient.createCard,
+			updateCardStatus: func(params reap.UpdateCardStatusParams) (*reap.UpdateCardStatusResponse, error) {
+				assert.Equal(t, reap.CardStatusTerminated, params.Status)
+				terminated = append(terminated, params.CardID)
+				return &reap.UpdateCardStatusResponse{}, nil
+			},
+			getCard: func(params reap.GetCardParams) (*reap.GetCardResponse, error) {
+				return &reap.GetCardResponse{Card: reap.Card{Status: reap.CardStatusTerminated}}, nil
+			},
+			getCards: func(reap.GetCardsParams) (*reap.GetCardsResponse, error) {
+				return &reap.GetCardsResponse{}, nil
+			},
+		}
+		burnerRepo := &failingBurnerCardRepository{
+			BurnerCardRepository: memory.NewBurnerCardRepository(),
+			saveErr:              errors.New("database is down"),
+		}
+		cardSvc := acme.NewReapCardService(reapClient, memory.NewCardRepository(),
+			acme.WithBurnerCardRepository(burnerRepo),
+		)
+
+		// the card is terminated instead of being left unmonitored
+		params := withMode(acme.CardModeSingleUse, "")
+		params.CardID = acme.NewCardID()
+		_, err := cardSvc.CreateCard(ctx, params)
+		assert.ErrorIs(t, err, acme.ErrCardCreationAborted)
+		assert.Equal(t, []string{"external" + params.CardID}, terminated)
+
+		// the terminated card is not completed by a retry
+		burnerRepo.saveErr = nil
+		_, err = cardSvc.CreateCard(ctx, params)
+		assert.ErrorIs(t, err, acme.ErrCardCreationAborted)
+		assert.Empty(t, burnerCards(t, burnerRepo))
+	})
+}
+
+// rangeTransactionSource only returns the transactions from the start of the
+// date range and records it
+type rangeTransactionSource struct {
+	stubTransactionSource
+	from time.Time
+}
+
+func (s *rangeTransactionSource) EachCardTransaction(ctx context.Context, cardID string, dateRange acme.DateRange, fn func(acme.Transaction) error) error {
+	s.from = dateRange.From
+	return s.stubTransactionSource.EachCardTransaction(ctx, cardID, dateRange, func(t acme.Transaction) error {
+		if t.CreatedAt.Before(dateRange.From) {
+			return nil
+		}
+		return fn(t)
+	})
+}
+
+func TestBurnerCardService(t *testing.T) {
+	ctx := context.Background()
+
+	createdAt := time.Now().UTC().Add(-time.Hour)
+	newTx := func(id, status, merchantID string, minutes int) acme.Transaction {
+		return acme.Transaction{
+			ID:        id,
+			CardID:    "card1",
+			Status:    status,
+			Amount:    acme.MustParseMoney("10.00", "USD"),
+			Merchant:  acme.MerchantDetails{ID: merchantID},
+			CreatedAt: createdAt.Add(time.Duration(minutes) * time.Minute),
+		}
+	}
+
+	newService := func(t *testing.T, txs acme.TransactionSource, card acme.BurnerCard) (*acme.TransactionBurnerCardService, *fakeCardService, *memory.BurnerCardRepository) {
+		card.CardID = "card1"
+		cardSvc := newCardService(acme.Card{ID: "card1", Status: acme.CardStatusActive})
+		burnerRepo := memory.NewBurnerCardRepository()
+		require.NoError(t, burnerRepo.SaveBurnerCard(ctx, &card))
+		return acme.NewTransactionBurnerCardService(txs, cardSvc, burnerRepo), cardSvc, burnerRepo
+	}
+
+	t.Run("single use", func(t *testing.T) {
+		txs := stubTransactionSource{
+			newTx("tx1", "declined", "m1", 1),
+			newTx("tx2", "pending", "m1", 2),
+		}
+		burnerSvc, cardSvc, burnerRepo := newService(t, txs, acme.BurnerCard{Mode: acme.CardModeSingleUse})
+
+		// the card is terminated once the transaction settles
+		require.NoError(t, burnerSvc.EnforceBurnerCards(ctx))
+		assert.Equal(t, acme.CardStatusActive, cardStatus(t, cardSvc, "card1"))
+		assert.False(t, burnerCards(t, burnerRepo)[0].IsEnded())
+
+		burnerSvc, cardSvc, burnerRepo = newService(t, append(txs, newTx("tx3", "settled", "m1", 3)), acme.BurnerCard{Mode: acme.CardModeSingleUse})
+		require.NoError(t, burnerSvc.EnforceBurnerCards(ctx))
+		assert.Equal(t, acme.CardStatusTerminated, cardStatus(t, cardSvc, "card1"))
+		assert.True(t, burnerCards(t, burnerRepo)[0].IsEnded())
+		assert.Equal(t, "transaction tx3 settled", burnerCards(t, burnerRepo)[0].EndReason)
+
+		// ended cards are no longer monitored
+		require.NoError(t, cardSvc.UpdateCardStatus(ctx, "card1", acme.CardStatusActive))
+		require.NoError(t, burnerSvc.EnforceBurnerCards(ctx))
+		assert.Equal(t, acme.CardStatusActive, cardStatus(t, cardSvc, "card1"))
+	})
+
+	t.Run("merchant locked", func(t *testing.T) {
+		txs := stubTransactionSource{
+			newTx("tx2", "cleared", "m1", 2),
+			newTx("tx1", "pending", "m1", 1),
+			// declined transactions did not spend
+			newTx("tx3", "declined", "m2", 3),
+		}
+		burnerSvc, cardSvc, burnerRepo := newService(t, txs, acme.BurnerCard{Mode: acme.CardModeMerchantLocked, MerchantID: "m1"})
+
+		require.NoError(t, burnerSvc.EnforceBurnerCards(ctx))
+		assert.Equal(t, acme.CardStatusActive, cardStatus(t, cardSvc, "card1"))
+
+		txs = append(txs, newTx("tx4", "pending", "m2", 4))
+		burnerSvc = acme.NewTransactionBurnerCardService(txs, cardSvc, burnerRepo)
+		require.NoError(t, burnerSvc.EnforceBurnerCards(ctx))
+		assert.Equal(t, acme.CardStatusFrozen, cardStatus(t, cardSvc, "card1"))
+		assert.False(t, burnerCards(t, burnerRepo)[0].FrozenAt.IsZero())
+		assert.False(t, burnerCards(t, burnerRepo)[0].IsEnded())
+
+		// the card is not frozen again for the same transaction
+		require.NoError(t, cardSvc.UpdateCardStatus(ctx, "card1", acme.CardStatusActive))
+		require.NoError(t, burnerSvc.EnforceBurnerCards(ctx))
+		assert.Equal(t, acme.CardStatusActive, cardStatus(t, cardSvc, "card1"))
+	})
+
+	t.Run("without merchant", func(t *testing.T) {
+		// the cards saved before the merchant was required are frozen on
+		// their first transaction
+		txs := stubTransactionSource{newTx("tx1", "pending", "m1", 1)}
+		burnerSvc, cardSvc, _ := newService(t, txs, acme.BurnerCard{Mode: acme.CardModeMerchantLocked})
+
+		require.NoError(t, burnerSvc.EnforceBurnerCards(ctx))
+		assert.Equal(t, acme.CardStatusFrozen, cardStatus(t, cardSvc, "card1"))
+	})
+
+	t.Run("last check", func(t *testing.T) {
+		txs := &rangeTransactionSource{stubTransactionSource: stubTransactionSource{
+			newTx("tx1", "pending", "m1", 1),
+		}}
+		_, cardSvc, burnerRepo := newService(t, txs, acme.BurnerCard{Mode: acme.CardModeSingleUse})
+		backdatedRepo := &backdatedBurnerCardRepository{BurnerCardRepository: burnerRepo, age: 30 * 24 * time.Hour}
+		burnerSvc := acme.NewTransactionBurnerCardService(txs, cardSvc, backdatedRepo)
+
+		// a card that was never checked is checked from its creation
+		require.NoError(t, burnerSvc.EnforceBurnerCards(ctx))
+		card := burnerCards(t, backdatedRepo)[0]
+		assert.True(t, card.CreatedAt.Equal(txs.from))
+		checkedAt := card.CheckedAt
+		assert.True(t, checkedAt.After(createdAt))
+
+		// the next check starts a settlement lookback before the last one
+		require.NoError(t, burnerSvc.EnforceBurnerCards(ctx))
+		assert.True(t, checkedAt.Add(-7*24*time.Hour).Equal(txs.from))
+		assert.False(t, burnerCards(t, burnerRepo)[0].IsEnded())
+	})
+
+	t.Run("terminated", func(t *testing.T) {
+		// e.g. the card expired, it is no longer monitored
+		txs := stubTransactionSource{newTx("tx1", "pending", "m2", 1)}
+		burnerSvc, cardSvc, burnerRepo := newService(t, txs, acme.BurnerCard{Mode: acme.CardModeMerchantLocked, MerchantID: "m1"})
+		require.NoError(t, cardSvc.UpdateCardStatus(ctx, "card1", acme.CardStatusTerminated))
+
+		require.NoError(t, burnerSvc.EnforceBurnerCards(ctx))
+		card := burnerCards(t, burnerRepo)[0]
+		assert.True(t, card.IsEnded())
+		assert.True(t, card.FrozenAt.IsZero())
+		assert.Equal(t, "card terminated", card.EndReason)
+	})
+
+	t.Run("terminated before saved", func(t *testing.T) {
+		// the card was terminated on an earlier run that failed to save its end
+		txs := stubTransactionSource{newTx("tx1", "settled", "m1", 1)}
+		burnerSvc, cardSvc, burnerRepo := newService(t, txs, acme.BurnerCard{Mode: acme.CardModeSingleUse})
+		require.NoError(t, cardSvc.UpdateCardStatus(ctx, "card1", acme.CardStatusTerminated))
+
+		require.NoError(t, burnerSvc.EnforceBurnerCards(ctx))
+		card := burnerCards(t, burnerRepo)[0]
+		assert.True(t, card.IsEnded())
+		assert.Equal(t, "transaction tx1 settled", card.EndReason)
+
+		// a card that no longer exists keeps its reason too
+		burnerRepo = memory.NewBurnerCardRepository()
+		require.NoError(t, burnerRepo.SaveBurnerCard(ctx, &acme.BurnerCard{CardID: "card1", Mode: acme.CardModeSingleUse}))
+		burnerSvc = acme.NewTransactionBurnerCardService(txs, newCardService(), burnerRepo)
+
+		require.NoError(t, burnerSvc.EnforceBurnerCards(ctx))
+		card = burnerCards(t, burnerRepo)[0]
+		assert.True(t, card.IsEnded())
+		assert.Equal(t, "transaction tx1 settled", card.EndReason)
+	})
+
+	t.Run("already frozen", func(t *testing.T) {
+		frozenAt := createdAt.Add(2 * time.Minute)
+		txs := stubTransactionSource{
+			newTx("tx1", "pending", "m2", 1),
+			newTx("tx2", "pending", "m2", 3),
+		}
+		burnerSvc, cardSvc, burnerRepo := newService(t, txs, acme.BurnerCard{Mode: acme.CardModeMerchantLocked, MerchantID: "m1"})
+		card := burnerCards(t, burnerRepo)[0]
+		card.FrozenAt = frozenAt
+		require.NoError(t, burnerRepo.UpdateBurnerCard(ctx, card))
+		require.NoError(t, cardSvc.UpdateCardStatus(ctx, "card1", acme.CardStatusFrozen))
+		cardSvc.statusErr = errors.New("reap is down")
+
+		// the frozen card is only checked
+		require.NoError(t, burnerSvc.EnforceBurnerCards(ctx))
+		card = burnerCards(t, burnerRepo)[0]
+		assert.True(t, frozenAt.Equal(card.FrozenAt))
+		assert.False(t, card.CheckedAt.IsZero())
+		assert.Equal(t, acme.CardStatusFrozen, cardStatus(t, cardSvc, "card1"))
+	})
+
+	t.Run("freeze failed", func(t *testing.T) {
+		txs := stubTransactionSource{newTx("tx1", "pending", "m2", 1)}
+		burnerSvc, cardSvc, burnerRepo := newService(t, txs, acme.BurnerCard{Mode: acme.CardModeMerchantLocked, MerchantID: "m1"})
+		cardSvc.statusErr = errors.New("reap is down")
+
+		// the check is not saved so that the freeze is retried
+		err := burnerSvc.EnforceBurnerCards(ctx)
+		assert.ErrorIs(t, err, cardSvc.statusErr)
+		card := burnerCards(t, burnerRepo)[0]
+		assert.True(t, card.FrozenAt.IsZero())
+		assert.True(t, card.CheckedAt.IsZero())
+
+		cardSvc.statusErr = nil
+		require.NoError(t, burnerSvc.EnforceBurnerCards(ctx))
+		assert.Equal(t, acme.CardStatusFrozen, cardStatus(t, cardSvc, "card1"))
+		assert.False(t, burnerCards(t, burnerRepo)[0].FrozenAt.IsZero())
+	})
+
+	t.Run("terminate failed", func(t *testing.T) {
+		txs := stubTransactionSource{newTx("tx1", "settled", "m1", 1)}
+		burnerSvc, cardSvc, burnerRepo := newService(t, txs, acme.BurnerCard{Mode: acme.CardModeSingleUse})
+		cardSvc.statusErr = errors.New("reap is down")
+
+		err := burnerSvc.EnforceBurnerCards(ctx)
+		assert.ErrorIs(t, err, cardSvc.statusErr)
+		assert.False(t, burnerCards(t, burnerRepo)[0].IsEnded())
+
+		cardSvc.statusErr = nil
+		require.NoError(t, burnerSvc.EnforceBurnerCards(ctx))
+		assert.Equal(t, acme.CardStatusTerminated, cardStatus(t, cardSvc, "card1"))
+		assert.True(t, burnerCards(t, burnerRepo)[0].IsEnded())
+	})
+}
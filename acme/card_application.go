@@ -0,0 +1,462 @@
+package acme
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+var (
+	// ErrInvalidCardApplication is returned for missing KYC fields, checkers
+	// or rejection reasons
+	ErrInvalidCardApplication = errors.New("invalid card application")
+	// ErrCardApplicationNotFound is returned when the application does not
+	// exist
+	ErrCardApplicationNotFound = errors.New("card application not found")
+	// ErrCardApplicationStatus is returned when the status of the
+	// application does not allow the action e.g. approving an expired
+	// application
+	ErrCardApplicationStatus = errors.New("invalid card application status")
+	// ErrCardApplicationNotAllowed is returned when the maker of the
+	// application tries to approve or reject it
+	ErrCardApplicationNotAllowed = errors.New("card application decision not allowed")
+)
+
+// Card application statuses
+const (
+	CardApplicationPending = "pending"
+	// CardApplicationApproved is an approved application whose card is
+	// being issued
+	CardApplicationApproved = "approved"
+	CardApplicationIssued   = "issued"
+	CardApplicationRejected = "rejected"
+	// CardApplicationExpired is a pending application that was not decided
+	// in time
+	CardApplicationExpired = "expired"
+	// CardApplicationFailed is an approved application whose card creation
+	// failed, it can be approved again
+	CardApplicationFailed = "failed"
+)
+
+// Card application audit actions
+const (
+	CardApplicationSubmittedAction   = "submitted"
+	CardApplicationApprovedAction    = "approved"
+	CardApplicationRejectedAction    = "rejected"
+	CardApplicationExpiredAction     = "expired"
+	CardApplicationIssuedAction      = "issued"
+	CardApplicationIssueFailedAction = "issue_failed"
+)
+
+// defaultCardApplicationTTL is how long an application stays pending
+const defaultCardApplicationTTL = 7 * 24 * time.Hour
+
+// cardApplicationIssueTimeout is how long an approved application is left
+// to its approval before RecoverCardApplications issues its card
+const cardApplicationIssueTimeout = 10 * time.Minute
+
+// CardApplication is a card creation waiting for the approval of a checker,
+// the card is only created in Reap once a different user approved it.
+type CardApplication struct {
+	ID int64
+	// Params is the KYC and the options of the card to create
+	Params CreateCardParams
+	Maker  string
+	// Checker is the user who approved or rejected the application
+	Checker         string
+	Status          string
+	RejectionReason string
+	// CardID is the card of the application, it is set on approval and the
+	// card is issued once the status is issued
+	CardID string
+	// Error is why the last card creation failed
+	Error string
+	// ExpiresAt is when the pending application expires
+	ExpiresAt time.Time
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	// Events is the audit trail of the application, oldest first, it is
+	// only set on GetCardApplication
+	Events []CardApplicationEvent
+}
+
+// CardApplicationEvent is an entry of the audit trail of an application
+type CardApplicationEvent struct {
+	ID            int64
+	ApplicationID int64
+	Action        string
+	// Actor is the maker or the checker, it is empty on the expiry and the
+	// card creation
+	Actor     string
+	Reason    string
+	CreatedAt time.Time
+}
+
+type CardApplicationFilter struct {
+	Status string
+	Maker  string
+}
+
+type CardApplicationRepository interface {
+	// SaveCardApplication saves the application and sets its ID and times
+	SaveCardApplication(context.Context, *CardApplication) error
+	GetCardApplication(ctx context.Context, id int64) (*CardApplication, error)
+	// FindCardApplications returns the applications, latest first
+	FindCardApplications(context.Context, CardApplicationFilter) ([]CardApplication, error)
+	// UpdateCardApplication saves the status, the checker, the rejection
+	// reason, the card and the error of the application when its status is
+	// still from, ErrCardApplicationStatus is returned otherwise
+	UpdateCardApplication(ctx context.Context, app *CardApplication, from string) error
+	// LockCardApplication calls fn while holding the lock of the application
+	// shared by the server instances, it returns false without calling fn
+	// when the application is locked
+	LockCardApplication(ctx context.Context, id int64, fn func(context.Context) error) (bool, error)
+
+	// SaveCardApplicationEvent saves the event and sets its ID and creation
+	// time
+	SaveCardApplicationEvent(context.Context, *CardApplicationEvent) error
+	// FindCardApplicationEvents returns the events of the application,
+	// oldest first
+	FindCardApplicationEvents(ctx context.Context, applicationID int64) ([]CardApplicationEvent, error)
+}
+
+type CardApplicationService interface {
+	// SubmitCardApplication stores the card to create until a checker
+	// approves it
+	SubmitCardApplication(ctx context.Context, maker string, params CreateCardParams) (*CardApplication, error)
+	// GetCardApplication returns the application with its audit trail
+	GetCardApplication(ctx context.Context, id int64) (*CardApplication, error)
+	ListCardApplications(context.Context, CardApplicationFilter) ([]CardApplication, error)
+
+	// ApproveCardApplication creates the card of the application
+	ApproveCardApplication(ctx context.Context, id int64, checker string) (*CardApplication, error)
+	RejectCardApplication(ctx context.Context, id int64, checker, reason string) (*CardApplication, error)
+
+	// ExpireCardApplications expires the pending applications past their
+	// expiry
+	ExpireCardApplications(context.Context) error
+	// RecoverCardApplications issues the cards of the applications stuck
+	// approved
+	RecoverCardApplications(context.Context) error
+}
+
+// MakerCheckerCardApplicationService implements CardApplicationService.
+type MakerCheckerCardApplicationService struct {
+	cardSvc CardService
+	appRepo CardApplicationRepository
+	ttl     time.Duration
+}
+
+var _ CardApplicationService = (*MakerCheckerCardApplicationService)(nil)
+
+// NewMakerCheckerCardApplicationService returns the service, the pending
+// applications expire after ttlDays, or 7 days when ttlDays is zero.
+func NewMakerCheckerCardApplicationService(
+	cardSvc CardService,
+	appRepo CardApplicationRepository,
+	ttlDays int,
+) *MakerCheckerCardApplicationService {
+	ttl := defaultCardApplicationTTL
+	if ttlDays > 0 {
+		ttl = time.Duration(ttlDays) * 24 * time.Hour
+	}
+
+	return &MakerCheckerCardApplicationService{
+		cardSvc: cardSvc,
+		appRepo: appRepo,
+		ttl:     ttl,
+	}
+}
+
+func (s *MakerCheckerCardApplicationService) SubmitCardApplication(ctx context.Context, maker string, params CreateCardParams) (*CardApplication, error) {
+	if maker == "" {
+		return nil, fmt.Errorf("%w: maker is required", ErrInvalidCardApplication)
+	}
+
+	err := validateCardApplicationKYC(params)
+	if err != nil {
+		return nil, err
+	}
+
+	// the card options are checked now rather than on approval
+	now := time.Now().UTC()
+	err = validateCardMode(&params)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = toCardExpiry("", UpdateCardExpiryParams{ValidUntil: params.ValidUntil, ExpiryAction: params.ExpiryAction}, now)
+	if err != nil {
+		return nil, err
+	}
+
+	app := CardApplication{
+		Params:    params,
+		Maker:     maker,
+		Status:    CardApplicationPending,
+		ExpiresAt: now.Add(s.ttl),
+	}
+	err = s.appRepo.SaveCardApplication(ctx, &app)
+	if err != nil {
+		return nil, fmt.Errorf("save card application: %w", err)
+	}
+
+	err = s.saveEvent(ctx, app.ID, CardApplicationSubmittedAction, maker, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return &app, nil
+}
+
+// validateCardApplicationKYC checks that the application has the KYC that
+// the checker reviews
+func validateCardApplicationKYC(params CreateCardParams) error {
+	switch {
+	case params.FirstName == "" || params.LastName == "":
+		return fmt.Errorf("%w: first and last name are required", ErrInvalidCardApplication)
+	case params.DOB == "":
+		return fmt.Errorf("%w: date of birth is required", ErrInvalidCardApplication)
+	case params.IDDocument.Type == "" || params.IDDocument.Number == "":
+		return fmt.Errorf("%w: id document is required", ErrInvalidCardApplication)
+	case params.ContactInfo.Email == "":
+		return fmt.Errorf("%w: contact email is required", ErrInvalidCardApplication)
+	}
+
+	return nil
+}
+
+func (s *MakerCheckerCardApplicationService) GetCardApplication(ctx context.Context, id int64) (*CardApplication, error) {
+	app, err := s.appRepo.GetCardApplication(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	app.Events, err = s.appRepo.FindCardApplicationEvents(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("find card application events: %w", err)
+	}
+
+	return app, nil
+}
+
+func (s *MakerCheckerCardApplicationService) ListCardApplications(ctx context.Context, filter CardApplicationFilter) ([]CardApplication, error) {
+	return s.appRepo.FindCardApplications(ctx, filter)
+}
+
+func (s *MakerCheckerCardApplicationService) ApproveCardApplication(ctx context.Context, id int64, checker string) (*CardApplication, error) {
+	app, err := s.decidable(ctx, id, checker, CardApplicationPending, CardApplicationFailed)
+	if err != nil {
+		return nil, err
+	}
+
+	// the card ID is saved before the card is created so that approving a
+	// failed application again completes its card instead of issuing another
+	from := app.Status
+	app.Status, app.Checker = CardApplicationApproved, checker
+	if app.CardID == "" {
+		app.CardID = NewCardID()
+	}
+	err = s.appRepo.UpdateCardApplication(ctx, app, from)
+	if err != nil {
+		return nil, fmt.Errorf("update card application: %w", err)
+	}
+
+	err = s.saveEvent(ctx, id, CardApplicationApprovedAction, checker, "")
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.issue(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetCardApplication(ctx, id)
+}
+
+// issue creates the card of the approved application while holding its
+// lock, the application is read again under the lock so that the approval
+// and the recovery of other instances do not create the card at the same
+// time. The card is left to the instance holding the lock.
+func (s *MakerCheckerCardApplicationService) issue(ctx context.Context, id int64) error {
+	_, err := s.appRepo.LockCardApplication(ctx, id, func(ctx context.Context) error {
+		app, err := s.appRepo.GetCardApplication(ctx, id)
+		if err != nil {
+			return fmt.Errorf("get card application: %w", err)
+		}
+
+		// issued or failed in the meantime
+		if app.Status != CardApplicationApproved {
+			return nil
+		}
+
+		params := app.Params
+		params.CardID = app.CardID
+
+		action, reason := CardApplicationIssuedAction, ""
+		_, err = s.cardSvc.CreateCard(ctx, params)
+		if err != nil {
+			app.Status, app.Error = CardApplicationFailed, err.Error()
+			action, reason = CardApplicationIssueFailedAction, err.Error()
+			if errors.Is(err, ErrCardCreationAborted) {
+				// the card was terminated, approving again issues another card
+				app.CardID = ""
+			}
+		} else {
+			app.Status, app.Error = CardApplicationIssued, ""
+		}
+
+		err = s.appRepo.UpdateCardApplication(ctx, app, CardApplicationApproved)
+		if err != nil {
+			return fmt.Errorf("update card application: %w", err)
+		}
+
+		return s.saveEvent(ctx, app.ID, action, "", reason)
+	})
+
+	return err
+}
+
+// RecoverCardApplications issues the cards of the applications left
+// approved for longer than cardApplicationIssueTimeout e.g. when the
+// process stopped while issuing, the card ID saved on approval keeps the
+// card from being issued twice.
+func (s *MakerCheckerCardApplicationService) RecoverCardApplications(ctx context.Context) error {
+	apps, err := s.appRepo.FindCardApplications(ctx, CardApplicationFilter{Status: CardApplicationApproved})
+	if err != nil {
+		return fmt.Errorf("find card applications: %w", err)
+	}
+
+	stale := time.Now().Add(-cardApplicationIssueTimeout)
+	var errs []error
+	for _, app := range apps {
+		if app.UpdatedAt.After(stale) {
+			continue
+		}
+
+		err = s.issue(ctx, app.ID)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("card application %d: %w", app.ID, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (s *MakerCheckerCardApplicationService) RejectCardApplication(ctx context.Context, id int64, checker, reason string) (*CardApplication, error) {
+	if reason == "" {
+		return nil, fmt.Errorf("%w: rejection reason is required", ErrInvalidCardApplication)
+	}
+
+	app, err := s.decidable(ctx, id, checker, CardApplicationPending)
+	if err != nil {
+		return nil, err
+	}
+
+	app.Status, app.Checker, app.RejectionReason = CardApplicationRejected, checker, reason
+	err = s.appRepo.UpdateCardApplication(ctx, app, CardApplicationPending)
+	if err != nil {
+		return nil, fmt.Errorf("update card application: %w", err)
+	}
+
+	err = s.saveEvent(ctx, id, CardApplicationRejectedAction, checker, reason)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetCardApplication(ctx, id)
+}
+
+// decidable returns the application when it is in one of the statuses and
+// the checker is not its maker, a stale pending application is expired
+func (s *MakerCheckerCardApplicationService) decidable(ctx context.Context, id int64, checker string, statuses ...string) (*CardApplication, error) {
+	if checker == "" {
+		return nil, fmt.Errorf("%w: checker is required", ErrInvalidCardApplication)
+	}
+
+	app, err := s.appRepo.GetCardApplication(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if app.Status == CardApplicationPending && !app.ExpiresAt.After(time.Now()) {
+		err = s.expire(ctx, app)
+		if err != nil {
+			return nil, err
+		}
+
+		// decided by another checker in the meantime
+		if app.Status == CardApplicationPending {
+			app, err = s.appRepo.GetCardApplication(ctx, id)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if !slices.Contains(statuses, app.Status) {
+		return nil, fmt.Errorf("%w: the application is %s", ErrCardApplicationStatus, app.Status)
+	}
+
+	if checker == app.Maker {
+		return nil, fmt.Errorf("%w: the maker cannot decide their own application", ErrCardApplicationNotAllowed)
+	}
+
+	return app, nil
+}
+
+func (s *MakerCheckerCardApplicationService) ExpireCardApplications(ctx context.Context) error {
+	apps, err := s.appRepo.FindCardApplications(ctx, CardApplicationFilter{Status: CardApplicationPending})
+	if err != nil {
+		return fmt.Errorf("find card applications: %w", err)
+	}
+
+	now := time.Now()
+	var errs []error
+	for _, app := range apps {
+		if app.ExpiresAt.After(now) {
+			continue
+		}
+
+		err = s.expire(ctx, &app)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("card application %d: %w", app.ID, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// expire expires the pending application, an application decided in the
+// meantime is left as is and app is only updated once it expired
+func (s *MakerCheckerCardApplicationService) expire(ctx context.Context, app *CardApplication) error {
+	expired := *app
+	expired.Status = CardApplicationExpired
+	err := s.appRepo.UpdateCardApplication(ctx, &expired, CardApplicationPending)
+	if err != nil {
+		if errors.Is(err, ErrCardApplicationStatus) {
+			return nil
+		}
+		return fmt.Errorf("update card application: %w", err)
+	}
+	*app = expired
+
+	return s.saveEvent(ctx, app.ID, CardApplicationExpiredAction, "", "")
+}
+
+func (s *MakerCheckerCardApplicationService) saveEvent(ctx context.Context, id int64, action, actor, reason string) error {
+	err := s.appRepo.SaveCardApplicationEvent(ctx, &CardApplicationEvent{
+		ApplicationID: id,
+		Action:        action,
+		Actor:         actor,
+		Reason:        reason,
+	})
+	if err != nil {
+		return fmt.Errorf("save card application event: %w", err)
+	}
+
+	return nil
+}
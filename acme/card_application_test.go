@@ -0,0 +1,420 @@
+package acme_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/acme/memory"
+	"github.com/stevenferrer/acme-cards-api/reap"
+)
+
+// agedCardApplicationRepository returns the applications as if they were
+// saved and last updated their age ago
+type agedCardApplicationRepository struct {
+	*memory.CardApplicationRepository
+	ages map[int64]time.Duration
+}
+
+func newAgedCardApplicationRepository() *agedCardApplicationRepository {
+	return &agedCardApplicationRepository{
+		CardApplicationRepository: memory.NewCardApplicationRepository(),
+		ages:                      make(map[int64]time.Duration),
+	}
+}
+
+func (r *agedCardApplicationRepository) age(app *acme.CardApplication) {
+	age := r.ages[app.ID]
+	app.ExpiresAt = app.ExpiresAt.Add(-age)
+	app.CreatedAt = app.CreatedAt.Add(-age)
+	app.UpdatedAt = app.UpdatedAt.Add(-age)
+}
+
+func (r *agedCardApplicationRepository) GetCardApplication(ctx context.Context, id int64) (*acme.CardApplication, error) {
+	app, err := r.CardApplicationRepository.GetCardApplication(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	r.age(app)
+	return app, nil
+}
+
+func (r *agedCardApplicationRepository) FindCardApplications(ctx context.Context, filter acme.CardApplicationFilter) ([]acme.CardApplication, error) {
+	apps, err := r.CardApplicationRepository.FindCardApplications(ctx, filter)
+	for i := range apps {
+		r.age(&apps[i])
+	}
+	return apps, err
+}
+
+// staleCardApplicationRepository returns stale on the next read of the
+// application, e.g. when it was read before another checker decided it
+type staleCardApplicationRepository struct {
+	*agedCardApplicationRepository
+	stale *acme.CardApplication
+}
+
+func (r *staleCardApplicationRepository) GetCardApplication(ctx context.Context, id int64) (*acme.CardApplication, error) {
+	if r.stale != nil && r.stale.ID == id {
+		app := r.stale
+		r.stale = nil
+		return app, nil
+	}
+	return r.agedCardApplicationRepository.GetCardApplication(ctx, id)
+}
+
+// failingCardRepository fails to save the card IDs while saveErr is set
+// and the card expiries while expiryErr is set
+type failingCardRepository struct {
+	acme.CardRepository
+	saveErr   error
+	expiryErr error
+}
+
+func (r *failingCardRepository) SaveCardID(ctx context.Context, cardID, externalCardID string) error {
+	if r.saveErr != nil {
+		return r.saveErr
+	}
+	return r.CardRepository.SaveCardID(ctx, cardID, externalCardID)
+}
+
+func (r *failingCardRepository) SaveCardExpiry(ctx context.Context, expiry acme.CardExpiry) error {
+	if r.expiryErr != nil {
+		return r.expiryErr
+	}
+	return r.CardRepository.SaveCardExpiry(ctx, expiry)
+}
+
+func TestCardApplicationService(t *testing.T) {
+	ctx := context.Background()
+
+	params := acme.CreateCardParams{
+		FirstName:   "Jane",
+		LastName:    "Doe",
+		DOB:         "1990-01-01",
+		ContactInfo: acme.ContactInfo{Email: "jane@example.com"},
+		IDDocument:  acme.IDDocument{Type: "Passport", Number: "P1234567"},
+	}
+
+	newService := func() (*acme.MakerCheckerCardApplicationService, *agedCardApplicationRepository, *int, *error) {
+		var created int
+		var createErr error
+		reapClient := &stubReapClient{
+			createCard: func(params reap.CreateCardParams) (*reap.CreateCardResponse, error) {
+				if createErr != nil {
+					return nil, createErr
+				}
+				created++
+				return &reap.CreateCardResponse{CardID: "external" + params.Meta.ID}, nil
+			},
+			getCards: func(reap.GetCardsParams) (*reap.GetCardsResponse, error) {
+				return &reap.GetCardsResponse{}, nil
+			},
+		}
+		cardSvc := acme.NewReapCardService(reapClient, memory.NewCardRepository())
+		appRepo := newAgedCardApplicationRepository()
+		return acme.NewMakerCheckerCardApplicationService(cardSvc, appRepo, 0), appRepo, &created, &createErr
+	}
+
+	actions := func(app *acme.CardApplication) []string {
+		var actions []string
+		for _, e := range app.Events {
+			actions = append(actions, e.Action)
+		}
+		return actions
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		appSvc, _, _, _ := newService()
+
+		_, err := appSvc.SubmitCardApplication(ctx, "", params)
+		assert.ErrorIs(t, err, acme.ErrInvalidCardApplication)
+
+		noID := params
+		noID.IDDocument = acme.IDDocument{}
+		_, err = appSvc.SubmitCardApplication(ctx, "alice", noID)
+		assert.ErrorIs(t, err, acme.ErrInvalidCardApplication)
+
+		badMode := params
+		badMode.Mode = "disposable"
+		_, err = appSvc.SubmitCardApplication(ctx, "alice", badMode)
+		assert.ErrorIs(t, err, acme.ErrInvalidCardMode)
+
+		badExpiry := params
+		badExpiry.ValidUntil = time.Now().Add(-time.Hour)
+		_, err = appSvc.SubmitCardApplication(ctx, "alice", badExpiry)
+		assert.ErrorIs(t, err, acme.ErrInvalidCardExpiry)
+	})
+
+	t.Run("approved", func(t *testing.T) {
+		appSvc, _, created, _ := newService()
+
+		app, err := appSvc.SubmitCardApplication(ctx, "alice", params)
+		require.NoError(t, err)
+		assert.Equal(t, acme.CardApplicationPending, app.Status)
+		assert.Zero(t, *created)
+
+		// the maker cannot approve their own application
+		_, err = appSvc.ApproveCardApplication(ctx, app.ID, "alice")
+		assert.ErrorIs(t, err, acme.ErrCardApplicationNotAllowed)
+		assert.Zero(t, *created)
+
+		app, err = appSvc.ApproveCardApplication(ctx, app.ID, "bob")
+		require.NoError(t, err)
+		assert.Equal(t, acme.CardApplicationIssued, app.Status)
+		assert.Equal(t, "bob", app.Checker)
+		assert.NotEmpty(t, app.CardID)
+		assert.Equal(t, 1, *created)
+		assert.Equal(t, []string{
+			acme.CardApplicationSubmittedAction,
+			acme.CardApplicationApprovedAction,
+			acme.CardApplicationIssuedAction,
+		}, actions(app))
+
+		_, err = appSvc.ApproveCardApplication(ctx, app.ID, "carol")
+		assert.ErrorIs(t, err, acme.ErrCardApplicationStatus)
+		assert.Equal(t, 1, *created)
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		appSvc, _, created, _ := newService()
+
+		app, err := appSvc.SubmitCardApplication(ctx, "alice", params)
+		require.NoError(t, err)
+
+		_, err = appSvc.RejectCardApplication(ctx, app.ID, "bob", "")
+		assert.ErrorIs(t, err, acme.ErrInvalidCardApplication)
+
+		app, err = appSvc.RejectCardApplication(ctx, app.ID, "bob", "passport expired")
+		require.NoError(t, err)
+		assert.Equal(t, acme.CardApplicationRejected, app.Status)
+		assert.Equal(t, "passport expired", app.RejectionReason)
+		assert.Equal(t, "passport expired", app.Events[1].Reason)
+
+		_, err = appSvc.ApproveCardApplication(ctx, app.ID, "carol")
+		assert.ErrorIs(t, err, acme.ErrCardApplicationStatus)
+		assert.Zero(t, *created)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		appSvc, _, created, createErr := newService()
+
+		app, err := appSvc.SubmitCardApplication(ctx, "alice", params)
+		require.NoError(t, err)
+
+		*createErr = errors.New("reap is down")
+		app, err = appSvc.ApproveCardApplication(ctx, app.ID, "bob")
+		require.NoError(t, err)
+		assert.Equal(t, acme.CardApplicationFailed, app.Status)
+		assert.Contains(t, app.Error, "reap is down")
+
+		// failed applications can be approved again
+		*createErr = nil
+		app, err = appSvc.ApproveCardApplication(ctx, app.ID, "bob")
+		require.NoError(t, err)
+		assert.Equal(t, acme.CardApplicationIssued, app.Status)
+		assert.Empty(t, app.Error)
+		assert.Equal(t, 1, *created)
+	})
+
+	t.Run("failed after creation", func(t *testing.T) {
+		// the card reached Reap but saving it failed
+		var reapCards []reap.Card
+		reapClient := &stubReapClient{
+			createCard: func(params reap.CreateCardParams) (*reap.CreateCardResponse, error) {
+				reapCards = append(reapCards, reap.Card{ID: "external" + params.Meta.ID, Meta: reap.Meta{ID: params.Meta.ID}})
+				return &reap.CreateCardResponse{CardID: "external" + params.Meta.ID}, nil
+			},
+			getCards: func(params reap.GetCardsParams) (*reap.GetCardsResponse, error) {
+				resp := &reap.GetCardsResponse{}
+				if params.Status == reap.CardStatusActive {
+					resp.Items = reapCards
+				}
+				return resp, nil
+			},
+		}
+		cardRepo := &failingCardRepository{CardRepository: memory.NewCardRepository(), saveErr: errors.New("db is down")}
+		appSvc := acme.NewMakerCheckerCardApplicationService(acme.NewReapCardService(reapClient, cardRepo), memory.NewCardApplicationRepository(), 0)
+
+		app, err := appSvc.SubmitCardApplication(ctx, "alice", params)
+		require.NoError(t, err)
+
+		app, err = appSvc.ApproveCardApplication(ctx, app.ID, "bob")
+		require.NoError(t, err)
+		assert.Equal(t, acme.CardApplicationFailed, app.Status)
+		require.NotEmpty(t, app.CardID)
+		require.Len(t, reapCards, 1)
+
+		// approving again completes the card created in Reap
+		cardRepo.saveErr = nil
+		app, err = appSvc.ApproveCardApplication(ctx, app.ID, "bob")
+		require.NoError(t, err)
+		assert.Equal(t, acme.CardApplicationIssued, app.Status)
+		assert.Len(t, reapCards, 1)
+
+		externalID, err := cardRepo.GetExternalID(ctx, app.CardID)
+		require.NoError(t, err)
+		assert.Equal(t, "external"+app.CardID, externalID)
+	})
+
+	t.Run("aborted", func(t *testing.T) {
+		// the burner card could not be saved and was terminated
+		var terminated int
+		reapClient := &stubReapClient{
+			createCard: func(params reap.CreateCardParams) (*reap.CreateCardResponse, error) {
+				return &reap.CreateCardResponse{CardID: "external" + params.Meta.ID}, nil
+			},
+			updateCardStatus: func(reap.UpdateCardStatusParams) (*reap.UpdateCardStatusResponse, error) {
+				terminated++
+				return &reap.UpdateCardStatusResponse{}, nil
+			},
+			getCards: func(reap.GetCardsParams) (*reap.GetCardsResponse, error) {
+				return &reap.GetCardsResponse{}, nil
+			},
+		}
+		burnerRepo := &failingBurnerCardRepository{
+			BurnerCardRepository: memory.NewBurnerCardRepository(),
+			saveErr:              errors.New("db is down"),
+		}
+		cardSvc := acme.NewReapCardService(reapClient, memory.NewCardRepository(), acme.WithBurnerCardRepository(burnerRepo))
+		appSvc := acme.NewMakerCheckerCardApplicationService(cardSvc, memory.NewCardApplicationRepository(), 0)
+
+		params := params
+		params.Mode = acme.CardModeSingleUse
+		app, err := appSvc.SubmitCardApplication(ctx, "alice", params)
+		require.NoError(t, err)
+
+		app, err = appSvc.ApproveCardApplication(ctx, app.ID, "bob")
+		require.NoError(t, err)
+		assert.Equal(t, acme.CardApplicationFailed, app.Status)
+		assert.Equal(t, 1, terminated)
+		assert.Empty(t, app.CardID)
+
+		// approving again issues another card
+		burnerRepo.saveErr = nil
+		app, err = appSvc.ApproveCardApplication(ctx, app.ID, "bob")
+		require.NoError(t, err)
+		assert.Equal(t, acme.CardApplicationIssued, app.Status)
+		cards := burnerCards(t, burnerRepo)
+		require.Len(t, cards, 1)
+		assert.Equal(t, app.CardID, cards[0].CardID)
+	})
+
+	t.Run("recovered", func(t *testing.T) {
+		appSvc, appRepo, created, _ := newService()
+
+		stuck, err := appSvc.SubmitCardApplication(ctx, "alice", params)
+		require.NoError(t, err)
+		fresh, err := appSvc.SubmitCardApplication(ctx, "alice", params)
+		require.NoError(t, err)
+
+		// the process stopped after the approvals were saved
+		for _, app := range []*acme.CardApplication{stuck, fresh} {
+			app.Status, app.Checker, app.CardID = acme.CardApplicationApproved, "bob", acme.NewCardID()
+			require.NoError(t, appRepo.UpdateCardApplication(ctx, app, acme.CardApplicationPending))
+		}
+		appRepo.ages[stuck.ID] = time.Hour
+
+		require.NoError(t, appSvc.RecoverCardApplications(ctx))
+		assert.Equal(t, 1, *created)
+
+		app, err := appSvc.GetCardApplication(ctx, stuck.ID)
+		require.NoError(t, err)
+		assert.Equal(t, acme.CardApplicationIssued, app.Status)
+		assert.Equal(t, stuck.CardID, app.CardID)
+
+		// the application being issued is left to its approval
+		app, err = appSvc.GetCardApplication(ctx, fresh.ID)
+		require.NoError(t, err)
+		assert.Equal(t, acme.CardApplicationApproved, app.Status)
+	})
+
+	t.Run("issued once", func(t *testing.T) {
+		appSvc, appRepo, created, _ := newService()
+
+		app, err := appSvc.SubmitCardApplication(ctx, "alice", params)
+		require.NoError(t, err)
+		app.Status, app.Checker, app.CardID = acme.CardApplicationApproved, "bob", acme.NewCardID()
+		require.NoError(t, appRepo.UpdateCardApplication(ctx, app, acme.CardApplicationPending))
+		appRepo.ages[app.ID] = time.Hour
+
+		// the card is left to the instance issuing it
+		locked, err := appRepo.LockCardApplication(ctx, app.ID, func(ctx context.Context) error {
+			return appSvc.RecoverCardApplications(ctx)
+		})
+		require.NoError(t, err)
+		assert.True(t, locked)
+		assert.Zero(t, *created)
+
+		require.NoError(t, appSvc.RecoverCardApplications(ctx))
+		assert.Equal(t, 1, *created)
+
+		// the issued application is read again under the lock
+		require.NoError(t, appSvc.RecoverCardApplications(ctx))
+		assert.Equal(t, 1, *created)
+
+		got, err := appSvc.GetCardApplication(ctx, app.ID)
+		require.NoError(t, err)
+		assert.Equal(t, acme.CardApplicationIssued, got.Status)
+		assert.Equal(t, []string{acme.CardApplicationSubmittedAction, acme.CardApplicationIssuedAction}, actions(got))
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		appSvc, appRepo, created, _ := newService()
+
+		stale, err := appSvc.SubmitCardApplication(ctx, "alice", params)
+		require.NoError(t, err)
+		fresh, err := appSvc.SubmitCardApplication(ctx, "alice", params)
+		require.NoError(t, err)
+		appRepo.ages[stale.ID] = 8 * 24 * time.Hour
+
+		require.NoError(t, appSvc.ExpireCardApplications(ctx))
+
+		app, err := appSvc.GetCardApplication(ctx, stale.ID)
+		require.NoError(t, err)
+		assert.Equal(t, acme.CardApplicationExpired, app.Status)
+		assert.Equal(t, []string{acme.CardApplicationSubmittedAction, acme.CardApplicationExpiredAction}, actions(app))
+
+		_, err = appSvc.ApproveCardApplication(ctx, stale.ID, "bob")
+		assert.ErrorIs(t, err, acme.ErrCardApplicationStatus)
+
+		app, err = appSvc.GetCardApplication(ctx, fresh.ID)
+		require.NoError(t, err)
+		assert.Equal(t, acme.CardApplicationPending, app.Status)
+
+		// stale applications are expired on approval before the worker runs
+		appRepo.ages[fresh.ID] = 8 * 24 * time.Hour
+		_, err = appSvc.ApproveCardApplication(ctx, fresh.ID, "bob")
+		assert.ErrorIs(t, err, acme.ErrCardApplicationStatus)
+		assert.Zero(t, *created)
+	})
+
+	t.Run("decided before expired", func(t *testing.T) {
+		appRepo := &staleCardApplicationRepository{agedCardApplicationRepository: newAgedCardApplicationRepository()}
+		appSvc := acme.NewMakerCheckerCardApplicationService(newCardService(), appRepo, 0)
+
+		app, err := appSvc.SubmitCardApplication(ctx, "alice", params)
+		require.NoError(t, err)
+		appRepo.ages[app.ID] = 8 * 24 * time.Hour
+
+		// the application is read as pending before carol rejects it
+		appRepo.stale, err = appRepo.GetCardApplication(ctx, app.ID)
+		require.NoError(t, err)
+		app.Status, app.Checker, app.RejectionReason = acme.CardApplicationRejected, "carol", "id document expired"
+		require.NoError(t, appRepo.UpdateCardApplication(ctx, app, acme.CardApplicationPending))
+
+		_, err = appSvc.ApproveCardApplication(ctx, app.ID, "bob")
+		assert.ErrorIs(t, err, acme.ErrCardApplicationStatus)
+		assert.ErrorContains(t, err, "the application is rejected")
+
+		got, err := appSvc.GetCardApplication(ctx, app.ID)
+		require.NoError(t, err)
+		assert.Equal(t, acme.CardApplicationRejected, got.Status)
+		assert.Equal(t, []string{acme.CardApplicationSubmittedAction}, actions(got))
+	})
+}
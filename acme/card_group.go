@@ -0,0 +1,346 @@
+package acme
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/stevenferrer/acme-cards-api/reap"
+)
+
+var (
+	// ErrInvalidCardGroup is returned for missing names, unknown periods or
+	// invalid budgets
+	ErrInvalidCardGroup = errors.New("invalid card group")
+	// ErrCardGroupNotFound is returned when the group does not exist, or
+	// when the card is in no group
+	ErrCardGroupNotFound = errors.New("card group not found")
+	// ErrCardGroupMemberExists is returned when the card is already in a
+	// group
+	ErrCardGroupMemberExists = errors.New("card is already in a card group")
+	// ErrCardGroupMemberNotFound is returned when the card is not in the
+	// group
+	ErrCardGroupMemberNotFound = errors.New("card group member not found")
+	// ErrCardGroupBudgetExceeded is returned when a top-up of a member card
+	// exceeds the remaining budget of its group
+	ErrCardGroupBudgetExceeded = errors.New("card group budget exceeded")
+)
+
+// Card group budget periods, the periods start at midnight UTC and the
+// weeks start on Monday
+const (
+	CardGroupPeriodWeek    = "week"
+	CardGroupPeriodMonth   = "month"
+	CardGroupPeriodQuarter = "quarter"
+	CardGroupPeriodYear    = "year"
+)
+
+// CardGroup is a department of cards sharing a budget per period, a card is
+// in at most one group.
+type CardGroup struct {
+	ID     int64
+	Name   string
+	Budget Money
+	Period string
+	// CardIDs are the member cards, they are only set on GetCardGroup
+	CardIDs   []string
+	CreatedAt time.Time
+}
+
+// CardGroupConsumption is the budget consumption of a group in the current
+// period
+type CardGroupConsumption struct {
+	GroupID     int64
+	Period      string
+	PeriodStart time.Time
+	// PeriodEnd is exclusive
+	PeriodEnd time.Time
+	Budget    Money
+	// Spent is the posted spend of the member cards in the period, net of
+	// the refunds
+	Spent Money
+	// Allocated is the available credit of the member cards, it is spent
+	// from the budget once used
+	Allocated Money
+	// Remaining is the budget left to top up the member cards, it is
+	// negative when the group is over budget
+	Remaining Money
+	Cards     []CardGroupCardConsumption
+}
+
+type CardGroupCardConsumption struct {
+	CardID          string
+	Spent           Money
+	AvailableCredit Money
+}
+
+type CardGroupRepository interface {
+	// SaveCardGroup creates the group when its ID is zero and replaces it
+	// otherwise, it sets the ID and the creation time
+	SaveCardGroup(context.Context, *CardGroup) error
+	GetCardGroup(ctx context.Context, id int64) (*CardGroup, error)
+	FindCardGroups(context.Context) ([]CardGroup, error)
+	// DeleteCardGroup deletes the group and its memberships
+	DeleteCardGroup(ctx context.Context, id int64) error
+
+	// AddCardGroupMember returns ErrCardGroupMemberExists when the card is
+	// already in a group
+	AddCardGroupMember(ctx context.Context, groupID int64, cardID string) error
+	RemoveCardGroupMember(ctx context.Context, groupID int64, cardID string) error
+	// FindCardGroupMembers returns the card IDs of the group
+	FindCardGroupMembers(ctx context.Context, groupID int64) ([]string, error)
+	// GetCardGroupByCard returns the group of the card, ErrCardGroupNotFound
+	// is returned when the card is in no group
+	GetCardGroupByCard(ctx context.Context, cardID string) (*CardGroup, error)
+
+	// LockCardGroup runs fn while holding the lock of the group, it waits
+	// for the lock so that the budget checks and the top-ups of the group
+	// run one at a time across the instances
+	LockCardGroup(ctx context.Context, id int64, fn func(context.Context) error) error
+}
+
+type CardGroupService interface {
+	CreateCardGroup(context.Context, CardGroup) (*CardGroup, error)
+	// GetCardGroup returns the group with its member cards
+	GetCardGroup(ctx context.Context, id int64) (*CardGroup, error)
+	ListCardGroups(context.Context) ([]CardGroup, error)
+	UpdateCardGroup(context.Context, CardGroup) (*CardGroup, error)
+	DeleteCardGroup(ctx context.Context, id int64) error
+
+	AddCardGroupMember(ctx context.Context, groupID int64, cardID string) error
+	RemoveCardGroupMember(ctx context.Context, groupID int64, cardID string) error
+
+	// GetCardGroupConsumption computes the consumption of the budget in the
+	// current period from the member card transactions
+	GetCardGroupConsumption(ctx context.Context, id int64) (*CardGroupConsumption, error)
+}
+
+// TransactionCardGroupService implements CardGroupService
+type TransactionCardGroupService struct {
+	txSource  TransactionSource
+	cardSvc   CardService
+	groupRepo CardGroupRepository
+}
+
+var _ CardGroupService = (*TransactionCardGroupService)(nil)
+
+func NewTransactionCardGroupService(
+	txSource TransactionSource,
+	cardSvc CardService,
+	groupRepo CardGroupRepository,
+) *TransactionCardGroupService {
+	return &TransactionCardGroupService{
+		txSource:  txSource,
+		cardSvc:   cardSvc,
+		groupRepo: groupRepo,
+	}
+}
+
+func (s *TransactionCardGroupService) CreateCardGroup(ctx context.Context, group CardGroup) (*CardGroup, error) {
+	group.ID = 0
+	return s.saveCardGroup(ctx, group)
+}
+
+func (s *TransactionCardGroupService) UpdateCardGroup(ctx context.Context, group CardGroup) (*CardGroup, error) {
+	_, err := s.groupRepo.GetCardGroup(ctx, group.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.saveCardGroup(ctx, group)
+}
+
+func (s *TransactionCardGroupService) saveCardGroup(ctx context.Context, group CardGroup) (*CardGroup, error) {
+	if group.Name == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidCardGroup)
+	}
+
+	switch group.Period {
+	case CardGroupPeriodWeek, CardGroupPeriodMonth, CardGroupPeriodQuarter, CardGroupPeriodYear:
+	default:
+		return nil, fmt.Errorf("%w: period %q", ErrInvalidCardGroup, group.Period)
+	}
+
+	if group.Budget.Sign() <= 0 {
+		return nil, fmt.Errorf("%w: budget must be positive", ErrInvalidCardGroup)
+	}
+
+	if group.Budget.Currency() != reap.AccountCurrency {
+		return nil, fmt.Errorf("%w: budget must be in %s", ErrInvalidCardGroup, reap.AccountCurrency)
+	}
+
+	group.CardIDs = nil
+	err := s.groupRepo.SaveCardGroup(ctx, &group)
+	if err != nil {
+		return nil, fmt.Errorf("save card group: %w", err)
+	}
+
+	return &group, nil
+}
+
+func (s *TransactionCardGroupService) GetCardGroup(ctx context.Context, id int64) (*CardGroup, error) {
+	group, err := s.groupRepo.GetCardGroup(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	group.CardIDs, err = s.groupRepo.FindCardGroupMembers(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("find card group members: %w", err)
+	}
+
+	return group, nil
+}
+
+func (s *TransactionCardGroupService) ListCardGroups(ctx context.Context) ([]CardGroup, error) {
+	return s.groupRepo.FindCardGroups(ctx)
+}
+
+func (s *TransactionCardGroupService) DeleteCardGroup(ctx context.Context, id int64) error {
+	return s.groupRepo.DeleteCardGroup(ctx, id)
+}
+
+func (s *TransactionCardGroupService) AddCardGroupMember(ctx context.Context, groupID int64, cardID string) error {
+	_, err := s.groupRepo.GetCardGroup(ctx, groupID)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.cardSvc.GetCard(ctx, cardID)
+	if err != nil {
+		return fmt.Errorf("get card: %w", err)
+	}
+
+	return s.groupRepo.LockCardGroup(ctx, groupID, func(ctx context.Context) error {
+		group, err := s.GetCardGroup(ctx, groupID)
+		if err != nil {
+			return err
+		}
+
+		// the spend and the available credit of the card count against the
+		// budget once it joins, a card that does not fit is refused
+		if !slices.Contains(group.CardIDs, cardID) {
+			group.CardIDs = append(group.CardIDs, cardID)
+		}
+		consumption, err := cardGroupConsumption(ctx, s.txSource, s.cardSvc, *group, time.Now())
+		if err != nil {
+			return fmt.Errorf("card group consumption: %w", err)
+		}
+		if consumption.Remaining.Sign() < 0 {
+			return fmt.Errorf("%w: the card would leave %s remaining in %q",
+				ErrCardGroupBudgetExceeded, consumption.Remaining, group.Name)
+		}
+
+		return s.groupRepo.AddCardGroupMember(ctx, groupID, cardID)
+	})
+}
+
+func (s *TransactionCardGroupService) RemoveCardGroupMember(ctx context.Context, groupID int64, cardID string) error {
+	return s.groupRepo.RemoveCardGroupMember(ctx, groupID, cardID)
+}
+
+func (s *TransactionCardGroupService) GetCardGroupConsumption(ctx context.Context, id int64) (*CardGroupConsumption, error) {
+	group, err := s.GetCardGroup(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return cardGroupConsumption(ctx, s.txSource, s.cardSvc, *group, time.Now())
+}
+
+// cardGroupConsumption sums the posted transactions and the available
+// credit of the member cards in the period of now, the member cards must
+// be set on the group.
+func cardGroupConsumption(ctx context.Context, txSource TransactionSource, cardSvc CardService, group CardGroup, now time.Time) (*CardGroupConsumption, error) {
+	start, end := cardGroupPeriod(group.Period, now)
+	currency := group.Budget.Currency()
+	consumption := CardGroupConsumption{
+		GroupID:     group.ID,
+		Period:      group.Period,
+		PeriodStart: start,
+		PeriodEnd:   end,
+		Budget:      group.Budget,
+		Cards:       make([]CardGroupCardConsumption, 0, len(group.CardIDs)),
+	}
+
+	// the amounts start in the budget currency so that a card or a
+	// transaction in another currency returns ErrCurrencyMismatch
+	spent, allocated := NewMoney(0, currency), NewMoney(0, currency)
+	for _, cardID := range group.CardIDs {
+		card, err := cardSvc.GetCard(ctx, cardID)
+		if err != nil {
+			return nil, fmt.Errorf("get card %q: %w", cardID, err)
+		}
+
+		// debits are negative, the spend is reported as a positive amount
+		cardSpent := NewMoney(0, currency)
+		err = txSource.EachCardTransaction(ctx, cardID, DateRange{From: start}, func(t Transaction) error {
+			if !t.IsPosted() {
+				return nil
+			}
+
+			next, err := cardSpent.Sub(t.SignedAmount())
+			if err != nil {
+				return fmt.Errorf("transaction %q: %w", t.ID, err)
+			}
+			cardSpent = next
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("each card %q transaction: %w", cardID, err)
+		}
+
+		spent, err = spent.Add(cardSpent)
+		if err != nil {
+			return nil, fmt.Errorf("card %q spend: %w", cardID, err)
+		}
+		allocated, err = allocated.Add(card.AvailableCredit)
+		if err != nil {
+			return nil, fmt.Errorf("card %q available credit: %w", cardID, err)
+		}
+
+		consumption.Cards = append(consumption.Cards, CardGroupCardConsumption{
+			CardID:          cardID,
+			Spent:           cardSpent,
+			AvailableCredit: card.AvailableCredit,
+		})
+	}
+
+	remaining, err := group.Budget.Sub(spent)
+	if err != nil {
+		return nil, fmt.Errorf("remaining budget: %w", err)
+	}
+	remaining, err = remaining.Sub(allocated)
+	if err != nil {
+		return nil, fmt.Errorf("remaining budget: %w", err)
+	}
+
+	consumption.Spent = spent
+	consumption.Allocated = allocated
+	consumption.Remaining = remaining
+
+	return &consumption, nil
+}
+
+// cardGroupPeriod returns the start and the exclusive end of the period
+// containing t
+func cardGroupPeriod(period string, t time.Time) (time.Time, time.Time) {
+	t = t.UTC()
+	switch period {
+	case CardGroupPeriodWeek:
+		start := spendPeriodStart(SpendPeriodWeek, t)
+		return start, start.AddDate(0, 0, 7)
+	case CardGroupPeriodQuarter:
+		month := time.Month((int(t.Month())-1)/3*3 + 1)
+		start := time.Date(t.Year(), month, 1, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(0, 3, 0)
+	case CardGroupPeriodYear:
+		start := time.Date(t.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(1, 0, 0)
+	default:
+		start := spendPeriodStart(SpendPeriodMonth, t)
+		return start, start.AddDate(0, 1, 0)
+	}
+}
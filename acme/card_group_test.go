@@ -0,0 +1,302 @@
+package acme_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/acme/memory"
+	"github.com/stevenferrer/acme-cards-api/reap"
+)
+
+// lockCountingCardGroupRepository counts the runs under the group lock
+type lockCountingCardGroupRepository struct {
+	*memory.CardGroupRepository
+
+	locked atomic.Int32
+}
+
+func (r *lockCountingCardGroupRepository) LockCardGroup(ctx context.Context, id int64, fn func(context.Context) error) error {
+	return r.CardGroupRepository.LockCardGroup(ctx, id, func(ctx context.Context) error {
+		r.locked.Add(1)
+		return fn(ctx)
+	})
+}
+
+// cardTransactionSource serves the transactions of each card from the start
+// of the date range
+type cardTransactionSource map[string][]acme.Transaction
+
+func (s cardTransactionSource) EachTransaction(context.Context, acme.DateRange, func(acme.Transaction) error) error {
+	return nil
+}
+
+func (s cardTransactionSource) EachCardTransaction(_ context.Context, cardID string, dateRange acme.DateRange, fn func(acme.Transaction) error) error {
+	for _, t := range s[cardID] {
+		if t.CreatedAt.Before(dateRange.From) {
+			continue
+		}
+		if err := fn(t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestCardGroupService(t *testing.T) {
+	ctx := context.Background()
+
+	usd := func(amount string) acme.Money { return acme.MustParseMoney(amount, "USD") }
+
+	cardSvc := newCardService(
+		acme.Card{ID: "card1", AvailableCredit: usd("100.00")},
+		acme.Card{ID: "card2", AvailableCredit: usd("50.00")},
+		acme.Card{ID: "card3", AvailableCredit: usd("10.00")},
+		acme.Card{ID: "card4", AvailableCredit: acme.MustParseMoney("10.00", "EUR")},
+		acme.Card{ID: "card5", AvailableCredit: usd("10.00")},
+	)
+
+	now := time.Now()
+	lastYear := now.AddDate(-1, 0, 0)
+	txSource := cardTransactionSource{
+		"card1": {
+			{CardID: "card1", Category: "purchase", Status: "settled", Amount: usd("200.00"), CreatedAt: now},
+			{CardID: "card1", Category: "refund", Status: "settled", Amount: usd("20.00"), CreatedAt: now},
+			{CardID: "card1", Category: "purchase", Status: "declined", Amount: usd("30.00"), CreatedAt: now},
+			{CardID: "card1", Category: "purchase", Status: "settled", Amount: usd("500.00"), CreatedAt: lastYear},
+		},
+		"card2": {
+			{CardID: "card2", Category: "purchase", Status: "pending", Amount: usd("5.00"), CreatedAt: now},
+		},
+		"card5": {
+			{CardID: "card5", Category: "purchase", Status: "settled", Amount: acme.MustParseMoney("5.00", "EUR"), CreatedAt: now},
+		},
+	}
+
+	groupRepo := memory.NewCardGroupRepository()
+	groupSvc := acme.NewTransactionCardGroupService(txSource, cardSvc, groupRepo)
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, group := range []acme.CardGroup{
+			{Budget: usd("1000.00"), Period: acme.CardGroupPeriodMonth},
+			{Name: "Marketing", Budget: usd("1000.00"), Period: "fortnight"},
+			{Name: "Marketing", Budget: usd("0.00"), Period: acme.CardGroupPeriodMonth},
+			{Name: "Marketing", Budget: acme.MustParseMoney("1000.00", "EUR"), Period: acme.CardGroupPeriodMonth},
+		} {
+			_, err := groupSvc.CreateCardGroup(ctx, group)
+			assert.ErrorIs(t, err, acme.ErrInvalidCardGroup)
+		}
+
+		_, err := groupSvc.UpdateCardGroup(ctx, acme.CardGroup{ID: 42, Name: "Sales", Budget: usd("1.00"), Period: acme.CardGroupPeriodWeek})
+		assert.ErrorIs(t, err, acme.ErrCardGroupNotFound)
+	})
+
+	marketing, err := groupSvc.CreateCardGroup(ctx, acme.CardGroup{Name: "Marketing", Budget: usd("1000.00"), Period: acme.CardGroupPeriodYear})
+	require.NoError(t, err)
+	sales, err := groupSvc.CreateCardGroup(ctx, acme.CardGroup{Name: "Sales", Budget: usd("500.00"), Period: acme.CardGroupPeriodMonth})
+	require.NoError(t, err)
+
+	t.Run("members", func(t *testing.T) {
+		require.NoError(t, groupSvc.AddCardGroupMember(ctx, marketing.ID, "card1"))
+		require.NoError(t, groupSvc.AddCardGroupMember(ctx, marketing.ID, "card2"))
+
+		err := groupSvc.AddCardGroupMember(ctx, sales.ID, "card1")
+		assert.ErrorIs(t, err, acme.ErrCardGroupMemberExists)
+
+		err = groupSvc.AddCardGroupMember(ctx, sales.ID, "card9")
+		assert.ErrorIs(t, err, acme.ErrCardNotFound)
+
+		err = groupSvc.AddCardGroupMember(ctx, 42, "card3")
+		assert.ErrorIs(t, err, acme.ErrCardGroupNotFound)
+
+		// a card whose credit does not fit the remaining budget is refused
+		tiny, err := groupSvc.CreateCardGroup(ctx, acme.CardGroup{Name: "Tiny", Budget: usd("5.00"), Period: acme.CardGroupPeriodWeek})
+		require.NoError(t, err)
+		err = groupSvc.AddCardGroupMember(ctx, tiny.ID, "card3")
+		assert.ErrorIs(t, err, acme.ErrCardGroupBudgetExceeded)
+		_, err = groupRepo.GetCardGroupByCard(ctx, "card3")
+		assert.ErrorIs(t, err, acme.ErrCardGroupNotFound)
+		require.NoError(t, groupRepo.DeleteCardGroup(ctx, tiny.ID))
+
+		// the amounts of the cards are in the budget currency
+		err = groupSvc.AddCardGroupMember(ctx, sales.ID, "card4")
+		assert.ErrorIs(t, err, acme.ErrCurrencyMismatch)
+		err = groupSvc.AddCardGroupMember(ctx, sales.ID, "card5")
+		assert.ErrorIs(t, err, acme.ErrCurrencyMismatch)
+
+		group, err := groupSvc.GetCardGroup(ctx, marketing.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"card1", "card2"}, group.CardIDs)
+	})
+
+	t.Run("consumption", func(t *testing.T) {
+		consumption, err := groupSvc.GetCardGroupConsumption(ctx, marketing.ID)
+		require.NoError(t, err)
+
+		// the declined and last year's transactions are not counted
+		assert.Equal(t, time.Date(now.UTC().Year(), time.January, 1, 0, 0, 0, 0, time.UTC), consumption.PeriodStart)
+		assert.Equal(t, consumption.PeriodStart.AddDate(1, 0, 0), consumption.PeriodEnd)
+		assert.Equal(t, "185.00", consumption.Spent.Decimal())
+		assert.Equal(t, "150.00", consumption.Allocated.Decimal())
+		assert.Equal(t, "665.00", consumption.Remaining.Decimal())
+		require.Len(t, consumption.Cards, 2)
+		assert.Equal(t, "180.00", consumption.Cards[0].Spent.Decimal())
+		assert.Equal(t, "5.00", consumption.Cards[1].Spent.Decimal())
+
+		consumption, err = groupSvc.GetCardGroupConsumption(ctx, sales.ID)
+		require.NoError(t, err)
+		assert.Empty(t, consumption.Cards)
+		assert.Equal(t, "500.00", consumption.Remaining.Decimal())
+	})
+
+	t.Run("update", func(t *testing.T) {
+		group := *marketing
+		group.Budget = usd("250.00")
+		_, err := groupSvc.UpdateCardGroup(ctx, group)
+		require.NoError(t, err)
+
+		// the members are kept and the group is over budget
+		consumption, err := groupSvc.GetCardGroupConsumption(ctx, marketing.ID)
+		require.NoError(t, err)
+		assert.Len(t, consumption.Cards, 2)
+		assert.Equal(t, "-85.00", consumption.Remaining.Decimal())
+	})
+}
+
+func TestReapCardServiceCardGroupBudget(t *testing.T) {
+	ctx := context.Background()
+
+	cardRepo := memory.NewCardRepository()
+	require.NoError(t, cardRepo.SaveCardID(ctx, "card1", "external1"))
+	require.NoError(t, cardRepo.SaveCardID(ctx, "card2", "external2"))
+
+	credits := map[string]string{"external1": "100.00", "external2": "0.00"}
+	var adjusted []reap.AdjustCardBalanceParams
+	reapClient := &stubReapClient{
+		getCard: func(params reap.GetCardParams) (*reap.GetCardResponse, error) {
+			return &reap.GetCardResponse{Card: reap.Card{AvailableCredit: credits[params.CardID]}}, nil
+		},
+		getCardTransactions: func(params reap.GetCardTransactionsParams) (*reap.GetCardTransactionsResponse, error) {
+			resp := &reap.GetCardTransactionsResponse{}
+			if params.CardID == "external1" {
+				resp.Transactions = []reap.Transaction{{
+					ID:           "tx1",
+					CardID:       "external1",
+					Category:     "purchase",
+					BillAmount:   "300.00",
+					BillCurrency: "USD",
+					Status:       "settled",
+					CreatedAt:    time.Now(),
+				}}
+			}
+			return resp, nil
+		},
+		adjustCardBalance: func(params reap.AdjustCardBalanceParams) (*reap.AdjustCardBalanceResponse, error) {
+			adjusted = append(adjusted, params)
+			return &reap.AdjustCardBalanceResponse{ID: "adjustment1", AvailableCredit: "0.00"}, nil
+		},
+	}
+
+	groupRepo := &lockCountingCardGroupRepository{CardGroupRepository: memory.NewCardGroupRepository()}
+	group := &acme.CardGroup{Name: "Marketing", Budget: acme.MustParseMoney("500.00", "USD"), Period: acme.CardGroupPeriodMonth}
+	require.NoError(t, groupRepo.SaveCardGroup(ctx, group))
+	require.NoError(t, groupRepo.AddCardGroupMember(ctx, group.ID, "card1"))
+
+	cardSvc := acme.NewReapCardService(reapClient, cardRepo, acme.WithCardGroupRepository(groupRepo))
+
+	topUp := func(cardID, amount string) error {
+		_, err := cardSvc.AdjustCardBalance(ctx, cardID, acme.AdjustCardBalanceParams{
+			Type:   acme.BalanceAdjustmentTopUp,
+			Amount: acme.MustParseMoney(amount, "USD"),
+		})
+		return err
+	}
+
+	// 300.00 spent and 100.00 allocated leave 100.00 of the budget
+	err := topUp("card1", "100.01")
+	assert.ErrorIs(t, err, acme.ErrCardGroupBudgetExceeded)
+	assert.Empty(t, adjusted)
+
+	require.NoError(t, topUp("card1", "100.00"))
+	assert.Len(t, adjusted, 1)
+	assert.Equal(t, int32(2), groupRepo.locked.Load())
+
+	// withdrawals and cards in no group are not limited
+	_, err = cardSvc.AdjustCardBalance(ctx, "card1", acme.AdjustCardBalanceParams{
+		Type:   acme.BalanceAdjustmentWithdraw,
+		Amount: acme.MustParseMoney("1000.00", "USD"),
+	})
+	require.NoError(t, err)
+	require.NoError(t, topUp("card2", "1000.00"))
+	assert.Len(t, adjusted, 3)
+}
+
+func TestReapCardServiceCardGroupBudgetConcurrent(t *testing.T) {
+	ctx := context.Background()
+
+	cardRepo := memory.NewCardRepository()
+	require.NoError(t, cardRepo.SaveCardID(ctx, "card1", "external1"))
+
+	var mu sync.Mutex
+	credit := acme.MustParseMoney("0.00", "USD")
+	reapClient := &stubReapClient{
+		getCard: func(reap.GetCardParams) (*reap.GetCardResponse, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			return &reap.GetCardResponse{Card: reap.Card{AvailableCredit: credit.Decimal()}}, nil
+		},
+		getCardTransactions: func(reap.GetCardTransactionsParams) (*reap.GetCardTransactionsResponse, error) {
+			return &reap.GetCardTransactionsResponse{}, nil
+		},
+		adjustCardBalance: func(params reap.AdjustCardBalanceParams) (*reap.AdjustCardBalanceResponse, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			var err error
+			credit, err = credit.Add(acme.MustParseMoney(params.Amount.String(), "USD"))
+			if err != nil {
+				return nil, err
+			}
+			return &reap.AdjustCardBalanceResponse{ID: "adjustment", AvailableCredit: credit.Decimal()}, nil
+		},
+	}
+
+	groupRepo := &lockCountingCardGroupRepository{CardGroupRepository: memory.NewCardGroupRepository()}
+	group := &acme.CardGroup{Name: "Marketing", Budget: acme.MustParseMoney("100.00", "USD"), Period: acme.CardGroupPeriodMonth}
+	require.NoError(t, groupRepo.SaveCardGroup(ctx, group))
+	require.NoError(t, groupRepo.AddCardGroupMember(ctx, group.ID, "card1"))
+
+	cardSvc := acme.NewReapCardService(reapClient, cardRepo, acme.WithCardGroupRepository(groupRepo))
+
+	// only one of the top-ups fits the budget
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = cardSvc.AdjustCardBalance(ctx, "card1", acme.AdjustCardBalanceParams{
+				Type:   acme.BalanceAdjustmentTopUp,
+				Amount: acme.MustParseMoney("60.00", "USD"),
+			})
+		}()
+	}
+	wg.Wait()
+
+	exceeded := 0
+	for _, err := range errs {
+		if errors.Is(err, acme.ErrCardGroupBudgetExceeded) {
+			exceeded++
+		} else {
+			require.NoError(t, err)
+		}
+	}
+	assert.Equal(t, 1, exceeded)
+	assert.Equal(t, "60.00", credit.Decimal())
+}
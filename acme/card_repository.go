@@ -1,10 +1,30 @@
 package acme
 
-import "context"
+import (
+	"context"
+	"errors"
+)
+
+var (
+	// ErrCardNotFound is returned when the card does not exist
+	ErrCardNotFound = errors.New("card not found")
+	// ErrCardExists is returned when saving a card ID that already exists
+	ErrCardExists = errors.New("card already exists")
+)
 
 type CardRepository interface {
 	SaveCardID(ctx context.Context, cardID string, externalCardID string) error
+	// FindCardIDs returns the card IDs, most recently saved first
 	FindCardIDs(context.Context) ([]string, error)
 	GetExternalID(ctx context.Context, cardID string) (externalCardID string, err error)
+	// GetExternalIDMapping maps external IDs to card IDs, unknown external IDs are omitted
 	GetExternalIDMapping(ctx context.Context, externalIDs ...string) (map[string]string, error)
+
+	// SaveCardExpiry sets the expiry of the card, a zero ValidUntil clears it
+	SaveCardExpiry(context.Context, CardExpiry) error
+	// GetCardExpiry returns the expiry of the card, ValidUntil is zero when
+	// the card has none
+	GetCardExpiry(ctx context.Context, cardID string) (*CardExpiry, error)
+	// FindCardExpiries returns the cards with an expiry, earliest first
+	FindCardExpiries(context.Context) ([]CardExpiry, error)
 }
@@ -2,9 +2,25 @@ package acme
 
 import (
 	"context"
+	"errors"
+	"slices"
+	"strings"
 	"time"
 )
 
+// ErrInvalidCardExpiry is returned for expiries in the past or unknown
+// expiry actions
+var ErrInvalidCardExpiry = errors.New("invalid card expiry")
+
+// ErrInvalidCardParams is returned when the cardholder details required to
+// create a card are missing
+var ErrInvalidCardParams = errors.New("invalid card params")
+
+// ErrCardCreationAborted is returned when completing a card creation whose
+// card was terminated because it could not be set up, the card must be
+// created again with another ID
+var ErrCardCreationAborted = errors.New("card creation aborted")
+
 type CardService interface {
 	GetAccountBalance(context.Context) (*AccountBalance, error)
 
@@ -18,53 +34,147 @@ type CardService interface {
 		params ListCardsParams,
 	) (*ListCardsResponse, error)
 
+	// UpdateCardStatus freezes, unfreezes or terminates a card
+	UpdateCardStatus(
+		ctx context.Context,
+		cardID string,
+		status string,
+	) error
+
+	// UpdateCardExpiry sets or clears the date the card stops working
+	UpdateCardExpiry(
+		ctx context.Context,
+		cardID string,
+		params UpdateCardExpiryParams,
+	) error
+
+	// AdjustCardBalance tops up or withdraws from the card balance
+	AdjustCardBalance(
+		ctx context.Context,
+		cardID string,
+		params AdjustCardBalanceParams,
+	) (*AdjustCardBalanceResponse, error)
+
 	ListCardTransactions(
 		ctx context.Context,
 		cardID string,
+		params ListCardTransactionsParams,
 	) (*ListCardTransactionsResponse, error)
 
 	ListCardBalanceHistory(
 		ctx context.Context,
 		cardID string,
+		params ListCardBalanceHistoryParams,
 	) (*ListCardBalanceHistoryResponse, error)
 
 	ListTransactions(
 		ctx context.Context,
+		params ListTransactionsParams,
 	) (*ListTransactionsResponse, error)
 }
 
+// Card statuses
+const (
+	CardStatusActive     = "ACTIVE"
+	CardStatusFrozen     = "FROZEN"
+	CardStatusTerminated = "TERMINATED"
+)
+
+// Balance adjustment types
+const (
+	BalanceAdjustmentTopUp    = "TOPUP"
+	BalanceAdjustmentWithdraw = "WITHDRAW"
+)
+
 type AccountBalance struct {
-	AvailableBalance    string
-	AvailableToAllocate string
+	AvailableBalance    Money
+	AvailableToAllocate Money
 }
 
 type CreateCardParams struct {
+	// CardID is the internal ID of the card, it is generated when empty.
+	// Creating a card again with the same ID completes the interrupted
+	// creation instead of issuing a second card, unless the card was
+	// terminated, see ErrCardCreationAborted.
+	CardID string
+
 	FirstName   string
 	LastName    string
 	DOB         string
 	Address     Address
 	ContactInfo ContactInfo
 	IDDocument  IDDocument
+
+	// Mode defaults to CardModeStandard, MerchantID is the merchant of the
+	// merchant-locked cards
+	Mode       string
+	MerchantID string
+
+	// ValidUntil freezes or terminates the card at that time when set, see
+	// UpdateCardExpiryParams, the single-use and merchant-locked cards are
+	// terminated by default
+	ValidUntil   time.Time
+	ExpiryAction string
 }
 
 type CreateCardResponse struct {
 	CardID string
 }
 
-type ListCardsParams struct{}
+type ListCardsParams struct {
+	// Fresh bypasses the card snapshots and fetches the cards from Reap
+	Fresh bool
+	// ValidUntilBefore only lists the cards that expire before it when set
+	ValidUntilBefore time.Time
+}
 type ListCardsResponse struct {
 	Cards []Card
 }
 
+type UpdateCardExpiryParams struct {
+	// ValidUntil clears the expiry when zero
+	ValidUntil time.Time
+	// ExpiryAction is the status of the card at ValidUntil, CardStatusFrozen
+	// or CardStatusTerminated, it defaults to CardStatusFrozen
+	ExpiryAction string
+}
+
+type AdjustCardBalanceParams struct {
+	Type   string
+	Amount Money
+}
+type AdjustCardBalanceResponse struct {
+	ID              string
+	AvailableCredit Money
+}
+
+// DateRange filters results by date, zero values mean no bound
+// except for From which defaults to the current day.
+type DateRange struct {
+	From time.Time
+	To   time.Time
+}
+
+type ListCardTransactionsParams struct {
+	DateRange
+	Limit int
+}
 type ListCardTransactionsResponse struct {
 	Transactions []Transaction
 }
 
+type ListTransactionsParams struct {
+	DateRange
+	Limit int
+}
 type ListTransactionsResponse struct {
 	Transactions []Transaction
 }
 
-type ListCardBalanceHistoryParams struct{}
+type ListCardBalanceHistoryParams struct {
+	DateRange
+	Limit int
+}
 type ListCardBalanceHistoryResponse struct {
 	BalanceChanges []BalanceChange
 }
@@ -91,8 +201,35 @@ type Card struct {
 	ID              string
 	Name            string
 	Last4           string
-	AvailableCredit string
+	Status          string
+	AvailableCredit Money
+	SpendUsage      SpendUsage
 	ContactInfo     ContactInfo
+	// ValidUntil is zero for the cards without an expiry
+	ValidUntil   time.Time
+	ExpiryAction string
+}
+
+// CardExpiry is the date a card stops working, the card is frozen or
+// terminated at ValidUntil and its owner is notified beforehand
+type CardExpiry struct {
+	CardID     string
+	ValidUntil time.Time
+	// Action is the status of the card at ValidUntil
+	Action string
+	// NotifiedAt and ExpiredAt are set once the owner was notified and the
+	// card status was changed
+	NotifiedAt time.Time
+	ExpiredAt  time.Time
+}
+
+// SpendUsage is the amount spent on the card per period
+type SpendUsage struct {
+	Daily   Money
+	Weekly  Money
+	Monthly Money
+	Yearly  Money
+	AllTime Money
 }
 
 type Transaction struct {
@@ -102,8 +239,13 @@ type Transaction struct {
 	Status   string
 	Channel  string
 
-	Amount   string
-	Currency string
+	// Amount is the billed amount in the card currency
+	Amount Money
+	// TransactionAmount is the amount in the merchant local currency
+	TransactionAmount Money
+	// ConversionRate is the exchange rate applied from the transaction
+	// currency to the bill currency, kept as a decimal string
+	ConversionRate string
 
 	Fees     FeeDetails
 	Merchant MerchantDetails
@@ -111,23 +253,82 @@ type Transaction struct {
 	CreatedAt time.Time
 }
 
+// settledTransactionStatuses are the statuses of settled transactions,
+// reap reports them as cleared
+var settledTransactionStatuses = []string{"settled", "cleared"}
+
+// IsSettled reports whether the transaction is settled.
+func (t Transaction) IsSettled() bool {
+	return slices.ContainsFunc(settledTransactionStatuses, func(status string) bool {
+		return strings.EqualFold(t.Status, status)
+	})
+}
+
+// unpostedTransactionStatuses are never posted to the card
+var unpostedTransactionStatuses = []string{"declined", "void"}
+
+// IsPosted reports whether the transaction moved the card balance, declined
+// and voided transactions did not.
+func (t Transaction) IsPosted() bool {
+	return !slices.ContainsFunc(unpostedTransactionStatuses, func(status string) bool {
+		return strings.EqualFold(t.Status, status)
+	})
+}
+
+// creditTransactionCategories are the categories that credit the card
+var creditTransactionCategories = []string{"refund", "reversal", "credit"}
+
+// IsCredit reports whether the transaction credits the card e.g. a refund.
+func (t Transaction) IsCredit() bool {
+	if t.Amount.Sign() < 0 {
+		return false
+	}
+
+	return slices.ContainsFunc(creditTransactionCategories, func(category string) bool {
+		return strings.EqualFold(t.Category, category)
+	})
+}
+
+// SignedAmount returns the amount as seen by the card holder, negative for
+// debits and positive for credits. Reap reports debits as positive amounts.
+func (t Transaction) SignedAmount() Money {
+	if t.Amount.Sign() < 0 || t.IsCredit() {
+		return t.Amount
+	}
+
+	return t.Amount.Neg()
+}
+
+// SignedAmount returns the amount as seen by the card holder, withdrawals
+// are negative.
+func (bc BalanceChange) SignedAmount() Money {
+	if strings.EqualFold(bc.Type, BalanceAdjustmentWithdraw) && bc.Amount.Sign() > 0 {
+		return bc.Amount.Neg()
+	}
+
+	return bc.Amount
+}
+
 type MerchantDetails struct {
-	ID      string
-	Name    string
-	City    string
-	Country string
+	ID          string
+	Name        string
+	City        string
+	PostCode    string
+	State       string
+	Country     string
+	MCCCode     string
+	MCCCategory string
 }
 
 type FeeDetails struct {
-	ATMFees string
-	FXFees  string
+	ATMFees Money
+	FXFees  Money
 }
 
 type BalanceChange struct {
-	ID       string
-	Date     time.Time
-	Type     string
-	Status   string
-	Amount   string
-	Currency string
+	ID     string
+	Date   time.Time
+	Type   string
+	Status string
+	Amount Money
 }
@@ -0,0 +1,360 @@
+package acme_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+)
+
+// fakeCardService is a thread-safe in-memory acme.CardService standing in
+// for Reap, the cards, the transactions and the account balance are seeded
+// with addCard, addTransaction and setAccountBalance. The calls whose error
+// is set fail.
+type fakeCardService struct {
+	statusErr error
+	adjustErr error
+	// appliedErr is returned after the adjustment is applied, like a
+	// timeout after Reap applied it
+	appliedErr error
+
+	mu sync.RWMutex
+	// cardIDs in creation order
+	cardIDs        []string
+	cards          map[string]acme.Card
+	accountBalance acme.AccountBalance
+	transactions   []acme.Transaction
+	balanceChanges map[string][]acme.BalanceChange
+	// adjustments numbers the balance adjustments
+	adjustments int
+}
+
+var (
+	_ acme.CardService         = (*fakeCardService)(nil)
+	_ acme.BalanceChangeSource = (*fakeCardService)(nil)
+)
+
+// newCardService returns a card service with the cards and 1000.00 USD to
+// allocate
+func newCardService(cards ...acme.Card) *fakeCardService {
+	s := &fakeCardService{
+		cards:          make(map[string]acme.Card),
+		balanceChanges: make(map[string][]acme.BalanceChange),
+		accountBalance: acme.AccountBalance{AvailableToAllocate: acme.MustParseMoney("1000.00", "USD")},
+	}
+	for _, card := range cards {
+		s.addCard(card)
+	}
+	return s
+}
+
+// addCard adds the card or replaces the card with the same ID.
+func (s *fakeCardService) addCard(card acme.Card) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.cards[card.ID]; !ok {
+		s.cardIDs = append(s.cardIDs, card.ID)
+	}
+	s.cards[card.ID] = card
+}
+
+// addTransaction adds the transaction of a card.
+func (s *fakeCardService) addTransaction(t acme.Transaction) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.transactions = append(s.transactions, t)
+}
+
+// setAccountBalance sets the balance of the account.
+func (s *fakeCardService) setAccountBalance(balance acme.AccountBalance) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.accountBalance = balance
+}
+
+// GetAccountBalance implements acme.CardService.
+func (s *fakeCardService) GetAccountBalance(context.Context) (*acme.AccountBalance, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	balance := s.accountBalance
+	return &balance, nil
+}
+
+// CreateCard implements acme.CardService.
+func (s *fakeCardService) CreateCard(_ context.Context, params acme.CreateCardParams) (*acme.CreateCardResponse, error) {
+	if params.Mode != "" && params.Mode != acme.CardModeStandard {
+		return nil, fmt.Errorf("%w: the fake card service only creates standard cards", acme.ErrInvalidCardMode)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cardID := params.CardID
+	if cardID == "" {
+		cardID = fmt.Sprintf("card%d", len(s.cardIDs)+1)
+	}
+
+	// creating the card again completes its creation
+	if _, ok := s.cards[cardID]; ok {
+		return &acme.CreateCardResponse{CardID: cardID}, nil
+	}
+
+	s.cardIDs = append(s.cardIDs, cardID)
+	s.cards[cardID] = acme.Card{
+		ID:           cardID,
+		Name:         strings.TrimSpace(params.FirstName + " " + params.LastName),
+		Status:       acme.CardStatusActive,
+		ContactInfo:  params.ContactInfo,
+		ValidUntil:   params.ValidUntil,
+		ExpiryAction: params.ExpiryAction,
+	}
+
+	return &acme.CreateCardResponse{CardID: cardID}, nil
+}
+
+// GetCard implements acme.CardService.
+func (s *fakeCardService) GetCard(_ context.Context, cardID string) (*acme.Card, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	card, ok := s.cards[cardID]
+	if !ok {
+		return nil, acme.ErrCardNotFound
+	}
+
+	return &card, nil
+}
+
+// ListCards implements acme.CardService.
+func (s *fakeCardService) ListCards(_ context.Context, params acme.ListCardsParams) (*acme.ListCardsResponse, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	cards := make([]acme.Card, 0, len(s.cardIDs))
+	for _, cardID := range s.cardIDs {
+		card := s.cards[cardID]
+		if !params.ValidUntilBefore.IsZero() &&
+			(card.ValidUntil.IsZero() || !card.ValidUntil.Before(params.ValidUntilBefore)) {
+			continue
+		}
+		cards = append(cards, card)
+	}
+
+	return &acme.ListCardsResponse{Cards: cards}, nil
+}
+
+// UpdateCardStatus implements acme.CardService.
+func (s *fakeCardService) UpdateCardStatus(_ context.Context, cardID string, status string) error {
+	if s.statusErr != nil {
+		return s.statusErr
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	card, ok := s.cards[cardID]
+	if !ok {
+		return acme.ErrCardNotFound
+	}
+
+	card.Status = status
+	s.cards[cardID] = card
+
+	return nil
+}
+
+// UpdateCardExpiry implements acme.CardService.
+func (s *fakeCardService) UpdateCardExpiry(_ context.Context, cardID string, params acme.UpdateCardExpiryParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	card, ok := s.cards[cardID]
+	if !ok {
+		return acme.ErrCardNotFound
+	}
+
+	card.ValidUntil, card.ExpiryAction = params.ValidUntil, params.ExpiryAction
+	if !card.ValidUntil.IsZero() && card.ExpiryAction == "" {
+		card.ExpiryAction = acme.CardStatusFrozen
+	}
+	if card.ValidUntil.IsZero() {
+		card.ExpiryAction = ""
+	}
+	s.cards[cardID] = card
+
+	return nil
+}
+
+// AdjustCardBalance implements acme.CardService, the adjustment is recorded
+// as a balance change of the card.
+func (s *fakeCardService) AdjustCardBalance(_ context.Context, cardID string, params acme.AdjustCardBalanceParams) (*acme.AdjustCardBalanceResponse, error) {
+	if s.adjustErr != nil {
+		return nil, s.adjustErr
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	card, ok := s.cards[cardID]
+	if !ok {
+		return nil, acme.ErrCardNotFound
+	}
+
+	amount := params.Amount.Minor()
+	if params.Type == acme.BalanceAdjustmentWithdraw {
+		amount = -amount
+	}
+
+	card.AvailableCredit = acme.NewMoney(card.AvailableCredit.Minor()+amount, params.Amount.Currency())
+	s.cards[cardID] = card
+
+	s.adjustments++
+	id := fmt.Sprintf("adjustment%d", s.adjustments)
+	s.balanceChanges[cardID] = append(s.balanceChanges[cardID], acme.BalanceChange{
+		ID:     id,
+		Date:   time.Now().UTC(),
+		Type:   params.Type,
+		Amount: params.Amount,
+	})
+
+	if s.appliedErr != nil {
+		return nil, s.appliedErr
+	}
+
+	return &acme.AdjustCardBalanceResponse{ID: id, AvailableCredit: card.AvailableCredit}, nil
+}
+
+// ListCardTransactions implements acme.CardService.
+func (s *fakeCardService) ListCardTransactions(_ context.Context, cardID string, params acme.ListCardTransactionsParams) (*acme.ListCardTransactionsResponse, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, ok := s.cards[cardID]; !ok {
+		return nil, acme.ErrCardNotFound
+	}
+
+	txs := s.findTransactions(cardID, params.DateRange, params.Limit)
+	return &acme.ListCardTransactionsResponse{Transactions: txs}, nil
+}
+
+// ListCardBalanceHistory implements acme.CardService.
+func (s *fakeCardService) ListCardBalanceHistory(_ context.Context, cardID string, params acme.ListCardBalanceHistoryParams) (*acme.ListCardBalanceHistoryResponse, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, ok := s.cards[cardID]; !ok {
+		return nil, acme.ErrCardNotFound
+	}
+
+	changes := make([]acme.BalanceChange, 0)
+	for _, bc := range s.balanceChanges[cardID] {
+		if params.Limit > 0 && len(changes) == params.Limit {
+			break
+		}
+		if inDateRange(params.DateRange, bc.Date) {
+			changes = append(changes, bc)
+		}
+	}
+
+	return &acme.ListCardBalanceHistoryResponse{BalanceChanges: changes}, nil
+}
+
+// ListTransactions implements acme.CardService.
+func (s *fakeCardService) ListTransactions(_ context.Context, params acme.ListTransactionsParams) (*acme.ListTransactionsResponse, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	txs := s.findTransactions("", params.DateRange, params.Limit)
+	return &acme.ListTransactionsResponse{Transactions: txs}, nil
+}
+
+// EachCardBalanceChange implements acme.BalanceChangeSource.
+func (s *fakeCardService) EachCardBalanceChange(ctx context.Context, cardID string, dateRange acme.DateRange, fn func(acme.BalanceChange) error) error {
+	res, err := s.ListCardBalanceHistory(ctx, cardID, acme.ListCardBalanceHistoryParams{DateRange: dateRange})
+	if err != nil {
+		return fmt.Errorf("list card balance history: %w", err)
+	}
+
+	for _, bc := range res.BalanceChanges {
+		err = fn(bc)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// findTransactions returns the transactions of the card in the date range,
+// the transactions of every card when cardID is empty
+func (s *fakeCardService) findTransactions(cardID string, dateRange acme.DateRange, limit int) []acme.Transaction {
+	txs := make([]acme.Transaction, 0)
+	for _, t := range s.transactions {
+		if limit > 0 && len(txs) == limit {
+			break
+		}
+		if (cardID == "" || t.CardID == cardID) && inDateRange(dateRange, t.CreatedAt) {
+			txs = append(txs, t)
+		}
+	}
+
+	return txs
+}
+
+// inDateRange reports whether t is in the date range, the dates are
+// compared by day like Reap does and From defaults to the current day
+func inDateRange(dateRange acme.DateRange, t time.Time) bool {
+	from := dateRange.From
+	if from.IsZero() {
+		from = time.Now()
+	}
+
+	day := t.UTC().Format(time.DateOnly)
+	if day < from.UTC().Format(time.DateOnly) {
+		return false
+	}
+
+	return dateRange.To.IsZero() || day <= dateRange.To.UTC().Format(time.DateOnly)
+}
+
+func getCard(t *testing.T, cardSvc acme.CardService, cardID string) acme.Card {
+	t.Helper()
+
+	card, err := cardSvc.GetCard(context.Background(), cardID)
+	require.NoError(t, err)
+	return *card
+}
+
+func cardStatus(t *testing.T, cardSvc acme.CardService, cardID string) string {
+	t.Helper()
+
+	return getCard(t, cardSvc, cardID).Status
+}
+
+// setAvailableCredit sets the available credit of the card as if it was spent
+func setAvailableCredit(t *testing.T, cardSvc *fakeCardService, cardID string, credit acme.Money) {
+	t.Helper()
+
+	card := getCard(t, cardSvc, cardID)
+	card.AvailableCredit = credit
+	cardSvc.addCard(card)
+}
+
+// balanceChanges returns the balance adjustments of the card
+func balanceChanges(t *testing.T, cardSvc acme.CardService, cardID string) []acme.BalanceChange {
+	t.Helper()
+
+	res, err := cardSvc.ListCardBalanceHistory(context.Background(), cardID, acme.ListCardBalanceHistoryParams{})
+	require.NoError(t, err)
+	return res.BalanceChanges
+}
@@ -0,0 +1,33 @@
+package acme
+
+import (
+	"context"
+	"time"
+)
+
+// CardSnapshot is a locally cached copy of the card details from Reap
+type CardSnapshot struct {
+	Card
+	UpdatedAt time.Time
+}
+
+// CardSnapshotRepository stores the card snapshots
+type CardSnapshotRepository interface {
+	// SaveCardSnapshots inserts or replaces the snapshots by card ID
+	SaveCardSnapshots(ctx context.Context, snapshots ...CardSnapshot) error
+	// FindCardSnapshots returns the snapshots, most recently created cards first
+	FindCardSnapshots(ctx context.Context) ([]CardSnapshot, error)
+	// DeleteCardSnapshotsBefore removes snapshots not updated since t
+	DeleteCardSnapshotsBefore(ctx context.Context, t time.Time) error
+	// DeleteCardSnapshot removes the snapshot of the card, it is a no-op
+	// when the card has no snapshot
+	DeleteCardSnapshot(ctx context.Context, cardID string) error
+}
+
+// CardSnapshotService keeps the card snapshots in sync with Reap
+type CardSnapshotService interface {
+	// SyncCardSnapshots refreshes the snapshots of all cards
+	SyncCardSnapshots(ctx context.Context) error
+	// RefreshCardSnapshot refreshes the snapshot of a single card
+	RefreshCardSnapshot(ctx context.Context, externalCardID string) error
+}
@@ -0,0 +1,96 @@
+package acme
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// Event types published by the card operations
+const (
+	EventCardCreated        = "card.created"
+	EventCardStatusUpdated  = "card.status_updated"
+	EventCardFunded         = "card.funded"
+	EventCardWithdrawn      = "card.withdrawn"
+	EventCardExpiring       = "card.expiring"
+	EventTransactionCreated = "transaction.created"
+	EventTransactionSettled = "transaction.settled"
+)
+
+var eventTypes = []string{
+	EventCardCreated,
+	EventCardStatusUpdated,
+	EventCardFunded,
+	EventCardWithdrawn,
+	EventCardExpiring,
+	EventTransactionCreated,
+	EventTransactionSettled,
+}
+
+// Event is a change of a card published to the webhooks and event streams
+type Event struct {
+	ID   string
+	Type string
+	// CardID is the card the event is about
+	CardID string
+	// Data is the JSON encoded event data
+	Data      any
+	CreatedAt time.Time
+}
+
+// eventBody is the JSON encoding of the events
+type eventBody struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"createdAt"`
+	Data      any       `json:"data"`
+}
+
+// MarshalJSON encodes the event as sent to the webhooks and event streams.
+func (e Event) MarshalJSON() ([]byte, error) {
+	return json.Marshal(eventBody{
+		ID:        e.ID,
+		Type:      e.Type,
+		CreatedAt: e.CreatedAt,
+		Data:      e.Data,
+	})
+}
+
+// EventPublisher publishes the events of the card operations
+type EventPublisher interface {
+	PublishEvent(context.Context, Event) error
+}
+
+// CardEventData is the data of the card created and status events
+type CardEventData struct {
+	CardID string `json:"cardId"`
+	Status string `json:"status,omitempty"`
+}
+
+// CardBalanceEventData is the data of the card funded and withdrawn events
+type CardBalanceEventData struct {
+	CardID          string `json:"cardId"`
+	AdjustmentID    string `json:"adjustmentId"`
+	Amount          Money  `json:"amount"`
+	AvailableCredit Money  `json:"availableCredit"`
+}
+
+// CardExpiryEventData is the data of the card expiring events
+type CardExpiryEventData struct {
+	CardID     string    `json:"cardId"`
+	ValidUntil time.Time `json:"validUntil"`
+	// Action is the status of the card at ValidUntil
+	Action string `json:"action"`
+}
+
+// TransactionEventData is the data of the transaction events
+type TransactionEventData struct {
+	TransactionID string    `json:"transactionId"`
+	CardID        string    `json:"cardId"`
+	Status        string    `json:"status"`
+	Category      string    `json:"category"`
+	Amount        Money     `json:"amount"`
+	MerchantName  string    `json:"merchantName"`
+	MCCCode       string    `json:"mccCode"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
@@ -0,0 +1,224 @@
+package acme
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrStreamEventExists is returned when the event is already published
+	ErrStreamEventExists = errors.New("stream event exists")
+	// ErrStreamEventNotFound is returned when the event does not exist
+	ErrStreamEventNotFound = errors.New("stream event not found")
+)
+
+// StreamEvent is a published event of the live event streams, Seq is the
+// event ID of the streams and is unique across the server instances
+type StreamEvent struct {
+	Seq     int64
+	ID      string
+	Type    string
+	CardID  string
+	Payload []byte
+	// CreatedAt is the time the event was published
+	CreatedAt time.Time
+}
+
+type StreamEventRepository interface {
+	// SaveStreamEvent saves the event, sets its Seq and notifies the
+	// listening server instances, it fails with ErrStreamEventExists when
+	// the event is already saved
+	SaveStreamEvent(context.Context, *StreamEvent) error
+	GetStreamEvent(ctx context.Context, seq int64) (*StreamEvent, error)
+	// FindLatestStreamEvents returns up to limit of the latest events
+	// published since the time, oldest first
+	FindLatestStreamEvents(ctx context.Context, since time.Time, limit int) ([]StreamEvent, error)
+	DeleteStreamEventsBefore(context.Context, time.Time) error
+}
+
+// EventStreamPublisher implements EventPublisher, the saved events reach the
+// event hubs of the server instances through the repository notifications
+type EventStreamPublisher struct {
+	streamRepo StreamEventRepository
+}
+
+var _ EventPublisher = (*EventStreamPublisher)(nil)
+
+func NewEventStreamPublisher(streamRepo StreamEventRepository) *EventStreamPublisher {
+	return &EventStreamPublisher{streamRepo: streamRepo}
+}
+
+// PublishEvent implements EventPublisher, events already published are
+// ignored.
+func (p *EventStreamPublisher) PublishEvent(ctx context.Context, event Event) error {
+	if event.ID == "" {
+		event.ID = uuid.New().String()
+	}
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now().UTC()
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("marshal event: %w", err)
+	}
+
+	err = p.streamRepo.SaveStreamEvent(ctx, &StreamEvent{
+		ID:        event.ID,
+		Type:      event.Type,
+		CardID:    event.CardID,
+		Payload:   payload,
+		CreatedAt: event.CreatedAt,
+	})
+	if err != nil {
+		if errors.Is(err, ErrStreamEventExists) {
+			return nil
+		}
+		return fmt.Errorf("save stream event: %w", err)
+	}
+
+	return nil
+}
+
+// streamSubscriberBuffer is the events queued for a client, slower clients
+// are disconnected and resume from the hub buffer
+const streamSubscriberBuffer = 64
+
+// EventHub fans out the stream events to the connected clients of the
+// server instance, it keeps the latest events to resume the streams.
+type EventHub struct {
+	mu sync.Mutex
+	// buffer is ordered by arrival, the order the events were committed
+	buffer      []StreamEvent
+	size        int
+	subscribers map[*StreamSubscriber]struct{}
+}
+
+func NewEventHub(size int) *EventHub {
+	return &EventHub{
+		size:        size,
+		subscribers: make(map[*StreamSubscriber]struct{}),
+	}
+}
+
+// StreamSubscriber receives the hub events of a card, or of every card
+// when the card ID is empty
+type StreamSubscriber struct {
+	cardID string
+	events chan StreamEvent
+}
+
+// Events is closed when the subscriber is removed or falls behind.
+func (s *StreamSubscriber) Events() <-chan StreamEvent {
+	return s.events
+}
+
+func (s *StreamSubscriber) matches(e StreamEvent) bool {
+	return s.cardID == "" || s.cardID == e.CardID
+}
+
+// Subscribe adds a subscriber of the card events and returns the buffered
+// events after lastSeq, no events are replayed when lastSeq is zero.
+func (h *EventHub) Subscribe(cardID string, lastSeq int64) (*StreamSubscriber, []StreamEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	sub := &StreamSubscriber{
+		cardID: cardID,
+		events: make(chan StreamEvent, streamSubscriberBuffer),
+	}
+	h.subscribers[sub] = struct{}{}
+
+	if lastSeq == 0 {
+		return sub, nil
+	}
+
+	// events may commit out of seq order, replay the events buffered after
+	// the last event seen, or with a greater seq when it left the buffer
+	last := slices.IndexFunc(h.buffer, func(e StreamEvent) bool { return e.Seq == lastSeq })
+
+	var backlog []StreamEvent
+	for i, e := range h.buffer {
+		missed := i > last
+		if last < 0 {
+			missed = e.Seq > lastSeq
+		}
+
+		if missed && sub.matches(e) {
+			backlog = append(backlog, e)
+		}
+	}
+
+	return sub, backlog
+}
+
+// Unsubscribe removes the subscriber and closes its events.
+func (h *EventHub) Unsubscribe(sub *StreamSubscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.remove(sub)
+}
+
+func (h *EventHub) remove(sub *StreamSubscriber) {
+	if _, ok := h.subscribers[sub]; ok {
+		delete(h.subscribers, sub)
+		close(sub.events)
+	}
+}
+
+// Broadcast buffers the event and sends it to the subscribers, events
+// already buffered are ignored.
+func (h *EventHub) Broadcast(e StreamEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if slices.ContainsFunc(h.buffer, func(b StreamEvent) bool { return b.Seq == e.Seq }) {
+		return
+	}
+
+	h.buffer = append(h.buffer, e)
+	if len(h.buffer) > h.size {
+		h.buffer = slices.Delete(h.buffer, 0, len(h.buffer)-h.size)
+	}
+
+	for sub := range h.subscribers {
+		if !sub.matches(e) {
+			continue
+		}
+
+		select {
+		case sub.events <- e:
+		default:
+			h.remove(sub)
+		}
+	}
+}
+
+// LatestEventTime returns the publish time of the latest buffered event,
+// zero when the buffer is empty.
+func (h *EventHub) LatestEventTime() time.Time {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var latest time.Time
+	for _, e := range h.buffer {
+		if e.CreatedAt.After(latest) {
+			latest = e.CreatedAt
+		}
+	}
+
+	return latest
+}
+
+// Size returns the number of events the hub buffers.
+func (h *EventHub) Size() int {
+	return h.size
+}
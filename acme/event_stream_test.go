@@ -0,0 +1,112 @@
+package acme_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/acme/memory"
+)
+
+func TestEventStreamPublisher(t *testing.T) {
+	ctx := context.Background()
+
+	streamRepo := memory.NewStreamEventRepository()
+	publisher := acme.NewEventStreamPublisher(streamRepo)
+
+	event := acme.Event{
+		ID:        "event1",
+		Type:      acme.EventCardStatusUpdated,
+		CardID:    "card1",
+		Data:      acme.CardEventData{CardID: "card1", Status: acme.CardStatusFrozen},
+		CreatedAt: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+	}
+	require.NoError(t, publisher.PublishEvent(ctx, event))
+	// events are published once
+	require.NoError(t, publisher.PublishEvent(ctx, event))
+
+	events, err := streamRepo.FindLatestStreamEvents(ctx, time.Time{}, 10)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, "card1", events[0].CardID)
+	assert.JSONEq(t, `{
+		"id": "event1",
+		"type": "card.status_updated",
+		"createdAt": "2024-05-01T10:00:00Z",
+		"data": {"cardId": "card1", "status": "FROZEN"}
+	}`, string(events[0].Payload))
+}
+
+func TestEventHub(t *testing.T) {
+	newEvent := func(seq int64, cardID string) acme.StreamEvent {
+		return acme.StreamEvent{Seq: seq, CardID: cardID, Type: acme.EventTransactionCreated}
+	}
+	seqs := func(events []acme.StreamEvent) []int64 {
+		var seqs []int64
+		for _, e := range events {
+			seqs = append(seqs, e.Seq)
+		}
+		return seqs
+	}
+
+	hub := acme.NewEventHub(4)
+
+	all, backlog := hub.Subscribe("", 0)
+	assert.Empty(t, backlog)
+	card1, _ := hub.Subscribe("card1", 0)
+
+	// seq 3 committed before seq 2
+	for _, e := range []acme.StreamEvent{
+		newEvent(1, "card1"),
+		newEvent(3, "card2"),
+		newEvent(2, "card1"),
+		// duplicates are ignored
+		newEvent(3, "card2"),
+	} {
+		hub.Broadcast(e)
+	}
+
+	assert.Len(t, all.Events(), 3)
+	assert.Len(t, card1.Events(), 2)
+	assert.Equal(t, int64(1), (<-card1.Events()).Seq)
+
+	t.Run("resume", func(t *testing.T) {
+		_, backlog := hub.Subscribe("", 3)
+		assert.Equal(t, []int64{2}, seqs(backlog))
+
+		_, backlog = hub.Subscribe("card1", 1)
+		assert.Equal(t, []int64{2}, seqs(backlog))
+
+		hub.Broadcast(newEvent(4, "card1"))
+		hub.Broadcast(newEvent(5, "card1"))
+
+		// seq 1 left the buffer
+		_, backlog = hub.Subscribe("", 1)
+		assert.Equal(t, []int64{3, 2, 4, 5}, seqs(backlog))
+	})
+
+	t.Run("unsubscribe", func(t *testing.T) {
+		hub.Unsubscribe(card1)
+		for range card1.Events() {
+		}
+		// unsubscribing twice is a no-op
+		hub.Unsubscribe(card1)
+	})
+
+	t.Run("slow subscriber", func(t *testing.T) {
+		slow, _ := hub.Subscribe("card2", 0)
+		for i := range 100 {
+			hub.Broadcast(newEvent(int64(100+i), "card2"))
+		}
+
+		var received int
+		for range slow.Events() {
+			received++
+		}
+		assert.Less(t, received, 100)
+	})
+}
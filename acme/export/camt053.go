@@ -0,0 +1,213 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+)
+
+// Account statement entry statuses, booked entries move the account
+// balance while information entries do not
+const (
+	EntryStatusBooked      = "BOOK"
+	EntryStatusInformation = "INFO"
+)
+
+// AccountStatement is the end of day statement of the Reap account
+type AccountStatement struct {
+	ID        string
+	AccountID string
+	Date      time.Time
+	// OpeningBalance and ClosingBalance are the available balance at the
+	// start and end of the day
+	OpeningBalance acme.Money
+	ClosingBalance acme.Money
+	// Entries are sorted by date
+	Entries     []AccountStatementEntry
+	GeneratedAt time.Time
+}
+
+// AccountStatementEntry is a movement of the account
+type AccountStatementEntry struct {
+	StatementEntry
+	CardID string
+	// Status is one of the EntryStatus constants
+	Status string
+	// Channel is the card channel e.g. POS or ATM
+	Channel string
+	// InstructedAmount is the merchant local amount of foreign currency
+	// transactions
+	InstructedAmount acme.Money
+	ConversionRate   string
+}
+
+// Camt053Generator builds the end of day account statements
+type Camt053Generator struct {
+	cardSvc    acme.CardService
+	txSource   acme.TransactionSource
+	balanceSrc acme.BalanceChangeSource
+	accountID  string
+	now        func() time.Time
+}
+
+// NewCamt053Generator returns a generator of the account statements,
+// accountID identifies the Reap account in the treasury system.
+func NewCamt053Generator(
+	cardSvc acme.CardService,
+	txSource acme.TransactionSource,
+	balanceSrc acme.BalanceChangeSource,
+	accountID string,
+) *Camt053Generator {
+	return &Camt053Generator{
+		cardSvc:    cardSvc,
+		txSource:   txSource,
+		balanceSrc: balanceSrc,
+		accountID:  accountID,
+		now:        time.Now,
+	}
+}
+
+// GenerateAccountStatement returns the statement of the day in UTC. Reap
+// only reports the current balance so the closing balance is the current
+// balance less the transactions booked after the day.
+func (g *Camt053Generator) GenerateAccountStatement(ctx context.Context, date time.Time) (*AccountStatement, error) {
+	now := g.now().UTC()
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	nextDay := day.AddDate(0, 0, 1)
+	if day.After(now) {
+		return nil, fmt.Errorf("statement date %s is in the future", day.Format(time.DateOnly))
+	}
+
+	balance, err := g.cardSvc.GetAccountBalance(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get account balance: %w", err)
+	}
+
+	closing := balance.AvailableBalance
+	if nextDay.Before(now) {
+		err = g.eachBookedEntry(ctx, acme.DateRange{From: nextDay}, func(e AccountStatementEntry) error {
+			if e.Date.Before(nextDay) {
+				return nil
+			}
+
+			c, err := closing.Sub(e.Amount)
+			if err != nil {
+				return err
+			}
+			closing = c
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("unwind balance: %w", err)
+		}
+	}
+
+	var entries []AccountStatementEntry
+	opening := closing
+	err = g.eachBookedEntry(ctx, acme.DateRange{From: day, To: day}, func(e AccountStatementEntry) error {
+		if e.Date.Before(day) || !e.Date.Before(nextDay) {
+			return nil
+		}
+
+		o, err := opening.Sub(e.Amount)
+		if err != nil {
+			return err
+		}
+		opening = o
+		entries = append(entries, e)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("booked entries: %w", err)
+	}
+
+	transfers, err := g.transferEntries(ctx, day, nextDay)
+	if err != nil {
+		return nil, err
+	}
+	entries = append(entries, transfers...)
+
+	slices.SortStableFunc(entries, func(a, b AccountStatementEntry) int {
+		return a.Date.Compare(b.Date)
+	})
+
+	return &AccountStatement{
+		ID:             "ACME" + day.Format("20060102"),
+		AccountID:      g.accountID,
+		Date:           day,
+		OpeningBalance: opening,
+		ClosingBalance: closing,
+		Entries:        entries,
+		GeneratedAt:    now,
+	}, nil
+}
+
+// eachBookedEntry walks the card transactions and their fees
+func (g *Camt053Generator) eachBookedEntry(ctx context.Context, dateRange acme.DateRange, fn func(AccountStatementEntry) error) error {
+	return g.txSource.EachTransaction(ctx, dateRange, func(t acme.Transaction) error {
+		if !t.IsPosted() {
+			return nil
+		}
+
+		for i, se := range transactionEntries(t) {
+			e := AccountStatementEntry{
+				StatementEntry: se,
+				CardID:         t.CardID,
+				Status:         EntryStatusBooked,
+				Channel:        t.Channel,
+			}
+			// the first entry is the transaction, the rest are its fees
+			if i == 0 && t.TransactionAmount.Currency() != "" && t.TransactionAmount.Currency() != t.Amount.Currency() {
+				e.InstructedAmount = t.TransactionAmount
+				e.ConversionRate = t.ConversionRate
+			}
+
+			err := fn(e)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// transferEntries returns the top-ups and withdrawals of every card as
+// information entries, they move funds between the account and the cards.
+func (g *Camt053Generator) transferEntries(ctx context.Context, day, nextDay time.Time) ([]AccountStatementEntry, error) {
+	resp, err := g.cardSvc.ListCards(ctx, acme.ListCardsParams{})
+	if err != nil {
+		return nil, fmt.Errorf("list cards: %w", err)
+	}
+
+	var entries []AccountStatementEntry
+	for _, card := range resp.Cards {
+		err = g.balanceSrc.EachCardBalanceChange(ctx, card.ID, acme.DateRange{From: day, To: day}, func(bc acme.BalanceChange) error {
+			if bc.Date.Before(day) || !bc.Date.Before(nextDay) {
+				return nil
+			}
+
+			se, ok := balanceChangeEntry(bc)
+			if !ok {
+				return nil
+			}
+
+			// a card top-up is a debit of the account
+			se.Amount = se.Amount.Neg()
+			entries = append(entries, AccountStatementEntry{
+				StatementEntry: se,
+				CardID:         card.ID,
+				Status:         EntryStatusInformation,
+			})
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("each card %q balance change: %w", card.ID, err)
+		}
+	}
+
+	return entries, nil
+}
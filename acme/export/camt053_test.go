@@ -0,0 +1,283 @@
+package export_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/acme/export"
+)
+
+// camt053Sequences are the children of the camt.053.001.08 complex types
+// in schema order keyed by element path, required children start with "!".
+// Elements without an entry must have simple content.
+var camt053Sequences = map[string][]string{
+	"Document":                      {"!BkToCstmrStmt"},
+	"Document/BkToCstmrStmt":        {"!GrpHdr", "!Stmt", "SplmtryData"},
+	"Document/BkToCstmrStmt/GrpHdr": {"!MsgId", "!CreDtTm", "MsgRcpt", "MsgPgntn", "OrgnlBizQry", "AddtlInf"},
+	"Document/BkToCstmrStmt/Stmt":   {"!Id", "StmtPgntn", "ElctrncSeqNb", "RptgSeq", "LglSeqNb", "CreDtTm", "FrToDt", "CpyDplctInd", "RptgSrc", "!Acct", "RltdAcct", "Intrst", "!Bal", "TxsSummry", "Ntry", "AddtlStmtInf"},
+	"Stmt/FrToDt":                   {"!FrDtTm", "!ToDtTm"},
+	"Stmt/Acct":                     {"!Id", "Tp", "Ccy", "Nm", "Prxy", "Ownr", "Svcr"},
+	"Stmt/Acct/Id":                  {"IBAN", "Othr"},
+	"Stmt/Acct/Id/Othr":             {"!Id", "SchmeNm", "Issr"},
+	"Stmt/Bal":                      {"!Tp", "CdtLine", "!Amt", "!CdtDbtInd", "!Dt", "Avlbty"},
+	"Stmt/Bal/Tp":                   {"!CdOrPrtry", "SubTp"},
+	"Stmt/Bal/Tp/CdOrPrtry":         {"Cd", "Prtry"},
+	"Stmt/Bal/Dt":                   {"Dt", "DtTm"},
+	"Stmt/TxsSummry":                {"TtlNtries", "TtlCdtNtries", "TtlDbtNtries", "TtlNtriesPerBkTxCd"},
+	"Stmt/TxsSummry/TtlNtries":      {"NbOfNtries", "Sum", "TtlNetNtry"},
+	"TtlNtries/TtlNetNtry":          {"!Amt", "!CdtDbtInd"},
+	"Stmt/TxsSummry/TtlCdtNtries":   {"NbOfNtries", "Sum"},
+	"Stmt/TxsSummry/TtlDbtNtries":   {"NbOfNtries", "Sum"},
+	"Stmt/Ntry":                     {"NtryRef", "!Amt", "!CdtDbtInd", "RvslInd", "!Sts", "BookgDt", "ValDt", "AcctSvcrRef", "Avlbty", "!BkTxCd", "ComssnWvrInd", "AddtlInfInd", "AmtDtls", "Chrgs", "TechInptChanl", "Intrst", "CardTx", "NtryDtls", "AddtlNtryInf"},
+	"Ntry/Sts":                      {"Cd", "Prtry"},
+	"Ntry/BookgDt":                  {"Dt", "DtTm"},
+	"Ntry/BkTxCd":                   {"Domn", "Prtry"},
+	"BkTxCd/Domn":                   {"!Cd", "!Fmly"},
+	"Domn/Fmly":                     {"!Cd", "!SubFmlyCd"},
+	"BkTxCd/Prtry":                  {"!Cd", "Issr"},
+	"Ntry/NtryDtls":                 {"Btch", "TxDtls"},
+	"NtryDtls/TxDtls":               {"Refs", "Amt", "CdtDbtInd", "AmtDtls", "Avlbty", "BkTxCd", "Chrgs", "Intrst", "RltdPties", "RltdAgts", "LclInstrm", "Purp", "RltdRmtInf", "RmtInf", "RltdDts", "RltdPric", "RltdQties", "FinInstrmId", "Tax", "RtrInf", "CorpActn", "SfkpgAcct", "CshDpst", "CardTx", "AddtlTxInf", "SplmtryData"},
+	"TxDtls/Refs":                   {"MsgId", "AcctSvcrRef", "PmtInfId", "InstrId", "EndToEndId", "UETR", "TxId", "MndtId", "ChqNb", "ClrSysRef", "AcctOwnrTxId", "AcctSvcrTxId", "MktInfrstrctrTxId", "PrcgId", "Prtry"},
+	"TxDtls/AmtDtls":                {"InstdAmt", "TxAmt", "CntrValAmt", "AnncdPstngAmt", "PrtryAmt"},
+	"AmtDtls/InstdAmt":              {"!Amt", "CcyXchg"},
+	"AmtDtls/TxAmt":                 {"!Amt", "CcyXchg"},
+	"TxAmt/CcyXchg":                 {"!SrcCcy", "TrgtCcy", "UnitCcy", "!XchgRate", "CtrctId", "QtnDt"},
+	"TxDtls/RltdPties":              {"InitgPty", "Dbtr", "DbtrAcct", "UltmtDbtr", "Cdtr", "CdtrAcct", "UltmtCdtr", "TradgPty", "Prtry"},
+	"RltdPties/Cdtr":                {"Pty", "Agt"},
+	"Cdtr/Pty":                      {"Nm", "PstlAdr", "Id", "CtryOfRes", "CtctDtls"},
+}
+
+// camt053Patterns are the simple type restrictions of leaf elements
+var camt053Patterns = map[string]*regexp.Regexp{
+	"Amt":        regexp.MustCompile(`^\d{1,13}(\.\d{1,5})?$`),
+	"CdtDbtInd":  regexp.MustCompile(`^(CRDT|DBIT)$`),
+	"MsgId":      regexp.MustCompile(`^.{1,35}$`),
+	"NtryRef":    regexp.MustCompile(`^.{1,35}$`),
+	"CreDtTm":    regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}Z$`),
+	"DtTm":       regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}Z$`),
+	"Dt":         regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`),
+	"Ccy":        regexp.MustCompile(`^[A-Z]{3}$`),
+	"NbOfNtries": regexp.MustCompile(`^\d{1,15}$`),
+}
+
+type xmlNode struct {
+	name     string
+	attrs    []xml.Attr
+	text     string
+	children []*xmlNode
+}
+
+func parseXMLTree(t *testing.T, b []byte) *xmlNode {
+	dec := xml.NewDecoder(bytes.NewReader(b))
+
+	var stack []*xmlNode
+	var root *xmlNode
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			n := &xmlNode{name: tok.Name.Local, attrs: tok.Attr}
+			if len(stack) == 0 {
+				root = n
+			} else {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, n)
+			}
+			stack = append(stack, n)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text += strings.TrimSpace(string(tok))
+			}
+		}
+	}
+
+	return root
+}
+
+// sequenceFor returns the schema sequence of the longest matching path
+// suffix
+func sequenceFor(path string) ([]string, bool) {
+	parts := strings.Split(path, "/")
+	for i := range parts {
+		if seq, ok := camt053Sequences[strings.Join(parts[i:], "/")]; ok {
+			return seq, true
+		}
+	}
+
+	return nil, false
+}
+
+func validateCamt053Node(t *testing.T, n *xmlNode, path string) {
+	seq, ok := sequenceFor(path)
+	if !ok {
+		assert.Empty(t, n.children, "%s must have simple content", path)
+
+		if re, ok := camt053Patterns[n.name]; ok {
+			assert.Regexp(t, re, n.text, path)
+		}
+		// amounts carry the currency except the net total
+		if n.name == "Amt" && !strings.HasSuffix(path, "TtlNetNtry/Amt") {
+			require.Len(t, n.attrs, 1, path)
+			assert.Equal(t, "Ccy", n.attrs[0].Name.Local, path)
+			assert.Regexp(t, camt053Patterns["Ccy"], n.attrs[0].Value, path)
+		}
+		return
+	}
+
+	index := make(map[string]int, len(seq))
+	required := make(map[string]bool)
+	for i, name := range seq {
+		if strings.HasPrefix(name, "!") {
+			name = name[1:]
+			required[name] = true
+		}
+		index[name] = i
+	}
+
+	last := -1
+	seen := make(map[string]bool)
+	for _, c := range n.children {
+		i, ok := index[c.name]
+		if !assert.True(t, ok, "unexpected %s in %s", c.name, path) {
+			continue
+		}
+		assert.GreaterOrEqual(t, i, last, "%s is out of order in %s", c.name, path)
+		last = i
+		seen[c.name] = true
+
+		validateCamt053Node(t, c, path+"/"+c.name)
+	}
+
+	for name := range required {
+		assert.True(t, seen[name], "missing %s in %s", name, path)
+	}
+}
+
+func TestCamt053(t *testing.T) {
+	day := time.Date(2025, 3, 14, 0, 0, 0, 0, time.UTC)
+	src := &stubStatementSource{
+		card:    acme.Card{ID: "card1", AvailableCredit: acme.MustParseMoney("486.28", "USD")},
+		balance: acme.AccountBalance{AvailableBalance: acme.MustParseMoney("1000.00", "USD")},
+		transactions: []acme.Transaction{
+			{
+				ID:                "tx1",
+				CardID:            "card1",
+				Category:          "purchase",
+				Status:            "settled",
+				Channel:           "POS",
+				Amount:            acme.MustParseMoney("13.45", "USD"),
+				TransactionAmount: acme.MustParseMoney("2000", "JPY"),
+				ConversionRate:    "0.006725",
+				Fees:              acme.FeeDetails{FXFees: acme.MustParseMoney("0.27", "USD")},
+				Merchant:          acme.MerchantDetails{Name: "Tsukiji Sushi", MCCCategory: "Restaurants"},
+				CreatedAt:         day.Add(9 * time.Hour),
+			},
+			{
+				ID:        "tx2",
+				CardID:    "card1",
+				Category:  "refund",
+				Status:    "settled",
+				Amount:    acme.MustParseMoney("5.00", "USD"),
+				CreatedAt: day.Add(10 * time.Hour),
+			},
+			// booked after the statement day
+			{
+				ID:        "tx3",
+				CardID:    "card1",
+				Category:  "purchase",
+				Status:    "settled",
+				Amount:    acme.MustParseMoney("100.00", "USD"),
+				CreatedAt: day.AddDate(0, 0, 2),
+			},
+		},
+		balanceChanges: []acme.BalanceChange{
+			{ID: "bc1", Type: acme.BalanceAdjustmentTopUp, Amount: acme.MustParseMoney("500", "USD"), Date: day.Add(8 * time.Hour)},
+		},
+	}
+
+	generator := export.NewCamt053Generator(src, src, src, "REAP-USD")
+	statement, err := generator.GenerateAccountStatement(context.Background(), day)
+	require.NoError(t, err)
+
+	// closing is the current balance before tx3, opening is before tx1 and tx2
+	assert.Equal(t, "1100.00 USD", statement.ClosingBalance.String())
+	assert.Equal(t, "1108.72 USD", statement.OpeningBalance.String())
+	require.Len(t, statement.Entries, 4)
+	assert.Equal(t, export.EntryStatusInformation, statement.Entries[0].Status)
+	assert.Equal(t, "-500.00", statement.Entries[0].Amount.Decimal())
+
+	buf := &bytes.Buffer{}
+	err = export.WriteCamt053(buf, *statement)
+	require.NoError(t, err)
+
+	root := parseXMLTree(t, buf.Bytes())
+	require.Equal(t, "Document", root.name)
+	require.Len(t, root.attrs, 1)
+	assert.Equal(t, export.Camt053Namespace, root.attrs[0].Value)
+	validateCamt053Node(t, root, "Document")
+
+	var doc struct {
+		Stmt struct {
+			Bal []struct {
+				Cd        string `xml:"Tp>CdOrPrtry>Cd"`
+				Amt       string `xml:"Amt"`
+				CdtDbtInd string `xml:"CdtDbtInd"`
+			} `xml:"Bal"`
+			TtlNtries   string `xml:"TxsSummry>TtlNtries>NbOfNtries"`
+			TtlNetNtry  string `xml:"TxsSummry>TtlNtries>TtlNetNtry>Amt"`
+			TtlNetInd   string `xml:"TxsSummry>TtlNtries>TtlNetNtry>CdtDbtInd"`
+			TtlDbtNtSum string `xml:"TxsSummry>TtlDbtNtries>Sum"`
+			Ntry        []struct {
+				NtryRef   string `xml:"NtryRef"`
+				CdtDbtInd string `xml:"CdtDbtInd"`
+				Sts       string `xml:"Sts>Cd"`
+				SubFmly   string `xml:"BkTxCd>Domn>Fmly>SubFmlyCd"`
+				InstdAmt  string `xml:"NtryDtls>TxDtls>AmtDtls>InstdAmt>Amt"`
+			} `xml:"Ntry"`
+		} `xml:"BkToCstmrStmt>Stmt"`
+	}
+	err = xml.Unmarshal(buf.Bytes(), &doc)
+	require.NoError(t, err)
+
+	require.Len(t, doc.Stmt.Bal, 2)
+	assert.Equal(t, "OPBD", doc.Stmt.Bal[0].Cd)
+	assert.Equal(t, "1108.72", doc.Stmt.Bal[0].Amt)
+	assert.Equal(t, "CLBD", doc.Stmt.Bal[1].Cd)
+	assert.Equal(t, "1100.00", doc.Stmt.Bal[1].Amt)
+
+	// only booked entries are totalled
+	assert.Equal(t, "3", doc.Stmt.TtlNtries)
+	assert.Equal(t, "8.72", doc.Stmt.TtlNetNtry)
+	assert.Equal(t, "DBIT", doc.Stmt.TtlNetInd)
+	assert.Equal(t, "13.72", doc.Stmt.TtlDbtNtSum)
+
+	require.Len(t, doc.Stmt.Ntry, 4)
+	assert.Equal(t, "INFO", doc.Stmt.Ntry[0].Sts)
+	assert.Equal(t, "tx1", doc.Stmt.Ntry[1].NtryRef)
+	assert.Equal(t, "DBIT", doc.Stmt.Ntry[1].CdtDbtInd)
+	assert.Equal(t, "POSD", doc.Stmt.Ntry[1].SubFmly)
+	assert.Equal(t, "2000", doc.Stmt.Ntry[1].InstdAmt)
+	assert.Equal(t, "CHRG", doc.Stmt.Ntry[2].SubFmly)
+	assert.Equal(t, "CRDT", doc.Stmt.Ntry[3].CdtDbtInd)
+
+	_, err = generator.GenerateAccountStatement(context.Background(), time.Now().AddDate(0, 0, 2))
+	assert.Error(t, err)
+}
@@ -0,0 +1,354 @@
+package export
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+)
+
+// Camt053Namespace is the namespace of the camt.053.001.08 documents
+const Camt053Namespace = "urn:iso:std:iso:20022:tech:xsd:camt.053.001.08"
+
+// camt053 elements follow the sequence of the camt.053.001.08 schema, the
+// field order matters.
+
+type camtDocument struct {
+	XMLName xml.Name          `xml:"Document"`
+	Xmlns   string            `xml:"xmlns,attr"`
+	Stmt    camtBkToCstmrStmt `xml:"BkToCstmrStmt"`
+}
+
+type camtBkToCstmrStmt struct {
+	GrpHdr camtGrpHdr `xml:"GrpHdr"`
+	Stmt   camtStmt   `xml:"Stmt"`
+}
+
+type camtGrpHdr struct {
+	MsgID    string `xml:"MsgId"`
+	CreDtTm  string `xml:"CreDtTm"`
+	AddtlInf string `xml:"AddtlInf,omitempty"`
+}
+
+type camtStmt struct {
+	ID      string        `xml:"Id"`
+	CreDtTm string        `xml:"CreDtTm"`
+	FrToDt  camtFrToDt    `xml:"FrToDt"`
+	Acct    camtAcct      `xml:"Acct"`
+	Bal     []camtBal     `xml:"Bal"`
+	Summry  camtTxsSummry `xml:"TxsSummry"`
+	Ntry    []camtNtry    `xml:"Ntry"`
+}
+
+type camtFrToDt struct {
+	FrDtTm string `xml:"FrDtTm"`
+	ToDtTm string `xml:"ToDtTm"`
+}
+
+type camtAcct struct {
+	ID struct {
+		Othr struct {
+			ID string `xml:"Id"`
+		} `xml:"Othr"`
+	} `xml:"Id"`
+	Ccy string `xml:"Ccy"`
+}
+
+type camtAmt struct {
+	Ccy   string `xml:"Ccy,attr"`
+	Value string `xml:",chardata"`
+}
+
+type camtBal struct {
+	Tp struct {
+		CdOrPrtry struct {
+			Cd string `xml:"Cd"`
+		} `xml:"CdOrPrtry"`
+	} `xml:"Tp"`
+	Amt       camtAmt `xml:"Amt"`
+	CdtDbtInd string  `xml:"CdtDbtInd"`
+	Dt        struct {
+		Dt string `xml:"Dt"`
+	} `xml:"Dt"`
+}
+
+type camtNbAndSum struct {
+	NbOfNtries string `xml:"NbOfNtries"`
+	Sum        string `xml:"Sum"`
+}
+
+type camtTxsSummry struct {
+	TtlNtries struct {
+		camtNbAndSum
+		TtlNetNtry struct {
+			Amt       string `xml:"Amt"`
+			CdtDbtInd string `xml:"CdtDbtInd"`
+		} `xml:"TtlNetNtry"`
+	} `xml:"TtlNtries"`
+	TtlCdtNtries camtNbAndSum `xml:"TtlCdtNtries"`
+	TtlDbtNtries camtNbAndSum `xml:"TtlDbtNtries"`
+}
+
+type camtDomain struct {
+	Cd   string `xml:"Cd"`
+	Fmly struct {
+		Cd        string `xml:"Cd"`
+		SubFmlyCd string `xml:"SubFmlyCd"`
+	} `xml:"Fmly"`
+}
+
+type camtBkTxCd struct {
+	Domn  *camtDomain `xml:"Domn,omitempty"`
+	Prtry struct {
+		Cd string `xml:"Cd"`
+	} `xml:"Prtry"`
+}
+
+type camtNtry struct {
+	NtryRef   string  `xml:"NtryRef"`
+	Amt       camtAmt `xml:"Amt"`
+	CdtDbtInd string  `xml:"CdtDbtInd"`
+	Sts       struct {
+		Cd string `xml:"Cd"`
+	} `xml:"Sts"`
+	BookgDt struct {
+		DtTm string `xml:"DtTm"`
+	} `xml:"BookgDt"`
+	AcctSvcrRef string       `xml:"AcctSvcrRef"`
+	BkTxCd      camtBkTxCd   `xml:"BkTxCd"`
+	NtryDtls    camtNtryDtls `xml:"NtryDtls"`
+}
+
+type camtNtryDtls struct {
+	TxDtls struct {
+		Refs struct {
+			AcctSvcrRef string `xml:"AcctSvcrRef"`
+		} `xml:"Refs"`
+		AmtDtls    *camtAmtDtls   `xml:"AmtDtls,omitempty"`
+		RltdPties  *camtRltdPties `xml:"RltdPties,omitempty"`
+		AddtlTxInf string         `xml:"AddtlTxInf,omitempty"`
+	} `xml:"TxDtls"`
+}
+
+type camtRltdPties struct {
+	Cdtr struct {
+		Pty struct {
+			Nm string `xml:"Nm"`
+		} `xml:"Pty"`
+	} `xml:"Cdtr"`
+}
+
+type camtAmtDtls struct {
+	InstdAmt struct {
+		Amt camtAmt `xml:"Amt"`
+	} `xml:"InstdAmt"`
+	TxAmt struct {
+		Amt     camtAmt `xml:"Amt"`
+		CcyXchg struct {
+			SrcCcy   string `xml:"SrcCcy"`
+			TrgtCcy  string `xml:"TrgtCcy"`
+			XchgRate string `xml:"XchgRate"`
+		} `xml:"CcyXchg"`
+	} `xml:"TxAmt"`
+}
+
+// camt053 text limits
+const (
+	camtMax34Text  = 34
+	camtMax35Text  = 35
+	camtMax140Text = 140
+	camtMax500Text = 500
+)
+
+// WriteCamt053 writes the account statement as a camt.053.001.08 bank to
+// customer statement.
+func WriteCamt053(w io.Writer, s AccountStatement) error {
+	currency := s.ClosingBalance.Currency()
+	generatedAt := s.GeneratedAt.UTC().Format(time.RFC3339)
+
+	stmt := camtStmt{
+		ID:      truncate(s.ID, camtMax35Text),
+		CreDtTm: generatedAt,
+		FrToDt: camtFrToDt{
+			FrDtTm: s.Date.UTC().Format(time.RFC3339),
+			ToDtTm: s.Date.UTC().AddDate(0, 0, 1).Add(-time.Second).Format(time.RFC3339),
+		},
+		Bal: []camtBal{
+			camtBalance("OPBD", s.OpeningBalance, s.Date),
+			camtBalance("CLBD", s.ClosingBalance, s.Date),
+		},
+	}
+	stmt.Acct.ID.Othr.ID = truncate(s.AccountID, camtMax34Text)
+	stmt.Acct.Ccy = currency
+
+	summary, err := camtSummary(s.Entries, currency)
+	if err != nil {
+		return err
+	}
+	stmt.Summry = summary
+
+	stmt.Ntry = make([]camtNtry, 0, len(s.Entries))
+	for _, e := range s.Entries {
+		stmt.Ntry = append(stmt.Ntry, camtEntry(e))
+	}
+
+	doc := camtDocument{
+		Xmlns: Camt053Namespace,
+		Stmt: camtBkToCstmrStmt{
+			GrpHdr: camtGrpHdr{
+				MsgID:   truncate(s.ID+s.GeneratedAt.UTC().Format("150405"), camtMax35Text),
+				CreDtTm: generatedAt,
+			},
+			Stmt: stmt,
+		},
+	}
+
+	_, err = io.WriteString(w, xml.Header)
+	if err != nil {
+		return fmt.Errorf("write header: %w", err)
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	err = enc.Encode(doc)
+	if err != nil {
+		return fmt.Errorf("encode camt.053: %w", err)
+	}
+
+	return enc.Close()
+}
+
+func camtBalance(code string, m acme.Money, date time.Time) camtBal {
+	var b camtBal
+	b.Tp.CdOrPrtry.Cd = code
+	b.Amt = camtAmount(m)
+	b.CdtDbtInd = camtCreditDebit(m)
+	b.Dt.Dt = date.UTC().Format(time.DateOnly)
+	return b
+}
+
+// camtAmount returns the unsigned amount, the sign is the credit debit
+// indicator
+func camtAmount(m acme.Money) camtAmt {
+	return camtAmt{Ccy: m.Currency(), Value: m.Abs().Decimal()}
+}
+
+func camtCreditDebit(m acme.Money) string {
+	if m.Sign() < 0 {
+		return "DBIT"
+	}
+
+	return "CRDT"
+}
+
+// camtSummary totals the booked entries
+func camtSummary(entries []AccountStatementEntry, currency string) (camtTxsSummry, error) {
+	var summary camtTxsSummry
+
+	var count, credits, debits int
+	net := acme.NewMoney(0, currency)
+	total := acme.NewMoney(0, currency)
+	creditSum := acme.NewMoney(0, currency)
+	debitSum := acme.NewMoney(0, currency)
+	for _, e := range entries {
+		if e.Status != EntryStatusBooked {
+			continue
+		}
+
+		var err error
+		count++
+		net, err = net.Add(e.Amount)
+		if err != nil {
+			return summary, fmt.Errorf("add entry %q: %w", e.ID, err)
+		}
+		total, err = total.Add(e.Amount.Abs())
+		if err != nil {
+			return summary, fmt.Errorf("add entry %q: %w", e.ID, err)
+		}
+
+		if e.Amount.Sign() < 0 {
+			debits++
+			debitSum, err = debitSum.Add(e.Amount.Abs())
+		} else {
+			credits++
+			creditSum, err = creditSum.Add(e.Amount)
+		}
+		if err != nil {
+			return summary, fmt.Errorf("add entry %q: %w", e.ID, err)
+		}
+	}
+
+	summary.TtlNtries.NbOfNtries = strconv.Itoa(count)
+	summary.TtlNtries.Sum = total.Decimal()
+	summary.TtlNtries.TtlNetNtry.Amt = net.Abs().Decimal()
+	summary.TtlNtries.TtlNetNtry.CdtDbtInd = camtCreditDebit(net)
+	summary.TtlCdtNtries = camtNbAndSum{NbOfNtries: strconv.Itoa(credits), Sum: creditSum.Decimal()}
+	summary.TtlDbtNtries = camtNbAndSum{NbOfNtries: strconv.Itoa(debits), Sum: debitSum.Decimal()}
+
+	return summary, nil
+}
+
+func camtEntry(e AccountStatementEntry) camtNtry {
+	ref := truncate(e.ID, camtMax35Text)
+
+	n := camtNtry{
+		NtryRef:     ref,
+		Amt:         camtAmount(e.Amount),
+		CdtDbtInd:   camtCreditDebit(e.Amount),
+		AcctSvcrRef: ref,
+		BkTxCd:      camtBankTransactionCode(e),
+	}
+	n.Sts.Cd = e.Status
+	n.BookgDt.DtTm = e.Date.UTC().Format(time.RFC3339)
+
+	tx := &n.NtryDtls.TxDtls
+	tx.Refs.AcctSvcrRef = ref
+	tx.AddtlTxInf = truncate(e.Memo, camtMax500Text)
+	if e.Payee != "" {
+		tx.RltdPties = &camtRltdPties{}
+		tx.RltdPties.Cdtr.Pty.Nm = truncate(e.Payee, camtMax140Text)
+	}
+
+	if !e.InstructedAmount.IsZero() {
+		amtDtls := &camtAmtDtls{}
+		amtDtls.InstdAmt.Amt = camtAmount(e.InstructedAmount)
+		amtDtls.TxAmt.Amt = camtAmount(e.Amount)
+		amtDtls.TxAmt.CcyXchg.SrcCcy = e.InstructedAmount.Currency()
+		amtDtls.TxAmt.CcyXchg.TrgtCcy = e.Amount.Currency()
+		amtDtls.TxAmt.CcyXchg.XchgRate = e.ConversionRate
+		tx.AmtDtls = amtDtls
+	}
+
+	return n
+}
+
+// camtBankTransactionCode returns the ISO bank transaction code of card
+// entries, the entry type is always sent as the proprietary code.
+func camtBankTransactionCode(e AccountStatementEntry) camtBkTxCd {
+	var code camtBkTxCd
+	code.Prtry.Cd = e.Type
+
+	var subFamily string
+	switch e.Type {
+	case EntryDebit:
+		subFamily = "POSD"
+		if e.Channel == "ATM" {
+			subFamily = "CWDL"
+		}
+	case EntryCredit:
+		subFamily = "RIMB"
+	case EntryFee:
+		subFamily = "CHRG"
+	default:
+		return code
+	}
+
+	code.Domn = &camtDomain{Cd: "PMNT"}
+	code.Domn.Fmly.Cd = "CCRD"
+	code.Domn.Fmly.SubFmlyCd = subFamily
+
+	return code
+}
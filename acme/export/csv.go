@@ -0,0 +1,47 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strings"
+)
+
+type csvRowWriter struct {
+	w *csv.Writer
+}
+
+func newCSVRowWriter(w io.Writer) *csvRowWriter {
+	return &csvRowWriter{w: csv.NewWriter(w)}
+}
+
+func (c *csvRowWriter) writeRow(cells []cell) error {
+	record := make([]string, 0, len(cells))
+	for _, cl := range cells {
+		if cl.number {
+			record = append(record, cl.value)
+			continue
+		}
+		record = append(record, csvText(cl.value))
+	}
+
+	return c.w.Write(record)
+}
+
+func (c *csvRowWriter) flush() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *csvRowWriter) close() error {
+	return c.flush()
+}
+
+// csvText neutralises the text starting like a spreadsheet formula e.g. a
+// merchant name of "=HYPERLINK(...)", the leading quote keeps it as text.
+func csvText(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+
+	return s
+}
@@ -0,0 +1,95 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+)
+
+// xeroDateLayout is the day first date format of the Xero manual journal
+// import
+const xeroDateLayout = "02/01/2006"
+
+// xeroTaxRate is the tax rate of card spend lines, taxes are reconciled
+// from the receipts in the ledger.
+const xeroTaxRate = "Tax Exempt"
+
+var journalCSVHeader = []string{
+	"exportId", "date", "transactionId", "lineNo", "account",
+	"description", "debit", "credit", "currency",
+}
+
+// WriteJournalCSV writes the journal lines as a generic CSV with separate
+// debit and credit columns.
+func WriteJournalCSV(w io.Writer, e acme.JournalExport) error {
+	cw := csv.NewWriter(w)
+
+	err := cw.Write(journalCSVHeader)
+	if err != nil {
+		return fmt.Errorf("write header: %w", err)
+	}
+
+	exportID := strconv.FormatInt(e.ID, 10)
+	for _, l := range e.Lines {
+		err = cw.Write([]string{
+			exportID,
+			l.Date.UTC().Format(time.DateOnly),
+			csvText(l.TransactionID),
+			strconv.Itoa(l.LineNo),
+			csvText(l.Account),
+			csvText(l.Description),
+			l.Debit.Decimal(),
+			l.Credit.Decimal(),
+			l.Debit.Currency(),
+		})
+		if err != nil {
+			return fmt.Errorf("write line: %w", err)
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+var xeroJournalHeader = []string{
+	"*Narration", "*Date", "Description", "*AccountCode", "*TaxRate", "*Amount",
+}
+
+// WriteXeroJournal writes the journal lines as a Xero manual journal
+// import, Xero groups the lines by narration and date so each transaction
+// is imported as its own balanced journal. Debits are positive and credits
+// negative.
+func WriteXeroJournal(w io.Writer, e acme.JournalExport) error {
+	cw := csv.NewWriter(w)
+
+	err := cw.Write(xeroJournalHeader)
+	if err != nil {
+		return fmt.Errorf("write header: %w", err)
+	}
+
+	for _, l := range e.Lines {
+		amount, err := l.Debit.Sub(l.Credit)
+		if err != nil {
+			return fmt.Errorf("line %s/%d amount: %w", l.TransactionID, l.LineNo, err)
+		}
+
+		err = cw.Write([]string{
+			"Card transaction " + l.TransactionID,
+			l.Date.UTC().Format(xeroDateLayout),
+			csvText(l.Description),
+			csvText(l.Account),
+			xeroTaxRate,
+			amount.Decimal(),
+		})
+		if err != nil {
+			return fmt.Errorf("write line: %w", err)
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
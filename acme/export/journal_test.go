@@ -0,0 +1,54 @@
+package export_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/acme/export"
+)
+
+func TestWriteJournal(t *testing.T) {
+	date := time.Date(2025, 3, 12, 10, 0, 0, 0, time.UTC)
+	zero := acme.NewMoney(0, "USD")
+	je := acme.JournalExport{
+		ID: 7,
+		Lines: []acme.JournalLine{
+			{TransactionID: "tx1", LineNo: 1, Date: date, Account: "6100", Description: "Sushi, Tokyo", Debit: acme.MustParseMoney("12.50", "USD"), Credit: zero},
+			{TransactionID: "tx1", LineNo: 2, Date: date, Account: "2100", Description: "Sushi, Tokyo", Debit: zero, Credit: acme.MustParseMoney("12.50", "USD")},
+		},
+	}
+
+	t.Run("csv", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, export.WriteJournalCSV(&buf, je))
+		assert.Equal(t, "exportId,date,transactionId,lineNo,account,description,debit,credit,currency\n"+
+			"7,2025-03-12,tx1,1,6100,\"Sushi, Tokyo\",12.50,0.00,USD\n"+
+			"7,2025-03-12,tx1,2,2100,\"Sushi, Tokyo\",0.00,12.50,USD\n", buf.String())
+	})
+
+	t.Run("xero", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, export.WriteXeroJournal(&buf, je))
+		assert.Equal(t, "*Narration,*Date,Description,*AccountCode,*TaxRate,*Amount\n"+
+			"Card transaction tx1,12/03/2025,\"Sushi, Tokyo\",6100,Tax Exempt,12.50\n"+
+			"Card transaction tx1,12/03/2025,\"Sushi, Tokyo\",2100,Tax Exempt,-12.50\n", buf.String())
+	})
+	t.Run("formulas", func(t *testing.T) {
+		formula := acme.JournalExport{ID: 8, Lines: []acme.JournalLine{
+			{TransactionID: "tx2", LineNo: 1, Date: date, Account: "6100", Description: "=1+2", Debit: acme.MustParseMoney("1.00", "USD"), Credit: zero},
+		}}
+
+		var buf bytes.Buffer
+		require.NoError(t, export.WriteJournalCSV(&buf, formula))
+		assert.Contains(t, buf.String(), "6100,'=1+2,1.00,0.00,USD\n")
+
+		buf.Reset()
+		require.NoError(t, export.WriteXeroJournal(&buf, formula))
+		assert.Contains(t, buf.String(), "'=1+2,6100,Tax Exempt,1.00\n")
+	})
+}
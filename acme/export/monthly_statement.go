@@ -0,0 +1,175 @@
+package export
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+)
+
+var (
+	// ErrMonthlyStatementNotFound is returned when the statement is not saved
+	ErrMonthlyStatementNotFound = errors.New("monthly statement not found")
+	// ErrInvalidStatementMonth is returned for months that have not started
+	ErrInvalidStatementMonth = errors.New("invalid statement month")
+)
+
+// MonthlyStatement is the statement of a card for a calendar month in UTC,
+// amounts are in the card currency.
+type MonthlyStatement struct {
+	// Card only keeps the ID, name and last 4 digits once saved
+	Card acme.Card
+	// Month is the first day of the month
+	Month time.Time
+	// OpeningBalance and ClosingBalance are the available credit at the
+	// start and end of the month
+	OpeningBalance acme.Money
+	ClosingBalance acme.Money
+	TopUps         acme.Money
+	Withdrawals    acme.Money
+	Purchases      acme.Money
+	Refunds        acme.Money
+	Fees           acme.Money
+	// Entries are sorted by date
+	Entries     []StatementEntry
+	GeneratedAt time.Time
+}
+
+// MonthlyStatementRepository stores the statements of complete months
+type MonthlyStatementRepository interface {
+	SaveMonthlyStatement(context.Context, MonthlyStatement) error
+	// GetMonthlyStatement returns ErrMonthlyStatementNotFound when the
+	// statement is not saved
+	GetMonthlyStatement(ctx context.Context, cardID string, month time.Time) (*MonthlyStatement, error)
+}
+
+// MonthlyStatementGenerator builds the monthly card statements
+type MonthlyStatementGenerator struct {
+	loader        *StatementLoader
+	statementRepo MonthlyStatementRepository
+	now           func() time.Time
+}
+
+// NewMonthlyStatementGenerator returns a generator of the monthly
+// statements, the statements are not saved when statementRepo is nil.
+func NewMonthlyStatementGenerator(loader *StatementLoader, statementRepo MonthlyStatementRepository) *MonthlyStatementGenerator {
+	return &MonthlyStatementGenerator{
+		loader:        loader,
+		statementRepo: statementRepo,
+		now:           time.Now,
+	}
+}
+
+// GetMonthlyStatement returns the saved statement of the month or
+// generates it. Statements of complete months are saved so they never
+// change once issued, the current month is generated on every call.
+func (g *MonthlyStatementGenerator) GetMonthlyStatement(ctx context.Context, cardID string, month time.Time) (*MonthlyStatement, error) {
+	now := g.now().UTC()
+	start := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, 0)
+	if start.After(now) {
+		return nil, fmt.Errorf("%w: %s has not started", ErrInvalidStatementMonth, start.Format("2006-01"))
+	}
+
+	complete := !end.After(now)
+	if complete && g.statementRepo != nil {
+		s, err := g.statementRepo.GetMonthlyStatement(ctx, cardID, start)
+		if err == nil {
+			return s, nil
+		}
+		if !errors.Is(err, ErrMonthlyStatementNotFound) {
+			return nil, fmt.Errorf("get monthly statement: %w", err)
+		}
+	}
+
+	s, err := g.generate(ctx, cardID, start, end, now)
+	if err != nil {
+		return nil, err
+	}
+
+	if complete && g.statementRepo != nil {
+		err = g.statementRepo.SaveMonthlyStatement(ctx, *s)
+		if err != nil {
+			return nil, fmt.Errorf("save monthly statement: %w", err)
+		}
+	}
+
+	return s, nil
+}
+
+// generate computes the balances from the current available credit of the
+// card, the closing balance is the available credit less the entries after
+// the month and the opening balance is the closing balance less the
+// entries of the month.
+func (g *MonthlyStatementGenerator) generate(ctx context.Context, cardID string, start, end, now time.Time) (*MonthlyStatement, error) {
+	// loads up to now to unwind the entries after the month
+	statement, err := g.loader.LoadStatement(ctx, cardID, acme.DateRange{From: start})
+	if err != nil {
+		return nil, err
+	}
+
+	currency := statement.Card.AvailableCredit.Currency()
+	zero := acme.NewMoney(0, currency)
+	s := &MonthlyStatement{
+		Card:        statement.Card,
+		Month:       start,
+		TopUps:      zero,
+		Withdrawals: zero,
+		Purchases:   zero,
+		Refunds:     zero,
+		Fees:        zero,
+		Entries:     make([]StatementEntry, 0),
+		GeneratedAt: now,
+	}
+
+	closing := statement.Card.AvailableCredit
+	for _, e := range statement.Entries {
+		if e.Date.Before(start) {
+			continue
+		}
+
+		if !e.Date.Before(end) {
+			closing, err = closing.Sub(e.Amount)
+			if err != nil {
+				return nil, fmt.Errorf("unwind entry %q: %w", e.ID, err)
+			}
+			continue
+		}
+
+		s.Entries = append(s.Entries, e)
+	}
+
+	opening := closing
+	for _, e := range s.Entries {
+		opening, err = opening.Sub(e.Amount)
+		if err != nil {
+			return nil, fmt.Errorf("unwind entry %q: %w", e.ID, err)
+		}
+
+		var total *acme.Money
+		switch {
+		case e.Type == EntryTransfer && e.Amount.Sign() >= 0:
+			total = &s.TopUps
+		case e.Type == EntryTransfer:
+			total = &s.Withdrawals
+		case e.Type == EntryCredit:
+			total = &s.Refunds
+		case e.Type == EntryFee:
+			total = &s.Fees
+		default:
+			total = &s.Purchases
+		}
+
+		*total, err = total.Add(e.Amount.Abs())
+		if err != nil {
+			return nil, fmt.Errorf("total entry %q: %w", e.ID, err)
+		}
+	}
+
+	s.OpeningBalance = opening
+	s.ClosingBalance = closing
+
+	return s, nil
+}
@@ -0,0 +1,58 @@
+package export
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"time"
+)
+
+var monthlyStatementTemplate = template.Must(template.New("statement").Funcs(template.FuncMap{
+	"date": func(t time.Time) string { return t.UTC().Format(time.DateOnly) },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Statement {{.Month.Format "January 2006"}} - {{.Card.Name}}</title>
+<style>
+body { font-family: sans-serif; font-size: 12px; margin: 2em; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 2em; }
+th, td { border-bottom: 1px solid #ddd; padding: 4px 8px; text-align: left; }
+td.amount, th.amount { text-align: right; white-space: nowrap; }
+@media print { body { margin: 0; } tr { page-break-inside: avoid; } }
+</style>
+</head>
+<body>
+<h1>Card statement</h1>
+<p>{{.Card.Name}} **** {{.Card.Last4}}<br>{{.Month.Format "January 2006"}}</p>
+<table>
+<tr><th>Opening balance</th><td class="amount">{{.OpeningBalance}}</td></tr>
+<tr><th>Top-ups</th><td class="amount">{{.TopUps}}</td></tr>
+<tr><th>Withdrawals</th><td class="amount">{{.Withdrawals}}</td></tr>
+<tr><th>Purchases</th><td class="amount">{{.Purchases}}</td></tr>
+<tr><th>Refunds</th><td class="amount">{{.Refunds}}</td></tr>
+<tr><th>Fees</th><td class="amount">{{.Fees}}</td></tr>
+<tr><th>Closing balance</th><td class="amount">{{.ClosingBalance}}</td></tr>
+</table>
+<table>
+<tr><th>Date</th><th>Description</th><th>Details</th><th class="amount">Amount</th></tr>
+{{- range .Entries}}
+<tr><td>{{date .Date}}</td><td>{{.Payee}}</td><td>{{.Memo}}</td><td class="amount">{{.Amount}}</td></tr>
+{{- else}}
+<tr><td colspan="4">No transactions</td></tr>
+{{- end}}
+</table>
+<p>Generated {{date .GeneratedAt}}</p>
+</body>
+</html>
+`))
+
+// WriteMonthlyStatementHTML writes the statement as a printable HTML page.
+func WriteMonthlyStatementHTML(w io.Writer, s MonthlyStatement) error {
+	err := monthlyStatementTemplate.Execute(w, s)
+	if err != nil {
+		return fmt.Errorf("execute template: %w", err)
+	}
+
+	return nil
+}
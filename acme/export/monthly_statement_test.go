@@ -0,0 +1,73 @@
+package export_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/acme/export"
+	"github.com/stevenferrer/acme-cards-api/acme/memory"
+)
+
+func TestMonthlyStatementGenerator(t *testing.T) {
+	ctx := context.Background()
+
+	usd := func(s string) acme.Money { return acme.MustParseMoney(s, "USD") }
+	march := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	april := time.Date(2025, 4, 2, 0, 0, 0, 0, time.UTC)
+	src := &stubStatementSource{
+		card: acme.Card{ID: "card1", Name: "Jane Doe", Last4: "4242", AvailableCredit: usd("480.00")},
+		transactions: []acme.Transaction{
+			{ID: "tx1", Category: "purchase", Status: "settled", Amount: usd("50.00"), Fees: acme.FeeDetails{FXFees: usd("1.00")}, Merchant: acme.MerchantDetails{Name: "Sushi"}, CreatedAt: march},
+			{ID: "tx2", Category: "refund", Status: "settled", Amount: usd("10.00"), Merchant: acme.MerchantDetails{Name: "Air"}, CreatedAt: march.Add(time.Hour)},
+			{ID: "tx3", Category: "purchase", Status: "declined", Amount: usd("999.00"), CreatedAt: march},
+			{ID: "tx4", Category: "purchase", Status: "settled", Amount: usd("20.00"), CreatedAt: april},
+		},
+		balanceChanges: []acme.BalanceChange{
+			{ID: "bc1", Type: acme.BalanceAdjustmentTopUp, Amount: usd("100.00"), Date: march.Add(-time.Hour)},
+			{ID: "bc2", Type: acme.BalanceAdjustmentWithdraw, Amount: usd("30.00"), Date: march.Add(2 * time.Hour)},
+		},
+	}
+
+	statementRepo := memory.NewMonthlyStatementRepository()
+	generator := export.NewMonthlyStatementGenerator(export.NewStatementLoader(src, src, src), statementRepo)
+
+	s, err := generator.GetMonthlyStatement(ctx, "card1", march)
+	require.NoError(t, err)
+
+	assert.Equal(t, time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), s.Month)
+	// 480 now plus the april purchase
+	assert.Equal(t, "500.00", s.ClosingBalance.Decimal())
+	// 500 - 100 + 30 + 50 + 1 - 10
+	assert.Equal(t, "471.00", s.OpeningBalance.Decimal())
+	assert.Equal(t, "100.00", s.TopUps.Decimal())
+	assert.Equal(t, "30.00", s.Withdrawals.Decimal())
+	assert.Equal(t, "50.00", s.Purchases.Decimal())
+	assert.Equal(t, "10.00", s.Refunds.Decimal())
+	assert.Equal(t, "1.00", s.Fees.Decimal())
+	assert.Len(t, s.Entries, 5)
+
+	saved, err := statementRepo.GetMonthlyStatement(ctx, "card1", s.Month)
+	require.NoError(t, err)
+	assert.Equal(t, s.OpeningBalance, saved.OpeningBalance)
+
+	// complete months are served from the repository
+	src.transactions = nil
+	s, err = generator.GetMonthlyStatement(ctx, "card1", march)
+	require.NoError(t, err)
+	assert.Equal(t, "471.00", s.OpeningBalance.Decimal())
+	assert.Len(t, s.Entries, 5)
+
+	var buf bytes.Buffer
+	require.NoError(t, export.WriteMonthlyStatementHTML(&buf, *s))
+	assert.Contains(t, buf.String(), "March 2025")
+	assert.Contains(t, buf.String(), "471.00 USD")
+
+	_, err = generator.GetMonthlyStatement(ctx, "card1", time.Now().AddDate(0, 2, 0))
+	assert.ErrorIs(t, err, export.ErrInvalidStatementMonth)
+}
@@ -0,0 +1,153 @@
+package export
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+)
+
+// ofxDateLayout is the OFX datetime in UTC
+const ofxDateLayout = "20060102150405"
+
+// ofxNameMaxLen is the maximum length of the OFX NAME element
+const ofxNameMaxLen = 32
+
+const ofxHeader = `<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>` + "\n"
+
+type ofxStatus struct {
+	Code     int    `xml:"CODE"`
+	Severity string `xml:"SEVERITY"`
+}
+
+var ofxStatusOK = ofxStatus{Code: 0, Severity: "INFO"}
+
+type ofxDocument struct {
+	XMLName xml.Name `xml:"OFX"`
+	SignOn  struct {
+		Response struct {
+			Status   ofxStatus `xml:"STATUS"`
+			DTServer string    `xml:"DTSERVER"`
+			Language string    `xml:"LANGUAGE"`
+		} `xml:"SONRS"`
+	} `xml:"SIGNONMSGSRSV1"`
+	CreditCard struct {
+		Transaction struct {
+			TrnUID    string       `xml:"TRNUID"`
+			Status    ofxStatus    `xml:"STATUS"`
+			Statement ofxStatement `xml:"CCSTMTRS"`
+		} `xml:"CCSTMTTRNRS"`
+	} `xml:"CREDITCARDMSGSRSV1"`
+}
+
+type ofxStatement struct {
+	Currency string `xml:"CURDEF"`
+	Account  struct {
+		ID string `xml:"ACCTID"`
+	} `xml:"CCACCTFROM"`
+	TransactionList struct {
+		Start        string           `xml:"DTSTART"`
+		End          string           `xml:"DTEND"`
+		Transactions []ofxTransaction `xml:"STMTTRN"`
+	} `xml:"BANKTRANLIST"`
+	LedgerBalance struct {
+		Amount string `xml:"BALAMT"`
+		AsOf   string `xml:"DTASOF"`
+	} `xml:"LEDGERBAL"`
+}
+
+type ofxTransaction struct {
+	Type   string `xml:"TRNTYPE"`
+	Posted string `xml:"DTPOSTED"`
+	Amount string `xml:"TRNAMT"`
+	FITID  string `xml:"FITID"`
+	Name   string `xml:"NAME,omitempty"`
+	Memo   string `xml:"MEMO,omitempty"`
+	// OrigCurrency is the merchant currency of a converted amount, the
+	// amount stays in the statement currency
+	OrigCurrency *ofxCurrency `xml:"ORIGCURRENCY,omitempty"`
+}
+
+// ofxCurrency is the OFX currency aggregate, the rate and the symbol are
+// both required
+type ofxCurrency struct {
+	Rate   string `xml:"CURRATE"`
+	Symbol string `xml:"CURSYM"`
+}
+
+// WriteOFX writes the statement as an OFX 2.2 credit card statement, the
+// FITID is the reap transaction ID and the ledger balance is the available
+// credit of the card. The amounts must be in the statement currency, the
+// merchant currency of the converted amounts is kept with its rate.
+func WriteOFX(w io.Writer, s Statement) error {
+	var doc ofxDocument
+	doc.SignOn.Response.Status = ofxStatusOK
+	doc.SignOn.Response.DTServer = s.GeneratedAt.UTC().Format(ofxDateLayout)
+	doc.SignOn.Response.Language = "ENG"
+
+	doc.CreditCard.Transaction.TrnUID = "0"
+	doc.CreditCard.Transaction.Status = ofxStatusOK
+
+	stmt := &doc.CreditCard.Transaction.Statement
+	stmt.Currency = s.Card.AvailableCredit.Currency()
+	if stmt.Currency == "" && len(s.Entries) > 0 {
+		stmt.Currency = s.Entries[0].Amount.Currency()
+	}
+	stmt.Account.ID = s.Card.ID
+	stmt.TransactionList.Start = ofxDate(s.DateRange.From, s.GeneratedAt)
+	stmt.TransactionList.End = ofxDate(s.DateRange.To, s.GeneratedAt)
+	stmt.LedgerBalance.Amount = s.Card.AvailableCredit.Decimal()
+	stmt.LedgerBalance.AsOf = s.GeneratedAt.UTC().Format(ofxDateLayout)
+
+	stmt.TransactionList.Transactions = make([]ofxTransaction, 0, len(s.Entries))
+	for _, e := range s.Entries {
+		t := ofxTransaction{
+			Type:   e.Type,
+			Posted: e.Date.UTC().Format(ofxDateLayout),
+			Amount: e.Amount.Decimal(),
+			FITID:  e.ID,
+			Name:   truncate(e.Payee, ofxNameMaxLen),
+			Memo:   e.Memo,
+		}
+		// OFX has no rate to convert an amount in another currency
+		if e.Amount.Currency() != stmt.Currency {
+			return fmt.Errorf("entry %q: currency %s is not the statement currency %s", e.ID, e.Amount.Currency(), stmt.Currency)
+		}
+		if e.OriginalAmount.Currency() != "" && e.ConversionRate != "" {
+			t.OrigCurrency = &ofxCurrency{Rate: e.ConversionRate, Symbol: e.OriginalAmount.Currency()}
+		}
+		stmt.TransactionList.Transactions = append(stmt.TransactionList.Transactions, t)
+	}
+
+	_, err := io.WriteString(w, xml.Header+ofxHeader)
+	if err != nil {
+		return fmt.Errorf("write header: %w", err)
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	err = enc.Encode(doc)
+	if err != nil {
+		return fmt.Errorf("encode ofx: %w", err)
+	}
+
+	return enc.Close()
+}
+
+// ofxDate formats t or the fallback when t is not set
+func ofxDate(t, fallback time.Time) string {
+	if t.IsZero() {
+		t = fallback
+	}
+
+	return t.UTC().Format(ofxDateLayout)
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+
+	return string(r[:n])
+}
@@ -0,0 +1,44 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// qifDateLayout is the US date format understood by most QIF importers
+const qifDateLayout = "01/02/2006"
+
+// WriteQIF writes the statement as a QIF credit card account, QIF has no
+// transaction ID so the reap transaction ID is written as the reference.
+func WriteQIF(w io.Writer, s Statement) error {
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintln(bw, "!Type:CCard")
+	for _, e := range s.Entries {
+		fmt.Fprintf(bw, "D%s\n", e.Date.UTC().Format(qifDateLayout))
+		fmt.Fprintf(bw, "T%s\n", e.Amount.Decimal())
+		fmt.Fprintf(bw, "N%s\n", qifField(e.ID))
+		fmt.Fprintf(bw, "P%s\n", qifField(e.Payee))
+		if e.Category != "" {
+			fmt.Fprintf(bw, "L%s\n", qifField(e.Category))
+		}
+		if e.Memo != "" {
+			fmt.Fprintf(bw, "M%s\n", qifField(e.Memo))
+		}
+		fmt.Fprintln(bw, "^")
+	}
+
+	err := bw.Flush()
+	if err != nil {
+		return fmt.Errorf("flush: %w", err)
+	}
+
+	return nil
+}
+
+// qifField keeps the value on a single line
+func qifField(s string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(s)
+}
@@ -0,0 +1,189 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+)
+
+// Statement entry types, named after the OFX transaction types
+const (
+	EntryDebit    = "DEBIT"
+	EntryCredit   = "CREDIT"
+	EntryFee      = "FEE"
+	EntryTransfer = "XFER"
+)
+
+// Statement is the card activity in a date range for personal finance
+// tools.
+type Statement struct {
+	Card      acme.Card
+	DateRange acme.DateRange
+	// Entries are sorted by date
+	Entries     []StatementEntry
+	GeneratedAt time.Time
+}
+
+// StatementEntry is a single line of a statement
+type StatementEntry struct {
+	// ID is unique per card and stable across exports
+	ID   string
+	Type string
+	Date time.Time
+	// Amount is negative for debits
+	Amount   acme.Money
+	Payee    string
+	Category string
+	Memo     string
+	// OriginalAmount is the amount in the merchant currency when it differs
+	// from the currency of Amount, ConversionRate converts it to Amount
+	OriginalAmount acme.Money
+	ConversionRate string
+}
+
+// StatementLoader collects the statement data of a card
+type StatementLoader struct {
+	cardSvc    acme.CardService
+	txSource   acme.TransactionSource
+	balanceSrc acme.BalanceChangeSource
+}
+
+func NewStatementLoader(
+	cardSvc acme.CardService,
+	txSource acme.TransactionSource,
+	balanceSrc acme.BalanceChangeSource,
+) *StatementLoader {
+	return &StatementLoader{
+		cardSvc:    cardSvc,
+		txSource:   txSource,
+		balanceSrc: balanceSrc,
+	}
+}
+
+// LoadStatement returns the transactions, fees, top-ups and withdrawals of
+// the card in the date range.
+func (l *StatementLoader) LoadStatement(ctx context.Context, cardID string, dateRange acme.DateRange) (*Statement, error) {
+	card, err := l.cardSvc.GetCard(ctx, cardID)
+	if err != nil {
+		return nil, fmt.Errorf("get card: %w", err)
+	}
+
+	var entries []StatementEntry
+	err = l.txSource.EachCardTransaction(ctx, cardID, dateRange, func(t acme.Transaction) error {
+		if !t.IsPosted() {
+			return nil
+		}
+
+		entries = append(entries, transactionEntries(t)...)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("each card transaction: %w", err)
+	}
+
+	err = l.balanceSrc.EachCardBalanceChange(ctx, cardID, dateRange, func(bc acme.BalanceChange) error {
+		entry, ok := balanceChangeEntry(bc)
+		if ok {
+			entries = append(entries, entry)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("each card balance change: %w", err)
+	}
+
+	slices.SortStableFunc(entries, func(a, b StatementEntry) int {
+		return a.Date.Compare(b.Date)
+	})
+
+	return &Statement{
+		Card:        *card,
+		DateRange:   dateRange,
+		Entries:     entries,
+		GeneratedAt: time.Now().UTC(),
+	}, nil
+}
+
+// transactionEntries returns the transaction and its fees as separate
+// entries.
+func transactionEntries(t acme.Transaction) []StatementEntry {
+	entryType := EntryDebit
+	if t.IsCredit() {
+		entryType = EntryCredit
+	}
+
+	payee := t.Merchant.Name
+	if payee == "" {
+		payee = t.Category
+	}
+
+	entry := StatementEntry{
+		ID:       t.ID,
+		Type:     entryType,
+		Date:     t.CreatedAt,
+		Amount:   t.SignedAmount(),
+		Payee:    payee,
+		Category: t.Merchant.MCCCategory,
+		Memo:     t.Merchant.MCCCategory,
+	}
+	if t.TransactionAmount.Currency() != "" && t.TransactionAmount.Currency() != t.Amount.Currency() {
+		entry.Memo = strings.TrimSpace(fmt.Sprintf("%s %s @ %s", entry.Memo, t.TransactionAmount, t.ConversionRate))
+		entry.OriginalAmount = t.TransactionAmount
+		entry.ConversionRate = t.ConversionRate
+	}
+
+	entries := []StatementEntry{entry}
+
+	fees := []struct {
+		suffix, name string
+		amount       acme.Money
+	}{
+		{"atm", "ATM fee", t.Fees.ATMFees},
+		{"fx", "FX fee", t.Fees.FXFees},
+	}
+	for _, fee := range fees {
+		if fee.amount.IsZero() {
+			continue
+		}
+
+		entries = append(entries, StatementEntry{
+			ID:       t.ID + "-" + fee.suffix,
+			Type:     EntryFee,
+			Date:     t.CreatedAt,
+			Amount:   fee.amount.Abs().Neg(),
+			Payee:    payee,
+			Category: fee.name,
+			Memo:     fee.name,
+		})
+	}
+
+	return entries
+}
+
+// balanceChangeEntry returns the top-up or withdrawal entry, other balance
+// changes mirror the card transactions and are skipped.
+func balanceChangeEntry(bc acme.BalanceChange) (StatementEntry, bool) {
+	var payee string
+	switch {
+	case strings.EqualFold(bc.Type, acme.BalanceAdjustmentTopUp):
+		payee = "Top-up"
+	case strings.EqualFold(bc.Type, acme.BalanceAdjustmentWithdraw):
+		payee = "Withdrawal"
+	default:
+		return StatementEntry{}, false
+	}
+
+	return StatementEntry{
+		ID:       bc.ID,
+		Type:     EntryTransfer,
+		Date:     bc.Date,
+		Amount:   bc.SignedAmount(),
+		Payee:    payee,
+		Category: "Transfer",
+		Memo:     payee,
+	}, true
+}
@@ -0,0 +1,184 @@
+package export_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/acme/export"
+)
+
+// stubStatementSource embeds acme.CardService so only GetCard is stubbed
+type stubStatementSource struct {
+	acme.CardService
+
+	card           acme.Card
+	balance        acme.AccountBalance
+	transactions   []acme.Transaction
+	balanceChanges []acme.BalanceChange
+}
+
+func (s *stubStatementSource) GetAccountBalance(_ context.Context) (*acme.AccountBalance, error) {
+	return &s.balance, nil
+}
+
+func (s *stubStatementSource) ListCards(_ context.Context, _ acme.ListCardsParams) (*acme.ListCardsResponse, error) {
+	return &acme.ListCardsResponse{Cards: []acme.Card{s.card}}, nil
+}
+
+func (s *stubStatementSource) GetCard(_ context.Context, _ string) (*acme.Card, error) {
+	return &s.card, nil
+}
+
+func (s *stubStatementSource) EachTransaction(ctx context.Context, dateRange acme.DateRange, fn func(acme.Transaction) error) error {
+	return s.EachCardTransaction(ctx, s.card.ID, dateRange, fn)
+}
+
+func (s *stubStatementSource) EachCardTransaction(_ context.Context, _ string, _ acme.DateRange, fn func(acme.Transaction) error) error {
+	for _, t := range s.transactions {
+		if err := fn(t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *stubStatementSource) EachCardBalanceChange(_ context.Context, _ string, _ acme.DateRange, fn func(acme.BalanceChange) error) error {
+	for _, bc := range s.balanceChanges {
+		if err := fn(bc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func testStatement(t *testing.T) *export.Statement {
+	day := time.Date(2025, 3, 14, 9, 26, 53, 0, time.UTC)
+	src := &stubStatementSource{
+		card: acme.Card{ID: "card1", AvailableCredit: acme.MustParseMoney("486.28", "USD")},
+		transactions: []acme.Transaction{
+			{
+				ID:                "tx1",
+				Category:          "purchase",
+				Status:            "settled",
+				Amount:            acme.MustParseMoney("13.45", "USD"),
+				TransactionAmount: acme.MustParseMoney("2000", "JPY"),
+				ConversionRate:    "0.006725",
+				Fees:              acme.FeeDetails{FXFees: acme.MustParseMoney("0.27", "USD")},
+				Merchant:          acme.MerchantDetails{Name: "Tsukiji Sushi & Grill", MCCCategory: "Restaurants"},
+				CreatedAt:         day,
+			},
+			{
+				ID:        "tx2",
+				Category:  "refund",
+				Status:    "settled",
+				Amount:    acme.MustParseMoney("5.00", "USD"),
+				Merchant:  acme.MerchantDetails{Name: "Book Store"},
+				CreatedAt: day.AddDate(0, 0, 1),
+			},
+			{ID: "tx3", Status: "declined", Amount: acme.MustParseMoney("1.00", "USD"), CreatedAt: day},
+		},
+		balanceChanges: []acme.BalanceChange{
+			{ID: "bc1", Type: acme.BalanceAdjustmentTopUp, Amount: acme.MustParseMoney("500", "USD"), Date: day.AddDate(0, 0, -1)},
+			{ID: "bc2", Type: "PURCHASE", Amount: acme.MustParseMoney("13.45", "USD"), Date: day},
+		},
+	}
+
+	loader := export.NewStatementLoader(src, src, src)
+	statement, err := loader.LoadStatement(context.Background(), "card1", acme.DateRange{
+		From: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC),
+	})
+	require.NoError(t, err)
+
+	return statement
+}
+
+func TestLoadStatement(t *testing.T) {
+	statement := testStatement(t)
+
+	ids := make([]string, 0, len(statement.Entries))
+	amounts := make([]string, 0, len(statement.Entries))
+	for _, e := range statement.Entries {
+		ids = append(ids, e.ID)
+		amounts = append(amounts, e.Amount.Decimal())
+	}
+
+	assert.Equal(t, []string{"bc1", "tx1", "tx1-fx", "tx2"}, ids)
+	assert.Equal(t, []string{"500.00", "-13.45", "-0.27", "5.00"}, amounts)
+	assert.Equal(t, "Restaurants 2000 JPY @ 0.006725", statement.Entries[1].Memo)
+}
+
+func TestWriteOFX(t *testing.T) {
+	statement := testStatement(t)
+
+	buf := &bytes.Buffer{}
+	err := export.WriteOFX(buf, *statement)
+	require.NoError(t, err)
+
+	out := buf.String()
+	assert.True(t, strings.HasPrefix(out, `<?xml version="1.0" encoding="UTF-8"?>`+"\n"+`<?OFX OFXHEADER="200" VERSION="220"`))
+
+	var doc struct {
+		Statement struct {
+			Currency     string `xml:"CURDEF"`
+			AccountID    string `xml:"CCACCTFROM>ACCTID"`
+			Start        string `xml:"BANKTRANLIST>DTSTART"`
+			Transactions []struct {
+				Type   string `xml:"TRNTYPE"`
+				Amount string `xml:"TRNAMT"`
+				FITID  string `xml:"FITID"`
+				Name   string `xml:"NAME"`
+				Rate   string `xml:"ORIGCURRENCY>CURRATE"`
+				Symbol string `xml:"ORIGCURRENCY>CURSYM"`
+			} `xml:"BANKTRANLIST>STMTTRN"`
+			Balance string `xml:"LEDGERBAL>BALAMT"`
+		} `xml:"CREDITCARDMSGSRSV1>CCSTMTTRNRS>CCSTMTRS"`
+	}
+	err = xml.Unmarshal(buf.Bytes(), &doc)
+	require.NoError(t, err)
+
+	stmt := doc.Statement
+	assert.Equal(t, "USD", stmt.Currency)
+	assert.Equal(t, "card1", stmt.AccountID)
+	assert.Equal(t, "20250301000000", stmt.Start)
+	assert.Equal(t, "486.28", stmt.Balance)
+	require.Len(t, stmt.Transactions, 4)
+	assert.Equal(t, "XFER", stmt.Transactions[0].Type)
+	assert.Equal(t, "DEBIT", stmt.Transactions[1].Type)
+	assert.Equal(t, "tx1", stmt.Transactions[1].FITID)
+	assert.Equal(t, "-13.45", stmt.Transactions[1].Amount)
+	assert.Equal(t, "Tsukiji Sushi & Grill", stmt.Transactions[1].Name)
+	assert.Equal(t, "0.006725", stmt.Transactions[1].Rate)
+	assert.Equal(t, "JPY", stmt.Transactions[1].Symbol)
+	assert.Empty(t, stmt.Transactions[3].Symbol)
+	assert.Equal(t, "FEE", stmt.Transactions[2].Type)
+	assert.Equal(t, "CREDIT", stmt.Transactions[3].Type)
+
+	// the amounts in another currency cannot be converted
+	statement.Entries[3].Amount = acme.MustParseMoney("5.00", "EUR")
+	err = export.WriteOFX(&bytes.Buffer{}, *statement)
+	assert.Error(t, err)
+}
+
+func TestWriteQIF(t *testing.T) {
+	statement := testStatement(t)
+
+	buf := &bytes.Buffer{}
+	err := export.WriteQIF(buf, *statement)
+	require.NoError(t, err)
+
+	expected := "!Type:CCard\n" +
+		"D03/13/2025\nT500.00\nNbc1\nPTop-up\nLTransfer\nMTop-up\n^\n" +
+		"D03/14/2025\nT-13.45\nNtx1\nPTsukiji Sushi & Grill\nLRestaurants\nMRestaurants 2000 JPY @ 0.006725\n^\n" +
+		"D03/14/2025\nT-0.27\nNtx1-fx\nPTsukiji Sushi & Grill\nLFX fee\nMFX fee\n^\n" +
+		"D03/15/2025\nT5.00\nNtx2\nPBook Store\n^\n"
+	assert.Equal(t, expected, buf.String())
+}
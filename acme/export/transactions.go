@@ -0,0 +1,204 @@
+// Package export renders card transactions into files for accounting tools.
+package export
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+)
+
+// Export formats
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+// ContentType returns the media type of the format.
+func ContentType(format string) string {
+	if format == FormatXLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+
+	return "text/csv"
+}
+
+// cell is a single value of a row, numbers are written as numeric cells
+// in spreadsheets.
+type cell struct {
+	value  string
+	number bool
+}
+
+func text(s string) cell { return cell{value: s} }
+
+func amount(m acme.Money) cell { return cell{value: m.Decimal(), number: true} }
+
+type column struct {
+	name  string
+	value func(acme.Transaction) cell
+}
+
+// transactionColumns are the exportable transaction columns in their
+// default order.
+var transactionColumns = []column{
+	{"id", func(t acme.Transaction) cell { return text(t.ID) }},
+	{"cardId", func(t acme.Transaction) cell { return text(t.CardID) }},
+	{"date", func(t acme.Transaction) cell { return text(t.CreatedAt.UTC().Format(time.RFC3339)) }},
+	{"category", func(t acme.Transaction) cell { return text(t.Category) }},
+	{"status", func(t acme.Transaction) cell { return text(t.Status) }},
+	{"channel", func(t acme.Transaction) cell { return text(t.Channel) }},
+	{"amount", func(t acme.Transaction) cell { return amount(t.Amount) }},
+	{"currency", func(t acme.Transaction) cell { return text(t.Amount.Currency()) }},
+	{"transactionAmount", func(t acme.Transaction) cell { return amount(t.TransactionAmount) }},
+	{"transactionCurrency", func(t acme.Transaction) cell { return text(t.TransactionAmount.Currency()) }},
+	{"conversionRate", func(t acme.Transaction) cell { return text(t.ConversionRate) }},
+	{"atmFees", func(t acme.Transaction) cell { return amount(t.Fees.ATMFees) }},
+	{"fxFees", func(t acme.Transaction) cell { return amount(t.Fees.FXFees) }},
+	{"merchantId", func(t acme.Transaction) cell { return text(t.Merchant.ID) }},
+	{"merchantName", func(t acme.Transaction) cell { return text(t.Merchant.Name) }},
+	{"merchantCity", func(t acme.Transaction) cell { return text(t.Merchant.City) }},
+	{"merchantCountry", func(t acme.Transaction) cell { return text(t.Merchant.Country) }},
+	{"mccCode", func(t acme.Transaction) cell { return text(t.Merchant.MCCCode) }},
+	{"mccCategory", func(t acme.Transaction) cell { return text(t.Merchant.MCCCategory) }},
+}
+
+// TransactionColumns returns the names of the exportable columns.
+func TransactionColumns() []string {
+	names := make([]string, 0, len(transactionColumns))
+	for _, c := range transactionColumns {
+		names = append(names, c.name)
+	}
+
+	return names
+}
+
+// ParseTransactionColumns parses a comma separated list of column names,
+// an empty list selects every column.
+func ParseTransactionColumns(s string) ([]string, error) {
+	if strings.TrimSpace(s) == "" {
+		return TransactionColumns(), nil
+	}
+
+	names := strings.Split(s, ",")
+	for i, name := range names {
+		names[i] = strings.TrimSpace(name)
+		if _, err := findColumn(names[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return names, nil
+}
+
+func findColumn(name string) (column, error) {
+	for _, c := range transactionColumns {
+		if c.name == name {
+			return c, nil
+		}
+	}
+
+	return column{}, fmt.Errorf("unknown column %q", name)
+}
+
+// rowWriter writes the rows of a single sheet
+type rowWriter interface {
+	writeRow(cells []cell) error
+	// flush writes the buffered rows to the underlying writer
+	flush() error
+	close() error
+}
+
+// flushEvery is the number of rows between flushes, about a page of reap
+// transactions
+const flushEvery = 100
+
+// TransactionWriter writes transactions one row at a time and flushes them
+// regularly so large exports are not buffered in memory. Nothing is
+// written until the first transaction or Close so the caller can still
+// report errors from setting it up.
+type TransactionWriter struct {
+	rw            rowWriter
+	columns       []column
+	headerWritten bool
+	rows          int
+}
+
+// NewTransactionWriter returns a writer of the columns in the format.
+func NewTransactionWriter(w io.Writer, format string, columnNames []string) (*TransactionWriter, error) {
+	if len(columnNames) == 0 {
+		columnNames = TransactionColumns()
+	}
+
+	columns := make([]column, 0, len(columnNames))
+	for _, name := range columnNames {
+		c, err := findColumn(name)
+		if err != nil {
+			return nil, err
+		}
+		columns = append(columns, c)
+	}
+
+	var rw rowWriter
+	switch format {
+	case FormatCSV:
+		rw = newCSVRowWriter(w)
+	case FormatXLSX:
+		rw = newXLSXRowWriter(w)
+	default:
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+
+	return &TransactionWriter{rw: rw, columns: columns}, nil
+}
+
+// Write writes the transaction as a row.
+func (tw *TransactionWriter) Write(t acme.Transaction) error {
+	err := tw.writeHeader()
+	if err != nil {
+		return err
+	}
+
+	cells := make([]cell, 0, len(tw.columns))
+	for _, c := range tw.columns {
+		cells = append(cells, c.value(t))
+	}
+
+	err = tw.rw.writeRow(cells)
+	if err != nil {
+		return err
+	}
+
+	tw.rows++
+	if tw.rows%flushEvery == 0 {
+		return tw.rw.flush()
+	}
+
+	return nil
+}
+
+// Close completes the file, it does not close the underlying writer.
+func (tw *TransactionWriter) Close() error {
+	err := tw.writeHeader()
+	if err != nil {
+		return err
+	}
+
+	return tw.rw.close()
+}
+
+func (tw *TransactionWriter) writeHeader() error {
+	if tw.headerWritten {
+		return nil
+	}
+	tw.headerWritten = true
+
+	cells := make([]cell, 0, len(tw.columns))
+	for _, c := range tw.columns {
+		cells = append(cells, text(c.name))
+	}
+
+	return tw.rw.writeRow(cells)
+}
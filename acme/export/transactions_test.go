@@ -0,0 +1,129 @@
+package export_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/acme/export"
+)
+
+func testTransactions(n int) []acme.Transaction {
+	transactions := make([]acme.Transaction, 0, n)
+	for range n {
+		transactions = append(transactions, acme.Transaction{
+			ID:        "tx1",
+			CardID:    "card1",
+			Amount:    acme.MustParseMoney("-12.30", "USD"),
+			CreatedAt: time.Date(2025, 3, 14, 9, 26, 53, 0, time.UTC),
+			Merchant:  acme.MerchantDetails{Name: `Fish & "Chips"`},
+		})
+	}
+
+	return transactions
+}
+
+func TestTransactionWriterCSV(t *testing.T) {
+	columns, err := export.ParseTransactionColumns("id, amount,currency,merchantName")
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	tw, err := export.NewTransactionWriter(buf, export.FormatCSV, columns)
+	require.NoError(t, err)
+
+	for _, tx := range testTransactions(2) {
+		require.NoError(t, tw.Write(tx))
+	}
+	require.NoError(t, tw.Close())
+
+	expected := "id,amount,currency,merchantName\n" +
+		"tx1,-12.30,USD,\"Fish & \"\"Chips\"\"\"\n" +
+		"tx1,-12.30,USD,\"Fish & \"\"Chips\"\"\"\n"
+	assert.Equal(t, expected, buf.String())
+
+	t.Run("formulas", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		tw, err := export.NewTransactionWriter(buf, export.FormatCSV, columns)
+		require.NoError(t, err)
+
+		for _, name := range []string{`=HYPERLINK("http://evil.example","Refund")`, "+1 Shop", "-2 Shop", "@SUM(A1)", "Shop-1"} {
+			tx := testTransactions(1)[0]
+			tx.Merchant.Name = name
+			require.NoError(t, tw.Write(tx))
+		}
+		require.NoError(t, tw.Close())
+
+		// the amounts stay numbers and the text cells are not formulas
+		expected := "id,amount,currency,merchantName\n" +
+			"tx1,-12.30,USD,\"'=HYPERLINK(\"\"http://evil.example\"\",\"\"Refund\"\")\"\n" +
+			"tx1,-12.30,USD,'+1 Shop\n" +
+			"tx1,-12.30,USD,'-2 Shop\n" +
+			"tx1,-12.30,USD,'@SUM(A1)\n" +
+			"tx1,-12.30,USD,Shop-1\n"
+		assert.Equal(t, expected, buf.String())
+	})
+
+	_, err = export.ParseTransactionColumns("id,secret")
+	assert.Error(t, err)
+
+	_, err = export.NewTransactionWriter(buf, "pdf", nil)
+	assert.Error(t, err)
+}
+
+func TestTransactionWriterXLSX(t *testing.T) {
+	buf := &bytes.Buffer{}
+	tw, err := export.NewTransactionWriter(buf, export.FormatXLSX, nil)
+	require.NoError(t, err)
+
+	// more than a flush worth of rows
+	for _, tx := range testTransactions(150) {
+		require.NoError(t, tw.Write(tx))
+	}
+	require.NoError(t, tw.Close())
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+
+	parts := make(map[string][]byte)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		parts[f.Name], err = io.ReadAll(rc)
+		require.NoError(t, err)
+		rc.Close()
+	}
+
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels"} {
+		assert.Contains(t, parts, name)
+	}
+
+	var sheet struct {
+		Rows []struct {
+			Cells []struct {
+				Type   string `xml:"t,attr"`
+				Value  string `xml:"v"`
+				Inline string `xml:"is>t"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	err = xml.Unmarshal(parts["xl/worksheets/sheet1.xml"], &sheet)
+	require.NoError(t, err)
+
+	require.Len(t, sheet.Rows, 151)
+	assert.Equal(t, "id", sheet.Rows[0].Cells[0].Inline)
+
+	row := sheet.Rows[1]
+	require.Len(t, row.Cells, len(export.TransactionColumns()))
+	assert.Equal(t, "tx1", row.Cells[0].Inline)
+	// amount is a numeric cell
+	assert.Equal(t, "", row.Cells[6].Type)
+	assert.Equal(t, "-12.30", row.Cells[6].Value)
+	assert.Equal(t, `Fish & "Chips"`, row.Cells[14].Inline)
+}
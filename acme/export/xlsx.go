@@ -0,0 +1,135 @@
+package export
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+// xlsxStaticParts are the workbook parts other than the sheet, a minimal
+// SpreadsheetML package with a single sheet of inline strings.
+var xlsxStaticParts = []struct{ name, content string }{
+	{"[Content_Types].xml", xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`},
+	{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`},
+	{"xl/workbook.xml", xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="Transactions" sheetId="1" r:id="rId1"/></sheets>` +
+		`</workbook>`},
+	{"xl/_rels/workbook.xml.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`</Relationships>`},
+}
+
+// xlsxRowWriter streams the rows into the sheet part of the zip archive,
+// the static parts are written before the first row.
+type xlsxRowWriter struct {
+	zw    *zip.Writer
+	sheet *bufio.Writer
+	rows  int
+	err   error
+}
+
+func newXLSXRowWriter(w io.Writer) *xlsxRowWriter {
+	return &xlsxRowWriter{zw: zip.NewWriter(w)}
+}
+
+func (x *xlsxRowWriter) open() error {
+	if x.sheet != nil || x.err != nil {
+		return x.err
+	}
+
+	for _, part := range xlsxStaticParts {
+		pw, err := x.zw.Create(part.name)
+		if err != nil {
+			x.err = fmt.Errorf("create %s: %w", part.name, err)
+			return x.err
+		}
+
+		_, err = io.WriteString(pw, part.content)
+		if err != nil {
+			x.err = fmt.Errorf("write %s: %w", part.name, err)
+			return x.err
+		}
+	}
+
+	sw, err := x.zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		x.err = fmt.Errorf("create sheet: %w", err)
+		return x.err
+	}
+
+	x.sheet = bufio.NewWriter(sw)
+	_, err = x.sheet.WriteString(xml.Header + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	if err != nil {
+		x.err = fmt.Errorf("write sheet: %w", err)
+	}
+
+	return x.err
+}
+
+func (x *xlsxRowWriter) writeRow(cells []cell) error {
+	err := x.open()
+	if err != nil {
+		return err
+	}
+
+	x.rows++
+	fmt.Fprintf(x.sheet, `<row r="%d">`, x.rows)
+	for _, c := range cells {
+		if c.number {
+			fmt.Fprintf(x.sheet, `<c><v>%s</v></c>`, c.value)
+			continue
+		}
+
+		x.sheet.WriteString(`<c t="inlineStr"><is><t xml:space="preserve">`)
+		err = xml.EscapeText(x.sheet, []byte(c.value))
+		if err != nil {
+			return fmt.Errorf("escape text: %w", err)
+		}
+		x.sheet.WriteString(`</t></is></c>`)
+	}
+	_, err = x.sheet.WriteString(`</row>`)
+
+	// bufio keeps the first write error
+	return err
+}
+
+func (x *xlsxRowWriter) flush() error {
+	if x.sheet == nil {
+		return x.err
+	}
+
+	err := x.sheet.Flush()
+	if err != nil {
+		return fmt.Errorf("flush sheet: %w", err)
+	}
+
+	return x.zw.Flush()
+}
+
+func (x *xlsxRowWriter) close() error {
+	err := x.open()
+	if err != nil {
+		return err
+	}
+
+	_, err = x.sheet.WriteString(`</sheetData></worksheet>`)
+	if err != nil {
+		return fmt.Errorf("write sheet: %w", err)
+	}
+
+	err = x.sheet.Flush()
+	if err != nil {
+		return fmt.Errorf("flush sheet: %w", err)
+	}
+
+	return x.zw.Close()
+}
@@ -0,0 +1,527 @@
+package acme
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidFraudRule is returned for unknown rule types or actions and
+	// missing rule params
+	ErrInvalidFraudRule = errors.New("invalid fraud rule")
+	// ErrFraudRuleNotFound is returned when the rule does not exist
+	ErrFraudRuleNotFound = errors.New("fraud rule not found")
+	// ErrFraudDecisionExists is returned when the rule already fired for
+	// the transaction
+	ErrFraudDecisionExists = errors.New("fraud decision exists")
+	// ErrTransactionFlagNotFound is returned when the transaction has no
+	// open flag
+	ErrTransactionFlagNotFound = errors.New("transaction flag not found")
+)
+
+// Fraud rule types
+const (
+	// FraudRuleVelocity fires when the card has more than MaxCount posted
+	// transactions within Window
+	FraudRuleVelocity = "velocity"
+	// FraudRuleAmountMultiple fires when the amount is above Multiplier
+	// times the average amount of the previous card transactions
+	FraudRuleAmountMultiple = "amount_multiple"
+	// FraudRuleForeignCountry fires when the merchant country is not one of
+	// Countries
+	FraudRuleForeignCountry = "foreign_country"
+	// FraudRuleDeclinedBurst fires when the card has MaxCount declined
+	// attempts within Window
+	FraudRuleDeclinedBurst = "declined_burst"
+	// FraudRuleMCCRisk fires when the MCC code is one of MCCCodes
+	FraudRuleMCCRisk = "mcc_risk"
+)
+
+// Fraud rule actions
+const (
+	FraudActionFreeze = "freeze"
+	// FraudActionFlag flags the transaction for review, see TransactionFlag
+	FraudActionFlag   = "flag"
+	FraudActionNotify = "notify"
+)
+
+// FraudRule is a check of the new transactions and the action taken when
+// it fires
+type FraudRule struct {
+	ID     int64
+	Name   string
+	Type   string
+	Params FraudRuleParams
+	Action string
+	// DryRun logs the decisions without taking the action
+	DryRun    bool
+	Enabled   bool
+	CreatedAt time.Time
+}
+
+// FraudRuleParams are the params of the rule type, unused params are zero
+type FraudRuleParams struct {
+	MaxCount int
+	Window   time.Duration
+	// Multiplier and MinHistory are used by the amount rule, the average is
+	// only trusted after MinHistory transactions
+	Multiplier float64
+	MinHistory int
+	Countries  []string
+	MCCCodes   []string
+}
+
+// FraudDecision explains why a rule fired for a transaction
+type FraudDecision struct {
+	ID            int64
+	RuleID        int64
+	RuleName      string
+	TransactionID string
+	CardID        string
+	Action        string
+	DryRun        bool
+	Reason        string
+	CreatedAt     time.Time
+}
+
+// TransactionFlag marks a transaction for review, it is set by the rules
+// with the flag action and cleared by the reviewer
+type TransactionFlag struct {
+	TransactionID string
+	CardID        string
+	// RuleID is the rule that flagged the transaction first
+	RuleID int64
+	Reason string
+	// ClearedAt is zero while the flag is open
+	ClearedAt time.Time
+	CreatedAt time.Time
+}
+
+type TransactionFlagFilter struct {
+	CardID string
+	// Open omits the cleared flags
+	Open bool
+}
+
+type FraudDecisionFilter struct {
+	CardID        string
+	TransactionID string
+	Action        string
+}
+
+type FraudRepository interface {
+	// SaveFraudRule creates the rule or updates the rule with the ID
+	SaveFraudRule(context.Context, *FraudRule) error
+	GetFraudRule(ctx context.Context, id int64) (*FraudRule, error)
+	FindFraudRules(context.Context) ([]FraudRule, error)
+	DeleteFraudRule(ctx context.Context, id int64) error
+
+	FraudDecisionExists(ctx context.Context, ruleID int64, transactionID string) (bool, error)
+	// SaveFraudDecision fails with ErrFraudDecisionExists when the rule
+	// already fired for the transaction
+	SaveFraudDecision(context.Context, *FraudDecision) error
+	// FindFraudDecisions returns the decisions, latest first
+	FindFraudDecisions(context.Context, FraudDecisionFilter) ([]FraudDecision, error)
+
+	// SaveTransactionFlag flags the transaction and sets the creation time,
+	// flagging a flagged transaction keeps the first flag
+	SaveTransactionFlag(context.Context, *TransactionFlag) error
+	// FindTransactionFlags returns the flags, latest first
+	FindTransactionFlags(context.Context, TransactionFlagFilter) ([]TransactionFlag, error)
+	// ClearTransactionFlag clears the open flag of the transaction,
+	// ErrTransactionFlagNotFound is returned when there is none
+	ClearTransactionFlag(ctx context.Context, transactionID string) error
+
+	// LockFraudEvaluation calls fn while holding the evaluation lock shared
+	// by the server instances, it returns false without calling fn when
+	// another instance holds it
+	LockFraudEvaluation(ctx context.Context, fn func(context.Context) error) (bool, error)
+}
+
+type FraudService interface {
+	CreateFraudRule(context.Context, FraudRule) (*FraudRule, error)
+	UpdateFraudRule(context.Context, FraudRule) (*FraudRule, error)
+	ListFraudRules(context.Context) ([]FraudRule, error)
+	DeleteFraudRule(ctx context.Context, id int64) error
+	ListFraudDecisions(context.Context, FraudDecisionFilter) ([]FraudDecision, error)
+	ListTransactionFlags(context.Context, TransactionFlagFilter) ([]TransactionFlag, error)
+	// ClearTransactionFlag marks the flagged transaction as reviewed
+	ClearTransactionFlag(ctx context.Context, transactionID string) error
+
+	// EvaluateTransactions runs the enabled rules on the new transactions,
+	// it is skipped while another instance evaluates them
+	EvaluateTransactions(context.Context) error
+}
+
+const (
+	// fraudLookback is how far back the new transactions are evaluated
+	fraudLookback = 24 * time.Hour
+	// fraudAverageLookback is the history of the card average amount
+	fraudAverageLookback = 30 * 24 * time.Hour
+	// maxFraudRuleWindow keeps the rule windows within the new transactions
+	maxFraudRuleWindow = fraudLookback
+	// defaultFraudMinHistory is the default MinHistory of the amount rule
+	defaultFraudMinHistory = 5
+)
+
+// TransactionFraudService implements FraudService
+type TransactionFraudService struct {
+	txSource  TransactionSource
+	cardSvc   CardService
+	fraudRepo FraudRepository
+	alerter   Alerter
+}
+
+var _ FraudService = (*TransactionFraudService)(nil)
+
+func NewTransactionFraudService(
+	txSource TransactionSource,
+	cardSvc CardService,
+	fraudRepo FraudRepository,
+	alerter Alerter,
+) *TransactionFraudService {
+	return &TransactionFraudService{
+		txSource:  txSource,
+		cardSvc:   cardSvc,
+		fraudRepo: fraudRepo,
+		alerter:   alerter,
+	}
+}
+
+func (s *TransactionFraudService) CreateFraudRule(ctx context.Context, rule FraudRule) (*FraudRule, error) {
+	rule.ID = 0
+	return s.saveFraudRule(ctx, rule)
+}
+
+func (s *TransactionFraudService) UpdateFraudRule(ctx context.Context, rule FraudRule) (*FraudRule, error) {
+	_, err := s.fraudRepo.GetFraudRule(ctx, rule.ID)
+	if err != nil {
+		return nil, fmt.Errorf("get fraud rule: %w", err)
+	}
+
+	return s.saveFraudRule(ctx, rule)
+}
+
+func (s *TransactionFraudService) saveFraudRule(ctx context.Context, rule FraudRule) (*FraudRule, error) {
+	err := validateFraudRule(&rule)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.fraudRepo.SaveFraudRule(ctx, &rule)
+	if err != nil {
+		return nil, fmt.Errorf("save fraud rule: %w", err)
+	}
+
+	return &rule, nil
+}
+
+func (s *TransactionFraudService) ListFraudRules(ctx context.Context) ([]FraudRule, error) {
+	return s.fraudRepo.FindFraudRules(ctx)
+}
+
+func (s *TransactionFraudService) DeleteFraudRule(ctx context.Context, id int64) error {
+	return s.fraudRepo.DeleteFraudRule(ctx, id)
+}
+
+func (s *TransactionFraudService) ListFraudDecisions(ctx context.Context, filter FraudDecisionFilter) ([]FraudDecision, error) {
+	return s.fraudRepo.FindFraudDecisions(ctx, filter)
+}
+
+func (s *TransactionFraudService) ListTransactionFlags(ctx context.Context, filter TransactionFlagFilter) ([]TransactionFlag, error) {
+	return s.fraudRepo.FindTransactionFlags(ctx, filter)
+}
+
+func (s *TransactionFraudService) ClearTransactionFlag(ctx context.Context, transactionID string) error {
+	return s.fraudRepo.ClearTransactionFlag(ctx, transactionID)
+}
+
+// validateFraudRule checks the params of the rule type and sets the
+// default params
+func validateFraudRule(rule *FraudRule) error {
+	if rule.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidFraudRule)
+	}
+
+	switch rule.Action {
+	case FraudActionFreeze, FraudActionFlag, FraudActionNotify:
+	default:
+		return fmt.Errorf("%w: action %q", ErrInvalidFraudRule, rule.Action)
+	}
+
+	p := &rule.Params
+	switch rule.Type {
+	case FraudRuleVelocity, FraudRuleDeclinedBurst:
+		if p.MaxCount <= 0 {
+			return fmt.Errorf("%w: max count must be positive", ErrInvalidFraudRule)
+		}
+		if p.Window <= 0 {
+			p.Window = time.Hour
+		}
+		if p.Window > maxFraudRuleWindow {
+			return fmt.Errorf("%w: window must not exceed %s", ErrInvalidFraudRule, maxFraudRuleWindow)
+		}
+	case FraudRuleAmountMultiple:
+		if p.Multiplier <= 1 {
+			return fmt.Errorf("%w: multiplier must be greater than 1", ErrInvalidFraudRule)
+		}
+		if p.MinHistory <= 0 {
+			p.MinHistory = defaultFraudMinHistory
+		}
+	case FraudRuleForeignCountry:
+		if len(p.Countries) == 0 {
+			return fmt.Errorf("%w: countries are required", ErrInvalidFraudRule)
+		}
+	case FraudRuleMCCRisk:
+		if len(p.MCCCodes) == 0 {
+			return fmt.Errorf("%w: mcc codes are required", ErrInvalidFraudRule)
+		}
+		for _, code := range p.MCCCodes {
+			if !mccCodeRegexp.MatchString(code) {
+				return fmt.Errorf("%w: mcc code %q", ErrInvalidFraudRule, code)
+			}
+		}
+	default:
+		return fmt.Errorf("%w: type %q", ErrInvalidFraudRule, rule.Type)
+	}
+
+	return nil
+}
+
+func (s *TransactionFraudService) EvaluateTransactions(ctx context.Context) error {
+	// the transactions locked by another instance are evaluated by it
+	_, err := s.fraudRepo.LockFraudEvaluation(ctx, s.evaluateTransactions)
+	return err
+}
+
+func (s *TransactionFraudService) evaluateTransactions(ctx context.Context) error {
+	rules, err := s.fraudRepo.FindFraudRules(ctx)
+	if err != nil {
+		return fmt.Errorf("find fraud rules: %w", err)
+	}
+
+	rules = slices.DeleteFunc(rules, func(r FraudRule) bool { return !r.Enabled })
+	if len(rules) == 0 {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	from := now.Add(-fraudLookback)
+	// declined attempts are kept since the burst rule counts them
+	cardTxs := map[string][]Transaction{}
+	err = s.txSource.EachTransaction(ctx, DateRange{From: from}, func(t Transaction) error {
+		cardTxs[t.CardID] = append(cardTxs[t.CardID], t)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("each transaction: %w", err)
+	}
+
+	needsAverage := slices.ContainsFunc(rules, func(r FraudRule) bool { return r.Type == FraudRuleAmountMultiple })
+
+	var errs []error
+	for cardID, txs := range cardTxs {
+		history := txs
+		if needsAverage {
+			history, err = s.cardHistory(ctx, cardID, now)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+		}
+		slices.SortStableFunc(history, func(a, b Transaction) int {
+			return a.CreatedAt.Compare(b.CreatedAt)
+		})
+
+		for _, t := range txs {
+			if t.CreatedAt.Before(from) {
+				continue
+			}
+
+			for _, rule := range rules {
+				reason, fired := evaluateFraudRule(rule, t, history)
+				if !fired {
+					continue
+				}
+
+				err = s.decide(ctx, rule, t, reason)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("rule %q transaction %q: %w", rule.Name, t.ID, err))
+				}
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// cardHistory returns the card transactions of the average lookback
+func (s *TransactionFraudService) cardHistory(ctx context.Context, cardID string, now time.Time) ([]Transaction, error) {
+	var history []Transaction
+	err := s.txSource.EachCardTransaction(ctx, cardID, DateRange{From: now.Add(-fraudAverageLookback)}, func(t Transaction) error {
+		history = append(history, t)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("each card %q transaction: %w", cardID, err)
+	}
+
+	return history, nil
+}
+
+// decide logs the decision once per rule and transaction and takes the
+// action unless the rule is a dry run. The decision is only saved once the
+// action succeeded so that a failed action is retried on the next run.
+func (s *TransactionFraudService) decide(ctx context.Context, rule FraudRule, t Transaction, reason string) error {
+	exists, err := s.fraudRepo.FraudDecisionExists(ctx, rule.ID, t.ID)
+	if err != nil {
+		return fmt.Errorf("fraud decision exists: %w", err)
+	}
+	if exists {
+		return nil
+	}
+
+	decision := &FraudDecision{
+		RuleID:        rule.ID,
+		RuleName:      rule.Name,
+		TransactionID: t.ID,
+		CardID:        t.CardID,
+		Action:        rule.Action,
+		DryRun:        rule.DryRun,
+		Reason:        reason,
+		CreatedAt:     time.Now().UTC(),
+	}
+
+	if !rule.DryRun {
+		switch rule.Action {
+		case FraudActionFreeze:
+			err = s.freezeCard(ctx, t.CardID)
+		case FraudActionFlag:
+			err = s.fraudRepo.SaveTransactionFlag(ctx, &TransactionFlag{
+				TransactionID: t.ID,
+				CardID:        t.CardID,
+				RuleID:        rule.ID,
+				Reason:        reason,
+			})
+			if err != nil {
+				err = fmt.Errorf("save transaction flag: %w", err)
+			}
+		case FraudActionNotify:
+			err = s.alerter.AlertFraudDecision(ctx, *decision)
+			if err != nil {
+				err = fmt.Errorf("alert fraud decision: %w", err)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	err = s.fraudRepo.SaveFraudDecision(ctx, decision)
+	if err != nil && !errors.Is(err, ErrFraudDecisionExists) {
+		return fmt.Errorf("save fraud decision: %w", err)
+	}
+
+	return nil
+}
+
+func (s *TransactionFraudService) freezeCard(ctx context.Context, cardID string) error {
+	card, err := s.cardSvc.GetCard(ctx, cardID)
+	if err != nil {
+		return fmt.Errorf("get card: %w", err)
+	}
+	if card.Status != CardStatusActive {
+		return nil
+	}
+
+	err = s.cardSvc.UpdateCardStatus(ctx, cardID, CardStatusFrozen)
+	if err != nil {
+		return fmt.Errorf("freeze card: %w", err)
+	}
+
+	return nil
+}
+
+// evaluateFraudRule returns why the rule fired for the transaction, history
+// is the card transactions sorted by date.
+func evaluateFraudRule(rule FraudRule, t Transaction, history []Transaction) (string, bool) {
+	p := rule.Params
+	switch rule.Type {
+	case FraudRuleVelocity:
+		if !t.IsPosted() {
+			return "", false
+		}
+
+		n := countWithin(history, t, p.Window, Transaction.IsPosted)
+		if n > p.MaxCount {
+			return fmt.Sprintf("%d transactions within %s, more than %d", n, p.Window, p.MaxCount), true
+		}
+	case FraudRuleDeclinedBurst:
+		if t.IsPosted() {
+			return "", false
+		}
+
+		n := countWithin(history, t, p.Window, func(h Transaction) bool { return !h.IsPosted() })
+		if n >= p.MaxCount {
+			return fmt.Sprintf("%d declined attempts within %s, at least %d", n, p.Window, p.MaxCount), true
+		}
+	case FraudRuleAmountMultiple:
+		if !t.IsPosted() || t.IsCredit() {
+			return "", false
+		}
+
+		var count, total int64
+		for _, h := range history {
+			if !h.CreatedAt.Before(t.CreatedAt) || !h.IsPosted() || h.IsCredit() || h.Amount.Currency() != t.Amount.Currency() {
+				continue
+			}
+			count++
+			total += h.Amount.Abs().Minor()
+		}
+		if count < int64(p.MinHistory) || total == 0 {
+			return "", false
+		}
+
+		average := NewMoney(total/count, t.Amount.Currency())
+		if float64(t.Amount.Abs().Minor()) > float64(average.Minor())*p.Multiplier {
+			return fmt.Sprintf("amount %s is above %g times the average of %s", t.Amount.Abs(), p.Multiplier, average), true
+		}
+	case FraudRuleForeignCountry:
+		country := t.Merchant.Country
+		if !t.IsPosted() || country == "" {
+			return "", false
+		}
+
+		if !slices.ContainsFunc(p.Countries, func(c string) bool { return strings.EqualFold(c, country) }) {
+			return fmt.Sprintf("merchant country %s is foreign", country), true
+		}
+	case FraudRuleMCCRisk:
+		if slices.Contains(p.MCCCodes, t.Merchant.MCCCode) {
+			return fmt.Sprintf("mcc %s is on the risk list", t.Merchant.MCCCode), true
+		}
+	}
+
+	return "", false
+}
+
+// countWithin counts the history matching fn within the window ending at
+// the transaction, the transaction included
+func countWithin(history []Transaction, t Transaction, window time.Duration, fn func(Transaction) bool) int {
+	start := t.CreatedAt.Add(-window)
+
+	var n int
+	for _, h := range history {
+		if !h.CreatedAt.After(start) || h.CreatedAt.After(t.CreatedAt) {
+			continue
+		}
+		if fn(h) {
+			n++
+		}
+	}
+
+	return n
+}
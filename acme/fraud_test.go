@@ -0,0 +1,223 @@
+package acme_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/acme/memory"
+)
+
+// failingFraudRepository fails the flags when flagErr is set
+type failingFraudRepository struct {
+	*memory.FraudRepository
+
+	flagErr error
+}
+
+func (r *failingFraudRepository) SaveTransactionFlag(ctx context.Context, flag *acme.TransactionFlag) error {
+	if r.flagErr != nil {
+		return r.flagErr
+	}
+	return r.FraudRepository.SaveTransactionFlag(ctx, flag)
+}
+
+func fraudDecisions(t *testing.T, fraudRepo acme.FraudRepository) []acme.FraudDecision {
+	t.Helper()
+
+	decisions, err := fraudRepo.FindFraudDecisions(context.Background(), acme.FraudDecisionFilter{})
+	require.NoError(t, err)
+	return decisions
+}
+
+func TestFraudService(t *testing.T) {
+	ctx := context.Background()
+
+	now := time.Now().UTC()
+	newTx := func(id, status, amount, country, mcc string, ago time.Duration) acme.Transaction {
+		return acme.Transaction{
+			ID:        id,
+			CardID:    "card1",
+			Status:    status,
+			Category:  "purchase",
+			Amount:    acme.MustParseMoney(amount, "USD"),
+			Merchant:  acme.MerchantDetails{Country: country, MCCCode: mcc},
+			CreatedAt: now.Add(-ago),
+		}
+	}
+
+	txSource := stubTransactionSource{
+		newTx("tx1", "cleared", "10.00", "US", "5812", 5*time.Hour),
+		newTx("tx2", "cleared", "10.00", "US", "5812", 4*time.Hour),
+		newTx("tx3", "cleared", "10.00", "US", "5812", 3*time.Hour),
+		newTx("tx4", "cleared", "100.00", "JP", "5812", 50*time.Minute),
+		newTx("tx5", "cleared", "10.00", "US", "7995", 40*time.Minute),
+		newTx("tx6", "declined", "10.00", "US", "5812", 30*time.Minute),
+		newTx("tx7", "declined", "10.00", "US", "5812", 29*time.Minute),
+	}
+
+	cardSvc := newCardService(acme.Card{ID: "card1", Status: acme.CardStatusActive})
+	fraudRepo := &failingFraudRepository{FraudRepository: memory.NewFraudRepository()}
+	alerter := &recordingAlerter{}
+	fraudSvc := acme.NewTransactionFraudService(txSource, cardSvc, fraudRepo, alerter)
+
+	t.Run("invalid rules", func(t *testing.T) {
+		for _, rule := range []acme.FraudRule{
+			{Name: "r", Type: "unknown", Action: acme.FraudActionFlag},
+			{Name: "r", Type: acme.FraudRuleVelocity, Action: "block", Params: acme.FraudRuleParams{MaxCount: 1}},
+			{Name: "r", Type: acme.FraudRuleVelocity, Action: acme.FraudActionFlag},
+			{Name: "r", Type: acme.FraudRuleVelocity, Action: acme.FraudActionFlag, Params: acme.FraudRuleParams{MaxCount: 1, Window: 48 * time.Hour}},
+			{Name: "r", Type: acme.FraudRuleAmountMultiple, Action: acme.FraudActionFlag, Params: acme.FraudRuleParams{Multiplier: 1}},
+			{Name: "r", Type: acme.FraudRuleMCCRisk, Action: acme.FraudActionFlag, Params: acme.FraudRuleParams{MCCCodes: []string{"casino"}}},
+		} {
+			_, err := fraudSvc.CreateFraudRule(ctx, rule)
+			assert.ErrorIs(t, err, acme.ErrInvalidFraudRule)
+		}
+	})
+
+	rules := []acme.FraudRule{
+		{Name: "velocity", Type: acme.FraudRuleVelocity, Action: acme.FraudActionFlag, Enabled: true, Params: acme.FraudRuleParams{MaxCount: 1}},
+		{Name: "large amount", Type: acme.FraudRuleAmountMultiple, Action: acme.FraudActionNotify, Enabled: true, Params: acme.FraudRuleParams{Multiplier: 3, MinHistory: 3}},
+		{Name: "foreign", Type: acme.FraudRuleForeignCountry, Action: acme.FraudActionFreeze, Enabled: true, DryRun: true, Params: acme.FraudRuleParams{Countries: []string{"us"}}},
+		{Name: "declined burst", Type: acme.FraudRuleDeclinedBurst, Action: acme.FraudActionFreeze, Enabled: true, Params: acme.FraudRuleParams{MaxCount: 2, Window: 10 * time.Minute}},
+		{Name: "gambling", Type: acme.FraudRuleMCCRisk, Action: acme.FraudActionFlag, Params: acme.FraudRuleParams{MCCCodes: []string{"7995"}}},
+	}
+	for _, rule := range rules {
+		saved, err := fraudSvc.CreateFraudRule(ctx, rule)
+		require.NoError(t, err)
+		assert.NotZero(t, saved.ID)
+	}
+
+	require.NoError(t, fraudSvc.EvaluateTransactions(ctx))
+
+	fired := map[string][]string{}
+	for _, d := range fraudDecisions(t, fraudRepo) {
+		fired[d.RuleName] = append(fired[d.RuleName], d.TransactionID)
+	}
+	assert.Equal(t, map[string][]string{
+		"velocity":       {"tx5"},
+		"large amount":   {"tx4"},
+		"foreign":        {"tx4"},
+		"declined burst": {"tx7"},
+		// disabled rules do not run
+	}, fired)
+
+	// the foreign rule is a dry run, the declined burst froze the card
+	assert.Equal(t, acme.CardStatusFrozen, cardStatus(t, cardSvc, "card1"))
+	require.Len(t, alerter.decisions, 1)
+	assert.Equal(t, "amount 100.00 USD is above 3 times the average of 10.00 USD", alerter.decisions[0].Reason)
+
+	// the velocity rule flagged the transaction for review
+	flags, err := fraudSvc.ListTransactionFlags(ctx, acme.TransactionFlagFilter{Open: true})
+	require.NoError(t, err)
+	require.Len(t, flags, 1)
+	assert.Equal(t, "tx5", flags[0].TransactionID)
+	require.NoError(t, fraudSvc.ClearTransactionFlag(ctx, "tx5"))
+	assert.ErrorIs(t, fraudSvc.ClearTransactionFlag(ctx, "tx5"), acme.ErrTransactionFlagNotFound)
+
+	// decisions are logged once
+	require.NoError(t, fraudSvc.EvaluateTransactions(ctx))
+	assert.Len(t, fraudDecisions(t, fraudRepo), 4)
+	flags, err = fraudSvc.ListTransactionFlags(ctx, acme.TransactionFlagFilter{Open: true})
+	require.NoError(t, err)
+	assert.Empty(t, flags)
+}
+
+func TestFraudServiceActionFailure(t *testing.T) {
+	ctx := context.Background()
+
+	txSource := stubTransactionSource{{
+		ID:        "tx1",
+		CardID:    "card1",
+		Status:    "cleared",
+		Category:  "purchase",
+		Amount:    acme.MustParseMoney("10.00", "USD"),
+		Merchant:  acme.MerchantDetails{MCCCode: "7995"},
+		CreatedAt: time.Now().UTC(),
+	}}
+
+	cardSvc := newCardService(acme.Card{ID: "card1", Status: acme.CardStatusActive})
+	fraudRepo := &failingFraudRepository{FraudRepository: memory.NewFraudRepository()}
+	alerter := &recordingAlerter{}
+	fraudSvc := acme.NewTransactionFraudService(txSource, cardSvc, fraudRepo, alerter)
+
+	for _, action := range []string{acme.FraudActionFreeze, acme.FraudActionNotify} {
+		_, err := fraudSvc.CreateFraudRule(ctx, acme.FraudRule{
+			Name: action, Type: acme.FraudRuleMCCRisk, Action: action, Enabled: true,
+			Params: acme.FraudRuleParams{MCCCodes: []string{"7995"}},
+		})
+		require.NoError(t, err)
+	}
+
+	// the decisions of the failed actions are not logged
+	cardSvc.statusErr = errors.New("reap is down")
+	alerter.err = errors.New("smtp is down")
+	err := fraudSvc.EvaluateTransactions(ctx)
+	assert.ErrorContains(t, err, "reap is down")
+	assert.ErrorContains(t, err, "smtp is down")
+	assert.Empty(t, fraudDecisions(t, fraudRepo))
+
+	// and the actions are retried on the next run
+	cardSvc.statusErr, alerter.err = nil, nil
+	require.NoError(t, fraudSvc.EvaluateTransactions(ctx))
+	assert.Len(t, fraudDecisions(t, fraudRepo), 2)
+	assert.Equal(t, acme.CardStatusFrozen, cardStatus(t, cardSvc, "card1"))
+	assert.Len(t, alerter.decisions, 1)
+}
+
+func TestFraudServiceFlagFailure(t *testing.T) {
+	ctx := context.Background()
+
+	txSource := stubTransactionSource{{
+		ID:        "tx1",
+		CardID:    "card1",
+		Status:    "cleared",
+		Category:  "purchase",
+		Amount:    acme.MustParseMoney("10.00", "USD"),
+		Merchant:  acme.MerchantDetails{MCCCode: "7995"},
+		CreatedAt: time.Now().UTC(),
+	}}
+
+	cardSvc := newCardService(acme.Card{ID: "card1", Status: acme.CardStatusActive})
+	fraudRepo := &failingFraudRepository{FraudRepository: memory.NewFraudRepository()}
+	fraudSvc := acme.NewTransactionFraudService(txSource, cardSvc, fraudRepo, &recordingAlerter{})
+
+	_, err := fraudSvc.CreateFraudRule(ctx, acme.FraudRule{
+		Name: "gambling", Type: acme.FraudRuleMCCRisk, Action: acme.FraudActionFlag, Enabled: true,
+		Params: acme.FraudRuleParams{MCCCodes: []string{"7995"}},
+	})
+	require.NoError(t, err)
+
+	// the transaction is flagged on the next run
+	fraudRepo.flagErr = errors.New("database is down")
+	err = fraudSvc.EvaluateTransactions(ctx)
+	assert.ErrorContains(t, err, "database is down")
+	assert.Empty(t, fraudDecisions(t, fraudRepo))
+
+	fraudRepo.flagErr = nil
+	require.NoError(t, fraudSvc.EvaluateTransactions(ctx))
+	assert.Len(t, fraudDecisions(t, fraudRepo), 1)
+
+	flags, err := fraudSvc.ListTransactionFlags(ctx, acme.TransactionFlagFilter{Open: true})
+	require.NoError(t, err)
+	require.Len(t, flags, 1)
+	assert.Equal(t, "tx1", flags[0].TransactionID)
+
+	// the rules are not evaluated while another instance evaluates them
+	_, err = fraudSvc.CreateFraudRule(ctx, acme.FraudRule{
+		Name: "freeze gambling", Type: acme.FraudRuleMCCRisk, Action: acme.FraudActionFreeze, Enabled: true,
+		Params: acme.FraudRuleParams{MCCCodes: []string{"7995"}},
+	})
+	require.NoError(t, err)
+
+	locked, err := fraudRepo.LockFraudEvaluation(ctx, fraudSvc.EvaluateTransactions)
+	require.NoError(t, err)
+	assert.True(t, locked)
+	assert.Len(t, fraudDecisions(t, fraudRepo), 1)
+	assert.Equal(t, acme.CardStatusActive, cardStatus(t, cardSvc, "card1"))
+}
@@ -0,0 +1,310 @@
+package acme
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrJournalAccountMissing is returned when a transaction has no account to book to
+	ErrJournalAccountMissing = errors.New("journal account missing")
+	// ErrJournalExportNotFound is returned when the journal export does not exist
+	ErrJournalExportNotFound = errors.New("journal export not found")
+	// ErrTransactionAlreadyBooked is returned when saving lines of a transaction already in the journal
+	ErrTransactionAlreadyBooked = errors.New("transaction already booked")
+	// ErrInvalidJournalExportParams is returned for an empty or unbounded export period
+	ErrInvalidJournalExportParams = errors.New("invalid journal export params")
+)
+
+// Journal account roles, expense accounts per MCC category use
+// JournalExpenseAccountRole
+const (
+	// JournalAccountClearing is the card clearing account credited by spend
+	JournalAccountClearing = "clearing"
+	// JournalAccountExpense is the expense account of unmapped MCC categories
+	JournalAccountExpense = "expense"
+	JournalAccountFXFee   = "fx_fee"
+	JournalAccountATMFee  = "atm_fee"
+)
+
+// JournalExpenseAccountRole returns the role of the expense account of the
+// MCC category.
+func JournalExpenseAccountRole(mccCategory string) string {
+	return JournalAccountExpense + ":" + mccCategory
+}
+
+// JournalAccount maps a role to a general ledger account code
+type JournalAccount struct {
+	Role    string
+	Account string
+}
+
+// JournalLine is one side of a double entry booking, either Debit or
+// Credit is zero.
+type JournalLine struct {
+	TransactionID string
+	// LineNo orders the lines of a transaction starting at 1
+	LineNo      int
+	Date        time.Time
+	Account     string
+	Description string
+	Debit       Money
+	Credit      Money
+}
+
+// JournalExport is a batch of journal lines booked once, Until is the
+// watermark of the next export.
+type JournalExport struct {
+	ID        int64
+	From      time.Time
+	Until     time.Time
+	CreatedAt time.Time
+	Lines     []JournalLine
+}
+
+type JournalRepository interface {
+	// SaveJournalAccount creates or replaces the account of the role
+	SaveJournalAccount(context.Context, JournalAccount) error
+	FindJournalAccounts(context.Context) ([]JournalAccount, error)
+	DeleteJournalAccount(ctx context.Context, role string) error
+
+	// FindBookedTransactionIDs returns the given transaction IDs that
+	// already have journal lines
+	FindBookedTransactionIDs(ctx context.Context, transactionIDs ...string) ([]string, error)
+	// SaveJournalExport saves the export and its lines and sets the ID and
+	// creation time, it fails with ErrTransactionAlreadyBooked when a line
+	// is already saved.
+	SaveJournalExport(context.Context, *JournalExport) error
+	GetJournalExport(ctx context.Context, id int64) (*JournalExport, error)
+	// FindJournalExports returns the exports without lines, latest first
+	FindJournalExports(context.Context) ([]JournalExport, error)
+}
+
+type JournalService interface {
+	SetJournalAccount(context.Context, JournalAccount) error
+	ListJournalAccounts(context.Context) ([]JournalAccount, error)
+	DeleteJournalAccount(ctx context.Context, role string) error
+
+	// CreateJournalExport books the settled transactions not yet exported
+	CreateJournalExport(context.Context, CreateJournalExportParams) (*JournalExport, error)
+	GetJournalExport(ctx context.Context, id int64) (*JournalExport, error)
+	ListJournalExports(context.Context) ([]JournalExport, error)
+}
+
+type CreateJournalExportParams struct {
+	// From defaults to the watermark of the previous export less the
+	// settlement lookback, it is required for the first export
+	From time.Time
+	// Until is exclusive and defaults to the start of the current day
+	Until time.Time
+}
+
+// journalSettlementLookback rescans the days before the watermark for
+// transactions that settled after the previous export
+const journalSettlementLookback = 7 * 24 * time.Hour
+
+// TransactionJournalService implements JournalService
+type TransactionJournalService struct {
+	txSource    TransactionSource
+	journalRepo JournalRepository
+}
+
+var _ JournalService = (*TransactionJournalService)(nil)
+
+func NewTransactionJournalService(txSource TransactionSource, journalRepo JournalRepository) *TransactionJournalService {
+	return &TransactionJournalService{txSource: txSource, journalRepo: journalRepo}
+}
+
+func (s *TransactionJournalService) SetJournalAccount(ctx context.Context, account JournalAccount) error {
+	if account.Role == "" || account.Account == "" {
+		return fmt.Errorf("%w: role and account are required", ErrInvalidJournalExportParams)
+	}
+
+	return s.journalRepo.SaveJournalAccount(ctx, account)
+}
+
+func (s *TransactionJournalService) ListJournalAccounts(ctx context.Context) ([]JournalAccount, error) {
+	return s.journalRepo.FindJournalAccounts(ctx)
+}
+
+func (s *TransactionJournalService) DeleteJournalAccount(ctx context.Context, role string) error {
+	return s.journalRepo.DeleteJournalAccount(ctx, role)
+}
+
+func (s *TransactionJournalService) GetJournalExport(ctx context.Context, id int64) (*JournalExport, error) {
+	return s.journalRepo.GetJournalExport(ctx, id)
+}
+
+func (s *TransactionJournalService) ListJournalExports(ctx context.Context) ([]JournalExport, error) {
+	return s.journalRepo.FindJournalExports(ctx)
+}
+
+func (s *TransactionJournalService) CreateJournalExport(ctx context.Context, params CreateJournalExportParams) (*JournalExport, error) {
+	from, until, err := s.exportPeriod(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	accounts, err := s.journalRepo.FindJournalAccounts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("find journal accounts: %w", err)
+	}
+
+	accountsByRole := make(map[string]string, len(accounts))
+	for _, a := range accounts {
+		accountsByRole[a.Role] = a.Account
+	}
+
+	// the last day is inclusive in reap
+	var transactions []Transaction
+	err = s.txSource.EachTransaction(ctx, DateRange{From: from, To: until.Add(-time.Nanosecond)}, func(t Transaction) error {
+		if t.IsSettled() && !t.CreatedAt.Before(from) && t.CreatedAt.Before(until) {
+			transactions = append(transactions, t)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("each transaction: %w", err)
+	}
+
+	ids := make([]string, 0, len(transactions))
+	for _, t := range transactions {
+		ids = append(ids, t.ID)
+	}
+
+	booked, err := s.journalRepo.FindBookedTransactionIDs(ctx, ids...)
+	if err != nil {
+		return nil, fmt.Errorf("find booked transaction ids: %w", err)
+	}
+
+	export := &JournalExport{From: from, Until: until}
+	for _, t := range transactions {
+		if slices.Contains(booked, t.ID) {
+			continue
+		}
+
+		lines, err := journalLines(t, accountsByRole)
+		if err != nil {
+			return nil, err
+		}
+		export.Lines = append(export.Lines, lines...)
+	}
+
+	slices.SortStableFunc(export.Lines, func(a, b JournalLine) int {
+		return a.Date.Compare(b.Date)
+	})
+
+	err = s.journalRepo.SaveJournalExport(ctx, export)
+	if err != nil {
+		return nil, fmt.Errorf("save journal export: %w", err)
+	}
+
+	return export, nil
+}
+
+// exportPeriod returns the export period in UTC, it starts before the
+// watermark of the previous export to catch late settlements.
+func (s *TransactionJournalService) exportPeriod(ctx context.Context, params CreateJournalExportParams) (from, until time.Time, err error) {
+	until = params.Until.UTC()
+	if until.IsZero() {
+		now := time.Now().UTC()
+		until = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	}
+
+	from = params.From.UTC()
+	if from.IsZero() {
+		exports, err := s.journalRepo.FindJournalExports(ctx)
+		if err != nil {
+			return from, until, fmt.Errorf("find journal exports: %w", err)
+		}
+		if len(exports) == 0 {
+			return from, until, fmt.Errorf("%w: from is required for the first export", ErrInvalidJournalExportParams)
+		}
+
+		from = exports[0].Until.UTC().Add(-journalSettlementLookback)
+	}
+
+	if !from.Before(until) {
+		return from, until, fmt.Errorf("%w: from must be before until", ErrInvalidJournalExportParams)
+	}
+
+	return from, until, nil
+}
+
+// journalLines books the transaction against the card clearing account,
+// purchases debit the expense account of the MCC category and refunds
+// credit it back. Fees are booked on their own lines.
+func journalLines(t Transaction, accounts map[string]string) ([]JournalLine, error) {
+	clearing, err := journalAccount(accounts, JournalAccountClearing)
+	if err != nil {
+		return nil, err
+	}
+
+	expense, ok := accounts[JournalExpenseAccountRole(t.Merchant.MCCCategory)]
+	if !ok {
+		expense, err = journalAccount(accounts, JournalAccountExpense)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	description := strings.TrimSpace(t.Merchant.Name + " " + t.Merchant.MCCCategory)
+	if description == "" {
+		description = t.Category
+	}
+
+	var lines []JournalLine
+	book := func(debitAccount, creditAccount, description string, amount Money) {
+		zero := NewMoney(0, amount.Currency())
+		lines = append(lines,
+			JournalLine{Account: debitAccount, Description: description, Debit: amount, Credit: zero},
+			JournalLine{Account: creditAccount, Description: description, Debit: zero, Credit: amount},
+		)
+	}
+
+	if t.IsCredit() {
+		book(clearing, expense, "Refund "+description, t.Amount.Abs())
+	} else {
+		book(expense, clearing, description, t.Amount.Abs())
+	}
+
+	fees := []struct {
+		role, name string
+		amount     Money
+	}{
+		{JournalAccountFXFee, "FX fee", t.Fees.FXFees},
+		{JournalAccountATMFee, "ATM fee", t.Fees.ATMFees},
+	}
+	for _, fee := range fees {
+		if fee.amount.IsZero() {
+			continue
+		}
+
+		account, err := journalAccount(accounts, fee.role)
+		if err != nil {
+			return nil, err
+		}
+		book(account, clearing, fee.name+" "+description, fee.amount.Abs())
+	}
+
+	for i := range lines {
+		lines[i].TransactionID = t.ID
+		lines[i].LineNo = i + 1
+		lines[i].Date = t.CreatedAt.UTC()
+	}
+
+	return lines, nil
+}
+
+func journalAccount(accounts map[string]string, role string) (string, error) {
+	account, ok := accounts[role]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrJournalAccountMissing, role)
+	}
+
+	return account, nil
+}
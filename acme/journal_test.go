@@ -0,0 +1,104 @@
+package acme_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/acme/memory"
+)
+
+func TestJournalService(t *testing.T) {
+	ctx := context.Background()
+
+	day := time.Date(2025, 3, 12, 10, 0, 0, 0, time.UTC)
+	txSource := stubTransactionSource{
+		{
+			ID:       "tx1",
+			Status:   "cleared",
+			Category: "purchase",
+			Amount:   acme.MustParseMoney("12.50", "USD"),
+			Fees: acme.FeeDetails{
+				FXFees:  acme.MustParseMoney("0.25", "USD"),
+				ATMFees: acme.NewMoney(0, "USD"),
+			},
+			Merchant:  acme.MerchantDetails{Name: "Sushi", MCCCategory: "Restaurants"},
+			CreatedAt: day,
+		},
+		{
+			ID:        "tx2",
+			Status:    "cleared",
+			Category:  "refund",
+			Amount:    acme.MustParseMoney("3.00", "USD"),
+			Merchant:  acme.MerchantDetails{Name: "Air", MCCCategory: "Airlines"},
+			CreatedAt: day.Add(time.Hour),
+		},
+		{
+			ID:        "tx3",
+			Status:    "pending",
+			Amount:    acme.MustParseMoney("99.00", "USD"),
+			CreatedAt: day,
+		},
+	}
+
+	journalSvc := acme.NewTransactionJournalService(txSource, memory.NewJournalRepository())
+
+	from := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("first export requires from", func(t *testing.T) {
+		_, err := journalSvc.CreateJournalExport(ctx, acme.CreateJournalExportParams{Until: until})
+		assert.ErrorIs(t, err, acme.ErrInvalidJournalExportParams)
+	})
+
+	t.Run("missing account", func(t *testing.T) {
+		_, err := journalSvc.CreateJournalExport(ctx, acme.CreateJournalExportParams{From: from, Until: until})
+		assert.ErrorIs(t, err, acme.ErrJournalAccountMissing)
+	})
+
+	for _, a := range []acme.JournalAccount{
+		{Role: acme.JournalAccountClearing, Account: "2100"},
+		{Role: acme.JournalAccountExpense, Account: "6000"},
+		{Role: acme.JournalExpenseAccountRole("Restaurants"), Account: "6100"},
+		{Role: acme.JournalAccountFXFee, Account: "6900"},
+	} {
+		require.NoError(t, journalSvc.SetJournalAccount(ctx, a))
+	}
+
+	t.Run("books settled transactions", func(t *testing.T) {
+		export, err := journalSvc.CreateJournalExport(ctx, acme.CreateJournalExportParams{From: from, Until: until})
+		require.NoError(t, err)
+		require.Len(t, export.Lines, 6)
+
+		type line struct {
+			tx      string
+			account string
+			debit   string
+			credit  string
+		}
+		var got []line
+		for _, l := range export.Lines {
+			got = append(got, line{l.TransactionID, l.Account, l.Debit.Decimal(), l.Credit.Decimal()})
+		}
+		assert.Equal(t, []line{
+			{"tx1", "6100", "12.50", "0.00"},
+			{"tx1", "2100", "0.00", "12.50"},
+			{"tx1", "6900", "0.25", "0.00"},
+			{"tx1", "2100", "0.00", "0.25"},
+			{"tx2", "2100", "3.00", "0.00"},
+			{"tx2", "6000", "0.00", "3.00"},
+		}, got)
+	})
+
+	t.Run("skips booked transactions", func(t *testing.T) {
+		export, err := journalSvc.CreateJournalExport(ctx, acme.CreateJournalExportParams{})
+		require.NoError(t, err)
+		assert.Empty(t, export.Lines)
+		// continues from the watermark less the settlement lookback
+		assert.Equal(t, until.AddDate(0, 0, -7), export.From)
+	})
+}
@@ -0,0 +1,205 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+)
+
+// AllowanceRepository is a thread-safe in-memory acme.AllowanceRepository
+type AllowanceRepository struct {
+	mu             sync.RWMutex
+	lastScheduleID int64
+	lastRunID      int64
+	// schedules in insertion order
+	schedules []acme.AllowanceSchedule
+	// runs in insertion order
+	runs []acme.AllowanceRun
+	// locks are the schedule locks, they are never deleted
+	locks map[int64]*sync.Mutex
+}
+
+var _ acme.AllowanceRepository = (*AllowanceRepository)(nil)
+
+func NewAllowanceRepository() *AllowanceRepository {
+	return &AllowanceRepository{locks: make(map[int64]*sync.Mutex)}
+}
+
+// SaveAllowanceSchedule implements acme.AllowanceRepository.
+func (r *AllowanceRepository) SaveAllowanceSchedule(_ context.Context, s *acme.AllowanceSchedule) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lastScheduleID++
+	s.ID = r.lastScheduleID
+	s.CreatedAt = time.Now().UTC()
+	r.schedules = append(r.schedules, *s)
+
+	return nil
+}
+
+// GetAllowanceSchedule implements acme.AllowanceRepository.
+func (r *AllowanceRepository) GetAllowanceSchedule(_ context.Context, id int64) (*acme.AllowanceSchedule, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	i := r.scheduleIndex(id)
+	if i < 0 {
+		return nil, acme.ErrAllowanceScheduleNotFound
+	}
+
+	s := r.schedules[i]
+	return &s, nil
+}
+
+// FindAllowanceSchedules implements acme.AllowanceRepository.
+func (r *AllowanceRepository) FindAllowanceSchedules(_ context.Context, cardID string) ([]acme.AllowanceSchedule, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	schedules := make([]acme.AllowanceSchedule, 0)
+	for _, s := range r.schedules {
+		if cardID == "" || s.CardID == cardID {
+			schedules = append(schedules, s)
+		}
+	}
+
+	return schedules, nil
+}
+
+// FindDueAllowanceSchedules implements acme.AllowanceRepository.
+func (r *AllowanceRepository) FindDueAllowanceSchedules(_ context.Context, now time.Time) ([]acme.AllowanceSchedule, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	due := make([]acme.AllowanceSchedule, 0)
+	for _, s := range r.schedules {
+		if !s.NextRunAt.IsZero() && !s.NextRunAt.After(now) {
+			due = append(due, s)
+		}
+	}
+
+	slices.SortStableFunc(due, func(a, b acme.AllowanceSchedule) int {
+		return a.NextRunAt.Compare(b.NextRunAt)
+	})
+
+	return due, nil
+}
+
+// UpdateAllowanceScheduleNextRun implements acme.AllowanceRepository.
+func (r *AllowanceRepository) UpdateAllowanceScheduleNextRun(_ context.Context, id int64, nextRunAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.scheduleIndex(id)
+	if i >= 0 {
+		r.schedules[i].NextRunAt = nextRunAt
+	}
+
+	return nil
+}
+
+// DeleteAllowanceSchedule implements acme.AllowanceRepository.
+func (r *AllowanceRepository) DeleteAllowanceSchedule(_ context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.scheduleIndex(id)
+	if i < 0 {
+		return acme.ErrAllowanceScheduleNotFound
+	}
+	r.schedules = slices.Delete(r.schedules, i, i+1)
+
+	return nil
+}
+
+// LockAllowanceSchedule implements acme.AllowanceRepository.
+func (r *AllowanceRepository) LockAllowanceSchedule(ctx context.Context, id int64, fn func(context.Context) error) (bool, error) {
+	r.mu.Lock()
+	lock, ok := r.locks[id]
+	if !ok {
+		lock = &sync.Mutex{}
+		r.locks[id] = lock
+	}
+	r.mu.Unlock()
+
+	if !lock.TryLock() {
+		return false, nil
+	}
+	defer lock.Unlock()
+
+	return true, fn(ctx)
+}
+
+// SaveAllowanceRun implements acme.AllowanceRepository.
+func (r *AllowanceRepository) SaveAllowanceRun(_ context.Context, run *acme.AllowanceRun) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := slices.IndexFunc(r.runs, func(o acme.AllowanceRun) bool {
+		return o.ScheduleID == run.ScheduleID && o.ScheduledAt.Equal(run.ScheduledAt)
+	})
+	if i < 0 {
+		r.lastRunID++
+		run.ID = r.lastRunID
+		run.Attempts = 1
+		r.runs = append(r.runs, *run)
+		return nil
+	}
+
+	// the run keeps the creation time of its first attempt
+	run.ID = r.runs[i].ID
+	run.Attempts = r.runs[i].Attempts + 1
+	run.CreatedAt = r.runs[i].CreatedAt
+	r.runs[i] = *run
+
+	return nil
+}
+
+// UpdateAllowanceRun implements acme.AllowanceRepository.
+func (r *AllowanceRepository) UpdateAllowanceRun(_ context.Context, run acme.AllowanceRun) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := slices.IndexFunc(r.runs, func(o acme.AllowanceRun) bool { return o.ID == run.ID })
+	if i >= 0 {
+		r.runs[i].Status = run.Status
+		r.runs[i].AvailableCreditAfter = run.AvailableCreditAfter
+		r.runs[i].AdjustmentID = run.AdjustmentID
+		r.runs[i].Error = run.Error
+	}
+
+	return nil
+}
+
+// FindAllowanceRuns implements acme.AllowanceRepository.
+func (r *AllowanceRepository) FindAllowanceRuns(_ context.Context, filter acme.AllowanceRunFilter) ([]acme.AllowanceRun, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	runs := make([]acme.AllowanceRun, 0)
+	for _, run := range r.runs {
+		if (filter.ScheduleID == 0 || run.ScheduleID == filter.ScheduleID) &&
+			(filter.CardID == "" || run.CardID == filter.CardID) &&
+			(filter.ScheduledAt.IsZero() || run.ScheduledAt.Equal(filter.ScheduledAt)) {
+			runs = append(runs, run)
+		}
+	}
+
+	slices.SortFunc(runs, func(a, b acme.AllowanceRun) int {
+		if c := b.ScheduledAt.Compare(a.ScheduledAt); c != 0 {
+			return c
+		}
+		return cmp.Compare(b.ID, a.ID)
+	})
+
+	return runs, nil
+}
+
+func (r *AllowanceRepository) scheduleIndex(id int64) int {
+	return slices.IndexFunc(r.schedules, func(s acme.AllowanceSchedule) bool { return s.ID == id })
+}
@@ -0,0 +1,180 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+)
+
+// BalanceRuleRepository is a thread-safe in-memory acme.BalanceRuleRepository
+type BalanceRuleRepository struct {
+	mu              sync.RWMutex
+	lastRuleID      int64
+	lastExecutionID int64
+	// rules in insertion order
+	rules []balanceRule
+	// executions in insertion order
+	executions []acme.BalanceRuleExecution
+}
+
+// balanceRule is a rule with its lease
+type balanceRule struct {
+	acme.BalanceRule
+	leasedUntil time.Time
+}
+
+var _ acme.BalanceRuleRepository = (*BalanceRuleRepository)(nil)
+
+func NewBalanceRuleRepository() *BalanceRuleRepository {
+	return &BalanceRuleRepository{}
+}
+
+// SaveBalanceRule implements acme.BalanceRuleRepository.
+func (r *BalanceRuleRepository) SaveBalanceRule(_ context.Context, rule *acme.BalanceRule) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lastRuleID++
+	rule.ID = r.lastRuleID
+	rule.CreatedAt = time.Now().UTC()
+	r.rules = append(r.rules, balanceRule{BalanceRule: *rule})
+
+	return nil
+}
+
+// GetBalanceRule implements acme.BalanceRuleRepository.
+func (r *BalanceRuleRepository) GetBalanceRule(_ context.Context, id int64) (*acme.BalanceRule, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	i := r.ruleIndex(id)
+	if i < 0 {
+		return nil, acme.ErrBalanceRuleNotFound
+	}
+
+	rule := r.rules[i].BalanceRule
+	return &rule, nil
+}
+
+// FindBalanceRules implements acme.BalanceRuleRepository.
+func (r *BalanceRuleRepository) FindBalanceRules(_ context.Context, cardID string) ([]acme.BalanceRule, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	rules := make([]acme.BalanceRule, 0)
+	for _, rule := range r.rules {
+		if cardID == "" || rule.CardID == cardID {
+			rules = append(rules, rule.BalanceRule)
+		}
+	}
+
+	return rules, nil
+}
+
+// DeleteBalanceRule implements acme.BalanceRuleRepository.
+func (r *BalanceRuleRepository) DeleteBalanceRule(_ context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.ruleIndex(id)
+	if i < 0 {
+		return acme.ErrBalanceRuleNotFound
+	}
+	r.rules = slices.Delete(r.rules, i, i+1)
+
+	return nil
+}
+
+// ClaimBalanceRules implements acme.BalanceRuleRepository.
+func (r *BalanceRuleRepository) ClaimBalanceRules(_ context.Context, now time.Time, lease time.Duration) ([]acme.BalanceRule, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rules := make([]acme.BalanceRule, 0)
+	for i, rule := range r.rules {
+		if !rule.leasedUntil.IsZero() && rule.leasedUntil.After(now) {
+			continue
+		}
+
+		r.rules[i].leasedUntil = now.Add(lease)
+		rules = append(rules, rule.BalanceRule)
+	}
+
+	return rules, nil
+}
+
+// ReleaseBalanceRule implements acme.BalanceRuleRepository.
+func (r *BalanceRuleRepository) ReleaseBalanceRule(_ context.Context, rule acme.BalanceRule) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// the rule may have been deleted while it was evaluated
+	i := r.ruleIndex(rule.ID)
+	if i < 0 {
+		return nil
+	}
+
+	r.rules[i].TriggeredAt = rule.TriggeredAt
+	r.rules[i].leasedUntil = time.Time{}
+
+	return nil
+}
+
+// SaveBalanceRuleExecution implements acme.BalanceRuleRepository.
+func (r *BalanceRuleRepository) SaveBalanceRuleExecution(_ context.Context, e *acme.BalanceRuleExecution) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lastExecutionID++
+	e.ID = r.lastExecutionID
+	r.executions = append(r.executions, *e)
+
+	return nil
+}
+
+// UpdateBalanceRuleExecution implements acme.BalanceRuleRepository.
+func (r *BalanceRuleRepository) UpdateBalanceRuleExecution(_ context.Context, e acme.BalanceRuleExecution) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := slices.IndexFunc(r.executions, func(o acme.BalanceRuleExecution) bool { return o.ID == e.ID })
+	if i >= 0 {
+		r.executions[i].Status = e.Status
+		r.executions[i].AdjustmentID = e.AdjustmentID
+		r.executions[i].Reason = e.Reason
+	}
+
+	return nil
+}
+
+// FindBalanceRuleExecutions implements acme.BalanceRuleRepository.
+func (r *BalanceRuleRepository) FindBalanceRuleExecutions(_ context.Context, filter acme.BalanceRuleExecutionFilter) ([]acme.BalanceRuleExecution, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	executions := make([]acme.BalanceRuleExecution, 0)
+	for _, e := range r.executions {
+		if (filter.CardID == "" || e.CardID == filter.CardID) &&
+			(filter.RuleID == 0 || e.RuleID == filter.RuleID) &&
+			!e.CreatedAt.Before(filter.Since) {
+			executions = append(executions, e)
+		}
+	}
+
+	slices.SortFunc(executions, func(a, b acme.BalanceRuleExecution) int {
+		if c := b.CreatedAt.Compare(a.CreatedAt); c != 0 {
+			return c
+		}
+		return cmp.Compare(b.ID, a.ID)
+	})
+
+	return executions, nil
+}
+
+func (r *BalanceRuleRepository) ruleIndex(id int64) int {
+	return slices.IndexFunc(r.rules, func(rule balanceRule) bool { return rule.ID == id })
+}
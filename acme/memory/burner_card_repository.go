@@ -0,0 +1,86 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+)
+
+// BurnerCardRepository is a thread-safe in-memory acme.BurnerCardRepository
+type BurnerCardRepository struct {
+	mu    sync.RWMutex
+	cards map[string]acme.BurnerCard
+}
+
+var _ acme.BurnerCardRepository = (*BurnerCardRepository)(nil)
+
+func NewBurnerCardRepository() *BurnerCardRepository {
+	return &BurnerCardRepository{cards: make(map[string]acme.BurnerCard)}
+}
+
+// SaveBurnerCard implements acme.BurnerCardRepository.
+func (r *BurnerCardRepository) SaveBurnerCard(_ context.Context, c *acme.BurnerCard) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if saved, ok := r.cards[c.CardID]; ok {
+		c.CreatedAt = saved.CreatedAt
+		return nil
+	}
+
+	c.CreatedAt = time.Now().UTC()
+	r.cards[c.CardID] = acme.BurnerCard{
+		CardID:     c.CardID,
+		Mode:       c.Mode,
+		MerchantID: c.MerchantID,
+		CreatedAt:  c.CreatedAt,
+	}
+
+	return nil
+}
+
+// FindBurnerCards implements acme.BurnerCardRepository.
+func (r *BurnerCardRepository) FindBurnerCards(_ context.Context, active bool) ([]acme.BurnerCard, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	cards := make([]acme.BurnerCard, 0, len(r.cards))
+	for _, c := range r.cards {
+		if !active || !c.IsEnded() {
+			cards = append(cards, c)
+		}
+	}
+
+	slices.SortFunc(cards, func(a, b acme.BurnerCard) int {
+		if c := b.CreatedAt.Compare(a.CreatedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.CardID, b.CardID)
+	})
+
+	return cards, nil
+}
+
+// UpdateBurnerCard implements acme.BurnerCardRepository.
+func (r *BurnerCardRepository) UpdateBurnerCard(_ context.Context, c acme.BurnerCard) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	saved, ok := r.cards[c.CardID]
+	if !ok {
+		return acme.ErrCardNotFound
+	}
+
+	saved.MerchantID = c.MerchantID
+	saved.FrozenAt = c.FrozenAt
+	saved.CheckedAt = c.CheckedAt
+	saved.EndedAt = c.EndedAt
+	saved.EndReason = c.EndReason
+	r.cards[c.CardID] = saved
+
+	return nil
+}
@@ -0,0 +1,158 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+)
+
+// CardApplicationRepository is a thread-safe in-memory
+// acme.CardApplicationRepository
+type CardApplicationRepository struct {
+	mu          sync.RWMutex
+	lastAppID   int64
+	lastEventID int64
+	// apps in insertion order
+	apps []acme.CardApplication
+	// events in insertion order
+	events []acme.CardApplicationEvent
+	// locks are the application locks, they are never deleted
+	locks map[int64]*sync.Mutex
+}
+
+var _ acme.CardApplicationRepository = (*CardApplicationRepository)(nil)
+
+func NewCardApplicationRepository() *CardApplicationRepository {
+	return &CardApplicationRepository{locks: make(map[int64]*sync.Mutex)}
+}
+
+// SaveCardApplication implements acme.CardApplicationRepository.
+func (r *CardApplicationRepository) SaveCardApplication(_ context.Context, app *acme.CardApplication) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lastAppID++
+	app.ID = r.lastAppID
+	app.CreatedAt = time.Now().UTC()
+	app.UpdatedAt = app.CreatedAt
+
+	saved := *app
+	saved.Events = nil
+	r.apps = append(r.apps, saved)
+
+	return nil
+}
+
+// GetCardApplication implements acme.CardApplicationRepository.
+func (r *CardApplicationRepository) GetCardApplication(_ context.Context, id int64) (*acme.CardApplication, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	i := r.appIndex(id)
+	if i < 0 {
+		return nil, acme.ErrCardApplicationNotFound
+	}
+
+	app := r.apps[i]
+	return &app, nil
+}
+
+// FindCardApplications implements acme.CardApplicationRepository.
+func (r *CardApplicationRepository) FindCardApplications(_ context.Context, filter acme.CardApplicationFilter) ([]acme.CardApplication, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	apps := make([]acme.CardApplication, 0)
+	for _, app := range r.apps {
+		if (filter.Status == "" || app.Status == filter.Status) &&
+			(filter.Maker == "" || app.Maker == filter.Maker) {
+			apps = append(apps, app)
+		}
+	}
+
+	slices.SortFunc(apps, func(a, b acme.CardApplication) int {
+		if c := b.CreatedAt.Compare(a.CreatedAt); c != 0 {
+			return c
+		}
+		return cmp.Compare(b.ID, a.ID)
+	})
+
+	return apps, nil
+}
+
+// UpdateCardApplication implements acme.CardApplicationRepository.
+func (r *CardApplicationRepository) UpdateCardApplication(_ context.Context, app *acme.CardApplication, from string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.appIndex(app.ID)
+	if i < 0 || r.apps[i].Status != from {
+		return fmt.Errorf("%w: the application is no longer %s", acme.ErrCardApplicationStatus, from)
+	}
+
+	app.UpdatedAt = time.Now().UTC()
+	saved := &r.apps[i]
+	saved.Status = app.Status
+	saved.Checker = app.Checker
+	saved.RejectionReason = app.RejectionReason
+	saved.CardID = app.CardID
+	saved.Error = app.Error
+	saved.UpdatedAt = app.UpdatedAt
+
+	return nil
+}
+
+// LockCardApplication implements acme.CardApplicationRepository.
+func (r *CardApplicationRepository) LockCardApplication(ctx context.Context, id int64, fn func(context.Context) error) (bool, error) {
+	r.mu.Lock()
+	lock, ok := r.locks[id]
+	if !ok {
+		lock = &sync.Mutex{}
+		r.locks[id] = lock
+	}
+	r.mu.Unlock()
+
+	if !lock.TryLock() {
+		return false, nil
+	}
+	defer lock.Unlock()
+
+	return true, fn(ctx)
+}
+
+// SaveCardApplicationEvent implements acme.CardApplicationRepository.
+func (r *CardApplicationRepository) SaveCardApplicationEvent(_ context.Context, e *acme.CardApplicationEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lastEventID++
+	e.ID = r.lastEventID
+	e.CreatedAt = time.Now().UTC()
+	r.events = append(r.events, *e)
+
+	return nil
+}
+
+// FindCardApplicationEvents implements acme.CardApplicationRepository.
+func (r *CardApplicationRepository) FindCardApplicationEvents(_ context.Context, applicationID int64) ([]acme.CardApplicationEvent, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	events := make([]acme.CardApplicationEvent, 0)
+	for _, e := range r.events {
+		if e.ApplicationID == applicationID {
+			events = append(events, e)
+		}
+	}
+
+	return events, nil
+}
+
+func (r *CardApplicationRepository) appIndex(id int64) int {
+	return slices.IndexFunc(r.apps, func(app acme.CardApplication) bool { return app.ID == id })
+}
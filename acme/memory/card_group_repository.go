@@ -0,0 +1,196 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+)
+
+// CardGroupRepository is a thread-safe in-memory acme.CardGroupRepository
+type CardGroupRepository struct {
+	mu     sync.RWMutex
+	lastID int64
+	groups map[int64]acme.CardGroup
+	// members maps the card IDs to their group, memberOrder keeps the card
+	// IDs in the order they were added
+	members     map[string]int64
+	memberOrder []string
+	// locks are the group locks, they are never deleted
+	locks map[int64]*sync.Mutex
+}
+
+var _ acme.CardGroupRepository = (*CardGroupRepository)(nil)
+
+func NewCardGroupRepository() *CardGroupRepository {
+	return &CardGroupRepository{
+		groups:  make(map[int64]acme.CardGroup),
+		members: make(map[string]int64),
+		locks:   make(map[int64]*sync.Mutex),
+	}
+}
+
+// SaveCardGroup implements acme.CardGroupRepository.
+func (r *CardGroupRepository) SaveCardGroup(_ context.Context, group *acme.CardGroup) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if group.ID == 0 {
+		r.lastID++
+		group.ID = r.lastID
+		group.CreatedAt = time.Now().UTC()
+		r.groups[group.ID] = *group
+		return nil
+	}
+
+	saved, ok := r.groups[group.ID]
+	if !ok {
+		return acme.ErrCardGroupNotFound
+	}
+
+	group.CreatedAt = saved.CreatedAt
+	r.groups[group.ID] = *group
+
+	return nil
+}
+
+// GetCardGroup implements acme.CardGroupRepository.
+func (r *CardGroupRepository) GetCardGroup(_ context.Context, id int64) (*acme.CardGroup, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	group, ok := r.groups[id]
+	if !ok {
+		return nil, acme.ErrCardGroupNotFound
+	}
+
+	return &group, nil
+}
+
+// FindCardGroups implements acme.CardGroupRepository.
+func (r *CardGroupRepository) FindCardGroups(context.Context) ([]acme.CardGroup, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	groups := make([]acme.CardGroup, 0, len(r.groups))
+	for _, group := range r.groups {
+		groups = append(groups, group)
+	}
+
+	slices.SortFunc(groups, func(a, b acme.CardGroup) int {
+		if c := strings.Compare(a.Name, b.Name); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	return groups, nil
+}
+
+// DeleteCardGroup implements acme.CardGroupRepository.
+func (r *CardGroupRepository) DeleteCardGroup(_ context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.groups[id]; !ok {
+		return acme.ErrCardGroupNotFound
+	}
+
+	delete(r.groups, id)
+	r.memberOrder = slices.DeleteFunc(r.memberOrder, func(cardID string) bool {
+		return r.members[cardID] == id
+	})
+	for cardID, groupID := range r.members {
+		if groupID == id {
+			delete(r.members, cardID)
+		}
+	}
+
+	return nil
+}
+
+// AddCardGroupMember implements acme.CardGroupRepository.
+func (r *CardGroupRepository) AddCardGroupMember(_ context.Context, groupID int64, cardID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.groups[groupID]; !ok {
+		return acme.ErrCardGroupNotFound
+	}
+
+	if _, ok := r.members[cardID]; ok {
+		return acme.ErrCardGroupMemberExists
+	}
+
+	r.members[cardID] = groupID
+	r.memberOrder = append(r.memberOrder, cardID)
+
+	return nil
+}
+
+// RemoveCardGroupMember implements acme.CardGroupRepository.
+func (r *CardGroupRepository) RemoveCardGroupMember(_ context.Context, groupID int64, cardID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id, ok := r.members[cardID]
+	if !ok || id != groupID {
+		return acme.ErrCardGroupMemberNotFound
+	}
+
+	delete(r.members, cardID)
+	r.memberOrder = slices.DeleteFunc(r.memberOrder, func(id string) bool {
+		return id == cardID
+	})
+
+	return nil
+}
+
+// FindCardGroupMembers implements acme.CardGroupRepository.
+func (r *CardGroupRepository) FindCardGroupMembers(_ context.Context, groupID int64) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	cardIDs := make([]string, 0)
+	for _, cardID := range r.memberOrder {
+		if r.members[cardID] == groupID {
+			cardIDs = append(cardIDs, cardID)
+		}
+	}
+
+	return cardIDs, nil
+}
+
+// GetCardGroupByCard implements acme.CardGroupRepository.
+func (r *CardGroupRepository) GetCardGroupByCard(_ context.Context, cardID string) (*acme.CardGroup, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	groupID, ok := r.members[cardID]
+	if !ok {
+		return nil, acme.ErrCardGroupNotFound
+	}
+
+	group := r.groups[groupID]
+	return &group, nil
+}
+
+// LockCardGroup implements acme.CardGroupRepository.
+func (r *CardGroupRepository) LockCardGroup(ctx context.Context, id int64, fn func(context.Context) error) error {
+	r.mu.Lock()
+	lock, ok := r.locks[id]
+	if !ok {
+		lock = &sync.Mutex{}
+		r.locks[id] = lock
+	}
+	r.mu.Unlock()
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	return fn(ctx)
+}
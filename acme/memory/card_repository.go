@@ -0,0 +1,146 @@
+// Package memory implements acme repositories in memory, useful
+// for tests and demos where persistence is not needed.
+package memory
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+)
+
+// CardRepository is a thread-safe in-memory acme.CardRepository
+type CardRepository struct {
+	mu sync.RWMutex
+	// cardIDs in insertion order
+	cardIDs     []string
+	externalIDs map[string]string
+	expiries    map[string]acme.CardExpiry
+}
+
+var _ acme.CardRepository = (*CardRepository)(nil)
+
+func NewCardRepository() *CardRepository {
+	return &CardRepository{
+		externalIDs: make(map[string]string),
+		expiries:    make(map[string]acme.CardExpiry),
+	}
+}
+
+// SaveCardID implements acme.CardRepository.
+func (r *CardRepository) SaveCardID(_ context.Context, cardID string, externalCardID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.externalIDs[cardID]; ok {
+		return acme.ErrCardExists
+	}
+
+	r.cardIDs = append(r.cardIDs, cardID)
+	r.externalIDs[cardID] = externalCardID
+
+	return nil
+}
+
+// GetExternalID implements acme.CardRepository.
+func (r *CardRepository) GetExternalID(_ context.Context, cardID string) (string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	externalID, ok := r.externalIDs[cardID]
+	if !ok {
+		return "", acme.ErrCardNotFound
+	}
+
+	return externalID, nil
+}
+
+// FindCardIDs implements acme.CardRepository.
+func (r *CardRepository) FindCardIDs(context.Context) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	cardIDs := make([]string, 0, len(r.cardIDs))
+	for i := len(r.cardIDs) - 1; i >= 0; i-- {
+		cardIDs = append(cardIDs, r.cardIDs[i])
+	}
+
+	return cardIDs, nil
+}
+
+// GetExternalIDMapping implements acme.CardRepository.
+func (r *CardRepository) GetExternalIDMapping(_ context.Context, externalIDs ...string) (map[string]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	wanted := make(map[string]struct{}, len(externalIDs))
+	for _, externalID := range externalIDs {
+		wanted[externalID] = struct{}{}
+	}
+
+	cardIDMap := make(map[string]string, len(externalIDs))
+	for cardID, externalID := range r.externalIDs {
+		if _, ok := wanted[externalID]; ok {
+			cardIDMap[externalID] = cardID
+		}
+	}
+
+	return cardIDMap, nil
+}
+
+// SaveCardExpiry implements acme.CardRepository.
+func (r *CardRepository) SaveCardExpiry(_ context.Context, expiry acme.CardExpiry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.externalIDs[expiry.CardID]; !ok {
+		return acme.ErrCardNotFound
+	}
+
+	if expiry.ValidUntil.IsZero() {
+		delete(r.expiries, expiry.CardID)
+		return nil
+	}
+	r.expiries[expiry.CardID] = expiry
+
+	return nil
+}
+
+// GetCardExpiry implements acme.CardRepository.
+func (r *CardRepository) GetCardExpiry(_ context.Context, cardID string) (*acme.CardExpiry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, ok := r.externalIDs[cardID]; !ok {
+		return nil, acme.ErrCardNotFound
+	}
+
+	expiry, ok := r.expiries[cardID]
+	if !ok {
+		expiry = acme.CardExpiry{CardID: cardID}
+	}
+
+	return &expiry, nil
+}
+
+// FindCardExpiries implements acme.CardRepository.
+func (r *CardRepository) FindCardExpiries(context.Context) ([]acme.CardExpiry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	expiries := make([]acme.CardExpiry, 0, len(r.expiries))
+	for _, expiry := range r.expiries {
+		expiries = append(expiries, expiry)
+	}
+
+	slices.SortFunc(expiries, func(a, b acme.CardExpiry) int {
+		if c := a.ValidUntil.Compare(b.ValidUntil); c != 0 {
+			return c
+		}
+		return strings.Compare(a.CardID, b.CardID)
+	})
+
+	return expiries, nil
+}
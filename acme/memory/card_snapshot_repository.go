@@ -0,0 +1,83 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+)
+
+// CardSnapshotRepository is a thread-safe in-memory
+// acme.CardSnapshotRepository, the snapshots are ordered by the cards of
+// the card repository
+type CardSnapshotRepository struct {
+	mu        sync.RWMutex
+	cardRepo  acme.CardRepository
+	snapshots map[string]acme.CardSnapshot
+}
+
+var _ acme.CardSnapshotRepository = (*CardSnapshotRepository)(nil)
+
+func NewCardSnapshotRepository(cardRepo acme.CardRepository) *CardSnapshotRepository {
+	return &CardSnapshotRepository{
+		cardRepo:  cardRepo,
+		snapshots: make(map[string]acme.CardSnapshot),
+	}
+}
+
+// SaveCardSnapshots implements acme.CardSnapshotRepository.
+func (r *CardSnapshotRepository) SaveCardSnapshots(_ context.Context, snapshots ...acme.CardSnapshot) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, s := range snapshots {
+		r.snapshots[s.ID] = s
+	}
+
+	return nil
+}
+
+// FindCardSnapshots implements acme.CardSnapshotRepository.
+func (r *CardSnapshotRepository) FindCardSnapshots(ctx context.Context) ([]acme.CardSnapshot, error) {
+	cardIDs, err := r.cardRepo.FindCardIDs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("find card ids: %w", err)
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	snapshots := make([]acme.CardSnapshot, 0, len(r.snapshots))
+	for _, cardID := range cardIDs {
+		if s, ok := r.snapshots[cardID]; ok {
+			snapshots = append(snapshots, s)
+		}
+	}
+
+	return snapshots, nil
+}
+
+// DeleteCardSnapshotsBefore implements acme.CardSnapshotRepository.
+func (r *CardSnapshotRepository) DeleteCardSnapshotsBefore(_ context.Context, t time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	maps.DeleteFunc(r.snapshots, func(_ string, s acme.CardSnapshot) bool {
+		return s.UpdatedAt.Before(t)
+	})
+
+	return nil
+}
+
+// DeleteCardSnapshot implements acme.CardSnapshotRepository.
+func (r *CardSnapshotRepository) DeleteCardSnapshot(_ context.Context, cardID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.snapshots, cardID)
+
+	return nil
+}
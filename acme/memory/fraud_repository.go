@@ -0,0 +1,218 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+)
+
+// FraudRepository is a thread-safe in-memory acme.FraudRepository
+type FraudRepository struct {
+	mu             sync.RWMutex
+	lastRuleID     int64
+	lastDecisionID int64
+	// rules, decisions and flags in insertion order
+	rules     []acme.FraudRule
+	decisions []acme.FraudDecision
+	flags     []acme.TransactionFlag
+	// evaluating is the evaluation lock
+	evaluating sync.Mutex
+}
+
+var _ acme.FraudRepository = (*FraudRepository)(nil)
+
+func NewFraudRepository() *FraudRepository {
+	return &FraudRepository{}
+}
+
+// SaveFraudRule implements acme.FraudRepository.
+func (r *FraudRepository) SaveFraudRule(_ context.Context, rule *acme.FraudRule) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if rule.ID == 0 {
+		r.lastRuleID++
+		rule.ID = r.lastRuleID
+		rule.CreatedAt = time.Now().UTC()
+		r.rules = append(r.rules, *rule)
+		return nil
+	}
+
+	i := r.ruleIndex(rule.ID)
+	if i < 0 {
+		return acme.ErrFraudRuleNotFound
+	}
+
+	rule.CreatedAt = r.rules[i].CreatedAt
+	r.rules[i] = *rule
+
+	return nil
+}
+
+// GetFraudRule implements acme.FraudRepository.
+func (r *FraudRepository) GetFraudRule(_ context.Context, id int64) (*acme.FraudRule, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	i := r.ruleIndex(id)
+	if i < 0 {
+		return nil, acme.ErrFraudRuleNotFound
+	}
+
+	rule := r.rules[i]
+	return &rule, nil
+}
+
+// FindFraudRules implements acme.FraudRepository.
+func (r *FraudRepository) FindFraudRules(context.Context) ([]acme.FraudRule, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return append(make([]acme.FraudRule, 0, len(r.rules)), r.rules...), nil
+}
+
+// DeleteFraudRule implements acme.FraudRepository.
+func (r *FraudRepository) DeleteFraudRule(_ context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.ruleIndex(id)
+	if i < 0 {
+		return acme.ErrFraudRuleNotFound
+	}
+	r.rules = slices.Delete(r.rules, i, i+1)
+
+	return nil
+}
+
+// FraudDecisionExists implements acme.FraudRepository.
+func (r *FraudRepository) FraudDecisionExists(_ context.Context, ruleID int64, transactionID string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.decisionExists(ruleID, transactionID), nil
+}
+
+// SaveFraudDecision implements acme.FraudRepository.
+func (r *FraudRepository) SaveFraudDecision(_ context.Context, d *acme.FraudDecision) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.decisionExists(d.RuleID, d.TransactionID) {
+		return acme.ErrFraudDecisionExists
+	}
+
+	r.lastDecisionID++
+	d.ID = r.lastDecisionID
+	r.decisions = append(r.decisions, *d)
+
+	return nil
+}
+
+// FindFraudDecisions implements acme.FraudRepository.
+func (r *FraudRepository) FindFraudDecisions(_ context.Context, filter acme.FraudDecisionFilter) ([]acme.FraudDecision, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	decisions := make([]acme.FraudDecision, 0)
+	for _, d := range r.decisions {
+		if (filter.CardID == "" || d.CardID == filter.CardID) &&
+			(filter.TransactionID == "" || d.TransactionID == filter.TransactionID) &&
+			(filter.Action == "" || d.Action == filter.Action) {
+			decisions = append(decisions, d)
+		}
+	}
+
+	slices.SortFunc(decisions, func(a, b acme.FraudDecision) int {
+		if c := b.CreatedAt.Compare(a.CreatedAt); c != 0 {
+			return c
+		}
+		return cmp.Compare(b.ID, a.ID)
+	})
+
+	return decisions, nil
+}
+
+// SaveTransactionFlag implements acme.FraudRepository.
+func (r *FraudRepository) SaveTransactionFlag(_ context.Context, flag *acme.TransactionFlag) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := slices.IndexFunc(r.flags, func(f acme.TransactionFlag) bool {
+		return f.TransactionID == flag.TransactionID
+	})
+	if i >= 0 {
+		flag.CreatedAt = r.flags[i].CreatedAt
+		return nil
+	}
+
+	flag.CreatedAt = time.Now().UTC()
+	flag.ClearedAt = time.Time{}
+	r.flags = append(r.flags, *flag)
+
+	return nil
+}
+
+// FindTransactionFlags implements acme.FraudRepository.
+func (r *FraudRepository) FindTransactionFlags(_ context.Context, filter acme.TransactionFlagFilter) ([]acme.TransactionFlag, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	flags := make([]acme.TransactionFlag, 0)
+	for _, f := range r.flags {
+		if (filter.CardID == "" || f.CardID == filter.CardID) &&
+			(!filter.Open || f.ClearedAt.IsZero()) {
+			flags = append(flags, f)
+		}
+	}
+
+	slices.SortFunc(flags, func(a, b acme.TransactionFlag) int {
+		if c := b.CreatedAt.Compare(a.CreatedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.TransactionID, b.TransactionID)
+	})
+
+	return flags, nil
+}
+
+// ClearTransactionFlag implements acme.FraudRepository.
+func (r *FraudRepository) ClearTransactionFlag(_ context.Context, transactionID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := slices.IndexFunc(r.flags, func(f acme.TransactionFlag) bool {
+		return f.TransactionID == transactionID && f.ClearedAt.IsZero()
+	})
+	if i < 0 {
+		return acme.ErrTransactionFlagNotFound
+	}
+	r.flags[i].ClearedAt = time.Now().UTC()
+
+	return nil
+}
+
+// LockFraudEvaluation implements acme.FraudRepository.
+func (r *FraudRepository) LockFraudEvaluation(ctx context.Context, fn func(context.Context) error) (bool, error) {
+	if !r.evaluating.TryLock() {
+		return false, nil
+	}
+	defer r.evaluating.Unlock()
+
+	return true, fn(ctx)
+}
+
+func (r *FraudRepository) ruleIndex(id int64) int {
+	return slices.IndexFunc(r.rules, func(rule acme.FraudRule) bool { return rule.ID == id })
+}
+
+func (r *FraudRepository) decisionExists(ruleID int64, transactionID string) bool {
+	return slices.ContainsFunc(r.decisions, func(d acme.FraudDecision) bool {
+		return d.RuleID == ruleID && d.TransactionID == transactionID
+	})
+}
@@ -0,0 +1,169 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+)
+
+// JournalRepository is a thread-safe in-memory acme.JournalRepository
+type JournalRepository struct {
+	mu           sync.RWMutex
+	lastExportID int64
+	accounts     map[string]string
+	// exports in insertion order
+	exports []acme.JournalExport
+}
+
+var _ acme.JournalRepository = (*JournalRepository)(nil)
+
+func NewJournalRepository() *JournalRepository {
+	return &JournalRepository{accounts: make(map[string]string)}
+}
+
+// SaveJournalAccount implements acme.JournalRepository.
+func (r *JournalRepository) SaveJournalAccount(_ context.Context, account acme.JournalAccount) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.accounts[account.Role] = account.Account
+	return nil
+}
+
+// FindJournalAccounts implements acme.JournalRepository.
+func (r *JournalRepository) FindJournalAccounts(context.Context) ([]acme.JournalAccount, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	accounts := make([]acme.JournalAccount, 0, len(r.accounts))
+	for role, account := range r.accounts {
+		accounts = append(accounts, acme.JournalAccount{Role: role, Account: account})
+	}
+
+	slices.SortFunc(accounts, func(a, b acme.JournalAccount) int {
+		return strings.Compare(a.Role, b.Role)
+	})
+
+	return accounts, nil
+}
+
+// DeleteJournalAccount implements acme.JournalRepository.
+func (r *JournalRepository) DeleteJournalAccount(_ context.Context, role string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.accounts[role]; !ok {
+		return fmt.Errorf("%w: %s", acme.ErrJournalAccountMissing, role)
+	}
+	delete(r.accounts, role)
+
+	return nil
+}
+
+// FindBookedTransactionIDs implements acme.JournalRepository.
+func (r *JournalRepository) FindBookedTransactionIDs(_ context.Context, transactionIDs ...string) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	booked := make([]string, 0)
+	for _, id := range transactionIDs {
+		if !slices.Contains(booked, id) && r.booked(id, 0) {
+			booked = append(booked, id)
+		}
+	}
+
+	return booked, nil
+}
+
+// SaveJournalExport implements acme.JournalRepository.
+func (r *JournalRepository) SaveJournalExport(_ context.Context, export *acme.JournalExport) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, l := range export.Lines {
+		// the transaction lines are booked once
+		if r.booked(l.TransactionID, l.LineNo) || slices.ContainsFunc(export.Lines[:i], func(o acme.JournalLine) bool {
+			return o.TransactionID == l.TransactionID && o.LineNo == l.LineNo
+		}) {
+			return fmt.Errorf("%w: %s", acme.ErrTransactionAlreadyBooked, l.TransactionID)
+		}
+	}
+
+	r.lastExportID++
+	export.ID = r.lastExportID
+	export.CreatedAt = time.Now().UTC()
+
+	saved := *export
+	saved.Lines = slices.Clone(export.Lines)
+	r.exports = append(r.exports, saved)
+
+	return nil
+}
+
+// GetJournalExport implements acme.JournalRepository.
+func (r *JournalRepository) GetJournalExport(_ context.Context, id int64) (*acme.JournalExport, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	i := slices.IndexFunc(r.exports, func(e acme.JournalExport) bool { return e.ID == id })
+	if i < 0 {
+		return nil, acme.ErrJournalExportNotFound
+	}
+
+	export := r.exports[i]
+	export.Lines = slices.Clone(export.Lines)
+	if export.Lines == nil {
+		export.Lines = make([]acme.JournalLine, 0)
+	}
+	slices.SortStableFunc(export.Lines, func(a, b acme.JournalLine) int {
+		if c := a.Date.Compare(b.Date); c != 0 {
+			return c
+		}
+		if c := strings.Compare(a.TransactionID, b.TransactionID); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.LineNo, b.LineNo)
+	})
+
+	return &export, nil
+}
+
+// FindJournalExports implements acme.JournalRepository.
+func (r *JournalRepository) FindJournalExports(context.Context) ([]acme.JournalExport, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	exports := make([]acme.JournalExport, 0, len(r.exports))
+	for _, e := range r.exports {
+		e.Lines = nil
+		exports = append(exports, e)
+	}
+
+	slices.SortFunc(exports, func(a, b acme.JournalExport) int {
+		if c := b.Until.Compare(a.Until); c != 0 {
+			return c
+		}
+		return cmp.Compare(b.ID, a.ID)
+	})
+
+	return exports, nil
+}
+
+// booked reports whether the transaction line is saved, any line when
+// lineNo is zero
+func (r *JournalRepository) booked(transactionID string, lineNo int) bool {
+	for _, e := range r.exports {
+		for _, l := range e.Lines {
+			if l.TransactionID == transactionID && (lineNo == 0 || l.LineNo == lineNo) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,138 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+)
+
+// MerchantControlRepository is a thread-safe in-memory
+// acme.MerchantControlRepository, the group controls of a card are resolved
+// with the card group repository
+type MerchantControlRepository struct {
+	groupRepo acme.CardGroupRepository
+
+	mu     sync.RWMutex
+	lastID int64
+	// controls in insertion order
+	controls []acme.MerchantControl
+	// violations in insertion order
+	violations []acme.MerchantControlViolation
+	// enforcing is the enforcement lock
+	enforcing sync.Mutex
+}
+
+var _ acme.MerchantControlRepository = (*MerchantControlRepository)(nil)
+
+func NewMerchantControlRepository(groupRepo acme.CardGroupRepository) *MerchantControlRepository {
+	return &MerchantControlRepository{groupRepo: groupRepo}
+}
+
+// SaveMerchantControl implements acme.MerchantControlRepository.
+func (r *MerchantControlRepository) SaveMerchantControl(_ context.Context, control *acme.MerchantControl) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lastID++
+	control.ID = r.lastID
+	control.CreatedAt = time.Now().UTC()
+	r.controls = append(r.controls, *control)
+
+	return nil
+}
+
+// FindMerchantControls implements acme.MerchantControlRepository.
+func (r *MerchantControlRepository) FindMerchantControls(ctx context.Context, cardID string) ([]acme.MerchantControl, error) {
+	var groupID int64
+	if cardID != "" {
+		group, err := r.groupRepo.GetCardGroupByCard(ctx, cardID)
+		if err != nil && !errors.Is(err, acme.ErrCardGroupNotFound) {
+			return nil, fmt.Errorf("get card group by card: %w", err)
+		}
+		if group != nil {
+			groupID = group.ID
+		}
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	controls := make([]acme.MerchantControl, 0)
+	for _, c := range r.controls {
+		if cardID == "" ||
+			(c.CardID == "" && c.GroupID == 0) ||
+			c.CardID == cardID ||
+			(groupID != 0 && c.GroupID == groupID) {
+			controls = append(controls, c)
+		}
+	}
+
+	return controls, nil
+}
+
+// DeleteMerchantControl implements acme.MerchantControlRepository.
+func (r *MerchantControlRepository) DeleteMerchantControl(_ context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := slices.IndexFunc(r.controls, func(c acme.MerchantControl) bool { return c.ID == id })
+	if i < 0 {
+		return acme.ErrMerchantControlNotFound
+	}
+	r.controls = slices.Delete(r.controls, i, i+1)
+
+	return nil
+}
+
+// LockMerchantControls implements acme.MerchantControlRepository.
+func (r *MerchantControlRepository) LockMerchantControls(ctx context.Context, fn func(context.Context) error) (bool, error) {
+	if !r.enforcing.TryLock() {
+		return false, nil
+	}
+	defer r.enforcing.Unlock()
+
+	return true, fn(ctx)
+}
+
+// MerchantControlViolationExists implements acme.MerchantControlRepository.
+func (r *MerchantControlRepository) MerchantControlViolationExists(_ context.Context, transactionID string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return slices.ContainsFunc(r.violations, func(v acme.MerchantControlViolation) bool {
+		return v.TransactionID == transactionID
+	}), nil
+}
+
+// SaveMerchantControlViolation implements acme.MerchantControlRepository.
+func (r *MerchantControlRepository) SaveMerchantControlViolation(_ context.Context, v acme.MerchantControlViolation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if slices.ContainsFunc(r.violations, func(saved acme.MerchantControlViolation) bool {
+		return saved.TransactionID == v.TransactionID
+	}) {
+		return acme.ErrMerchantControlViolationExists
+	}
+	r.violations = append(r.violations, v)
+
+	return nil
+}
+
+// FindMerchantControlViolations implements acme.MerchantControlRepository.
+func (r *MerchantControlRepository) FindMerchantControlViolations(context.Context) ([]acme.MerchantControlViolation, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	violations := slices.Clone(r.violations)
+	slices.SortStableFunc(violations, func(a, b acme.MerchantControlViolation) int {
+		return b.CreatedAt.Compare(a.CreatedAt)
+	})
+
+	return violations, nil
+}
@@ -0,0 +1,59 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/stevenferrer/acme-cards-api/acme/export"
+)
+
+// MonthlyStatementRepository is a thread-safe in-memory
+// export.MonthlyStatementRepository
+type MonthlyStatementRepository struct {
+	mu         sync.RWMutex
+	statements map[monthlyStatementKey]export.MonthlyStatement
+}
+
+type monthlyStatementKey struct {
+	cardID string
+	month  time.Time
+}
+
+var _ export.MonthlyStatementRepository = (*MonthlyStatementRepository)(nil)
+
+func NewMonthlyStatementRepository() *MonthlyStatementRepository {
+	return &MonthlyStatementRepository{statements: make(map[monthlyStatementKey]export.MonthlyStatement)}
+}
+
+// SaveMonthlyStatement implements export.MonthlyStatementRepository, saving a
+// statement twice keeps the first statement.
+func (r *MonthlyStatementRepository) SaveMonthlyStatement(_ context.Context, s export.MonthlyStatement) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := monthlyStatementKey{cardID: s.Card.ID, month: s.Month.UTC()}
+	if _, ok := r.statements[key]; ok {
+		return nil
+	}
+
+	s.Entries = slices.Clone(s.Entries)
+	r.statements[key] = s
+
+	return nil
+}
+
+// GetMonthlyStatement implements export.MonthlyStatementRepository.
+func (r *MonthlyStatementRepository) GetMonthlyStatement(_ context.Context, cardID string, month time.Time) (*export.MonthlyStatement, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	s, ok := r.statements[monthlyStatementKey{cardID: cardID, month: month.UTC()}]
+	if !ok {
+		return nil, export.ErrMonthlyStatementNotFound
+	}
+
+	s.Entries = slices.Clone(s.Entries)
+	return &s, nil
+}
@@ -0,0 +1,145 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+)
+
+// NotificationRepository is a thread-safe in-memory
+// acme.NotificationRepository
+type NotificationRepository struct {
+	mu                 sync.RWMutex
+	lastNotificationID int64
+	// notifications in insertion order
+	notifications []acme.Notification
+	prefs         map[string]acme.NotificationPreference
+}
+
+var _ acme.NotificationRepository = (*NotificationRepository)(nil)
+
+func NewNotificationRepository() *NotificationRepository {
+	return &NotificationRepository{prefs: make(map[string]acme.NotificationPreference)}
+}
+
+// SaveNotification implements acme.NotificationRepository.
+func (r *NotificationRepository) SaveNotification(_ context.Context, n *acme.Notification) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if slices.ContainsFunc(r.notifications, func(o acme.Notification) bool {
+		return o.EventID == n.EventID && o.Type == n.Type
+	}) {
+		return acme.ErrNotificationExists
+	}
+
+	r.lastNotificationID++
+	n.ID = r.lastNotificationID
+	r.notifications = append(r.notifications, *n)
+
+	return nil
+}
+
+// ClaimNotifications implements acme.NotificationRepository.
+func (r *NotificationRepository) ClaimNotifications(_ context.Context, now time.Time, lease time.Duration, limit int) ([]acme.Notification, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	due := make([]int, 0)
+	for i, n := range r.notifications {
+		if n.Status == acme.NotificationPending && !n.NextAttemptAt.After(now) {
+			due = append(due, i)
+		}
+	}
+	slices.SortStableFunc(due, func(a, b int) int {
+		return r.notifications[a].NextAttemptAt.Compare(r.notifications[b].NextAttemptAt)
+	})
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	claimed := make([]acme.Notification, 0, len(due))
+	for _, i := range due {
+		r.notifications[i].NextAttemptAt = now.Add(lease)
+		claimed = append(claimed, r.notifications[i])
+	}
+
+	return claimed, nil
+}
+
+// UpdateNotification implements acme.NotificationRepository.
+func (r *NotificationRepository) UpdateNotification(_ context.Context, n acme.Notification) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := slices.IndexFunc(r.notifications, func(o acme.Notification) bool { return o.ID == n.ID })
+	if i < 0 {
+		return nil
+	}
+
+	saved := &r.notifications[i]
+	saved.Email = n.Email
+	saved.Subject = n.Subject
+	saved.Status = n.Status
+	saved.Attempts = n.Attempts
+	saved.NextAttemptAt = n.NextAttemptAt
+	saved.Error = n.Error
+	saved.SentAt = n.SentAt
+
+	return nil
+}
+
+// FindNotifications implements acme.NotificationRepository.
+func (r *NotificationRepository) FindNotifications(_ context.Context, filter acme.NotificationFilter) ([]acme.Notification, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	notifications := make([]acme.Notification, 0)
+	for _, n := range r.notifications {
+		if (filter.CardID == "" || n.CardID == filter.CardID) &&
+			(filter.Status == "" || n.Status == filter.Status) {
+			notifications = append(notifications, n)
+		}
+	}
+
+	slices.SortFunc(notifications, func(a, b acme.Notification) int {
+		if c := b.CreatedAt.Compare(a.CreatedAt); c != 0 {
+			return c
+		}
+		return cmp.Compare(b.ID, a.ID)
+	})
+
+	return notifications, nil
+}
+
+// GetNotificationPreference implements acme.NotificationRepository.
+func (r *NotificationRepository) GetNotificationPreference(_ context.Context, email string) (*acme.NotificationPreference, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	pref, ok := r.prefs[email]
+	if !ok {
+		return nil, acme.ErrNotificationPreferenceNotFound
+	}
+
+	pref.OptOutTypes = slices.Clone(pref.OptOutTypes)
+	return &pref, nil
+}
+
+// SaveNotificationPreference implements acme.NotificationRepository.
+func (r *NotificationRepository) SaveNotificationPreference(_ context.Context, pref *acme.NotificationPreference) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	pref.UpdatedAt = time.Now().UTC()
+
+	saved := *pref
+	saved.OptOutTypes = slices.Clone(pref.OptOutTypes)
+	r.prefs[pref.Email] = saved
+
+	return nil
+}
@@ -0,0 +1,102 @@
+package memory_test
+
+import (
+	"testing"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/acme/export"
+	"github.com/stevenferrer/acme-cards-api/acme/memory"
+	"github.com/stevenferrer/acme-cards-api/acme/repotest"
+)
+
+func TestAllowanceRepository(t *testing.T) {
+	repotest.RunAllowanceRepositorySuite(t, func(*testing.T) (acme.CardRepository, acme.AllowanceRepository) {
+		return memory.NewCardRepository(), memory.NewAllowanceRepository()
+	})
+}
+
+func TestBalanceRuleRepository(t *testing.T) {
+	repotest.RunBalanceRuleRepositorySuite(t, func(*testing.T) (acme.CardRepository, acme.BalanceRuleRepository) {
+		return memory.NewCardRepository(), memory.NewBalanceRuleRepository()
+	})
+}
+
+func TestBurnerCardRepository(t *testing.T) {
+	repotest.RunBurnerCardRepositorySuite(t, func(*testing.T) (acme.CardRepository, acme.BurnerCardRepository) {
+		return memory.NewCardRepository(), memory.NewBurnerCardRepository()
+	})
+}
+
+func TestCardApplicationRepository(t *testing.T) {
+	repotest.RunCardApplicationRepositorySuite(t, func(*testing.T) acme.CardApplicationRepository {
+		return memory.NewCardApplicationRepository()
+	})
+}
+
+func TestCardGroupRepository(t *testing.T) {
+	repotest.RunCardGroupRepositorySuite(t, func(*testing.T) (acme.CardRepository, acme.CardGroupRepository) {
+		return memory.NewCardRepository(), memory.NewCardGroupRepository()
+	})
+}
+
+func TestCardSnapshotRepository(t *testing.T) {
+	repotest.RunCardSnapshotRepositorySuite(t, func(*testing.T) (acme.CardRepository, acme.CardSnapshotRepository) {
+		cardRepo := memory.NewCardRepository()
+		return cardRepo, memory.NewCardSnapshotRepository(cardRepo)
+	})
+}
+
+func TestCardRepository(t *testing.T) {
+	repotest.RunCardRepositorySuite(t, func(*testing.T) acme.CardRepository {
+		return memory.NewCardRepository()
+	})
+}
+
+func TestFraudRepository(t *testing.T) {
+	repotest.RunFraudRepositorySuite(t, func(*testing.T) acme.FraudRepository {
+		return memory.NewFraudRepository()
+	})
+}
+
+func TestJournalRepository(t *testing.T) {
+	repotest.RunJournalRepositorySuite(t, func(*testing.T) acme.JournalRepository {
+		return memory.NewJournalRepository()
+	})
+}
+
+func TestMerchantControlRepository(t *testing.T) {
+	repotest.RunMerchantControlRepositorySuite(t, func(*testing.T) (acme.CardRepository, acme.CardGroupRepository, acme.MerchantControlRepository) {
+		groupRepo := memory.NewCardGroupRepository()
+		return memory.NewCardRepository(), groupRepo, memory.NewMerchantControlRepository(groupRepo)
+	})
+}
+
+func TestMonthlyStatementRepository(t *testing.T) {
+	repotest.RunMonthlyStatementRepositorySuite(t, func(*testing.T) (acme.CardRepository, export.MonthlyStatementRepository) {
+		return memory.NewCardRepository(), memory.NewMonthlyStatementRepository()
+	})
+}
+
+func TestNotificationRepository(t *testing.T) {
+	repotest.RunNotificationRepositorySuite(t, func(*testing.T) acme.NotificationRepository {
+		return memory.NewNotificationRepository()
+	})
+}
+
+func TestSpendRequestRepository(t *testing.T) {
+	repotest.RunSpendRequestRepositorySuite(t, func(*testing.T) (acme.CardRepository, acme.SpendRequestRepository) {
+		return memory.NewCardRepository(), memory.NewSpendRequestRepository()
+	})
+}
+
+func TestStreamEventRepository(t *testing.T) {
+	repotest.RunStreamEventRepositorySuite(t, func(*testing.T) acme.StreamEventRepository {
+		return memory.NewStreamEventRepository()
+	})
+}
+
+func TestWebhookRepository(t *testing.T) {
+	repotest.RunWebhookRepositorySuite(t, func(*testing.T) acme.WebhookRepository {
+		return memory.NewWebhookRepository()
+	})
+}
@@ -0,0 +1,205 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+)
+
+// SpendRequestRepository is a thread-safe in-memory
+// acme.SpendRequestRepository
+type SpendRequestRepository struct {
+	mu             sync.RWMutex
+	lastRequestID  int64
+	lastActivityID int64
+	// reqs in insertion order
+	reqs []acme.SpendRequest
+	// activities in insertion order
+	activities []acme.SpendRequestActivity
+	approvers  map[string]acme.SpendRequestApprover
+}
+
+var _ acme.SpendRequestRepository = (*SpendRequestRepository)(nil)
+
+func NewSpendRequestRepository() *SpendRequestRepository {
+	return &SpendRequestRepository{approvers: make(map[string]acme.SpendRequestApprover)}
+}
+
+// SaveSpendRequest implements acme.SpendRequestRepository.
+func (r *SpendRequestRepository) SaveSpendRequest(_ context.Context, req *acme.SpendRequest) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lastRequestID++
+	req.ID = r.lastRequestID
+	req.CreatedAt = time.Now().UTC()
+	req.UpdatedAt = req.CreatedAt
+
+	saved := *req
+	saved.Activities = nil
+	r.reqs = append(r.reqs, saved)
+
+	return nil
+}
+
+// GetSpendRequest implements acme.SpendRequestRepository.
+func (r *SpendRequestRepository) GetSpendRequest(_ context.Context, id int64) (*acme.SpendRequest, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	i := r.requestIndex(id)
+	if i < 0 {
+		return nil, acme.ErrSpendRequestNotFound
+	}
+
+	req := r.reqs[i]
+	return &req, nil
+}
+
+// FindSpendRequests implements acme.SpendRequestRepository.
+func (r *SpendRequestRepository) FindSpendRequests(_ context.Context, filter acme.SpendRequestFilter) ([]acme.SpendRequest, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	reqs := make([]acme.SpendRequest, 0)
+	for _, req := range r.reqs {
+		if (filter.CardID == "" || req.CardID == filter.CardID) &&
+			(filter.Requester == "" || req.Requester == filter.Requester) &&
+			(filter.Status == "" || req.Status == filter.Status) &&
+			(filter.UpdatedBefore.IsZero() || req.UpdatedAt.Before(filter.UpdatedBefore)) {
+			reqs = append(reqs, req)
+		}
+	}
+
+	slices.SortFunc(reqs, func(a, b acme.SpendRequest) int {
+		if c := b.CreatedAt.Compare(a.CreatedAt); c != 0 {
+			return c
+		}
+		return cmp.Compare(b.ID, a.ID)
+	})
+
+	return reqs, nil
+}
+
+// UpdateSpendRequest implements acme.SpendRequestRepository.
+func (r *SpendRequestRepository) UpdateSpendRequest(_ context.Context, req *acme.SpendRequest, from string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.requestIndex(req.ID)
+	if i < 0 || r.reqs[i].Status != from {
+		return fmt.Errorf("%w: the request is no longer %s", acme.ErrSpendRequestStatus, from)
+	}
+
+	req.UpdatedAt = time.Now().UTC()
+	saved := &r.reqs[i]
+	saved.Status = req.Status
+	saved.AdjustmentID = req.AdjustmentID
+	saved.Error = req.Error
+	saved.FundingStartedAt = req.FundingStartedAt
+	saved.UpdatedAt = req.UpdatedAt
+
+	return nil
+}
+
+// SaveSpendRequestActivity implements acme.SpendRequestRepository.
+func (r *SpendRequestRepository) SaveSpendRequestActivity(_ context.Context, a *acme.SpendRequestActivity) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if a.Kind == acme.SpendRequestApproval && slices.ContainsFunc(r.activities, func(o acme.SpendRequestActivity) bool {
+		return o.RequestID == a.RequestID && o.Kind == acme.SpendRequestApproval && o.Actor == a.Actor
+	}) {
+		return fmt.Errorf("%w: %s already approved the request", acme.ErrSpendRequestNotAllowed, a.Actor)
+	}
+
+	r.lastActivityID++
+	a.ID = r.lastActivityID
+	a.CreatedAt = time.Now().UTC()
+	r.activities = append(r.activities, *a)
+
+	return nil
+}
+
+// FindSpendRequestActivities implements acme.SpendRequestRepository.
+func (r *SpendRequestRepository) FindSpendRequestActivities(_ context.Context, requestID int64) ([]acme.SpendRequestActivity, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	activities := make([]acme.SpendRequestActivity, 0)
+	for _, a := range r.activities {
+		if a.RequestID == requestID {
+			activities = append(activities, a)
+		}
+	}
+
+	return activities, nil
+}
+
+// SaveSpendRequestApprover implements acme.SpendRequestRepository.
+func (r *SpendRequestRepository) SaveSpendRequestApprover(_ context.Context, approver *acme.SpendRequestApprover) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now().UTC()
+	approver.CreatedAt, approver.UpdatedAt = now, now
+	if saved, ok := r.approvers[approver.Name]; ok {
+		approver.CreatedAt = saved.CreatedAt
+	}
+	r.approvers[approver.Name] = *approver
+
+	return nil
+}
+
+// GetSpendRequestApprover implements acme.SpendRequestRepository.
+func (r *SpendRequestRepository) GetSpendRequestApprover(_ context.Context, name string) (*acme.SpendRequestApprover, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	approver, ok := r.approvers[name]
+	if !ok {
+		return nil, acme.ErrSpendRequestApproverNotFound
+	}
+
+	return &approver, nil
+}
+
+// FindSpendRequestApprovers implements acme.SpendRequestRepository.
+func (r *SpendRequestRepository) FindSpendRequestApprovers(context.Context) ([]acme.SpendRequestApprover, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	approvers := make([]acme.SpendRequestApprover, 0, len(r.approvers))
+	for _, approver := range r.approvers {
+		approvers = append(approvers, approver)
+	}
+
+	slices.SortFunc(approvers, func(a, b acme.SpendRequestApprover) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return approvers, nil
+}
+
+// DeleteSpendRequestApprover implements acme.SpendRequestRepository.
+func (r *SpendRequestRepository) DeleteSpendRequestApprover(_ context.Context, name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.approvers[name]; !ok {
+		return acme.ErrSpendRequestApproverNotFound
+	}
+	delete(r.approvers, name)
+
+	return nil
+}
+
+func (r *SpendRequestRepository) requestIndex(id int64) int {
+	return slices.IndexFunc(r.reqs, func(req acme.SpendRequest) bool { return req.ID == id })
+}
@@ -0,0 +1,91 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+)
+
+// StreamEventRepository is a thread-safe in-memory
+// acme.StreamEventRepository, it has no listening server instances to
+// notify of the saved events
+type StreamEventRepository struct {
+	mu      sync.RWMutex
+	lastSeq int64
+	// events in seq order
+	events []acme.StreamEvent
+}
+
+var _ acme.StreamEventRepository = (*StreamEventRepository)(nil)
+
+func NewStreamEventRepository() *StreamEventRepository {
+	return &StreamEventRepository{}
+}
+
+// SaveStreamEvent implements acme.StreamEventRepository.
+func (r *StreamEventRepository) SaveStreamEvent(_ context.Context, e *acme.StreamEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if slices.ContainsFunc(r.events, func(saved acme.StreamEvent) bool { return saved.ID == e.ID }) {
+		return acme.ErrStreamEventExists
+	}
+
+	r.lastSeq++
+	e.Seq = r.lastSeq
+
+	saved := *e
+	saved.Payload = slices.Clone(e.Payload)
+	r.events = append(r.events, saved)
+
+	return nil
+}
+
+// GetStreamEvent implements acme.StreamEventRepository.
+func (r *StreamEventRepository) GetStreamEvent(_ context.Context, seq int64) (*acme.StreamEvent, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	i := slices.IndexFunc(r.events, func(e acme.StreamEvent) bool { return e.Seq == seq })
+	if i < 0 {
+		return nil, acme.ErrStreamEventNotFound
+	}
+
+	e := r.events[i]
+	e.Payload = slices.Clone(e.Payload)
+	return &e, nil
+}
+
+// FindLatestStreamEvents implements acme.StreamEventRepository.
+func (r *StreamEventRepository) FindLatestStreamEvents(_ context.Context, since time.Time, limit int) ([]acme.StreamEvent, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	events := make([]acme.StreamEvent, 0)
+	for i := len(r.events) - 1; i >= 0 && len(events) < limit; i-- {
+		e := r.events[i]
+		if e.CreatedAt.Before(since) {
+			continue
+		}
+		e.Payload = slices.Clone(e.Payload)
+		events = append(events, e)
+	}
+	slices.Reverse(events)
+
+	return events, nil
+}
+
+// DeleteStreamEventsBefore implements acme.StreamEventRepository.
+func (r *StreamEventRepository) DeleteStreamEventsBefore(_ context.Context, t time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.events = slices.DeleteFunc(r.events, func(e acme.StreamEvent) bool {
+		return e.CreatedAt.Before(t)
+	})
+
+	return nil
+}
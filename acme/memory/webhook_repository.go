@@ -0,0 +1,256 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+)
+
+// WebhookRepository is a thread-safe in-memory acme.WebhookRepository
+type WebhookRepository struct {
+	mu             sync.RWMutex
+	lastSubID      int64
+	lastDeliveryID int64
+	// subs in insertion order
+	subs   []acme.WebhookSubscription
+	events map[string]acme.WebhookEvent
+	// deliveries in insertion order
+	deliveries []acme.WebhookDelivery
+	// attempts in insertion order
+	attempts []acme.WebhookDeliveryAttempt
+}
+
+var _ acme.WebhookRepository = (*WebhookRepository)(nil)
+
+func NewWebhookRepository() *WebhookRepository {
+	return &WebhookRepository{events: make(map[string]acme.WebhookEvent)}
+}
+
+// SaveWebhookSubscription implements acme.WebhookRepository.
+func (r *WebhookRepository) SaveWebhookSubscription(_ context.Context, sub *acme.WebhookSubscription) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lastSubID++
+	sub.ID = r.lastSubID
+	sub.CreatedAt = time.Now().UTC()
+
+	saved := *sub
+	saved.EventTypes = slices.Clone(sub.EventTypes)
+	r.subs = append(r.subs, saved)
+
+	return nil
+}
+
+// GetWebhookSubscription implements acme.WebhookRepository.
+func (r *WebhookRepository) GetWebhookSubscription(_ context.Context, id int64) (*acme.WebhookSubscription, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	i := slices.IndexFunc(r.subs, func(sub acme.WebhookSubscription) bool { return sub.ID == id })
+	if i < 0 {
+		return nil, acme.ErrWebhookSubscriptionNotFound
+	}
+
+	sub := r.subs[i]
+	sub.EventTypes = slices.Clone(sub.EventTypes)
+	return &sub, nil
+}
+
+// FindWebhookSubscriptions implements acme.WebhookRepository.
+func (r *WebhookRepository) FindWebhookSubscriptions(context.Context) ([]acme.WebhookSubscription, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	subs := make([]acme.WebhookSubscription, 0, len(r.subs))
+	for _, sub := range r.subs {
+		sub.EventTypes = slices.Clone(sub.EventTypes)
+		subs = append(subs, sub)
+	}
+
+	return subs, nil
+}
+
+// DeleteWebhookSubscription implements acme.WebhookRepository.
+func (r *WebhookRepository) DeleteWebhookSubscription(_ context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := slices.IndexFunc(r.subs, func(sub acme.WebhookSubscription) bool { return sub.ID == id })
+	if i < 0 {
+		return acme.ErrWebhookSubscriptionNotFound
+	}
+	r.subs = slices.Delete(r.subs, i, i+1)
+
+	// the deliveries and their attempts are deleted with the subscription
+	var deleted []int64
+	r.deliveries = slices.DeleteFunc(r.deliveries, func(d acme.WebhookDelivery) bool {
+		if d.SubscriptionID != id {
+			return false
+		}
+		deleted = append(deleted, d.ID)
+		return true
+	})
+	r.attempts = slices.DeleteFunc(r.attempts, func(a acme.WebhookDeliveryAttempt) bool {
+		return slices.Contains(deleted, a.DeliveryID)
+	})
+
+	return nil
+}
+
+// SaveWebhookEvent implements acme.WebhookRepository.
+func (r *WebhookRepository) SaveWebhookEvent(_ context.Context, event acme.WebhookEvent, subscriptionIDs []int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.events[event.ID]; ok {
+		return acme.ErrWebhookEventExists
+	}
+	event.Payload = slices.Clone(event.Payload)
+	r.events[event.ID] = event
+
+	for _, sub := range r.subs {
+		// subscriptions deleted in the meantime are skipped
+		if !slices.Contains(subscriptionIDs, sub.ID) {
+			continue
+		}
+
+		r.lastDeliveryID++
+		r.deliveries = append(r.deliveries, acme.WebhookDelivery{
+			ID:             r.lastDeliveryID,
+			SubscriptionID: sub.ID,
+			EventID:        event.ID,
+			Status:         acme.WebhookDeliveryPending,
+			NextAttemptAt:  event.CreatedAt,
+			CreatedAt:      event.CreatedAt,
+			UpdatedAt:      event.CreatedAt,
+		})
+	}
+
+	return nil
+}
+
+// ClaimWebhookDeliveries implements acme.WebhookRepository.
+func (r *WebhookRepository) ClaimWebhookDeliveries(_ context.Context, now time.Time, lease time.Duration, limit int) ([]acme.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	due := make([]int, 0)
+	for i, d := range r.deliveries {
+		if d.Status == acme.WebhookDeliveryPending && !d.NextAttemptAt.After(now) {
+			due = append(due, i)
+		}
+	}
+	slices.SortStableFunc(due, func(a, b int) int {
+		return r.deliveries[a].NextAttemptAt.Compare(r.deliveries[b].NextAttemptAt)
+	})
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	claimed := make([]acme.WebhookDelivery, 0, len(due))
+	for _, i := range due {
+		r.deliveries[i].NextAttemptAt = now.Add(lease)
+		claimed = append(claimed, r.delivery(r.deliveries[i]))
+	}
+
+	slices.SortFunc(claimed, func(a, b acme.WebhookDelivery) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	return claimed, nil
+}
+
+// GetWebhookDelivery implements acme.WebhookRepository.
+func (r *WebhookRepository) GetWebhookDelivery(_ context.Context, id int64) (*acme.WebhookDelivery, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	i := r.deliveryIndex(id)
+	if i < 0 {
+		return nil, acme.ErrWebhookDeliveryNotFound
+	}
+
+	d := r.delivery(r.deliveries[i])
+	return &d, nil
+}
+
+// FindWebhookDeliveries implements acme.WebhookRepository.
+func (r *WebhookRepository) FindWebhookDeliveries(_ context.Context, subscriptionID int64) ([]acme.WebhookDelivery, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	deliveries := make([]acme.WebhookDelivery, 0)
+	for _, d := range r.deliveries {
+		if d.SubscriptionID == subscriptionID {
+			deliveries = append(deliveries, r.delivery(d))
+		}
+	}
+
+	slices.SortFunc(deliveries, func(a, b acme.WebhookDelivery) int {
+		if c := b.CreatedAt.Compare(a.CreatedAt); c != 0 {
+			return c
+		}
+		return cmp.Compare(b.ID, a.ID)
+	})
+
+	return deliveries, nil
+}
+
+// SaveWebhookDeliveryAttempt implements acme.WebhookRepository.
+func (r *WebhookRepository) SaveWebhookDeliveryAttempt(_ context.Context, d acme.WebhookDelivery, attempt acme.WebhookDeliveryAttempt) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.deliveryIndex(d.ID)
+	if i < 0 {
+		return acme.ErrWebhookDeliveryNotFound
+	}
+
+	saved := &r.deliveries[i]
+	saved.Status = d.Status
+	saved.Attempts = d.Attempts
+	saved.NextAttemptAt = d.NextAttemptAt
+	saved.ResponseCode = d.ResponseCode
+	saved.Error = d.Error
+	saved.UpdatedAt = d.UpdatedAt
+
+	r.attempts = append(r.attempts, attempt)
+
+	return nil
+}
+
+// FindWebhookDeliveryAttempts implements acme.WebhookRepository.
+func (r *WebhookRepository) FindWebhookDeliveryAttempts(_ context.Context, deliveryID int64) ([]acme.WebhookDeliveryAttempt, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	attempts := make([]acme.WebhookDeliveryAttempt, 0)
+	for _, a := range r.attempts {
+		if a.DeliveryID == deliveryID {
+			attempts = append(attempts, a)
+		}
+	}
+
+	slices.SortStableFunc(attempts, func(a, b acme.WebhookDeliveryAttempt) int {
+		return cmp.Compare(a.Attempt, b.Attempt)
+	})
+
+	return attempts, nil
+}
+
+func (r *WebhookRepository) deliveryIndex(id int64) int {
+	return slices.IndexFunc(r.deliveries, func(d acme.WebhookDelivery) bool { return d.ID == id })
+}
+
+// delivery joins the delivery with its event
+func (r *WebhookRepository) delivery(d acme.WebhookDelivery) acme.WebhookDelivery {
+	event := r.events[d.EventID]
+	d.EventType = event.Type
+	d.Payload = slices.Clone(event.Payload)
+	return d
+}
@@ -0,0 +1,363 @@
+package acme
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"time"
+)
+
+var (
+	// ErrInvalidMerchantControl is returned for unknown kinds or targets, or
+	// a control of both a card and a card group
+	ErrInvalidMerchantControl = errors.New("invalid merchant control")
+	// ErrMerchantControlNotFound is returned when the control does not exist
+	ErrMerchantControlNotFound = errors.New("merchant control not found")
+	// ErrMerchantControlViolationExists is returned when the transaction
+	// violation is already recorded
+	ErrMerchantControlViolationExists = errors.New("merchant control violation exists")
+)
+
+// Merchant control kinds, a card with allow controls of a target may only
+// spend at the allowed values of that target
+const (
+	MerchantControlAllow = "allow"
+	MerchantControlBlock = "block"
+)
+
+// Merchant control targets
+const (
+	MerchantControlTargetMCC      = "mcc"
+	MerchantControlTargetMerchant = "merchant"
+)
+
+var mccCodeRegexp = regexp.MustCompile(`^[0-9]{4}$`)
+
+// MerchantControl allows or blocks an MCC code or a merchant ID for a card,
+// the cards of a card group or every card. Reap only supports amount caps so
+// controls are enforced by monitoring the card transactions.
+type MerchantControl struct {
+	ID int64
+	// CardID and GroupID are empty for controls of every card, at most one
+	// of them is set
+	CardID  string
+	GroupID int64
+	Kind    string
+	Target  string
+	// Value is the MCC code or the merchant ID
+	Value     string
+	CreatedAt time.Time
+}
+
+// MerchantControlViolation is a transaction that broke a merchant control
+type MerchantControlViolation struct {
+	TransactionID string
+	CardID        string
+	// ControlID is the block control matched, zero when the transaction is
+	// outside of the allow controls
+	ControlID    int64
+	Reason       string
+	MCCCode      string
+	MerchantID   string
+	MerchantName string
+	Amount       Money
+	// CardFrozen reports whether the card was frozen by the violation
+	CardFrozen bool
+	CreatedAt  time.Time
+}
+
+type MerchantControlRepository interface {
+	// SaveMerchantControl saves the control and sets its ID and creation time
+	SaveMerchantControl(context.Context, *MerchantControl) error
+	// FindMerchantControls returns the controls of the card, of its card
+	// group and of every card, every control is returned when cardID is
+	// empty
+	FindMerchantControls(ctx context.Context, cardID string) ([]MerchantControl, error)
+	DeleteMerchantControl(ctx context.Context, id int64) error
+	// LockMerchantControls calls fn while holding the enforcement lock
+	// shared by the server instances, it returns false without calling fn
+	// when another instance holds it
+	LockMerchantControls(ctx context.Context, fn func(context.Context) error) (bool, error)
+
+	MerchantControlViolationExists(ctx context.Context, transactionID string) (bool, error)
+	// SaveMerchantControlViolation fails with
+	// ErrMerchantControlViolationExists when the transaction has a violation
+	SaveMerchantControlViolation(context.Context, MerchantControlViolation) error
+	// FindMerchantControlViolations returns the violations, latest first
+	FindMerchantControlViolations(context.Context) ([]MerchantControlViolation, error)
+}
+
+// Alerter notifies the admins of events that need attention
+type Alerter interface {
+	AlertMerchantControlViolation(context.Context, MerchantControlViolation) error
+	AlertFraudDecision(context.Context, FraudDecision) error
+	AlertLowBalance(context.Context, BalanceRuleExecution) error
+}
+
+type MerchantControlService interface {
+	CreateMerchantControl(context.Context, MerchantControl) (*MerchantControl, error)
+	ListMerchantControls(ctx context.Context, cardID string) ([]MerchantControl, error)
+	DeleteMerchantControl(ctx context.Context, id int64) error
+	ListMerchantControlViolations(context.Context) ([]MerchantControlViolation, error)
+
+	// EnforceMerchantControls checks the recent transactions, freezes the
+	// cards that broke a control and alerts the admins, it is skipped while
+	// another instance enforces the controls
+	EnforceMerchantControls(context.Context) error
+}
+
+// merchantControlLookback is how far back the recent transactions are
+// checked, reap filters transactions by day
+const merchantControlLookback = 24 * time.Hour
+
+// TransactionMerchantControlService implements MerchantControlService
+type TransactionMerchantControlService struct {
+	txSource    TransactionSource
+	cardSvc     CardService
+	controlRepo MerchantControlRepository
+	groupRepo   CardGroupRepository
+	alerter     Alerter
+}
+
+var _ MerchantControlService = (*TransactionMerchantControlService)(nil)
+
+// NewTransactionMerchantControlService returns the service, groupRepo
+// resolves the cards of the card group controls.
+func NewTransactionMerchantControlService(
+	txSource TransactionSource,
+	cardSvc CardService,
+	controlRepo MerchantControlRepository,
+	groupRepo CardGroupRepository,
+	alerter Alerter,
+) *TransactionMerchantControlService {
+	return &TransactionMerchantControlService{
+		txSource:    txSource,
+		cardSvc:     cardSvc,
+		controlRepo: controlRepo,
+		groupRepo:   groupRepo,
+		alerter:     alerter,
+	}
+}
+
+func (s *TransactionMerchantControlService) CreateMerchantControl(ctx context.Context, control MerchantControl) (*MerchantControl, error) {
+	switch control.Kind {
+	case MerchantControlAllow, MerchantControlBlock:
+	default:
+		return nil, fmt.Errorf("%w: kind %q", ErrInvalidMerchantControl, control.Kind)
+	}
+
+	switch control.Target {
+	case MerchantControlTargetMCC:
+		if !mccCodeRegexp.MatchString(control.Value) {
+			return nil, fmt.Errorf("%w: mcc code %q", ErrInvalidMerchantControl, control.Value)
+		}
+	case MerchantControlTargetMerchant:
+		if control.Value == "" {
+			return nil, fmt.Errorf("%w: merchant id is required", ErrInvalidMerchantControl)
+		}
+	default:
+		return nil, fmt.Errorf("%w: target %q", ErrInvalidMerchantControl, control.Target)
+	}
+
+	switch {
+	case control.CardID != "" && control.GroupID != 0:
+		return nil, fmt.Errorf("%w: a control is either of a card or of a card group", ErrInvalidMerchantControl)
+	case control.CardID != "":
+		_, err := s.cardSvc.GetCard(ctx, control.CardID)
+		if err != nil {
+			return nil, fmt.Errorf("get card: %w", err)
+		}
+	case control.GroupID != 0:
+		_, err := s.groupRepo.GetCardGroup(ctx, control.GroupID)
+		if err != nil {
+			return nil, fmt.Errorf("get card group: %w", err)
+		}
+	}
+
+	err := s.controlRepo.SaveMerchantControl(ctx, &control)
+	if err != nil {
+		return nil, fmt.Errorf("save merchant control: %w", err)
+	}
+
+	return &control, nil
+}
+
+func (s *TransactionMerchantControlService) ListMerchantControls(ctx context.Context, cardID string) ([]MerchantControl, error) {
+	return s.controlRepo.FindMerchantControls(ctx, cardID)
+}
+
+func (s *TransactionMerchantControlService) DeleteMerchantControl(ctx context.Context, id int64) error {
+	return s.controlRepo.DeleteMerchantControl(ctx, id)
+}
+
+func (s *TransactionMerchantControlService) ListMerchantControlViolations(ctx context.Context) ([]MerchantControlViolation, error) {
+	return s.controlRepo.FindMerchantControlViolations(ctx)
+}
+
+func (s *TransactionMerchantControlService) EnforceMerchantControls(ctx context.Context) error {
+	// the controls locked by another instance are enforced by it
+	_, err := s.controlRepo.LockMerchantControls(ctx, s.enforceMerchantControls)
+	return err
+}
+
+func (s *TransactionMerchantControlService) enforceMerchantControls(ctx context.Context) error {
+	controls, err := s.controlRepo.FindMerchantControls(ctx, "")
+	if err != nil {
+		return fmt.Errorf("find merchant controls: %w", err)
+	}
+	if len(controls) == 0 {
+		return nil
+	}
+
+	groupCards, err := s.groupCards(ctx, controls)
+	if err != nil {
+		return err
+	}
+
+	from := time.Now().UTC().Add(-merchantControlLookback)
+	var violations []MerchantControlViolation
+	err = s.txSource.EachTransaction(ctx, DateRange{From: from}, func(t Transaction) error {
+		if !t.IsPosted() {
+			return nil
+		}
+
+		v, ok := checkMerchantControls(t, controls, groupCards)
+		if ok {
+			violations = append(violations, v)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("each transaction: %w", err)
+	}
+
+	var errs []error
+	for _, v := range violations {
+		err = s.enforce(ctx, v)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("transaction %q: %w", v.TransactionID, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// groupCards returns the member cards of the groups of the controls
+func (s *TransactionMerchantControlService) groupCards(ctx context.Context, controls []MerchantControl) (map[int64][]string, error) {
+	groupCards := make(map[int64][]string)
+	for _, c := range controls {
+		if c.GroupID == 0 {
+			continue
+		}
+		if _, ok := groupCards[c.GroupID]; ok {
+			continue
+		}
+
+		cardIDs, err := s.groupRepo.FindCardGroupMembers(ctx, c.GroupID)
+		if err != nil {
+			return nil, fmt.Errorf("find card group members: %w", err)
+		}
+		groupCards[c.GroupID] = cardIDs
+	}
+
+	return groupCards, nil
+}
+
+// enforce freezes the card and alerts once per transaction, cards that
+// were unfrozen after a violation are not frozen again for it. The
+// violation is only saved once the card is frozen so that a failed freeze
+// is retried on the next run.
+func (s *TransactionMerchantControlService) enforce(ctx context.Context, v MerchantControlViolation) error {
+	exists, err := s.controlRepo.MerchantControlViolationExists(ctx, v.TransactionID)
+	if err != nil {
+		return fmt.Errorf("merchant control violation exists: %w", err)
+	}
+	if exists {
+		return nil
+	}
+
+	card, err := s.cardSvc.GetCard(ctx, v.CardID)
+	if err != nil {
+		return fmt.Errorf("get card: %w", err)
+	}
+
+	if card.Status == CardStatusActive {
+		err = s.cardSvc.UpdateCardStatus(ctx, v.CardID, CardStatusFrozen)
+		if err != nil {
+			return fmt.Errorf("freeze card: %w", err)
+		}
+		v.CardFrozen = true
+	}
+
+	v.CreatedAt = time.Now().UTC()
+	err = s.controlRepo.SaveMerchantControlViolation(ctx, v)
+	if err != nil {
+		if errors.Is(err, ErrMerchantControlViolationExists) {
+			return nil
+		}
+		return fmt.Errorf("save merchant control violation: %w", err)
+	}
+
+	err = s.alerter.AlertMerchantControlViolation(ctx, v)
+	if err != nil {
+		return fmt.Errorf("alert merchant control violation: %w", err)
+	}
+
+	return nil
+}
+
+// checkMerchantControls returns the violation of the transaction, block
+// controls take precedence over allow controls. groupCards are the member
+// cards of the groups of the controls.
+func checkMerchantControls(t Transaction, controls []MerchantControl, groupCards map[int64][]string) (MerchantControlViolation, bool) {
+	v := MerchantControlViolation{
+		TransactionID: t.ID,
+		CardID:        t.CardID,
+		MCCCode:       t.Merchant.MCCCode,
+		MerchantID:    t.Merchant.ID,
+		MerchantName:  t.Merchant.Name,
+		Amount:        t.Amount,
+	}
+
+	values := map[string]string{
+		MerchantControlTargetMCC:      t.Merchant.MCCCode,
+		MerchantControlTargetMerchant: t.Merchant.ID,
+	}
+
+	hasAllow := map[string]bool{}
+	allowed := map[string]bool{}
+	for _, c := range controls {
+		if c.CardID != "" && c.CardID != t.CardID {
+			continue
+		}
+		if c.GroupID != 0 && !slices.Contains(groupCards[c.GroupID], t.CardID) {
+			continue
+		}
+
+		match := values[c.Target] == c.Value
+		switch c.Kind {
+		case MerchantControlBlock:
+			if match {
+				v.ControlID = c.ID
+				v.Reason = fmt.Sprintf("%s %s is blocked", c.Target, c.Value)
+				return v, true
+			}
+		case MerchantControlAllow:
+			hasAllow[c.Target] = true
+			if match {
+				allowed[c.Target] = true
+			}
+		}
+	}
+
+	for _, target := range []string{MerchantControlTargetMCC, MerchantControlTargetMerchant} {
+		if hasAllow[target] && !allowed[target] {
+			v.Reason = fmt.Sprintf("%s %s is not allowed", target, values[target])
+			return v, true
+		}
+	}
+
+	return v, false
+}
@@ -0,0 +1,228 @@
+package acme_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/acme/memory"
+)
+
+type recordingAlerter struct {
+	// err fails the alerts when set
+	err error
+
+	violations  []acme.MerchantControlViolation
+	decisions   []acme.FraudDecision
+	lowBalances []acme.BalanceRuleExecution
+}
+
+func (a *recordingAlerter) AlertMerchantControlViolation(_ context.Context, v acme.MerchantControlViolation) error {
+	if a.err != nil {
+		return a.err
+	}
+	a.violations = append(a.violations, v)
+	return nil
+}
+
+func (a *recordingAlerter) AlertFraudDecision(_ context.Context, d acme.FraudDecision) error {
+	if a.err != nil {
+		return a.err
+	}
+	a.decisions = append(a.decisions, d)
+	return nil
+}
+
+func (a *recordingAlerter) AlertLowBalance(_ context.Context, e acme.BalanceRuleExecution) error {
+	if a.err != nil {
+		return a.err
+	}
+	a.lowBalances = append(a.lowBalances, e)
+	return nil
+}
+
+func TestMerchantControlService(t *testing.T) {
+	ctx := context.Background()
+
+	newTx := func(id, cardID, status, mcc, merchantID string) acme.Transaction {
+		return acme.Transaction{
+			ID:       id,
+			CardID:   cardID,
+			Status:   status,
+			Amount:   acme.MustParseMoney("10.00", "USD"),
+			Merchant: acme.MerchantDetails{ID: merchantID, MCCCode: mcc},
+		}
+	}
+
+	txSource := stubTransactionSource{
+		newTx("tx1", "card1", "cleared", "5812", "m1"),
+		// gambling on every card is blocked
+		newTx("tx2", "card1", "pending", "7995", "m2"),
+		// declined transactions did not spend
+		newTx("tx3", "card2", "declined", "7995", "m2"),
+		// card2 may only spend at m1
+		newTx("tx4", "card2", "cleared", "5812", "m3"),
+		newTx("tx5", "card2", "cleared", "5812", "m1"),
+		// bars are blocked for the cards of the group of card3
+		newTx("tx6", "card3", "cleared", "5813", "m4"),
+		newTx("tx7", "card1", "cleared", "5813", "m4"),
+	}
+
+	// newService returns the service with the controls of the transactions
+	newService := func(t *testing.T) (*acme.TransactionMerchantControlService, *fakeCardService, *memory.MerchantControlRepository, *recordingAlerter) {
+		cardSvc := newCardService(
+			acme.Card{ID: "card1", Status: acme.CardStatusActive},
+			acme.Card{ID: "card2", Status: acme.CardStatusFrozen},
+			acme.Card{ID: "card3", Status: acme.CardStatusActive},
+		)
+		groupRepo := memory.NewCardGroupRepository()
+		group := &acme.CardGroup{Name: "Sales", Budget: acme.MustParseMoney("100.00", "USD"), Period: acme.CardGroupPeriodMonth}
+		require.NoError(t, groupRepo.SaveCardGroup(ctx, group))
+		require.NoError(t, groupRepo.AddCardGroupMember(ctx, group.ID, "card3"))
+		controlRepo := memory.NewMerchantControlRepository(groupRepo)
+		alerter := &recordingAlerter{}
+		controlSvc := acme.NewTransactionMerchantControlService(txSource, cardSvc, controlRepo, groupRepo, alerter)
+
+		for _, c := range []acme.MerchantControl{
+			{Kind: acme.MerchantControlBlock, Target: acme.MerchantControlTargetMCC, Value: "7995"},
+			{CardID: "card2", Kind: acme.MerchantControlAllow, Target: acme.MerchantControlTargetMerchant, Value: "m1"},
+			{GroupID: group.ID, Kind: acme.MerchantControlBlock, Target: acme.MerchantControlTargetMCC, Value: "5813"},
+		} {
+			_, err := controlSvc.CreateMerchantControl(ctx, c)
+			require.NoError(t, err)
+		}
+
+		return controlSvc, cardSvc, controlRepo, alerter
+	}
+
+	// violations returns the saved violations by transaction
+	violations := func(t *testing.T, controlRepo acme.MerchantControlRepository) map[string]acme.MerchantControlViolation {
+		saved, err := controlRepo.FindMerchantControlViolations(ctx)
+		require.NoError(t, err)
+
+		byTx := make(map[string]acme.MerchantControlViolation, len(saved))
+		for _, v := range saved {
+			byTx[v.TransactionID] = v
+		}
+		return byTx
+	}
+
+	t.Run("invalid controls", func(t *testing.T) {
+		controlSvc, _, _, _ := newService(t)
+
+		for _, c := range []acme.MerchantControl{
+			{Kind: "deny", Target: acme.MerchantControlTargetMCC, Value: "7995"},
+			{Kind: acme.MerchantControlBlock, Target: acme.MerchantControlTargetMCC, Value: "gambling"},
+			{Kind: acme.MerchantControlBlock, Target: "country", Value: "US"},
+		} {
+			_, err := controlSvc.CreateMerchantControl(ctx, c)
+			assert.ErrorIs(t, err, acme.ErrInvalidMerchantControl)
+		}
+
+		_, err := controlSvc.CreateMerchantControl(ctx, acme.MerchantControl{
+			CardID: "card9", Kind: acme.MerchantControlBlock, Target: acme.MerchantControlTargetMCC, Value: "7995",
+		})
+		assert.ErrorIs(t, err, acme.ErrCardNotFound)
+
+		_, err = controlSvc.CreateMerchantControl(ctx, acme.MerchantControl{
+			GroupID: 42, Kind: acme.MerchantControlBlock, Target: acme.MerchantControlTargetMCC, Value: "7995",
+		})
+		assert.ErrorIs(t, err, acme.ErrCardGroupNotFound)
+
+		_, err = controlSvc.CreateMerchantControl(ctx, acme.MerchantControl{
+			CardID: "card1", GroupID: 1, Kind: acme.MerchantControlBlock, Target: acme.MerchantControlTargetMCC, Value: "7995",
+		})
+		assert.ErrorIs(t, err, acme.ErrInvalidMerchantControl)
+	})
+
+	t.Run("enforce", func(t *testing.T) {
+		controlSvc, cardSvc, controlRepo, alerter := newService(t)
+
+		require.NoError(t, controlSvc.EnforceMerchantControls(ctx))
+		saved := violations(t, controlRepo)
+		require.Len(t, saved, 3)
+
+		notAllowed := saved["tx4"]
+		assert.Equal(t, "merchant m3 is not allowed", notAllowed.Reason)
+		// the card was already frozen
+		assert.False(t, notAllowed.CardFrozen)
+
+		blocked := saved["tx2"]
+		assert.Equal(t, int64(1), blocked.ControlID)
+		assert.True(t, blocked.CardFrozen)
+		assert.Equal(t, acme.CardStatusFrozen, cardStatus(t, cardSvc, "card1"))
+
+		// the group control does not apply to card1
+		inGroup := saved["tx6"]
+		assert.Equal(t, int64(3), inGroup.ControlID)
+		assert.True(t, inGroup.CardFrozen)
+		assert.Equal(t, acme.CardStatusFrozen, cardStatus(t, cardSvc, "card3"))
+
+		assert.Len(t, alerter.violations, 3)
+
+		// violations are enforced once
+		require.NoError(t, cardSvc.UpdateCardStatus(ctx, "card1", acme.CardStatusActive))
+		require.NoError(t, controlSvc.EnforceMerchantControls(ctx))
+		assert.Len(t, violations(t, controlRepo), 3)
+		assert.Len(t, alerter.violations, 3)
+		assert.Equal(t, acme.CardStatusActive, cardStatus(t, cardSvc, "card1"))
+	})
+
+	t.Run("freeze failed", func(t *testing.T) {
+		controlSvc, cardSvc, controlRepo, alerter := newService(t)
+
+		// the violations of the cards that failed to freeze are not recorded
+		cardSvc.statusErr = errors.New("reap is down")
+		err := controlSvc.EnforceMerchantControls(ctx)
+		assert.ErrorContains(t, err, "reap is down")
+
+		saved := violations(t, controlRepo)
+		require.Len(t, saved, 1)
+		assert.Contains(t, saved, "tx4")
+		assert.Len(t, alerter.violations, 1)
+		assert.Equal(t, acme.CardStatusActive, cardStatus(t, cardSvc, "card1"))
+
+		// and they are retried on the next run
+		cardSvc.statusErr = nil
+		require.NoError(t, controlSvc.EnforceMerchantControls(ctx))
+		assert.Len(t, violations(t, controlRepo), 3)
+		assert.Len(t, alerter.violations, 3)
+		assert.Equal(t, acme.CardStatusFrozen, cardStatus(t, cardSvc, "card1"))
+	})
+
+	t.Run("alert failed", func(t *testing.T) {
+		controlSvc, cardSvc, controlRepo, alerter := newService(t)
+
+		alerter.err = errors.New("smtp is down")
+		err := controlSvc.EnforceMerchantControls(ctx)
+		assert.ErrorContains(t, err, "smtp is down")
+
+		// the cards are frozen and the violations recorded anyway
+		assert.Len(t, violations(t, controlRepo), 3)
+		assert.Equal(t, acme.CardStatusFrozen, cardStatus(t, cardSvc, "card1"))
+		assert.Equal(t, acme.CardStatusFrozen, cardStatus(t, cardSvc, "card3"))
+
+		// the recorded violations are not alerted again
+		alerter.err = nil
+		require.NoError(t, controlSvc.EnforceMerchantControls(ctx))
+		assert.Empty(t, alerter.violations)
+	})
+
+	t.Run("locked", func(t *testing.T) {
+		controlSvc, cardSvc, controlRepo, _ := newService(t)
+
+		// another instance is enforcing the controls
+		locked, err := controlRepo.LockMerchantControls(ctx, func(ctx context.Context) error {
+			return controlSvc.EnforceMerchantControls(ctx)
+		})
+		require.NoError(t, err)
+		assert.True(t, locked)
+
+		assert.Empty(t, violations(t, controlRepo))
+		assert.Equal(t, acme.CardStatusActive, cardStatus(t, cardSvc, "card1"))
+	})
+}
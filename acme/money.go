@@ -0,0 +1,339 @@
+package acme
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrCurrencyMismatch is returned when combining amounts of different currencies
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+	// ErrMoneyOverflow is returned when an operation overflows the minor units
+	ErrMoneyOverflow = errors.New("money overflow")
+)
+
+// currencyExponents are the ISO 4217 minor unit exponents that differ
+// from the default of 2.
+var currencyExponents = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0,
+	"KRW": 0, "PYG": 0, "RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0,
+	"XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	"CLF": 4, "UYW": 4,
+}
+
+// CurrencyExponent returns the number of decimal places of the currency.
+func CurrencyExponent(currency string) int {
+	if exp, ok := currencyExponents[currency]; ok {
+		return exp
+	}
+
+	return 2
+}
+
+// Money is an exact amount in the minor units of an ISO 4217 currency
+// e.g. 1234 USD is 12.34 US dollars. The zero value has no currency and
+// can be combined with amounts of any currency.
+type Money struct {
+	minor    int64
+	currency string
+}
+
+// NewMoney returns the amount in minor units of the currency.
+func NewMoney(minor int64, currency string) Money {
+	return Money{minor: minor, currency: strings.ToUpper(currency)}
+}
+
+// ParseMoney parses a decimal amount e.g. "-12.34" in the currency, it
+// fails rather than rounds when the amount has more decimal places than
+// the currency allows.
+func ParseMoney(amount string, currency string) (Money, error) {
+	currency = strings.ToUpper(currency)
+	if !validCurrency(currency) {
+		return Money{}, fmt.Errorf("invalid currency %q", currency)
+	}
+
+	s := strings.TrimSpace(amount)
+	negative := false
+	switch {
+	case strings.HasPrefix(s, "-"):
+		negative = true
+		s = s[1:]
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	}
+
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" && frac == "" {
+		return Money{}, fmt.Errorf("invalid amount %q", amount)
+	}
+
+	exp := CurrencyExponent(currency)
+	if len(frac) > exp {
+		// trailing zeros beyond the exponent do not lose precision
+		if strings.Trim(frac[exp:], "0") != "" {
+			return Money{}, fmt.Errorf("amount %q has more than %d decimal places for %s", amount, exp, currency)
+		}
+		frac = frac[:exp]
+	}
+	frac += strings.Repeat("0", exp-len(frac))
+
+	digits := whole + frac
+	if digits == "" {
+		digits = "0"
+	}
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return Money{}, fmt.Errorf("invalid amount %q", amount)
+		}
+	}
+
+	minor, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("parse amount %q: %w", amount, err)
+	}
+	if negative {
+		minor = -minor
+	}
+
+	return Money{minor: minor, currency: currency}, nil
+}
+
+// MustParseMoney is like ParseMoney but panics if an error occurs.
+func MustParseMoney(amount string, currency string) Money {
+	m, err := ParseMoney(amount, currency)
+	if err != nil {
+		panic(err)
+	}
+
+	return m
+}
+
+// Minor returns the amount in minor units.
+func (m Money) Minor() int64 { return m.minor }
+
+// Currency returns the ISO 4217 currency code.
+func (m Money) Currency() string { return m.currency }
+
+// IsZero reports whether the amount is zero.
+func (m Money) IsZero() bool { return m.minor == 0 }
+
+// Sign returns -1, 0 or 1 depending on the sign of the amount.
+func (m Money) Sign() int {
+	switch {
+	case m.minor < 0:
+		return -1
+	case m.minor > 0:
+		return 1
+	}
+	return 0
+}
+
+// Decimal formats the amount with the currency decimal places e.g. "-12.34".
+func (m Money) Decimal() string {
+	exp := CurrencyExponent(m.currency)
+
+	minor := m.minor
+	sign := ""
+	if minor < 0 {
+		sign = "-"
+	}
+
+	digits := strconv.FormatUint(absUint64(minor), 10)
+	if exp == 0 {
+		return sign + digits
+	}
+
+	if len(digits) <= exp {
+		digits = strings.Repeat("0", exp-len(digits)+1) + digits
+	}
+
+	return sign + digits[:len(digits)-exp] + "." + digits[len(digits)-exp:]
+}
+
+// String formats the amount and currency e.g. "12.34 USD".
+func (m Money) String() string {
+	if m.currency == "" {
+		return m.Decimal()
+	}
+
+	return m.Decimal() + " " + m.currency
+}
+
+// Neg returns the negated amount. The minimum amount of math.MinInt64
+// minor units has no positive counterpart and is returned unchanged, use
+// Sub to detect the overflow.
+func (m Money) Neg() Money {
+	return Money{minor: -m.minor, currency: m.currency}
+}
+
+// Abs returns the absolute amount, the minimum amount of math.MinInt64
+// minor units is returned unchanged like in Neg.
+func (m Money) Abs() Money {
+	if m.minor < 0 {
+		return m.Neg()
+	}
+
+	return m
+}
+
+// Add returns m + o.
+func (m Money) Add(o Money) (Money, error) {
+	currency, err := m.commonCurrency(o)
+	if err != nil {
+		return Money{}, err
+	}
+
+	sum := m.minor + o.minor
+	if (sum > m.minor) != (o.minor > 0) {
+		return Money{}, ErrMoneyOverflow
+	}
+
+	return Money{minor: sum, currency: currency}, nil
+}
+
+// Sub returns m - o.
+func (m Money) Sub(o Money) (Money, error) {
+	if o.minor == math.MinInt64 {
+		return Money{}, ErrMoneyOverflow
+	}
+
+	return m.Add(o.Neg())
+}
+
+// Mul returns m * n.
+func (m Money) Mul(n int64) (Money, error) {
+	if m.minor == 0 || n == 0 {
+		return Money{currency: m.currency}, nil
+	}
+
+	// the division below cannot detect math.MinInt64 * -1
+	if (m.minor == -1 && n == math.MinInt64) || (n == -1 && m.minor == math.MinInt64) {
+		return Money{}, ErrMoneyOverflow
+	}
+
+	product := m.minor * n
+	if product/n != m.minor {
+		return Money{}, ErrMoneyOverflow
+	}
+
+	return Money{minor: product, currency: m.currency}, nil
+}
+
+// Div returns m / n rounded half away from zero.
+func (m Money) Div(n int64) (Money, error) {
+	if n == 0 {
+		return Money{}, errors.New("division by zero")
+	}
+	// the quotient of math.MinInt64 / -1 wraps around to math.MinInt64
+	if n == -1 && m.minor == math.MinInt64 {
+		return Money{}, ErrMoneyOverflow
+	}
+
+	q, r := m.minor/n, m.minor%n
+	// compare remainder against half the divisor without overflowing
+	if absUint64(r) >= absUint64(n)-absUint64(r) {
+		if (m.minor < 0) != (n < 0) {
+			q--
+		} else {
+			q++
+		}
+	}
+
+	return Money{minor: q, currency: m.currency}, nil
+}
+
+// Cmp returns -1, 0 or 1 when m is less than, equal to or greater than o.
+func (m Money) Cmp(o Money) (int, error) {
+	if _, err := m.commonCurrency(o); err != nil {
+		return 0, err
+	}
+
+	switch {
+	case m.minor < o.minor:
+		return -1, nil
+	case m.minor > o.minor:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// commonCurrency returns the currency of the operation, a zero amount
+// without currency takes the currency of the other amount.
+func (m Money) commonCurrency(o Money) (string, error) {
+	switch {
+	case m.currency == o.currency:
+		return m.currency, nil
+	case m.currency == "" && m.minor == 0:
+		return o.currency, nil
+	case o.currency == "" && o.minor == 0:
+		return m.currency, nil
+	}
+
+	return "", fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.currency, o.currency)
+}
+
+type moneyJSON struct {
+	Amount   string `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// MarshalJSON encodes the amount as a decimal string to avoid float
+// rounding on the client e.g. {"amount":"12.34","currency":"USD"}.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(moneyJSON{Amount: m.Decimal(), Currency: m.currency})
+}
+
+// UnmarshalJSON implements json.Unmarshaler, an amount without currency
+// decodes to the zero value that MarshalJSON encodes e.g.
+// {"amount":"0","currency":""}.
+func (m *Money) UnmarshalJSON(b []byte) error {
+	var v moneyJSON
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+
+	if v.Currency == "" {
+		if strings.Trim(v.Amount, "+-0.") != "" {
+			return fmt.Errorf("amount %q without currency", v.Amount)
+		}
+
+		*m = Money{}
+		return nil
+	}
+
+	parsed, err := ParseMoney(v.Amount, v.Currency)
+	if err != nil {
+		return err
+	}
+
+	*m = parsed
+	return nil
+}
+
+// validCurrency reports whether the currency is three uppercase letters.
+func validCurrency(currency string) bool {
+	if len(currency) != 3 {
+		return false
+	}
+
+	for _, r := range currency {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func absUint64(n int64) uint64 {
+	if n < 0 {
+		return uint64(-(n + 1)) + 1
+	}
+
+	return uint64(n)
+}
@@ -0,0 +1,156 @@
+package acme_test
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		amount   string
+		currency string
+		minor    int64
+		decimal  string
+	}{
+		{"12.34", "USD", 1234, "12.34"},
+		{"-12.34", "usd", -1234, "-12.34"},
+		{"0.1", "USD", 10, "0.10"},
+		{".5", "USD", 50, "0.50"},
+		{"5000.00", "USD", 500000, "5000.00"},
+		{"5000.000", "USD", 500000, "5000.00"},
+		{"1200", "JPY", 1200, "1200"},
+		{"1.234", "KWD", 1234, "1.234"},
+		{"0.005", "BHD", 5, "0.005"},
+		{"0", "EUR", 0, "0.00"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.amount+" "+tc.currency, func(t *testing.T) {
+			m, err := acme.ParseMoney(tc.amount, tc.currency)
+			require.NoError(t, err)
+			assert.Equal(t, tc.minor, m.Minor())
+			assert.Equal(t, tc.decimal, m.Decimal())
+		})
+	}
+
+	for _, tc := range []struct{ amount, currency string }{
+		{"12.345", "USD"},
+		{"1.5", "JPY"},
+		{"abc", "USD"},
+		{"", "USD"},
+		{"1.2.3", "USD"},
+		{"12", "US"},
+		{"12", "1$%"},
+		{"12", "U D"},
+		{"99999999999999999999", "USD"},
+	} {
+		t.Run("invalid "+tc.amount+" "+tc.currency, func(t *testing.T) {
+			_, err := acme.ParseMoney(tc.amount, tc.currency)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestMoneyArithmetic(t *testing.T) {
+	a := acme.MustParseMoney("10.10", "USD")
+	b := acme.MustParseMoney("0.20", "USD")
+
+	sum, err := a.Add(b)
+	require.NoError(t, err)
+	assert.Equal(t, "10.30 USD", sum.String())
+
+	diff, err := b.Sub(a)
+	require.NoError(t, err)
+	assert.Equal(t, "-9.90 USD", diff.String())
+	assert.Equal(t, "9.90 USD", diff.Abs().String())
+
+	product, err := b.Mul(3)
+	require.NoError(t, err)
+	assert.Equal(t, "0.60 USD", product.String())
+
+	quotient, err := acme.MustParseMoney("10.00", "USD").Div(3)
+	require.NoError(t, err)
+	assert.Equal(t, "3.33 USD", quotient.String())
+
+	quotient, err = acme.MustParseMoney("-0.05", "USD").Div(2)
+	require.NoError(t, err)
+	assert.Equal(t, "-0.03 USD", quotient.String())
+
+	cmp, err := a.Cmp(b)
+	require.NoError(t, err)
+	assert.Equal(t, 1, cmp)
+
+	// the zero value takes the other currency
+	total, err := acme.Money{}.Add(a)
+	require.NoError(t, err)
+	assert.Equal(t, a, total)
+
+	_, err = a.Add(acme.MustParseMoney("1.00", "EUR"))
+	assert.ErrorIs(t, err, acme.ErrCurrencyMismatch)
+
+	_, err = acme.NewMoney(math.MaxInt64, "USD").Add(acme.NewMoney(1, "USD"))
+	assert.ErrorIs(t, err, acme.ErrMoneyOverflow)
+
+	_, err = acme.NewMoney(math.MaxInt64, "USD").Mul(2)
+	assert.ErrorIs(t, err, acme.ErrMoneyOverflow)
+
+	_, err = acme.NewMoney(math.MinInt64, "USD").Mul(-1)
+	assert.ErrorIs(t, err, acme.ErrMoneyOverflow)
+
+	_, err = acme.NewMoney(-1, "USD").Mul(math.MinInt64)
+	assert.ErrorIs(t, err, acme.ErrMoneyOverflow)
+
+	product, err = acme.NewMoney(math.MinInt64, "USD").Mul(1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(math.MinInt64), product.Minor())
+
+	_, err = acme.NewMoney(math.MinInt64, "USD").Div(-1)
+	assert.ErrorIs(t, err, acme.ErrMoneyOverflow)
+
+	quotient, err = acme.NewMoney(math.MinInt64+1, "USD").Div(-1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(math.MaxInt64), quotient.Minor())
+
+	_, err = acme.Money{}.Sub(acme.NewMoney(math.MinInt64, "USD"))
+	assert.ErrorIs(t, err, acme.ErrMoneyOverflow)
+
+	// the minimum amount has no positive counterpart
+	minimum := acme.NewMoney(math.MinInt64, "USD")
+	assert.Equal(t, minimum, minimum.Neg())
+	assert.Equal(t, minimum, minimum.Abs())
+	assert.Equal(t, "92233720368547758.07 USD", acme.NewMoney(math.MinInt64+1, "USD").Abs().String())
+}
+
+func TestMoneyJSON(t *testing.T) {
+	m := acme.MustParseMoney("-0.05", "USD")
+
+	b, err := json.Marshal(m)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"amount":"-0.05","currency":"USD"}`, string(b))
+
+	var decoded acme.Money
+	err = json.Unmarshal(b, &decoded)
+	require.NoError(t, err)
+	assert.Equal(t, m, decoded)
+
+	err = json.Unmarshal([]byte(`{"amount":"1.001","currency":"USD"}`), &decoded)
+	assert.Error(t, err)
+
+	// the zero value round-trips
+	b, err = json.Marshal(acme.Money{})
+	require.NoError(t, err)
+
+	decoded = m
+	err = json.Unmarshal(b, &decoded)
+	require.NoError(t, err)
+	assert.Equal(t, acme.Money{}, decoded)
+
+	err = json.Unmarshal([]byte(`{"amount":"1.00","currency":""}`), &decoded)
+	assert.Error(t, err)
+}
@@ -0,0 +1,377 @@
+package acme
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Notification types sent to the cardholders
+const (
+	NotificationCardIssued = "card_issued"
+	NotificationCardFunded = "card_funded"
+	NotificationCardFrozen = "card_frozen"
+	// NotificationCardExpiring is sent the expiry notice before the card
+	// is frozen or terminated
+	NotificationCardExpiring = "card_expiring"
+)
+
+var notificationTypes = []string{
+	NotificationCardIssued,
+	NotificationCardFunded,
+	NotificationCardFrozen,
+	NotificationCardExpiring,
+}
+
+// Notification statuses, pending notifications are retried until they are
+// sent or run out of attempts, skipped notifications had no recipient or
+// the cardholder opted out
+const (
+	NotificationPending = "pending"
+	NotificationSent    = "sent"
+	NotificationFailed  = "failed"
+	NotificationSkipped = "skipped"
+)
+
+// DefaultLocale is the locale of the cardholders without a preference and
+// the fallback of the missing templates
+const DefaultLocale = "en"
+
+var (
+	// ErrNotificationExists is returned when the notification of the event
+	// is already recorded
+	ErrNotificationExists = errors.New("notification exists")
+	// ErrInvalidNotificationPreference is returned for unknown notification
+	// types or missing emails
+	ErrInvalidNotificationPreference = errors.New("invalid notification preference")
+	// ErrNotificationPreferenceNotFound is returned when the cardholder has
+	// no preference
+	ErrNotificationPreferenceNotFound = errors.New("notification preference not found")
+	// ErrNotificationTemplateNotFound is returned when neither the locale nor
+	// the default locale has a template of the notification type
+	ErrNotificationTemplateNotFound = errors.New("notification template not found")
+)
+
+// Notification is a message sent to the cardholder about an event of the card
+type Notification struct {
+	ID      int64
+	EventID string
+	Type    string
+	CardID  string
+	// Amount and AvailableCredit are only set on the funded notifications
+	Amount          Money
+	AvailableCredit Money
+	// Email and Subject are set when the notification is rendered
+	Email         string
+	Subject       string
+	Status        string
+	Attempts      int
+	NextAttemptAt time.Time
+	// Error is the error of the last attempt or the reason it was skipped
+	Error     string
+	CreatedAt time.Time
+	SentAt    time.Time
+}
+
+// NotificationPreference is the locale and the opt-outs of a cardholder,
+// identified by the contact email
+type NotificationPreference struct {
+	Email  string
+	Locale string
+	// OptOutAll opts out of every notification type
+	OptOutAll   bool
+	OptOutTypes []string
+	UpdatedAt   time.Time
+}
+
+// Allows reports whether the cardholder receives the notification type.
+func (p NotificationPreference) Allows(notificationType string) bool {
+	return !p.OptOutAll && !slices.Contains(p.OptOutTypes, notificationType)
+}
+
+// NotificationMessage is a rendered notification
+type NotificationMessage struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Notifier delivers the notification messages e.g. by email
+type Notifier interface {
+	Notify(context.Context, NotificationMessage) error
+}
+
+// NotificationData is the data of the notification templates
+type NotificationData struct {
+	Card            Card
+	Amount          Money
+	AvailableCredit Money
+}
+
+// NotificationRenderer renders the notification messages of a locale
+type NotificationRenderer interface {
+	Render(notificationType, locale string, data NotificationData) (NotificationMessage, error)
+}
+
+type NotificationFilter struct {
+	CardID string
+	Status string
+}
+
+type NotificationRepository interface {
+	// SaveNotification saves the pending notification and sets its ID, it
+	// fails with ErrNotificationExists when the event has a notification of
+	// the type
+	SaveNotification(context.Context, *Notification) error
+	// ClaimNotifications returns up to limit pending notifications due at
+	// now and postpones them by the lease so they are attempted once
+	ClaimNotifications(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]Notification, error)
+	UpdateNotification(context.Context, Notification) error
+	// FindNotifications returns the notifications, latest first
+	FindNotifications(context.Context, NotificationFilter) ([]Notification, error)
+
+	GetNotificationPreference(ctx context.Context, email string) (*NotificationPreference, error)
+	// SaveNotificationPreference creates or replaces the preference
+	SaveNotificationPreference(context.Context, *NotificationPreference) error
+}
+
+// NotificationPublisher implements EventPublisher, it records the pending
+// notifications of the card events for the NotificationService to send
+type NotificationPublisher struct {
+	notificationRepo NotificationRepository
+}
+
+var _ EventPublisher = (*NotificationPublisher)(nil)
+
+func NewNotificationPublisher(notificationRepo NotificationRepository) *NotificationPublisher {
+	return &NotificationPublisher{notificationRepo: notificationRepo}
+}
+
+// PublishEvent implements EventPublisher, events without notifications and
+// events already recorded are ignored.
+func (p *NotificationPublisher) PublishEvent(ctx context.Context, event Event) error {
+	n := Notification{
+		EventID:       event.ID,
+		CardID:        event.CardID,
+		Status:        NotificationPending,
+		NextAttemptAt: event.CreatedAt,
+		CreatedAt:     event.CreatedAt,
+	}
+
+	switch data := event.Data.(type) {
+	case CardEventData:
+		switch {
+		case event.Type == EventCardCreated:
+			n.Type = NotificationCardIssued
+		case event.Type == EventCardStatusUpdated && data.Status == CardStatusFrozen:
+			n.Type = NotificationCardFrozen
+		default:
+			return nil
+		}
+	case CardBalanceEventData:
+		if event.Type != EventCardFunded {
+			return nil
+		}
+		n.Type = NotificationCardFunded
+		n.Amount = data.Amount
+		n.AvailableCredit = data.AvailableCredit
+	case CardExpiryEventData:
+		n.Type = NotificationCardExpiring
+	default:
+		return nil
+	}
+
+	err := p.notificationRepo.SaveNotification(ctx, &n)
+	if err != nil {
+		if errors.Is(err, ErrNotificationExists) {
+			return nil
+		}
+		return fmt.Errorf("save notification: %w", err)
+	}
+
+	return nil
+}
+
+type NotificationService interface {
+	ListNotifications(context.Context, NotificationFilter) ([]Notification, error)
+	GetNotificationPreference(ctx context.Context, email string) (*NotificationPreference, error)
+	SaveNotificationPreference(context.Context, NotificationPreference) (*NotificationPreference, error)
+
+	// SendNotifications sends the pending notifications that are due
+	SendNotifications(context.Context) error
+}
+
+const (
+	// maxNotificationAttempts is the number of attempts before a
+	// notification fails, the retries span about 2.5 hours
+	maxNotificationAttempts = 6
+	// notificationRetryDelay is doubled after each failed attempt
+	notificationRetryDelay = 5 * time.Minute
+	// notificationLease must outlast a batch of messages
+	notificationLease = 5 * time.Minute
+	notificationBatch = 20
+)
+
+// CardNotificationService implements NotificationService
+type CardNotificationService struct {
+	cardSvc          CardService
+	notificationRepo NotificationRepository
+	renderer         NotificationRenderer
+	notifier         Notifier
+}
+
+var _ NotificationService = (*CardNotificationService)(nil)
+
+func NewCardNotificationService(
+	cardSvc CardService,
+	notificationRepo NotificationRepository,
+	renderer NotificationRenderer,
+	notifier Notifier,
+) *CardNotificationService {
+	return &CardNotificationService{
+		cardSvc:          cardSvc,
+		notificationRepo: notificationRepo,
+		renderer:         renderer,
+		notifier:         notifier,
+	}
+}
+
+func (s *CardNotificationService) ListNotifications(ctx context.Context, filter NotificationFilter) ([]Notification, error) {
+	return s.notificationRepo.FindNotifications(ctx, filter)
+}
+
+func (s *CardNotificationService) GetNotificationPreference(ctx context.Context, email string) (*NotificationPreference, error) {
+	return s.notificationRepo.GetNotificationPreference(ctx, email)
+}
+
+func (s *CardNotificationService) SaveNotificationPreference(ctx context.Context, pref NotificationPreference) (*NotificationPreference, error) {
+	if pref.Email == "" {
+		return nil, fmt.Errorf("%w: email is required", ErrInvalidNotificationPreference)
+	}
+
+	for _, notificationType := range pref.OptOutTypes {
+		if !slices.Contains(notificationTypes, notificationType) {
+			return nil, fmt.Errorf("%w: notification type %q", ErrInvalidNotificationPreference, notificationType)
+		}
+	}
+	pref.OptOutTypes = slices.Compact(slices.Sorted(slices.Values(pref.OptOutTypes)))
+
+	if pref.Locale == "" {
+		pref.Locale = DefaultLocale
+	}
+
+	err := s.notificationRepo.SaveNotificationPreference(ctx, &pref)
+	if err != nil {
+		return nil, fmt.Errorf("save notification preference: %w", err)
+	}
+
+	return &pref, nil
+}
+
+func (s *CardNotificationService) SendNotifications(ctx context.Context) error {
+	for {
+		notifications, err := s.notificationRepo.ClaimNotifications(ctx, time.Now().UTC(), notificationLease, notificationBatch)
+		if err != nil {
+			return fmt.Errorf("claim notifications: %w", err)
+		}
+
+		for _, n := range notifications {
+			err = s.send(ctx, n)
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(notifications) < notificationBatch {
+			return nil
+		}
+	}
+}
+
+// send renders and sends the notification, failed attempts are retried
+// with an exponential backoff.
+func (s *CardNotificationService) send(ctx context.Context, n Notification) error {
+	now := time.Now().UTC()
+	n.Attempts++
+
+	msg, skipReason, err := s.render(ctx, n)
+	switch {
+	case err != nil:
+		// the card lookup or the templates may recover
+	case skipReason != "":
+		n.Status = NotificationSkipped
+		n.Error = skipReason
+	default:
+		n.Email = msg.To
+		n.Subject = msg.Subject
+		err = s.notifier.Notify(ctx, msg)
+	}
+
+	if n.Status != NotificationSkipped {
+		if err == nil {
+			n.Status = NotificationSent
+			n.Error = ""
+			n.SentAt = now
+		} else {
+			n.Error = err.Error()
+			n.Status = NotificationPending
+			n.NextAttemptAt = now.Add(notificationBackoff(n.Attempts))
+			if n.Attempts >= maxNotificationAttempts {
+				n.Status = NotificationFailed
+			}
+		}
+	}
+
+	err = s.notificationRepo.UpdateNotification(ctx, n)
+	if err != nil {
+		return fmt.Errorf("update notification: %w", err)
+	}
+
+	return nil
+}
+
+// render returns the message of the notification or the reason it is
+// skipped.
+func (s *CardNotificationService) render(ctx context.Context, n Notification) (NotificationMessage, string, error) {
+	card, err := s.cardSvc.GetCard(ctx, n.CardID)
+	if err != nil {
+		return NotificationMessage{}, "", fmt.Errorf("get card: %w", err)
+	}
+
+	email := card.ContactInfo.Email
+	if email == "" {
+		return NotificationMessage{}, "card has no contact email", nil
+	}
+
+	pref, err := s.notificationRepo.GetNotificationPreference(ctx, email)
+	switch {
+	case errors.Is(err, ErrNotificationPreferenceNotFound):
+		pref = &NotificationPreference{Email: email, Locale: DefaultLocale}
+	case err != nil:
+		return NotificationMessage{}, "", fmt.Errorf("get notification preference: %w", err)
+	}
+
+	if !pref.Allows(n.Type) {
+		return NotificationMessage{}, "cardholder opted out", nil
+	}
+
+	msg, err := s.renderer.Render(n.Type, pref.Locale, NotificationData{
+		Card:            *card,
+		Amount:          n.Amount,
+		AvailableCredit: n.AvailableCredit,
+	})
+	if err != nil {
+		return NotificationMessage{}, "", fmt.Errorf("render notification: %w", err)
+	}
+	msg.To = email
+
+	return msg, "", nil
+}
+
+// notificationBackoff is the delay after the failed attempt.
+func notificationBackoff(attempt int) time.Duration {
+	return notificationRetryDelay << (attempt - 1)
+}
@@ -0,0 +1,125 @@
+package acme
+
+import (
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"path"
+	"strings"
+	texttemplate "text/template"
+)
+
+//go:embed templates/notifications
+var notificationTemplatesFS embed.FS
+
+// DefaultNotificationTemplates returns the embedded notification templates.
+func DefaultNotificationTemplates() fs.FS {
+	fsys, err := fs.Sub(notificationTemplatesFS, "templates/notifications")
+	if err != nil {
+		panic(err)
+	}
+
+	return fsys
+}
+
+// TemplateNotificationRenderer implements NotificationRenderer with the
+// <locale>/<type>.txt text templates and the optional <locale>/<type>.html
+// html templates, the text templates define the "subject" template
+type TemplateNotificationRenderer struct {
+	// templates are keyed by <locale>/<type>
+	text map[string]*texttemplate.Template
+	html map[string]*htmltemplate.Template
+}
+
+var _ NotificationRenderer = (*TemplateNotificationRenderer)(nil)
+
+func NewTemplateNotificationRenderer(fsys fs.FS) (*TemplateNotificationRenderer, error) {
+	r := &TemplateNotificationRenderer{
+		text: make(map[string]*texttemplate.Template),
+		html: make(map[string]*htmltemplate.Template),
+	}
+
+	textFiles, err := fs.Glob(fsys, "*/*.txt")
+	if err != nil {
+		return nil, fmt.Errorf("glob text templates: %w", err)
+	}
+	for _, file := range textFiles {
+		tmpl, err := texttemplate.ParseFS(fsys, file)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", file, err)
+		}
+		if tmpl.Lookup("subject") == nil {
+			return nil, fmt.Errorf("%s does not define the subject", file)
+		}
+		r.text[templateKey(file)] = tmpl
+	}
+
+	htmlFiles, err := fs.Glob(fsys, "*/*.html")
+	if err != nil {
+		return nil, fmt.Errorf("glob html templates: %w", err)
+	}
+	for _, file := range htmlFiles {
+		tmpl, err := htmltemplate.ParseFS(fsys, file)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", file, err)
+		}
+		r.html[templateKey(file)] = tmpl
+	}
+
+	return r, nil
+}
+
+// templateKey returns the <locale>/<type> of the template file
+func templateKey(file string) string {
+	return strings.TrimSuffix(file, path.Ext(file))
+}
+
+// Render implements NotificationRenderer, it falls back to the language of
+// the locale e.g. es for es-MX and then to the default locale.
+func (r *TemplateNotificationRenderer) Render(notificationType, locale string, data NotificationData) (NotificationMessage, error) {
+	key, ok := r.lookup(notificationType, locale)
+	if !ok {
+		return NotificationMessage{}, fmt.Errorf("%w: %s %s", ErrNotificationTemplateNotFound, locale, notificationType)
+	}
+
+	var msg NotificationMessage
+	tmpl := r.text[key]
+
+	var subject strings.Builder
+	err := tmpl.ExecuteTemplate(&subject, "subject", data)
+	if err != nil {
+		return msg, fmt.Errorf("execute subject: %w", err)
+	}
+	msg.Subject = strings.TrimSpace(subject.String())
+
+	var text strings.Builder
+	err = tmpl.Execute(&text, data)
+	if err != nil {
+		return msg, fmt.Errorf("execute text: %w", err)
+	}
+	msg.Text = text.String()
+
+	if htmlTmpl, ok := r.html[key]; ok {
+		var html strings.Builder
+		err = htmlTmpl.Execute(&html, data)
+		if err != nil {
+			return msg, fmt.Errorf("execute html: %w", err)
+		}
+		msg.HTML = html.String()
+	}
+
+	return msg, nil
+}
+
+func (r *TemplateNotificationRenderer) lookup(notificationType, locale string) (string, bool) {
+	language, _, _ := strings.Cut(strings.ReplaceAll(locale, "_", "-"), "-")
+	for _, l := range []string{locale, language, DefaultLocale} {
+		key := l + "/" + notificationType
+		if _, ok := r.text[key]; ok {
+			return key, true
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,271 @@
+package acme_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/acme/memory"
+)
+
+// cardLookupService embeds acme.CardService so only the card lookup is stubbed
+type cardLookupService struct {
+	acme.CardService
+
+	cards map[string]acme.Card
+}
+
+func (s *cardLookupService) GetCard(_ context.Context, cardID string) (*acme.Card, error) {
+	card, ok := s.cards[cardID]
+	if !ok {
+		return nil, acme.ErrCardNotFound
+	}
+	return &card, nil
+}
+
+// notifications returns the notifications of the repository, oldest first
+func notifications(t *testing.T, repo acme.NotificationRepository) []acme.Notification {
+	t.Helper()
+
+	found, err := repo.FindNotifications(context.Background(), acme.NotificationFilter{})
+	require.NoError(t, err)
+	slices.Reverse(found)
+	return found
+}
+
+// dueNotifications makes the pending notifications due
+func dueNotifications(t *testing.T, repo acme.NotificationRepository) {
+	t.Helper()
+
+	for _, n := range notifications(t, repo) {
+		if n.Status == acme.NotificationPending {
+			n.NextAttemptAt = time.Now().UTC().Add(-time.Second)
+			require.NoError(t, repo.UpdateNotification(context.Background(), n))
+		}
+	}
+}
+
+type recordingNotifier struct {
+	err      error
+	messages []acme.NotificationMessage
+}
+
+func (n *recordingNotifier) Notify(_ context.Context, msg acme.NotificationMessage) error {
+	if n.err != nil {
+		return n.err
+	}
+	n.messages = append(n.messages, msg)
+	return nil
+}
+
+func TestNotificationPublisher(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewNotificationRepository()
+	publisher := acme.NewNotificationPublisher(repo)
+
+	now := time.Now().UTC()
+	for _, event := range []acme.Event{
+		{ID: "event1", Type: acme.EventCardCreated, CardID: "card1", Data: acme.CardEventData{CardID: "card1", Status: acme.CardStatusActive}},
+		{ID: "event2", Type: acme.EventCardStatusUpdated, CardID: "card1", Data: acme.CardEventData{CardID: "card1", Status: acme.CardStatusFrozen}},
+		{ID: "event3", Type: acme.EventCardStatusUpdated, CardID: "card1", Data: acme.CardEventData{CardID: "card1", Status: acme.CardStatusActive}},
+		{ID: "event4", Type: acme.EventCardFunded, CardID: "card1", Data: acme.CardBalanceEventData{
+			CardID:          "card1",
+			Amount:          acme.MustParseMoney("10.00", "USD"),
+			AvailableCredit: acme.MustParseMoney("25.00", "USD"),
+		}},
+		{ID: "event5", Type: acme.EventCardWithdrawn, CardID: "card1", Data: acme.CardBalanceEventData{CardID: "card1"}},
+		{ID: "event6", Type: acme.EventCardExpiring, CardID: "card1", Data: acme.CardExpiryEventData{
+			CardID:     "card1",
+			ValidUntil: now.AddDate(0, 0, 7),
+			Action:     acme.CardStatusFrozen,
+		}},
+		// redelivered events are ignored
+		{ID: "event1", Type: acme.EventCardCreated, CardID: "card1", Data: acme.CardEventData{CardID: "card1", Status: acme.CardStatusActive}},
+	} {
+		event.CreatedAt = now
+		require.NoError(t, publisher.PublishEvent(ctx, event))
+	}
+
+	found := notifications(t, repo)
+	require.Len(t, found, 4)
+	assert.Equal(t, acme.NotificationCardIssued, found[0].Type)
+	assert.Equal(t, acme.NotificationCardFrozen, found[1].Type)
+	assert.Equal(t, acme.NotificationCardFunded, found[2].Type)
+	assert.Equal(t, acme.NotificationCardExpiring, found[3].Type)
+	assert.Equal(t, acme.MustParseMoney("10.00", "USD"), found[2].Amount)
+	for _, n := range found {
+		assert.Equal(t, acme.NotificationPending, n.Status)
+	}
+}
+
+func TestCardNotificationService(t *testing.T) {
+	ctx := context.Background()
+
+	renderer, err := acme.NewTemplateNotificationRenderer(acme.DefaultNotificationTemplates())
+	require.NoError(t, err)
+
+	cardSvc := &cardLookupService{cards: map[string]acme.Card{
+		"card1": {ID: "card1", Name: "Travel", Last4: "4242", ContactInfo: acme.ContactInfo{Email: "jane@example.com"}},
+		"card2": {ID: "card2", Name: "Office", Last4: "1111", ContactInfo: acme.ContactInfo{Email: "juan@example.com"}},
+		"card3": {ID: "card3", Name: "Ads", Last4: "0005"},
+		"card4": {
+			ID: "card4", Name: "Contractor", Last4: "7777", ContactInfo: acme.ContactInfo{Email: "ana@example.com"},
+			ValidUntil: time.Date(2030, 6, 30, 17, 0, 0, 0, time.UTC), ExpiryAction: acme.CardStatusTerminated,
+		},
+	}}
+
+	newService := func() (*acme.CardNotificationService, *memory.NotificationRepository, *recordingNotifier) {
+		repo := memory.NewNotificationRepository()
+		notifier := &recordingNotifier{}
+		return acme.NewCardNotificationService(cardSvc, repo, renderer, notifier), repo, notifier
+	}
+
+	publish := func(t *testing.T, repo *memory.NotificationRepository, event acme.Event) {
+		event.CreatedAt = time.Now().UTC().Add(-time.Second)
+		require.NoError(t, acme.NewNotificationPublisher(repo).PublishEvent(ctx, event))
+	}
+
+	t.Run("sent", func(t *testing.T) {
+		notificationSvc, repo, notifier := newService()
+		publish(t, repo, acme.Event{ID: "event1", Type: acme.EventCardFunded, CardID: "card1", Data: acme.CardBalanceEventData{
+			CardID:          "card1",
+			Amount:          acme.MustParseMoney("10.00", "USD"),
+			AvailableCredit: acme.MustParseMoney("25.00", "USD"),
+		}})
+
+		require.NoError(t, notificationSvc.SendNotifications(ctx))
+		require.Len(t, notifier.messages, 1)
+
+		msg := notifier.messages[0]
+		assert.Equal(t, "jane@example.com", msg.To)
+		assert.Equal(t, "10.00 USD was added to your card ending in 4242", msg.Subject)
+		assert.Contains(t, msg.Text, "Your available balance is 25.00 USD.")
+		assert.NotEmpty(t, msg.HTML)
+
+		n := notifications(t, repo)[0]
+		assert.Equal(t, acme.NotificationSent, n.Status)
+		assert.Equal(t, msg.Subject, n.Subject)
+		assert.Equal(t, 1, n.Attempts)
+		assert.False(t, n.SentAt.IsZero())
+
+		// sent notifications are not sent again
+		require.NoError(t, notificationSvc.SendNotifications(ctx))
+		assert.Len(t, notifier.messages, 1)
+	})
+
+	t.Run("locale", func(t *testing.T) {
+		notificationSvc, repo, notifier := newService()
+		_, err := notificationSvc.SaveNotificationPreference(ctx, acme.NotificationPreference{
+			Email:  "juan@example.com",
+			Locale: "es-MX",
+		})
+		require.NoError(t, err)
+
+		publish(t, repo, acme.Event{ID: "event1", Type: acme.EventCardCreated, CardID: "card2", Data: acme.CardEventData{CardID: "card2"}})
+
+		require.NoError(t, notificationSvc.SendNotifications(ctx))
+		require.Len(t, notifier.messages, 1)
+		assert.Equal(t, "Su tarjeta terminada en 1111 está lista", notifier.messages[0].Subject)
+	})
+
+	t.Run("expiring", func(t *testing.T) {
+		notificationSvc, repo, notifier := newService()
+		publish(t, repo, acme.Event{ID: "event1", Type: acme.EventCardExpiring, CardID: "card4", Data: acme.CardExpiryEventData{CardID: "card4"}})
+
+		require.NoError(t, notificationSvc.SendNotifications(ctx))
+		require.Len(t, notifier.messages, 1)
+		assert.Equal(t, "Your card ending in 7777 expires on June 30, 2030", notifier.messages[0].Subject)
+		assert.Contains(t, notifier.messages[0].Text, "will be terminated on June 30, 2030 17:00 UTC")
+	})
+
+	t.Run("skipped", func(t *testing.T) {
+		notificationSvc, repo, notifier := newService()
+		_, err := notificationSvc.SaveNotificationPreference(ctx, acme.NotificationPreference{
+			Email:       "jane@example.com",
+			OptOutTypes: []string{acme.NotificationCardFrozen},
+		})
+		require.NoError(t, err)
+
+		publish(t, repo, acme.Event{ID: "event1", Type: acme.EventCardStatusUpdated, CardID: "card1", Data: acme.CardEventData{
+			CardID: "card1",
+			Status: acme.CardStatusFrozen,
+		}})
+		publish(t, repo, acme.Event{ID: "event2", Type: acme.EventCardCreated, CardID: "card3", Data: acme.CardEventData{CardID: "card3"}})
+
+		require.NoError(t, notificationSvc.SendNotifications(ctx))
+		assert.Empty(t, notifier.messages)
+
+		skipped := notifications(t, repo)
+		for _, n := range skipped {
+			assert.Equal(t, acme.NotificationSkipped, n.Status)
+		}
+		assert.Equal(t, "cardholder opted out", skipped[0].Error)
+		assert.Equal(t, "card has no contact email", skipped[1].Error)
+	})
+
+	t.Run("retried", func(t *testing.T) {
+		notificationSvc, repo, notifier := newService()
+		notifier.err = errors.New("connection refused")
+
+		publish(t, repo, acme.Event{ID: "event1", Type: acme.EventCardCreated, CardID: "card1", Data: acme.CardEventData{CardID: "card1"}})
+
+		require.NoError(t, notificationSvc.SendNotifications(ctx))
+		n := notifications(t, repo)[0]
+		assert.Equal(t, acme.NotificationPending, n.Status)
+		assert.Equal(t, 1, n.Attempts)
+		assert.Equal(t, "connection refused", n.Error)
+		assert.WithinDuration(t, time.Now().Add(5*time.Minute), n.NextAttemptAt, time.Minute)
+
+		// not due yet
+		require.NoError(t, notificationSvc.SendNotifications(ctx))
+		assert.Equal(t, 1, notifications(t, repo)[0].Attempts)
+
+		notifier.err = nil
+		dueNotifications(t, repo)
+		require.NoError(t, notificationSvc.SendNotifications(ctx))
+		n = notifications(t, repo)[0]
+		assert.Equal(t, acme.NotificationSent, n.Status)
+		assert.Empty(t, n.Error)
+		assert.Len(t, notifier.messages, 1)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		notificationSvc, repo, notifier := newService()
+		notifier.err = errors.New("mailbox unavailable")
+
+		publish(t, repo, acme.Event{ID: "event1", Type: acme.EventCardCreated, CardID: "card1", Data: acme.CardEventData{CardID: "card1"}})
+
+		for i := 0; i < 6; i++ {
+			dueNotifications(t, repo)
+			require.NoError(t, notificationSvc.SendNotifications(ctx))
+		}
+		n := notifications(t, repo)[0]
+		assert.Equal(t, acme.NotificationFailed, n.Status)
+		assert.Equal(t, 6, n.Attempts)
+	})
+
+	t.Run("invalid preferences", func(t *testing.T) {
+		notificationSvc, _, _ := newService()
+		for _, pref := range []acme.NotificationPreference{
+			{Locale: "en"},
+			{Email: "jane@example.com", OptOutTypes: []string{"card_deleted"}},
+		} {
+			_, err := notificationSvc.SaveNotificationPreference(ctx, pref)
+			assert.ErrorIs(t, err, acme.ErrInvalidNotificationPreference)
+		}
+
+		pref, err := notificationSvc.SaveNotificationPreference(ctx, acme.NotificationPreference{
+			Email:       "jane@example.com",
+			OptOutTypes: []string{acme.NotificationCardFunded, acme.NotificationCardIssued, acme.NotificationCardFunded},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, acme.DefaultLocale, pref.Locale)
+		assert.Equal(t, []string{acme.NotificationCardFunded, acme.NotificationCardIssued}, pref.OptOutTypes)
+	})
+}
@@ -0,0 +1,57 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+)
+
+// withAdvisoryLock calls fn while holding the session advisory lock of the
+// key on a dedicated connection, the first key is the hashed lock space and
+// the second key is the ID wrapped to an int. It waits for the lock unless
+// try is set, in which case it returns false without calling fn when the
+// lock is held. The lock is released when the connection closes if the
+// unlock fails.
+func withAdvisoryLock(ctx context.Context, db *sql.DB, space string, id int64, try bool, fn func(context.Context) error) (bool, error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return false, fmt.Errorf("conn: %w", err)
+	}
+	defer conn.Close()
+
+	if try {
+		var locked bool
+		err = conn.QueryRowContext(ctx, `select pg_try_advisory_lock(hashtext($1), ($2::bigint % 2147483647)::int)`,
+			space, id,
+		).Scan(&locked)
+		if err != nil {
+			return false, fmt.Errorf("try advisory lock: %w", err)
+		}
+		if !locked {
+			return false, nil
+		}
+	} else {
+		_, err = conn.ExecContext(ctx, `select pg_advisory_lock(hashtext($1), ($2::bigint % 2147483647)::int)`,
+			space, id,
+		)
+		if err != nil {
+			return false, fmt.Errorf("advisory lock: %w", err)
+		}
+	}
+
+	fnErr := fn(ctx)
+
+	// the lock must be released even when ctx is done
+	_, err = conn.ExecContext(context.WithoutCancel(ctx), `select pg_advisory_unlock(hashtext($1), ($2::bigint % 2147483647)::int)`,
+		space, id,
+	)
+	if err != nil {
+		// closing the connection releases the lock
+		_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+		return true, errors.Join(fnErr, fmt.Errorf("advisory unlock: %w", err))
+	}
+
+	return true, fnErr
+}
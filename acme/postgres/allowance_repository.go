@@ -0,0 +1,260 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+)
+
+// allowanceScheduleLockSpace is hashed into the first key of the schedule
+// advisory locks, the second key is the schedule ID wrapped to an int
+const allowanceScheduleLockSpace = "allowance_schedules"
+
+type AllowanceRepository struct {
+	db *sql.DB
+}
+
+var _ acme.AllowanceRepository = (*AllowanceRepository)(nil)
+
+func NewAllowanceRepository(db *sql.DB) *AllowanceRepository {
+	return &AllowanceRepository{db: db}
+}
+
+// SaveAllowanceSchedule implements acme.AllowanceRepository.
+func (r *AllowanceRepository) SaveAllowanceSchedule(ctx context.Context, s *acme.AllowanceSchedule) error {
+	stmnt := `insert into allowance_schedules (
+		card_id, amount, currency, cadence, mode, start_date, end_date, next_run_at
+	) values ($1, $2, $3, $4, $5, $6, $7, $8)
+	returning id, created_at`
+
+	err := r.db.QueryRowContext(ctx, stmnt,
+		s.CardID, s.Amount.Minor(), s.Amount.Currency(), s.Cadence, s.Mode, s.StartDate,
+		sql.NullTime{Time: s.EndDate, Valid: !s.EndDate.IsZero()},
+		sql.NullTime{Time: s.NextRunAt, Valid: !s.NextRunAt.IsZero()},
+	).Scan(&s.ID, &s.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("query row context: %w", err)
+	}
+
+	return nil
+}
+
+const selectAllowanceSchedules = `select
+	id, card_id, amount, currency, cadence, mode, start_date, end_date, next_run_at, created_at
+from allowance_schedules`
+
+func scanAllowanceSchedule(row rowScanner) (acme.AllowanceSchedule, error) {
+	var s acme.AllowanceSchedule
+	var amount int64
+	var currency string
+	var endDate, nextRunAt sql.NullTime
+	err := row.Scan(
+		&s.ID, &s.CardID, &amount, &currency, &s.Cadence, &s.Mode, &s.StartDate, &endDate, &nextRunAt, &s.CreatedAt,
+	)
+	if err != nil {
+		return s, err
+	}
+
+	s.Amount = acme.NewMoney(amount, currency)
+	s.EndDate = endDate.Time
+	s.NextRunAt = nextRunAt.Time
+
+	return s, nil
+}
+
+func scanAllowanceSchedules(rows *sql.Rows) ([]acme.AllowanceSchedule, error) {
+	defer rows.Close()
+
+	schedules := make([]acme.AllowanceSchedule, 0)
+	for rows.Next() {
+		s, err := scanAllowanceSchedule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("row scan: %w", err)
+		}
+		schedules = append(schedules, s)
+	}
+
+	return schedules, rows.Err()
+}
+
+// GetAllowanceSchedule implements acme.AllowanceRepository.
+func (r *AllowanceRepository) GetAllowanceSchedule(ctx context.Context, id int64) (*acme.AllowanceSchedule, error) {
+	stmnt := selectAllowanceSchedules + ` where id = $1`
+
+	s, err := scanAllowanceSchedule(r.db.QueryRowContext(ctx, stmnt, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, acme.ErrAllowanceScheduleNotFound
+		}
+		return nil, fmt.Errorf("query row context: %w", err)
+	}
+
+	return &s, nil
+}
+
+// FindAllowanceSchedules implements acme.AllowanceRepository.
+func (r *AllowanceRepository) FindAllowanceSchedules(ctx context.Context, cardID string) ([]acme.AllowanceSchedule, error) {
+	stmnt := selectAllowanceSchedules + `
+	where $1::text = '' or card_id = $1::text
+	order by id`
+
+	rows, err := r.db.QueryContext(ctx, stmnt, cardID)
+	if err != nil {
+		return nil, fmt.Errorf("query context: %w", err)
+	}
+
+	return scanAllowanceSchedules(rows)
+}
+
+// FindDueAllowanceSchedules implements acme.AllowanceRepository.
+func (r *AllowanceRepository) FindDueAllowanceSchedules(ctx context.Context, now time.Time) ([]acme.AllowanceSchedule, error) {
+	stmnt := selectAllowanceSchedules + `
+	where next_run_at <= $1
+	order by next_run_at`
+
+	rows, err := r.db.QueryContext(ctx, stmnt, now)
+	if err != nil {
+		return nil, fmt.Errorf("query context: %w", err)
+	}
+
+	return scanAllowanceSchedules(rows)
+}
+
+// UpdateAllowanceScheduleNextRun implements acme.AllowanceRepository.
+func (r *AllowanceRepository) UpdateAllowanceScheduleNextRun(ctx context.Context, id int64, nextRunAt time.Time) error {
+	stmnt := `update allowance_schedules set next_run_at = $2 where id = $1`
+
+	_, err := r.db.ExecContext(ctx, stmnt, id, sql.NullTime{Time: nextRunAt, Valid: !nextRunAt.IsZero()})
+	if err != nil {
+		return fmt.Errorf("exec context: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteAllowanceSchedule implements acme.AllowanceRepository.
+func (r *AllowanceRepository) DeleteAllowanceSchedule(ctx context.Context, id int64) error {
+	stmnt := `delete from allowance_schedules where id = $1`
+	res, err := r.db.ExecContext(ctx, stmnt, id)
+	if err != nil {
+		return fmt.Errorf("exec context: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+	if n == 0 {
+		return acme.ErrAllowanceScheduleNotFound
+	}
+
+	return nil
+}
+
+// LockAllowanceSchedule implements acme.AllowanceRepository.
+func (r *AllowanceRepository) LockAllowanceSchedule(ctx context.Context, id int64, fn func(context.Context) error) (bool, error) {
+	return withAdvisoryLock(ctx, r.db, allowanceScheduleLockSpace, id, true, fn)
+}
+
+// SaveAllowanceRun implements acme.AllowanceRepository.
+func (r *AllowanceRepository) SaveAllowanceRun(ctx context.Context, run *acme.AllowanceRun) error {
+	stmnt := `insert into allowance_runs (
+		schedule_id, card_id, scheduled_at, mode, status, adjustment_type, amount,
+		available_credit_before, available_credit_after, currency, adjustment_id,
+		attempts, error, created_at, updated_at
+	) values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, 1, $12, $13, $13)
+	on conflict (schedule_id, scheduled_at) do update set
+		status = excluded.status,
+		adjustment_type = excluded.adjustment_type,
+		amount = excluded.amount,
+		available_credit_before = excluded.available_credit_before,
+		available_credit_after = excluded.available_credit_after,
+		currency = excluded.currency,
+		adjustment_id = excluded.adjustment_id,
+		attempts = allowance_runs.attempts + 1,
+		error = excluded.error,
+		updated_at = excluded.updated_at
+	returning id, attempts, created_at`
+
+	// skipped runs of missing cards have no amounts
+	currency := run.AvailableCreditBefore.Currency()
+	if currency == "" {
+		currency = run.Amount.Currency()
+	}
+
+	err := r.db.QueryRowContext(ctx, stmnt,
+		run.ScheduleID, run.CardID, run.ScheduledAt, run.Mode, run.Status, run.AdjustmentType, run.Amount.Minor(),
+		run.AvailableCreditBefore.Minor(), run.AvailableCreditAfter.Minor(), currency, run.AdjustmentID,
+		run.Error, run.CreatedAt,
+	).Scan(&run.ID, &run.Attempts, &run.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("query row context: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateAllowanceRun implements acme.AllowanceRepository.
+func (r *AllowanceRepository) UpdateAllowanceRun(ctx context.Context, run acme.AllowanceRun) error {
+	stmnt := `update allowance_runs set
+		status = $2, available_credit_after = $3, adjustment_id = $4, error = $5, updated_at = $6
+	where id = $1`
+
+	_, err := r.db.ExecContext(ctx, stmnt,
+		run.ID, run.Status, run.AvailableCreditAfter.Minor(), run.AdjustmentID, run.Error, time.Now().UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("exec context: %w", err)
+	}
+
+	return nil
+}
+
+// FindAllowanceRuns implements acme.AllowanceRepository.
+func (r *AllowanceRepository) FindAllowanceRuns(ctx context.Context, filter acme.AllowanceRunFilter) ([]acme.AllowanceRun, error) {
+	stmnt := `select
+		id, schedule_id, card_id, scheduled_at, mode, status, adjustment_type, amount,
+		available_credit_before, available_credit_after, currency, adjustment_id,
+		attempts, error, created_at
+	from allowance_runs
+	where ($1::bigint = 0 or schedule_id = $1::bigint)
+		and ($2::text = '' or card_id = $2::text)
+		and ($3::timestamp is null or scheduled_at = $3::timestamp)
+	order by scheduled_at desc, id desc`
+
+	rows, err := r.db.QueryContext(ctx, stmnt, filter.ScheduleID, filter.CardID,
+		sql.NullTime{Time: filter.ScheduledAt, Valid: !filter.ScheduledAt.IsZero()},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query context: %w", err)
+	}
+	defer rows.Close()
+
+	runs := make([]acme.AllowanceRun, 0)
+	for rows.Next() {
+		var run acme.AllowanceRun
+		var amount, before, after int64
+		var currency string
+		err = rows.Scan(
+			&run.ID, &run.ScheduleID, &run.CardID, &run.ScheduledAt, &run.Mode, &run.Status, &run.AdjustmentType, &amount,
+			&before, &after, &currency, &run.AdjustmentID,
+			&run.Attempts, &run.Error, &run.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("row scan: %w", err)
+		}
+
+		if currency != "" {
+			run.Amount = acme.NewMoney(amount, currency)
+			run.AvailableCreditBefore = acme.NewMoney(before, currency)
+			run.AvailableCreditAfter = acme.NewMoney(after, currency)
+		}
+		runs = append(runs, run)
+	}
+
+	return runs, rows.Err()
+}
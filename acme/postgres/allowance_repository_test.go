@@ -0,0 +1,22 @@
+package postgres_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/acme/postgres"
+	"github.com/stevenferrer/acme-cards-api/acme/repotest"
+)
+
+func TestAllowanceRepository(t *testing.T) {
+	db := newTestDB(t)
+
+	repotest.RunAllowanceRepositorySuite(t, func(t *testing.T) (acme.CardRepository, acme.AllowanceRepository) {
+		_, err := db.Exec(`truncate table cards, allowance_schedules, allowance_runs cascade`)
+		require.NoError(t, err)
+
+		return postgres.NewCardRepository(db), postgres.NewAllowanceRepository(db)
+	})
+}
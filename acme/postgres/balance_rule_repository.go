@@ -0,0 +1,233 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+)
+
+type BalanceRuleRepository struct {
+	db *sql.DB
+}
+
+var _ acme.BalanceRuleRepository = (*BalanceRuleRepository)(nil)
+
+func NewBalanceRuleRepository(db *sql.DB) *BalanceRuleRepository {
+	return &BalanceRuleRepository{db: db}
+}
+
+// SaveBalanceRule implements acme.BalanceRuleRepository.
+func (r *BalanceRuleRepository) SaveBalanceRule(ctx context.Context, rule *acme.BalanceRule) error {
+	stmnt := `insert into balance_rules (card_id, kind, threshold, top_up_to, monthly_limit, currency)
+	values ($1, $2, $3, $4, $5, $6)
+	returning id, created_at`
+
+	err := r.db.QueryRowContext(ctx, stmnt,
+		rule.CardID, rule.Kind, rule.Threshold.Minor(), rule.TopUpTo.Minor(), rule.MonthlyLimit.Minor(),
+		rule.Threshold.Currency(),
+	).Scan(&rule.ID, &rule.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("query row context: %w", err)
+	}
+
+	return nil
+}
+
+const selectBalanceRules = `select
+	id, card_id, kind, threshold, top_up_to, monthly_limit, currency, triggered_at, created_at
+from balance_rules`
+
+func scanBalanceRule(row rowScanner) (acme.BalanceRule, error) {
+	var rule acme.BalanceRule
+	var threshold, topUpTo, monthlyLimit int64
+	var currency string
+	var triggeredAt sql.NullTime
+	err := row.Scan(
+		&rule.ID, &rule.CardID, &rule.Kind, &threshold, &topUpTo, &monthlyLimit, &currency,
+		&triggeredAt, &rule.CreatedAt,
+	)
+	if err != nil {
+		return rule, err
+	}
+
+	rule.Threshold = acme.NewMoney(threshold, currency)
+	if rule.Kind == acme.BalanceRuleTopUp {
+		rule.TopUpTo = acme.NewMoney(topUpTo, currency)
+		rule.MonthlyLimit = acme.NewMoney(monthlyLimit, currency)
+	}
+	rule.TriggeredAt = triggeredAt.Time
+
+	return rule, nil
+}
+
+func scanBalanceRules(rows *sql.Rows) ([]acme.BalanceRule, error) {
+	defer rows.Close()
+
+	rules := make([]acme.BalanceRule, 0)
+	for rows.Next() {
+		rule, err := scanBalanceRule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("row scan: %w", err)
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, rows.Err()
+}
+
+// GetBalanceRule implements acme.BalanceRuleRepository.
+func (r *BalanceRuleRepository) GetBalanceRule(ctx context.Context, id int64) (*acme.BalanceRule, error) {
+	stmnt := selectBalanceRules + ` where id = $1`
+
+	rule, err := scanBalanceRule(r.db.QueryRowContext(ctx, stmnt, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, acme.ErrBalanceRuleNotFound
+		}
+		return nil, fmt.Errorf("query row context: %w", err)
+	}
+
+	return &rule, nil
+}
+
+// FindBalanceRules implements acme.BalanceRuleRepository.
+func (r *BalanceRuleRepository) FindBalanceRules(ctx context.Context, cardID string) ([]acme.BalanceRule, error) {
+	stmnt := selectBalanceRules + `
+	where $1::text = '' or card_id = $1::text
+	order by id`
+
+	rows, err := r.db.QueryContext(ctx, stmnt, cardID)
+	if err != nil {
+		return nil, fmt.Errorf("query context: %w", err)
+	}
+
+	return scanBalanceRules(rows)
+}
+
+// DeleteBalanceRule implements acme.BalanceRuleRepository.
+func (r *BalanceRuleRepository) DeleteBalanceRule(ctx context.Context, id int64) error {
+	stmnt := `delete from balance_rules where id = $1`
+	res, err := r.db.ExecContext(ctx, stmnt, id)
+	if err != nil {
+		return fmt.Errorf("exec context: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+	if n == 0 {
+		return acme.ErrBalanceRuleNotFound
+	}
+
+	return nil
+}
+
+// ClaimBalanceRules implements acme.BalanceRuleRepository.
+func (r *BalanceRuleRepository) ClaimBalanceRules(ctx context.Context, now time.Time, lease time.Duration) ([]acme.BalanceRule, error) {
+	// skip locked lets the other instances claim the remaining rules
+	stmnt := `update balance_rules set leased_until = $2
+	where id in (
+		select id from balance_rules
+		where leased_until is null or leased_until <= $1
+		order by id
+		for update skip locked
+	)
+	returning id, card_id, kind, threshold, top_up_to, monthly_limit, currency, triggered_at, created_at`
+
+	rows, err := r.db.QueryContext(ctx, stmnt, now, now.Add(lease))
+	if err != nil {
+		return nil, fmt.Errorf("query context: %w", err)
+	}
+
+	return scanBalanceRules(rows)
+}
+
+// ReleaseBalanceRule implements acme.BalanceRuleRepository.
+func (r *BalanceRuleRepository) ReleaseBalanceRule(ctx context.Context, rule acme.BalanceRule) error {
+	stmnt := `update balance_rules set triggered_at = $2, leased_until = null where id = $1`
+
+	_, err := r.db.ExecContext(ctx, stmnt,
+		rule.ID, sql.NullTime{Time: rule.TriggeredAt, Valid: !rule.TriggeredAt.IsZero()},
+	)
+	if err != nil {
+		return fmt.Errorf("exec context: %w", err)
+	}
+
+	return nil
+}
+
+// SaveBalanceRuleExecution implements acme.BalanceRuleRepository.
+func (r *BalanceRuleRepository) SaveBalanceRuleExecution(ctx context.Context, e *acme.BalanceRuleExecution) error {
+	stmnt := `insert into balance_rule_executions (
+		rule_id, card_id, kind, status, available_credit, amount, currency,
+		adjustment_id, reason, created_at
+	) values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	returning id`
+
+	err := r.db.QueryRowContext(ctx, stmnt,
+		e.RuleID, e.CardID, e.Kind, e.Status, e.AvailableCredit.Minor(), e.Amount.Minor(), e.AvailableCredit.Currency(),
+		e.AdjustmentID, e.Reason, e.CreatedAt,
+	).Scan(&e.ID)
+	if err != nil {
+		return fmt.Errorf("query row context: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateBalanceRuleExecution implements acme.BalanceRuleRepository.
+func (r *BalanceRuleRepository) UpdateBalanceRuleExecution(ctx context.Context, e acme.BalanceRuleExecution) error {
+	stmnt := `update balance_rule_executions set status = $2, adjustment_id = $3, reason = $4 where id = $1`
+
+	_, err := r.db.ExecContext(ctx, stmnt, e.ID, e.Status, e.AdjustmentID, e.Reason)
+	if err != nil {
+		return fmt.Errorf("exec context: %w", err)
+	}
+
+	return nil
+}
+
+// FindBalanceRuleExecutions implements acme.BalanceRuleRepository.
+func (r *BalanceRuleRepository) FindBalanceRuleExecutions(ctx context.Context, filter acme.BalanceRuleExecutionFilter) ([]acme.BalanceRuleExecution, error) {
+	stmnt := `select
+		id, rule_id, card_id, kind, status, available_credit, amount, currency,
+		adjustment_id, reason, created_at
+	from balance_rule_executions
+	where ($1::text = '' or card_id = $1::text)
+		and ($2::bigint = 0 or rule_id = $2::bigint)
+		and created_at >= $3
+	order by created_at desc, id desc`
+
+	rows, err := r.db.QueryContext(ctx, stmnt, filter.CardID, filter.RuleID, filter.Since)
+	if err != nil {
+		return nil, fmt.Errorf("query context: %w", err)
+	}
+	defer rows.Close()
+
+	executions := make([]acme.BalanceRuleExecution, 0)
+	for rows.Next() {
+		var e acme.BalanceRuleExecution
+		var availableCredit, amount int64
+		var currency string
+		err = rows.Scan(
+			&e.ID, &e.RuleID, &e.CardID, &e.Kind, &e.Status, &availableCredit, &amount, &currency,
+			&e.AdjustmentID, &e.Reason, &e.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("row scan: %w", err)
+		}
+
+		e.AvailableCredit = acme.NewMoney(availableCredit, currency)
+		if amount != 0 {
+			e.Amount = acme.NewMoney(amount, currency)
+		}
+		executions = append(executions, e)
+	}
+
+	return executions, rows.Err()
+}
@@ -0,0 +1,22 @@
+package postgres_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/acme/postgres"
+	"github.com/stevenferrer/acme-cards-api/acme/repotest"
+)
+
+func TestBalanceRuleRepository(t *testing.T) {
+	db := newTestDB(t)
+
+	repotest.RunBalanceRuleRepositorySuite(t, func(t *testing.T) (acme.CardRepository, acme.BalanceRuleRepository) {
+		_, err := db.Exec(`truncate table cards, balance_rules, balance_rule_executions cascade`)
+		require.NoError(t, err)
+
+		return postgres.NewCardRepository(db), postgres.NewBalanceRuleRepository(db)
+	})
+}
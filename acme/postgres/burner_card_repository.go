@@ -0,0 +1,95 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+)
+
+type BurnerCardRepository struct {
+	db *sql.DB
+}
+
+var _ acme.BurnerCardRepository = (*BurnerCardRepository)(nil)
+
+func NewBurnerCardRepository(db *sql.DB) *BurnerCardRepository {
+	return &BurnerCardRepository{db: db}
+}
+
+// SaveBurnerCard implements acme.BurnerCardRepository.
+func (r *BurnerCardRepository) SaveBurnerCard(ctx context.Context, c *acme.BurnerCard) error {
+	stmnt := `insert into burner_cards (card_id, mode, merchant_id)
+	values ($1, $2, $3)
+	on conflict (card_id) do update set card_id = excluded.card_id
+	returning created_at`
+
+	err := r.db.QueryRowContext(ctx, stmnt, c.CardID, c.Mode, c.MerchantID).Scan(&c.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("query row context: %w", err)
+	}
+
+	return nil
+}
+
+// FindBurnerCards implements acme.BurnerCardRepository.
+func (r *BurnerCardRepository) FindBurnerCards(ctx context.Context, active bool) ([]acme.BurnerCard, error) {
+	stmnt := `select
+		card_id, mode, merchant_id, frozen_at, checked_at, ended_at, end_reason, created_at
+	from burner_cards
+	where not $1::boolean or ended_at is null
+	order by created_at desc, card_id`
+
+	rows, err := r.db.QueryContext(ctx, stmnt, active)
+	if err != nil {
+		return nil, fmt.Errorf("query context: %w", err)
+	}
+	defer rows.Close()
+
+	cards := make([]acme.BurnerCard, 0)
+	for rows.Next() {
+		var c acme.BurnerCard
+		var frozenAt, checkedAt, endedAt sql.NullTime
+		err = rows.Scan(
+			&c.CardID, &c.Mode, &c.MerchantID, &frozenAt, &checkedAt, &endedAt, &c.EndReason, &c.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("row scan: %w", err)
+		}
+
+		c.FrozenAt = frozenAt.Time
+		c.CheckedAt = checkedAt.Time
+		c.EndedAt = endedAt.Time
+		cards = append(cards, c)
+	}
+
+	return cards, rows.Err()
+}
+
+// UpdateBurnerCard implements acme.BurnerCardRepository.
+func (r *BurnerCardRepository) UpdateBurnerCard(ctx context.Context, c acme.BurnerCard) error {
+	stmnt := `update burner_cards set
+		merchant_id = $2, frozen_at = $3, checked_at = $4, ended_at = $5, end_reason = $6
+	where card_id = $1`
+
+	res, err := r.db.ExecContext(ctx, stmnt, c.CardID, c.MerchantID,
+		sql.NullTime{Time: c.FrozenAt, Valid: !c.FrozenAt.IsZero()},
+		sql.NullTime{Time: c.CheckedAt, Valid: !c.CheckedAt.IsZero()},
+		sql.NullTime{Time: c.EndedAt, Valid: !c.EndedAt.IsZero()},
+		c.EndReason,
+	)
+	if err != nil {
+		return fmt.Errorf("exec context: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+	if n == 0 {
+		return acme.ErrCardNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,22 @@
+package postgres_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/acme/postgres"
+	"github.com/stevenferrer/acme-cards-api/acme/repotest"
+)
+
+func TestBurnerCardRepository(t *testing.T) {
+	db := newTestDB(t)
+
+	repotest.RunBurnerCardRepositorySuite(t, func(t *testing.T) (acme.CardRepository, acme.BurnerCardRepository) {
+		_, err := db.Exec(`truncate table cards, burner_cards cascade`)
+		require.NoError(t, err)
+
+		return postgres.NewCardRepository(db), postgres.NewBurnerCardRepository(db)
+	})
+}
@@ -0,0 +1,248 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+)
+
+// cardApplicationLockSpace is hashed into the first key of the application
+// advisory locks, the second key is the application ID wrapped to an int
+const cardApplicationLockSpace = "card_applications"
+
+type CardApplicationRepository struct {
+	db *sql.DB
+}
+
+var _ acme.CardApplicationRepository = (*CardApplicationRepository)(nil)
+
+func NewCardApplicationRepository(db *sql.DB) *CardApplicationRepository {
+	return &CardApplicationRepository{db: db}
+}
+
+// cardApplicationParams is the stored JSON of acme.CreateCardParams
+type cardApplicationParams struct {
+	FirstName   string `json:"firstName"`
+	LastName    string `json:"lastName"`
+	DOB         string `json:"dob"`
+	Line1       string `json:"line1"`
+	Line2       string `json:"line2,omitempty"`
+	City        string `json:"city"`
+	CountryCode string `json:"countryCode"`
+	Email       string `json:"email"`
+	DialCode    int    `json:"dialCode"`
+	PhoneNumber string `json:"phoneNumber"`
+	IDType      string `json:"idType"`
+	IDNumber    string `json:"idNumber"`
+	Mode        string `json:"mode,omitempty"`
+	MerchantID  string `json:"merchantId,omitempty"`
+	// ExpiresAt is the burner card expiry of the applications saved before
+	// it was folded into ValidUntil, it is only read
+	ExpiresAt    time.Time `json:"expiresAt,omitzero"`
+	ValidUntil   time.Time `json:"validUntil,omitzero"`
+	ExpiryAction string    `json:"expiryAction,omitempty"`
+}
+
+// SaveCardApplication implements acme.CardApplicationRepository.
+func (r *CardApplicationRepository) SaveCardApplication(ctx context.Context, app *acme.CardApplication) error {
+	p := app.Params
+	params, err := json.Marshal(cardApplicationParams{
+		FirstName:    p.FirstName,
+		LastName:     p.LastName,
+		DOB:          p.DOB,
+		Line1:        p.Address.Line1,
+		Line2:        p.Address.Line2,
+		City:         p.Address.City,
+		CountryCode:  p.Address.CountryCode,
+		Email:        p.ContactInfo.Email,
+		DialCode:     p.ContactInfo.DialCode,
+		PhoneNumber:  p.ContactInfo.PhoneNumber,
+		IDType:       p.IDDocument.Type,
+		IDNumber:     p.IDDocument.Number,
+		Mode:         p.Mode,
+		MerchantID:   p.MerchantID,
+		ValidUntil:   p.ValidUntil,
+		ExpiryAction: p.ExpiryAction,
+	})
+	if err != nil {
+		return fmt.Errorf("marshal params: %w", err)
+	}
+
+	stmnt := `insert into card_applications (
+		params, maker, checker, status, rejection_reason, card_id, error, expires_at
+	) values ($1, $2, $3, $4, $5, $6, $7, $8)
+	returning id, created_at, updated_at`
+
+	err = r.db.QueryRowContext(ctx, stmnt,
+		params, app.Maker, app.Checker, app.Status, app.RejectionReason, app.CardID, app.Error, app.ExpiresAt,
+	).Scan(&app.ID, &app.CreatedAt, &app.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("query row context: %w", err)
+	}
+
+	return nil
+}
+
+const selectCardApplications = `select
+	id, params, maker, checker, status, rejection_reason, card_id, error,
+	expires_at, created_at, updated_at
+from card_applications`
+
+func scanCardApplication(row rowScanner) (acme.CardApplication, error) {
+	var app acme.CardApplication
+	var params []byte
+	err := row.Scan(
+		&app.ID, &params, &app.Maker, &app.Checker, &app.Status, &app.RejectionReason, &app.CardID, &app.Error,
+		&app.ExpiresAt, &app.CreatedAt, &app.UpdatedAt,
+	)
+	if err != nil {
+		return app, err
+	}
+
+	var p cardApplicationParams
+	err = json.Unmarshal(params, &p)
+	if err != nil {
+		return app, fmt.Errorf("unmarshal params: %w", err)
+	}
+
+	if !p.ExpiresAt.IsZero() && (p.ValidUntil.IsZero() || p.ExpiresAt.Before(p.ValidUntil)) {
+		p.ValidUntil, p.ExpiryAction = p.ExpiresAt, acme.CardStatusTerminated
+	}
+
+	app.Params = acme.CreateCardParams{
+		FirstName: p.FirstName,
+		LastName:  p.LastName,
+		DOB:       p.DOB,
+		Address: acme.Address{
+			Line1:       p.Line1,
+			Line2:       p.Line2,
+			City:        p.City,
+			CountryCode: p.CountryCode,
+		},
+		ContactInfo: acme.ContactInfo{
+			Email:       p.Email,
+			DialCode:    p.DialCode,
+			PhoneNumber: p.PhoneNumber,
+		},
+		IDDocument: acme.IDDocument{
+			Type:   p.IDType,
+			Number: p.IDNumber,
+		},
+		Mode:         p.Mode,
+		MerchantID:   p.MerchantID,
+		ValidUntil:   p.ValidUntil,
+		ExpiryAction: p.ExpiryAction,
+	}
+
+	return app, nil
+}
+
+// GetCardApplication implements acme.CardApplicationRepository.
+func (r *CardApplicationRepository) GetCardApplication(ctx context.Context, id int64) (*acme.CardApplication, error) {
+	stmnt := selectCardApplications + ` where id = $1`
+
+	app, err := scanCardApplication(r.db.QueryRowContext(ctx, stmnt, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, acme.ErrCardApplicationNotFound
+		}
+		return nil, fmt.Errorf("query row context: %w", err)
+	}
+
+	return &app, nil
+}
+
+// FindCardApplications implements acme.CardApplicationRepository.
+func (r *CardApplicationRepository) FindCardApplications(ctx context.Context, filter acme.CardApplicationFilter) ([]acme.CardApplication, error) {
+	stmnt := selectCardApplications + `
+	where ($1::text = '' or status = $1::text)
+		and ($2::text = '' or maker = $2::text)
+	order by created_at desc, id desc`
+
+	rows, err := r.db.QueryContext(ctx, stmnt, filter.Status, filter.Maker)
+	if err != nil {
+		return nil, fmt.Errorf("query context: %w", err)
+	}
+	defer rows.Close()
+
+	apps := make([]acme.CardApplication, 0)
+	for rows.Next() {
+		app, err := scanCardApplication(rows)
+		if err != nil {
+			return nil, fmt.Errorf("row scan: %w", err)
+		}
+		apps = append(apps, app)
+	}
+
+	return apps, rows.Err()
+}
+
+// UpdateCardApplication implements acme.CardApplicationRepository.
+func (r *CardApplicationRepository) UpdateCardApplication(ctx context.Context, app *acme.CardApplication, from string) error {
+	stmnt := `update card_applications set
+		status = $3, checker = $4, rejection_reason = $5, card_id = $6, error = $7, updated_at = now()
+	where id = $1 and status = $2
+	returning updated_at`
+
+	err := r.db.QueryRowContext(ctx, stmnt,
+		app.ID, from, app.Status, app.Checker, app.RejectionReason, app.CardID, app.Error,
+	).Scan(&app.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: the application is no longer %s", acme.ErrCardApplicationStatus, from)
+		}
+		return fmt.Errorf("query row context: %w", err)
+	}
+
+	return nil
+}
+
+// LockCardApplication implements acme.CardApplicationRepository.
+func (r *CardApplicationRepository) LockCardApplication(ctx context.Context, id int64, fn func(context.Context) error) (bool, error) {
+	return withAdvisoryLock(ctx, r.db, cardApplicationLockSpace, id, true, fn)
+}
+
+// SaveCardApplicationEvent implements acme.CardApplicationRepository.
+func (r *CardApplicationRepository) SaveCardApplicationEvent(ctx context.Context, e *acme.CardApplicationEvent) error {
+	stmnt := `insert into card_application_events (application_id, action, actor, reason)
+	values ($1, $2, $3, $4)
+	returning id, created_at`
+
+	err := r.db.QueryRowContext(ctx, stmnt, e.ApplicationID, e.Action, e.Actor, e.Reason).Scan(&e.ID, &e.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("query row context: %w", err)
+	}
+
+	return nil
+}
+
+// FindCardApplicationEvents implements acme.CardApplicationRepository.
+func (r *CardApplicationRepository) FindCardApplicationEvents(ctx context.Context, applicationID int64) ([]acme.CardApplicationEvent, error) {
+	stmnt := `select id, application_id, action, actor, reason, created_at
+	from card_application_events
+	where application_id = $1
+	order by id`
+
+	rows, err := r.db.QueryContext(ctx, stmnt, applicationID)
+	if err != nil {
+		return nil, fmt.Errorf("query context: %w", err)
+	}
+	defer rows.Close()
+
+	events := make([]acme.CardApplicationEvent, 0)
+	for rows.Next() {
+		var e acme.CardApplicationEvent
+		err = rows.Scan(&e.ID, &e.ApplicationID, &e.Action, &e.Actor, &e.Reason, &e.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("row scan: %w", err)
+		}
+		events = append(events, e)
+	}
+
+	return events, rows.Err()
+}
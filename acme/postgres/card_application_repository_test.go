@@ -0,0 +1,58 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/acme/postgres"
+	"github.com/stevenferrer/acme-cards-api/acme/repotest"
+)
+
+func TestCardApplicationRepository(t *testing.T) {
+	db := newTestDB(t)
+
+	repotest.RunCardApplicationRepositorySuite(t, func(t *testing.T) acme.CardApplicationRepository {
+		_, err := db.Exec(`truncate table card_applications, card_application_events cascade`)
+		require.NoError(t, err)
+
+		return postgres.NewCardApplicationRepository(db)
+	})
+
+	t.Run("burner card expiry", func(t *testing.T) {
+		_, err := db.Exec(`truncate table card_applications, card_application_events cascade`)
+		require.NoError(t, err)
+
+		ctx := context.Background()
+		repo := postgres.NewCardApplicationRepository(db)
+
+		validUntil := time.Now().UTC().Add(30 * 24 * time.Hour).Truncate(time.Second)
+		app := &acme.CardApplication{
+			Params: acme.CreateCardParams{
+				FirstName:    "Jane",
+				LastName:     "Doe",
+				Mode:         acme.CardModeSingleUse,
+				ValidUntil:   validUntil,
+				ExpiryAction: acme.CardStatusFrozen,
+			},
+			Maker:     "alice",
+			Status:    acme.CardApplicationPending,
+			ExpiresAt: time.Now().UTC(),
+		}
+		require.NoError(t, repo.SaveCardApplication(ctx, app))
+
+		// the burner card expiry of the older applications is their valid until
+		burnerExpiry := validUntil.Add(-time.Hour)
+		_, err = db.Exec(`update card_applications set params = params || jsonb_build_object('expiresAt', $2::timestamptz) where id = $1`, app.ID, burnerExpiry)
+		require.NoError(t, err)
+
+		got, err := repo.GetCardApplication(ctx, app.ID)
+		require.NoError(t, err)
+		assert.True(t, burnerExpiry.Equal(got.Params.ValidUntil))
+		assert.Equal(t, acme.CardStatusTerminated, got.Params.ExpiryAction)
+	})
+}
@@ -0,0 +1,216 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+)
+
+// cardGroupLockSpace is hashed into the first key of the card group
+// advisory locks, the second key is the group ID wrapped to an int
+const cardGroupLockSpace = "card_groups"
+
+type CardGroupRepository struct {
+	db *sql.DB
+}
+
+var _ acme.CardGroupRepository = (*CardGroupRepository)(nil)
+
+func NewCardGroupRepository(db *sql.DB) *CardGroupRepository {
+	return &CardGroupRepository{db: db}
+}
+
+// SaveCardGroup implements acme.CardGroupRepository.
+func (r *CardGroupRepository) SaveCardGroup(ctx context.Context, group *acme.CardGroup) error {
+	if group.ID == 0 {
+		stmnt := `insert into card_groups (name, budget, currency, period)
+		values ($1, $2, $3, $4)
+		returning id, created_at`
+
+		err := r.db.QueryRowContext(ctx, stmnt,
+			group.Name, group.Budget.Minor(), group.Budget.Currency(), group.Period,
+		).Scan(&group.ID, &group.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("query row context: %w", err)
+		}
+
+		return nil
+	}
+
+	stmnt := `update card_groups set
+		name = $2, budget = $3, currency = $4, period = $5, updated_at = now()
+	where id = $1
+	returning created_at`
+
+	err := r.db.QueryRowContext(ctx, stmnt,
+		group.ID, group.Name, group.Budget.Minor(), group.Budget.Currency(), group.Period,
+	).Scan(&group.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return acme.ErrCardGroupNotFound
+		}
+		return fmt.Errorf("query row context: %w", err)
+	}
+
+	return nil
+}
+
+const selectCardGroups = `select
+	g.id, g.name, g.budget, g.currency, g.period, g.created_at
+from card_groups g`
+
+func scanCardGroup(row rowScanner) (acme.CardGroup, error) {
+	var group acme.CardGroup
+	var budget int64
+	var currency string
+	err := row.Scan(&group.ID, &group.Name, &budget, &currency, &group.Period, &group.CreatedAt)
+	if err != nil {
+		return group, err
+	}
+
+	group.Budget = acme.NewMoney(budget, currency)
+
+	return group, nil
+}
+
+// GetCardGroup implements acme.CardGroupRepository.
+func (r *CardGroupRepository) GetCardGroup(ctx context.Context, id int64) (*acme.CardGroup, error) {
+	stmnt := selectCardGroups + ` where g.id = $1`
+
+	group, err := scanCardGroup(r.db.QueryRowContext(ctx, stmnt, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, acme.ErrCardGroupNotFound
+		}
+		return nil, fmt.Errorf("query row context: %w", err)
+	}
+
+	return &group, nil
+}
+
+// FindCardGroups implements acme.CardGroupRepository.
+func (r *CardGroupRepository) FindCardGroups(ctx context.Context) ([]acme.CardGroup, error) {
+	stmnt := selectCardGroups + ` order by g.name, g.id`
+
+	rows, err := r.db.QueryContext(ctx, stmnt)
+	if err != nil {
+		return nil, fmt.Errorf("query context: %w", err)
+	}
+	defer rows.Close()
+
+	groups := make([]acme.CardGroup, 0)
+	for rows.Next() {
+		group, err := scanCardGroup(rows)
+		if err != nil {
+			return nil, fmt.Errorf("row scan: %w", err)
+		}
+		groups = append(groups, group)
+	}
+
+	return groups, rows.Err()
+}
+
+// DeleteCardGroup implements acme.CardGroupRepository.
+func (r *CardGroupRepository) DeleteCardGroup(ctx context.Context, id int64) error {
+	stmnt := `delete from card_groups where id = $1`
+	res, err := r.db.ExecContext(ctx, stmnt, id)
+	if err != nil {
+		return fmt.Errorf("exec context: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+	if n == 0 {
+		return acme.ErrCardGroupNotFound
+	}
+
+	return nil
+}
+
+// AddCardGroupMember implements acme.CardGroupRepository.
+func (r *CardGroupRepository) AddCardGroupMember(ctx context.Context, groupID int64, cardID string) error {
+	stmnt := `insert into card_group_members (card_id, group_id) values ($1, $2)`
+
+	_, err := r.db.ExecContext(ctx, stmnt, cardID, groupID)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+			return acme.ErrCardGroupMemberExists
+		}
+		return fmt.Errorf("exec context: %w", err)
+	}
+
+	return nil
+}
+
+// RemoveCardGroupMember implements acme.CardGroupRepository.
+func (r *CardGroupRepository) RemoveCardGroupMember(ctx context.Context, groupID int64, cardID string) error {
+	stmnt := `delete from card_group_members where card_id = $1 and group_id = $2`
+	res, err := r.db.ExecContext(ctx, stmnt, cardID, groupID)
+	if err != nil {
+		return fmt.Errorf("exec context: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+	if n == 0 {
+		return acme.ErrCardGroupMemberNotFound
+	}
+
+	return nil
+}
+
+// FindCardGroupMembers implements acme.CardGroupRepository.
+func (r *CardGroupRepository) FindCardGroupMembers(ctx context.Context, groupID int64) ([]string, error) {
+	stmnt := `select card_id from card_group_members where group_id = $1 order by created_at, card_id`
+
+	rows, err := r.db.QueryContext(ctx, stmnt, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("query context: %w", err)
+	}
+	defer rows.Close()
+
+	cardIDs := make([]string, 0)
+	for rows.Next() {
+		var cardID string
+		err = rows.Scan(&cardID)
+		if err != nil {
+			return nil, fmt.Errorf("row scan: %w", err)
+		}
+		cardIDs = append(cardIDs, cardID)
+	}
+
+	return cardIDs, rows.Err()
+}
+
+// GetCardGroupByCard implements acme.CardGroupRepository.
+func (r *CardGroupRepository) GetCardGroupByCard(ctx context.Context, cardID string) (*acme.CardGroup, error) {
+	stmnt := selectCardGroups + `
+	join card_group_members m on m.group_id = g.id
+	where m.card_id = $1`
+
+	group, err := scanCardGroup(r.db.QueryRowContext(ctx, stmnt, cardID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, acme.ErrCardGroupNotFound
+		}
+		return nil, fmt.Errorf("query row context: %w", err)
+	}
+
+	return &group, nil
+}
+
+// LockCardGroup implements acme.CardGroupRepository.
+func (r *CardGroupRepository) LockCardGroup(ctx context.Context, id int64, fn func(context.Context) error) error {
+	_, err := withAdvisoryLock(ctx, r.db, cardGroupLockSpace, id, false, fn)
+	return err
+}
@@ -0,0 +1,22 @@
+package postgres_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/acme/postgres"
+	"github.com/stevenferrer/acme-cards-api/acme/repotest"
+)
+
+func TestCardGroupRepository(t *testing.T) {
+	db := newTestDB(t)
+
+	repotest.RunCardGroupRepositorySuite(t, func(t *testing.T) (acme.CardRepository, acme.CardGroupRepository) {
+		_, err := db.Exec(`truncate table cards, card_groups, card_group_members cascade`)
+		require.NoError(t, err)
+
+		return postgres.NewCardRepository(db), postgres.NewCardGroupRepository(db)
+	})
+}
@@ -5,11 +5,15 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
-	"strings"
+
+	"github.com/lib/pq"
 
 	"github.com/stevenferrer/acme-cards-api/acme"
 )
 
+// uniqueViolation is the postgres error code for unique constraint violations
+const uniqueViolation = "23505"
+
 type CardRepository struct {
 	db *sql.DB
 }
@@ -25,6 +29,10 @@ func (r *CardRepository) SaveCardID(ctx context.Context, cardID string, external
 	stmnt := `insert into cards (id, external_id) values ($1, $2)`
 	_, err := r.db.ExecContext(ctx, stmnt, cardID, externalCardID)
 	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+			return acme.ErrCardExists
+		}
 		return fmt.Errorf("exec context: %w", err)
 	}
 
@@ -38,6 +46,9 @@ func (r *CardRepository) GetExternalID(ctx context.Context, cardID string) (stri
 	var externalID string
 	err := r.db.QueryRowContext(ctx, stmnt, cardID).Scan(&externalID)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", acme.ErrCardNotFound
+		}
 		return "", fmt.Errorf("query row context: %w", err)
 	}
 
@@ -68,23 +79,22 @@ func (r *CardRepository) FindCardIDs(ctx context.Context) ([]string, error) {
 	return cardIDs, nil
 }
 
+// GetExternalIDMapping implements acme.CardRepository.
 func (r *CardRepository) GetExternalIDMapping(ctx context.Context, externalIDs ...string) (map[string]string, error) {
-	args := make([]any, 0, len(externalIDs))
-	dollars := make([]string, 0, len(externalIDs))
-	for i, externalID := range externalIDs {
-		dollars = append(dollars, fmt.Sprintf("$%d", i+1))
-		args = append(args, externalID)
-		fmt.Printf("%s\n", externalID)
+	cardIDMap := make(map[string]string, len(externalIDs))
+	if len(externalIDs) == 0 {
+		return cardIDMap, nil
 	}
-	stmnt := fmt.Sprintf(`select "id", external_id from cards where external_id in (%s)`, strings.Join(dollars, ","))
 
-	rows, err := r.db.QueryContext(ctx, stmnt, args...)
-	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+	// array parameter avoids the bind parameters limit on large inputs
+	stmnt := `select "id", external_id from cards where external_id = any($1)`
+
+	rows, err := r.db.QueryContext(ctx, stmnt, pq.Array(externalIDs))
+	if err != nil {
 		return nil, fmt.Errorf("query context: %w", err)
 	}
 	defer rows.Close()
 
-	cardIDMap := make(map[string]string, len(externalIDs))
 	for rows.Next() {
 		var cardID, externalID string
 		err = rows.Scan(&cardID, &externalID)
@@ -94,5 +104,91 @@ func (r *CardRepository) GetExternalIDMapping(ctx context.Context, externalIDs .
 		cardIDMap[externalID] = cardID
 	}
 
-	return cardIDMap, nil
+	return cardIDMap, rows.Err()
+}
+
+// SaveCardExpiry implements acme.CardRepository.
+func (r *CardRepository) SaveCardExpiry(ctx context.Context, expiry acme.CardExpiry) error {
+	stmnt := `update cards set
+		valid_until = $1, expiry_action = $2, expiry_notified_at = $3, expired_at = $4
+	where id = $5`
+
+	res, err := r.db.ExecContext(ctx, stmnt,
+		sql.NullTime{Time: expiry.ValidUntil, Valid: !expiry.ValidUntil.IsZero()},
+		expiry.Action,
+		sql.NullTime{Time: expiry.NotifiedAt, Valid: !expiry.NotifiedAt.IsZero()},
+		sql.NullTime{Time: expiry.ExpiredAt, Valid: !expiry.ExpiredAt.IsZero()},
+		expiry.CardID,
+	)
+	if err != nil {
+		return fmt.Errorf("exec context: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+	if n == 0 {
+		return acme.ErrCardNotFound
+	}
+
+	return nil
+}
+
+const selectCardExpiries = `select
+	id, valid_until, expiry_action, expiry_notified_at, expired_at
+from cards`
+
+func scanCardExpiry(row rowScanner) (acme.CardExpiry, error) {
+	var expiry acme.CardExpiry
+	var validUntil, notifiedAt, expiredAt sql.NullTime
+	err := row.Scan(&expiry.CardID, &validUntil, &expiry.Action, &notifiedAt, &expiredAt)
+	if err != nil {
+		return expiry, err
+	}
+
+	expiry.ValidUntil = validUntil.Time
+	expiry.NotifiedAt = notifiedAt.Time
+	expiry.ExpiredAt = expiredAt.Time
+
+	return expiry, nil
+}
+
+// GetCardExpiry implements acme.CardRepository.
+func (r *CardRepository) GetCardExpiry(ctx context.Context, cardID string) (*acme.CardExpiry, error) {
+	stmnt := selectCardExpiries + ` where id = $1`
+
+	expiry, err := scanCardExpiry(r.db.QueryRowContext(ctx, stmnt, cardID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, acme.ErrCardNotFound
+		}
+		return nil, fmt.Errorf("query row context: %w", err)
+	}
+
+	return &expiry, nil
+}
+
+// FindCardExpiries implements acme.CardRepository.
+func (r *CardRepository) FindCardExpiries(ctx context.Context) ([]acme.CardExpiry, error) {
+	stmnt := selectCardExpiries + `
+	where valid_until is not null
+	order by valid_until, id`
+
+	rows, err := r.db.QueryContext(ctx, stmnt)
+	if err != nil {
+		return nil, fmt.Errorf("query context: %w", err)
+	}
+	defer rows.Close()
+
+	expiries := make([]acme.CardExpiry, 0)
+	for rows.Next() {
+		expiry, err := scanCardExpiry(rows)
+		if err != nil {
+			return nil, fmt.Errorf("row scan: %w", err)
+		}
+		expiries = append(expiries, expiry)
+	}
+
+	return expiries, rows.Err()
 }
@@ -0,0 +1,41 @@
+package postgres_test
+
+import (
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/acme/postgres"
+	"github.com/stevenferrer/acme-cards-api/acme/repotest"
+)
+
+// newTestDB connects to the database in POSTGRES_TEST_DSN, the tests
+// are skipped when it is not set since they truncate tables.
+func newTestDB(t *testing.T) *sql.DB {
+	dsn := os.Getenv("POSTGRES_TEST_DSN")
+	if dsn == "" {
+		t.Skip("POSTGRES_TEST_DSN is not set")
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	postgres.MustMigrate(db)
+
+	return db
+}
+
+func TestCardRepository(t *testing.T) {
+	db := newTestDB(t)
+
+	repotest.RunCardRepositorySuite(t, func(t *testing.T) acme.CardRepository {
+		_, err := db.Exec(`truncate table cards cascade`)
+		require.NoError(t, err)
+
+		return postgres.NewCardRepository(db)
+	})
+}
@@ -0,0 +1,131 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+)
+
+type CardSnapshotRepository struct {
+	db *sql.DB
+}
+
+var _ acme.CardSnapshotRepository = (*CardSnapshotRepository)(nil)
+
+func NewCardSnapshotRepository(db *sql.DB) *CardSnapshotRepository {
+	return &CardSnapshotRepository{db: db}
+}
+
+// SaveCardSnapshots implements acme.CardSnapshotRepository.
+func (r *CardSnapshotRepository) SaveCardSnapshots(ctx context.Context, snapshots ...acme.CardSnapshot) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmnt := `insert into card_snapshots (
+		card_id, name, last4, status, available_credit,
+		daily_spent, weekly_spent, monthly_spent, yearly_spent, all_time_spent,
+		currency, email, dial_code, phone_number, updated_at
+	) values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+	on conflict (card_id) do update set
+		name = excluded.name,
+		last4 = excluded.last4,
+		status = excluded.status,
+		available_credit = excluded.available_credit,
+		daily_spent = excluded.daily_spent,
+		weekly_spent = excluded.weekly_spent,
+		monthly_spent = excluded.monthly_spent,
+		yearly_spent = excluded.yearly_spent,
+		all_time_spent = excluded.all_time_spent,
+		currency = excluded.currency,
+		email = excluded.email,
+		dial_code = excluded.dial_code,
+		phone_number = excluded.phone_number,
+		updated_at = excluded.updated_at`
+
+	for _, s := range snapshots {
+		// amounts are stored in minor units of the available credit currency
+		_, err = tx.ExecContext(ctx, stmnt,
+			s.ID, s.Name, s.Last4, s.Status, s.AvailableCredit.Minor(),
+			s.SpendUsage.Daily.Minor(), s.SpendUsage.Weekly.Minor(), s.SpendUsage.Monthly.Minor(),
+			s.SpendUsage.Yearly.Minor(), s.SpendUsage.AllTime.Minor(),
+			s.AvailableCredit.Currency(), s.ContactInfo.Email, s.ContactInfo.DialCode, s.ContactInfo.PhoneNumber, s.UpdatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("exec context: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// FindCardSnapshots implements acme.CardSnapshotRepository.
+func (r *CardSnapshotRepository) FindCardSnapshots(ctx context.Context) ([]acme.CardSnapshot, error) {
+	stmnt := `select
+		s.card_id, s.name, s.last4, s.status, s.available_credit,
+		s.daily_spent, s.weekly_spent, s.monthly_spent, s.yearly_spent, s.all_time_spent,
+		s.currency, s.email, s.dial_code, s.phone_number, s.updated_at
+	from card_snapshots s
+	join cards c on c.id = s.card_id
+	order by c.created_at desc`
+
+	rows, err := r.db.QueryContext(ctx, stmnt)
+	if err != nil {
+		return nil, fmt.Errorf("query context: %w", err)
+	}
+	defer rows.Close()
+
+	snapshots := make([]acme.CardSnapshot, 0)
+	for rows.Next() {
+		var s acme.CardSnapshot
+		var availableCredit, daily, weekly, monthly, yearly, allTime int64
+		var currency string
+		err = rows.Scan(
+			&s.ID, &s.Name, &s.Last4, &s.Status, &availableCredit,
+			&daily, &weekly, &monthly, &yearly, &allTime,
+			&currency, &s.ContactInfo.Email, &s.ContactInfo.DialCode, &s.ContactInfo.PhoneNumber, &s.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("row scan: %w", err)
+		}
+
+		s.AvailableCredit = acme.NewMoney(availableCredit, currency)
+		s.SpendUsage = acme.SpendUsage{
+			Daily:   acme.NewMoney(daily, currency),
+			Weekly:  acme.NewMoney(weekly, currency),
+			Monthly: acme.NewMoney(monthly, currency),
+			Yearly:  acme.NewMoney(yearly, currency),
+			AllTime: acme.NewMoney(allTime, currency),
+		}
+		snapshots = append(snapshots, s)
+	}
+
+	return snapshots, rows.Err()
+}
+
+// DeleteCardSnapshotsBefore implements acme.CardSnapshotRepository.
+func (r *CardSnapshotRepository) DeleteCardSnapshotsBefore(ctx context.Context, t time.Time) error {
+	stmnt := `delete from card_snapshots where updated_at < $1`
+	_, err := r.db.ExecContext(ctx, stmnt, t)
+	if err != nil {
+		return fmt.Errorf("exec context: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteCardSnapshot implements acme.CardSnapshotRepository.
+func (r *CardSnapshotRepository) DeleteCardSnapshot(ctx context.Context, cardID string) error {
+	stmnt := `delete from card_snapshots where card_id = $1`
+	_, err := r.db.ExecContext(ctx, stmnt, cardID)
+	if err != nil {
+		return fmt.Errorf("exec context: %w", err)
+	}
+
+	return nil
+}
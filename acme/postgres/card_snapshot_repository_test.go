@@ -0,0 +1,22 @@
+package postgres_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/acme/postgres"
+	"github.com/stevenferrer/acme-cards-api/acme/repotest"
+)
+
+func TestCardSnapshotRepository(t *testing.T) {
+	db := newTestDB(t)
+
+	repotest.RunCardSnapshotRepositorySuite(t, func(t *testing.T) (acme.CardRepository, acme.CardSnapshotRepository) {
+		_, err := db.Exec(`truncate table cards cascade`)
+		require.NoError(t, err)
+
+		return postgres.NewCardRepository(db), postgres.NewCardSnapshotRepository(db)
+	})
+}
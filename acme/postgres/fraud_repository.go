@@ -0,0 +1,309 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+)
+
+// fraudEvaluationLockSpace is hashed into the first key of the evaluation
+// advisory lock
+const fraudEvaluationLockSpace = "fraud_evaluation"
+
+type FraudRepository struct {
+	db *sql.DB
+}
+
+var _ acme.FraudRepository = (*FraudRepository)(nil)
+
+func NewFraudRepository(db *sql.DB) *FraudRepository {
+	return &FraudRepository{db: db}
+}
+
+// fraudRuleParams is the stored JSON of acme.FraudRuleParams
+type fraudRuleParams struct {
+	MaxCount      int      `json:"maxCount,omitempty"`
+	WindowSeconds int64    `json:"windowSeconds,omitempty"`
+	Multiplier    float64  `json:"multiplier,omitempty"`
+	MinHistory    int      `json:"minHistory,omitempty"`
+	Countries     []string `json:"countries,omitempty"`
+	MCCCodes      []string `json:"mccCodes,omitempty"`
+}
+
+// SaveFraudRule implements acme.FraudRepository.
+func (r *FraudRepository) SaveFraudRule(ctx context.Context, rule *acme.FraudRule) error {
+	p := rule.Params
+	params, err := json.Marshal(fraudRuleParams{
+		MaxCount:      p.MaxCount,
+		WindowSeconds: int64(p.Window / time.Second),
+		Multiplier:    p.Multiplier,
+		MinHistory:    p.MinHistory,
+		Countries:     p.Countries,
+		MCCCodes:      p.MCCCodes,
+	})
+	if err != nil {
+		return fmt.Errorf("marshal params: %w", err)
+	}
+
+	if rule.ID == 0 {
+		stmnt := `insert into fraud_rules (name, type, params, action, dry_run, enabled)
+		values ($1, $2, $3, $4, $5, $6)
+		returning id, created_at`
+		err = r.db.QueryRowContext(ctx, stmnt,
+			rule.Name, rule.Type, params, rule.Action, rule.DryRun, rule.Enabled,
+		).Scan(&rule.ID, &rule.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("query row context: %w", err)
+		}
+
+		return nil
+	}
+
+	stmnt := `update fraud_rules set
+		name = $2, type = $3, params = $4, action = $5, dry_run = $6, enabled = $7
+	where id = $1
+	returning created_at`
+	err = r.db.QueryRowContext(ctx, stmnt,
+		rule.ID, rule.Name, rule.Type, params, rule.Action, rule.DryRun, rule.Enabled,
+	).Scan(&rule.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return acme.ErrFraudRuleNotFound
+		}
+		return fmt.Errorf("query row context: %w", err)
+	}
+
+	return nil
+}
+
+const selectFraudRules = `select id, name, type, params, action, dry_run, enabled, created_at from fraud_rules`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanFraudRule(row rowScanner) (acme.FraudRule, error) {
+	var rule acme.FraudRule
+	var params []byte
+	err := row.Scan(&rule.ID, &rule.Name, &rule.Type, &params, &rule.Action, &rule.DryRun, &rule.Enabled, &rule.CreatedAt)
+	if err != nil {
+		return rule, err
+	}
+
+	var p fraudRuleParams
+	err = json.Unmarshal(params, &p)
+	if err != nil {
+		return rule, fmt.Errorf("unmarshal params: %w", err)
+	}
+
+	rule.Params = acme.FraudRuleParams{
+		MaxCount:   p.MaxCount,
+		Window:     time.Duration(p.WindowSeconds) * time.Second,
+		Multiplier: p.Multiplier,
+		MinHistory: p.MinHistory,
+		Countries:  p.Countries,
+		MCCCodes:   p.MCCCodes,
+	}
+
+	return rule, nil
+}
+
+// GetFraudRule implements acme.FraudRepository.
+func (r *FraudRepository) GetFraudRule(ctx context.Context, id int64) (*acme.FraudRule, error) {
+	stmnt := selectFraudRules + ` where id = $1`
+
+	rule, err := scanFraudRule(r.db.QueryRowContext(ctx, stmnt, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, acme.ErrFraudRuleNotFound
+		}
+		return nil, fmt.Errorf("query row context: %w", err)
+	}
+
+	return &rule, nil
+}
+
+// FindFraudRules implements acme.FraudRepository.
+func (r *FraudRepository) FindFraudRules(ctx context.Context) ([]acme.FraudRule, error) {
+	stmnt := selectFraudRules + ` order by id`
+
+	rows, err := r.db.QueryContext(ctx, stmnt)
+	if err != nil {
+		return nil, fmt.Errorf("query context: %w", err)
+	}
+	defer rows.Close()
+
+	rules := make([]acme.FraudRule, 0)
+	for rows.Next() {
+		rule, err := scanFraudRule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("row scan: %w", err)
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, rows.Err()
+}
+
+// DeleteFraudRule implements acme.FraudRepository.
+func (r *FraudRepository) DeleteFraudRule(ctx context.Context, id int64) error {
+	stmnt := `delete from fraud_rules where id = $1`
+	res, err := r.db.ExecContext(ctx, stmnt, id)
+	if err != nil {
+		return fmt.Errorf("exec context: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+	if n == 0 {
+		return acme.ErrFraudRuleNotFound
+	}
+
+	return nil
+}
+
+// FraudDecisionExists implements acme.FraudRepository.
+func (r *FraudRepository) FraudDecisionExists(ctx context.Context, ruleID int64, transactionID string) (bool, error) {
+	stmnt := `select exists (select 1 from fraud_decisions where rule_id = $1 and transaction_id = $2)`
+
+	var exists bool
+	err := r.db.QueryRowContext(ctx, stmnt, ruleID, transactionID).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("query row context: %w", err)
+	}
+
+	return exists, nil
+}
+
+// SaveFraudDecision implements acme.FraudRepository.
+func (r *FraudRepository) SaveFraudDecision(ctx context.Context, d *acme.FraudDecision) error {
+	stmnt := `insert into fraud_decisions (
+		rule_id, rule_name, transaction_id, card_id, action, dry_run, reason, created_at
+	) values ($1, $2, $3, $4, $5, $6, $7, $8)
+	returning id`
+
+	err := r.db.QueryRowContext(ctx, stmnt,
+		d.RuleID, d.RuleName, d.TransactionID, d.CardID, d.Action, d.DryRun, d.Reason, d.CreatedAt,
+	).Scan(&d.ID)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+			return acme.ErrFraudDecisionExists
+		}
+		return fmt.Errorf("query row context: %w", err)
+	}
+
+	return nil
+}
+
+// FindFraudDecisions implements acme.FraudRepository.
+func (r *FraudRepository) FindFraudDecisions(ctx context.Context, filter acme.FraudDecisionFilter) ([]acme.FraudDecision, error) {
+	stmnt := `select
+		id, rule_id, rule_name, transaction_id, card_id, action, dry_run, reason, created_at
+	from fraud_decisions
+	where ($1::text = '' or card_id = $1::text)
+		and ($2::text = '' or transaction_id = $2::text)
+		and ($3::text = '' or action = $3::text)
+	order by created_at desc, id desc`
+
+	rows, err := r.db.QueryContext(ctx, stmnt, filter.CardID, filter.TransactionID, filter.Action)
+	if err != nil {
+		return nil, fmt.Errorf("query context: %w", err)
+	}
+	defer rows.Close()
+
+	decisions := make([]acme.FraudDecision, 0)
+	for rows.Next() {
+		var d acme.FraudDecision
+		err = rows.Scan(&d.ID, &d.RuleID, &d.RuleName, &d.TransactionID, &d.CardID, &d.Action, &d.DryRun, &d.Reason, &d.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("row scan: %w", err)
+		}
+		decisions = append(decisions, d)
+	}
+
+	return decisions, rows.Err()
+}
+
+// SaveTransactionFlag implements acme.FraudRepository.
+func (r *FraudRepository) SaveTransactionFlag(ctx context.Context, flag *acme.TransactionFlag) error {
+	// the no-op update returns the row of the first flag
+	stmnt := `insert into transaction_flags (transaction_id, card_id, rule_id, reason)
+	values ($1, $2, $3, $4)
+	on conflict (transaction_id) do update set transaction_id = excluded.transaction_id
+	returning created_at`
+
+	err := r.db.QueryRowContext(ctx, stmnt,
+		flag.TransactionID, flag.CardID, flag.RuleID, flag.Reason,
+	).Scan(&flag.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("query row context: %w", err)
+	}
+
+	return nil
+}
+
+// FindTransactionFlags implements acme.FraudRepository.
+func (r *FraudRepository) FindTransactionFlags(ctx context.Context, filter acme.TransactionFlagFilter) ([]acme.TransactionFlag, error) {
+	stmnt := `select transaction_id, card_id, rule_id, reason, cleared_at, created_at
+	from transaction_flags
+	where ($1::text = '' or card_id = $1::text)
+		and (not $2::boolean or cleared_at is null)
+	order by created_at desc, transaction_id`
+
+	rows, err := r.db.QueryContext(ctx, stmnt, filter.CardID, filter.Open)
+	if err != nil {
+		return nil, fmt.Errorf("query context: %w", err)
+	}
+	defer rows.Close()
+
+	flags := make([]acme.TransactionFlag, 0)
+	for rows.Next() {
+		var f acme.TransactionFlag
+		var clearedAt sql.NullTime
+		err = rows.Scan(&f.TransactionID, &f.CardID, &f.RuleID, &f.Reason, &clearedAt, &f.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("row scan: %w", err)
+		}
+
+		f.ClearedAt = clearedAt.Time
+		flags = append(flags, f)
+	}
+
+	return flags, rows.Err()
+}
+
+// ClearTransactionFlag implements acme.FraudRepository.
+func (r *FraudRepository) ClearTransactionFlag(ctx context.Context, transactionID string) error {
+	stmnt := `update transaction_flags set cleared_at = now()
+	where transaction_id = $1 and cleared_at is null`
+
+	res, err := r.db.ExecContext(ctx, stmnt, transactionID)
+	if err != nil {
+		return fmt.Errorf("exec context: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+	if n == 0 {
+		return acme.ErrTransactionFlagNotFound
+	}
+
+	return nil
+}
+
+// LockFraudEvaluation implements acme.FraudRepository.
+func (r *FraudRepository) LockFraudEvaluation(ctx context.Context, fn func(context.Context) error) (bool, error) {
+	return withAdvisoryLock(ctx, r.db, fraudEvaluationLockSpace, 0, true, fn)
+}
@@ -0,0 +1,22 @@
+package postgres_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/acme/postgres"
+	"github.com/stevenferrer/acme-cards-api/acme/repotest"
+)
+
+func TestFraudRepository(t *testing.T) {
+	db := newTestDB(t)
+
+	repotest.RunFraudRepositorySuite(t, func(t *testing.T) acme.FraudRepository {
+		_, err := db.Exec(`truncate table fraud_rules, fraud_decisions, transaction_flags`)
+		require.NoError(t, err)
+
+		return postgres.NewFraudRepository(db)
+	})
+}
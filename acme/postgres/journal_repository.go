@@ -0,0 +1,215 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+)
+
+type JournalRepository struct {
+	db *sql.DB
+}
+
+var _ acme.JournalRepository = (*JournalRepository)(nil)
+
+func NewJournalRepository(db *sql.DB) *JournalRepository {
+	return &JournalRepository{db: db}
+}
+
+// SaveJournalAccount implements acme.JournalRepository.
+func (r *JournalRepository) SaveJournalAccount(ctx context.Context, account acme.JournalAccount) error {
+	stmnt := `insert into journal_accounts (role, account) values ($1, $2)
+	on conflict (role) do update set
+		account = excluded.account,
+		updated_at = now()`
+	_, err := r.db.ExecContext(ctx, stmnt, account.Role, account.Account)
+	if err != nil {
+		return fmt.Errorf("exec context: %w", err)
+	}
+
+	return nil
+}
+
+// FindJournalAccounts implements acme.JournalRepository.
+func (r *JournalRepository) FindJournalAccounts(ctx context.Context) ([]acme.JournalAccount, error) {
+	stmnt := `select role, account from journal_accounts order by role`
+
+	rows, err := r.db.QueryContext(ctx, stmnt)
+	if err != nil {
+		return nil, fmt.Errorf("query context: %w", err)
+	}
+	defer rows.Close()
+
+	accounts := make([]acme.JournalAccount, 0)
+	for rows.Next() {
+		var a acme.JournalAccount
+		err = rows.Scan(&a.Role, &a.Account)
+		if err != nil {
+			return nil, fmt.Errorf("row scan: %w", err)
+		}
+		accounts = append(accounts, a)
+	}
+
+	return accounts, rows.Err()
+}
+
+// DeleteJournalAccount implements acme.JournalRepository.
+func (r *JournalRepository) DeleteJournalAccount(ctx context.Context, role string) error {
+	stmnt := `delete from journal_accounts where role = $1`
+	res, err := r.db.ExecContext(ctx, stmnt, role)
+	if err != nil {
+		return fmt.Errorf("exec context: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%w: %s", acme.ErrJournalAccountMissing, role)
+	}
+
+	return nil
+}
+
+// FindBookedTransactionIDs implements acme.JournalRepository.
+func (r *JournalRepository) FindBookedTransactionIDs(ctx context.Context, transactionIDs ...string) ([]string, error) {
+	booked := make([]string, 0)
+	if len(transactionIDs) == 0 {
+		return booked, nil
+	}
+
+	stmnt := `select distinct transaction_id from journal_lines where transaction_id = any($1)`
+
+	rows, err := r.db.QueryContext(ctx, stmnt, pq.Array(transactionIDs))
+	if err != nil {
+		return nil, fmt.Errorf("query context: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		err = rows.Scan(&id)
+		if err != nil {
+			return nil, fmt.Errorf("row scan: %w", err)
+		}
+		booked = append(booked, id)
+	}
+
+	return booked, rows.Err()
+}
+
+// SaveJournalExport implements acme.JournalRepository.
+func (r *JournalRepository) SaveJournalExport(ctx context.Context, export *acme.JournalExport) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmnt := `insert into journal_exports (period_start, period_end) values ($1, $2)
+	returning id, created_at`
+	err = tx.QueryRowContext(ctx, stmnt, export.From, export.Until).Scan(&export.ID, &export.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("query row context: %w", err)
+	}
+
+	stmnt = `insert into journal_lines (
+		transaction_id, line_no, export_id, booked_at, account,
+		description, debit, credit, currency
+	) values ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+
+	for _, l := range export.Lines {
+		// amounts are stored in minor units of the debit currency
+		_, err = tx.ExecContext(ctx, stmnt,
+			l.TransactionID, l.LineNo, export.ID, l.Date, l.Account,
+			l.Description, l.Debit.Minor(), l.Credit.Minor(), l.Debit.Currency(),
+		)
+		if err != nil {
+			var pqErr *pq.Error
+			if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+				return fmt.Errorf("%w: %s", acme.ErrTransactionAlreadyBooked, l.TransactionID)
+			}
+			return fmt.Errorf("exec context: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetJournalExport implements acme.JournalRepository.
+func (r *JournalRepository) GetJournalExport(ctx context.Context, id int64) (*acme.JournalExport, error) {
+	stmnt := `select id, period_start, period_end, created_at from journal_exports where id = $1`
+
+	var export acme.JournalExport
+	err := r.db.QueryRowContext(ctx, stmnt, id).Scan(&export.ID, &export.From, &export.Until, &export.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, acme.ErrJournalExportNotFound
+		}
+		return nil, fmt.Errorf("query row context: %w", err)
+	}
+
+	stmnt = `select
+		transaction_id, line_no, booked_at, account,
+		description, debit, credit, currency
+	from journal_lines
+	where export_id = $1
+	order by booked_at, transaction_id, line_no`
+
+	rows, err := r.db.QueryContext(ctx, stmnt, id)
+	if err != nil {
+		return nil, fmt.Errorf("query context: %w", err)
+	}
+	defer rows.Close()
+
+	export.Lines = make([]acme.JournalLine, 0)
+	for rows.Next() {
+		var l acme.JournalLine
+		var debit, credit int64
+		var currency string
+		err = rows.Scan(
+			&l.TransactionID, &l.LineNo, &l.Date, &l.Account,
+			&l.Description, &debit, &credit, &currency,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("row scan: %w", err)
+		}
+
+		l.Debit = acme.NewMoney(debit, currency)
+		l.Credit = acme.NewMoney(credit, currency)
+		export.Lines = append(export.Lines, l)
+	}
+
+	return &export, rows.Err()
+}
+
+// FindJournalExports implements acme.JournalRepository.
+func (r *JournalRepository) FindJournalExports(ctx context.Context) ([]acme.JournalExport, error) {
+	stmnt := `select id, period_start, period_end, created_at
+	from journal_exports
+	order by period_end desc, id desc`
+
+	rows, err := r.db.QueryContext(ctx, stmnt)
+	if err != nil {
+		return nil, fmt.Errorf("query context: %w", err)
+	}
+	defer rows.Close()
+
+	exports := make([]acme.JournalExport, 0)
+	for rows.Next() {
+		var e acme.JournalExport
+		err = rows.Scan(&e.ID, &e.From, &e.Until, &e.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("row scan: %w", err)
+		}
+		exports = append(exports, e)
+	}
+
+	return exports, rows.Err()
+}
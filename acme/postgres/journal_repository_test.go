@@ -0,0 +1,22 @@
+package postgres_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/acme/postgres"
+	"github.com/stevenferrer/acme-cards-api/acme/repotest"
+)
+
+func TestJournalRepository(t *testing.T) {
+	db := newTestDB(t)
+
+	repotest.RunJournalRepositorySuite(t, func(t *testing.T) acme.JournalRepository {
+		_, err := db.Exec(`truncate table journal_accounts, journal_exports cascade`)
+		require.NoError(t, err)
+
+		return postgres.NewJournalRepository(db)
+	})
+}
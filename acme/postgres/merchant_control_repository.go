@@ -0,0 +1,173 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+)
+
+// merchantControlLockSpace is hashed into the first key of the enforcement
+// advisory lock
+const merchantControlLockSpace = "merchant_controls"
+
+type MerchantControlRepository struct {
+	db *sql.DB
+}
+
+var _ acme.MerchantControlRepository = (*MerchantControlRepository)(nil)
+
+func NewMerchantControlRepository(db *sql.DB) *MerchantControlRepository {
+	return &MerchantControlRepository{db: db}
+}
+
+// SaveMerchantControl implements acme.MerchantControlRepository.
+func (r *MerchantControlRepository) SaveMerchantControl(ctx context.Context, control *acme.MerchantControl) error {
+	stmnt := `insert into merchant_controls (card_id, group_id, kind, target, value)
+	values ($1, $2, $3, $4, $5)
+	returning id, created_at`
+
+	err := r.db.QueryRowContext(ctx, stmnt,
+		sql.NullString{String: control.CardID, Valid: control.CardID != ""},
+		sql.NullInt64{Int64: control.GroupID, Valid: control.GroupID != 0},
+		control.Kind, control.Target, control.Value,
+	).Scan(&control.ID, &control.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("query row context: %w", err)
+	}
+
+	return nil
+}
+
+// FindMerchantControls implements acme.MerchantControlRepository.
+func (r *MerchantControlRepository) FindMerchantControls(ctx context.Context, cardID string) ([]acme.MerchantControl, error) {
+	stmnt := `select id, card_id, group_id, kind, target, value, created_at
+	from merchant_controls
+	where $1::text = ''
+		or (card_id is null and group_id is null)
+		or card_id = $1::text
+		or group_id = (select group_id from card_group_members where card_id = $1::text)
+	order by id`
+
+	rows, err := r.db.QueryContext(ctx, stmnt, cardID)
+	if err != nil {
+		return nil, fmt.Errorf("query context: %w", err)
+	}
+	defer rows.Close()
+
+	controls := make([]acme.MerchantControl, 0)
+	for rows.Next() {
+		var c acme.MerchantControl
+		var controlCardID sql.NullString
+		var groupID sql.NullInt64
+		err = rows.Scan(&c.ID, &controlCardID, &groupID, &c.Kind, &c.Target, &c.Value, &c.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("row scan: %w", err)
+		}
+
+		c.CardID = controlCardID.String
+		c.GroupID = groupID.Int64
+		controls = append(controls, c)
+	}
+
+	return controls, rows.Err()
+}
+
+// DeleteMerchantControl implements acme.MerchantControlRepository.
+func (r *MerchantControlRepository) DeleteMerchantControl(ctx context.Context, id int64) error {
+	stmnt := `delete from merchant_controls where id = $1`
+	res, err := r.db.ExecContext(ctx, stmnt, id)
+	if err != nil {
+		return fmt.Errorf("exec context: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+	if n == 0 {
+		return acme.ErrMerchantControlNotFound
+	}
+
+	return nil
+}
+
+// LockMerchantControls implements acme.MerchantControlRepository.
+func (r *MerchantControlRepository) LockMerchantControls(ctx context.Context, fn func(context.Context) error) (bool, error) {
+	return withAdvisoryLock(ctx, r.db, merchantControlLockSpace, 0, true, fn)
+}
+
+// MerchantControlViolationExists implements acme.MerchantControlRepository.
+func (r *MerchantControlRepository) MerchantControlViolationExists(ctx context.Context, transactionID string) (bool, error) {
+	stmnt := `select exists (select 1 from merchant_control_violations where transaction_id = $1)`
+
+	var exists bool
+	err := r.db.QueryRowContext(ctx, stmnt, transactionID).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("query row context: %w", err)
+	}
+
+	return exists, nil
+}
+
+// SaveMerchantControlViolation implements acme.MerchantControlRepository.
+func (r *MerchantControlRepository) SaveMerchantControlViolation(ctx context.Context, v acme.MerchantControlViolation) error {
+	stmnt := `insert into merchant_control_violations (
+		transaction_id, card_id, control_id, reason, mcc_code, merchant_id,
+		merchant_name, amount, currency, card_frozen, created_at
+	) values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`
+
+	_, err := r.db.ExecContext(ctx, stmnt,
+		v.TransactionID, v.CardID, sql.NullInt64{Int64: v.ControlID, Valid: v.ControlID != 0}, v.Reason, v.MCCCode, v.MerchantID,
+		v.MerchantName, v.Amount.Minor(), v.Amount.Currency(), v.CardFrozen, v.CreatedAt,
+	)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+			return acme.ErrMerchantControlViolationExists
+		}
+		return fmt.Errorf("exec context: %w", err)
+	}
+
+	return nil
+}
+
+// FindMerchantControlViolations implements acme.MerchantControlRepository.
+func (r *MerchantControlRepository) FindMerchantControlViolations(ctx context.Context) ([]acme.MerchantControlViolation, error) {
+	stmnt := `select
+		transaction_id, card_id, control_id, reason, mcc_code, merchant_id,
+		merchant_name, amount, currency, card_frozen, created_at
+	from merchant_control_violations
+	order by created_at desc`
+
+	rows, err := r.db.QueryContext(ctx, stmnt)
+	if err != nil {
+		return nil, fmt.Errorf("query context: %w", err)
+	}
+	defer rows.Close()
+
+	violations := make([]acme.MerchantControlViolation, 0)
+	for rows.Next() {
+		var v acme.MerchantControlViolation
+		var controlID sql.NullInt64
+		var amount int64
+		var currency string
+		err = rows.Scan(
+			&v.TransactionID, &v.CardID, &controlID, &v.Reason, &v.MCCCode, &v.MerchantID,
+			&v.MerchantName, &amount, &currency, &v.CardFrozen, &v.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("row scan: %w", err)
+		}
+
+		v.ControlID = controlID.Int64
+		v.Amount = acme.NewMoney(amount, currency)
+		violations = append(violations, v)
+	}
+
+	return violations, rows.Err()
+}
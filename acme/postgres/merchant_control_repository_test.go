@@ -0,0 +1,22 @@
+package postgres_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/acme/postgres"
+	"github.com/stevenferrer/acme-cards-api/acme/repotest"
+)
+
+func TestMerchantControlRepository(t *testing.T) {
+	db := newTestDB(t)
+
+	repotest.RunMerchantControlRepositorySuite(t, func(t *testing.T) (acme.CardRepository, acme.CardGroupRepository, acme.MerchantControlRepository) {
+		_, err := db.Exec(`truncate table cards, card_groups, merchant_controls, merchant_control_violations cascade`)
+		require.NoError(t, err)
+
+		return postgres.NewCardRepository(db), postgres.NewCardGroupRepository(db), postgres.NewMerchantControlRepository(db)
+	})
+}
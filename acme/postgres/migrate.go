@@ -2,10 +2,14 @@ package postgres
 
 import (
 	"database/sql"
+	"embed"
+	"io/fs"
 
 	// postgres driver
 	_ "github.com/lib/pq"
 	"github.com/lopezator/migrator"
+
+	"github.com/stevenferrer/acme-cards-api/x/xmigrate"
 )
 
 // defaultOpts is the migration options
@@ -17,13 +21,29 @@ func (*nopLogger) Printf(string, ...interface{}) {}
 
 func newNopLogger() migrator.Logger { return &nopLogger{} }
 
+//go:embed migrations/*.sql
+var migrationsFS embed.FS
+
+// MigrationsDir is the migrations directory relative to the module root.
+const MigrationsDir = "acme/postgres/migrations"
+
+// Migrations returns the embedded migrations.
+func Migrations() []xmigrate.Migration {
+	sub, err := fs.Sub(migrationsFS, "migrations")
+	if err != nil {
+		panic(err)
+	}
+
+	return xmigrate.MustLoad(sub)
+}
+
 // Migrate migrates the database.
 func Migrate(db *sql.DB, opts ...migrator.Option) error {
 	if len(opts) == 0 {
 		opts = defaultOpts
 	}
 
-	opts = append(opts, migrations)
+	opts = append(opts, xmigrate.Migrations(Migrations()))
 
 	m, err := migrator.New(opts...)
 	if err != nil {
@@ -35,38 +55,32 @@ func Migrate(db *sql.DB, opts ...migrator.Option) error {
 
 // MustMigrate migrates the database and panics if an error occurs.
 func MustMigrate(db *sql.DB, opts ...migrator.Option) {
-	if len(opts) == 0 {
-		opts = defaultOpts
-	}
-
-	opts = append(opts, migrations)
-
-	m, err := migrator.New(opts...)
+	err := Migrate(db, opts...)
 	if err != nil {
 		panic(err)
 	}
+}
 
-	err = m.Migrate(db)
-	if err != nil {
-		panic(err)
+// Rollback rolls back the last n applied migrations.
+func Rollback(db *sql.DB, n int, logger migrator.Logger) error {
+	if logger == nil {
+		logger = newNopLogger()
 	}
+
+	return xmigrate.Down(db, Migrations(), n, logger)
 }
 
-var migrations = migrator.Migrations(
-	&migrator.Migration{
-		Name: "Create cards table",
-		Func: func(tx *sql.Tx) error {
-			stmnt := `CREATE TABLE IF NOT EXISTS "cards" (
-				id varchar(32) PRIMARY KEY,
-				external_id varchar(36),
-				deleted_at timestamp,
-				created_at timestamp NOT NULL DEFAULT now()
-			)`
-			if _, err := tx.Exec(stmnt); err != nil {
-				return err
-			}
-
-			return nil
-		},
-	},
-)
+// MigrationStatuses returns the applied and pending migrations.
+func MigrationStatuses(db *sql.DB) ([]xmigrate.Status, error) {
+	return xmigrate.Statuses(db, Migrations())
+}
+
+// PendingMigrations returns the migrations that are not yet applied.
+func PendingMigrations(db *sql.DB) ([]xmigrate.Migration, error) {
+	return xmigrate.Pending(db, Migrations())
+}
+
+// RollbackMigrations returns the last n applied migrations in rollback order.
+func RollbackMigrations(db *sql.DB, n int) ([]xmigrate.Migration, error) {
+	return xmigrate.Rollbacks(db, Migrations(), n)
+}
@@ -0,0 +1,10 @@
+DELETE FROM "card_snapshots";
+
+ALTER TABLE "card_snapshots"
+	ALTER COLUMN available_credit TYPE text,
+	ALTER COLUMN daily_spent TYPE text,
+	ALTER COLUMN weekly_spent TYPE text,
+	ALTER COLUMN monthly_spent TYPE text,
+	ALTER COLUMN yearly_spent TYPE text,
+	ALTER COLUMN all_time_spent TYPE text,
+	DROP COLUMN currency;
@@ -0,0 +1,11 @@
+-- snapshots are a cache, the next sync refills them
+DELETE FROM "card_snapshots";
+
+ALTER TABLE "card_snapshots"
+	ALTER COLUMN available_credit TYPE bigint USING 0,
+	ALTER COLUMN daily_spent TYPE bigint USING 0,
+	ALTER COLUMN weekly_spent TYPE bigint USING 0,
+	ALTER COLUMN monthly_spent TYPE bigint USING 0,
+	ALTER COLUMN yearly_spent TYPE bigint USING 0,
+	ALTER COLUMN all_time_spent TYPE bigint USING 0,
+	ADD COLUMN currency varchar(3) NOT NULL;
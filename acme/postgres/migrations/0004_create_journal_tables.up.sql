@@ -0,0 +1,29 @@
+CREATE TABLE IF NOT EXISTS "journal_accounts" (
+	role text PRIMARY KEY,
+	account text NOT NULL,
+	updated_at timestamp NOT NULL DEFAULT now()
+);
+
+-- period_end of the latest export is the watermark of the next one
+CREATE TABLE IF NOT EXISTS "journal_exports" (
+	id bigserial PRIMARY KEY,
+	period_start timestamp NOT NULL,
+	period_end timestamp NOT NULL,
+	created_at timestamp NOT NULL DEFAULT now()
+);
+
+-- the primary key guards against booking a transaction twice
+CREATE TABLE IF NOT EXISTS "journal_lines" (
+	transaction_id text NOT NULL,
+	line_no integer NOT NULL,
+	export_id bigint NOT NULL REFERENCES journal_exports (id) ON DELETE CASCADE,
+	booked_at timestamp NOT NULL,
+	account text NOT NULL,
+	description text NOT NULL,
+	debit bigint NOT NULL,
+	credit bigint NOT NULL,
+	currency varchar(3) NOT NULL,
+	PRIMARY KEY (transaction_id, line_no)
+);
+
+CREATE INDEX IF NOT EXISTS journal_lines_export_id_idx ON "journal_lines" (export_id);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "card_statement_entries";
+DROP TABLE IF EXISTS "card_statements";
@@ -0,0 +1,33 @@
+-- statements of complete months, they never change once issued
+CREATE TABLE IF NOT EXISTS "card_statements" (
+	card_id varchar(32) NOT NULL REFERENCES cards (id) ON DELETE CASCADE,
+	month date NOT NULL,
+	card_name text NOT NULL,
+	card_last4 varchar(4) NOT NULL,
+	opening_balance bigint NOT NULL,
+	closing_balance bigint NOT NULL,
+	top_ups bigint NOT NULL,
+	withdrawals bigint NOT NULL,
+	purchases bigint NOT NULL,
+	refunds bigint NOT NULL,
+	fees bigint NOT NULL,
+	currency varchar(3) NOT NULL,
+	generated_at timestamp NOT NULL,
+	PRIMARY KEY (card_id, month)
+);
+
+CREATE TABLE IF NOT EXISTS "card_statement_entries" (
+	card_id varchar(32) NOT NULL,
+	month date NOT NULL,
+	position integer NOT NULL,
+	entry_id text NOT NULL,
+	entry_type varchar(8) NOT NULL,
+	date timestamp NOT NULL,
+	amount bigint NOT NULL,
+	currency varchar(3) NOT NULL,
+	payee text NOT NULL,
+	category text NOT NULL,
+	memo text NOT NULL,
+	PRIMARY KEY (card_id, month, position),
+	FOREIGN KEY (card_id, month) REFERENCES card_statements (card_id, month) ON DELETE CASCADE
+);
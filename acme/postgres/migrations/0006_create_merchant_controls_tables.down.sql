@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "merchant_control_violations";
+DROP TABLE IF EXISTS "merchant_controls";
@@ -0,0 +1,25 @@
+-- controls without a card apply to every card
+CREATE TABLE IF NOT EXISTS "merchant_controls" (
+	id bigserial PRIMARY KEY,
+	card_id varchar(32) REFERENCES cards (id) ON DELETE CASCADE,
+	kind varchar(8) NOT NULL,
+	target varchar(16) NOT NULL,
+	value text NOT NULL,
+	created_at timestamp NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS merchant_controls_card_id_idx ON "merchant_controls" (card_id);
+
+CREATE TABLE IF NOT EXISTS "merchant_control_violations" (
+	transaction_id text PRIMARY KEY,
+	card_id varchar(32) NOT NULL,
+	control_id bigint,
+	reason text NOT NULL,
+	mcc_code text NOT NULL,
+	merchant_id text NOT NULL,
+	merchant_name text NOT NULL,
+	amount bigint NOT NULL,
+	currency varchar(3) NOT NULL,
+	card_frozen boolean NOT NULL,
+	created_at timestamp NOT NULL
+);
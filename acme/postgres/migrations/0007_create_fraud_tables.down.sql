@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "fraud_decisions";
+DROP TABLE IF EXISTS "fraud_rules";
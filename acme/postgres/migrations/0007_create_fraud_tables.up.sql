@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS "fraud_rules" (
+	id bigserial PRIMARY KEY,
+	name text NOT NULL,
+	type varchar(32) NOT NULL,
+	params jsonb NOT NULL,
+	action varchar(16) NOT NULL,
+	dry_run boolean NOT NULL,
+	enabled boolean NOT NULL,
+	created_at timestamp NOT NULL DEFAULT now()
+);
+
+-- decisions outlive their rules to explain past actions
+CREATE TABLE IF NOT EXISTS "fraud_decisions" (
+	id bigserial PRIMARY KEY,
+	rule_id bigint NOT NULL,
+	rule_name text NOT NULL,
+	transaction_id text NOT NULL,
+	card_id varchar(32) NOT NULL,
+	action varchar(16) NOT NULL,
+	dry_run boolean NOT NULL,
+	reason text NOT NULL,
+	created_at timestamp NOT NULL,
+	UNIQUE (rule_id, transaction_id)
+);
+
+CREATE INDEX IF NOT EXISTS fraud_decisions_card_id_idx ON "fraud_decisions" (card_id);
@@ -0,0 +1,44 @@
+-- empty event types subscribe to every event type
+CREATE TABLE IF NOT EXISTS "webhook_subscriptions" (
+	id bigserial PRIMARY KEY,
+	url text NOT NULL,
+	secret text NOT NULL,
+	event_types text[] NOT NULL,
+	created_at timestamp NOT NULL DEFAULT now()
+);
+
+-- the payload is kept as text to send the body as published
+CREATE TABLE IF NOT EXISTS "webhook_events" (
+	id varchar(36) PRIMARY KEY,
+	type varchar(64) NOT NULL,
+	card_id varchar(32) NOT NULL,
+	payload text NOT NULL,
+	created_at timestamp NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS "webhook_deliveries" (
+	id bigserial PRIMARY KEY,
+	subscription_id bigint NOT NULL REFERENCES webhook_subscriptions (id) ON DELETE CASCADE,
+	event_id varchar(36) NOT NULL REFERENCES webhook_events (id) ON DELETE CASCADE,
+	status varchar(16) NOT NULL,
+	attempts int NOT NULL DEFAULT 0,
+	next_attempt_at timestamp NOT NULL,
+	response_code int NOT NULL DEFAULT 0,
+	error text NOT NULL DEFAULT '',
+	created_at timestamp NOT NULL,
+	updated_at timestamp NOT NULL,
+	UNIQUE (subscription_id, event_id)
+);
+
+CREATE INDEX IF NOT EXISTS webhook_deliveries_due_idx ON "webhook_deliveries" (next_attempt_at)
+	WHERE status = 'pending';
+
+CREATE TABLE IF NOT EXISTS "webhook_delivery_attempts" (
+	delivery_id bigint NOT NULL REFERENCES webhook_deliveries (id) ON DELETE CASCADE,
+	attempt int NOT NULL,
+	response_code int NOT NULL,
+	error text NOT NULL,
+	duration_ms bigint NOT NULL,
+	created_at timestamp NOT NULL,
+	PRIMARY KEY (delivery_id, attempt)
+);
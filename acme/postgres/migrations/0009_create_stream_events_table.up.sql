@@ -0,0 +1,12 @@
+-- stream events are kept for a day to dedupe the published events and to
+-- catch up after a lost listener connection
+CREATE TABLE IF NOT EXISTS "stream_events" (
+	seq bigserial PRIMARY KEY,
+	id varchar(36) NOT NULL UNIQUE,
+	type varchar(64) NOT NULL,
+	card_id varchar(32) NOT NULL,
+	payload text NOT NULL,
+	created_at timestamp NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS stream_events_created_at_idx ON "stream_events" (created_at);
@@ -0,0 +1,32 @@
+-- amounts are only set on the funded notifications
+CREATE TABLE IF NOT EXISTS "notifications" (
+	id bigserial PRIMARY KEY,
+	event_id varchar(36) NOT NULL,
+	type varchar(32) NOT NULL,
+	card_id varchar(32) NOT NULL,
+	amount bigint NOT NULL DEFAULT 0,
+	available_credit bigint NOT NULL DEFAULT 0,
+	currency varchar(3) NOT NULL DEFAULT '',
+	email text NOT NULL DEFAULT '',
+	subject text NOT NULL DEFAULT '',
+	status varchar(16) NOT NULL,
+	attempts int NOT NULL DEFAULT 0,
+	next_attempt_at timestamp NOT NULL,
+	error text NOT NULL DEFAULT '',
+	created_at timestamp NOT NULL,
+	sent_at timestamp,
+	UNIQUE (event_id, type)
+);
+
+CREATE INDEX IF NOT EXISTS notifications_card_id_idx ON "notifications" (card_id);
+
+CREATE INDEX IF NOT EXISTS notifications_due_idx ON "notifications" (next_attempt_at)
+	WHERE status = 'pending';
+
+CREATE TABLE IF NOT EXISTS "notification_preferences" (
+	email text PRIMARY KEY,
+	locale varchar(16) NOT NULL,
+	opt_out_all boolean NOT NULL,
+	opt_out_types text[] NOT NULL,
+	updated_at timestamp NOT NULL
+);
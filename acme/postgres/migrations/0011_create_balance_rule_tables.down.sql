@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "balance_rule_executions";
+DROP TABLE IF EXISTS "balance_rules";
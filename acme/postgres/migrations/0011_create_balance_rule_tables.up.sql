@@ -0,0 +1,34 @@
+-- the top up amounts are zero on the alert rules
+CREATE TABLE IF NOT EXISTS "balance_rules" (
+	id bigserial PRIMARY KEY,
+	card_id varchar(32) NOT NULL REFERENCES cards (id) ON DELETE CASCADE,
+	kind varchar(16) NOT NULL,
+	threshold bigint NOT NULL,
+	top_up_to bigint NOT NULL,
+	monthly_limit bigint NOT NULL,
+	currency varchar(3) NOT NULL,
+	triggered_at timestamp,
+	leased_until timestamp,
+	created_at timestamp NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS balance_rules_card_id_idx ON "balance_rules" (card_id);
+
+-- the executions are kept when their rule is deleted
+CREATE TABLE IF NOT EXISTS "balance_rule_executions" (
+	id bigserial PRIMARY KEY,
+	rule_id bigint NOT NULL,
+	card_id varchar(32) NOT NULL,
+	kind varchar(16) NOT NULL,
+	status varchar(16) NOT NULL,
+	available_credit bigint NOT NULL,
+	amount bigint NOT NULL,
+	currency varchar(3) NOT NULL,
+	adjustment_id text NOT NULL,
+	reason text NOT NULL,
+	created_at timestamp NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS balance_rule_executions_rule_id_idx ON "balance_rule_executions" (rule_id, created_at);
+
+CREATE INDEX IF NOT EXISTS balance_rule_executions_card_id_idx ON "balance_rule_executions" (card_id);
@@ -0,0 +1,41 @@
+-- next_run_at is null once the schedule ended
+CREATE TABLE IF NOT EXISTS "allowance_schedules" (
+	id bigserial PRIMARY KEY,
+	card_id varchar(32) NOT NULL REFERENCES cards (id) ON DELETE CASCADE,
+	amount bigint NOT NULL,
+	currency varchar(3) NOT NULL,
+	cadence text NOT NULL,
+	mode varchar(16) NOT NULL,
+	start_date date NOT NULL,
+	end_date date,
+	next_run_at timestamp,
+	created_at timestamp NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS allowance_schedules_card_id_idx ON "allowance_schedules" (card_id);
+
+CREATE INDEX IF NOT EXISTS allowance_schedules_next_run_at_idx ON "allowance_schedules" (next_run_at)
+	WHERE next_run_at IS NOT NULL;
+
+-- the runs are kept when their schedule is deleted
+CREATE TABLE IF NOT EXISTS "allowance_runs" (
+	id bigserial PRIMARY KEY,
+	schedule_id bigint NOT NULL,
+	card_id varchar(32) NOT NULL,
+	scheduled_at timestamp NOT NULL,
+	mode varchar(16) NOT NULL,
+	status varchar(16) NOT NULL,
+	adjustment_type varchar(16) NOT NULL,
+	amount bigint NOT NULL,
+	available_credit_before bigint NOT NULL,
+	available_credit_after bigint NOT NULL,
+	currency varchar(3) NOT NULL,
+	adjustment_id text NOT NULL,
+	attempts int NOT NULL,
+	error text NOT NULL,
+	created_at timestamp NOT NULL,
+	updated_at timestamp NOT NULL,
+	UNIQUE (schedule_id, scheduled_at)
+);
+
+CREATE INDEX IF NOT EXISTS allowance_runs_card_id_idx ON "allowance_runs" (card_id);
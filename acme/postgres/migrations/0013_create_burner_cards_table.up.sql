@@ -0,0 +1,14 @@
+-- the merchant id of a merchant-locked card is empty until its first
+-- transaction when not given
+CREATE TABLE IF NOT EXISTS "burner_cards" (
+	card_id varchar(32) PRIMARY KEY REFERENCES cards (id) ON DELETE CASCADE,
+	mode varchar(16) NOT NULL,
+	merchant_id text NOT NULL,
+	expires_at timestamp,
+	frozen_at timestamp,
+	ended_at timestamp,
+	end_reason text NOT NULL DEFAULT '',
+	created_at timestamp NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS burner_cards_active_idx ON "burner_cards" (created_at) WHERE ended_at IS NULL;
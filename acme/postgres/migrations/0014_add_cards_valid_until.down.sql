@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS cards_valid_until_idx;
+
+ALTER TABLE "cards"
+	DROP COLUMN IF EXISTS valid_until,
+	DROP COLUMN IF EXISTS expiry_action,
+	DROP COLUMN IF EXISTS expiry_notified_at,
+	DROP COLUMN IF EXISTS expired_at;
@@ -0,0 +1,8 @@
+-- the expiry action is empty on the cards without a valid until
+ALTER TABLE "cards"
+	ADD COLUMN IF NOT EXISTS valid_until timestamp,
+	ADD COLUMN IF NOT EXISTS expiry_action varchar(16) NOT NULL DEFAULT '',
+	ADD COLUMN IF NOT EXISTS expiry_notified_at timestamp,
+	ADD COLUMN IF NOT EXISTS expired_at timestamp;
+
+CREATE INDEX IF NOT EXISTS cards_valid_until_idx ON "cards" (valid_until) WHERE valid_until IS NOT NULL;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS "spend_request_approvers";
+DROP TABLE IF EXISTS "spend_request_activities";
+DROP TABLE IF EXISTS "spend_requests";
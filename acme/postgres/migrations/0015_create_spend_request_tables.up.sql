@@ -0,0 +1,39 @@
+CREATE TABLE IF NOT EXISTS "spend_requests" (
+	id bigserial PRIMARY KEY,
+	card_id varchar(32) NOT NULL REFERENCES cards (id) ON DELETE CASCADE,
+	requester text NOT NULL,
+	amount bigint NOT NULL,
+	currency varchar(3) NOT NULL,
+	justification text NOT NULL,
+	status varchar(16) NOT NULL,
+	required_approvals int NOT NULL,
+	adjustment_id text NOT NULL,
+	error text NOT NULL,
+	created_at timestamp NOT NULL DEFAULT now(),
+	updated_at timestamp NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS spend_requests_card_id_idx ON "spend_requests" (card_id);
+
+CREATE INDEX IF NOT EXISTS spend_requests_status_idx ON "spend_requests" (status);
+
+CREATE TABLE IF NOT EXISTS "spend_request_activities" (
+	id bigserial PRIMARY KEY,
+	request_id bigint NOT NULL REFERENCES spend_requests (id) ON DELETE CASCADE,
+	kind varchar(16) NOT NULL,
+	actor text NOT NULL,
+	comment text NOT NULL,
+	created_at timestamp NOT NULL DEFAULT now()
+);
+
+-- an approver approves a request once
+CREATE UNIQUE INDEX IF NOT EXISTS spend_request_activities_approval_idx ON "spend_request_activities" (request_id, actor) WHERE kind = 'approved';
+
+-- the limit is zero on the approvers without limit
+CREATE TABLE IF NOT EXISTS "spend_request_approvers" (
+	name text PRIMARY KEY,
+	approval_limit bigint NOT NULL,
+	currency varchar(3) NOT NULL,
+	created_at timestamp NOT NULL DEFAULT now(),
+	updated_at timestamp NOT NULL DEFAULT now()
+);
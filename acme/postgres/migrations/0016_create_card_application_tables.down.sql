@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "card_application_events";
+DROP TABLE IF EXISTS "card_applications";
@@ -0,0 +1,28 @@
+-- params is the KYC and the options of the card to create
+CREATE TABLE IF NOT EXISTS "card_applications" (
+	id bigserial PRIMARY KEY,
+	params jsonb NOT NULL,
+	maker text NOT NULL,
+	checker text NOT NULL,
+	status varchar(16) NOT NULL,
+	rejection_reason text NOT NULL,
+	card_id varchar(32) NOT NULL,
+	error text NOT NULL,
+	expires_at timestamp NOT NULL,
+	created_at timestamp NOT NULL DEFAULT now(),
+	updated_at timestamp NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS card_applications_status_idx ON "card_applications" (status);
+
+-- the audit trail is append only
+CREATE TABLE IF NOT EXISTS "card_application_events" (
+	id bigserial PRIMARY KEY,
+	application_id bigint NOT NULL REFERENCES card_applications (id) ON DELETE CASCADE,
+	action varchar(16) NOT NULL,
+	actor text NOT NULL,
+	reason text NOT NULL,
+	created_at timestamp NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS card_application_events_application_id_idx ON "card_application_events" (application_id);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "card_group_members";
+DROP TABLE IF EXISTS "card_groups";
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS "card_groups" (
+	id bigserial PRIMARY KEY,
+	name text NOT NULL,
+	budget bigint NOT NULL,
+	currency varchar(3) NOT NULL,
+	period varchar(16) NOT NULL,
+	created_at timestamp NOT NULL DEFAULT now(),
+	updated_at timestamp NOT NULL DEFAULT now()
+);
+
+-- a card is in at most one group
+CREATE TABLE IF NOT EXISTS "card_group_members" (
+	card_id varchar(32) PRIMARY KEY REFERENCES cards (id) ON DELETE CASCADE,
+	group_id bigint NOT NULL REFERENCES card_groups (id) ON DELETE CASCADE,
+	created_at timestamp NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS card_group_members_group_id_idx ON "card_group_members" (group_id);
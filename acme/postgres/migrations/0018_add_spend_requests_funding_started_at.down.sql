@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS spend_requests_adjustment_id_idx;
+
+ALTER TABLE "spend_requests" DROP COLUMN IF EXISTS funding_started_at;
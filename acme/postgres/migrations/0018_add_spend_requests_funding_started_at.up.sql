@@ -0,0 +1,5 @@
+-- the funding start bounds the balance history searched for a top-up whose
+-- outcome is unknown
+ALTER TABLE "spend_requests" ADD COLUMN IF NOT EXISTS funding_started_at timestamp;
+
+CREATE INDEX IF NOT EXISTS spend_requests_adjustment_id_idx ON "spend_requests" (adjustment_id) WHERE adjustment_id <> '';
@@ -0,0 +1 @@
+ALTER TABLE "merchant_controls" DROP COLUMN IF EXISTS group_id;
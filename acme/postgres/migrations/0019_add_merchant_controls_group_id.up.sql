@@ -0,0 +1,2 @@
+-- controls with a group apply to the member cards of the group
+ALTER TABLE "merchant_controls" ADD COLUMN IF NOT EXISTS group_id bigint REFERENCES card_groups (id) ON DELETE CASCADE;
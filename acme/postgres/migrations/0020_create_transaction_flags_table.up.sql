@@ -0,0 +1,11 @@
+-- a transaction has at most one flag, the first rule that flagged it
+CREATE TABLE IF NOT EXISTS "transaction_flags" (
+	transaction_id text PRIMARY KEY,
+	card_id varchar(32) NOT NULL,
+	rule_id bigint NOT NULL,
+	reason text NOT NULL,
+	cleared_at timestamp,
+	created_at timestamp NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS transaction_flags_card_id_idx ON "transaction_flags" (card_id);
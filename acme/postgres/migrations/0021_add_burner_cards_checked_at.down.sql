@@ -0,0 +1 @@
+ALTER TABLE "burner_cards" DROP COLUMN IF EXISTS checked_at;
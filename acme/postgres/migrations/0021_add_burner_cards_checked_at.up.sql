@@ -0,0 +1,3 @@
+-- the transactions of a burner card are checked from a lookback before
+-- checked_at, or from its creation when null
+ALTER TABLE "burner_cards" ADD COLUMN IF NOT EXISTS checked_at timestamp;
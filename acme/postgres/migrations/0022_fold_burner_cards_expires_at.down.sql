@@ -0,0 +1,2 @@
+-- the expiries stay on the cards
+ALTER TABLE "burner_cards" ADD COLUMN IF NOT EXISTS expires_at timestamp;
@@ -0,0 +1,14 @@
+-- the burner card expiry is the valid until of the card, the cards are
+-- terminated unless their own valid until comes first
+UPDATE "cards" c SET
+	valid_until = b.expires_at,
+	expiry_action = 'TERMINATED',
+	expiry_notified_at = NULL,
+	expired_at = NULL
+FROM "burner_cards" b
+WHERE b.card_id = c.id
+	AND b.expires_at IS NOT NULL
+	AND b.ended_at IS NULL
+	AND (c.valid_until IS NULL OR b.expires_at < c.valid_until);
+
+ALTER TABLE "burner_cards" DROP COLUMN IF EXISTS expires_at;
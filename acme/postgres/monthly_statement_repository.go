@@ -0,0 +1,129 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/acme/export"
+)
+
+type MonthlyStatementRepository struct {
+	db *sql.DB
+}
+
+var _ export.MonthlyStatementRepository = (*MonthlyStatementRepository)(nil)
+
+func NewMonthlyStatementRepository(db *sql.DB) *MonthlyStatementRepository {
+	return &MonthlyStatementRepository{db: db}
+}
+
+// SaveMonthlyStatement implements export.MonthlyStatementRepository.
+func (r *MonthlyStatementRepository) SaveMonthlyStatement(ctx context.Context, s export.MonthlyStatement) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmnt := `insert into card_statements (
+		card_id, month, card_name, card_last4, opening_balance, closing_balance,
+		top_ups, withdrawals, purchases, refunds, fees, currency, generated_at
+	) values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`
+
+	// amounts are stored in minor units of the closing balance currency
+	_, err = tx.ExecContext(ctx, stmnt,
+		s.Card.ID, s.Month, s.Card.Name, s.Card.Last4, s.OpeningBalance.Minor(), s.ClosingBalance.Minor(),
+		s.TopUps.Minor(), s.Withdrawals.Minor(), s.Purchases.Minor(), s.Refunds.Minor(), s.Fees.Minor(),
+		s.ClosingBalance.Currency(), s.GeneratedAt,
+	)
+	if err != nil {
+		var pqErr *pq.Error
+		// a concurrent request saved the same statement
+		if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+			return nil
+		}
+		return fmt.Errorf("exec context: %w", err)
+	}
+
+	stmnt = `insert into card_statement_entries (
+		card_id, month, position, entry_id, entry_type, date,
+		amount, currency, payee, category, memo
+	) values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`
+
+	for i, e := range s.Entries {
+		_, err = tx.ExecContext(ctx, stmnt,
+			s.Card.ID, s.Month, i, e.ID, e.Type, e.Date,
+			e.Amount.Minor(), e.Amount.Currency(), e.Payee, e.Category, e.Memo,
+		)
+		if err != nil {
+			return fmt.Errorf("exec context: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetMonthlyStatement implements export.MonthlyStatementRepository.
+func (r *MonthlyStatementRepository) GetMonthlyStatement(ctx context.Context, cardID string, month time.Time) (*export.MonthlyStatement, error) {
+	stmnt := `select
+		card_id, month, card_name, card_last4, opening_balance, closing_balance,
+		top_ups, withdrawals, purchases, refunds, fees, currency, generated_at
+	from card_statements
+	where card_id = $1 and month = $2`
+
+	var s export.MonthlyStatement
+	var opening, closing, topUps, withdrawals, purchases, refunds, fees int64
+	var currency string
+	err := r.db.QueryRowContext(ctx, stmnt, cardID, month).Scan(
+		&s.Card.ID, &s.Month, &s.Card.Name, &s.Card.Last4, &opening, &closing,
+		&topUps, &withdrawals, &purchases, &refunds, &fees, &currency, &s.GeneratedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, export.ErrMonthlyStatementNotFound
+		}
+		return nil, fmt.Errorf("query row context: %w", err)
+	}
+
+	s.Month = s.Month.UTC()
+	s.OpeningBalance = acme.NewMoney(opening, currency)
+	s.ClosingBalance = acme.NewMoney(closing, currency)
+	s.TopUps = acme.NewMoney(topUps, currency)
+	s.Withdrawals = acme.NewMoney(withdrawals, currency)
+	s.Purchases = acme.NewMoney(purchases, currency)
+	s.Refunds = acme.NewMoney(refunds, currency)
+	s.Fees = acme.NewMoney(fees, currency)
+
+	stmnt = `select entry_id, entry_type, date, amount, currency, payee, category, memo
+	from card_statement_entries
+	where card_id = $1 and month = $2
+	order by position`
+
+	rows, err := r.db.QueryContext(ctx, stmnt, cardID, month)
+	if err != nil {
+		return nil, fmt.Errorf("query context: %w", err)
+	}
+	defer rows.Close()
+
+	s.Entries = make([]export.StatementEntry, 0)
+	for rows.Next() {
+		var e export.StatementEntry
+		var amount int64
+		var entryCurrency string
+		err = rows.Scan(&e.ID, &e.Type, &e.Date, &amount, &entryCurrency, &e.Payee, &e.Category, &e.Memo)
+		if err != nil {
+			return nil, fmt.Errorf("row scan: %w", err)
+		}
+
+		e.Amount = acme.NewMoney(amount, entryCurrency)
+		s.Entries = append(s.Entries, e)
+	}
+
+	return &s, rows.Err()
+}
@@ -0,0 +1,23 @@
+package postgres_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/acme/export"
+	"github.com/stevenferrer/acme-cards-api/acme/postgres"
+	"github.com/stevenferrer/acme-cards-api/acme/repotest"
+)
+
+func TestMonthlyStatementRepository(t *testing.T) {
+	db := newTestDB(t)
+
+	repotest.RunMonthlyStatementRepositorySuite(t, func(t *testing.T) (acme.CardRepository, export.MonthlyStatementRepository) {
+		_, err := db.Exec(`truncate table cards cascade`)
+		require.NoError(t, err)
+
+		return postgres.NewCardRepository(db), postgres.NewMonthlyStatementRepository(db)
+	})
+}
@@ -0,0 +1,189 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+)
+
+type NotificationRepository struct {
+	db *sql.DB
+}
+
+var _ acme.NotificationRepository = (*NotificationRepository)(nil)
+
+func NewNotificationRepository(db *sql.DB) *NotificationRepository {
+	return &NotificationRepository{db: db}
+}
+
+// SaveNotification implements acme.NotificationRepository.
+func (r *NotificationRepository) SaveNotification(ctx context.Context, n *acme.Notification) error {
+	stmnt := `insert into notifications (
+		event_id, type, card_id, amount, available_credit, currency,
+		status, next_attempt_at, created_at
+	) values ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	returning id`
+
+	err := r.db.QueryRowContext(ctx, stmnt,
+		n.EventID, n.Type, n.CardID, n.Amount.Minor(), n.AvailableCredit.Minor(), n.Amount.Currency(),
+		n.Status, n.NextAttemptAt, n.CreatedAt,
+	).Scan(&n.ID)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+			return acme.ErrNotificationExists
+		}
+		return fmt.Errorf("query row context: %w", err)
+	}
+
+	return nil
+}
+
+const selectNotifications = `select
+	id, event_id, type, card_id, amount, available_credit, currency, email, subject,
+	status, attempts, next_attempt_at, error, created_at, sent_at
+from notifications`
+
+func scanNotification(row rowScanner) (acme.Notification, error) {
+	var n acme.Notification
+	var amount, availableCredit int64
+	var currency string
+	var sentAt sql.NullTime
+	err := row.Scan(
+		&n.ID, &n.EventID, &n.Type, &n.CardID, &amount, &availableCredit, &currency, &n.Email, &n.Subject,
+		&n.Status, &n.Attempts, &n.NextAttemptAt, &n.Error, &n.CreatedAt, &sentAt,
+	)
+	if err != nil {
+		return n, err
+	}
+
+	if currency != "" {
+		n.Amount = acme.NewMoney(amount, currency)
+		n.AvailableCredit = acme.NewMoney(availableCredit, currency)
+	}
+	n.SentAt = sentAt.Time
+
+	return n, nil
+}
+
+func scanNotifications(rows *sql.Rows) ([]acme.Notification, error) {
+	defer rows.Close()
+
+	notifications := make([]acme.Notification, 0)
+	for rows.Next() {
+		n, err := scanNotification(rows)
+		if err != nil {
+			return nil, fmt.Errorf("row scan: %w", err)
+		}
+		notifications = append(notifications, n)
+	}
+
+	return notifications, rows.Err()
+}
+
+// ClaimNotifications implements acme.NotificationRepository.
+func (r *NotificationRepository) ClaimNotifications(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]acme.Notification, error) {
+	// skip locked lets the other instances claim the next notifications
+	stmnt := `update notifications set next_attempt_at = $3
+	where id in (
+		select id from notifications
+		where status = $1 and next_attempt_at <= $2
+		order by next_attempt_at
+		limit $4
+		for update skip locked
+	)
+	returning
+		id, event_id, type, card_id, amount, available_credit, currency, email, subject,
+		status, attempts, next_attempt_at, error, created_at, sent_at`
+
+	rows, err := r.db.QueryContext(ctx, stmnt, acme.NotificationPending, now, now.Add(lease), limit)
+	if err != nil {
+		return nil, fmt.Errorf("query context: %w", err)
+	}
+
+	return scanNotifications(rows)
+}
+
+// UpdateNotification implements acme.NotificationRepository.
+func (r *NotificationRepository) UpdateNotification(ctx context.Context, n acme.Notification) error {
+	stmnt := `update notifications set
+		email = $2, subject = $3, status = $4, attempts = $5,
+		next_attempt_at = $6, error = $7, sent_at = $8
+	where id = $1`
+
+	_, err := r.db.ExecContext(ctx, stmnt,
+		n.ID, n.Email, n.Subject, n.Status, n.Attempts,
+		n.NextAttemptAt, n.Error, sql.NullTime{Time: n.SentAt, Valid: !n.SentAt.IsZero()},
+	)
+	if err != nil {
+		return fmt.Errorf("exec context: %w", err)
+	}
+
+	return nil
+}
+
+// FindNotifications implements acme.NotificationRepository.
+func (r *NotificationRepository) FindNotifications(ctx context.Context, filter acme.NotificationFilter) ([]acme.Notification, error) {
+	stmnt := selectNotifications + `
+	where ($1::text = '' or card_id = $1::text)
+		and ($2::text = '' or status = $2::text)
+	order by created_at desc, id desc`
+
+	rows, err := r.db.QueryContext(ctx, stmnt, filter.CardID, filter.Status)
+	if err != nil {
+		return nil, fmt.Errorf("query context: %w", err)
+	}
+
+	return scanNotifications(rows)
+}
+
+// GetNotificationPreference implements acme.NotificationRepository.
+func (r *NotificationRepository) GetNotificationPreference(ctx context.Context, email string) (*acme.NotificationPreference, error) {
+	stmnt := `select email, locale, opt_out_all, opt_out_types, updated_at
+	from notification_preferences
+	where email = $1`
+
+	var pref acme.NotificationPreference
+	err := r.db.QueryRowContext(ctx, stmnt, email).Scan(
+		&pref.Email, &pref.Locale, &pref.OptOutAll, pq.Array(&pref.OptOutTypes), &pref.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, acme.ErrNotificationPreferenceNotFound
+		}
+		return nil, fmt.Errorf("query row context: %w", err)
+	}
+
+	return &pref, nil
+}
+
+// SaveNotificationPreference implements acme.NotificationRepository.
+func (r *NotificationRepository) SaveNotificationPreference(ctx context.Context, pref *acme.NotificationPreference) error {
+	stmnt := `insert into notification_preferences (email, locale, opt_out_all, opt_out_types, updated_at)
+	values ($1, $2, $3, $4, now())
+	on conflict (email) do update set
+		locale = excluded.locale,
+		opt_out_all = excluded.opt_out_all,
+		opt_out_types = excluded.opt_out_types,
+		updated_at = excluded.updated_at
+	returning updated_at`
+
+	optOutTypes := pref.OptOutTypes
+	if optOutTypes == nil {
+		optOutTypes = []string{}
+	}
+
+	err := r.db.QueryRowContext(ctx, stmnt, pref.Email, pref.Locale, pref.OptOutAll, pq.Array(optOutTypes)).
+		Scan(&pref.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("query row context: %w", err)
+	}
+
+	return nil
+}
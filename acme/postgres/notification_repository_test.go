@@ -0,0 +1,22 @@
+package postgres_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/acme/postgres"
+	"github.com/stevenferrer/acme-cards-api/acme/repotest"
+)
+
+func TestNotificationRepository(t *testing.T) {
+	db := newTestDB(t)
+
+	repotest.RunNotificationRepositorySuite(t, func(t *testing.T) acme.NotificationRepository {
+		_, err := db.Exec(`truncate table notifications, notification_preferences`)
+		require.NoError(t, err)
+
+		return postgres.NewNotificationRepository(db)
+	})
+}
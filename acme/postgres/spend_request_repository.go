@@ -0,0 +1,271 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+)
+
+type SpendRequestRepository struct {
+	db *sql.DB
+}
+
+var _ acme.SpendRequestRepository = (*SpendRequestRepository)(nil)
+
+func NewSpendRequestRepository(db *sql.DB) *SpendRequestRepository {
+	return &SpendRequestRepository{db: db}
+}
+
+// SaveSpendRequest implements acme.SpendRequestRepository.
+func (r *SpendRequestRepository) SaveSpendRequest(ctx context.Context, req *acme.SpendRequest) error {
+	stmnt := `insert into spend_requests (
+		card_id, requester, amount, currency, justification, status,
+		required_approvals, adjustment_id, error, funding_started_at
+	) values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	returning id, created_at, updated_at`
+
+	err := r.db.QueryRowContext(ctx, stmnt,
+		req.CardID, req.Requester, req.Amount.Minor(), req.Amount.Currency(), req.Justification, req.Status,
+		req.RequiredApprovals, req.AdjustmentID, req.Error,
+		sql.NullTime{Time: req.FundingStartedAt, Valid: !req.FundingStartedAt.IsZero()},
+	).Scan(&req.ID, &req.CreatedAt, &req.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("query row context: %w", err)
+	}
+
+	return nil
+}
+
+const selectSpendRequests = `select
+	id, card_id, requester, amount, currency, justification, status,
+	required_approvals, adjustment_id, error, funding_started_at, created_at, updated_at
+from spend_requests`
+
+func scanSpendRequest(row rowScanner) (acme.SpendRequest, error) {
+	var req acme.SpendRequest
+	var amount int64
+	var currency string
+	var fundingStartedAt sql.NullTime
+	err := row.Scan(
+		&req.ID, &req.CardID, &req.Requester, &amount, &currency, &req.Justification, &req.Status,
+		&req.RequiredApprovals, &req.AdjustmentID, &req.Error, &fundingStartedAt, &req.CreatedAt, &req.UpdatedAt,
+	)
+	if err != nil {
+		return req, err
+	}
+
+	req.Amount = acme.NewMoney(amount, currency)
+	req.FundingStartedAt = fundingStartedAt.Time
+
+	return req, nil
+}
+
+// GetSpendRequest implements acme.SpendRequestRepository.
+func (r *SpendRequestRepository) GetSpendRequest(ctx context.Context, id int64) (*acme.SpendRequest, error) {
+	stmnt := selectSpendRequests + ` where id = $1`
+
+	req, err := scanSpendRequest(r.db.QueryRowContext(ctx, stmnt, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, acme.ErrSpendRequestNotFound
+		}
+		return nil, fmt.Errorf("query row context: %w", err)
+	}
+
+	return &req, nil
+}
+
+// FindSpendRequests implements acme.SpendRequestRepository.
+func (r *SpendRequestRepository) FindSpendRequests(ctx context.Context, filter acme.SpendRequestFilter) ([]acme.SpendRequest, error) {
+	stmnt := selectSpendRequests + `
+	where ($1::text = '' or card_id = $1::text)
+		and ($2::text = '' or requester = $2::text)
+		and ($3::text = '' or status = $3::text)
+		and ($4::timestamp is null or updated_at < $4::timestamp)
+	order by created_at desc, id desc`
+
+	rows, err := r.db.QueryContext(ctx, stmnt, filter.CardID, filter.Requester, filter.Status,
+		sql.NullTime{Time: filter.UpdatedBefore, Valid: !filter.UpdatedBefore.IsZero()},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query context: %w", err)
+	}
+	defer rows.Close()
+
+	reqs := make([]acme.SpendRequest, 0)
+	for rows.Next() {
+		req, err := scanSpendRequest(rows)
+		if err != nil {
+			return nil, fmt.Errorf("row scan: %w", err)
+		}
+		reqs = append(reqs, req)
+	}
+
+	return reqs, rows.Err()
+}
+
+// UpdateSpendRequest implements acme.SpendRequestRepository.
+func (r *SpendRequestRepository) UpdateSpendRequest(ctx context.Context, req *acme.SpendRequest, from string) error {
+	stmnt := `update spend_requests set
+		status = $3, adjustment_id = $4, error = $5, funding_started_at = $6, updated_at = now()
+	where id = $1 and status = $2
+	returning updated_at`
+
+	err := r.db.QueryRowContext(ctx, stmnt,
+		req.ID, from, req.Status, req.AdjustmentID, req.Error,
+		sql.NullTime{Time: req.FundingStartedAt, Valid: !req.FundingStartedAt.IsZero()},
+	).Scan(&req.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: the request is no longer %s", acme.ErrSpendRequestStatus, from)
+		}
+		return fmt.Errorf("query row context: %w", err)
+	}
+
+	return nil
+}
+
+// SaveSpendRequestActivity implements acme.SpendRequestRepository.
+func (r *SpendRequestRepository) SaveSpendRequestActivity(ctx context.Context, a *acme.SpendRequestActivity) error {
+	stmnt := `insert into spend_request_activities (request_id, kind, actor, comment)
+	values ($1, $2, $3, $4)
+	returning id, created_at`
+
+	err := r.db.QueryRowContext(ctx, stmnt, a.RequestID, a.Kind, a.Actor, a.Comment).Scan(&a.ID, &a.CreatedAt)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+			return fmt.Errorf("%w: %s already approved the request", acme.ErrSpendRequestNotAllowed, a.Actor)
+		}
+		return fmt.Errorf("query row context: %w", err)
+	}
+
+	return nil
+}
+
+// FindSpendRequestActivities implements acme.SpendRequestRepository.
+func (r *SpendRequestRepository) FindSpendRequestActivities(ctx context.Context, requestID int64) ([]acme.SpendRequestActivity, error) {
+	stmnt := `select id, request_id, kind, actor, comment, created_at
+	from spend_request_activities
+	where request_id = $1
+	order by id`
+
+	rows, err := r.db.QueryContext(ctx, stmnt, requestID)
+	if err != nil {
+		return nil, fmt.Errorf("query context: %w", err)
+	}
+	defer rows.Close()
+
+	activities := make([]acme.SpendRequestActivity, 0)
+	for rows.Next() {
+		var a acme.SpendRequestActivity
+		err = rows.Scan(&a.ID, &a.RequestID, &a.Kind, &a.Actor, &a.Comment, &a.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("row scan: %w", err)
+		}
+		activities = append(activities, a)
+	}
+
+	return activities, rows.Err()
+}
+
+// SaveSpendRequestApprover implements acme.SpendRequestRepository.
+func (r *SpendRequestRepository) SaveSpendRequestApprover(ctx context.Context, approver *acme.SpendRequestApprover) error {
+	stmnt := `insert into spend_request_approvers (name, approval_limit, currency)
+	values ($1, $2, $3)
+	on conflict (name) do update set
+		approval_limit = excluded.approval_limit,
+		currency = excluded.currency,
+		updated_at = now()
+	returning created_at, updated_at`
+
+	err := r.db.QueryRowContext(ctx, stmnt,
+		approver.Name, approver.Limit.Minor(), approver.Limit.Currency(),
+	).Scan(&approver.CreatedAt, &approver.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("query row context: %w", err)
+	}
+
+	return nil
+}
+
+const selectSpendRequestApprovers = `select
+	name, approval_limit, currency, created_at, updated_at
+from spend_request_approvers`
+
+func scanSpendRequestApprover(row rowScanner) (acme.SpendRequestApprover, error) {
+	var approver acme.SpendRequestApprover
+	var limit int64
+	var currency string
+	err := row.Scan(&approver.Name, &limit, &currency, &approver.CreatedAt, &approver.UpdatedAt)
+	if err != nil {
+		return approver, err
+	}
+
+	if limit != 0 {
+		approver.Limit = acme.NewMoney(limit, currency)
+	}
+
+	return approver, nil
+}
+
+// GetSpendRequestApprover implements acme.SpendRequestRepository.
+func (r *SpendRequestRepository) GetSpendRequestApprover(ctx context.Context, name string) (*acme.SpendRequestApprover, error) {
+	stmnt := selectSpendRequestApprovers + ` where name = $1`
+
+	approver, err := scanSpendRequestApprover(r.db.QueryRowContext(ctx, stmnt, name))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, acme.ErrSpendRequestApproverNotFound
+		}
+		return nil, fmt.Errorf("query row context: %w", err)
+	}
+
+	return &approver, nil
+}
+
+// FindSpendRequestApprovers implements acme.SpendRequestRepository.
+func (r *SpendRequestRepository) FindSpendRequestApprovers(ctx context.Context) ([]acme.SpendRequestApprover, error) {
+	stmnt := selectSpendRequestApprovers + ` order by name`
+
+	rows, err := r.db.QueryContext(ctx, stmnt)
+	if err != nil {
+		return nil, fmt.Errorf("query context: %w", err)
+	}
+	defer rows.Close()
+
+	approvers := make([]acme.SpendRequestApprover, 0)
+	for rows.Next() {
+		approver, err := scanSpendRequestApprover(rows)
+		if err != nil {
+			return nil, fmt.Errorf("row scan: %w", err)
+		}
+		approvers = append(approvers, approver)
+	}
+
+	return approvers, rows.Err()
+}
+
+// DeleteSpendRequestApprover implements acme.SpendRequestRepository.
+func (r *SpendRequestRepository) DeleteSpendRequestApprover(ctx context.Context, name string) error {
+	stmnt := `delete from spend_request_approvers where name = $1`
+	res, err := r.db.ExecContext(ctx, stmnt, name)
+	if err != nil {
+		return fmt.Errorf("exec context: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+	if n == 0 {
+		return acme.ErrSpendRequestApproverNotFound
+	}
+
+	return nil
+}
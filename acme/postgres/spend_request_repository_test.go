@@ -0,0 +1,22 @@
+package postgres_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/acme/postgres"
+	"github.com/stevenferrer/acme-cards-api/acme/repotest"
+)
+
+func TestSpendRequestRepository(t *testing.T) {
+	db := newTestDB(t)
+
+	repotest.RunSpendRequestRepositorySuite(t, func(t *testing.T) (acme.CardRepository, acme.SpendRequestRepository) {
+		_, err := db.Exec(`truncate table cards, spend_requests, spend_request_activities, spend_request_approvers cascade`)
+		require.NoError(t, err)
+
+		return postgres.NewCardRepository(db), postgres.NewSpendRequestRepository(db)
+	})
+}
@@ -0,0 +1,211 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+)
+
+// streamEventsChannel is notified with the seq of the saved stream events
+const streamEventsChannel = "stream_events"
+
+type StreamEventRepository struct {
+	db *sql.DB
+}
+
+var _ acme.StreamEventRepository = (*StreamEventRepository)(nil)
+
+func NewStreamEventRepository(db *sql.DB) *StreamEventRepository {
+	return &StreamEventRepository{db: db}
+}
+
+// SaveStreamEvent implements acme.StreamEventRepository.
+func (r *StreamEventRepository) SaveStreamEvent(ctx context.Context, e *acme.StreamEvent) error {
+	// the notification is sent on commit
+	stmnt := `with inserted as (
+		insert into stream_events (id, type, card_id, payload, created_at)
+		values ($1, $2, $3, $4, $5)
+		on conflict (id) do nothing
+		returning seq
+	)
+	select seq, pg_notify($6, seq::text) from inserted`
+
+	var notified string
+	err := r.db.QueryRowContext(ctx, stmnt,
+		e.ID, e.Type, e.CardID, string(e.Payload), e.CreatedAt, streamEventsChannel,
+	).Scan(&e.Seq, &notified)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return acme.ErrStreamEventExists
+		}
+		return fmt.Errorf("query row context: %w", err)
+	}
+
+	return nil
+}
+
+const selectStreamEvents = `select seq, id, type, card_id, payload, created_at from stream_events`
+
+func scanStreamEvent(row rowScanner) (acme.StreamEvent, error) {
+	var e acme.StreamEvent
+	var payload string
+	err := row.Scan(&e.Seq, &e.ID, &e.Type, &e.CardID, &payload, &e.CreatedAt)
+	e.Payload = []byte(payload)
+	return e, err
+}
+
+// GetStreamEvent implements acme.StreamEventRepository.
+func (r *StreamEventRepository) GetStreamEvent(ctx context.Context, seq int64) (*acme.StreamEvent, error) {
+	stmnt := selectStreamEvents + ` where seq = $1`
+
+	e, err := scanStreamEvent(r.db.QueryRowContext(ctx, stmnt, seq))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, acme.ErrStreamEventNotFound
+		}
+		return nil, fmt.Errorf("query row context: %w", err)
+	}
+
+	return &e, nil
+}
+
+// FindLatestStreamEvents implements acme.StreamEventRepository.
+func (r *StreamEventRepository) FindLatestStreamEvents(ctx context.Context, since time.Time, limit int) ([]acme.StreamEvent, error) {
+	stmnt := `select * from (
+		` + selectStreamEvents + `
+		where created_at >= $1
+		order by seq desc
+		limit $2
+	) latest order by seq`
+
+	rows, err := r.db.QueryContext(ctx, stmnt, since, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query context: %w", err)
+	}
+	defer rows.Close()
+
+	events := make([]acme.StreamEvent, 0)
+	for rows.Next() {
+		e, err := scanStreamEvent(rows)
+		if err != nil {
+			return nil, fmt.Errorf("row scan: %w", err)
+		}
+		events = append(events, e)
+	}
+
+	return events, rows.Err()
+}
+
+// DeleteStreamEventsBefore implements acme.StreamEventRepository.
+func (r *StreamEventRepository) DeleteStreamEventsBefore(ctx context.Context, t time.Time) error {
+	stmnt := `delete from stream_events where created_at < $1`
+	_, err := r.db.ExecContext(ctx, stmnt, t)
+	if err != nil {
+		return fmt.Errorf("exec context: %w", err)
+	}
+
+	return nil
+}
+
+// StreamEventListener broadcasts the stream events saved by every server
+// instance to the event hub of this instance
+type StreamEventListener struct {
+	dsn        string
+	streamRepo *StreamEventRepository
+	hub        *acme.EventHub
+}
+
+func NewStreamEventListener(dsn string, streamRepo *StreamEventRepository, hub *acme.EventHub) *StreamEventListener {
+	return &StreamEventListener{
+		dsn:        dsn,
+		streamRepo: streamRepo,
+		hub:        hub,
+	}
+}
+
+const (
+	// listenerPingInterval checks the listener connection when idle
+	listenerPingInterval = 90 * time.Second
+	// listenerCatchUpOverlap covers the events committed out of order
+	// before the latest event received
+	listenerCatchUpOverlap = time.Minute
+)
+
+// Listen broadcasts the notified events until ctx is done, the events
+// missed while the connection was lost are loaded from the table.
+func (l *StreamEventListener) Listen(ctx context.Context) error {
+	listener := pq.NewListener(l.dsn, time.Second, time.Minute, nil)
+	defer listener.Close()
+
+	err := listener.Listen(streamEventsChannel)
+	if err != nil {
+		return fmt.Errorf("listen: %w", err)
+	}
+
+	err = l.catchUp(ctx)
+	if err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case n := <-listener.Notify:
+			// a nil notification follows a reconnect
+			if n == nil {
+				err = l.catchUp(ctx)
+				if err != nil {
+					return err
+				}
+				continue
+			}
+
+			seq, err := strconv.ParseInt(n.Extra, 10, 64)
+			if err != nil {
+				return fmt.Errorf("parse seq %q: %w", n.Extra, err)
+			}
+
+			e, err := l.streamRepo.GetStreamEvent(ctx, seq)
+			if err != nil {
+				if errors.Is(err, acme.ErrStreamEventNotFound) {
+					continue
+				}
+				return fmt.Errorf("get stream event: %w", err)
+			}
+			l.hub.Broadcast(*e)
+		case <-time.After(listenerPingInterval):
+			err = listener.Ping()
+			if err != nil {
+				return fmt.Errorf("ping: %w", err)
+			}
+		}
+	}
+}
+
+// catchUp broadcasts the events since the latest event of the hub, it
+// fills the hub buffer on start.
+func (l *StreamEventListener) catchUp(ctx context.Context) error {
+	var since time.Time
+	if latest := l.hub.LatestEventTime(); !latest.IsZero() {
+		since = latest.Add(-listenerCatchUpOverlap)
+	}
+
+	events, err := l.streamRepo.FindLatestStreamEvents(ctx, since, l.hub.Size())
+	if err != nil {
+		return fmt.Errorf("find latest stream events: %w", err)
+	}
+
+	for _, e := range events {
+		l.hub.Broadcast(e)
+	}
+
+	return nil
+}
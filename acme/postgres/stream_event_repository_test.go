@@ -0,0 +1,22 @@
+package postgres_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/acme/postgres"
+	"github.com/stevenferrer/acme-cards-api/acme/repotest"
+)
+
+func TestStreamEventRepository(t *testing.T) {
+	db := newTestDB(t)
+
+	repotest.RunStreamEventRepositorySuite(t, func(t *testing.T) acme.StreamEventRepository {
+		_, err := db.Exec(`truncate table stream_events`)
+		require.NoError(t, err)
+
+		return postgres.NewStreamEventRepository(db)
+	})
+}
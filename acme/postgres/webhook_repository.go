@@ -0,0 +1,294 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+)
+
+type WebhookRepository struct {
+	db *sql.DB
+}
+
+var _ acme.WebhookRepository = (*WebhookRepository)(nil)
+
+func NewWebhookRepository(db *sql.DB) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+// SaveWebhookSubscription implements acme.WebhookRepository.
+func (r *WebhookRepository) SaveWebhookSubscription(ctx context.Context, sub *acme.WebhookSubscription) error {
+	stmnt := `insert into webhook_subscriptions (url, secret, event_types)
+	values ($1, $2, $3)
+	returning id, created_at`
+
+	eventTypes := sub.EventTypes
+	if eventTypes == nil {
+		eventTypes = []string{}
+	}
+
+	err := r.db.QueryRowContext(ctx, stmnt, sub.URL, sub.Secret, pq.Array(eventTypes)).
+		Scan(&sub.ID, &sub.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("query row context: %w", err)
+	}
+
+	return nil
+}
+
+const selectWebhookSubscriptions = `select id, url, secret, event_types, created_at from webhook_subscriptions`
+
+func scanWebhookSubscription(row rowScanner) (acme.WebhookSubscription, error) {
+	var sub acme.WebhookSubscription
+	err := row.Scan(&sub.ID, &sub.URL, &sub.Secret, pq.Array(&sub.EventTypes), &sub.CreatedAt)
+	return sub, err
+}
+
+// GetWebhookSubscription implements acme.WebhookRepository.
+func (r *WebhookRepository) GetWebhookSubscription(ctx context.Context, id int64) (*acme.WebhookSubscription, error) {
+	stmnt := selectWebhookSubscriptions + ` where id = $1`
+
+	sub, err := scanWebhookSubscription(r.db.QueryRowContext(ctx, stmnt, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, acme.ErrWebhookSubscriptionNotFound
+		}
+		return nil, fmt.Errorf("query row context: %w", err)
+	}
+
+	return &sub, nil
+}
+
+// FindWebhookSubscriptions implements acme.WebhookRepository.
+func (r *WebhookRepository) FindWebhookSubscriptions(ctx context.Context) ([]acme.WebhookSubscription, error) {
+	stmnt := selectWebhookSubscriptions + ` order by id`
+
+	rows, err := r.db.QueryContext(ctx, stmnt)
+	if err != nil {
+		return nil, fmt.Errorf("query context: %w", err)
+	}
+	defer rows.Close()
+
+	subs := make([]acme.WebhookSubscription, 0)
+	for rows.Next() {
+		sub, err := scanWebhookSubscription(rows)
+		if err != nil {
+			return nil, fmt.Errorf("row scan: %w", err)
+		}
+		subs = append(subs, sub)
+	}
+
+	return subs, rows.Err()
+}
+
+// DeleteWebhookSubscription implements acme.WebhookRepository.
+func (r *WebhookRepository) DeleteWebhookSubscription(ctx context.Context, id int64) error {
+	stmnt := `delete from webhook_subscriptions where id = $1`
+	res, err := r.db.ExecContext(ctx, stmnt, id)
+	if err != nil {
+		return fmt.Errorf("exec context: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+	if n == 0 {
+		return acme.ErrWebhookSubscriptionNotFound
+	}
+
+	return nil
+}
+
+// SaveWebhookEvent implements acme.WebhookRepository.
+func (r *WebhookRepository) SaveWebhookEvent(ctx context.Context, event acme.WebhookEvent, subscriptionIDs []int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmnt := `insert into webhook_events (id, type, card_id, payload, created_at)
+	values ($1, $2, $3, $4, $5)`
+	_, err = tx.ExecContext(ctx, stmnt, event.ID, event.Type, event.CardID, string(event.Payload), event.CreatedAt)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+			return acme.ErrWebhookEventExists
+		}
+		return fmt.Errorf("exec context: %w", err)
+	}
+
+	// subscriptions deleted in the meantime are skipped
+	stmnt = `insert into webhook_deliveries (
+		subscription_id, event_id, status, next_attempt_at, created_at, updated_at
+	)
+	select id, $2::text, $3::text, $4::timestamp, $4::timestamp, $4::timestamp
+	from webhook_subscriptions where id = any($1)`
+	_, err = tx.ExecContext(ctx, stmnt, pq.Array(subscriptionIDs), event.ID, acme.WebhookDeliveryPending, event.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("exec context: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+const selectWebhookDeliveries = `select
+	d.id, d.subscription_id, d.event_id, e.type, e.payload, d.status, d.attempts,
+	d.next_attempt_at, d.response_code, d.error, d.created_at, d.updated_at
+from webhook_deliveries d
+join webhook_events e on e.id = d.event_id`
+
+func scanWebhookDelivery(row rowScanner) (acme.WebhookDelivery, error) {
+	var d acme.WebhookDelivery
+	var payload string
+	err := row.Scan(
+		&d.ID, &d.SubscriptionID, &d.EventID, &d.EventType, &payload, &d.Status, &d.Attempts,
+		&d.NextAttemptAt, &d.ResponseCode, &d.Error, &d.CreatedAt, &d.UpdatedAt,
+	)
+	d.Payload = []byte(payload)
+	return d, err
+}
+
+func scanWebhookDeliveries(rows *sql.Rows) ([]acme.WebhookDelivery, error) {
+	defer rows.Close()
+
+	deliveries := make([]acme.WebhookDelivery, 0)
+	for rows.Next() {
+		d, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("row scan: %w", err)
+		}
+		deliveries = append(deliveries, d)
+	}
+
+	return deliveries, rows.Err()
+}
+
+// ClaimWebhookDeliveries implements acme.WebhookRepository.
+func (r *WebhookRepository) ClaimWebhookDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]acme.WebhookDelivery, error) {
+	// skip locked lets the other instances claim the next deliveries
+	stmnt := `with claimed as (
+		update webhook_deliveries set next_attempt_at = $3
+		where id in (
+			select id from webhook_deliveries
+			where status = $1 and next_attempt_at <= $2
+			order by next_attempt_at
+			limit $4
+			for update skip locked
+		)
+		returning id
+	)
+	` + selectWebhookDeliveries + `
+	where d.id in (select id from claimed)
+	order by d.id`
+
+	rows, err := r.db.QueryContext(ctx, stmnt, acme.WebhookDeliveryPending, now, now.Add(lease), limit)
+	if err != nil {
+		return nil, fmt.Errorf("query context: %w", err)
+	}
+
+	return scanWebhookDeliveries(rows)
+}
+
+// GetWebhookDelivery implements acme.WebhookRepository.
+func (r *WebhookRepository) GetWebhookDelivery(ctx context.Context, id int64) (*acme.WebhookDelivery, error) {
+	stmnt := selectWebhookDeliveries + ` where d.id = $1`
+
+	d, err := scanWebhookDelivery(r.db.QueryRowContext(ctx, stmnt, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, acme.ErrWebhookDeliveryNotFound
+		}
+		return nil, fmt.Errorf("query row context: %w", err)
+	}
+
+	return &d, nil
+}
+
+// FindWebhookDeliveries implements acme.WebhookRepository.
+func (r *WebhookRepository) FindWebhookDeliveries(ctx context.Context, subscriptionID int64) ([]acme.WebhookDelivery, error) {
+	stmnt := selectWebhookDeliveries + ` where d.subscription_id = $1 order by d.created_at desc, d.id desc`
+
+	rows, err := r.db.QueryContext(ctx, stmnt, subscriptionID)
+	if err != nil {
+		return nil, fmt.Errorf("query context: %w", err)
+	}
+
+	return scanWebhookDeliveries(rows)
+}
+
+// SaveWebhookDeliveryAttempt implements acme.WebhookRepository.
+func (r *WebhookRepository) SaveWebhookDeliveryAttempt(ctx context.Context, d acme.WebhookDelivery, attempt acme.WebhookDeliveryAttempt) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmnt := `update webhook_deliveries set
+		status = $2, attempts = $3, next_attempt_at = $4,
+		response_code = $5, error = $6, updated_at = $7
+	where id = $1`
+	res, err := tx.ExecContext(ctx, stmnt,
+		d.ID, d.Status, d.Attempts, d.NextAttemptAt, d.ResponseCode, d.Error, d.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("exec context: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+	if n == 0 {
+		return acme.ErrWebhookDeliveryNotFound
+	}
+
+	stmnt = `insert into webhook_delivery_attempts (
+		delivery_id, attempt, response_code, error, duration_ms, created_at
+	) values ($1, $2, $3, $4, $5, $6)`
+	_, err = tx.ExecContext(ctx, stmnt,
+		attempt.DeliveryID, attempt.Attempt, attempt.ResponseCode, attempt.Error,
+		attempt.Duration.Milliseconds(), attempt.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("exec context: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// FindWebhookDeliveryAttempts implements acme.WebhookRepository.
+func (r *WebhookRepository) FindWebhookDeliveryAttempts(ctx context.Context, deliveryID int64) ([]acme.WebhookDeliveryAttempt, error) {
+	stmnt := `select delivery_id, attempt, response_code, error, duration_ms, created_at
+	from webhook_delivery_attempts
+	where delivery_id = $1
+	order by attempt`
+
+	rows, err := r.db.QueryContext(ctx, stmnt, deliveryID)
+	if err != nil {
+		return nil, fmt.Errorf("query context: %w", err)
+	}
+	defer rows.Close()
+
+	attempts := make([]acme.WebhookDeliveryAttempt, 0)
+	for rows.Next() {
+		var a acme.WebhookDeliveryAttempt
+		var durationMS int64
+		err = rows.Scan(&a.DeliveryID, &a.Attempt, &a.ResponseCode, &a.Error, &durationMS, &a.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("row scan: %w", err)
+		}
+		a.Duration = time.Duration(durationMS) * time.Millisecond
+		attempts = append(attempts, a)
+	}
+
+	return attempts, rows.Err()
+}
@@ -0,0 +1,22 @@
+package postgres_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/acme/postgres"
+	"github.com/stevenferrer/acme-cards-api/acme/repotest"
+)
+
+func TestWebhookRepository(t *testing.T) {
+	db := newTestDB(t)
+
+	repotest.RunWebhookRepositorySuite(t, func(t *testing.T) acme.WebhookRepository {
+		_, err := db.Exec(`truncate table webhook_subscriptions, webhook_events cascade`)
+		require.NoError(t, err)
+
+		return postgres.NewWebhookRepository(db)
+	})
+}
@@ -2,7 +2,11 @@ package acme
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
+	"slices"
 	"strings"
 	"time"
 
@@ -13,20 +17,94 @@ import (
 
 // ReapCardService implements CardService
 type ReapCardService struct {
-	reapClient reap.Client
-	cardRepo   CardRepository
+	reapClient   reap.Client
+	cardRepo     CardRepository
+	snapshotRepo CardSnapshotRepository
+	burnerRepo   BurnerCardRepository
+	groupRepo    CardGroupRepository
+	publishers   []EventPublisher
+	logger       *slog.Logger
+	// expiryNotice is how long before their expiry the owners are notified
+	expiryNotice time.Duration
 }
 
-var _ CardService = (*ReapCardService)(nil)
+var (
+	_ CardService         = (*ReapCardService)(nil)
+	_ CardSnapshotService = (*ReapCardService)(nil)
+	_ TransactionSource   = (*ReapCardService)(nil)
+	_ BalanceChangeSource = (*ReapCardService)(nil)
+)
+
+// ReapCardServiceOption configures the optional dependencies of ReapCardService
+type ReapCardServiceOption func(*ReapCardService)
+
+// WithCardSnapshotRepository serves ListCards from the card snapshots
+func WithCardSnapshotRepository(snapshotRepo CardSnapshotRepository) ReapCardServiceOption {
+	return func(s *ReapCardService) {
+		s.snapshotRepo = snapshotRepo
+	}
+}
+
+// WithBurnerCardRepository enables the single-use and merchant-locked card
+// modes
+func WithBurnerCardRepository(burnerRepo BurnerCardRepository) ReapCardServiceOption {
+	return func(s *ReapCardService) {
+		s.burnerRepo = burnerRepo
+	}
+}
+
+// WithCardGroupRepository limits the top-ups of the member cards to the
+// remaining budget of their group
+func WithCardGroupRepository(groupRepo CardGroupRepository) ReapCardServiceOption {
+	return func(s *ReapCardService) {
+		s.groupRepo = groupRepo
+	}
+}
+
+// defaultCardExpiryNotice is how long before their expiry the owners are
+// notified by default
+const defaultCardExpiryNotice = 7 * 24 * time.Hour
+
+// WithCardExpiryNotice notifies the owners the number of days before the
+// expiry of their cards
+func WithCardExpiryNotice(days int) ReapCardServiceOption {
+	return func(s *ReapCardService) {
+		s.expiryNotice = time.Duration(days) * 24 * time.Hour
+	}
+}
+
+// WithEventPublisher publishes the events of the card operations, the
+// option may be repeated for several publishers
+func WithEventPublisher(publisher EventPublisher) ReapCardServiceOption {
+	return func(s *ReapCardService) {
+		s.publishers = append(s.publishers, publisher)
+	}
+}
+
+// WithLogger logs the events that could not be published after the card
+// operations, the default logger is used otherwise
+func WithLogger(logger *slog.Logger) ReapCardServiceOption {
+	return func(s *ReapCardService) {
+		s.logger = logger
+	}
+}
 
 func NewReapCardService(
 	reapClient reap.Client,
 	cardRepo CardRepository,
+	opts ...ReapCardServiceOption,
 ) *ReapCardService {
-	return &ReapCardService{
-		cardRepo:   cardRepo,
-		reapClient: reapClient,
+	s := &ReapCardService{
+		cardRepo:     cardRepo,
+		reapClient:   reapClient,
+		expiryNotice: defaultCardExpiryNotice,
+		logger:       slog.Default(),
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
 }
 
 func (s *ReapCardService) GetAccountBalance(ctx context.Context) (*AccountBalance, error) {
@@ -35,41 +113,179 @@ func (s *ReapCardService) GetAccountBalance(ctx context.Context) (*AccountBalanc
 		return nil, fmt.Errorf("get reap account balance: %w", err)
 	}
 
+	availableBalance, err := parseReapMoney(resp.AvailableBalance.String(), reap.AccountCurrency)
+	if err != nil {
+		return nil, fmt.Errorf("parse available balance: %w", err)
+	}
+
+	availableToAllocate, err := parseReapMoney(resp.AvailableToAllocate.String(), reap.AccountCurrency)
+	if err != nil {
+		return nil, fmt.Errorf("parse available to allocate: %w", err)
+	}
+
 	return &AccountBalance{
-		AvailableBalance:    fmt.Sprintf("%.2f", resp.AvailableBalance),
-		AvailableToAllocate: fmt.Sprintf("%.2f", resp.AvailableToAllocate),
+		AvailableBalance:    availableBalance,
+		AvailableToAllocate: availableToAllocate,
 	}, nil
 }
 
 func (s *ReapCardService) CreateCard(ctx context.Context, params CreateCardParams) (*CreateCardResponse, error) {
-	// TODO: Validate required params
-
-	// generate internal ID
-	cardID := uuid.New().String()
-	cardID = strings.ReplaceAll(cardID, "-", "")
+	err := validateCreateCardParams(&params)
+	if err != nil {
+		return nil, err
+	}
+	if params.Mode != CardModeStandard && s.burnerRepo == nil {
+		return nil, fmt.Errorf("%w: %s cards are not supported", ErrInvalidCardMode, params.Mode)
+	}
 
-	// create card on Reap side
-	resp, err := s.reapClient.CreateCard(ctx, toReapCreateCardParams(cardID, params))
+	expiry, err := toCardExpiry("", UpdateCardExpiryParams{
+		ValidUntil:   params.ValidUntil,
+		ExpiryAction: params.ExpiryAction,
+	}, time.Now().UTC())
 	if err != nil {
-		return nil, fmt.Errorf("create reap card: %w", err)
+		return nil, err
+	}
+
+	// generate internal ID, a given ID may belong to an interrupted creation
+	cardID, externalID := params.CardID, ""
+	if cardID == "" {
+		cardID = NewCardID()
+	} else {
+		externalID, err = s.findCreatedCard(ctx, cardID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if externalID == "" {
+		// create card on Reap side
+		resp, err := s.reapClient.CreateCard(ctx, toReapCreateCardParams(cardID, params))
+		if err != nil {
+			return nil, fmt.Errorf("create reap card: %w", err)
+		}
+		externalID = resp.CardID
 	}
 
 	// save id mapping to database
-	err = s.cardRepo.SaveCardID(ctx, cardID, resp.CardID)
-	if err != nil {
-		return nil, fmt.Errorf("save card ID %q external(%q): %w", cardID, resp.CardID, err)
+	err = s.cardRepo.SaveCardID(ctx, cardID, externalID)
+	if err != nil && !errors.Is(err, ErrCardExists) {
+		return nil, fmt.Errorf("save card ID %q external(%q): %w", cardID, externalID, err)
+	}
+
+	if params.Mode != CardModeStandard {
+		err = s.burnerRepo.SaveBurnerCard(ctx, &BurnerCard{
+			CardID:     cardID,
+			Mode:       params.Mode,
+			MerchantID: params.MerchantID,
+		})
+		if err != nil {
+			err = fmt.Errorf("save burner card %q: %w", cardID, err)
+			return nil, s.abortCardCreation(ctx, cardID, externalID, err)
+		}
+	}
+
+	// the card is issued at this point, a missing expiry is logged to be
+	// set again with UpdateCardExpiry instead of failing the creation
+	if !expiry.ValidUntil.IsZero() {
+		expiry.CardID = cardID
+		err = s.cardRepo.SaveCardExpiry(ctx, expiry)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "save card expiry", "cardID", cardID, "validUntil", expiry.ValidUntil, "err", err)
+		}
 	}
 
+	s.publishAppliedEvent(ctx, Event{
+		Type:   EventCardCreated,
+		CardID: cardID,
+		Data:   CardEventData{CardID: cardID},
+	})
+
 	return &CreateCardResponse{CardID: cardID}, nil
 }
 
+// abortCardCreation terminates the Reap card that could not be set up, an
+// unmonitored burner card could be used without its restrictions.
+func (s *ReapCardService) abortCardCreation(ctx context.Context, cardID, externalID string, cause error) error {
+	_, err := s.reapClient.UpdateCardStatus(context.WithoutCancel(ctx), reap.UpdateCardStatusParams{
+		CardID: externalID,
+		Status: reap.CardStatusTerminated,
+	})
+	if err != nil {
+		return errors.Join(cause, fmt.Errorf("terminate reap card %q: %w", cardID, err))
+	}
+
+	return fmt.Errorf("%w: %w", ErrCardCreationAborted, cause)
+}
+
+// NewCardID generates an internal card ID
+func NewCardID() string {
+	return strings.ReplaceAll(uuid.New().String(), "-", "")
+}
+
+// findCreatedCard returns the Reap ID of the card when an earlier creation
+// with the same internal ID already reached Reap, or an empty ID
+func (s *ReapCardService) findCreatedCard(ctx context.Context, cardID string) (string, error) {
+	externalID, err := s.cardRepo.GetExternalID(ctx, cardID)
+	if err == nil {
+		resp, err := s.reapClient.GetCard(ctx, reap.GetCardParams{CardID: externalID})
+		if err != nil {
+			return "", fmt.Errorf("get reap card: %w", err)
+		}
+
+		if resp.Card.Status == reap.CardStatusTerminated {
+			return "", fmt.Errorf("%w: card %q was terminated", ErrCardCreationAborted, cardID)
+		}
+
+		return externalID, nil
+	}
+	if !errors.Is(err, ErrCardNotFound) {
+		return "", fmt.Errorf("get external ID: %w", err)
+	}
+
+	// the card is looked up by the internal ID that Reap keeps as metadata
+	for _, status := range []string{reap.CardStatusActive, reap.CardStatusFrozen} {
+		resp, err := s.reapClient.GetCards(ctx, reap.GetCardsParams{
+			Status:      status,
+			MetadataIDs: []string{cardID},
+		})
+		if err != nil {
+			return "", fmt.Errorf("get reap cards: %w", err)
+		}
+
+		for _, reapCard := range resp.Items {
+			if reapCard.Meta.ID == cardID && reapCard.ID != "" {
+				return reapCard.ID, nil
+			}
+		}
+	}
+
+	return "", nil
+}
+
+// validateCreateCardParams checks the cardholder details that Reap requires
+// and the mode of the card to create
+func validateCreateCardParams(params *CreateCardParams) error {
+	switch {
+	case params.FirstName == "" || params.LastName == "":
+		return fmt.Errorf("%w: first and last name are required", ErrInvalidCardParams)
+	case params.DOB == "":
+		return fmt.Errorf("%w: date of birth is required", ErrInvalidCardParams)
+	case params.IDDocument.Type == "" || params.IDDocument.Number == "":
+		return fmt.Errorf("%w: id document is required", ErrInvalidCardParams)
+	case params.ContactInfo.Email == "":
+		return fmt.Errorf("%w: contact email is required", ErrInvalidCardParams)
+	}
+
+	return validateCardMode(params)
+}
+
 func toReapCreateCardParams(cardID string, params CreateCardParams) reap.CreateCardParams {
 	return reap.CreateCardParams{
 		CardType:          "Virtual",
 		CustomerType:      "Consumer",
 		PreferredCardName: fmt.Sprintf("%s %s", params.FirstName, params.LastName),
 		// The current default balance is 0, admin can update balance later
-		SpendLimit: 0,
+		SpendLimit: "0",
 		KYC: reap.KYC{
 			ConsumerInfo: reap.ConsumerInfo{
 				FirstName:          params.FirstName,
@@ -115,41 +331,380 @@ func (s *ReapCardService) GetCard(ctx context.Context, cardID string) (*Card, er
 		return nil, fmt.Errorf("get reap card: %w", err)
 	}
 
-	card := toAcmeCard(resp.Card)
+	card, err := toAcmeCard(resp.Card)
+	if err != nil {
+		return nil, fmt.Errorf("to acme card: %w", err)
+	}
+
+	expiry, err := s.cardRepo.GetCardExpiry(ctx, cardID)
+	if err != nil {
+		return nil, fmt.Errorf("get card expiry: %w", err)
+	}
+	card.ValidUntil = expiry.ValidUntil
+	card.ExpiryAction = expiry.Action
+
 	return &card, nil
 }
 
-func toAcmeCard(params reap.Card) Card {
+func toAcmeCard(params reap.Card) (Card, error) {
+	amounts := params.SpendControl.SpendControlAmount
+	parsed, err := parseReapMoneys(reap.AccountCurrency,
+		params.AvailableCredit,
+		amounts.DailySpent,
+		amounts.WeeklySpent,
+		amounts.MonthlySpent,
+		amounts.YearlySpent,
+		amounts.AllTimeSpent,
+	)
+	if err != nil {
+		return Card{}, fmt.Errorf("parse card %q amounts: %w", params.Meta.ID, err)
+	}
+
 	return Card{
 		ID:              params.Meta.ID,
 		Last4:           params.Last4,
 		Name:            params.CardName,
-		AvailableCredit: params.AvailableCredit,
+		Status:          params.Status,
+		AvailableCredit: parsed[0],
+		SpendUsage: SpendUsage{
+			Daily:   parsed[1],
+			Weekly:  parsed[2],
+			Monthly: parsed[3],
+			Yearly:  parsed[4],
+			AllTime: parsed[5],
+		},
 		ContactInfo: ContactInfo{
 			Email:       params.Meta.Email,
 			DialCode:    params.Meta.OTPPhoneNumber.DialCode,
 			PhoneNumber: params.Meta.OTPPhoneNumber.PhoneNumber,
 		},
+	}, nil
+}
+
+// parseReapMoney parses the decimal amount returned by Reap, empty
+// amounts are treated as zero.
+func parseReapMoney(amount string, currency string) (Money, error) {
+	if amount == "" {
+		return NewMoney(0, currency), nil
 	}
+
+	return ParseMoney(amount, currency)
 }
 
-// GetAllCards implements CardService.
-func (s *ReapCardService) ListCards(ctx context.Context, params ListCardsParams) (*ListCardsResponse, error) {
-	cardIDs, err := s.cardRepo.FindCardIDs(ctx)
+func parseReapMoneys(currency string, amounts ...string) ([]Money, error) {
+	parsed := make([]Money, 0, len(amounts))
+	for _, amount := range amounts {
+		m, err := parseReapMoney(amount, currency)
+		if err != nil {
+			return nil, err
+		}
+		parsed = append(parsed, m)
+	}
+
+	return parsed, nil
+}
+
+// UpdateCardStatus implements CardService.
+func (s *ReapCardService) UpdateCardStatus(ctx context.Context, cardID string, status string) error {
+	reapCardID, err := s.cardRepo.GetExternalID(ctx, cardID)
 	if err != nil {
-		return nil, fmt.Errorf("get card ids: %w", err)
+		return fmt.Errorf("get reap card id: %w", err)
+	}
+
+	_, err = s.reapClient.UpdateCardStatus(ctx, reap.UpdateCardStatusParams{
+		CardID: reapCardID,
+		Status: status,
+	})
+	if err != nil {
+		return fmt.Errorf("update reap card status: %w", err)
 	}
 
-	resp, err := s.reapClient.GetCards(ctx, reap.GetCardsParams{
-		MetadataIDs: cardIDs,
+	s.refreshAppliedCardSnapshot(ctx, cardID, reapCardID, status)
+	s.publishAppliedEvent(ctx, Event{
+		Type:   EventCardStatusUpdated,
+		CardID: cardID,
+		Data:   CardEventData{CardID: cardID, Status: status},
 	})
+
+	return nil
+}
+
+// UpdateCardExpiry implements CardService.
+func (s *ReapCardService) UpdateCardExpiry(ctx context.Context, cardID string, params UpdateCardExpiryParams) error {
+	expiry, err := toCardExpiry(cardID, params, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+
+	err = s.cardRepo.SaveCardExpiry(ctx, expiry)
+	if err != nil {
+		return fmt.Errorf("save card expiry: %w", err)
+	}
+
+	return nil
+}
+
+// toCardExpiry validates the expiry params, the expiry action defaults to
+// freezing the card.
+func toCardExpiry(cardID string, params UpdateCardExpiryParams, now time.Time) (CardExpiry, error) {
+	expiry := CardExpiry{CardID: cardID}
+	if params.ValidUntil.IsZero() {
+		if params.ExpiryAction != "" {
+			return expiry, fmt.Errorf("%w: expiry action without valid until", ErrInvalidCardExpiry)
+		}
+		return expiry, nil
+	}
+
+	if !params.ValidUntil.After(now) {
+		return expiry, fmt.Errorf("%w: valid until must be in the future", ErrInvalidCardExpiry)
+	}
+
+	switch params.ExpiryAction {
+	case "":
+		params.ExpiryAction = CardStatusFrozen
+	case CardStatusFrozen, CardStatusTerminated:
+	default:
+		return expiry, fmt.Errorf("%w: expiry action %q", ErrInvalidCardExpiry, params.ExpiryAction)
+	}
+
+	expiry.ValidUntil = params.ValidUntil.UTC()
+	expiry.Action = params.ExpiryAction
+
+	return expiry, nil
+}
+
+// ExpireCards notifies the owners of the cards that expire within the
+// expiry notice and freezes or terminates the expired cards, each step is
+// done once per expiry.
+func (s *ReapCardService) ExpireCards(ctx context.Context) error {
+	expiries, err := s.cardRepo.FindCardExpiries(ctx)
+	if err != nil {
+		return fmt.Errorf("find card expiries: %w", err)
+	}
+
+	now := time.Now().UTC()
+	var errs []error
+	for _, expiry := range expiries {
+		if !expiry.ExpiredAt.IsZero() {
+			continue
+		}
+
+		err = s.expireCard(ctx, expiry, now)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("card %q: %w", expiry.CardID, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (s *ReapCardService) expireCard(ctx context.Context, expiry CardExpiry, now time.Time) error {
+	if now.Before(expiry.ValidUntil) {
+		if !expiry.NotifiedAt.IsZero() || now.Before(expiry.ValidUntil.Add(-s.expiryNotice)) {
+			return nil
+		}
+
+		// the event ID derives from the expiry so the owner is notified
+		// once even when saving the expiry fails
+		err := s.publishEvent(ctx, Event{
+			ID:     uuid.NewSHA1(uuid.NameSpaceURL, []byte(EventCardExpiring+"/"+expiry.CardID+"/"+expiry.ValidUntil.Format(time.RFC3339))).String(),
+			Type:   EventCardExpiring,
+			CardID: expiry.CardID,
+			Data: CardExpiryEventData{
+				CardID:     expiry.CardID,
+				ValidUntil: expiry.ValidUntil,
+				Action:     expiry.Action,
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		expiry.NotifiedAt = now
+		err = s.cardRepo.SaveCardExpiry(ctx, expiry)
+		if err != nil {
+			return fmt.Errorf("save card expiry: %w", err)
+		}
+
+		return nil
+	}
+
+	card, err := s.GetCard(ctx, expiry.CardID)
 	if err != nil {
-		return nil, fmt.Errorf("get reap cards: %w", err)
+		return fmt.Errorf("get card: %w", err)
 	}
 
-	cards := make([]Card, 0, len(resp.Items))
-	for _, reapCard := range resp.Items {
-		cards = append(cards, toAcmeCard(reapCard))
+	// the cards already terminated or frozen by an admin are left as is
+	switch {
+	case card.Status == CardStatusTerminated:
+	case card.Status == CardStatusFrozen && expiry.Action == CardStatusFrozen:
+	default:
+		err = s.UpdateCardStatus(ctx, expiry.CardID, expiry.Action)
+		if err != nil {
+			return fmt.Errorf("update card status: %w", err)
+		}
+	}
+
+	expiry.ExpiredAt = now
+	err = s.cardRepo.SaveCardExpiry(ctx, expiry)
+	if err != nil {
+		return fmt.Errorf("save card expiry: %w", err)
+	}
+
+	return nil
+}
+
+// AdjustCardBalance implements CardService.
+func (s *ReapCardService) AdjustCardBalance(ctx context.Context, cardID string, params AdjustCardBalanceParams) (*AdjustCardBalanceResponse, error) {
+	switch params.Type {
+	case BalanceAdjustmentTopUp, BalanceAdjustmentWithdraw:
+	default:
+		return nil, fmt.Errorf("invalid balance adjustment type %q", params.Type)
+	}
+
+	if params.Amount.Sign() <= 0 {
+		return nil, fmt.Errorf("amount must be positive, got %s", params.Amount)
+	}
+
+	if params.Amount.Currency() != reap.AccountCurrency {
+		return nil, fmt.Errorf("%w: expecting %s, got %s", ErrCurrencyMismatch, reap.AccountCurrency, params.Amount.Currency())
+	}
+
+	if params.Type == BalanceAdjustmentTopUp && s.groupRepo != nil {
+		group, err := s.groupRepo.GetCardGroupByCard(ctx, cardID)
+		if err == nil {
+			// the budget check and the top-up run under the group lock so
+			// that concurrent top-ups cannot overspend the budget
+			var resp *AdjustCardBalanceResponse
+			err = s.groupRepo.LockCardGroup(ctx, group.ID, func(ctx context.Context) error {
+				err := s.checkCardGroupBudget(ctx, group.ID, params.Amount)
+				if err != nil {
+					return err
+				}
+
+				resp, err = s.adjustCardBalance(ctx, cardID, params)
+				return err
+			})
+			if err != nil {
+				return nil, err
+			}
+			return resp, nil
+		}
+		if !errors.Is(err, ErrCardGroupNotFound) {
+			return nil, fmt.Errorf("get card group: %w", err)
+		}
+	}
+
+	return s.adjustCardBalance(ctx, cardID, params)
+}
+
+// adjustCardBalance adjusts the balance of the card in Reap and publishes
+// the event
+func (s *ReapCardService) adjustCardBalance(ctx context.Context, cardID string, params AdjustCardBalanceParams) (*AdjustCardBalanceResponse, error) {
+	reapCardID, err := s.cardRepo.GetExternalID(ctx, cardID)
+	if err != nil {
+		return nil, fmt.Errorf("get reap card id: %w", err)
+	}
+
+	resp, err := s.reapClient.AdjustCardBalance(ctx, reap.AdjustCardBalanceParams{
+		CardID: reapCardID,
+		Type:   params.Type,
+		Amount: json.Number(params.Amount.Decimal()),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("adjust reap card balance: %w", err)
+	}
+
+	availableCredit, err := parseReapMoney(resp.AvailableCredit, reap.AccountCurrency)
+	if err != nil {
+		return nil, fmt.Errorf("parse available credit: %w", err)
+	}
+
+	s.refreshAppliedCardSnapshot(ctx, cardID, reapCardID, "")
+
+	eventType := EventCardFunded
+	if params.Type == BalanceAdjustmentWithdraw {
+		eventType = EventCardWithdrawn
+	}
+	s.publishAppliedEvent(ctx, Event{
+		Type:   eventType,
+		CardID: cardID,
+		Data: CardBalanceEventData{
+			CardID:          cardID,
+			AdjustmentID:    resp.ID,
+			Amount:          params.Amount,
+			AvailableCredit: availableCredit,
+		},
+	})
+
+	return &AdjustCardBalanceResponse{
+		ID:              resp.ID,
+		AvailableCredit: availableCredit,
+	}, nil
+}
+
+// checkCardGroupBudget returns ErrCardGroupBudgetExceeded when the top-up
+// exceeds the remaining budget of the group, the group must be locked.
+func (s *ReapCardService) checkCardGroupBudget(ctx context.Context, groupID int64, amount Money) error {
+	group, err := s.groupRepo.GetCardGroup(ctx, groupID)
+	if err != nil {
+		return fmt.Errorf("get card group: %w", err)
+	}
+
+	group.CardIDs, err = s.groupRepo.FindCardGroupMembers(ctx, group.ID)
+	if err != nil {
+		return fmt.Errorf("find card group members: %w", err)
+	}
+
+	consumption, err := cardGroupConsumption(ctx, s, s, *group, time.Now())
+	if err != nil {
+		return fmt.Errorf("card group consumption: %w", err)
+	}
+
+	cmp, err := amount.Cmp(consumption.Remaining)
+	if err != nil {
+		return fmt.Errorf("compare remaining budget: %w", err)
+	}
+
+	if cmp > 0 {
+		return fmt.Errorf("%w: %s remaining in %q, got %s",
+			ErrCardGroupBudgetExceeded, consumption.Remaining, group.Name, amount)
+	}
+
+	return nil
+}
+
+// ListCards implements CardService.
+func (s *ReapCardService) ListCards(ctx context.Context, params ListCardsParams) (*ListCardsResponse, error) {
+	var cards []Card
+	if s.snapshotRepo != nil && !params.Fresh {
+		snapshots, err := s.snapshotRepo.FindCardSnapshots(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("find card snapshots: %w", err)
+		}
+
+		cards = make([]Card, 0, len(snapshots))
+		for _, snapshot := range snapshots {
+			cards = append(cards, snapshot.Card)
+		}
+	} else {
+		var err error
+		cards, err = s.fetchCards(ctx, listedCardStatuses...)
+		if err != nil {
+			return nil, err
+		}
+
+		if s.snapshotRepo != nil {
+			err = s.saveCardSnapshots(ctx, cards)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	cards, err := s.withCardExpiries(ctx, cards, params.ValidUntilBefore)
+	if err != nil {
+		return nil, err
 	}
 
 	return &ListCardsResponse{
@@ -157,17 +712,264 @@ func (s *ReapCardService) ListCards(ctx context.Context, params ListCardsParams)
 	}, nil
 }
 
-func (s *ReapCardService) ListCardTransactions(ctx context.Context, cardID string) (*ListCardTransactionsResponse, error) {
+// withCardExpiries sets the expiries of the cards, only the cards that
+// expire before validUntilBefore are kept when it is set.
+func (s *ReapCardService) withCardExpiries(ctx context.Context, cards []Card, validUntilBefore time.Time) ([]Card, error) {
+	expiries, err := s.cardRepo.FindCardExpiries(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("find card expiries: %w", err)
+	}
+
+	byCardID := make(map[string]CardExpiry, len(expiries))
+	for _, expiry := range expiries {
+		byCardID[expiry.CardID] = expiry
+	}
+
+	filtered := make([]Card, 0, len(cards))
+	for _, card := range cards {
+		expiry := byCardID[card.ID]
+		card.ValidUntil = expiry.ValidUntil
+		card.ExpiryAction = expiry.Action
+
+		if !validUntilBefore.IsZero() && (card.ValidUntil.IsZero() || !card.ValidUntil.Before(validUntilBefore)) {
+			continue
+		}
+		filtered = append(filtered, card)
+	}
+
+	return filtered, nil
+}
+
+// reapCardsChunkSize limits the metadata IDs per request to keep the url short
+const reapCardsChunkSize = 50
+
+// listedCardStatuses are the statuses of the cards that are listed and
+// snapshotted, the fresh listing and the snapshots must return the same cards
+var listedCardStatuses = []string{reap.CardStatusActive, reap.CardStatusFrozen}
+
+// fetchCards fetches the cards with the given statuses from Reap, the card
+// IDs are sent in chunks and every page is fetched.
+func (s *ReapCardService) fetchCards(ctx context.Context, statuses ...string) ([]Card, error) {
+	cardIDs, err := s.cardRepo.FindCardIDs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get card ids: %w", err)
+	}
+
+	cards := make([]Card, 0, len(cardIDs))
+	for start := 0; start < len(cardIDs); start += reapCardsChunkSize {
+		chunk := cardIDs[start:min(start+reapCardsChunkSize, len(cardIDs))]
+
+		for _, status := range statuses {
+			for page := 1; ; page++ {
+				resp, err := s.reapClient.GetCards(ctx, reap.GetCardsParams{
+					Status:      status,
+					MetadataIDs: chunk,
+					Page:        page,
+					Limit:       reapCardsChunkSize,
+				})
+				if err != nil {
+					return nil, fmt.Errorf("get reap cards: %w", err)
+				}
+
+				for _, reapCard := range resp.Items {
+					card, err := toAcmeCard(reapCard)
+					if err != nil {
+						return nil, fmt.Errorf("to acme card: %w", err)
+					}
+					cards = append(cards, card)
+				}
+
+				if resp.Meta.CurrentPage >= resp.Meta.TotalPages {
+					break
+				}
+			}
+		}
+	}
+
+	return cards, nil
+}
+
+func (s *ReapCardService) saveCardSnapshots(ctx context.Context, cards []Card) error {
+	now := time.Now().UTC()
+	snapshots := make([]CardSnapshot, 0, len(cards))
+	for _, card := range cards {
+		snapshots = append(snapshots, CardSnapshot{Card: card, UpdatedAt: now})
+	}
+
+	err := s.snapshotRepo.SaveCardSnapshots(ctx, snapshots...)
+	if err != nil {
+		return fmt.Errorf("save card snapshots: %w", err)
+	}
+
+	return nil
+}
+
+// SyncCardSnapshots implements CardSnapshotService.
+func (s *ReapCardService) SyncCardSnapshots(ctx context.Context) error {
+	if s.snapshotRepo == nil {
+		return nil
+	}
+
+	syncStart := time.Now().UTC()
+	cards, err := s.fetchCards(ctx, listedCardStatuses...)
+	if err != nil {
+		return err
+	}
+
+	err = s.saveCardSnapshots(ctx, cards)
+	if err != nil {
+		return err
+	}
+
+	// terminated cards are no longer returned by Reap
+	err = s.snapshotRepo.DeleteCardSnapshotsBefore(ctx, syncStart)
+	if err != nil {
+		return fmt.Errorf("delete stale card snapshots: %w", err)
+	}
+
+	return nil
+}
+
+// RefreshCardSnapshot implements CardSnapshotService.
+func (s *ReapCardService) RefreshCardSnapshot(ctx context.Context, externalCardID string) error {
+	if s.snapshotRepo == nil {
+		return nil
+	}
+
+	// ignore cards not issued by us
+	mapping, err := s.cardRepo.GetExternalIDMapping(ctx, externalCardID)
+	if err != nil {
+		return fmt.Errorf("get external id mapping: %w", err)
+	}
+	if _, ok := mapping[externalCardID]; !ok {
+		return nil
+	}
+
+	resp, err := s.reapClient.GetCard(ctx, reap.GetCardParams{CardID: externalCardID})
+	if err != nil {
+		return fmt.Errorf("get reap card: %w", err)
+	}
+
+	card, err := toAcmeCard(resp.Card)
+	if err != nil {
+		return fmt.Errorf("to acme card: %w", err)
+	}
+
+	// e.g. the terminated cards are no longer listed
+	if !slices.Contains(listedCardStatuses, resp.Card.Status) {
+		err = s.snapshotRepo.DeleteCardSnapshot(ctx, card.ID)
+		if err != nil {
+			return fmt.Errorf("delete card snapshot: %w", err)
+		}
+		return nil
+	}
+
+	return s.saveCardSnapshots(ctx, []Card{card})
+}
+
+// refreshAppliedCardSnapshot refreshes the snapshot of a card changed in
+// Reap, the snapshot of a terminated card is deleted. The change was applied
+// so a failure is only logged, the next sync catches up.
+func (s *ReapCardService) refreshAppliedCardSnapshot(ctx context.Context, cardID, reapCardID, status string) {
+	if s.snapshotRepo == nil {
+		return
+	}
+
+	var err error
+	if status == CardStatusTerminated {
+		err = s.snapshotRepo.DeleteCardSnapshot(ctx, cardID)
+	} else {
+		err = s.RefreshCardSnapshot(ctx, reapCardID)
+	}
+	if err != nil {
+		s.logger.ErrorContext(ctx, "refresh card snapshot", "cardID", cardID, "err", err)
+	}
+}
+
+// transactionEventLookback is how far back the transaction events are
+// published, reap filters transactions by day
+const transactionEventLookback = 24 * time.Hour
+
+// PublishTransactionEvents publishes the transactions created and settled
+// within the last day, the event IDs derive from the transaction IDs so the
+// publishers can ignore the events already published.
+func (s *ReapCardService) PublishTransactionEvents(ctx context.Context) error {
+	if len(s.publishers) == 0 {
+		return nil
+	}
+
+	from := time.Now().UTC().Add(-transactionEventLookback)
+	return s.EachTransaction(ctx, DateRange{From: from}, func(t Transaction) error {
+		eventTypes := []string{EventTransactionCreated}
+		if t.IsSettled() {
+			eventTypes = append(eventTypes, EventTransactionSettled)
+		}
+
+		for _, eventType := range eventTypes {
+			err := s.publishEvent(ctx, Event{
+				ID:     uuid.NewSHA1(uuid.NameSpaceURL, []byte(eventType+"/"+t.ID)).String(),
+				Type:   eventType,
+				CardID: t.CardID,
+				Data: TransactionEventData{
+					TransactionID: t.ID,
+					CardID:        t.CardID,
+					Status:        t.Status,
+					Category:      t.Category,
+					Amount:        t.Amount,
+					MerchantName:  t.Merchant.Name,
+					MCCCode:       t.Merchant.MCCCode,
+					CreatedAt:     t.CreatedAt,
+				},
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (s *ReapCardService) publishEvent(ctx context.Context, event Event) error {
+	if event.ID == "" {
+		event.ID = uuid.New().String()
+	}
+	event.CreatedAt = time.Now().UTC()
+
+	// a failing publisher does not keep the event from the others
+	var errs []error
+	for _, publisher := range s.publishers {
+		err := publisher.PublishEvent(ctx, event)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("publish %s event: %w", event.Type, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// publishAppliedEvent publishes the event of an operation already applied at
+// Reap. The errors are only logged, failing would make the callers repeat
+// the operation.
+func (s *ReapCardService) publishAppliedEvent(ctx context.Context, event Event) {
+	err := s.publishEvent(ctx, event)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "publish event", "type", event.Type, "cardID", event.CardID, "err", err)
+	}
+}
+
+func (s *ReapCardService) ListCardTransactions(ctx context.Context, cardID string, params ListCardTransactionsParams) (*ListCardTransactionsResponse, error) {
 	reapCardID, err := s.cardRepo.GetExternalID(ctx, cardID)
 	if err != nil {
 		return nil, fmt.Errorf("get reap card id: %w", err)
 	}
 
-	fromDate := time.Now().Format("2006-01-02")
+	fromDate, toDate := toReapDateRange(params.DateRange)
 	resp, err := s.reapClient.GetCardTransactions(ctx, reap.GetCardTransactionsParams{
 		CardID:   reapCardID,
 		FromDate: fromDate,
-		Limit:    10,
+		ToDate:   toDate,
+		Limit:    limitOrDefault(params.Limit),
 	})
 	if err != nil {
 		return nil, fmt.Errorf("get reap card transactions: %w", err)
@@ -175,7 +977,11 @@ func (s *ReapCardService) ListCardTransactions(ctx context.Context, cardID strin
 
 	transactions := make([]Transaction, 0, len(resp.Transactions))
 	for _, t := range resp.Transactions {
-		transactions = append(transactions, toAcmeTransaction(cardID, t))
+		transaction, err := toAcmeTransaction(cardID, t)
+		if err != nil {
+			return nil, fmt.Errorf("to acme transaction: %w", err)
+		}
+		transactions = append(transactions, transaction)
 	}
 
 	return &ListCardTransactionsResponse{
@@ -183,21 +989,109 @@ func (s *ReapCardService) ListCardTransactions(ctx context.Context, cardID strin
 	}, nil
 }
 
-func (s *ReapCardService) ListTransactions(ctx context.Context) (*ListTransactionsResponse, error) {
-
-	fromDate := time.Now().Format("2006-01-02")
+func (s *ReapCardService) ListTransactions(ctx context.Context, params ListTransactionsParams) (*ListTransactionsResponse, error) {
+	fromDate, toDate := toReapDateRange(params.DateRange)
 	resp, err := s.reapClient.GetAllTransactions(ctx, reap.GetAllTransactionsParams{
 		FromDate: fromDate,
-		Limit:    10,
+		ToDate:   toDate,
+		Limit:    limitOrDefault(params.Limit),
 	})
 	if err != nil {
 		return nil, fmt.Errorf("get reap card transactions: %w", err)
 	}
 
+	transactions, err := s.toAcmeTransactions(ctx, resp.Transactions)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ListTransactionsResponse{
+		Transactions: transactions,
+	}, nil
+}
+
+// reapTransactionsPageSize is the page size when walking every transaction
+const reapTransactionsPageSize = 100
+
+// EachTransaction implements TransactionSource, it walks every page of the
+// account transactions in the date range.
+func (s *ReapCardService) EachTransaction(ctx context.Context, dateRange DateRange, fn func(Transaction) error) error {
+	fromDate, toDate := toReapDateRange(dateRange)
+	for page := 1; ; page++ {
+		resp, err := s.reapClient.GetAllTransactions(ctx, reap.GetAllTransactionsParams{
+			FromDate: fromDate,
+			ToDate:   toDate,
+			Limit:    reapTransactionsPageSize,
+			Page:     page,
+		})
+		if err != nil {
+			return fmt.Errorf("get reap transactions: %w", err)
+		}
+
+		transactions, err := s.toAcmeTransactions(ctx, resp.Transactions)
+		if err != nil {
+			return err
+		}
+
+		for _, t := range transactions {
+			err = fn(t)
+			if err != nil {
+				return err
+			}
+		}
+
+		if resp.Meta.CurrentPage >= resp.Meta.TotalPages {
+			return nil
+		}
+	}
+}
+
+// EachCardTransaction implements TransactionSource, it walks every page of
+// the card transactions in the date range.
+func (s *ReapCardService) EachCardTransaction(ctx context.Context, cardID string, dateRange DateRange, fn func(Transaction) error) error {
+	reapCardID, err := s.cardRepo.GetExternalID(ctx, cardID)
+	if err != nil {
+		return fmt.Errorf("get reap card id: %w", err)
+	}
+
+	fromDate, toDate := toReapDateRange(dateRange)
+	for page := 1; ; page++ {
+		resp, err := s.reapClient.GetCardTransactions(ctx, reap.GetCardTransactionsParams{
+			CardID:   reapCardID,
+			FromDate: fromDate,
+			ToDate:   toDate,
+			Limit:    reapTransactionsPageSize,
+			Page:     page,
+		})
+		if err != nil {
+			return fmt.Errorf("get reap card transactions: %w", err)
+		}
+
+		for _, t := range resp.Transactions {
+			transaction, err := toAcmeTransaction(cardID, t)
+			if err != nil {
+				return fmt.Errorf("to acme transaction: %w", err)
+			}
+
+			err = fn(transaction)
+			if err != nil {
+				return err
+			}
+		}
+
+		if resp.Meta.CurrentPage >= resp.Meta.TotalPages {
+			return nil
+		}
+	}
+}
+
+// toAcmeTransactions maps the reap transactions, transactions of cards
+// not issued by us are skipped.
+func (s *ReapCardService) toAcmeTransactions(ctx context.Context, reapTxs []reap.Transaction) ([]Transaction, error) {
 	// collect unique reap card ids
 	externalIDsSet := make(map[string]struct{})
-	externalIDs := make([]string, 0, len(externalIDsSet))
-	for _, t := range resp.Transactions {
+	externalIDs := make([]string, 0, len(reapTxs))
+	for _, t := range reapTxs {
 		if _, ok := externalIDsSet[t.CardID]; ok {
 			continue
 		}
@@ -210,56 +1104,80 @@ func (s *ReapCardService) ListTransactions(ctx context.Context) (*ListTransactio
 		return nil, fmt.Errorf("get external id mapping: %w", err)
 	}
 
-	transactions := make([]Transaction, 0, len(resp.Transactions))
-	for _, t := range resp.Transactions {
+	transactions := make([]Transaction, 0, len(reapTxs))
+	for _, t := range reapTxs {
 		// skip transactions with no mapping from database
 		cardID, ok := cardIDMapping[t.CardID]
 		if !ok {
 			continue
 		}
-		transactions = append(transactions, toAcmeTransaction(cardID, t))
+
+		transaction, err := toAcmeTransaction(cardID, t)
+		if err != nil {
+			return nil, fmt.Errorf("to acme transaction: %w", err)
+		}
+		transactions = append(transactions, transaction)
 	}
 
-	return &ListTransactionsResponse{
-		Transactions: transactions,
-	}, nil
+	return transactions, nil
 }
 
-func toAcmeTransaction(cardID string, reapTx reap.Transaction) Transaction {
+func toAcmeTransaction(cardID string, reapTx reap.Transaction) (Transaction, error) {
+	// fees are charged in the bill currency
+	parsed, err := parseReapMoneys(reapTx.BillCurrency,
+		reapTx.BillAmount,
+		reapTx.Fees.ATMFees,
+		reapTx.Fees.FXFees,
+	)
+	if err != nil {
+		return Transaction{}, fmt.Errorf("parse transaction %q amounts: %w", reapTx.ID, err)
+	}
+
+	transactionAmount, err := parseReapMoney(reapTx.TransactionAmount, reapTx.TransactionCurrency)
+	if err != nil {
+		return Transaction{}, fmt.Errorf("parse transaction %q local amount: %w", reapTx.ID, err)
+	}
+
 	return Transaction{
-		ID:        reapTx.ID,
-		CardID:    cardID,
-		Category:  reapTx.Category,
-		Status:    reapTx.Status,
-		Channel:   reapTx.Channel,
-		Amount:    reapTx.BillAmount,
-		Currency:  reapTx.BillCurrency,
-		CreatedAt: reapTx.CreatedAt,
+		ID:                reapTx.ID,
+		CardID:            cardID,
+		Category:          reapTx.Category,
+		Status:            reapTx.Status,
+		Channel:           reapTx.Channel,
+		Amount:            parsed[0],
+		TransactionAmount: transactionAmount,
+		ConversionRate:    reapTx.ConversionRate,
+		CreatedAt:         reapTx.CreatedAt,
 		Fees: FeeDetails{
-			ATMFees: reapTx.Fees.ATMFees,
-			FXFees:  reapTx.Fees.FXFees,
+			ATMFees: parsed[1],
+			FXFees:  parsed[2],
 		},
 		Merchant: MerchantDetails{
-			ID:      reapTx.Merchant.ID,
-			Name:    reapTx.Merchant.Name,
-			City:    reapTx.Merchant.City,
-			Country: reapTx.Merchant.Country,
+			ID:          reapTx.Merchant.ID,
+			Name:        reapTx.Merchant.Name,
+			City:        reapTx.Merchant.City,
+			PostCode:    reapTx.Merchant.PostCode,
+			State:       reapTx.Merchant.State,
+			Country:     reapTx.Merchant.Country,
+			MCCCode:     reapTx.Merchant.MCCCode,
+			MCCCategory: reapTx.Merchant.MCCCategory,
 		},
-	}
+	}, nil
 }
 
 // ListCardBalanceHistory implements CardService.
-func (s *ReapCardService) ListCardBalanceHistory(ctx context.Context, cardID string) (*ListCardBalanceHistoryResponse, error) {
+func (s *ReapCardService) ListCardBalanceHistory(ctx context.Context, cardID string, params ListCardBalanceHistoryParams) (*ListCardBalanceHistoryResponse, error) {
 	reapCardID, err := s.cardRepo.GetExternalID(ctx, cardID)
 	if err != nil {
 		return nil, fmt.Errorf("get reap card id: %w", err)
 	}
 
-	fromDate := time.Now().Format("2006-01-02")
+	fromDate, toDate := toReapDateRange(params.DateRange)
 	resp, err := s.reapClient.GetCardBalanceHistory(ctx, reap.GetCardBalanceHistoryParams{
 		CardID:   reapCardID,
 		FromDate: fromDate,
-		Limit:    10,
+		ToDate:   toDate,
+		Limit:    limitOrDefault(params.Limit),
 	})
 	if err != nil {
 		return nil, fmt.Errorf("get reap card balance history: %w", err)
@@ -267,7 +1185,11 @@ func (s *ReapCardService) ListCardBalanceHistory(ctx context.Context, cardID str
 
 	balanceChanges := make([]BalanceChange, 0, len(resp.BalanceChanges))
 	for _, b := range resp.BalanceChanges {
-		balanceChanges = append(balanceChanges, toAcmeBalanceChange(b))
+		balanceChange, err := toAcmeBalanceChange(b)
+		if err != nil {
+			return nil, fmt.Errorf("to acme balance change: %w", err)
+		}
+		balanceChanges = append(balanceChanges, balanceChange)
 	}
 
 	return &ListCardBalanceHistoryResponse{
@@ -275,13 +1197,84 @@ func (s *ReapCardService) ListCardBalanceHistory(ctx context.Context, cardID str
 	}, nil
 }
 
-func toAcmeBalanceChange(bc reap.BalanceChange) BalanceChange {
+// EachCardBalanceChange implements BalanceChangeSource, it walks every page
+// of the card balance history in the date range.
+func (s *ReapCardService) EachCardBalanceChange(ctx context.Context, cardID string, dateRange DateRange, fn func(BalanceChange) error) error {
+	reapCardID, err := s.cardRepo.GetExternalID(ctx, cardID)
+	if err != nil {
+		return fmt.Errorf("get reap card id: %w", err)
+	}
+
+	fromDate, toDate := toReapDateRange(dateRange)
+	for page := 1; ; page++ {
+		resp, err := s.reapClient.GetCardBalanceHistory(ctx, reap.GetCardBalanceHistoryParams{
+			CardID:   reapCardID,
+			FromDate: fromDate,
+			ToDate:   toDate,
+			Limit:    reapTransactionsPageSize,
+			Page:     page,
+		})
+		if err != nil {
+			return fmt.Errorf("get reap card balance history: %w", err)
+		}
+
+		for _, b := range resp.BalanceChanges {
+			balanceChange, err := toAcmeBalanceChange(b)
+			if err != nil {
+				return fmt.Errorf("to acme balance change: %w", err)
+			}
+
+			err = fn(balanceChange)
+			if err != nil {
+				return err
+			}
+		}
+
+		if resp.Meta.CurrentPage >= resp.Meta.TotalPages {
+			return nil
+		}
+	}
+}
+
+func toAcmeBalanceChange(bc reap.BalanceChange) (BalanceChange, error) {
+	amount, err := parseReapMoney(bc.Amount, bc.Currency)
+	if err != nil {
+		return BalanceChange{}, fmt.Errorf("parse balance change %q amount: %w", bc.ID, err)
+	}
+
 	return BalanceChange{
-		ID:       bc.ID,
-		Date:     bc.Date,
-		Type:     bc.Type,
-		Status:   bc.Status,
-		Amount:   bc.Amount,
-		Currency: bc.Currency,
+		ID:     bc.ID,
+		Date:   bc.Date,
+		Type:   bc.Type,
+		Status: bc.Status,
+		Amount: amount,
+	}, nil
+}
+
+const (
+	reapDateLayout = "2006-01-02"
+	defaultLimit   = 10
+)
+
+// toReapDateRange formats the date range for reap, the from date
+// defaults to the current day.
+func toReapDateRange(r DateRange) (fromDate, toDate string) {
+	from := r.From
+	if from.IsZero() {
+		from = time.Now()
+	}
+	fromDate = from.Format(reapDateLayout)
+
+	if !r.To.IsZero() {
+		toDate = r.To.Format(reapDateLayout)
+	}
+
+	return fromDate, toDate
+}
+
+func limitOrDefault(limit int) int {
+	if limit <= 0 {
+		return defaultLimit
 	}
+	return limit
 }
@@ -1 +1,487 @@
 package acme_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/acme/memory"
+	"github.com/stevenferrer/acme-cards-api/reap"
+)
+
+// stubReapClient embeds reap.Client so tests only stub the methods they use
+type stubReapClient struct {
+	reap.Client
+
+	createCard          func(reap.CreateCardParams) (*reap.CreateCardResponse, error)
+	getCard             func(reap.GetCardParams) (*reap.GetCardResponse, error)
+	updateCardStatus    func(reap.UpdateCardStatusParams) (*reap.UpdateCardStatusResponse, error)
+	getCards            func(reap.GetCardsParams) (*reap.GetCardsResponse, error)
+	getCardTransactions func(reap.GetCardTransactionsParams) (*reap.GetCardTransactionsResponse, error)
+	getAllTransactions  func(reap.GetAllTransactionsParams) (*reap.GetAllTransactionsResponse, error)
+	adjustCardBalance   func(reap.AdjustCardBalanceParams) (*reap.AdjustCardBalanceResponse, error)
+}
+
+func (c *stubReapClient) CreateCard(_ context.Context, params reap.CreateCardParams) (*reap.CreateCardResponse, error) {
+	return c.createCard(params)
+}
+
+func (c *stubReapClient) GetCard(_ context.Context, params reap.GetCardParams) (*reap.GetCardResponse, error) {
+	return c.getCard(params)
+}
+
+func (c *stubReapClient) UpdateCardStatus(_ context.Context, params reap.UpdateCardStatusParams) (*reap.UpdateCardStatusResponse, error) {
+	return c.updateCardStatus(params)
+}
+
+func (c *stubReapClient) GetCards(_ context.Context, params reap.GetCardsParams) (*reap.GetCardsResponse, error) {
+	return c.getCards(params)
+}
+
+func (c *stubReapClient) GetCardTransactions(_ context.Context, params reap.GetCardTransactionsParams) (*reap.GetCardTransactionsResponse, error) {
+	return c.getCardTransactions(params)
+}
+
+func (c *stubReapClient) GetAllTransactions(_ context.Context, params reap.GetAllTransactionsParams) (*reap.GetAllTransactionsResponse, error) {
+	return c.getAllTransactions(params)
+}
+
+func (c *stubReapClient) AdjustCardBalance(_ context.Context, params reap.AdjustCardBalanceParams) (*reap.AdjustCardBalanceResponse, error) {
+	return c.adjustCardBalance(params)
+}
+
+func TestReapCardServiceListCards(t *testing.T) {
+	ctx := context.Background()
+
+	cardRepo := memory.NewCardRepository()
+	for i := range 120 {
+		err := cardRepo.SaveCardID(ctx, fmt.Sprintf("card%d", i), fmt.Sprintf("external%d", i))
+		require.NoError(t, err)
+	}
+
+	var calls int
+	reapClient := &stubReapClient{
+		getCards: func(params reap.GetCardsParams) (*reap.GetCardsResponse, error) {
+			calls++
+			assert.LessOrEqual(t, len(params.MetadataIDs), 50, "metadata ids must be chunked")
+
+			// the odd cards are frozen
+			items := make([]reap.Card, 0, len(params.MetadataIDs))
+			for _, id := range params.MetadataIDs {
+				var n int
+				_, err := fmt.Sscanf(id, "card%d", &n)
+				require.NoError(t, err)
+
+				status := reap.CardStatusActive
+				if n%2 == 1 {
+					status = reap.CardStatusFrozen
+				}
+				if status == params.Status {
+					items = append(items, reap.Card{Status: status, Meta: reap.Meta{ID: id}})
+				}
+			}
+
+			return &reap.GetCardsResponse{
+				Items: items,
+				Meta:  reap.Pagination{CurrentPage: 1, TotalPages: 1},
+			}, nil
+		},
+	}
+
+	cardSvc := acme.NewReapCardService(reapClient, cardRepo)
+	resp, err := cardSvc.ListCards(ctx, acme.ListCardsParams{})
+	require.NoError(t, err)
+
+	// the active and the frozen cards are fetched like the snapshots
+	assert.Equal(t, 6, calls)
+	require.Len(t, resp.Cards, 120)
+	statuses := map[string]string{}
+	for _, card := range resp.Cards {
+		statuses[card.ID] = card.Status
+	}
+	assert.Equal(t, acme.CardStatusActive, statuses["card118"])
+	assert.Equal(t, acme.CardStatusFrozen, statuses["card119"])
+}
+
+func TestReapCardServiceListCardTransactions(t *testing.T) {
+	ctx := context.Background()
+
+	cardRepo := memory.NewCardRepository()
+	require.NoError(t, cardRepo.SaveCardID(ctx, "card1", "external1"))
+
+	createdAt := time.Date(2025, 3, 14, 9, 26, 53, 0, time.UTC)
+	reapClient := &stubReapClient{
+		getCardTransactions: func(params reap.GetCardTransactionsParams) (*reap.GetCardTransactionsResponse, error) {
+			assert.Equal(t, "external1", params.CardID)
+
+			return &reap.GetCardTransactionsResponse{
+				Transactions: []reap.Transaction{{
+					ID:     "tx1",
+					CardID: "external1",
+					Merchant: reap.Merchant{
+						ID:          "m1",
+						Name:        "Tsukiji Sushi",
+						City:        "Tokyo",
+						PostCode:    "104-0045",
+						State:       "Tokyo",
+						Country:     "JPN",
+						MCCCategory: "Restaurants",
+						MCCCode:     "5812",
+					},
+					Category:            "purchase",
+					Fees:                reap.Fees{FXFees: "0.27"},
+					BillAmount:          "13.45",
+					BillCurrency:        "USD",
+					TransactionAmount:   "2000.00",
+					TransactionCurrency: "JPY",
+					ConversionRate:      "0.006725",
+					Status:              "settled",
+					Channel:             "POS",
+					CreatedAt:           createdAt,
+				}},
+			}, nil
+		},
+	}
+
+	cardSvc := acme.NewReapCardService(reapClient, cardRepo)
+	resp, err := cardSvc.ListCardTransactions(ctx, "card1", acme.ListCardTransactionsParams{})
+	require.NoError(t, err)
+
+	expect := []acme.Transaction{{
+		ID:                "tx1",
+		CardID:            "card1",
+		Category:          "purchase",
+		Status:            "settled",
+		Channel:           "POS",
+		Amount:            acme.MustParseMoney("13.45", "USD"),
+		TransactionAmount: acme.MustParseMoney("2000", "JPY"),
+		ConversionRate:    "0.006725",
+		Fees: acme.FeeDetails{
+			ATMFees: acme.NewMoney(0, "USD"),
+			FXFees:  acme.MustParseMoney("0.27", "USD"),
+		},
+		Merchant: acme.MerchantDetails{
+			ID:          "m1",
+			Name:        "Tsukiji Sushi",
+			City:        "Tokyo",
+			PostCode:    "104-0045",
+			State:       "Tokyo",
+			Country:     "JPN",
+			MCCCode:     "5812",
+			MCCCategory: "Restaurants",
+		},
+		CreatedAt: createdAt,
+	}}
+	assert.Equal(t, expect, resp.Transactions)
+}
+
+func TestReapCardServiceEachTransaction(t *testing.T) {
+	ctx := context.Background()
+
+	cardRepo := memory.NewCardRepository()
+	require.NoError(t, cardRepo.SaveCardID(ctx, "card1", "external1"))
+
+	reapClient := &stubReapClient{
+		getAllTransactions: func(params reap.GetAllTransactionsParams) (*reap.GetAllTransactionsResponse, error) {
+			assert.Equal(t, "2025-03-01", params.FromDate)
+			assert.Equal(t, "2025-03-31", params.ToDate)
+
+			return &reap.GetAllTransactionsResponse{
+				Transactions: []reap.Transaction{
+					{ID: fmt.Sprintf("tx%d", params.Page), CardID: "external1", BillAmount: "-1.00", BillCurrency: "USD"},
+					// cards not issued by us are skipped
+					{ID: "other", CardID: "external2", BillAmount: "-1.00", BillCurrency: "USD"},
+				},
+				Meta: reap.Pagination{CurrentPage: params.Page, TotalPages: 3},
+			}, nil
+		},
+	}
+
+	cardSvc := acme.NewReapCardService(reapClient, cardRepo)
+
+	var ids []string
+	err := cardSvc.EachTransaction(ctx, acme.DateRange{
+		From: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC),
+	}, func(tx acme.Transaction) error {
+		assert.Equal(t, "card1", tx.CardID)
+		ids = append(ids, tx.ID)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"tx1", "tx2", "tx3"}, ids)
+}
+
+// recordingPublisher records the published events, err fails the publishing
+type recordingPublisher struct {
+	events []acme.Event
+	err    error
+}
+
+func (p *recordingPublisher) PublishEvent(_ context.Context, event acme.Event) error {
+	if p.err != nil {
+		return p.err
+	}
+	p.events = append(p.events, event)
+	return nil
+}
+
+func TestReapCardServicePublishFailure(t *testing.T) {
+	ctx := context.Background()
+
+	cardRepo := memory.NewCardRepository()
+	require.NoError(t, cardRepo.SaveCardID(ctx, "card1", "external1"))
+
+	var adjusted, updated int
+	reapClient := &stubReapClient{
+		adjustCardBalance: func(reap.AdjustCardBalanceParams) (*reap.AdjustCardBalanceResponse, error) {
+			adjusted++
+			return &reap.AdjustCardBalanceResponse{ID: "adjustment1", AvailableCredit: "10.00"}, nil
+		},
+		updateCardStatus: func(params reap.UpdateCardStatusParams) (*reap.UpdateCardStatusResponse, error) {
+			updated++
+			return &reap.UpdateCardStatusResponse{Status: params.Status}, nil
+		},
+	}
+
+	// the operations are applied at reap, an outage of a publisher neither
+	// fails them nor keeps the event from the other publishers
+	failing := &recordingPublisher{err: errors.New("webhooks are down")}
+	publisher := &recordingPublisher{}
+	cardSvc := acme.NewReapCardService(reapClient, cardRepo,
+		acme.WithEventPublisher(failing),
+		acme.WithEventPublisher(publisher),
+		acme.WithLogger(slog.New(slog.DiscardHandler)),
+	)
+
+	resp, err := cardSvc.AdjustCardBalance(ctx, "card1", acme.AdjustCardBalanceParams{
+		Type:   acme.BalanceAdjustmentTopUp,
+		Amount: acme.MustParseMoney("10.00", "USD"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "adjustment1", resp.ID)
+	assert.Equal(t, 1, adjusted)
+
+	require.NoError(t, cardSvc.UpdateCardStatus(ctx, "card1", acme.CardStatusFrozen))
+	assert.Equal(t, 1, updated)
+
+	require.Len(t, publisher.events, 2)
+	assert.Equal(t, acme.EventCardFunded, publisher.events[0].Type)
+	assert.Equal(t, acme.EventCardStatusUpdated, publisher.events[1].Type)
+}
+
+func TestReapCardServiceCardSnapshots(t *testing.T) {
+	ctx := context.Background()
+
+	cardRepo := memory.NewCardRepository()
+	for _, cardID := range []string{"card1", "card2"} {
+		require.NoError(t, cardRepo.SaveCardID(ctx, cardID, "external-"+cardID))
+	}
+
+	// reapCards are the cards in Reap by external ID
+	reapCards := map[string]reap.Card{
+		"external-card1": {ID: "external-card1", Status: reap.CardStatusActive, AvailableCredit: "10.00", Meta: reap.Meta{ID: "card1"}},
+		"external-card2": {ID: "external-card2", Status: reap.CardStatusActive, AvailableCredit: "10.00", Meta: reap.Meta{ID: "card2"}},
+	}
+	var getErr error
+	reapClient := &stubReapClient{
+		getCard: func(params reap.GetCardParams) (*reap.GetCardResponse, error) {
+			if getErr != nil {
+				return nil, getErr
+			}
+			return &reap.GetCardResponse{Card: reapCards[params.CardID]}, nil
+		},
+		updateCardStatus: func(params reap.UpdateCardStatusParams) (*reap.UpdateCardStatusResponse, error) {
+			card := reapCards[params.CardID]
+			card.Status = params.Status
+			reapCards[params.CardID] = card
+			return &reap.UpdateCardStatusResponse{Status: params.Status}, nil
+		},
+		adjustCardBalance: func(params reap.AdjustCardBalanceParams) (*reap.AdjustCardBalanceResponse, error) {
+			card := reapCards[params.CardID]
+			card.AvailableCredit = "25.00"
+			reapCards[params.CardID] = card
+			return &reap.AdjustCardBalanceResponse{ID: "adjustment1", AvailableCredit: card.AvailableCredit}, nil
+		},
+	}
+
+	snapshotRepo := memory.NewCardSnapshotRepository(cardRepo)
+	cardSvc := acme.NewReapCardService(reapClient, cardRepo,
+		acme.WithCardSnapshotRepository(snapshotRepo),
+		acme.WithLogger(slog.New(slog.DiscardHandler)),
+	)
+	for _, externalID := range []string{"external-card1", "external-card2"} {
+		require.NoError(t, cardSvc.RefreshCardSnapshot(ctx, externalID))
+	}
+
+	snapshot := func(cardID string) *acme.CardSnapshot {
+		snapshots, err := snapshotRepo.FindCardSnapshots(ctx)
+		require.NoError(t, err)
+		for _, s := range snapshots {
+			if s.ID == cardID {
+				return &s
+			}
+		}
+		return nil
+	}
+
+	// the changes made through the service refresh the snapshots
+	require.NoError(t, cardSvc.UpdateCardStatus(ctx, "card1", acme.CardStatusFrozen))
+	assert.Equal(t, acme.CardStatusFrozen, snapshot("card1").Status)
+
+	_, err := cardSvc.AdjustCardBalance(ctx, "card1", acme.AdjustCardBalanceParams{
+		Type:   acme.BalanceAdjustmentTopUp,
+		Amount: acme.MustParseMoney("15.00", "USD"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, acme.MustParseMoney("25.00", "USD"), snapshot("card1").AvailableCredit)
+
+	// a failed refresh does not fail the applied change
+	getErr = errors.New("reap is down")
+	require.NoError(t, cardSvc.UpdateCardStatus(ctx, "card1", acme.CardStatusActive))
+	assert.Equal(t, acme.CardStatusFrozen, snapshot("card1").Status)
+	getErr = nil
+
+	// the terminated cards are no longer listed
+	require.NoError(t, cardSvc.UpdateCardStatus(ctx, "card1", acme.CardStatusTerminated))
+	assert.Nil(t, snapshot("card1"))
+
+	// e.g. the card expired in Reap and a webhook was received
+	card2 := reapCards["external-card2"]
+	card2.Status = reap.CardStatusTerminated
+	reapCards["external-card2"] = card2
+	require.NoError(t, cardSvc.RefreshCardSnapshot(ctx, "external-card2"))
+	assert.Nil(t, snapshot("card2"))
+}
+
+func TestReapCardServiceExpireCards(t *testing.T) {
+	ctx := context.Background()
+
+	cardRepo := memory.NewCardRepository()
+	for _, cardID := range []string{"card1", "card2", "card3"} {
+		require.NoError(t, cardRepo.SaveCardID(ctx, cardID, "external-"+cardID))
+	}
+
+	statuses := map[string]string{
+		"external-card1": reap.CardStatusActive,
+		"external-card2": reap.CardStatusActive,
+		"external-card3": reap.CardStatusActive,
+	}
+	reapClient := &stubReapClient{
+		getCard: func(params reap.GetCardParams) (*reap.GetCardResponse, error) {
+			return &reap.GetCardResponse{Card: reap.Card{
+				Status: statuses[params.CardID],
+				Meta:   reap.Meta{ID: strings.TrimPrefix(params.CardID, "external-")},
+			}}, nil
+		},
+		updateCardStatus: func(params reap.UpdateCardStatusParams) (*reap.UpdateCardStatusResponse, error) {
+			statuses[params.CardID] = params.Status
+			return &reap.UpdateCardStatusResponse{Status: params.Status}, nil
+		},
+	}
+
+	publisher := &recordingPublisher{}
+	cardSvc := acme.NewReapCardService(reapClient, cardRepo,
+		acme.WithEventPublisher(publisher),
+		acme.WithCardExpiryNotice(3),
+	)
+
+	t.Run("invalid expiries", func(t *testing.T) {
+		for _, params := range []acme.UpdateCardExpiryParams{
+			{ValidUntil: time.Now().Add(-time.Hour)},
+			{ValidUntil: time.Now().Add(time.Hour), ExpiryAction: acme.CardStatusActive},
+			{ExpiryAction: acme.CardStatusFrozen},
+		} {
+			err := cardSvc.UpdateCardExpiry(ctx, "card1", params)
+			assert.ErrorIs(t, err, acme.ErrInvalidCardExpiry)
+		}
+
+		err := cardSvc.UpdateCardExpiry(ctx, "card4", acme.UpdateCardExpiryParams{ValidUntil: time.Now().Add(time.Hour)})
+		assert.ErrorIs(t, err, acme.ErrCardNotFound)
+	})
+
+	now := time.Now().UTC()
+	require.NoError(t, cardSvc.UpdateCardExpiry(ctx, "card1", acme.UpdateCardExpiryParams{
+		ValidUntil: now.AddDate(0, 0, 2),
+	}))
+	require.NoError(t, cardSvc.UpdateCardExpiry(ctx, "card2", acme.UpdateCardExpiryParams{
+		ValidUntil:   now.AddDate(0, 0, 10),
+		ExpiryAction: acme.CardStatusTerminated,
+	}))
+
+	card, err := cardSvc.GetCard(ctx, "card1")
+	require.NoError(t, err)
+	assert.Equal(t, acme.CardStatusFrozen, card.ExpiryAction)
+	assert.False(t, card.ValidUntil.IsZero())
+
+	t.Run("notified", func(t *testing.T) {
+		// only card1 expires within the notice
+		require.NoError(t, cardSvc.ExpireCards(ctx))
+		require.Len(t, publisher.events, 1)
+		assert.Equal(t, acme.EventCardExpiring, publisher.events[0].Type)
+		assert.Equal(t, "card1", publisher.events[0].CardID)
+
+		// the owners are notified once
+		require.NoError(t, cardSvc.ExpireCards(ctx))
+		assert.Len(t, publisher.events, 1)
+		assert.Equal(t, reap.CardStatusActive, statuses["external-card1"])
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		// move the expiries to the past
+		expiries, err := cardRepo.FindCardExpiries(ctx)
+		require.NoError(t, err)
+		for _, expiry := range expiries {
+			expiry.ValidUntil = now.Add(-time.Minute)
+			require.NoError(t, cardRepo.SaveCardExpiry(ctx, expiry))
+		}
+
+		require.NoError(t, cardSvc.ExpireCards(ctx))
+		assert.Equal(t, reap.CardStatusFrozen, statuses["external-card1"])
+		assert.Equal(t, reap.CardStatusTerminated, statuses["external-card2"])
+		assert.Equal(t, reap.CardStatusActive, statuses["external-card3"])
+
+		// unfrozen cards are not frozen again
+		statuses["external-card1"] = reap.CardStatusActive
+		require.NoError(t, cardSvc.ExpireCards(ctx))
+		assert.Equal(t, reap.CardStatusActive, statuses["external-card1"])
+
+		expiry, err := cardRepo.GetCardExpiry(ctx, "card1")
+		require.NoError(t, err)
+		assert.False(t, expiry.ExpiredAt.IsZero())
+	})
+
+	t.Run("list filter", func(t *testing.T) {
+		require.NoError(t, cardSvc.UpdateCardExpiry(ctx, "card1", acme.UpdateCardExpiryParams{ValidUntil: now.AddDate(0, 1, 0)}))
+		require.NoError(t, cardSvc.UpdateCardExpiry(ctx, "card2", acme.UpdateCardExpiryParams{}))
+
+		snapshotRepo := memory.NewCardSnapshotRepository(cardRepo)
+		listSvc := acme.NewReapCardService(reapClient, cardRepo, acme.WithCardSnapshotRepository(snapshotRepo))
+		for _, cardID := range []string{"card1", "card2", "card3"} {
+			require.NoError(t, snapshotRepo.SaveCardSnapshots(ctx, acme.CardSnapshot{Card: acme.Card{ID: cardID}}))
+		}
+
+		resp, err := listSvc.ListCards(ctx, acme.ListCardsParams{})
+		require.NoError(t, err)
+		require.Len(t, resp.Cards, 3)
+		assert.False(t, resp.Cards[2].ValidUntil.IsZero())
+
+		resp, err = listSvc.ListCards(ctx, acme.ListCardsParams{ValidUntilBefore: now.AddDate(0, 2, 0)})
+		require.NoError(t, err)
+		require.Len(t, resp.Cards, 1)
+		assert.Equal(t, "card1", resp.Cards[0].ID)
+		assert.Equal(t, acme.CardStatusFrozen, resp.Cards[0].ExpiryAction)
+
+		resp, err = listSvc.ListCards(ctx, acme.ListCardsParams{ValidUntilBefore: now})
+		require.NoError(t, err)
+		assert.Empty(t, resp.Cards)
+	})
+}
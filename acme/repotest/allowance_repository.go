@@ -0,0 +1,210 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+)
+
+// AllowanceRepositoryFactory returns empty repositories sharing the same
+// storage, it is called once per test.
+type AllowanceRepositoryFactory func(t *testing.T) (acme.CardRepository, acme.AllowanceRepository)
+
+// RunAllowanceRepositorySuite runs the acme.AllowanceRepository conformance tests.
+func RunAllowanceRepositorySuite(t *testing.T, newRepos AllowanceRepositoryFactory) {
+	ctx := context.Background()
+	usd := func(amount string) acme.Money { return acme.MustParseMoney(amount, "USD") }
+	now := time.Now().UTC().Truncate(time.Minute)
+
+	// newSchedules saves a due schedule of card1 and a later schedule of
+	// card2
+	newSchedules := func(t *testing.T) (acme.AllowanceRepository, *acme.AllowanceSchedule, *acme.AllowanceSchedule) {
+		cardRepo, repo := newRepos(t)
+		require.NoError(t, cardRepo.SaveCardID(ctx, "card1", "external1"))
+		require.NoError(t, cardRepo.SaveCardID(ctx, "card2", "external2"))
+
+		due := &acme.AllowanceSchedule{
+			CardID:    "card1",
+			Amount:    usd("50.00"),
+			Cadence:   "@monthly",
+			Mode:      acme.AllowanceResetTo,
+			StartDate: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+			NextRunAt: now,
+		}
+		later := &acme.AllowanceSchedule{
+			CardID:    "card2",
+			Amount:    usd("20.00"),
+			Cadence:   "@daily",
+			Mode:      acme.AllowanceAddTo,
+			StartDate: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+			EndDate:   time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC),
+			NextRunAt: now.Add(time.Hour),
+		}
+		for _, s := range []*acme.AllowanceSchedule{due, later} {
+			require.NoError(t, repo.SaveAllowanceSchedule(ctx, s))
+			assert.NotZero(t, s.ID)
+			assert.False(t, s.CreatedAt.IsZero())
+		}
+
+		return repo, due, later
+	}
+
+	t.Run("schedules", func(t *testing.T) {
+		repo, due, later := newSchedules(t)
+
+		got, err := repo.GetAllowanceSchedule(ctx, later.ID)
+		require.NoError(t, err)
+		assert.Equal(t, later.Amount, got.Amount)
+		assert.Equal(t, later.StartDate, got.StartDate.UTC())
+		assert.Equal(t, later.EndDate, got.EndDate.UTC())
+
+		schedules, err := repo.FindAllowanceSchedules(ctx, "card2")
+		require.NoError(t, err)
+		require.Len(t, schedules, 1)
+		assert.Equal(t, later.ID, schedules[0].ID)
+
+		schedules, err = repo.FindAllowanceSchedules(ctx, "")
+		require.NoError(t, err)
+		require.Len(t, schedules, 2)
+		assert.Equal(t, due.ID, schedules[0].ID)
+
+		found, err := repo.FindDueAllowanceSchedules(ctx, now)
+		require.NoError(t, err)
+		require.Len(t, found, 1)
+		assert.Equal(t, due.ID, found[0].ID)
+		assert.True(t, found[0].EndDate.IsZero())
+
+		found, err = repo.FindDueAllowanceSchedules(ctx, now.Add(time.Hour))
+		require.NoError(t, err)
+		require.Len(t, found, 2)
+		assert.Equal(t, due.ID, found[0].ID)
+
+		// the ended schedules are never due
+		require.NoError(t, repo.UpdateAllowanceScheduleNextRun(ctx, due.ID, time.Time{}))
+		found, err = repo.FindDueAllowanceSchedules(ctx, now)
+		require.NoError(t, err)
+		assert.Empty(t, found)
+
+		require.NoError(t, repo.DeleteAllowanceSchedule(ctx, due.ID))
+		assert.ErrorIs(t, repo.DeleteAllowanceSchedule(ctx, due.ID), acme.ErrAllowanceScheduleNotFound)
+		_, err = repo.GetAllowanceSchedule(ctx, due.ID)
+		assert.ErrorIs(t, err, acme.ErrAllowanceScheduleNotFound)
+	})
+
+	t.Run("lock is not shared", func(t *testing.T) {
+		repo, due, later := newSchedules(t)
+
+		locked, err := repo.LockAllowanceSchedule(ctx, due.ID, func(ctx context.Context) error {
+			// another instance cannot run the schedule
+			locked, err := repo.LockAllowanceSchedule(ctx, due.ID, func(context.Context) error {
+				t.Error("fn is called while the lock is held")
+				return nil
+			})
+			require.NoError(t, err)
+			assert.False(t, locked)
+
+			// the other schedules are not locked
+			locked, err = repo.LockAllowanceSchedule(ctx, later.ID, func(context.Context) error { return nil })
+			require.NoError(t, err)
+			assert.True(t, locked)
+			return nil
+		})
+		require.NoError(t, err)
+		assert.True(t, locked)
+
+		// the lock is released after fn
+		locked, err = repo.LockAllowanceSchedule(ctx, due.ID, func(context.Context) error { return nil })
+		require.NoError(t, err)
+		assert.True(t, locked)
+	})
+
+	t.Run("runs", func(t *testing.T) {
+		repo, due, later := newSchedules(t)
+
+		run := &acme.AllowanceRun{
+			ScheduleID:            due.ID,
+			CardID:                "card1",
+			ScheduledAt:           now,
+			Mode:                  acme.AllowanceResetTo,
+			Status:                acme.AllowanceRunFailed,
+			AdjustmentType:        acme.BalanceAdjustmentTopUp,
+			Amount:                usd("30.00"),
+			AvailableCreditBefore: usd("20.00"),
+			Error:                 "reap unavailable",
+			CreatedAt:             now,
+		}
+		require.NoError(t, repo.SaveAllowanceRun(ctx, run))
+		assert.NotZero(t, run.ID)
+		assert.Equal(t, 1, run.Attempts)
+
+		// saving the run again records another attempt
+		id := run.ID
+		run.Status = acme.AllowanceRunSucceeded
+		run.AvailableCreditAfter = usd("50.00")
+		run.AdjustmentID = "adjustment1"
+		run.Error = ""
+		run.CreatedAt = now.Add(time.Minute)
+		require.NoError(t, repo.SaveAllowanceRun(ctx, run))
+		assert.Equal(t, id, run.ID)
+		assert.Equal(t, 2, run.Attempts)
+		assert.True(t, now.Equal(run.CreatedAt))
+
+		skipped := &acme.AllowanceRun{
+			ScheduleID:  later.ID,
+			CardID:      "card2",
+			ScheduledAt: now.Add(time.Hour),
+			Mode:        acme.AllowanceAddTo,
+			Status:      acme.AllowanceRunSkipped,
+			Error:       "card not found",
+			CreatedAt:   now.Add(time.Hour),
+		}
+		require.NoError(t, repo.SaveAllowanceRun(ctx, skipped))
+
+		runs, err := repo.FindAllowanceRuns(ctx, acme.AllowanceRunFilter{})
+		require.NoError(t, err)
+		require.Len(t, runs, 2)
+		assert.Equal(t, skipped.ID, runs[0].ID)
+		assert.True(t, runs[0].Amount.IsZero())
+
+		runs, err = repo.FindAllowanceRuns(ctx, acme.AllowanceRunFilter{ScheduleID: due.ID})
+		require.NoError(t, err)
+		require.Len(t, runs, 1)
+		assert.Equal(t, acme.AllowanceRunSucceeded, runs[0].Status)
+		assert.Equal(t, usd("30.00"), runs[0].Amount)
+		assert.Equal(t, usd("50.00"), runs[0].AvailableCreditAfter)
+		assert.Equal(t, "adjustment1", runs[0].AdjustmentID)
+		assert.Equal(t, 2, runs[0].Attempts)
+
+		runs, err = repo.FindAllowanceRuns(ctx, acme.AllowanceRunFilter{CardID: "card2"})
+		require.NoError(t, err)
+		require.Len(t, runs, 1)
+		assert.Equal(t, skipped.ID, runs[0].ID)
+
+		runs, err = repo.FindAllowanceRuns(ctx, acme.AllowanceRunFilter{ScheduledAt: now.Add(time.Hour)})
+		require.NoError(t, err)
+		require.Len(t, runs, 1)
+		assert.Equal(t, skipped.ID, runs[0].ID)
+
+		// updating the run saves the outcome without another attempt
+		run.Status = acme.AllowanceRunFailed
+		run.AvailableCreditAfter = acme.Money{}
+		run.AdjustmentID = ""
+		run.Error = "reap unavailable"
+		require.NoError(t, repo.UpdateAllowanceRun(ctx, *run))
+
+		runs, err = repo.FindAllowanceRuns(ctx, acme.AllowanceRunFilter{ScheduleID: due.ID, ScheduledAt: now})
+		require.NoError(t, err)
+		require.Len(t, runs, 1)
+		assert.Equal(t, acme.AllowanceRunFailed, runs[0].Status)
+		assert.Equal(t, usd("30.00"), runs[0].Amount)
+		assert.True(t, runs[0].AvailableCreditAfter.IsZero())
+		assert.Empty(t, runs[0].AdjustmentID)
+		assert.Equal(t, "reap unavailable", runs[0].Error)
+		assert.Equal(t, 2, runs[0].Attempts)
+	})
+}
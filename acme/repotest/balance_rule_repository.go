@@ -0,0 +1,162 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+)
+
+// BalanceRuleRepositoryFactory returns empty repositories sharing the same
+// storage, it is called once per test.
+type BalanceRuleRepositoryFactory func(t *testing.T) (acme.CardRepository, acme.BalanceRuleRepository)
+
+// RunBalanceRuleRepositorySuite runs the acme.BalanceRuleRepository conformance tests.
+func RunBalanceRuleRepositorySuite(t *testing.T, newRepos BalanceRuleRepositoryFactory) {
+	ctx := context.Background()
+	usd := func(amount string) acme.Money { return acme.MustParseMoney(amount, "USD") }
+
+	// newRules saves an alert rule of card1 and a top-up rule of card2
+	newRules := func(t *testing.T) (acme.BalanceRuleRepository, *acme.BalanceRule, *acme.BalanceRule) {
+		cardRepo, repo := newRepos(t)
+		require.NoError(t, cardRepo.SaveCardID(ctx, "card1", "external1"))
+		require.NoError(t, cardRepo.SaveCardID(ctx, "card2", "external2"))
+
+		alert := &acme.BalanceRule{CardID: "card1", Kind: acme.BalanceRuleAlert, Threshold: usd("20.00")}
+		topUp := &acme.BalanceRule{
+			CardID:       "card2",
+			Kind:         acme.BalanceRuleTopUp,
+			Threshold:    usd("20.00"),
+			TopUpTo:      usd("100.00"),
+			MonthlyLimit: usd("500.00"),
+		}
+		for _, rule := range []*acme.BalanceRule{alert, topUp} {
+			require.NoError(t, repo.SaveBalanceRule(ctx, rule))
+			assert.NotZero(t, rule.ID)
+			assert.False(t, rule.CreatedAt.IsZero())
+		}
+
+		return repo, alert, topUp
+	}
+
+	t.Run("rules", func(t *testing.T) {
+		repo, alert, topUp := newRules(t)
+
+		rules, err := repo.FindBalanceRules(ctx, "card2")
+		require.NoError(t, err)
+		require.Len(t, rules, 1)
+		assert.Equal(t, topUp.TopUpTo, rules[0].TopUpTo)
+		assert.Equal(t, topUp.MonthlyLimit, rules[0].MonthlyLimit)
+
+		rules, err = repo.FindBalanceRules(ctx, "")
+		require.NoError(t, err)
+		require.Len(t, rules, 2)
+		assert.Equal(t, alert.ID, rules[0].ID)
+
+		got, err := repo.GetBalanceRule(ctx, alert.ID)
+		require.NoError(t, err)
+		assert.Equal(t, usd("20.00"), got.Threshold)
+		assert.True(t, got.TopUpTo.IsZero())
+		assert.True(t, got.TriggeredAt.IsZero())
+
+		require.NoError(t, repo.DeleteBalanceRule(ctx, alert.ID))
+		assert.ErrorIs(t, repo.DeleteBalanceRule(ctx, alert.ID), acme.ErrBalanceRuleNotFound)
+		_, err = repo.GetBalanceRule(ctx, alert.ID)
+		assert.ErrorIs(t, err, acme.ErrBalanceRuleNotFound)
+	})
+
+	t.Run("claim and release", func(t *testing.T) {
+		repo, alert, _ := newRules(t)
+
+		now := time.Now().UTC().Truncate(time.Millisecond)
+		claimed, err := repo.ClaimBalanceRules(ctx, now, time.Minute)
+		require.NoError(t, err)
+		require.Len(t, claimed, 2)
+
+		// claimed rules are leased
+		leased, err := repo.ClaimBalanceRules(ctx, now, time.Minute)
+		require.NoError(t, err)
+		assert.Empty(t, leased)
+
+		// until the lease expires
+		expired, err := repo.ClaimBalanceRules(ctx, now.Add(time.Minute), time.Minute)
+		require.NoError(t, err)
+		assert.Len(t, expired, 2)
+
+		claimed[0].TriggeredAt = now
+		for _, rule := range claimed {
+			require.NoError(t, repo.ReleaseBalanceRule(ctx, rule))
+		}
+
+		got, err := repo.GetBalanceRule(ctx, alert.ID)
+		require.NoError(t, err)
+		assert.Equal(t, now, got.TriggeredAt.UTC())
+
+		// released rules are claimed again
+		claimed, err = repo.ClaimBalanceRules(ctx, now, time.Minute)
+		require.NoError(t, err)
+		assert.Len(t, claimed, 2)
+	})
+
+	t.Run("executions", func(t *testing.T) {
+		repo, alert, topUp := newRules(t)
+
+		now := time.Now().UTC().Truncate(time.Millisecond)
+		executions := []*acme.BalanceRuleExecution{
+			{
+				RuleID: topUp.ID, CardID: "card2", Kind: acme.BalanceRuleTopUp, Status: acme.BalanceRuleExecutionToppedUp,
+				AvailableCredit: usd("10.00"), Amount: usd("90.00"),
+				AdjustmentID: "adjustment1", CreatedAt: now.Add(-time.Hour),
+			},
+			{
+				RuleID: alert.ID, CardID: "card1", Kind: acme.BalanceRuleAlert, Status: acme.BalanceRuleExecutionAlerted,
+				AvailableCredit: usd("5.00"), CreatedAt: now,
+			},
+		}
+		for _, e := range executions {
+			require.NoError(t, repo.SaveBalanceRuleExecution(ctx, e))
+			assert.NotZero(t, e.ID)
+		}
+
+		found, err := repo.FindBalanceRuleExecutions(ctx, acme.BalanceRuleExecutionFilter{})
+		require.NoError(t, err)
+		require.Len(t, found, 2)
+		assert.Equal(t, alert.ID, found[0].RuleID)
+		assert.Equal(t, topUp.ID, found[1].RuleID)
+
+		found, err = repo.FindBalanceRuleExecutions(ctx, acme.BalanceRuleExecutionFilter{CardID: "card1"})
+		require.NoError(t, err)
+		require.Len(t, found, 1)
+		assert.Equal(t, usd("5.00"), found[0].AvailableCredit)
+
+		found, err = repo.FindBalanceRuleExecutions(ctx, acme.BalanceRuleExecutionFilter{RuleID: topUp.ID, Since: now.Add(-2 * time.Hour)})
+		require.NoError(t, err)
+		require.Len(t, found, 1)
+		assert.Equal(t, usd("90.00"), found[0].Amount)
+		assert.Equal(t, "adjustment1", found[0].AdjustmentID)
+
+		found, err = repo.FindBalanceRuleExecutions(ctx, acme.BalanceRuleExecutionFilter{RuleID: topUp.ID, Since: now})
+		require.NoError(t, err)
+		assert.Empty(t, found)
+
+		// the outcome of a pending top-up
+		pending := &acme.BalanceRuleExecution{
+			RuleID: topUp.ID, CardID: "card2", Kind: acme.BalanceRuleTopUp, Status: acme.BalanceRuleExecutionPending,
+			AvailableCredit: usd("15.00"), Amount: usd("85.00"), CreatedAt: now,
+		}
+		require.NoError(t, repo.SaveBalanceRuleExecution(ctx, pending))
+		pending.Status, pending.Reason = acme.BalanceRuleExecutionFailed, "reap unavailable"
+		require.NoError(t, repo.UpdateBalanceRuleExecution(ctx, *pending))
+
+		found, err = repo.FindBalanceRuleExecutions(ctx, acme.BalanceRuleExecutionFilter{RuleID: topUp.ID, Since: now})
+		require.NoError(t, err)
+		require.Len(t, found, 1)
+		assert.Equal(t, acme.BalanceRuleExecutionFailed, found[0].Status)
+		assert.Equal(t, "reap unavailable", found[0].Reason)
+		assert.Equal(t, usd("85.00"), found[0].Amount)
+	})
+}
@@ -0,0 +1,102 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+)
+
+// BurnerCardRepositoryFactory returns empty repositories sharing the same
+// storage, it is called once per test.
+type BurnerCardRepositoryFactory func(t *testing.T) (acme.CardRepository, acme.BurnerCardRepository)
+
+// RunBurnerCardRepositorySuite runs the acme.BurnerCardRepository conformance tests.
+func RunBurnerCardRepositorySuite(t *testing.T, newRepos BurnerCardRepositoryFactory) {
+	ctx := context.Background()
+
+	// newCards saves a single-use card1 and a merchant-locked card2
+	newCards := func(t *testing.T) (acme.BurnerCardRepository, *acme.BurnerCard, *acme.BurnerCard) {
+		cardRepo, repo := newRepos(t)
+		require.NoError(t, cardRepo.SaveCardID(ctx, "card1", "external1"))
+		require.NoError(t, cardRepo.SaveCardID(ctx, "card2", "external2"))
+
+		singleUse := &acme.BurnerCard{CardID: "card1", Mode: acme.CardModeSingleUse}
+		merchantLocked := &acme.BurnerCard{CardID: "card2", Mode: acme.CardModeMerchantLocked, MerchantID: "m1"}
+		for _, c := range []*acme.BurnerCard{singleUse, merchantLocked} {
+			require.NoError(t, repo.SaveBurnerCard(ctx, c))
+			assert.False(t, c.CreatedAt.IsZero())
+		}
+
+		return repo, singleUse, merchantLocked
+	}
+
+	t.Run("save keeps the saved card", func(t *testing.T) {
+		repo, singleUse, _ := newCards(t)
+
+		again := &acme.BurnerCard{CardID: "card1", Mode: acme.CardModeMerchantLocked, MerchantID: "m2"}
+		require.NoError(t, repo.SaveBurnerCard(ctx, again))
+		assert.True(t, singleUse.CreatedAt.Equal(again.CreatedAt))
+
+		cards, err := repo.FindBurnerCards(ctx, false)
+		require.NoError(t, err)
+		require.Len(t, cards, 2)
+		for _, c := range cards {
+			if c.CardID == "card1" {
+				assert.Equal(t, acme.CardModeSingleUse, c.Mode)
+				assert.Empty(t, c.MerchantID)
+			}
+		}
+	})
+
+	t.Run("update and find", func(t *testing.T) {
+		repo, singleUse, merchantLocked := newCards(t)
+
+		cards, err := repo.FindBurnerCards(ctx, true)
+		require.NoError(t, err)
+		require.Len(t, cards, 2)
+
+		now := time.Now().UTC().Truncate(time.Millisecond)
+		singleUse.EndedAt = now
+		singleUse.EndReason = "transaction tx1 settled"
+		require.NoError(t, repo.UpdateBurnerCard(ctx, *singleUse))
+
+		merchantLocked.FrozenAt = now
+		merchantLocked.CheckedAt = now
+		require.NoError(t, repo.UpdateBurnerCard(ctx, *merchantLocked))
+
+		// the ended cards are no longer active
+		cards, err = repo.FindBurnerCards(ctx, true)
+		require.NoError(t, err)
+		require.Len(t, cards, 1)
+		assert.Equal(t, "card2", cards[0].CardID)
+		assert.Equal(t, acme.CardModeMerchantLocked, cards[0].Mode)
+		assert.Equal(t, "m1", cards[0].MerchantID)
+		assert.True(t, now.Equal(cards[0].FrozenAt))
+		assert.True(t, now.Equal(cards[0].CheckedAt))
+		assert.False(t, cards[0].IsEnded())
+
+		cards, err = repo.FindBurnerCards(ctx, false)
+		require.NoError(t, err)
+		require.Len(t, cards, 2)
+
+		i := 0
+		if cards[0].CardID != "card1" {
+			i = 1
+		}
+		assert.True(t, cards[i].IsEnded())
+		assert.True(t, now.Equal(cards[i].EndedAt))
+		assert.Equal(t, "transaction tx1 settled", cards[i].EndReason)
+	})
+
+	t.Run("update not found", func(t *testing.T) {
+		repo, _, _ := newCards(t)
+
+		err := repo.UpdateBurnerCard(ctx, acme.BurnerCard{CardID: "card3"})
+		assert.ErrorIs(t, err, acme.ErrCardNotFound)
+	})
+}
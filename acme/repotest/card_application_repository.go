@@ -0,0 +1,154 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+)
+
+// CardApplicationRepositoryFactory returns an empty repository, it is called
+// once per test.
+type CardApplicationRepositoryFactory func(t *testing.T) acme.CardApplicationRepository
+
+// RunCardApplicationRepositorySuite runs the acme.CardApplicationRepository
+// conformance tests.
+func RunCardApplicationRepositorySuite(t *testing.T, newRepo CardApplicationRepositoryFactory) {
+	ctx := context.Background()
+
+	validUntil := time.Now().UTC().Add(30 * 24 * time.Hour).Truncate(time.Second)
+	expiresAt := time.Now().UTC().Add(7 * 24 * time.Hour).Truncate(time.Millisecond)
+	params := acme.CreateCardParams{
+		FirstName:    "Jane",
+		LastName:     "Doe",
+		DOB:          "1990-01-01",
+		Address:      acme.Address{Line1: "1 Main St", City: "Manila", CountryCode: "PH"},
+		ContactInfo:  acme.ContactInfo{Email: "jane@example.com", DialCode: 63, PhoneNumber: "9171234567"},
+		IDDocument:   acme.IDDocument{Type: "Passport", Number: "P1234567"},
+		Mode:         acme.CardModeStandard,
+		ValidUntil:   validUntil,
+		ExpiryAction: acme.CardStatusFrozen,
+	}
+
+	// newApps saves the pending applications of alice and bob
+	newApps := func(t *testing.T) (acme.CardApplicationRepository, *acme.CardApplication, *acme.CardApplication) {
+		repo := newRepo(t)
+
+		app1 := &acme.CardApplication{Params: params, Maker: "alice", Status: acme.CardApplicationPending, ExpiresAt: expiresAt}
+		app2 := &acme.CardApplication{Params: params, Maker: "bob", Status: acme.CardApplicationPending, ExpiresAt: expiresAt}
+		for _, app := range []*acme.CardApplication{app1, app2} {
+			require.NoError(t, repo.SaveCardApplication(ctx, app))
+			assert.NotZero(t, app.ID)
+			assert.False(t, app.CreatedAt.IsZero())
+		}
+
+		return repo, app1, app2
+	}
+
+	t.Run("save and get", func(t *testing.T) {
+		repo, app1, _ := newApps(t)
+
+		got, err := repo.GetCardApplication(ctx, app1.ID)
+		require.NoError(t, err)
+		assert.Equal(t, params, got.Params)
+		assert.Equal(t, "alice", got.Maker)
+		assert.True(t, expiresAt.Equal(got.ExpiresAt))
+
+		_, err = repo.GetCardApplication(ctx, -1)
+		assert.ErrorIs(t, err, acme.ErrCardApplicationNotFound)
+	})
+
+	t.Run("update from the status", func(t *testing.T) {
+		repo, app1, app2 := newApps(t)
+
+		app1.Status, app1.Checker, app1.RejectionReason = acme.CardApplicationRejected, "carol", "id document expired"
+		require.NoError(t, repo.UpdateCardApplication(ctx, app1, acme.CardApplicationPending))
+		assert.False(t, app1.UpdatedAt.Before(app1.CreatedAt))
+
+		// the status only changes from the expected status
+		err := repo.UpdateCardApplication(ctx, app1, acme.CardApplicationPending)
+		assert.ErrorIs(t, err, acme.ErrCardApplicationStatus)
+
+		app2.Status, app2.Checker, app2.CardID = acme.CardApplicationApproved, "carol", "card2"
+		require.NoError(t, repo.UpdateCardApplication(ctx, app2, acme.CardApplicationPending))
+
+		got, err := repo.GetCardApplication(ctx, app2.ID)
+		require.NoError(t, err)
+		assert.Equal(t, acme.CardApplicationApproved, got.Status)
+		assert.Equal(t, "carol", got.Checker)
+		assert.Equal(t, "card2", got.CardID)
+
+		apps, err := repo.FindCardApplications(ctx, acme.CardApplicationFilter{Status: acme.CardApplicationApproved})
+		require.NoError(t, err)
+		require.Len(t, apps, 1)
+		assert.Equal(t, app2.ID, apps[0].ID)
+
+		apps, err = repo.FindCardApplications(ctx, acme.CardApplicationFilter{Maker: "alice"})
+		require.NoError(t, err)
+		require.Len(t, apps, 1)
+		assert.Equal(t, "id document expired", apps[0].RejectionReason)
+
+		// latest first
+		apps, err = repo.FindCardApplications(ctx, acme.CardApplicationFilter{})
+		require.NoError(t, err)
+		require.Len(t, apps, 2)
+		assert.Equal(t, app2.ID, apps[0].ID)
+	})
+
+	t.Run("lock is not shared", func(t *testing.T) {
+		repo, app1, app2 := newApps(t)
+
+		locked, err := repo.LockCardApplication(ctx, app1.ID, func(ctx context.Context) error {
+			// another instance cannot issue the card of the application
+			locked, err := repo.LockCardApplication(ctx, app1.ID, func(context.Context) error {
+				t.Error("fn is called while the lock is held")
+				return nil
+			})
+			require.NoError(t, err)
+			assert.False(t, locked)
+
+			// the other applications are not locked
+			locked, err = repo.LockCardApplication(ctx, app2.ID, func(context.Context) error { return nil })
+			require.NoError(t, err)
+			assert.True(t, locked)
+			return nil
+		})
+		require.NoError(t, err)
+		assert.True(t, locked)
+
+		// the lock is released after fn
+		locked, err = repo.LockCardApplication(ctx, app1.ID, func(context.Context) error { return nil })
+		require.NoError(t, err)
+		assert.True(t, locked)
+	})
+
+	t.Run("events", func(t *testing.T) {
+		repo, app1, app2 := newApps(t)
+
+		events := []*acme.CardApplicationEvent{
+			{ApplicationID: app1.ID, Action: acme.CardApplicationSubmittedAction, Actor: "alice"},
+			{ApplicationID: app2.ID, Action: acme.CardApplicationSubmittedAction, Actor: "bob"},
+			{ApplicationID: app1.ID, Action: acme.CardApplicationRejectedAction, Actor: "carol", Reason: "id document expired"},
+		}
+		for _, e := range events {
+			require.NoError(t, repo.SaveCardApplicationEvent(ctx, e))
+			assert.NotZero(t, e.ID)
+			assert.False(t, e.CreatedAt.IsZero())
+		}
+
+		found, err := repo.FindCardApplicationEvents(ctx, app1.ID)
+		require.NoError(t, err)
+		require.Len(t, found, 2)
+		assert.Equal(t, acme.CardApplicationSubmittedAction, found[0].Action)
+		assert.Equal(t, "carol", found[1].Actor)
+		assert.Equal(t, "id document expired", found[1].Reason)
+
+		found, err = repo.FindCardApplicationEvents(ctx, -1)
+		require.NoError(t, err)
+		assert.Empty(t, found)
+	})
+}
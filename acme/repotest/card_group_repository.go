@@ -0,0 +1,161 @@
+package repotest
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+)
+
+// CardGroupRepositoryFactory returns empty repositories sharing the same
+// storage, it is called once per test.
+type CardGroupRepositoryFactory func(t *testing.T) (acme.CardRepository, acme.CardGroupRepository)
+
+// RunCardGroupRepositorySuite runs the acme.CardGroupRepository conformance tests.
+func RunCardGroupRepositorySuite(t *testing.T, newRepos CardGroupRepositoryFactory) {
+	ctx := context.Background()
+
+	// newGroups saves card1 and card2 with the Marketing and Sales groups
+	newGroups := func(t *testing.T) (acme.CardGroupRepository, *acme.CardGroup, *acme.CardGroup) {
+		cardRepo, repo := newRepos(t)
+		require.NoError(t, cardRepo.SaveCardID(ctx, "card1", "external1"))
+		require.NoError(t, cardRepo.SaveCardID(ctx, "card2", "external2"))
+
+		// saved out of name order
+		sales := &acme.CardGroup{Name: "Sales", Budget: acme.MustParseMoney("1000.00", "USD"), Period: acme.CardGroupPeriodWeek}
+		marketing := &acme.CardGroup{Name: "Marketing", Budget: acme.MustParseMoney("5000.00", "USD"), Period: acme.CardGroupPeriodMonth}
+		for _, group := range []*acme.CardGroup{sales, marketing} {
+			require.NoError(t, repo.SaveCardGroup(ctx, group))
+			assert.NotZero(t, group.ID)
+			assert.False(t, group.CreatedAt.IsZero())
+		}
+
+		return repo, marketing, sales
+	}
+
+	t.Run("save and get", func(t *testing.T) {
+		repo, _, sales := newGroups(t)
+
+		sales.Budget, sales.Period = acme.MustParseMoney("12000.00", "USD"), acme.CardGroupPeriodQuarter
+		require.NoError(t, repo.SaveCardGroup(ctx, sales))
+
+		got, err := repo.GetCardGroup(ctx, sales.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Sales", got.Name)
+		assert.Equal(t, "12000.00", got.Budget.Decimal())
+		assert.Equal(t, "USD", got.Budget.Currency())
+		assert.Equal(t, acme.CardGroupPeriodQuarter, got.Period)
+	})
+
+	t.Run("group not found", func(t *testing.T) {
+		repo, _, _ := newGroups(t)
+
+		_, err := repo.GetCardGroup(ctx, -1)
+		assert.ErrorIs(t, err, acme.ErrCardGroupNotFound)
+
+		err = repo.SaveCardGroup(ctx, &acme.CardGroup{ID: -1, Name: "Finance", Budget: acme.MustParseMoney("1.00", "USD"), Period: acme.CardGroupPeriodMonth})
+		assert.ErrorIs(t, err, acme.ErrCardGroupNotFound)
+
+		err = repo.DeleteCardGroup(ctx, -1)
+		assert.ErrorIs(t, err, acme.ErrCardGroupNotFound)
+	})
+
+	t.Run("find groups by name", func(t *testing.T) {
+		repo, _, _ := newGroups(t)
+
+		groups, err := repo.FindCardGroups(ctx)
+		require.NoError(t, err)
+		require.Len(t, groups, 2)
+		assert.Equal(t, "Marketing", groups[0].Name)
+		assert.Equal(t, "Sales", groups[1].Name)
+	})
+
+	t.Run("members", func(t *testing.T) {
+		repo, marketing, sales := newGroups(t)
+
+		cardIDs, err := repo.FindCardGroupMembers(ctx, marketing.ID)
+		require.NoError(t, err)
+		assert.Empty(t, cardIDs)
+
+		require.NoError(t, repo.AddCardGroupMember(ctx, marketing.ID, "card1"))
+		require.NoError(t, repo.AddCardGroupMember(ctx, marketing.ID, "card2"))
+
+		// a card is in at most one group
+		err = repo.AddCardGroupMember(ctx, sales.ID, "card1")
+		assert.ErrorIs(t, err, acme.ErrCardGroupMemberExists)
+
+		cardIDs, err = repo.FindCardGroupMembers(ctx, marketing.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"card1", "card2"}, cardIDs)
+
+		got, err := repo.GetCardGroupByCard(ctx, "card2")
+		require.NoError(t, err)
+		assert.Equal(t, marketing.ID, got.ID)
+
+		err = repo.RemoveCardGroupMember(ctx, sales.ID, "card2")
+		assert.ErrorIs(t, err, acme.ErrCardGroupMemberNotFound)
+
+		require.NoError(t, repo.RemoveCardGroupMember(ctx, marketing.ID, "card2"))
+		err = repo.RemoveCardGroupMember(ctx, marketing.ID, "card2")
+		assert.ErrorIs(t, err, acme.ErrCardGroupMemberNotFound)
+
+		_, err = repo.GetCardGroupByCard(ctx, "card2")
+		assert.ErrorIs(t, err, acme.ErrCardGroupNotFound)
+
+		// the card can join another group once removed
+		require.NoError(t, repo.AddCardGroupMember(ctx, sales.ID, "card2"))
+	})
+
+	t.Run("delete deletes the members", func(t *testing.T) {
+		repo, marketing, _ := newGroups(t)
+
+		require.NoError(t, repo.AddCardGroupMember(ctx, marketing.ID, "card1"))
+		require.NoError(t, repo.DeleteCardGroup(ctx, marketing.ID))
+
+		_, err := repo.GetCardGroupByCard(ctx, "card1")
+		assert.ErrorIs(t, err, acme.ErrCardGroupNotFound)
+
+		err = repo.DeleteCardGroup(ctx, marketing.ID)
+		assert.ErrorIs(t, err, acme.ErrCardGroupNotFound)
+	})
+
+	t.Run("lock runs one at a time", func(t *testing.T) {
+		repo, marketing, _ := newGroups(t)
+
+		var (
+			wg      sync.WaitGroup
+			mu      sync.Mutex
+			running int
+			ran     int
+		)
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				err := repo.LockCardGroup(ctx, marketing.ID, func(context.Context) error {
+					mu.Lock()
+					running++
+					assert.Equal(t, 1, running)
+					mu.Unlock()
+
+					time.Sleep(time.Millisecond)
+
+					mu.Lock()
+					running--
+					ran++
+					mu.Unlock()
+					return nil
+				})
+				assert.NoError(t, err)
+			}()
+		}
+		wg.Wait()
+
+		assert.Equal(t, 5, ran)
+	})
+}
@@ -0,0 +1,234 @@
+// Package repotest provides conformance test suites that every
+// repository implementation is expected to pass.
+package repotest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+)
+
+// CardRepositoryFactory returns an empty repository, it is called once per test.
+type CardRepositoryFactory func(t *testing.T) acme.CardRepository
+
+// RunCardRepositorySuite runs the acme.CardRepository conformance tests.
+func RunCardRepositorySuite(t *testing.T, newRepo CardRepositoryFactory) {
+	ctx := context.Background()
+
+	t.Run("save and get external id", func(t *testing.T) {
+		repo := newRepo(t)
+
+		err := repo.SaveCardID(ctx, "card1", "external1")
+		require.NoError(t, err)
+
+		externalID, err := repo.GetExternalID(ctx, "card1")
+		require.NoError(t, err)
+		assert.Equal(t, "external1", externalID)
+	})
+
+	t.Run("get external id not found", func(t *testing.T) {
+		repo := newRepo(t)
+
+		_, err := repo.GetExternalID(ctx, "unknown")
+		assert.ErrorIs(t, err, acme.ErrCardNotFound)
+	})
+
+	t.Run("save duplicate card id", func(t *testing.T) {
+		repo := newRepo(t)
+
+		err := repo.SaveCardID(ctx, "card1", "external1")
+		require.NoError(t, err)
+
+		err = repo.SaveCardID(ctx, "card1", "external2")
+		assert.ErrorIs(t, err, acme.ErrCardExists)
+
+		// the original mapping is kept
+		externalID, err := repo.GetExternalID(ctx, "card1")
+		require.NoError(t, err)
+		assert.Equal(t, "external1", externalID)
+	})
+
+	t.Run("find card ids empty", func(t *testing.T) {
+		repo := newRepo(t)
+
+		cardIDs, err := repo.FindCardIDs(ctx)
+		require.NoError(t, err)
+		assert.NotNil(t, cardIDs)
+		assert.Empty(t, cardIDs)
+	})
+
+	t.Run("find card ids most recent first", func(t *testing.T) {
+		repo := newRepo(t)
+
+		for _, cardID := range []string{"card1", "card2", "card3"} {
+			err := repo.SaveCardID(ctx, cardID, "external-"+cardID)
+			require.NoError(t, err)
+		}
+
+		cardIDs, err := repo.FindCardIDs(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"card3", "card2", "card1"}, cardIDs)
+	})
+
+	t.Run("concurrent saves", func(t *testing.T) {
+		repo := newRepo(t)
+
+		const n = 20
+		var wg sync.WaitGroup
+		errs := make(chan error, n)
+		for i := range n {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs <- repo.SaveCardID(ctx, fmt.Sprintf("card%d", i), fmt.Sprintf("external%d", i))
+			}()
+		}
+		wg.Wait()
+		close(errs)
+
+		for err := range errs {
+			require.NoError(t, err)
+		}
+
+		cardIDs, err := repo.FindCardIDs(ctx)
+		require.NoError(t, err)
+		assert.Len(t, cardIDs, n)
+	})
+
+	t.Run("get external id mapping", func(t *testing.T) {
+		repo := newRepo(t)
+
+		for _, cardID := range []string{"card1", "card2", "card3"} {
+			err := repo.SaveCardID(ctx, cardID, "external-"+cardID)
+			require.NoError(t, err)
+		}
+
+		mapping, err := repo.GetExternalIDMapping(ctx, "external-card1", "external-card3", "unknown")
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{
+			"external-card1": "card1",
+			"external-card3": "card3",
+		}, mapping)
+	})
+
+	t.Run("get external id mapping empty input", func(t *testing.T) {
+		repo := newRepo(t)
+
+		err := repo.SaveCardID(ctx, "card1", "external1")
+		require.NoError(t, err)
+
+		mapping, err := repo.GetExternalIDMapping(ctx)
+		require.NoError(t, err)
+		assert.NotNil(t, mapping)
+		assert.Empty(t, mapping)
+	})
+
+	t.Run("get external id mapping large input", func(t *testing.T) {
+		repo := newRepo(t)
+
+		const saved = 50
+		for i := range saved {
+			err := repo.SaveCardID(ctx, fmt.Sprintf("card%d", i), fmt.Sprintf("external%d", i))
+			require.NoError(t, err)
+		}
+
+		// exceeds the postgres bind parameters limit
+		externalIDs := make([]string, 0, 70000)
+		for i := range cap(externalIDs) {
+			externalIDs = append(externalIDs, fmt.Sprintf("external%d", i))
+		}
+
+		mapping, err := repo.GetExternalIDMapping(ctx, externalIDs...)
+		require.NoError(t, err)
+		require.Len(t, mapping, saved)
+		for i := range saved {
+			assert.Equal(t, fmt.Sprintf("card%d", i), mapping[fmt.Sprintf("external%d", i)])
+		}
+	})
+
+	t.Run("save and get card expiry", func(t *testing.T) {
+		repo := newRepo(t)
+
+		err := repo.SaveCardID(ctx, "card1", "external1")
+		require.NoError(t, err)
+
+		// the cards have no expiry by default
+		expiry, err := repo.GetCardExpiry(ctx, "card1")
+		require.NoError(t, err)
+		assert.Equal(t, "card1", expiry.CardID)
+		assert.True(t, expiry.ValidUntil.IsZero())
+
+		validUntil := time.Date(2030, 6, 30, 17, 0, 0, 0, time.UTC)
+		notifiedAt := time.Date(2030, 6, 23, 17, 0, 0, 0, time.UTC)
+		err = repo.SaveCardExpiry(ctx, acme.CardExpiry{
+			CardID:     "card1",
+			ValidUntil: validUntil,
+			Action:     acme.CardStatusTerminated,
+			NotifiedAt: notifiedAt,
+		})
+		require.NoError(t, err)
+
+		expiry, err = repo.GetCardExpiry(ctx, "card1")
+		require.NoError(t, err)
+		assert.True(t, validUntil.Equal(expiry.ValidUntil))
+		assert.Equal(t, acme.CardStatusTerminated, expiry.Action)
+		assert.True(t, notifiedAt.Equal(expiry.NotifiedAt))
+		assert.True(t, expiry.ExpiredAt.IsZero())
+
+		// a zero valid until clears the expiry
+		err = repo.SaveCardExpiry(ctx, acme.CardExpiry{CardID: "card1"})
+		require.NoError(t, err)
+
+		expiry, err = repo.GetCardExpiry(ctx, "card1")
+		require.NoError(t, err)
+		assert.True(t, expiry.ValidUntil.IsZero())
+	})
+
+	t.Run("card expiry not found", func(t *testing.T) {
+		repo := newRepo(t)
+
+		err := repo.SaveCardExpiry(ctx, acme.CardExpiry{CardID: "unknown", ValidUntil: time.Now()})
+		assert.ErrorIs(t, err, acme.ErrCardNotFound)
+
+		_, err = repo.GetCardExpiry(ctx, "unknown")
+		assert.ErrorIs(t, err, acme.ErrCardNotFound)
+	})
+
+	t.Run("find card expiries earliest first", func(t *testing.T) {
+		repo := newRepo(t)
+
+		for _, cardID := range []string{"card1", "card2", "card3"} {
+			err := repo.SaveCardID(ctx, cardID, "external-"+cardID)
+			require.NoError(t, err)
+		}
+
+		expiries, err := repo.FindCardExpiries(ctx)
+		require.NoError(t, err)
+		assert.NotNil(t, expiries)
+		assert.Empty(t, expiries)
+
+		validUntil := time.Date(2030, 6, 30, 17, 0, 0, 0, time.UTC)
+		for cardID, days := range map[string]int{"card1": 2, "card3": 1} {
+			err = repo.SaveCardExpiry(ctx, acme.CardExpiry{
+				CardID:     cardID,
+				ValidUntil: validUntil.AddDate(0, 0, days),
+				Action:     acme.CardStatusFrozen,
+			})
+			require.NoError(t, err)
+		}
+
+		expiries, err = repo.FindCardExpiries(ctx)
+		require.NoError(t, err)
+		require.Len(t, expiries, 2)
+		assert.Equal(t, "card3", expiries[0].CardID)
+		assert.Equal(t, "card1", expiries[1].CardID)
+		assert.Equal(t, acme.CardStatusFrozen, expiries[1].Action)
+	})
+}
@@ -0,0 +1,131 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+)
+
+// CardSnapshotRepositoryFactory returns empty repositories sharing the
+// same storage, it is called once per test.
+type CardSnapshotRepositoryFactory func(t *testing.T) (acme.CardRepository, acme.CardSnapshotRepository)
+
+// RunCardSnapshotRepositorySuite runs the acme.CardSnapshotRepository conformance tests.
+func RunCardSnapshotRepositorySuite(t *testing.T, newRepos CardSnapshotRepositoryFactory) {
+	ctx := context.Background()
+
+	newSnapshot := func(cardID string, updatedAt time.Time) acme.CardSnapshot {
+		return acme.CardSnapshot{
+			Card: acme.Card{
+				ID:              cardID,
+				Name:            "Chin Zeng",
+				Last4:           "2112",
+				Status:          acme.CardStatusActive,
+				AvailableCredit: acme.MustParseMoney("5000.00", "USD"),
+				SpendUsage: acme.SpendUsage{
+					Daily:   acme.MustParseMoney("1.00", "USD"),
+					Weekly:  acme.MustParseMoney("2.00", "USD"),
+					Monthly: acme.MustParseMoney("3.00", "USD"),
+					Yearly:  acme.MustParseMoney("4.00", "USD"),
+					AllTime: acme.MustParseMoney("5.00", "USD"),
+				},
+				ContactInfo: acme.ContactInfo{
+					Email:       "chinzeng@myspace.xyz",
+					DialCode:    852,
+					PhoneNumber: "31070474",
+				},
+			},
+			UpdatedAt: updatedAt,
+		}
+	}
+
+	t.Run("save and find", func(t *testing.T) {
+		cardRepo, snapshotRepo := newRepos(t)
+
+		snapshots, err := snapshotRepo.FindCardSnapshots(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, snapshots)
+
+		now := time.Now().UTC().Truncate(time.Millisecond)
+		for _, cardID := range []string{"card1", "card2"} {
+			require.NoError(t, cardRepo.SaveCardID(ctx, cardID, "external-"+cardID))
+		}
+
+		err = snapshotRepo.SaveCardSnapshots(ctx, newSnapshot("card1", now), newSnapshot("card2", now))
+		require.NoError(t, err)
+
+		snapshots, err = snapshotRepo.FindCardSnapshots(ctx)
+		require.NoError(t, err)
+		require.Len(t, snapshots, 2)
+
+		// most recently created cards first
+		assert.Equal(t, "card2", snapshots[0].ID)
+		assert.Equal(t, "card1", snapshots[1].ID)
+		assert.Equal(t, newSnapshot("card1", now).Card, snapshots[1].Card)
+		assert.True(t, now.Equal(snapshots[1].UpdatedAt), "expecting %s, got %s", now, snapshots[1].UpdatedAt)
+	})
+
+	t.Run("save replaces existing snapshot", func(t *testing.T) {
+		cardRepo, snapshotRepo := newRepos(t)
+		require.NoError(t, cardRepo.SaveCardID(ctx, "card1", "external1"))
+
+		now := time.Now().UTC()
+		require.NoError(t, snapshotRepo.SaveCardSnapshots(ctx, newSnapshot("card1", now)))
+
+		updated := newSnapshot("card1", now.Add(time.Minute))
+		updated.Status = acme.CardStatusFrozen
+		updated.AvailableCredit = acme.MustParseMoney("10.00", "USD")
+		require.NoError(t, snapshotRepo.SaveCardSnapshots(ctx, updated))
+
+		snapshots, err := snapshotRepo.FindCardSnapshots(ctx)
+		require.NoError(t, err)
+		require.Len(t, snapshots, 1)
+		assert.Equal(t, acme.CardStatusFrozen, snapshots[0].Status)
+		assert.Equal(t, acme.MustParseMoney("10.00", "USD"), snapshots[0].AvailableCredit)
+	})
+
+	t.Run("delete snapshots before", func(t *testing.T) {
+		cardRepo, snapshotRepo := newRepos(t)
+		for _, cardID := range []string{"card1", "card2"} {
+			require.NoError(t, cardRepo.SaveCardID(ctx, cardID, "external-"+cardID))
+		}
+
+		syncStart := time.Now().UTC()
+		err := snapshotRepo.SaveCardSnapshots(ctx,
+			newSnapshot("card1", syncStart.Add(-time.Hour)),
+			newSnapshot("card2", syncStart.Add(time.Second)),
+		)
+		require.NoError(t, err)
+
+		err = snapshotRepo.DeleteCardSnapshotsBefore(ctx, syncStart)
+		require.NoError(t, err)
+
+		snapshots, err := snapshotRepo.FindCardSnapshots(ctx)
+		require.NoError(t, err)
+		require.Len(t, snapshots, 1)
+		assert.Equal(t, "card2", snapshots[0].ID)
+	})
+	t.Run("delete snapshot", func(t *testing.T) {
+		cardRepo, snapshotRepo := newRepos(t)
+		for _, cardID := range []string{"card1", "card2"} {
+			require.NoError(t, cardRepo.SaveCardID(ctx, cardID, "external-"+cardID))
+		}
+
+		now := time.Now().UTC()
+		require.NoError(t, snapshotRepo.SaveCardSnapshots(ctx, newSnapshot("card1", now), newSnapshot("card2", now)))
+
+		require.NoError(t, snapshotRepo.DeleteCardSnapshot(ctx, "card1"))
+		// deleting a missing snapshot is not an error
+		require.NoError(t, snapshotRepo.DeleteCardSnapshot(ctx, "card1"))
+
+		snapshots, err := snapshotRepo.FindCardSnapshots(ctx)
+		require.NoError(t, err)
+		require.Len(t, snapshots, 1)
+		assert.Equal(t, "card2", snapshots[0].ID)
+	})
+}
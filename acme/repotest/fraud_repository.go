@@ -0,0 +1,174 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+)
+
+// FraudRepositoryFactory returns an empty repository, it is called once per test.
+type FraudRepositoryFactory func(t *testing.T) acme.FraudRepository
+
+// RunFraudRepositorySuite runs the acme.FraudRepository conformance tests.
+func RunFraudRepositorySuite(t *testing.T, newRepo FraudRepositoryFactory) {
+	ctx := context.Background()
+
+	newRule := func(t *testing.T, repo acme.FraudRepository) *acme.FraudRule {
+		rule := &acme.FraudRule{
+			Name:    "velocity",
+			Type:    acme.FraudRuleVelocity,
+			Params:  acme.FraudRuleParams{MaxCount: 5, Window: time.Hour},
+			Action:  acme.FraudActionFreeze,
+			DryRun:  true,
+			Enabled: true,
+		}
+		require.NoError(t, repo.SaveFraudRule(ctx, rule))
+		require.NotZero(t, rule.ID)
+		assert.False(t, rule.CreatedAt.IsZero())
+
+		return rule
+	}
+
+	t.Run("rules", func(t *testing.T) {
+		repo := newRepo(t)
+		rule := newRule(t, repo)
+
+		rule.DryRun = false
+		require.NoError(t, repo.SaveFraudRule(ctx, rule))
+
+		got, err := repo.GetFraudRule(ctx, rule.ID)
+		require.NoError(t, err)
+		assert.Equal(t, rule.Params, got.Params)
+		assert.False(t, got.DryRun)
+
+		rules, err := repo.FindFraudRules(ctx)
+		require.NoError(t, err)
+		assert.Len(t, rules, 1)
+
+		require.NoError(t, repo.DeleteFraudRule(ctx, rule.ID))
+		_, err = repo.GetFraudRule(ctx, rule.ID)
+		assert.ErrorIs(t, err, acme.ErrFraudRuleNotFound)
+		assert.ErrorIs(t, repo.DeleteFraudRule(ctx, rule.ID), acme.ErrFraudRuleNotFound)
+		assert.ErrorIs(t, repo.SaveFraudRule(ctx, rule), acme.ErrFraudRuleNotFound)
+	})
+
+	t.Run("decisions", func(t *testing.T) {
+		repo := newRepo(t)
+		rule := newRule(t, repo)
+
+		exists, err := repo.FraudDecisionExists(ctx, rule.ID, "tx1")
+		require.NoError(t, err)
+		assert.False(t, exists)
+
+		now := time.Now().UTC().Truncate(time.Millisecond)
+		d := &acme.FraudDecision{
+			RuleID:        rule.ID,
+			RuleName:      rule.Name,
+			TransactionID: "tx1",
+			CardID:        "card1",
+			Action:        rule.Action,
+			Reason:        "6 transactions within 1h0m0s, more than 5",
+			CreatedAt:     now.Add(-time.Minute),
+		}
+		require.NoError(t, repo.SaveFraudDecision(ctx, d))
+		assert.NotZero(t, d.ID)
+		assert.ErrorIs(t, repo.SaveFraudDecision(ctx, d), acme.ErrFraudDecisionExists)
+
+		exists, err = repo.FraudDecisionExists(ctx, rule.ID, "tx1")
+		require.NoError(t, err)
+		assert.True(t, exists)
+
+		later := &acme.FraudDecision{
+			RuleID:        rule.ID,
+			RuleName:      rule.Name,
+			TransactionID: "tx2",
+			CardID:        "card2",
+			Action:        acme.FraudActionFlag,
+			CreatedAt:     now,
+		}
+		require.NoError(t, repo.SaveFraudDecision(ctx, later))
+
+		decisions, err := repo.FindFraudDecisions(ctx, acme.FraudDecisionFilter{})
+		require.NoError(t, err)
+		require.Len(t, decisions, 2)
+		assert.Equal(t, "tx2", decisions[0].TransactionID)
+		assert.Equal(t, "tx1", decisions[1].TransactionID)
+
+		for _, filter := range []acme.FraudDecisionFilter{
+			{CardID: "card1"},
+			{TransactionID: "tx1"},
+			{Action: acme.FraudActionFreeze},
+		} {
+			decisions, err = repo.FindFraudDecisions(ctx, filter)
+			require.NoError(t, err)
+			require.Len(t, decisions, 1)
+			assert.Equal(t, d.ID, decisions[0].ID)
+		}
+
+		decisions, err = repo.FindFraudDecisions(ctx, acme.FraudDecisionFilter{CardID: "card3"})
+		require.NoError(t, err)
+		assert.Empty(t, decisions)
+	})
+
+	t.Run("flags", func(t *testing.T) {
+		repo := newRepo(t)
+		rule := newRule(t, repo)
+
+		// the first flag of a transaction is kept
+		flag := &acme.TransactionFlag{TransactionID: "tx1", CardID: "card1", RuleID: rule.ID, Reason: "velocity"}
+		require.NoError(t, repo.SaveTransactionFlag(ctx, flag))
+		assert.False(t, flag.CreatedAt.IsZero())
+		require.NoError(t, repo.SaveTransactionFlag(ctx, &acme.TransactionFlag{TransactionID: "tx1", CardID: "card1", RuleID: 42, Reason: "other"}))
+
+		flags, err := repo.FindTransactionFlags(ctx, acme.TransactionFlagFilter{CardID: "card1", Open: true})
+		require.NoError(t, err)
+		require.Len(t, flags, 1)
+		assert.Equal(t, rule.ID, flags[0].RuleID)
+		assert.Equal(t, "velocity", flags[0].Reason)
+		assert.True(t, flags[0].ClearedAt.IsZero())
+
+		flags, err = repo.FindTransactionFlags(ctx, acme.TransactionFlagFilter{CardID: "card2"})
+		require.NoError(t, err)
+		assert.Empty(t, flags)
+
+		require.NoError(t, repo.ClearTransactionFlag(ctx, "tx1"))
+		assert.ErrorIs(t, repo.ClearTransactionFlag(ctx, "tx1"), acme.ErrTransactionFlagNotFound)
+		assert.ErrorIs(t, repo.ClearTransactionFlag(ctx, "tx2"), acme.ErrTransactionFlagNotFound)
+
+		flags, err = repo.FindTransactionFlags(ctx, acme.TransactionFlagFilter{Open: true})
+		require.NoError(t, err)
+		assert.Empty(t, flags)
+
+		flags, err = repo.FindTransactionFlags(ctx, acme.TransactionFlagFilter{})
+		require.NoError(t, err)
+		require.Len(t, flags, 1)
+		assert.False(t, flags[0].ClearedAt.IsZero())
+	})
+
+	t.Run("lock is not shared", func(t *testing.T) {
+		repo := newRepo(t)
+
+		locked, err := repo.LockFraudEvaluation(ctx, func(ctx context.Context) error {
+			// another evaluation cannot take the lock
+			locked, err := repo.LockFraudEvaluation(ctx, func(context.Context) error {
+				t.Error("fn is called while the lock is held")
+				return nil
+			})
+			require.NoError(t, err)
+			assert.False(t, locked)
+			return nil
+		})
+		require.NoError(t, err)
+		assert.True(t, locked)
+
+		// the lock is released after fn
+		locked, err = repo.LockFraudEvaluation(ctx, func(context.Context) error { return nil })
+		require.NoError(t, err)
+		assert.True(t, locked)
+	})
+}
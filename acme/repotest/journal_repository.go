@@ -0,0 +1,108 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+)
+
+// JournalRepositoryFactory returns an empty repository, it is called once per
+// test.
+type JournalRepositoryFactory func(t *testing.T) acme.JournalRepository
+
+// RunJournalRepositorySuite runs the acme.JournalRepository conformance tests.
+func RunJournalRepositorySuite(t *testing.T, newRepo JournalRepositoryFactory) {
+	ctx := context.Background()
+	usd := func(amount string) acme.Money { return acme.MustParseMoney(amount, "USD") }
+
+	t.Run("accounts", func(t *testing.T) {
+		repo := newRepo(t)
+
+		require.NoError(t, repo.SaveJournalAccount(ctx, acme.JournalAccount{Role: acme.JournalAccountExpense, Account: "6000"}))
+		require.NoError(t, repo.SaveJournalAccount(ctx, acme.JournalAccount{Role: acme.JournalAccountClearing, Account: "2100"}))
+		// saving the role again replaces its account
+		require.NoError(t, repo.SaveJournalAccount(ctx, acme.JournalAccount{Role: acme.JournalAccountClearing, Account: "2110"}))
+
+		accounts, err := repo.FindJournalAccounts(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []acme.JournalAccount{
+			{Role: acme.JournalAccountClearing, Account: "2110"},
+			{Role: acme.JournalAccountExpense, Account: "6000"},
+		}, accounts)
+
+		require.NoError(t, repo.DeleteJournalAccount(ctx, acme.JournalAccountClearing))
+		err = repo.DeleteJournalAccount(ctx, acme.JournalAccountClearing)
+		assert.ErrorIs(t, err, acme.ErrJournalAccountMissing)
+	})
+
+	t.Run("exports", func(t *testing.T) {
+		repo := newRepo(t)
+
+		from := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+		until := from.AddDate(0, 0, 1)
+		lines := []acme.JournalLine{
+			{
+				TransactionID: "tx2", LineNo: 1, Date: from.Add(2 * time.Hour), Account: "2100",
+				Description: "Air", Debit: usd("3.00"), Credit: usd("0.00"),
+			},
+			{
+				TransactionID: "tx1", LineNo: 2, Date: from.Add(time.Hour), Account: "2100",
+				Description: "Sushi", Debit: usd("0.00"), Credit: usd("12.50"),
+			},
+			{
+				TransactionID: "tx1", LineNo: 1, Date: from.Add(time.Hour), Account: "6100",
+				Description: "Sushi", Debit: usd("12.50"), Credit: usd("0.00"),
+			},
+		}
+
+		export := &acme.JournalExport{From: from, Until: until, Lines: lines}
+		require.NoError(t, repo.SaveJournalExport(ctx, export))
+		assert.NotZero(t, export.ID)
+		assert.False(t, export.CreatedAt.IsZero())
+
+		// the lines are ordered by date, transaction and line
+		got, err := repo.GetJournalExport(ctx, export.ID)
+		require.NoError(t, err)
+		assert.True(t, from.Equal(got.From))
+		assert.True(t, until.Equal(got.Until))
+		require.Len(t, got.Lines, 3)
+		assert.Equal(t, "6100", got.Lines[0].Account)
+		assert.Equal(t, usd("12.50"), got.Lines[0].Debit)
+		assert.Equal(t, usd("12.50"), got.Lines[1].Credit)
+		assert.Equal(t, "tx2", got.Lines[2].TransactionID)
+
+		booked, err := repo.FindBookedTransactionIDs(ctx, "tx1", "tx2", "tx3")
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"tx1", "tx2"}, booked)
+
+		booked, err = repo.FindBookedTransactionIDs(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, booked)
+
+		// the transactions are booked once
+		err = repo.SaveJournalExport(ctx, &acme.JournalExport{From: from, Until: until, Lines: lines[2:]})
+		assert.ErrorIs(t, err, acme.ErrTransactionAlreadyBooked)
+
+		empty := &acme.JournalExport{From: until.AddDate(0, 0, -7), Until: until.AddDate(0, 0, 1)}
+		require.NoError(t, repo.SaveJournalExport(ctx, empty))
+
+		got, err = repo.GetJournalExport(ctx, empty.ID)
+		require.NoError(t, err)
+		assert.Empty(t, got.Lines)
+
+		// latest first
+		exports, err := repo.FindJournalExports(ctx)
+		require.NoError(t, err)
+		require.Len(t, exports, 2)
+		assert.Equal(t, empty.ID, exports[0].ID)
+		assert.Empty(t, exports[1].Lines)
+
+		_, err = repo.GetJournalExport(ctx, -1)
+		assert.ErrorIs(t, err, acme.ErrJournalExportNotFound)
+	})
+}
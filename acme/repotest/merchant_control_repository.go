@@ -0,0 +1,141 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+)
+
+// MerchantControlRepositoryFactory returns empty repositories sharing the
+// same storage, it is called once per test.
+type MerchantControlRepositoryFactory func(t *testing.T) (acme.CardRepository, acme.CardGroupRepository, acme.MerchantControlRepository)
+
+// RunMerchantControlRepositorySuite runs the acme.MerchantControlRepository
+// conformance tests.
+func RunMerchantControlRepositorySuite(t *testing.T, newRepos MerchantControlRepositoryFactory) {
+	ctx := context.Background()
+
+	t.Run("controls", func(t *testing.T) {
+		cardRepo, groupRepo, repo := newRepos(t)
+		require.NoError(t, cardRepo.SaveCardID(ctx, "card1", "external1"))
+		require.NoError(t, cardRepo.SaveCardID(ctx, "card2", "external2"))
+
+		group := &acme.CardGroup{Name: "Marketing", Budget: acme.MustParseMoney("1000.00", "USD"), Period: acme.CardGroupPeriodMonth}
+		require.NoError(t, groupRepo.SaveCardGroup(ctx, group))
+		require.NoError(t, groupRepo.AddCardGroupMember(ctx, group.ID, "card2"))
+
+		every := &acme.MerchantControl{Kind: acme.MerchantControlBlock, Target: acme.MerchantControlTargetMCC, Value: "7995"}
+		card1 := &acme.MerchantControl{CardID: "card1", Kind: acme.MerchantControlAllow, Target: acme.MerchantControlTargetMerchant, Value: "m1"}
+		card2 := &acme.MerchantControl{CardID: "card2", Kind: acme.MerchantControlBlock, Target: acme.MerchantControlTargetMCC, Value: "6051"}
+		marketing := &acme.MerchantControl{GroupID: group.ID, Kind: acme.MerchantControlBlock, Target: acme.MerchantControlTargetMCC, Value: "5813"}
+		for _, c := range []*acme.MerchantControl{every, card1, card2, marketing} {
+			require.NoError(t, repo.SaveMerchantControl(ctx, c))
+			assert.NotZero(t, c.ID)
+			assert.False(t, c.CreatedAt.IsZero())
+		}
+
+		controls, err := repo.FindMerchantControls(ctx, "card1")
+		require.NoError(t, err)
+		require.Len(t, controls, 2)
+		assert.Equal(t, every.ID, controls[0].ID)
+		assert.Equal(t, "card1", controls[1].CardID)
+		assert.Equal(t, "m1", controls[1].Value)
+
+		// the controls of the group of the card are included
+		controls, err = repo.FindMerchantControls(ctx, "card2")
+		require.NoError(t, err)
+		require.Len(t, controls, 3)
+		assert.Equal(t, group.ID, controls[2].GroupID)
+
+		// a card in no group only has the controls of every card
+		controls, err = repo.FindMerchantControls(ctx, "card3")
+		require.NoError(t, err)
+		require.Len(t, controls, 1)
+		assert.Equal(t, every.ID, controls[0].ID)
+
+		controls, err = repo.FindMerchantControls(ctx, "")
+		require.NoError(t, err)
+		assert.Len(t, controls, 4)
+
+		require.NoError(t, repo.DeleteMerchantControl(ctx, card2.ID))
+		err = repo.DeleteMerchantControl(ctx, card2.ID)
+		assert.ErrorIs(t, err, acme.ErrMerchantControlNotFound)
+
+		controls, err = repo.FindMerchantControls(ctx, "")
+		require.NoError(t, err)
+		assert.Len(t, controls, 3)
+	})
+
+	t.Run("lock is not shared", func(t *testing.T) {
+		_, _, repo := newRepos(t)
+
+		locked, err := repo.LockMerchantControls(ctx, func(ctx context.Context) error {
+			// another enforcement cannot take the lock
+			locked, err := repo.LockMerchantControls(ctx, func(context.Context) error {
+				t.Error("fn is called while the lock is held")
+				return nil
+			})
+			require.NoError(t, err)
+			assert.False(t, locked)
+			return nil
+		})
+		require.NoError(t, err)
+		assert.True(t, locked)
+
+		// the lock is released after fn
+		var ran bool
+		locked, err = repo.LockMerchantControls(ctx, func(context.Context) error {
+			ran = true
+			return nil
+		})
+		require.NoError(t, err)
+		assert.True(t, locked)
+		assert.True(t, ran)
+	})
+
+	t.Run("violations", func(t *testing.T) {
+		cardRepo, _, repo := newRepos(t)
+		require.NoError(t, cardRepo.SaveCardID(ctx, "card1", "external1"))
+
+		now := time.Now().UTC().Truncate(time.Millisecond)
+		v := acme.MerchantControlViolation{
+			TransactionID: "tx1",
+			CardID:        "card1",
+			Reason:        "mcc 7995 is blocked",
+			MCCCode:       "7995",
+			Amount:        acme.MustParseMoney("20.00", "USD"),
+			CardFrozen:    true,
+			CreatedAt:     now.Add(-time.Hour),
+		}
+
+		exists, err := repo.MerchantControlViolationExists(ctx, "tx1")
+		require.NoError(t, err)
+		assert.False(t, exists)
+
+		require.NoError(t, repo.SaveMerchantControlViolation(ctx, v))
+		err = repo.SaveMerchantControlViolation(ctx, v)
+		assert.ErrorIs(t, err, acme.ErrMerchantControlViolationExists)
+
+		exists, err = repo.MerchantControlViolationExists(ctx, "tx1")
+		require.NoError(t, err)
+		assert.True(t, exists)
+
+		later := v
+		later.TransactionID, later.CardFrozen, later.CreatedAt = "tx2", false, now
+		require.NoError(t, repo.SaveMerchantControlViolation(ctx, later))
+
+		violations, err := repo.FindMerchantControlViolations(ctx)
+		require.NoError(t, err)
+		require.Len(t, violations, 2)
+		assert.Equal(t, "tx2", violations[0].TransactionID)
+		assert.False(t, violations[0].CardFrozen)
+		assert.Equal(t, "tx1", violations[1].TransactionID)
+		assert.Equal(t, v.Amount, violations[1].Amount)
+		assert.True(t, violations[1].CardFrozen)
+	})
+}
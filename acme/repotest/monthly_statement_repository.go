@@ -0,0 +1,89 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/acme/export"
+)
+
+// MonthlyStatementRepositoryFactory returns empty repositories sharing the
+// same storage, it is called once per test.
+type MonthlyStatementRepositoryFactory func(t *testing.T) (acme.CardRepository, export.MonthlyStatementRepository)
+
+// RunMonthlyStatementRepositorySuite runs the
+// export.MonthlyStatementRepository conformance tests.
+func RunMonthlyStatementRepositorySuite(t *testing.T, newRepos MonthlyStatementRepositoryFactory) {
+	ctx := context.Background()
+	usd := func(amount string) acme.Money { return acme.MustParseMoney(amount, "USD") }
+	march := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	newStatement := func(month time.Time, closingBalance string) export.MonthlyStatement {
+		return export.MonthlyStatement{
+			Card:           acme.Card{ID: "card1", Name: "Jane Doe", Last4: "4242"},
+			Month:          month,
+			OpeningBalance: usd("100.00"),
+			ClosingBalance: usd(closingBalance),
+			TopUps:         usd("0.00"),
+			Withdrawals:    usd("0.00"),
+			Purchases:      usd("12.50"),
+			Refunds:        usd("0.00"),
+			Fees:           usd("0.00"),
+			Entries: []export.StatementEntry{
+				{ID: "tx1", Type: export.EntryDebit, Date: month.Add(time.Hour), Amount: usd("-12.50"), Payee: "Sushi"},
+				{ID: "tx2", Type: export.EntryCredit, Date: month.Add(2 * time.Hour), Amount: usd("0.00"), Memo: "reversal"},
+			},
+			GeneratedAt: month.AddDate(0, 1, 0),
+		}
+	}
+
+	t.Run("save and get", func(t *testing.T) {
+		cardRepo, repo := newRepos(t)
+		require.NoError(t, cardRepo.SaveCardID(ctx, "card1", "external1"))
+
+		_, err := repo.GetMonthlyStatement(ctx, "card1", march)
+		assert.ErrorIs(t, err, export.ErrMonthlyStatementNotFound)
+
+		s := newStatement(march, "87.50")
+		require.NoError(t, repo.SaveMonthlyStatement(ctx, s))
+		require.NoError(t, repo.SaveMonthlyStatement(ctx, newStatement(march.AddDate(0, 1, 0), "50.00")))
+
+		got, err := repo.GetMonthlyStatement(ctx, "card1", march)
+		require.NoError(t, err)
+		assert.Equal(t, s.Card, got.Card)
+		assert.True(t, march.Equal(got.Month))
+		assert.Equal(t, usd("100.00"), got.OpeningBalance)
+		assert.Equal(t, usd("87.50"), got.ClosingBalance)
+		assert.Equal(t, usd("12.50"), got.Purchases)
+		assert.True(t, s.GeneratedAt.Equal(got.GeneratedAt))
+
+		// the entries keep their order
+		require.Len(t, got.Entries, 2)
+		assert.Equal(t, "tx1", got.Entries[0].ID)
+		assert.Equal(t, usd("-12.50"), got.Entries[0].Amount)
+		assert.Equal(t, "Sushi", got.Entries[0].Payee)
+		assert.Equal(t, export.EntryCredit, got.Entries[1].Type)
+		assert.Equal(t, "reversal", got.Entries[1].Memo)
+
+		_, err = repo.GetMonthlyStatement(ctx, "card2", march)
+		assert.ErrorIs(t, err, export.ErrMonthlyStatementNotFound)
+	})
+
+	t.Run("save keeps the first statement", func(t *testing.T) {
+		cardRepo, repo := newRepos(t)
+		require.NoError(t, cardRepo.SaveCardID(ctx, "card1", "external1"))
+
+		require.NoError(t, repo.SaveMonthlyStatement(ctx, newStatement(march, "87.50")))
+		require.NoError(t, repo.SaveMonthlyStatement(ctx, newStatement(march, "10.00")))
+
+		got, err := repo.GetMonthlyStatement(ctx, "card1", march)
+		require.NoError(t, err)
+		assert.Equal(t, usd("87.50"), got.ClosingBalance)
+		assert.Len(t, got.Entries, 2)
+	})
+}
@@ -0,0 +1,156 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+)
+
+// NotificationRepositoryFactory returns an empty repository, it is called
+// once per test.
+type NotificationRepositoryFactory func(t *testing.T) acme.NotificationRepository
+
+// RunNotificationRepositorySuite runs the acme.NotificationRepository
+// conformance tests.
+func RunNotificationRepositorySuite(t *testing.T, newRepo NotificationRepositoryFactory) {
+	ctx := context.Background()
+	usd := func(amount string) acme.Money { return acme.MustParseMoney(amount, "USD") }
+	now := time.Now().UTC().Truncate(time.Millisecond)
+
+	// newNotifications saves a pending funded notification of card1 and a
+	// later issued notification of card2
+	newNotifications := func(t *testing.T) (acme.NotificationRepository, *acme.Notification, *acme.Notification) {
+		repo := newRepo(t)
+
+		funded := &acme.Notification{
+			EventID:         "event1",
+			Type:            acme.NotificationCardFunded,
+			CardID:          "card1",
+			Amount:          usd("10.00"),
+			AvailableCredit: usd("25.00"),
+			Status:          acme.NotificationPending,
+			NextAttemptAt:   now,
+			CreatedAt:       now,
+		}
+		issued := &acme.Notification{
+			EventID:       "event2",
+			Type:          acme.NotificationCardIssued,
+			CardID:        "card2",
+			Status:        acme.NotificationPending,
+			NextAttemptAt: now.Add(time.Hour),
+			CreatedAt:     now.Add(time.Second),
+		}
+		for _, n := range []*acme.Notification{funded, issued} {
+			require.NoError(t, repo.SaveNotification(ctx, n))
+			assert.NotZero(t, n.ID)
+		}
+
+		return repo, funded, issued
+	}
+
+	t.Run("notifications", func(t *testing.T) {
+		repo, funded, issued := newNotifications(t)
+
+		// an event has one notification of each type
+		assert.ErrorIs(t, repo.SaveNotification(ctx, funded), acme.ErrNotificationExists)
+		require.NoError(t, repo.SaveNotification(ctx, &acme.Notification{
+			EventID:       funded.EventID,
+			Type:          acme.NotificationCardIssued,
+			CardID:        "card1",
+			Status:        acme.NotificationPending,
+			NextAttemptAt: now,
+			CreatedAt:     now,
+		}))
+
+		// latest first
+		notifications, err := repo.FindNotifications(ctx, acme.NotificationFilter{})
+		require.NoError(t, err)
+		require.Len(t, notifications, 3)
+		assert.Equal(t, issued.ID, notifications[0].ID)
+		assert.True(t, notifications[0].Amount.IsZero())
+
+		notifications, err = repo.FindNotifications(ctx, acme.NotificationFilter{CardID: "card1", Status: acme.NotificationPending})
+		require.NoError(t, err)
+		require.Len(t, notifications, 2)
+		assert.Equal(t, funded.ID, notifications[1].ID)
+		assert.Equal(t, usd("10.00"), notifications[1].Amount)
+		assert.Equal(t, usd("25.00"), notifications[1].AvailableCredit)
+
+		notifications, err = repo.FindNotifications(ctx, acme.NotificationFilter{Status: acme.NotificationSent})
+		require.NoError(t, err)
+		assert.Empty(t, notifications)
+	})
+
+	t.Run("claim and update", func(t *testing.T) {
+		repo, funded, _ := newNotifications(t)
+
+		claimed, err := repo.ClaimNotifications(ctx, now, time.Minute, 10)
+		require.NoError(t, err)
+		require.Len(t, claimed, 1)
+		assert.Equal(t, funded.ID, claimed[0].ID)
+		assert.Equal(t, funded.Amount, claimed[0].Amount)
+
+		// the claimed notifications are leased
+		claimed2, err := repo.ClaimNotifications(ctx, now, time.Minute, 10)
+		require.NoError(t, err)
+		assert.Empty(t, claimed2)
+
+		claimed2, err = repo.ClaimNotifications(ctx, now.Add(time.Hour), time.Minute, 1)
+		require.NoError(t, err)
+		require.Len(t, claimed2, 1)
+		assert.Equal(t, funded.ID, claimed2[0].ID)
+
+		sent := claimed[0]
+		sent.Status = acme.NotificationSent
+		sent.Attempts = 1
+		sent.Email = "jane@example.com"
+		sent.Subject = "10.00 USD was added to your card ending in 4242"
+		sent.SentAt = now
+		require.NoError(t, repo.UpdateNotification(ctx, sent))
+
+		notifications, err := repo.FindNotifications(ctx, acme.NotificationFilter{Status: acme.NotificationSent})
+		require.NoError(t, err)
+		require.Len(t, notifications, 1)
+		assert.Equal(t, "jane@example.com", notifications[0].Email)
+		assert.Equal(t, sent.Subject, notifications[0].Subject)
+		assert.Equal(t, 1, notifications[0].Attempts)
+		assert.True(t, now.Equal(notifications[0].SentAt))
+
+		// the sent notifications are not claimed
+		claimed, err = repo.ClaimNotifications(ctx, now.Add(2*time.Hour), time.Minute, 10)
+		require.NoError(t, err)
+		require.Len(t, claimed, 1)
+		assert.NotEqual(t, funded.ID, claimed[0].ID)
+	})
+
+	t.Run("preferences", func(t *testing.T) {
+		repo := newRepo(t)
+
+		_, err := repo.GetNotificationPreference(ctx, "jane@example.com")
+		assert.ErrorIs(t, err, acme.ErrNotificationPreferenceNotFound)
+
+		pref := &acme.NotificationPreference{
+			Email:       "jane@example.com",
+			Locale:      "es",
+			OptOutTypes: []string{acme.NotificationCardFunded},
+		}
+		require.NoError(t, repo.SaveNotificationPreference(ctx, pref))
+		assert.False(t, pref.UpdatedAt.IsZero())
+
+		// saving the preference again replaces it
+		pref.OptOutAll = true
+		pref.Locale = "en"
+		require.NoError(t, repo.SaveNotificationPreference(ctx, pref))
+
+		got, err := repo.GetNotificationPreference(ctx, "jane@example.com")
+		require.NoError(t, err)
+		assert.True(t, got.OptOutAll)
+		assert.Equal(t, "en", got.Locale)
+		assert.Equal(t, pref.OptOutTypes, got.OptOutTypes)
+	})
+}
@@ -0,0 +1,172 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+)
+
+// SpendRequestRepositoryFactory returns empty repositories sharing the same
+// storage, it is called once per test.
+type SpendRequestRepositoryFactory func(t *testing.T) (acme.CardRepository, acme.SpendRequestRepository)
+
+// RunSpendRequestRepositorySuite runs the acme.SpendRequestRepository
+// conformance tests.
+func RunSpendRequestRepositorySuite(t *testing.T, newRepos SpendRequestRepositoryFactory) {
+	ctx := context.Background()
+	usd := func(amount string) acme.Money { return acme.MustParseMoney(amount, "USD") }
+
+	// newRequests saves the pending requests of card1 and card2
+	newRequests := func(t *testing.T) (acme.SpendRequestRepository, *acme.SpendRequest, *acme.SpendRequest) {
+		cardRepo, repo := newRepos(t)
+		require.NoError(t, cardRepo.SaveCardID(ctx, "card1", "external1"))
+		require.NoError(t, cardRepo.SaveCardID(ctx, "card2", "external2"))
+
+		req1 := &acme.SpendRequest{
+			CardID: "card1", Requester: "alice@example.com", Amount: usd("50.00"),
+			Justification: "team lunch", Status: acme.SpendRequestPending, RequiredApprovals: 1,
+		}
+		req2 := &acme.SpendRequest{
+			CardID: "card2", Requester: "bob@example.com", Amount: usd("2500.00"),
+			Justification: "conference", Status: acme.SpendRequestPending, RequiredApprovals: 2,
+		}
+		for _, req := range []*acme.SpendRequest{req1, req2} {
+			require.NoError(t, repo.SaveSpendRequest(ctx, req))
+			assert.NotZero(t, req.ID)
+			assert.False(t, req.CreatedAt.IsZero())
+		}
+
+		return repo, req1, req2
+	}
+
+	t.Run("requests", func(t *testing.T) {
+		repo, req1, req2 := newRequests(t)
+
+		got, err := repo.GetSpendRequest(ctx, req2.ID)
+		require.NoError(t, err)
+		assert.Equal(t, req2.Amount, got.Amount)
+		assert.Equal(t, "conference", got.Justification)
+		assert.Equal(t, 2, got.RequiredApprovals)
+
+		_, err = repo.GetSpendRequest(ctx, -1)
+		assert.ErrorIs(t, err, acme.ErrSpendRequestNotFound)
+
+		reqs, err := repo.FindSpendRequests(ctx, acme.SpendRequestFilter{Requester: "alice@example.com"})
+		require.NoError(t, err)
+		require.Len(t, reqs, 1)
+		assert.Equal(t, req1.ID, reqs[0].ID)
+
+		reqs, err = repo.FindSpendRequests(ctx, acme.SpendRequestFilter{CardID: "card2"})
+		require.NoError(t, err)
+		require.Len(t, reqs, 1)
+		assert.Equal(t, req2.ID, reqs[0].ID)
+
+		// latest first
+		reqs, err = repo.FindSpendRequests(ctx, acme.SpendRequestFilter{})
+		require.NoError(t, err)
+		require.Len(t, reqs, 2)
+		assert.Equal(t, req2.ID, reqs[0].ID)
+	})
+
+	t.Run("update from the status", func(t *testing.T) {
+		repo, req1, _ := newRequests(t)
+
+		fundingStartedAt := time.Now().UTC().Truncate(time.Millisecond)
+		req1.Status, req1.FundingStartedAt = acme.SpendRequestInFunding, fundingStartedAt
+		require.NoError(t, repo.UpdateSpendRequest(ctx, req1, acme.SpendRequestPending))
+
+		// the request was just updated
+		reqs, err := repo.FindSpendRequests(ctx, acme.SpendRequestFilter{
+			Status:        acme.SpendRequestInFunding,
+			UpdatedBefore: time.Now().UTC().Add(-time.Minute),
+		})
+		require.NoError(t, err)
+		assert.Empty(t, reqs)
+
+		reqs, err = repo.FindSpendRequests(ctx, acme.SpendRequestFilter{
+			Status:        acme.SpendRequestInFunding,
+			UpdatedBefore: time.Now().UTC().Add(time.Minute),
+		})
+		require.NoError(t, err)
+		assert.Len(t, reqs, 1)
+
+		// the status only changes from the expected status
+		req1.Status, req1.AdjustmentID = acme.SpendRequestFunded, "adjustment1"
+		require.NoError(t, repo.UpdateSpendRequest(ctx, req1, acme.SpendRequestInFunding))
+		err = repo.UpdateSpendRequest(ctx, req1, acme.SpendRequestInFunding)
+		assert.ErrorIs(t, err, acme.ErrSpendRequestStatus)
+		err = repo.UpdateSpendRequest(ctx, req1, acme.SpendRequestPending)
+		assert.ErrorIs(t, err, acme.ErrSpendRequestStatus)
+
+		reqs, err = repo.FindSpendRequests(ctx, acme.SpendRequestFilter{Status: acme.SpendRequestFunded})
+		require.NoError(t, err)
+		require.Len(t, reqs, 1)
+		assert.Equal(t, "adjustment1", reqs[0].AdjustmentID)
+		assert.True(t, fundingStartedAt.Equal(reqs[0].FundingStartedAt))
+	})
+
+	t.Run("activities", func(t *testing.T) {
+		repo, req1, req2 := newRequests(t)
+
+		activities := []*acme.SpendRequestActivity{
+			{RequestID: req2.ID, Kind: acme.SpendRequestSubmitted, Actor: "bob@example.com"},
+			{RequestID: req2.ID, Kind: acme.SpendRequestApproval, Actor: "carol@example.com", Comment: "ok"},
+			{RequestID: req2.ID, Kind: acme.SpendRequestComment, Actor: "carol@example.com", Comment: "receipts please"},
+			// the approvers approve each request
+			{RequestID: req1.ID, Kind: acme.SpendRequestApproval, Actor: "carol@example.com"},
+		}
+		for _, a := range activities {
+			require.NoError(t, repo.SaveSpendRequestActivity(ctx, a))
+			assert.NotZero(t, a.ID)
+			assert.False(t, a.CreatedAt.IsZero())
+		}
+
+		// an approver approves a request once
+		err := repo.SaveSpendRequestActivity(ctx, &acme.SpendRequestActivity{
+			RequestID: req2.ID, Kind: acme.SpendRequestApproval, Actor: "carol@example.com",
+		})
+		assert.ErrorIs(t, err, acme.ErrSpendRequestNotAllowed)
+
+		found, err := repo.FindSpendRequestActivities(ctx, req2.ID)
+		require.NoError(t, err)
+		require.Len(t, found, 3)
+		assert.Equal(t, acme.SpendRequestSubmitted, found[0].Kind)
+		assert.Equal(t, "receipts please", found[2].Comment)
+	})
+
+	t.Run("approvers", func(t *testing.T) {
+		_, repo := newRepos(t)
+
+		dave := &acme.SpendRequestApprover{Name: "dave@example.com"}
+		carol := &acme.SpendRequestApprover{Name: "carol@example.com", Limit: usd("1000.00")}
+		for _, approver := range []*acme.SpendRequestApprover{dave, carol} {
+			require.NoError(t, repo.SaveSpendRequestApprover(ctx, approver))
+			assert.False(t, approver.CreatedAt.IsZero())
+		}
+
+		// saving the approver again replaces its limit
+		carol.Limit = usd("5000.00")
+		require.NoError(t, repo.SaveSpendRequestApprover(ctx, carol))
+
+		approver, err := repo.GetSpendRequestApprover(ctx, "carol@example.com")
+		require.NoError(t, err)
+		assert.Equal(t, usd("5000.00"), approver.Limit)
+
+		approvers, err := repo.FindSpendRequestApprovers(ctx)
+		require.NoError(t, err)
+		require.Len(t, approvers, 2)
+		assert.Equal(t, "carol@example.com", approvers[0].Name)
+		assert.True(t, approvers[1].Limit.IsZero())
+
+		require.NoError(t, repo.DeleteSpendRequestApprover(ctx, "dave@example.com"))
+		err = repo.DeleteSpendRequestApprover(ctx, "dave@example.com")
+		assert.ErrorIs(t, err, acme.ErrSpendRequestApproverNotFound)
+		_, err = repo.GetSpendRequestApprover(ctx, "dave@example.com")
+		assert.ErrorIs(t, err, acme.ErrSpendRequestApproverNotFound)
+	})
+}
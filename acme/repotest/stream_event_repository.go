@@ -0,0 +1,100 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+)
+
+// StreamEventRepositoryFactory returns an empty repository, it is called once
+// per test.
+type StreamEventRepositoryFactory func(t *testing.T) acme.StreamEventRepository
+
+// RunStreamEventRepositorySuite runs the acme.StreamEventRepository
+// conformance tests.
+func RunStreamEventRepositorySuite(t *testing.T, newRepo StreamEventRepositoryFactory) {
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Millisecond)
+
+	// newEvents saves event1 to event3 published an hour apart, event3 is
+	// published now
+	newEvents := func(t *testing.T) (acme.StreamEventRepository, []*acme.StreamEvent) {
+		repo := newRepo(t)
+
+		var events []*acme.StreamEvent
+		for i, id := range []string{"event1", "event2", "event3"} {
+			e := &acme.StreamEvent{
+				ID:        id,
+				Type:      acme.EventCardCreated,
+				CardID:    "card1",
+				Payload:   []byte(`{"id":"` + id + `"}`),
+				CreatedAt: now.Add(time.Duration(i-2) * time.Hour),
+			}
+			require.NoError(t, repo.SaveStreamEvent(ctx, e))
+			assert.NotZero(t, e.Seq)
+			events = append(events, e)
+		}
+
+		return repo, events
+	}
+
+	t.Run("save and get", func(t *testing.T) {
+		repo, events := newEvents(t)
+
+		assert.Less(t, events[0].Seq, events[1].Seq)
+
+		err := repo.SaveStreamEvent(ctx, &acme.StreamEvent{ID: "event1", CreatedAt: now})
+		assert.ErrorIs(t, err, acme.ErrStreamEventExists)
+
+		got, err := repo.GetStreamEvent(ctx, events[1].Seq)
+		require.NoError(t, err)
+		assert.Equal(t, "event2", got.ID)
+		assert.Equal(t, acme.EventCardCreated, got.Type)
+		assert.Equal(t, "card1", got.CardID)
+		assert.Equal(t, []byte(`{"id":"event2"}`), got.Payload)
+		assert.True(t, events[1].CreatedAt.Equal(got.CreatedAt))
+
+		_, err = repo.GetStreamEvent(ctx, -1)
+		assert.ErrorIs(t, err, acme.ErrStreamEventNotFound)
+	})
+
+	t.Run("latest", func(t *testing.T) {
+		repo, events := newEvents(t)
+
+		// oldest first
+		found, err := repo.FindLatestStreamEvents(ctx, time.Time{}, 10)
+		require.NoError(t, err)
+		require.Len(t, found, 3)
+		assert.Equal(t, events[0].Seq, found[0].Seq)
+
+		found, err = repo.FindLatestStreamEvents(ctx, time.Time{}, 2)
+		require.NoError(t, err)
+		require.Len(t, found, 2)
+		assert.Equal(t, "event2", found[0].ID)
+		assert.Equal(t, "event3", found[1].ID)
+
+		found, err = repo.FindLatestStreamEvents(ctx, now.Add(-time.Hour), 10)
+		require.NoError(t, err)
+		require.Len(t, found, 2)
+		assert.Equal(t, "event2", found[0].ID)
+	})
+
+	t.Run("delete before", func(t *testing.T) {
+		repo, events := newEvents(t)
+
+		require.NoError(t, repo.DeleteStreamEventsBefore(ctx, now.Add(-time.Minute)))
+
+		found, err := repo.FindLatestStreamEvents(ctx, time.Time{}, 10)
+		require.NoError(t, err)
+		require.Len(t, found, 1)
+		assert.Equal(t, "event3", found[0].ID)
+
+		_, err = repo.GetStreamEvent(ctx, events[0].Seq)
+		assert.ErrorIs(t, err, acme.ErrStreamEventNotFound)
+	})
+}
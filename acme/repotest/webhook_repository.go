@@ -0,0 +1,185 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+)
+
+// WebhookRepositoryFactory returns an empty repository, it is called once per
+// test.
+type WebhookRepositoryFactory func(t *testing.T) acme.WebhookRepository
+
+// RunWebhookRepositorySuite runs the acme.WebhookRepository conformance tests.
+func RunWebhookRepositorySuite(t *testing.T, newRepo WebhookRepositoryFactory) {
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Millisecond)
+
+	// newSubs saves a subscription to card.created and a subscription to
+	// every event type
+	newSubs := func(t *testing.T) (acme.WebhookRepository, *acme.WebhookSubscription, *acme.WebhookSubscription) {
+		repo := newRepo(t)
+
+		created := &acme.WebhookSubscription{
+			URL:        "https://example.com/webhooks",
+			Secret:     "whsec_0123456789abcdef",
+			EventTypes: []string{acme.EventCardCreated},
+		}
+		all := &acme.WebhookSubscription{
+			URL:    "https://example.com/all",
+			Secret: "whsec_fedcba9876543210",
+		}
+		for _, sub := range []*acme.WebhookSubscription{created, all} {
+			require.NoError(t, repo.SaveWebhookSubscription(ctx, sub))
+			assert.NotZero(t, sub.ID)
+			assert.False(t, sub.CreatedAt.IsZero())
+		}
+
+		return repo, created, all
+	}
+
+	newEvent := func(id string, createdAt time.Time) acme.WebhookEvent {
+		return acme.WebhookEvent{
+			ID:        id,
+			Type:      acme.EventCardCreated,
+			CardID:    "card1",
+			Payload:   []byte(`{"id":"` + id + `","type":"card.created"}`),
+			CreatedAt: createdAt,
+		}
+	}
+
+	t.Run("subscriptions", func(t *testing.T) {
+		repo, created, all := newSubs(t)
+
+		got, err := repo.GetWebhookSubscription(ctx, created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, created.URL, got.URL)
+		assert.Equal(t, created.Secret, got.Secret)
+		assert.Equal(t, created.EventTypes, got.EventTypes)
+
+		subs, err := repo.FindWebhookSubscriptions(ctx)
+		require.NoError(t, err)
+		require.Len(t, subs, 2)
+		assert.Equal(t, created.ID, subs[0].ID)
+		assert.Empty(t, subs[1].EventTypes)
+
+		require.NoError(t, repo.DeleteWebhookSubscription(ctx, all.ID))
+		assert.ErrorIs(t, repo.DeleteWebhookSubscription(ctx, all.ID), acme.ErrWebhookSubscriptionNotFound)
+		_, err = repo.GetWebhookSubscription(ctx, all.ID)
+		assert.ErrorIs(t, err, acme.ErrWebhookSubscriptionNotFound)
+	})
+
+	t.Run("events", func(t *testing.T) {
+		repo, created, all := newSubs(t)
+
+		event := newEvent("event1", now)
+		require.NoError(t, repo.SaveWebhookEvent(ctx, event, []int64{created.ID, all.ID}))
+		assert.ErrorIs(t, repo.SaveWebhookEvent(ctx, event, []int64{created.ID}), acme.ErrWebhookEventExists)
+
+		// the deleted subscriptions are skipped
+		require.NoError(t, repo.DeleteWebhookSubscription(ctx, all.ID))
+		require.NoError(t, repo.SaveWebhookEvent(ctx, newEvent("event2", now.Add(time.Second)), []int64{created.ID, all.ID}))
+
+		// latest first
+		deliveries, err := repo.FindWebhookDeliveries(ctx, created.ID)
+		require.NoError(t, err)
+		require.Len(t, deliveries, 2)
+		assert.Equal(t, "event2", deliveries[0].EventID)
+		assert.Equal(t, acme.EventCardCreated, deliveries[0].EventType)
+		assert.Equal(t, acme.WebhookDeliveryPending, deliveries[1].Status)
+		assert.Equal(t, event.Payload, deliveries[1].Payload)
+		assert.True(t, now.Equal(deliveries[1].NextAttemptAt))
+
+		deliveries, err = repo.FindWebhookDeliveries(ctx, all.ID)
+		require.NoError(t, err)
+		assert.Empty(t, deliveries)
+	})
+
+	t.Run("claim deliveries", func(t *testing.T) {
+		repo, created, all := newSubs(t)
+
+		require.NoError(t, repo.SaveWebhookEvent(ctx, newEvent("event1", now), []int64{created.ID, all.ID}))
+		require.NoError(t, repo.SaveWebhookEvent(ctx, newEvent("event2", now.Add(time.Hour)), []int64{created.ID}))
+
+		claimed, err := repo.ClaimWebhookDeliveries(ctx, now, time.Minute, 1)
+		require.NoError(t, err)
+		require.Len(t, claimed, 1)
+		assert.Equal(t, "event1", claimed[0].EventID)
+
+		// the later deliveries are not due
+		claimed2, err := repo.ClaimWebhookDeliveries(ctx, now, time.Minute, 10)
+		require.NoError(t, err)
+		require.Len(t, claimed2, 1)
+		assert.Equal(t, "event1", claimed2[0].EventID)
+		assert.NotEqual(t, claimed[0].ID, claimed2[0].ID)
+
+		// the claimed deliveries are leased
+		claimed3, err := repo.ClaimWebhookDeliveries(ctx, now, time.Minute, 10)
+		require.NoError(t, err)
+		assert.Empty(t, claimed3)
+
+		got, err := repo.GetWebhookDelivery(ctx, claimed[0].ID)
+		require.NoError(t, err)
+		assert.True(t, now.Add(time.Minute).Equal(got.NextAttemptAt))
+
+		claimed, err = repo.ClaimWebhookDeliveries(ctx, now.Add(time.Hour), time.Minute, 10)
+		require.NoError(t, err)
+		assert.Len(t, claimed, 3)
+	})
+
+	t.Run("attempts", func(t *testing.T) {
+		repo, created, _ := newSubs(t)
+
+		require.NoError(t, repo.SaveWebhookEvent(ctx, newEvent("event1", now), []int64{created.ID}))
+		claimed, err := repo.ClaimWebhookDeliveries(ctx, now, time.Minute, 10)
+		require.NoError(t, err)
+		require.Len(t, claimed, 1)
+
+		d := claimed[0]
+		for i, code := range []int{500, 200} {
+			d.Attempts = i + 1
+			d.ResponseCode = code
+			d.Status = acme.WebhookDeliveryPending
+			d.Error = "unexpected status code 500"
+			if code == 200 {
+				d.Status, d.Error = acme.WebhookDeliverySucceeded, ""
+			}
+			d.UpdatedAt = now.Add(time.Duration(i) * time.Minute)
+			require.NoError(t, repo.SaveWebhookDeliveryAttempt(ctx, d, acme.WebhookDeliveryAttempt{
+				DeliveryID:   d.ID,
+				Attempt:      d.Attempts,
+				ResponseCode: code,
+				Error:        d.Error,
+				Duration:     25 * time.Millisecond,
+				CreatedAt:    d.UpdatedAt,
+			}))
+		}
+
+		got, err := repo.GetWebhookDelivery(ctx, d.ID)
+		require.NoError(t, err)
+		assert.Equal(t, acme.WebhookDeliverySucceeded, got.Status)
+		assert.Equal(t, 2, got.Attempts)
+		assert.Equal(t, 200, got.ResponseCode)
+		assert.Empty(t, got.Error)
+
+		attempts, err := repo.FindWebhookDeliveryAttempts(ctx, d.ID)
+		require.NoError(t, err)
+		require.Len(t, attempts, 2)
+		assert.Equal(t, "unexpected status code 500", attempts[0].Error)
+		assert.Equal(t, 25*time.Millisecond, attempts[1].Duration)
+
+		d.ID = -1
+		err = repo.SaveWebhookDeliveryAttempt(ctx, d, acme.WebhookDeliveryAttempt{DeliveryID: d.ID, Attempt: 3, CreatedAt: now})
+		assert.ErrorIs(t, err, acme.ErrWebhookDeliveryNotFound)
+
+		// the deliveries are deleted with the subscription
+		require.NoError(t, repo.DeleteWebhookSubscription(ctx, created.ID))
+		_, err = repo.GetWebhookDelivery(ctx, got.ID)
+		assert.ErrorIs(t, err, acme.ErrWebhookDeliveryNotFound)
+	})
+}
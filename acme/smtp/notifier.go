@@ -0,0 +1,208 @@
+// Package smtp sends the notifications by email.
+package smtp
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"time"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+)
+
+// defaultTimeout bounds a message when the context has no deadline
+const defaultTimeout = 30 * time.Second
+
+type Config struct {
+	// Addr is the host:port of the SMTP server
+	Addr string
+	// Username and Password authenticate with PLAIN auth when set, the
+	// credentials are only sent over TLS or to localhost
+	Username string
+	Password string
+	// From is the sender address e.g. ACME Cards <cards@example.com>
+	From string
+}
+
+// Notifier implements acme.Notifier, it upgrades to TLS when the server
+// supports STARTTLS
+type Notifier struct {
+	cfg Config
+}
+
+var _ acme.Notifier = (*Notifier)(nil)
+
+func NewNotifier(cfg Config) *Notifier {
+	return &Notifier{cfg: cfg}
+}
+
+// Notify implements acme.Notifier.
+func (n *Notifier) Notify(ctx context.Context, msg acme.NotificationMessage) error {
+	from, err := mail.ParseAddress(n.cfg.From)
+	if err != nil {
+		return fmt.Errorf("parse from address: %w", err)
+	}
+
+	to, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return fmt.Errorf("parse to address: %w", err)
+	}
+
+	body, err := buildMessage(from, to, msg, time.Now())
+	if err != nil {
+		return fmt.Errorf("build message: %w", err)
+	}
+
+	host, _, err := net.SplitHostPort(n.cfg.Addr)
+	if err != nil {
+		return fmt.Errorf("split host port: %w", err)
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", n.cfg.Addr)
+	if err != nil {
+		return fmt.Errorf("dial: %w", err)
+	}
+	defer conn.Close()
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(defaultTimeout)
+	}
+	err = conn.SetDeadline(deadline)
+	if err != nil {
+		return fmt.Errorf("set deadline: %w", err)
+	}
+
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		return fmt.Errorf("new client: %w", err)
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		err = c.StartTLS(&tls.Config{ServerName: host})
+		if err != nil {
+			return fmt.Errorf("start tls: %w", err)
+		}
+	}
+
+	if n.cfg.Username != "" {
+		err = c.Auth(smtp.PlainAuth("", n.cfg.Username, n.cfg.Password, host))
+		if err != nil {
+			return fmt.Errorf("auth: %w", err)
+		}
+	}
+
+	err = c.Mail(from.Address)
+	if err != nil {
+		return fmt.Errorf("mail: %w", err)
+	}
+
+	err = c.Rcpt(to.Address)
+	if err != nil {
+		return fmt.Errorf("rcpt: %w", err)
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("data: %w", err)
+	}
+
+	_, err = w.Write(body)
+	if err != nil {
+		return fmt.Errorf("write body: %w", err)
+	}
+
+	err = w.Close()
+	if err != nil {
+		return fmt.Errorf("close data: %w", err)
+	}
+
+	return c.Quit()
+}
+
+// buildMessage returns the MIME message, a multipart/alternative message
+// when the notification has an HTML body
+func buildMessage(from, to *mail.Address, msg acme.NotificationMessage, date time.Time) ([]byte, error) {
+	var buf bytes.Buffer
+	header := textproto.MIMEHeader{}
+	header.Set("From", from.String())
+	header.Set("To", to.String())
+	header.Set("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	header.Set("Date", date.Format(time.RFC1123Z))
+	header.Set("MIME-Version", "1.0")
+
+	if msg.HTML == "" {
+		header.Set("Content-Type", "text/plain; charset=utf-8")
+		header.Set("Content-Transfer-Encoding", "quoted-printable")
+		writeHeader(&buf, header)
+
+		err := writeQuotedPrintable(&buf, msg.Text)
+		if err != nil {
+			return nil, err
+		}
+
+		return buf.Bytes(), nil
+	}
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	header.Set("Content-Type", "multipart/alternative; boundary="+mw.Boundary())
+	writeHeader(&buf, header)
+
+	// the preferred part comes last
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain; charset=utf-8", msg.Text},
+		{"text/html; charset=utf-8", msg.HTML},
+	} {
+		w, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("create part: %w", err)
+		}
+
+		err = writeQuotedPrintable(w, part.content)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err := mw.Close()
+	if err != nil {
+		return nil, fmt.Errorf("close multipart: %w", err)
+	}
+	buf.Write(body.Bytes())
+
+	return buf.Bytes(), nil
+}
+
+func writeHeader(buf *bytes.Buffer, header textproto.MIMEHeader) {
+	for _, k := range []string{"From", "To", "Subject", "Date", "MIME-Version", "Content-Type", "Content-Transfer-Encoding"} {
+		if v := header.Get(k); v != "" {
+			fmt.Fprintf(buf, "%s: %s\r\n", k, v)
+		}
+	}
+	buf.WriteString("\r\n")
+}
+
+func writeQuotedPrintable(w io.Writer, content string) error {
+	qw := quotedprintable.NewWriter(w)
+	_, err := qw.Write([]byte(content))
+	if err != nil {
+		return fmt.Errorf("write quoted printable: %w", err)
+	}
+
+	return qw.Close()
+}
@@ -0,0 +1,135 @@
+package smtp_test
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/acme/smtp"
+)
+
+// smtpStandIn accepts a single message and records the envelope and data
+type smtpStandIn struct {
+	addr string
+	from string
+	rcpt string
+	data chan string
+}
+
+func newSMTPStandIn(t *testing.T) *smtpStandIn {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	s := &smtpStandIn{addr: ln.Addr().String(), data: make(chan string, 1)}
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		reply := func(line string) { _, _ = io.WriteString(conn, line+"\r\n") }
+
+		reply("220 localhost ESMTP")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			cmd := strings.TrimSpace(line)
+
+			switch {
+			case strings.HasPrefix(cmd, "EHLO"):
+				reply("250 localhost")
+			case strings.HasPrefix(cmd, "MAIL FROM:"):
+				s.from = strings.TrimPrefix(cmd, "MAIL FROM:")
+				reply("250 OK")
+			case strings.HasPrefix(cmd, "RCPT TO:"):
+				s.rcpt = strings.TrimPrefix(cmd, "RCPT TO:")
+				reply("250 OK")
+			case cmd == "DATA":
+				reply("354 end with .")
+				var data strings.Builder
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if line == ".\r\n" {
+						break
+					}
+					data.WriteString(line)
+				}
+				s.data <- data.String()
+				reply("250 OK")
+			case cmd == "QUIT":
+				reply("221 bye")
+				return
+			default:
+				reply("502 not implemented")
+			}
+		}
+	}()
+
+	return s
+}
+
+func TestNotifier(t *testing.T) {
+	standIn := newSMTPStandIn(t)
+
+	notifier := smtp.NewNotifier(smtp.Config{
+		Addr: standIn.addr,
+		From: "ACME Cards <cards@example.com>",
+	})
+
+	err := notifier.Notify(context.Background(), acme.NotificationMessage{
+		To:      "jane@example.com",
+		Subject: "Su tarjeta está lista",
+		Text:    "Hola,\n\nSu tarjeta está lista.\n",
+		HTML:    "<p>Su tarjeta está lista.</p>",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "<cards@example.com>", standIn.from)
+	assert.Equal(t, "<jane@example.com>", standIn.rcpt)
+
+	msg, err := mail.ReadMessage(strings.NewReader(<-standIn.data))
+	require.NoError(t, err)
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	require.NoError(t, err)
+	assert.Equal(t, "Su tarjeta está lista", subject)
+	assert.Equal(t, `"ACME Cards" <cards@example.com>`, msg.Header.Get("From"))
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	assert.Equal(t, "multipart/alternative", mediaType)
+
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	var parts []string
+	for {
+		// the multipart reader decodes quoted-printable parts
+		p, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+
+		b, err := io.ReadAll(p)
+		require.NoError(t, err)
+		parts = append(parts, string(b))
+	}
+	assert.Equal(t, []string{"Hola,\r\n\r\nSu tarjeta está lista.\r\n", "<p>Su tarjeta está lista.</p>"}, parts)
+}
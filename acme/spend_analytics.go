@@ -0,0 +1,242 @@
+package acme
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// SpendAnalyticsService aggregates the card spend
+type SpendAnalyticsService interface {
+	GetSpendAnalytics(ctx context.Context, params SpendAnalyticsParams) (*SpendAnalytics, error)
+}
+
+// Spend analytics groupings
+const (
+	SpendGroupByCategory   = "category"
+	SpendGroupByMerchant   = "merchant"
+	SpendGroupByCard       = "card"
+	SpendGroupByCardholder = "cardholder"
+)
+
+// Spend analytics periods
+const (
+	SpendPeriodDay   = "day"
+	SpendPeriodWeek  = "week"
+	SpendPeriodMonth = "month"
+)
+
+// ErrInvalidSpendAnalyticsParams is returned for an unknown grouping or
+// period, or an empty date range
+var ErrInvalidSpendAnalyticsParams = errors.New("invalid spend analytics params")
+
+type SpendAnalyticsParams struct {
+	DateRange
+	// GroupBy is one of the SpendGroupBy constants
+	GroupBy string
+	// Period is one of the SpendPeriod constants
+	Period string
+}
+
+type SpendAnalytics struct {
+	GroupBy string
+	Period  string
+	From    time.Time
+	To      time.Time
+	// Buckets are sorted by period start, group key and currency
+	Buckets []SpendBucket
+}
+
+// SpendBucket is the spend of a group in a period for a single currency
+type SpendBucket struct {
+	PeriodStart time.Time
+	Key         string
+	Label       string
+	// Count is the number of purchases, the refunds are not counted
+	Count int64
+	// Total is the spend net of the refunds, it is negative when the
+	// refunds exceed the purchases
+	Total   Money
+	Average Money
+}
+
+// TransactionSpendAnalyticsService implements SpendAnalyticsService
+type TransactionSpendAnalyticsService struct {
+	txSource TransactionSource
+	cardSvc  CardService
+}
+
+var _ SpendAnalyticsService = (*TransactionSpendAnalyticsService)(nil)
+
+// NewTransactionSpendAnalyticsService aggregates the transactions from
+// txSource, cardSvc resolves the cardholders.
+func NewTransactionSpendAnalyticsService(txSource TransactionSource, cardSvc CardService) *TransactionSpendAnalyticsService {
+	return &TransactionSpendAnalyticsService{txSource: txSource, cardSvc: cardSvc}
+}
+
+type spendBucketKey struct {
+	periodStart time.Time
+	key         string
+	currency    string
+}
+
+func (s *TransactionSpendAnalyticsService) GetSpendAnalytics(ctx context.Context, params SpendAnalyticsParams) (*SpendAnalytics, error) {
+	err := validateSpendAnalyticsParams(params)
+	if err != nil {
+		return nil, err
+	}
+
+	var cardholders map[string]ContactInfo
+	if params.GroupBy == SpendGroupByCardholder {
+		cardholders, err = s.cardholders(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	buckets := make(map[spendBucketKey]*SpendBucket)
+	err = s.txSource.EachTransaction(ctx, params.DateRange, func(t Transaction) error {
+		if !t.IsSettled() {
+			return nil
+		}
+
+		key, label := spendGroup(params.GroupBy, t, cardholders)
+		periodStart := spendPeriodStart(params.Period, t.CreatedAt)
+		bk := spendBucketKey{periodStart: periodStart, key: key, currency: t.Amount.Currency()}
+
+		b, ok := buckets[bk]
+		if !ok {
+			b = &SpendBucket{PeriodStart: periodStart, Key: key, Label: label}
+			buckets[bk] = b
+		}
+
+		// purchases are debits, spend is reported as a positive amount and
+		// the refunds are subtracted from it
+		total, err := b.Total.Add(t.SignedAmount().Neg())
+		if err != nil {
+			return fmt.Errorf("add transaction %q amount: %w", t.ID, err)
+		}
+		b.Total = total
+		if !t.IsCredit() {
+			b.Count++
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("each transaction: %w", err)
+	}
+
+	result := make([]SpendBucket, 0, len(buckets))
+	for _, b := range buckets {
+		// a bucket of refunds only has no average
+		if b.Count > 0 {
+			b.Average, err = b.Total.Div(b.Count)
+			if err != nil {
+				return nil, fmt.Errorf("average: %w", err)
+			}
+		} else {
+			b.Average = Money{currency: b.Total.currency}
+		}
+		result = append(result, *b)
+	}
+
+	slices.SortFunc(result, func(a, b SpendBucket) int {
+		if c := a.PeriodStart.Compare(b.PeriodStart); c != 0 {
+			return c
+		}
+		if c := strings.Compare(a.Key, b.Key); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Total.Currency(), b.Total.Currency())
+	})
+
+	return &SpendAnalytics{
+		GroupBy: params.GroupBy,
+		Period:  params.Period,
+		From:    params.From,
+		To:      params.To,
+		Buckets: result,
+	}, nil
+}
+
+// cardholders maps the card IDs to the cardholder contact info
+func (s *TransactionSpendAnalyticsService) cardholders(ctx context.Context) (map[string]ContactInfo, error) {
+	resp, err := s.cardSvc.ListCards(ctx, ListCardsParams{})
+	if err != nil {
+		return nil, fmt.Errorf("list cards: %w", err)
+	}
+
+	cardholders := make(map[string]ContactInfo, len(resp.Cards))
+	for _, c := range resp.Cards {
+		cardholders[c.ID] = c.ContactInfo
+	}
+
+	return cardholders, nil
+}
+
+func validateSpendAnalyticsParams(params SpendAnalyticsParams) error {
+	switch params.GroupBy {
+	case SpendGroupByCategory, SpendGroupByMerchant, SpendGroupByCard, SpendGroupByCardholder:
+	default:
+		return fmt.Errorf("%w: unknown group by %q", ErrInvalidSpendAnalyticsParams, params.GroupBy)
+	}
+
+	switch params.Period {
+	case SpendPeriodDay, SpendPeriodWeek, SpendPeriodMonth:
+	default:
+		return fmt.Errorf("%w: unknown period %q", ErrInvalidSpendAnalyticsParams, params.Period)
+	}
+
+	if params.From.IsZero() {
+		return fmt.Errorf("%w: from date is required", ErrInvalidSpendAnalyticsParams)
+	}
+
+	if !params.To.IsZero() && params.To.Before(params.From) {
+		return fmt.Errorf("%w: to date is before from date", ErrInvalidSpendAnalyticsParams)
+	}
+
+	return nil
+}
+
+// spendGroup returns the group key and its display label
+func spendGroup(groupBy string, t Transaction, cardholders map[string]ContactInfo) (key, label string) {
+	switch groupBy {
+	case SpendGroupByCategory:
+		return t.Merchant.MCCCategory, t.Merchant.MCCCategory
+	case SpendGroupByMerchant:
+		if t.Merchant.ID == "" {
+			return t.Merchant.Name, t.Merchant.Name
+		}
+		return t.Merchant.ID, t.Merchant.Name
+	case SpendGroupByCardholder:
+		// cardholders with several cards are grouped by email
+		contact, ok := cardholders[t.CardID]
+		if !ok || contact.Email == "" {
+			return t.CardID, t.CardID
+		}
+		return contact.Email, contact.Email
+	}
+
+	return t.CardID, t.CardID
+}
+
+// spendPeriodStart truncates t in UTC to the start of the period, weeks
+// start on Monday.
+func spendPeriodStart(period string, t time.Time) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+
+	switch period {
+	case SpendPeriodWeek:
+		offset := (int(day.Weekday()) + 6) % 7
+		return day.AddDate(0, 0, -offset)
+	case SpendPeriodMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	return day
+}
@@ -0,0 +1,101 @@
+package acme_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+)
+
+type stubTransactionSource []acme.Transaction
+
+func (s stubTransactionSource) EachTransaction(_ context.Context, _ acme.DateRange, fn func(acme.Transaction) error) error {
+	for _, t := range s {
+		if err := fn(t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s stubTransactionSource) EachCardTransaction(ctx context.Context, _ string, dateRange acme.DateRange, fn func(acme.Transaction) error) error {
+	return s.EachTransaction(ctx, dateRange, fn)
+}
+
+func TestSpendAnalytics(t *testing.T) {
+	ctx := context.Background()
+
+	newTx := func(status, category, amount, currency string, createdAt time.Time) acme.Transaction {
+		return acme.Transaction{
+			CardID:    "card1",
+			Status:    status,
+			Amount:    acme.MustParseMoney(amount, currency),
+			Merchant:  acme.MerchantDetails{MCCCategory: category},
+			CreatedAt: createdAt,
+		}
+	}
+
+	// 2025-03-12 is a wednesday
+	wed := time.Date(2025, 3, 12, 10, 0, 0, 0, time.UTC)
+	txSource := stubTransactionSource{
+		newTx("Cleared", "Restaurants", "-10.00", "USD", wed),
+		newTx("cleared", "Restaurants", "-5.01", "USD", wed.AddDate(0, 0, 3)),
+		newTx("cleared", "Restaurants", "-7.00", "EUR", wed),
+		newTx("pending", "Restaurants", "-100.00", "USD", wed),
+		newTx("cleared", "Airlines", "-300.00", "USD", wed.AddDate(0, 0, 7)),
+	}
+
+	// the refunds are subtracted from the spend and are not counted
+	refund := func(category, amount string, createdAt time.Time) acme.Transaction {
+		t := newTx("cleared", category, amount, "USD", createdAt)
+		t.Category = "refund"
+		return t
+	}
+	txSource = append(txSource,
+		refund("Restaurants", "2.00", wed),
+		refund("Hotels", "20.00", wed.AddDate(0, 0, 7)),
+	)
+
+	analyticsSvc := acme.NewTransactionSpendAnalyticsService(txSource, nil)
+	resp, err := analyticsSvc.GetSpendAnalytics(ctx, acme.SpendAnalyticsParams{
+		DateRange: acme.DateRange{From: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)},
+		GroupBy:   acme.SpendGroupByCategory,
+		Period:    acme.SpendPeriodWeek,
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Buckets, 4)
+
+	monday := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+
+	eur := resp.Buckets[0]
+	assert.Equal(t, monday, eur.PeriodStart)
+	assert.Equal(t, "Restaurants", eur.Key)
+	assert.Equal(t, "7.00 EUR", eur.Total.String())
+
+	usd := resp.Buckets[1]
+	assert.Equal(t, monday, usd.PeriodStart)
+	assert.Equal(t, int64(2), usd.Count)
+	assert.Equal(t, "13.01 USD", usd.Total.String())
+	assert.Equal(t, "6.51 USD", usd.Average.String())
+
+	airlines := resp.Buckets[2]
+	assert.Equal(t, monday.AddDate(0, 0, 7), airlines.PeriodStart)
+	assert.Equal(t, "Airlines", airlines.Key)
+
+	hotels := resp.Buckets[3]
+	assert.Equal(t, "Hotels", hotels.Key)
+	assert.Zero(t, hotels.Count)
+	assert.Equal(t, "-20.00 USD", hotels.Total.String())
+	assert.Equal(t, "0.00 USD", hotels.Average.String())
+
+	_, err = analyticsSvc.GetSpendAnalytics(ctx, acme.SpendAnalyticsParams{
+		DateRange: acme.DateRange{From: monday},
+		GroupBy:   "country",
+		Period:    acme.SpendPeriodDay,
+	})
+	assert.ErrorIs(t, err, acme.ErrInvalidSpendAnalyticsParams)
+}
@@ -0,0 +1,644 @@
+package acme
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/stevenferrer/acme-cards-api/reap"
+)
+
+var (
+	// ErrInvalidSpendRequest is returned for missing fields or invalid
+	// amounts
+	ErrInvalidSpendRequest = errors.New("invalid spend request")
+	// ErrSpendRequestNotFound is returned when the request does not exist
+	ErrSpendRequestNotFound = errors.New("spend request not found")
+	// ErrSpendRequestStatus is returned when the status of the request does
+	// not allow the action e.g. approving a rejected request
+	ErrSpendRequestStatus = errors.New("invalid spend request status")
+	// ErrSpendRequestNotAllowed is returned when the approver may not decide
+	// the request
+	ErrSpendRequestNotAllowed = errors.New("spend request decision not allowed")
+	// ErrSpendRequestApproverNotFound is returned when the approver does not
+	// exist
+	ErrSpendRequestApproverNotFound = errors.New("spend request approver not found")
+)
+
+// Spend request statuses
+const (
+	SpendRequestPending = "pending"
+	// SpendRequestApproved is an approved request whose top-up has not
+	// started yet
+	SpendRequestApproved = "approved"
+	// SpendRequestInFunding is a request whose top-up started, its outcome
+	// is unknown until the request is funded or failed
+	SpendRequestInFunding = "funding"
+	SpendRequestRejected  = "rejected"
+	SpendRequestFunded    = "funded"
+	// SpendRequestFailed is an approved request whose top-up failed, the
+	// funding can be retried
+	SpendRequestFailed = "failed"
+)
+
+// spendRequestFundingTimeout is how long the requests may stay approved or
+// in funding before RecoverSpendRequestFunding takes them over
+const spendRequestFundingTimeout = 10 * time.Minute
+
+// spendRequestClockSkew widens the search of the top-ups in the Reap balance
+// history to the clock differences with Reap
+const spendRequestClockSkew = time.Minute
+
+// Spend request activity kinds
+const (
+	SpendRequestSubmitted     = "submitted"
+	SpendRequestApproval      = "approved"
+	SpendRequestRejection     = "rejected"
+	SpendRequestComment       = "commented"
+	SpendRequestFunding       = "funded"
+	SpendRequestFundingFailed = "funding_failed"
+	SpendRequestFundingRetry  = "funding_retried"
+)
+
+// SpendRequest is a request of a cardholder to top up their card
+type SpendRequest struct {
+	ID            int64
+	CardID        string
+	Requester     string
+	Amount        Money
+	Justification string
+	Status        string
+	// RequiredApprovals is two above the two-person approval limit
+	RequiredApprovals int
+	// AdjustmentID is the top-up of a funded request
+	AdjustmentID string
+	// Error is why the last top-up failed
+	Error string
+	// FundingStartedAt is when the last top-up started
+	FundingStartedAt time.Time
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	// Activities is the history of the request, oldest first, it is only
+	// set on GetSpendRequest
+	Activities []SpendRequestActivity
+}
+
+// Approvals returns the number of approvals in the activities of the
+// request
+func (r SpendRequest) Approvals() int {
+	var n int
+	for _, a := range r.Activities {
+		if a.Kind == SpendRequestApproval {
+			n++
+		}
+	}
+	return n
+}
+
+// SpendRequestActivity is a status change or a comment on a spend request
+type SpendRequestActivity struct {
+	ID        int64
+	RequestID int64
+	Kind      string
+	// Actor is the requester, the approver or the comment author, it is
+	// empty on the funding activities
+	Actor     string
+	Comment   string
+	CreatedAt time.Time
+}
+
+// SpendRequestApprover may approve the requests up to its limit and reject
+// any request
+type SpendRequestApprover struct {
+	Name string
+	// Limit is the largest amount the approver may approve, zero is no
+	// limit
+	Limit     Money
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type SpendRequestFilter struct {
+	CardID    string
+	Requester string
+	Status    string
+	// UpdatedBefore only returns the requests last updated before the time
+	// when set
+	UpdatedBefore time.Time
+}
+
+// SpendRequestDecision is the approval or the rejection of a request
+type SpendRequestDecision struct {
+	Approver string
+	Comment  string
+}
+
+type SpendRequestRepository interface {
+	// SaveSpendRequest saves the request and sets its ID and times
+	SaveSpendRequest(context.Context, *SpendRequest) error
+	GetSpendRequest(ctx context.Context, id int64) (*SpendRequest, error)
+	// FindSpendRequests returns the requests, latest first
+	FindSpendRequests(context.Context, SpendRequestFilter) ([]SpendRequest, error)
+	// UpdateSpendRequest saves the status, the adjustment, the error and the
+	// funding start of the request when its status is still from,
+	// ErrSpendRequestStatus is returned otherwise
+	UpdateSpendRequest(ctx context.Context, req *SpendRequest, from string) error
+
+	// SaveSpendRequestActivity saves the activity and sets its ID and
+	// creation time, ErrSpendRequestNotAllowed is returned when the actor
+	// already approved the request
+	SaveSpendRequestActivity(context.Context, *SpendRequestActivity) error
+	// FindSpendRequestActivities returns the activities of the request,
+	// oldest first
+	FindSpendRequestActivities(ctx context.Context, requestID int64) ([]SpendRequestActivity, error)
+
+	// SaveSpendRequestApprover creates or replaces the approver
+	SaveSpendRequestApprover(context.Context, *SpendRequestApprover) error
+	GetSpendRequestApprover(ctx context.Context, name string) (*SpendRequestApprover, error)
+	FindSpendRequestApprovers(context.Context) ([]SpendRequestApprover, error)
+	DeleteSpendRequestApprover(ctx context.Context, name string) error
+}
+
+type SpendRequestService interface {
+	SubmitSpendRequest(context.Context, SpendRequest) (*SpendRequest, error)
+	// GetSpendRequest returns the request with its activities
+	GetSpendRequest(ctx context.Context, id int64) (*SpendRequest, error)
+	ListSpendRequests(context.Context, SpendRequestFilter) ([]SpendRequest, error)
+
+	// ApproveSpendRequest approves the request, the card is topped up once
+	// the request has its required approvals
+	ApproveSpendRequest(ctx context.Context, id int64, decision SpendRequestDecision) (*SpendRequest, error)
+	RejectSpendRequest(ctx context.Context, id int64, decision SpendRequestDecision) (*SpendRequest, error)
+	CommentSpendRequest(ctx context.Context, id int64, author, comment string) (*SpendRequestActivity, error)
+	// RetrySpendRequestFunding tops up the card of a failed request again,
+	// unless the Reap balance history shows the failed top-up was applied
+	RetrySpendRequestFunding(ctx context.Context, id int64) (*SpendRequest, error)
+	// RecoverSpendRequestFunding funds the requests left approved and
+	// reconciles the requests left in funding, e.g. by a crash
+	RecoverSpendRequestFunding(context.Context) error
+
+	SaveSpendRequestApprover(context.Context, SpendRequestApprover) (*SpendRequestApprover, error)
+	ListSpendRequestApprovers(context.Context) ([]SpendRequestApprover, error)
+	DeleteSpendRequestApprover(ctx context.Context, name string) error
+}
+
+// CardSpendRequestService implements SpendRequestService.
+type CardSpendRequestService struct {
+	cardSvc    CardService
+	balanceSrc BalanceChangeSource
+	reqRepo    SpendRequestRepository
+	// twoPersonLimit is the amount above which two approvers must approve
+	// the request, zero disables the two-person approval
+	twoPersonLimit Money
+}
+
+var _ SpendRequestService = (*CardSpendRequestService)(nil)
+
+func NewCardSpendRequestService(
+	cardSvc CardService,
+	balanceSrc BalanceChangeSource,
+	reqRepo SpendRequestRepository,
+	twoPersonLimit Money,
+) *CardSpendRequestService {
+	return &CardSpendRequestService{
+		cardSvc:        cardSvc,
+		balanceSrc:     balanceSrc,
+		reqRepo:        reqRepo,
+		twoPersonLimit: twoPersonLimit,
+	}
+}
+
+func (s *CardSpendRequestService) SubmitSpendRequest(ctx context.Context, req SpendRequest) (*SpendRequest, error) {
+	switch {
+	case req.CardID == "":
+		return nil, fmt.Errorf("%w: card id is required", ErrInvalidSpendRequest)
+	case req.Requester == "":
+		return nil, fmt.Errorf("%w: requester is required", ErrInvalidSpendRequest)
+	case req.Justification == "":
+		return nil, fmt.Errorf("%w: justification is required", ErrInvalidSpendRequest)
+	}
+
+	err := validateSpendRequestAmount("amount", req.Amount)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.cardSvc.GetCard(ctx, req.CardID)
+	if err != nil {
+		return nil, fmt.Errorf("get card: %w", err)
+	}
+
+	req.Status = SpendRequestPending
+	req.RequiredApprovals = 1
+	if !s.twoPersonLimit.IsZero() && req.Amount.Minor() > s.twoPersonLimit.Minor() {
+		req.RequiredApprovals = 2
+	}
+	req.AdjustmentID, req.Error, req.FundingStartedAt = "", "", time.Time{}
+
+	err = s.reqRepo.SaveSpendRequest(ctx, &req)
+	if err != nil {
+		return nil, fmt.Errorf("save spend request: %w", err)
+	}
+
+	err = s.saveActivity(ctx, req.ID, SpendRequestSubmitted, req.Requester, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return &req, nil
+}
+
+// validateSpendRequestAmount checks that the amount is positive and in the
+// currency of the card balances
+func validateSpendRequestAmount(name string, amount Money) error {
+	if amount.Sign() <= 0 {
+		return fmt.Errorf("%w: %s must be positive", ErrInvalidSpendRequest, name)
+	}
+
+	if amount.Currency() != reap.AccountCurrency {
+		return fmt.Errorf("%w: %s must be in %s", ErrInvalidSpendRequest, name, reap.AccountCurrency)
+	}
+
+	return nil
+}
+
+func (s *CardSpendRequestService) GetSpendRequest(ctx context.Context, id int64) (*SpendRequest, error) {
+	req, err := s.reqRepo.GetSpendRequest(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Activities, err = s.reqRepo.FindSpendRequestActivities(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("find spend request activities: %w", err)
+	}
+
+	return req, nil
+}
+
+func (s *CardSpendRequestService) ListSpendRequests(ctx context.Context, filter SpendRequestFilter) ([]SpendRequest, error) {
+	return s.reqRepo.FindSpendRequests(ctx, filter)
+}
+
+func (s *CardSpendRequestService) ApproveSpendRequest(ctx context.Context, id int64, decision SpendRequestDecision) (*SpendRequest, error) {
+	req, approver, err := s.decidable(ctx, id, decision)
+	if err != nil {
+		return nil, err
+	}
+
+	if !approver.Limit.IsZero() && req.Amount.Minor() > approver.Limit.Minor() {
+		return nil, fmt.Errorf("%w: %s is above the %s limit of %s", ErrSpendRequestNotAllowed, req.Amount, approver.Limit, approver.Name)
+	}
+
+	for _, a := range req.Activities {
+		if a.Kind == SpendRequestApproval && a.Actor == decision.Approver {
+			return nil, fmt.Errorf("%w: %s already approved the request", ErrSpendRequestNotAllowed, decision.Approver)
+		}
+	}
+
+	err = s.saveActivity(ctx, id, SpendRequestApproval, decision.Approver, decision.Comment)
+	if err != nil {
+		return nil, err
+	}
+
+	// the approvals are counted again so that concurrent approvals are not
+	// missed, the status update lets a single approval fund the request
+	req.Activities, err = s.reqRepo.FindSpendRequestActivities(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("find spend request activities: %w", err)
+	}
+	if req.Approvals() < req.RequiredApprovals {
+		return req, nil
+	}
+
+	req.Status = SpendRequestApproved
+	err = s.reqRepo.UpdateSpendRequest(ctx, req, SpendRequestPending)
+	if err != nil {
+		if errors.Is(err, ErrSpendRequestStatus) {
+			return s.GetSpendRequest(ctx, id)
+		}
+		return nil, fmt.Errorf("update spend request: %w", err)
+	}
+
+	err = s.claimFunding(ctx, req, SpendRequestApproved)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.fund(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetSpendRequest(ctx, id)
+}
+
+func (s *CardSpendRequestService) RejectSpendRequest(ctx context.Context, id int64, decision SpendRequestDecision) (*SpendRequest, error) {
+	req, _, err := s.decidable(ctx, id, decision)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Status = SpendRequestRejected
+	err = s.reqRepo.UpdateSpendRequest(ctx, req, SpendRequestPending)
+	if err != nil {
+		return nil, fmt.Errorf("update spend request: %w", err)
+	}
+
+	err = s.saveActivity(ctx, id, SpendRequestRejection, decision.Approver, decision.Comment)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetSpendRequest(ctx, id)
+}
+
+// decidable returns the pending request and the approver when the
+// approver may decide the request, requesters cannot decide their own
+// requests
+func (s *CardSpendRequestService) decidable(ctx context.Context, id int64, decision SpendRequestDecision) (*SpendRequest, *SpendRequestApprover, error) {
+	if decision.Approver == "" {
+		return nil, nil, fmt.Errorf("%w: approver is required", ErrInvalidSpendRequest)
+	}
+
+	req, err := s.GetSpendRequest(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if req.Status != SpendRequestPending {
+		return nil, nil, fmt.Errorf("%w: the request is %s", ErrSpendRequestStatus, req.Status)
+	}
+
+	if decision.Approver == req.Requester {
+		return nil, nil, fmt.Errorf("%w: requesters cannot decide their own requests", ErrSpendRequestNotAllowed)
+	}
+
+	approver, err := s.reqRepo.GetSpendRequestApprover(ctx, decision.Approver)
+	if err != nil {
+		if errors.Is(err, ErrSpendRequestApproverNotFound) {
+			return nil, nil, fmt.Errorf("%w: %s is not an approver", ErrSpendRequestNotAllowed, decision.Approver)
+		}
+		return nil, nil, fmt.Errorf("get spend request approver: %w", err)
+	}
+
+	return req, approver, nil
+}
+
+// claimFunding moves the request from the status to funding and saves the
+// funding start before the top-up, so that a top-up whose outcome is lost
+// is reconciled with the Reap balance history rather than repeated
+func (s *CardSpendRequestService) claimFunding(ctx context.Context, req *SpendRequest, from string) error {
+	req.Status, req.FundingStartedAt = SpendRequestInFunding, time.Now().UTC()
+	err := s.reqRepo.UpdateSpendRequest(ctx, req, from)
+	if err != nil {
+		return fmt.Errorf("update spend request: %w", err)
+	}
+
+	return nil
+}
+
+// fund tops up the card of the request in funding, a failed top-up is saved
+// on the request rather than returned
+func (s *CardSpendRequestService) fund(ctx context.Context, req *SpendRequest) error {
+	kind, comment := SpendRequestFunding, ""
+	resp, err := s.cardSvc.AdjustCardBalance(ctx, req.CardID, AdjustCardBalanceParams{
+		Type:   BalanceAdjustmentTopUp,
+		Amount: req.Amount,
+	})
+	if err != nil {
+		req.Status, req.Error = SpendRequestFailed, err.Error()
+		kind, comment = SpendRequestFundingFailed, err.Error()
+	} else {
+		req.Status, req.Error = SpendRequestFunded, ""
+		req.AdjustmentID = resp.ID
+	}
+
+	err = s.reqRepo.UpdateSpendRequest(ctx, req, SpendRequestInFunding)
+	if err != nil {
+		return fmt.Errorf("update spend request: %w", err)
+	}
+
+	return s.saveActivity(ctx, req.ID, kind, "", comment)
+}
+
+// reconcile returns the adjustment ID of the top-up of a request whose
+// funding outcome is unknown, empty when Reap has no such top-up. The
+// top-up is a top-up of the request amount on its card since the funding
+// started that funds no other request, Reap takes no reference on the
+// top-ups so an unrelated top-up of the same amount is matched as well.
+func (s *CardSpendRequestService) reconcile(ctx context.Context, req *SpendRequest) (string, error) {
+	if req.FundingStartedAt.IsZero() {
+		return "", nil
+	}
+
+	funded, err := s.reqRepo.FindSpendRequests(ctx, SpendRequestFilter{CardID: req.CardID, Status: SpendRequestFunded})
+	if err != nil {
+		return "", fmt.Errorf("find spend requests: %w", err)
+	}
+
+	claimed := make(map[string]bool, len(funded))
+	for _, r := range funded {
+		claimed[r.AdjustmentID] = true
+	}
+
+	from := req.FundingStartedAt.Add(-spendRequestClockSkew)
+	var adjustmentID string
+	err = s.balanceSrc.EachCardBalanceChange(ctx, req.CardID, DateRange{From: from}, func(bc BalanceChange) error {
+		if adjustmentID != "" || claimed[bc.ID] || bc.Date.Before(from) ||
+			!strings.EqualFold(bc.Type, BalanceAdjustmentTopUp) {
+			return nil
+		}
+
+		// the balance change of a top-up has the ID of the adjustment
+		cmp, err := bc.Amount.Cmp(req.Amount)
+		if err == nil && cmp == 0 {
+			adjustmentID = bc.ID
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("each card balance change: %w", err)
+	}
+
+	return adjustmentID, nil
+}
+
+// settleReconciled funds the request with the top-up found in the Reap
+// balance history
+func (s *CardSpendRequestService) settleReconciled(ctx context.Context, req *SpendRequest, from, adjustmentID string) error {
+	req.Status, req.Error, req.AdjustmentID = SpendRequestFunded, "", adjustmentID
+	err := s.reqRepo.UpdateSpendRequest(ctx, req, from)
+	if err != nil {
+		return fmt.Errorf("update spend request: %w", err)
+	}
+
+	return s.saveActivity(ctx, req.ID, SpendRequestFunding, "", "reconciled with the Reap balance history")
+}
+
+func (s *CardSpendRequestService) CommentSpendRequest(ctx context.Context, id int64, author, comment string) (*SpendRequestActivity, error) {
+	if author == "" || comment == "" {
+		return nil, fmt.Errorf("%w: author and comment are required", ErrInvalidSpendRequest)
+	}
+
+	_, err := s.reqRepo.GetSpendRequest(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	activity := SpendRequestActivity{RequestID: id, Kind: SpendRequestComment, Actor: author, Comment: comment}
+	err = s.reqRepo.SaveSpendRequestActivity(ctx, &activity)
+	if err != nil {
+		return nil, fmt.Errorf("save spend request activity: %w", err)
+	}
+
+	return &activity, nil
+}
+
+func (s *CardSpendRequestService) RetrySpendRequestFunding(ctx context.Context, id int64) (*SpendRequest, error) {
+	req, err := s.reqRepo.GetSpendRequest(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Status != SpendRequestFailed {
+		return nil, fmt.Errorf("%w: only failed requests are retried, the request is %s", ErrSpendRequestStatus, req.Status)
+	}
+
+	// the failed top-up may have been applied e.g. on a timeout
+	adjustmentID, err := s.reconcile(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if adjustmentID != "" {
+		err = s.settleReconciled(ctx, req, SpendRequestFailed, adjustmentID)
+		if err != nil {
+			return nil, err
+		}
+		return s.GetSpendRequest(ctx, id)
+	}
+
+	err = s.claimFunding(ctx, req, SpendRequestFailed)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.saveActivity(ctx, id, SpendRequestFundingRetry, "", "")
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.fund(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetSpendRequest(ctx, id)
+}
+
+func (s *CardSpendRequestService) RecoverSpendRequestFunding(ctx context.Context) error {
+	before := time.Now().UTC().Add(-spendRequestFundingTimeout)
+
+	// the approved requests were never topped up
+	approved, err := s.reqRepo.FindSpendRequests(ctx, SpendRequestFilter{Status: SpendRequestApproved, UpdatedBefore: before})
+	if err != nil {
+		return fmt.Errorf("find approved spend requests: %w", err)
+	}
+
+	var errs []error
+	for _, req := range approved {
+		err = s.claimFunding(ctx, &req, SpendRequestApproved)
+		if err != nil {
+			if !errors.Is(err, ErrSpendRequestStatus) {
+				errs = append(errs, fmt.Errorf("claim spend request %d: %w", req.ID, err))
+			}
+			continue
+		}
+
+		err = s.fund(ctx, &req)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("fund spend request %d: %w", req.ID, err))
+		}
+	}
+
+	// the outcome of the top-ups in funding was lost, they are failed when
+	// Reap has no top-up so that the retry is left to a person
+	inFunding, err := s.reqRepo.FindSpendRequests(ctx, SpendRequestFilter{Status: SpendRequestInFunding, UpdatedBefore: before})
+	if err != nil {
+		return fmt.Errorf("find spend requests in funding: %w", err)
+	}
+
+	for _, req := range inFunding {
+		adjustmentID, err := s.reconcile(ctx, &req)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("reconcile spend request %d: %w", req.ID, err))
+			continue
+		}
+
+		if adjustmentID != "" {
+			err = s.settleReconciled(ctx, &req, SpendRequestInFunding, adjustmentID)
+		} else {
+			err = s.failInterrupted(ctx, &req)
+		}
+		if err != nil && !errors.Is(err, ErrSpendRequestStatus) {
+			errs = append(errs, fmt.Errorf("recover spend request %d: %w", req.ID, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (s *CardSpendRequestService) failInterrupted(ctx context.Context, req *SpendRequest) error {
+	const reason = "the funding was interrupted and Reap has no top-up"
+	req.Status, req.Error = SpendRequestFailed, reason
+	err := s.reqRepo.UpdateSpendRequest(ctx, req, SpendRequestInFunding)
+	if err != nil {
+		return fmt.Errorf("update spend request: %w", err)
+	}
+
+	return s.saveActivity(ctx, req.ID, SpendRequestFundingFailed, "", reason)
+}
+
+func (s *CardSpendRequestService) saveActivity(ctx context.Context, id int64, kind, actor, comment string) error {
+	err := s.reqRepo.SaveSpendRequestActivity(ctx, &SpendRequestActivity{
+		RequestID: id,
+		Kind:      kind,
+		Actor:     actor,
+		Comment:   comment,
+	})
+	if err != nil {
+		return fmt.Errorf("save spend request activity: %w", err)
+	}
+
+	return nil
+}
+
+func (s *CardSpendRequestService) SaveSpendRequestApprover(ctx context.Context, approver SpendRequestApprover) (*SpendRequestApprover, error) {
+	if approver.Name == "" {
+		return nil, fmt.Errorf("%w: approver name is required", ErrInvalidSpendRequest)
+	}
+
+	if !approver.Limit.IsZero() {
+		err := validateSpendRequestAmount("limit", approver.Limit)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err := s.reqRepo.SaveSpendRequestApprover(ctx, &approver)
+	if err != nil {
+		return nil, fmt.Errorf("save spend request approver: %w", err)
+	}
+
+	return &approver, nil
+}
+
+func (s *CardSpendRequestService) ListSpendRequestApprovers(ctx context.Context) ([]SpendRequestApprover, error) {
+	return s.reqRepo.FindSpendRequestApprovers(ctx)
+}
+
+func (s *CardSpendRequestService) DeleteSpendRequestApprover(ctx context.Context, name string) error {
+	return s.reqRepo.DeleteSpendRequestApprover(ctx, name)
+}
@@ -0,0 +1,257 @@
+package acme_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/acme/memory"
+)
+
+// agedSpendRequestRepository returns the requests as if they were last
+// updated age ago
+type agedSpendRequestRepository struct {
+	*memory.SpendRequestRepository
+	age time.Duration
+}
+
+func (r *agedSpendRequestRepository) FindSpendRequests(ctx context.Context, filter acme.SpendRequestFilter) ([]acme.SpendRequest, error) {
+	if !filter.UpdatedBefore.IsZero() {
+		filter.UpdatedBefore = filter.UpdatedBefore.Add(r.age)
+	}
+
+	reqs, err := r.SpendRequestRepository.FindSpendRequests(ctx, filter)
+	for i := range reqs {
+		reqs[i].UpdatedAt = reqs[i].UpdatedAt.Add(-r.age)
+	}
+	return reqs, err
+}
+
+func TestSpendRequestService(t *testing.T) {
+	ctx := context.Background()
+
+	newService := func() (*acme.CardSpendRequestService, *fakeCardService, *agedSpendRequestRepository) {
+		cardSvc := newCardService(acme.Card{ID: "card1", Status: acme.CardStatusActive, AvailableCredit: acme.MustParseMoney("10.00", "USD")})
+		reqRepo := &agedSpendRequestRepository{SpendRequestRepository: memory.NewSpendRequestRepository()}
+		reqSvc := acme.NewCardSpendRequestService(cardSvc, cardSvc, reqRepo, acme.MustParseMoney("1000.00", "USD"))
+
+		for _, approver := range []acme.SpendRequestApprover{
+			{Name: "carol", Limit: acme.MustParseMoney("500.00", "USD")},
+			{Name: "dave"},
+			{Name: "erin"},
+		} {
+			_, err := reqSvc.SaveSpendRequestApprover(ctx, approver)
+			require.NoError(t, err)
+		}
+
+		return reqSvc, cardSvc, reqRepo
+	}
+
+	submit := func(t *testing.T, reqSvc *acme.CardSpendRequestService, amount string) *acme.SpendRequest {
+		req, err := reqSvc.SubmitSpendRequest(ctx, acme.SpendRequest{
+			CardID:        "card1",
+			Requester:     "alice",
+			Amount:        acme.MustParseMoney(amount, "USD"),
+			Justification: "client dinner",
+		})
+		require.NoError(t, err)
+		return req
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		reqSvc, _, _ := newService()
+		for _, req := range []acme.SpendRequest{
+			{Requester: "alice", Amount: acme.MustParseMoney("10.00", "USD"), Justification: "x"},
+			{CardID: "card1", Amount: acme.MustParseMoney("10.00", "USD"), Justification: "x"},
+			{CardID: "card1", Requester: "alice", Amount: acme.MustParseMoney("10.00", "USD")},
+			{CardID: "card1", Requester: "alice", Amount: acme.MustParseMoney("-10.00", "USD"), Justification: "x"},
+			{CardID: "card1", Requester: "alice", Amount: acme.MustParseMoney("10.00", "EUR"), Justification: "x"},
+		} {
+			_, err := reqSvc.SubmitSpendRequest(ctx, req)
+			assert.ErrorIs(t, err, acme.ErrInvalidSpendRequest)
+		}
+
+		_, err := reqSvc.SubmitSpendRequest(ctx, acme.SpendRequest{
+			CardID: "card2", Requester: "alice", Amount: acme.MustParseMoney("10.00", "USD"), Justification: "x",
+		})
+		assert.ErrorIs(t, err, acme.ErrCardNotFound)
+
+		_, err = reqSvc.SaveSpendRequestApprover(ctx, acme.SpendRequestApprover{Name: "frank", Limit: acme.MustParseMoney("10.00", "EUR")})
+		assert.ErrorIs(t, err, acme.ErrInvalidSpendRequest)
+	})
+
+	t.Run("single approval", func(t *testing.T) {
+		reqSvc, cardSvc, _ := newService()
+		req := submit(t, reqSvc, "100.00")
+		assert.Equal(t, acme.SpendRequestPending, req.Status)
+		assert.Equal(t, 1, req.RequiredApprovals)
+
+		// requesters and unknown approvers cannot approve
+		for _, approver := range []string{"alice", "mallory"} {
+			_, err := reqSvc.ApproveSpendRequest(ctx, req.ID, acme.SpendRequestDecision{Approver: approver})
+			assert.ErrorIs(t, err, acme.ErrSpendRequestNotAllowed)
+		}
+
+		req, err := reqSvc.ApproveSpendRequest(ctx, req.ID, acme.SpendRequestDecision{Approver: "carol", Comment: "ok"})
+		require.NoError(t, err)
+		assert.Equal(t, acme.SpendRequestFunded, req.Status)
+		assert.Equal(t, "adjustment1", req.AdjustmentID)
+		require.Len(t, balanceChanges(t, cardSvc, "card1"), 1)
+		assert.Equal(t, acme.BalanceAdjustmentTopUp, balanceChanges(t, cardSvc, "card1")[0].Type)
+		assert.Equal(t, acme.MustParseMoney("100.00", "USD"), balanceChanges(t, cardSvc, "card1")[0].Amount)
+
+		var kinds []string
+		for _, a := range req.Activities {
+			kinds = append(kinds, a.Kind)
+		}
+		assert.Equal(t, []string{acme.SpendRequestSubmitted, acme.SpendRequestApproval, acme.SpendRequestFunding}, kinds)
+
+		_, err = reqSvc.ApproveSpendRequest(ctx, req.ID, acme.SpendRequestDecision{Approver: "dave"})
+		assert.ErrorIs(t, err, acme.ErrSpendRequestStatus)
+	})
+
+	t.Run("two-person approval", func(t *testing.T) {
+		reqSvc, cardSvc, _ := newService()
+		req := submit(t, reqSvc, "1500.00")
+		assert.Equal(t, 2, req.RequiredApprovals)
+
+		// the amount is above the limit of carol
+		_, err := reqSvc.ApproveSpendRequest(ctx, req.ID, acme.SpendRequestDecision{Approver: "carol"})
+		assert.ErrorIs(t, err, acme.ErrSpendRequestNotAllowed)
+
+		req, err = reqSvc.ApproveSpendRequest(ctx, req.ID, acme.SpendRequestDecision{Approver: "dave"})
+		require.NoError(t, err)
+		assert.Equal(t, acme.SpendRequestPending, req.Status)
+		assert.Equal(t, 1, req.Approvals())
+		assert.Empty(t, balanceChanges(t, cardSvc, "card1"))
+
+		_, err = reqSvc.ApproveSpendRequest(ctx, req.ID, acme.SpendRequestDecision{Approver: "dave"})
+		assert.ErrorIs(t, err, acme.ErrSpendRequestNotAllowed)
+
+		req, err = reqSvc.ApproveSpendRequest(ctx, req.ID, acme.SpendRequestDecision{Approver: "erin"})
+		require.NoError(t, err)
+		assert.Equal(t, acme.SpendRequestFunded, req.Status)
+		assert.Len(t, balanceChanges(t, cardSvc, "card1"), 1)
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		reqSvc, cardSvc, _ := newService()
+		req := submit(t, reqSvc, "100.00")
+
+		_, err := reqSvc.CommentSpendRequest(ctx, req.ID, "carol", "which client?")
+		require.NoError(t, err)
+
+		req, err = reqSvc.RejectSpendRequest(ctx, req.ID, acme.SpendRequestDecision{Approver: "carol", Comment: "no receipt"})
+		require.NoError(t, err)
+		assert.Equal(t, acme.SpendRequestRejected, req.Status)
+		require.Len(t, req.Activities, 3)
+		assert.Equal(t, acme.SpendRequestComment, req.Activities[1].Kind)
+		assert.Equal(t, "no receipt", req.Activities[2].Comment)
+
+		_, err = reqSvc.ApproveSpendRequest(ctx, req.ID, acme.SpendRequestDecision{Approver: "dave"})
+		assert.ErrorIs(t, err, acme.ErrSpendRequestStatus)
+		assert.Empty(t, balanceChanges(t, cardSvc, "card1"))
+	})
+
+	t.Run("failed funding", func(t *testing.T) {
+		reqSvc, cardSvc, _ := newService()
+		req := submit(t, reqSvc, "100.00")
+
+		cardSvc.adjustErr = errors.New("reap is down")
+		req, err := reqSvc.ApproveSpendRequest(ctx, req.ID, acme.SpendRequestDecision{Approver: "dave"})
+		require.NoError(t, err)
+		assert.Equal(t, acme.SpendRequestFailed, req.Status)
+		assert.Equal(t, "reap is down", req.Error)
+
+		cardSvc.adjustErr = nil
+		req, err = reqSvc.RetrySpendRequestFunding(ctx, req.ID)
+		require.NoError(t, err)
+		assert.Equal(t, acme.SpendRequestFunded, req.Status)
+		assert.Empty(t, req.Error)
+		assert.Len(t, balanceChanges(t, cardSvc, "card1"), 1)
+
+		_, err = reqSvc.RetrySpendRequestFunding(ctx, req.ID)
+		assert.ErrorIs(t, err, acme.ErrSpendRequestStatus)
+	})
+
+	t.Run("applied funding", func(t *testing.T) {
+		reqSvc, cardSvc, _ := newService()
+		req := submit(t, reqSvc, "100.00")
+
+		// the top-up timed out after reap applied it
+		cardSvc.appliedErr = errors.New("context deadline exceeded")
+		req, err := reqSvc.ApproveSpendRequest(ctx, req.ID, acme.SpendRequestDecision{Approver: "dave"})
+		require.NoError(t, err)
+		assert.Equal(t, acme.SpendRequestFailed, req.Status)
+		assert.Len(t, balanceChanges(t, cardSvc, "card1"), 1)
+
+		// the retry finds the top-up instead of topping up again
+		cardSvc.appliedErr = nil
+		req, err = reqSvc.RetrySpendRequestFunding(ctx, req.ID)
+		require.NoError(t, err)
+		assert.Equal(t, acme.SpendRequestFunded, req.Status)
+		assert.Equal(t, "adjustment1", req.AdjustmentID)
+		assert.Len(t, balanceChanges(t, cardSvc, "card1"), 1)
+
+		// the claimed top-up does not fund another request
+		other := submit(t, reqSvc, "100.00")
+		cardSvc.adjustErr = errors.New("reap is down")
+		other, err = reqSvc.ApproveSpendRequest(ctx, other.ID, acme.SpendRequestDecision{Approver: "dave"})
+		require.NoError(t, err)
+		assert.Equal(t, acme.SpendRequestFailed, other.Status)
+
+		cardSvc.adjustErr = nil
+		other, err = reqSvc.RetrySpendRequestFunding(ctx, other.ID)
+		require.NoError(t, err)
+		assert.Equal(t, acme.SpendRequestFunded, other.Status)
+		assert.Equal(t, "adjustment2", other.AdjustmentID)
+		assert.Len(t, balanceChanges(t, cardSvc, "card1"), 2)
+	})
+
+	t.Run("recovery", func(t *testing.T) {
+		reqSvc, cardSvc, reqRepo := newService()
+		approved := submit(t, reqSvc, "10.00")
+		applied := submit(t, reqSvc, "20.00")
+		lost := submit(t, reqSvc, "30.00")
+
+		// the process died after the approval, after the top-up and before
+		// the top-up
+		stale := time.Now().UTC().Add(-time.Hour)
+		approved.Status = acme.SpendRequestApproved
+		require.NoError(t, reqRepo.UpdateSpendRequest(ctx, approved, acme.SpendRequestPending))
+		for _, req := range []*acme.SpendRequest{applied, lost} {
+			req.Status, req.FundingStartedAt = acme.SpendRequestInFunding, stale
+			require.NoError(t, reqRepo.UpdateSpendRequest(ctx, req, acme.SpendRequestPending))
+		}
+		reqRepo.age = time.Hour
+		_, err := cardSvc.AdjustCardBalance(ctx, "card1", acme.AdjustCardBalanceParams{
+			Type:   acme.BalanceAdjustmentTopUp,
+			Amount: acme.MustParseMoney("20.00", "USD"),
+		})
+		require.NoError(t, err)
+
+		require.NoError(t, reqSvc.RecoverSpendRequestFunding(ctx))
+
+		req, err := reqSvc.GetSpendRequest(ctx, approved.ID)
+		require.NoError(t, err)
+		assert.Equal(t, acme.SpendRequestFunded, req.Status)
+
+		req, err = reqSvc.GetSpendRequest(ctx, applied.ID)
+		require.NoError(t, err)
+		assert.Equal(t, acme.SpendRequestFunded, req.Status)
+		assert.Equal(t, "adjustment1", req.AdjustmentID)
+
+		req, err = reqSvc.GetSpendRequest(ctx, lost.ID)
+		require.NoError(t, err)
+		assert.Equal(t, acme.SpendRequestFailed, req.Status)
+		assert.NotEmpty(t, req.Error)
+
+		// only the approved request was topped up by the recovery
+		assert.Len(t, balanceChanges(t, cardSvc, "card1"), 2)
+	})
+}
@@ -0,0 +1,212 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+)
+
+// maxVariables stays below the default SQLITE_MAX_VARIABLE_NUMBER
+const maxVariables = 32000
+
+type CardRepository struct {
+	db *sql.DB
+}
+
+var _ acme.CardRepository = (*CardRepository)(nil)
+
+func NewCardRepository(db *sql.DB) *CardRepository {
+	return &CardRepository{db: db}
+}
+
+// SaveCardID implements acme.CardRepository.
+func (r *CardRepository) SaveCardID(ctx context.Context, cardID string, externalCardID string) error {
+	stmnt := `insert into cards (id, external_id) values (?, ?)`
+	_, err := r.db.ExecContext(ctx, stmnt, cardID, externalCardID)
+	if err != nil {
+		var sqliteErr *sqlite.Error
+		if errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY {
+			return acme.ErrCardExists
+		}
+		return fmt.Errorf("exec context: %w", err)
+	}
+
+	return nil
+}
+
+// GetExternalID implements acme.CardRepository.
+func (r *CardRepository) GetExternalID(ctx context.Context, cardID string) (string, error) {
+	stmnt := `select external_id from cards where id = ?`
+
+	var externalID string
+	err := r.db.QueryRowContext(ctx, stmnt, cardID).Scan(&externalID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", acme.ErrCardNotFound
+		}
+		return "", fmt.Errorf("query row context: %w", err)
+	}
+
+	return externalID, nil
+}
+
+// FindCardIDs implements acme.CardRepository.
+func (r *CardRepository) FindCardIDs(ctx context.Context) ([]string, error) {
+	// rowid breaks ties between cards created within the same millisecond
+	stmnt := `select id from cards order by created_at desc, rowid desc`
+
+	rows, err := r.db.QueryContext(ctx, stmnt)
+	if err != nil {
+		return nil, fmt.Errorf("query context: %w", err)
+	}
+	defer rows.Close()
+
+	cardIDs := make([]string, 0)
+	for rows.Next() {
+		var cardID string
+		err = rows.Scan(&cardID)
+		if err != nil {
+			return nil, fmt.Errorf("row scan: %w", err)
+		}
+		cardIDs = append(cardIDs, cardID)
+	}
+
+	return cardIDs, rows.Err()
+}
+
+// GetExternalIDMapping implements acme.CardRepository.
+func (r *CardRepository) GetExternalIDMapping(ctx context.Context, externalIDs ...string) (map[string]string, error) {
+	cardIDMap := make(map[string]string, len(externalIDs))
+	for start := 0; start < len(externalIDs); start += maxVariables {
+		end := min(start+maxVariables, len(externalIDs))
+		err := r.mapExternalIDs(ctx, cardIDMap, externalIDs[start:end])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return cardIDMap, nil
+}
+
+func (r *CardRepository) mapExternalIDs(ctx context.Context, cardIDMap map[string]string, externalIDs []string) error {
+	args := make([]any, 0, len(externalIDs))
+	for _, externalID := range externalIDs {
+		args = append(args, externalID)
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(externalIDs)), ",")
+	stmnt := fmt.Sprintf(`select id, external_id from cards where external_id in (%s)`, placeholders)
+
+	rows, err := r.db.QueryContext(ctx, stmnt, args...)
+	if err != nil {
+		return fmt.Errorf("query context: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var cardID, externalID string
+		err = rows.Scan(&cardID, &externalID)
+		if err != nil {
+			return fmt.Errorf("row scan: %w", err)
+		}
+		cardIDMap[externalID] = cardID
+	}
+
+	return rows.Err()
+}
+
+// SaveCardExpiry implements acme.CardRepository.
+func (r *CardRepository) SaveCardExpiry(ctx context.Context, expiry acme.CardExpiry) error {
+	stmnt := `update cards set
+		valid_until = ?, expiry_action = ?, expiry_notified_at = ?, expired_at = ?
+	where id = ?`
+
+	res, err := r.db.ExecContext(ctx, stmnt,
+		sql.NullTime{Time: expiry.ValidUntil, Valid: !expiry.ValidUntil.IsZero()},
+		expiry.Action,
+		sql.NullTime{Time: expiry.NotifiedAt, Valid: !expiry.NotifiedAt.IsZero()},
+		sql.NullTime{Time: expiry.ExpiredAt, Valid: !expiry.ExpiredAt.IsZero()},
+		expiry.CardID,
+	)
+	if err != nil {
+		return fmt.Errorf("exec context: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+	if n == 0 {
+		return acme.ErrCardNotFound
+	}
+
+	return nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+const selectCardExpiries = `select
+	id, valid_until, expiry_action, expiry_notified_at, expired_at
+from cards`
+
+func scanCardExpiry(row rowScanner) (acme.CardExpiry, error) {
+	var expiry acme.CardExpiry
+	var validUntil, notifiedAt, expiredAt sql.NullTime
+	err := row.Scan(&expiry.CardID, &validUntil, &expiry.Action, &notifiedAt, &expiredAt)
+	if err != nil {
+		return expiry, err
+	}
+
+	expiry.ValidUntil = validUntil.Time
+	expiry.NotifiedAt = notifiedAt.Time
+	expiry.ExpiredAt = expiredAt.Time
+
+	return expiry, nil
+}
+
+// GetCardExpiry implements acme.CardRepository.
+func (r *CardRepository) GetCardExpiry(ctx context.Context, cardID string) (*acme.CardExpiry, error) {
+	stmnt := selectCardExpiries + ` where id = ?`
+
+	expiry, err := scanCardExpiry(r.db.QueryRowContext(ctx, stmnt, cardID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, acme.ErrCardNotFound
+		}
+		return nil, fmt.Errorf("query row context: %w", err)
+	}
+
+	return &expiry, nil
+}
+
+// FindCardExpiries implements acme.CardRepository.
+func (r *CardRepository) FindCardExpiries(ctx context.Context) ([]acme.CardExpiry, error) {
+	stmnt := selectCardExpiries + `
+	where valid_until is not null
+	order by valid_until, id`
+
+	rows, err := r.db.QueryContext(ctx, stmnt)
+	if err != nil {
+		return nil, fmt.Errorf("query context: %w", err)
+	}
+	defer rows.Close()
+
+	expiries := make([]acme.CardExpiry, 0)
+	for rows.Next() {
+		expiry, err := scanCardExpiry(rows)
+		if err != nil {
+			return nil, fmt.Errorf("row scan: %w", err)
+		}
+		expiries = append(expiries, expiry)
+	}
+
+	return expiries, rows.Err()
+}
@@ -0,0 +1,31 @@
+package sqlite_test
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/acme/repotest"
+	"github.com/stevenferrer/acme-cards-api/acme/sqlite"
+	"github.com/stevenferrer/acme-cards-api/x/xsql"
+)
+
+// newTestDB creates a migrated database in a temporary directory
+func newTestDB(t *testing.T) *sql.DB {
+	db, _, err := xsql.Open("sqlite://" + filepath.Join(t.TempDir(), "acme.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	sqlite.MustMigrate(db)
+
+	return db
+}
+
+func TestCardRepository(t *testing.T) {
+	repotest.RunCardRepositorySuite(t, func(t *testing.T) acme.CardRepository {
+		return sqlite.NewCardRepository(newTestDB(t))
+	})
+}
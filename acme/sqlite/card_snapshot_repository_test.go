@@ -0,0 +1,16 @@
+package sqlite_test
+
+import (
+	"testing"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/acme/repotest"
+	"github.com/stevenferrer/acme-cards-api/acme/sqlite"
+)
+
+func TestCardSnapshotRepository(t *testing.T) {
+	repotest.RunCardSnapshotRepositorySuite(t, func(t *testing.T) (acme.CardRepository, acme.CardSnapshotRepository) {
+		db := newTestDB(t)
+		return sqlite.NewCardRepository(db), sqlite.NewCardSnapshotRepository(db)
+	})
+}
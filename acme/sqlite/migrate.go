@@ -0,0 +1,59 @@
+package sqlite
+
+import (
+	"database/sql"
+	"embed"
+	"io/fs"
+
+	"github.com/lopezator/migrator"
+	// sqlite driver
+	_ "modernc.org/sqlite"
+
+	"github.com/stevenferrer/acme-cards-api/x/xmigrate"
+)
+
+// defaultOpts is the migration options
+var defaultOpts = []migrator.Option{migrator.WithLogger(newNopLogger())}
+
+type nopLogger struct{}
+
+func (*nopLogger) Printf(string, ...interface{}) {}
+
+func newNopLogger() migrator.Logger { return &nopLogger{} }
+
+//go:embed migrations/*.sql
+var migrationsFS embed.FS
+
+// Migrations returns the embedded migrations.
+func Migrations() []xmigrate.Migration {
+	sub, err := fs.Sub(migrationsFS, "migrations")
+	if err != nil {
+		panic(err)
+	}
+
+	return xmigrate.MustLoad(sub)
+}
+
+// Migrate migrates the database.
+func Migrate(db *sql.DB, opts ...migrator.Option) error {
+	if len(opts) == 0 {
+		opts = defaultOpts
+	}
+
+	opts = append(opts, xmigrate.Migrations(Migrations()))
+
+	m, err := migrator.New(opts...)
+	if err != nil {
+		return err
+	}
+
+	return m.Migrate(db)
+}
+
+// MustMigrate migrates the database and panics if an error occurs.
+func MustMigrate(db *sql.DB, opts ...migrator.Option) {
+	err := Migrate(db, opts...)
+	if err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS "cards" (
+	id varchar(32) PRIMARY KEY,
+	external_id varchar(36),
+	deleted_at timestamp,
+	created_at timestamp NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
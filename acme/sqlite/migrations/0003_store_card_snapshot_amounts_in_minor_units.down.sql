@@ -0,0 +1,18 @@
+DROP TABLE IF EXISTS "card_snapshots";
+
+CREATE TABLE "card_snapshots" (
+	card_id varchar(32) PRIMARY KEY REFERENCES cards (id) ON DELETE CASCADE,
+	name text NOT NULL,
+	last4 varchar(4) NOT NULL,
+	status varchar(32) NOT NULL,
+	available_credit text NOT NULL,
+	daily_spent text NOT NULL,
+	weekly_spent text NOT NULL,
+	monthly_spent text NOT NULL,
+	yearly_spent text NOT NULL,
+	all_time_spent text NOT NULL,
+	email text NOT NULL,
+	dial_code integer NOT NULL,
+	phone_number text NOT NULL,
+	updated_at timestamp NOT NULL
+);
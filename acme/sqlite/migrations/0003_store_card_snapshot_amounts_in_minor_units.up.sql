@@ -0,0 +1,20 @@
+-- snapshots are a cache, the next sync refills them
+DROP TABLE IF EXISTS "card_snapshots";
+
+CREATE TABLE "card_snapshots" (
+	card_id varchar(32) PRIMARY KEY REFERENCES cards (id) ON DELETE CASCADE,
+	name text NOT NULL,
+	last4 varchar(4) NOT NULL,
+	status varchar(32) NOT NULL,
+	available_credit bigint NOT NULL,
+	daily_spent bigint NOT NULL,
+	weekly_spent bigint NOT NULL,
+	monthly_spent bigint NOT NULL,
+	yearly_spent bigint NOT NULL,
+	all_time_spent bigint NOT NULL,
+	currency varchar(3) NOT NULL,
+	email text NOT NULL,
+	dial_code integer NOT NULL,
+	phone_number text NOT NULL,
+	updated_at timestamp NOT NULL
+);
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS cards_valid_until_idx;
+
+ALTER TABLE "cards" DROP COLUMN valid_until;
+ALTER TABLE "cards" DROP COLUMN expiry_action;
+ALTER TABLE "cards" DROP COLUMN expiry_notified_at;
+ALTER TABLE "cards" DROP COLUMN expired_at;
@@ -0,0 +1,7 @@
+-- the expiry action is empty on the cards without a valid until
+ALTER TABLE "cards" ADD COLUMN valid_until timestamp;
+ALTER TABLE "cards" ADD COLUMN expiry_action varchar(16) NOT NULL DEFAULT '';
+ALTER TABLE "cards" ADD COLUMN expiry_notified_at timestamp;
+ALTER TABLE "cards" ADD COLUMN expired_at timestamp;
+
+CREATE INDEX IF NOT EXISTS cards_valid_until_idx ON "cards" (valid_until) WHERE valid_until IS NOT NULL;
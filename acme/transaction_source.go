@@ -0,0 +1,19 @@
+package acme
+
+import "context"
+
+// TransactionSource walks the transactions in a date range without loading
+// them all at once, it is implemented by ReapCardService and can be backed
+// by a local store. Walking stops at the first error returned by fn.
+type TransactionSource interface {
+	// EachTransaction walks the transactions of every card
+	EachTransaction(ctx context.Context, dateRange DateRange, fn func(Transaction) error) error
+	// EachCardTransaction walks the transactions of a card
+	EachCardTransaction(ctx context.Context, cardID string, dateRange DateRange, fn func(Transaction) error) error
+}
+
+// BalanceChangeSource walks the balance changes of a card in a date range,
+// it is implemented by ReapCardService.
+type BalanceChangeSource interface {
+	EachCardBalanceChange(ctx context.Context, cardID string, dateRange DateRange, fn func(BalanceChange) error) error
+}
@@ -0,0 +1,482 @@
+package acme
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Webhook delivery statuses, pending deliveries are retried until they
+// succeed or run out of attempts
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// Webhook request headers, the signature header is t=<unix time>,v1=<hex
+// encoded HMAC-SHA256 of "<unix time>.<body>"> keyed by the subscription secret
+const (
+	WebhookSignatureHeader  = "Acme-Signature"
+	WebhookEventIDHeader    = "Acme-Event-Id"
+	WebhookEventTypeHeader  = "Acme-Event-Type"
+	WebhookDeliveryIDHeader = "Acme-Delivery-Id"
+)
+
+var (
+	// ErrInvalidWebhookSubscription is returned for invalid URLs, secrets
+	// or event types
+	ErrInvalidWebhookSubscription = errors.New("invalid webhook subscription")
+	// ErrWebhookSubscriptionNotFound is returned when the subscription does
+	// not exist
+	ErrWebhookSubscriptionNotFound = errors.New("webhook subscription not found")
+	// ErrWebhookDeliveryNotFound is returned when the delivery does not
+	// exist
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+	// ErrWebhookEventExists is returned when the event is already published
+	ErrWebhookEventExists = errors.New("webhook event exists")
+	// ErrInvalidWebhookSignature is returned when the signature header does
+	// not match the body or is too old
+	ErrInvalidWebhookSignature = errors.New("invalid webhook signature")
+)
+
+// WebhookSubscription receives the events of its types
+type WebhookSubscription struct {
+	ID     int64
+	URL    string
+	Secret string
+	// EventTypes is empty for every event type
+	EventTypes []string
+	CreatedAt  time.Time
+}
+
+// Subscribes reports whether the subscription receives the event type.
+func (s WebhookSubscription) Subscribes(eventType string) bool {
+	return len(s.EventTypes) == 0 || slices.Contains(s.EventTypes, eventType)
+}
+
+// WebhookEvent is a published event and its webhook body
+type WebhookEvent struct {
+	ID        string
+	Type      string
+	CardID    string
+	Payload   []byte
+	CreatedAt time.Time
+}
+
+// WebhookDelivery is the delivery of an event to a subscription
+type WebhookDelivery struct {
+	ID             int64
+	SubscriptionID int64
+	EventID        string
+	EventType      string
+	Payload        []byte
+	Status         string
+	Attempts       int
+	NextAttemptAt  time.Time
+	// ResponseCode and Error are the result of the last attempt, the
+	// response code is zero when the request failed
+	ResponseCode int
+	Error        string
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// WebhookDeliveryAttempt is the log of a delivery request
+type WebhookDeliveryAttempt struct {
+	DeliveryID   int64
+	Attempt      int
+	ResponseCode int
+	Error        string
+	Duration     time.Duration
+	CreatedAt    time.Time
+}
+
+type WebhookRepository interface {
+	// SaveWebhookSubscription saves the subscription and sets its ID and
+	// creation time
+	SaveWebhookSubscription(context.Context, *WebhookSubscription) error
+	GetWebhookSubscription(ctx context.Context, id int64) (*WebhookSubscription, error)
+	FindWebhookSubscriptions(context.Context) ([]WebhookSubscription, error)
+	// DeleteWebhookSubscription deletes the subscription and its deliveries
+	DeleteWebhookSubscription(ctx context.Context, id int64) error
+
+	// SaveWebhookEvent saves the event with a pending delivery for each
+	// subscription, it fails with ErrWebhookEventExists when the event is
+	// already saved
+	SaveWebhookEvent(ctx context.Context, event WebhookEvent, subscriptionIDs []int64) error
+	// ClaimWebhookDeliveries returns up to limit pending deliveries due at
+	// now and postpones them by the lease so they are attempted once
+	ClaimWebhookDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]WebhookDelivery, error)
+	GetWebhookDelivery(ctx context.Context, id int64) (*WebhookDelivery, error)
+	// FindWebhookDeliveries returns the deliveries of the subscription,
+	// latest first
+	FindWebhookDeliveries(ctx context.Context, subscriptionID int64) ([]WebhookDelivery, error)
+	// SaveWebhookDeliveryAttempt logs the attempt and updates the delivery
+	SaveWebhookDeliveryAttempt(context.Context, WebhookDelivery, WebhookDeliveryAttempt) error
+	FindWebhookDeliveryAttempts(ctx context.Context, deliveryID int64) ([]WebhookDeliveryAttempt, error)
+}
+
+type WebhookService interface {
+	EventPublisher
+
+	CreateWebhookSubscription(context.Context, WebhookSubscription) (*WebhookSubscription, error)
+	GetWebhookSubscription(ctx context.Context, id int64) (*WebhookSubscription, error)
+	ListWebhookSubscriptions(context.Context) ([]WebhookSubscription, error)
+	DeleteWebhookSubscription(ctx context.Context, id int64) error
+
+	ListWebhookDeliveries(ctx context.Context, subscriptionID int64) ([]WebhookDelivery, error)
+	GetWebhookDelivery(ctx context.Context, subscriptionID, deliveryID int64) (*WebhookDelivery, error)
+	ListWebhookDeliveryAttempts(ctx context.Context, subscriptionID, deliveryID int64) ([]WebhookDeliveryAttempt, error)
+	// RedeliverWebhook requeues the delivery and attempts it immediately
+	RedeliverWebhook(ctx context.Context, subscriptionID, deliveryID int64) (*WebhookDelivery, error)
+
+	// DeliverWebhooks attempts the pending deliveries that are due
+	DeliverWebhooks(context.Context) error
+}
+
+const (
+	// maxWebhookAttempts is the number of attempts before a delivery fails,
+	// the retries span about 8 hours
+	maxWebhookAttempts = 10
+	// webhookRetryDelay is doubled after each failed attempt
+	webhookRetryDelay    = time.Minute
+	maxWebhookRetryDelay = 6 * time.Hour
+	// webhookDeliveryLease must outlast a batch of requests
+	webhookDeliveryLease = 5 * time.Minute
+	webhookDeliveryBatch = 20
+	// maxWebhookResponseBytes limits the response body read to reuse the
+	// connection
+	maxWebhookResponseBytes = 64 << 10
+	minWebhookSecretLength  = 16
+)
+
+// HTTPWebhookService implements WebhookService
+type HTTPWebhookService struct {
+	webhookRepo WebhookRepository
+	httpClient  *http.Client
+}
+
+var _ WebhookService = (*HTTPWebhookService)(nil)
+
+// NewHTTPWebhookService creates the service, the http client timeout
+// should be well within a minute to fit a batch in the delivery lease.
+func NewHTTPWebhookService(webhookRepo WebhookRepository, httpClient *http.Client) *HTTPWebhookService {
+	return &HTTPWebhookService{
+		webhookRepo: webhookRepo,
+		httpClient:  httpClient,
+	}
+}
+
+func (s *HTTPWebhookService) CreateWebhookSubscription(ctx context.Context, sub WebhookSubscription) (*WebhookSubscription, error) {
+	u, err := url.Parse(sub.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("%w: url %q", ErrInvalidWebhookSubscription, sub.URL)
+	}
+
+	for _, eventType := range sub.EventTypes {
+		if !slices.Contains(eventTypes, eventType) {
+			return nil, fmt.Errorf("%w: event type %q", ErrInvalidWebhookSubscription, eventType)
+		}
+	}
+	sub.EventTypes = slices.Compact(slices.Sorted(slices.Values(sub.EventTypes)))
+
+	switch {
+	case sub.Secret == "":
+		sub.Secret, err = newWebhookSecret()
+		if err != nil {
+			return nil, fmt.Errorf("new webhook secret: %w", err)
+		}
+	case len(sub.Secret) < minWebhookSecretLength:
+		return nil, fmt.Errorf("%w: secret must have at least %d characters", ErrInvalidWebhookSubscription, minWebhookSecretLength)
+	}
+
+	err = s.webhookRepo.SaveWebhookSubscription(ctx, &sub)
+	if err != nil {
+		return nil, fmt.Errorf("save webhook subscription: %w", err)
+	}
+
+	return &sub, nil
+}
+
+func newWebhookSecret() (string, error) {
+	b := make([]byte, 24)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+func (s *HTTPWebhookService) GetWebhookSubscription(ctx context.Context, id int64) (*WebhookSubscription, error) {
+	return s.webhookRepo.GetWebhookSubscription(ctx, id)
+}
+
+func (s *HTTPWebhookService) ListWebhookSubscriptions(ctx context.Context) ([]WebhookSubscription, error) {
+	return s.webhookRepo.FindWebhookSubscriptions(ctx)
+}
+
+func (s *HTTPWebhookService) DeleteWebhookSubscription(ctx context.Context, id int64) error {
+	return s.webhookRepo.DeleteWebhookSubscription(ctx, id)
+}
+
+func (s *HTTPWebhookService) ListWebhookDeliveries(ctx context.Context, subscriptionID int64) ([]WebhookDelivery, error) {
+	_, err := s.webhookRepo.GetWebhookSubscription(ctx, subscriptionID)
+	if err != nil {
+		return nil, fmt.Errorf("get webhook subscription: %w", err)
+	}
+
+	return s.webhookRepo.FindWebhookDeliveries(ctx, subscriptionID)
+}
+
+func (s *HTTPWebhookService) GetWebhookDelivery(ctx context.Context, subscriptionID, deliveryID int64) (*WebhookDelivery, error) {
+	d, err := s.webhookRepo.GetWebhookDelivery(ctx, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+
+	if d.SubscriptionID != subscriptionID {
+		return nil, ErrWebhookDeliveryNotFound
+	}
+
+	return d, nil
+}
+
+func (s *HTTPWebhookService) ListWebhookDeliveryAttempts(ctx context.Context, subscriptionID, deliveryID int64) ([]WebhookDeliveryAttempt, error) {
+	_, err := s.GetWebhookDelivery(ctx, subscriptionID, deliveryID)
+	if err != nil {
+		return nil, fmt.Errorf("get webhook delivery: %w", err)
+	}
+
+	return s.webhookRepo.FindWebhookDeliveryAttempts(ctx, deliveryID)
+}
+
+// PublishEvent implements EventPublisher, events without subscribers are
+// dropped and events already published are ignored.
+func (s *HTTPWebhookService) PublishEvent(ctx context.Context, event Event) error {
+	subs, err := s.webhookRepo.FindWebhookSubscriptions(ctx)
+	if err != nil {
+		return fmt.Errorf("find webhook subscriptions: %w", err)
+	}
+
+	var subscriptionIDs []int64
+	for _, sub := range subs {
+		if sub.Subscribes(event.Type) {
+			subscriptionIDs = append(subscriptionIDs, sub.ID)
+		}
+	}
+	if len(subscriptionIDs) == 0 {
+		return nil
+	}
+
+	if event.ID == "" {
+		event.ID = uuid.New().String()
+	}
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now().UTC()
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("marshal event: %w", err)
+	}
+
+	err = s.webhookRepo.SaveWebhookEvent(ctx, WebhookEvent{
+		ID:        event.ID,
+		Type:      event.Type,
+		CardID:    event.CardID,
+		Payload:   payload,
+		CreatedAt: event.CreatedAt,
+	}, subscriptionIDs)
+	if err != nil {
+		if errors.Is(err, ErrWebhookEventExists) {
+			return nil
+		}
+		return fmt.Errorf("save webhook event: %w", err)
+	}
+
+	return nil
+}
+
+func (s *HTTPWebhookService) DeliverWebhooks(ctx context.Context) error {
+	for {
+		deliveries, err := s.webhookRepo.ClaimWebhookDeliveries(ctx, time.Now().UTC(), webhookDeliveryLease, webhookDeliveryBatch)
+		if err != nil {
+			return fmt.Errorf("claim webhook deliveries: %w", err)
+		}
+
+		for _, d := range deliveries {
+			err = s.deliver(ctx, &d)
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(deliveries) < webhookDeliveryBatch {
+			return nil
+		}
+	}
+}
+
+func (s *HTTPWebhookService) RedeliverWebhook(ctx context.Context, subscriptionID, deliveryID int64) (*WebhookDelivery, error) {
+	d, err := s.GetWebhookDelivery(ctx, subscriptionID, deliveryID)
+	if err != nil {
+		return nil, fmt.Errorf("get webhook delivery: %w", err)
+	}
+
+	d.Status = WebhookDeliveryPending
+	err = s.deliver(ctx, d)
+	if err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// deliver sends the delivery request and logs the attempt, failed attempts
+// are retried with an exponential backoff.
+func (s *HTTPWebhookService) deliver(ctx context.Context, d *WebhookDelivery) error {
+	sub, err := s.webhookRepo.GetWebhookSubscription(ctx, d.SubscriptionID)
+	if err != nil {
+		if errors.Is(err, ErrWebhookSubscriptionNotFound) {
+			// deleted with its deliveries while claimed
+			return nil
+		}
+		return fmt.Errorf("get webhook subscription: %w", err)
+	}
+
+	start := time.Now().UTC()
+	responseCode, sendErr := s.send(ctx, *sub, *d, start)
+
+	d.Attempts++
+	attempt := WebhookDeliveryAttempt{
+		DeliveryID:   d.ID,
+		Attempt:      d.Attempts,
+		ResponseCode: responseCode,
+		Duration:     time.Since(start),
+		CreatedAt:    start,
+	}
+	if sendErr != nil {
+		attempt.Error = sendErr.Error()
+	}
+
+	d.ResponseCode = responseCode
+	d.Error = attempt.Error
+	d.UpdatedAt = start
+	switch {
+	case sendErr == nil:
+		d.Status = WebhookDeliverySucceeded
+	case d.Attempts >= maxWebhookAttempts:
+		d.Status = WebhookDeliveryFailed
+	default:
+		d.Status = WebhookDeliveryPending
+		d.NextAttemptAt = start.Add(webhookBackoff(d.Attempts))
+	}
+
+	err = s.webhookRepo.SaveWebhookDeliveryAttempt(ctx, *d, attempt)
+	if err != nil {
+		return fmt.Errorf("save webhook delivery attempt: %w", err)
+	}
+
+	return nil
+}
+
+// send posts the delivery payload, responses other than 2xx are errors.
+func (s *HTTPWebhookService) send(ctx context.Context, sub WebhookSubscription, d WebhookDelivery, now time.Time) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("new request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookEventIDHeader, d.EventID)
+	req.Header.Set(WebhookEventTypeHeader, d.EventType)
+	req.Header.Set(WebhookDeliveryIDHeader, strconv.FormatInt(d.ID, 10))
+	req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(sub.Secret, now, d.Payload))
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxWebhookResponseBytes))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// webhookBackoff is the delay after the failed attempt.
+func webhookBackoff(attempt int) time.Duration {
+	delay := webhookRetryDelay
+	for i := 1; i < attempt && delay < maxWebhookRetryDelay; i++ {
+		delay *= 2
+	}
+
+	return min(delay, maxWebhookRetryDelay)
+}
+
+// SignWebhookPayload returns the signature header of the payload sent at t.
+func SignWebhookPayload(secret string, t time.Time, payload []byte) string {
+	timestamp := strconv.FormatInt(t.Unix(), 10)
+	return "t=" + timestamp + ",v1=" + hex.EncodeToString(webhookMAC(secret, timestamp, payload))
+}
+
+func webhookMAC(secret, timestamp string, payload []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+
+	return mac.Sum(nil)
+}
+
+// VerifyWebhookSignature checks the signature header of the payload, the
+// signature must be at most tolerance old to prevent replays.
+func VerifyWebhookSignature(secret, header string, payload []byte, now time.Time, tolerance time.Duration) error {
+	var timestamp, signature string
+	for part := range strings.SplitSeq(header, ",") {
+		k, v, _ := strings.Cut(part, "=")
+		switch k {
+		case "t":
+			timestamp = v
+		case "v1":
+			signature = v
+		}
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: timestamp %q", ErrInvalidWebhookSignature, timestamp)
+	}
+
+	if now.Sub(time.Unix(unix, 0)).Abs() > tolerance {
+		return fmt.Errorf("%w: timestamp outside of tolerance", ErrInvalidWebhookSignature)
+	}
+
+	expected, err := hex.DecodeString(signature)
+	if err != nil || !hmac.Equal(webhookMAC(secret, timestamp, payload), expected) {
+		return ErrInvalidWebhookSignature
+	}
+
+	return nil
+}
@@ -0,0 +1,139 @@
+package acme_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/acme/memory"
+)
+
+func TestWebhookService(t *testing.T) {
+	ctx := context.Background()
+
+	const secret = "whsec_0123456789abcdef"
+	status := http.StatusInternalServerError
+	var received [][]byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		err = acme.VerifyWebhookSignature(secret, r.Header.Get(acme.WebhookSignatureHeader), body, time.Now(), time.Minute)
+		assert.NoError(t, err)
+		assert.Equal(t, acme.EventCardFunded, r.Header.Get(acme.WebhookEventTypeHeader))
+
+		received = append(received, body)
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	webhookSvc := acme.NewHTTPWebhookService(memory.NewWebhookRepository(), srv.Client())
+
+	t.Run("invalid subscriptions", func(t *testing.T) {
+		for _, sub := range []acme.WebhookSubscription{
+			{URL: "ftp://example.com"},
+			{URL: "/webhooks"},
+			{URL: srv.URL, Secret: "short"},
+			{URL: srv.URL, EventTypes: []string{"card.deleted"}},
+		} {
+			_, err := webhookSvc.CreateWebhookSubscription(ctx, sub)
+			assert.ErrorIs(t, err, acme.ErrInvalidWebhookSubscription)
+		}
+	})
+
+	generated, err := webhookSvc.CreateWebhookSubscription(ctx, acme.WebhookSubscription{
+		URL:        srv.URL,
+		EventTypes: []string{acme.EventCardCreated},
+	})
+	require.NoError(t, err)
+	assert.Regexp(t, "^whsec_[0-9a-f]{48}$", generated.Secret)
+
+	sub, err := webhookSvc.CreateWebhookSubscription(ctx, acme.WebhookSubscription{
+		URL:        srv.URL,
+		Secret:     secret,
+		EventTypes: []string{acme.EventCardFunded, acme.EventCardWithdrawn, acme.EventCardFunded},
+	})
+	require.NoError(t, err)
+
+	event := acme.Event{
+		ID:     "event1",
+		Type:   acme.EventCardFunded,
+		CardID: "card1",
+		Data: acme.CardBalanceEventData{
+			CardID:          "card1",
+			AdjustmentID:    "adjustment1",
+			Amount:          acme.MustParseMoney("10.00", "USD"),
+			AvailableCredit: acme.MustParseMoney("25.00", "USD"),
+		},
+		CreatedAt: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+	}
+	require.NoError(t, webhookSvc.PublishEvent(ctx, event))
+	// events are published once
+	require.NoError(t, webhookSvc.PublishEvent(ctx, event))
+	// events without subscribers are dropped
+	require.NoError(t, webhookSvc.PublishEvent(ctx, acme.Event{Type: acme.EventTransactionSettled}))
+
+	deliveries, err := webhookSvc.ListWebhookDeliveries(ctx, sub.ID)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.JSONEq(t, `{
+		"id": "event1",
+		"type": "card.funded",
+		"createdAt": "2024-05-01T10:00:00Z",
+		"data": {
+			"cardId": "card1",
+			"adjustmentId": "adjustment1",
+			"amount": {"amount": "10.00", "currency": "USD"},
+			"availableCredit": {"amount": "25.00", "currency": "USD"}
+		}
+	}`, string(deliveries[0].Payload))
+
+	require.NoError(t, webhookSvc.DeliverWebhooks(ctx))
+	require.Len(t, received, 1)
+
+	failed, err := webhookSvc.GetWebhookDelivery(ctx, sub.ID, deliveries[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, acme.WebhookDeliveryPending, failed.Status)
+	assert.Equal(t, 1, failed.Attempts)
+	assert.Equal(t, http.StatusInternalServerError, failed.ResponseCode)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), failed.NextAttemptAt, 5*time.Second)
+
+	// the retry is not due yet
+	require.NoError(t, webhookSvc.DeliverWebhooks(ctx))
+	assert.Len(t, received, 1)
+
+	status = http.StatusNoContent
+	d, err := webhookSvc.RedeliverWebhook(ctx, failed.SubscriptionID, failed.ID)
+	require.NoError(t, err)
+	assert.Equal(t, acme.WebhookDeliverySucceeded, d.Status)
+	assert.Equal(t, 2, d.Attempts)
+	assert.Equal(t, received[0], received[1])
+
+	attempts, err := webhookSvc.ListWebhookDeliveryAttempts(ctx, failed.SubscriptionID, failed.ID)
+	require.NoError(t, err)
+	require.Len(t, attempts, 2)
+	assert.Equal(t, "unexpected status code 500", attempts[0].Error)
+	assert.Equal(t, http.StatusNoContent, attempts[1].ResponseCode)
+
+	_, err = webhookSvc.GetWebhookDelivery(ctx, generated.ID, failed.ID)
+	assert.ErrorIs(t, err, acme.ErrWebhookDeliveryNotFound)
+}
+
+func TestVerifyWebhookSignature(t *testing.T) {
+	payload := []byte(`{"id":"event1"}`)
+	now := time.Now()
+
+	header := acme.SignWebhookPayload("secret", now, payload)
+	assert.NoError(t, acme.VerifyWebhookSignature("secret", header, payload, now, time.Minute))
+	assert.ErrorIs(t, acme.VerifyWebhookSignature("other", header, payload, now, time.Minute), acme.ErrInvalidWebhookSignature)
+	assert.ErrorIs(t, acme.VerifyWebhookSignature("secret", header, []byte(`{}`), now, time.Minute), acme.ErrInvalidWebhookSignature)
+	assert.ErrorIs(t, acme.VerifyWebhookSignature("secret", header, payload, now.Add(2*time.Minute), time.Minute), acme.ErrInvalidWebhookSignature)
+	assert.ErrorIs(t, acme.VerifyWebhookSignature("secret", "v1=abc", payload, now, time.Minute), acme.ErrInvalidWebhookSignature)
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+)
+
+func accountCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "account",
+		Usage: "show account details",
+		Subcommands: []*cli.Command{
+			{
+				Name:   "balance",
+				Usage:  "show account balance",
+				Action: getAccountBalanceAction,
+			},
+			{
+				Name:   "transactions",
+				Usage:  "list transactions across all cards",
+				Flags:  dateRangeFlags(),
+				Action: listTransactionsAction,
+			},
+			{
+				Name:   "export",
+				Usage:  "export transactions across all cards to a csv or xlsx file",
+				Flags:  exportFlags(),
+				Action: exportTransactionsAction,
+			},
+		},
+	}
+}
+
+func getAccountBalanceAction(c *cli.Context) error {
+	return withCardService(c, func(cardSvc acme.CardService) error {
+		bal, err := cardSvc.GetAccountBalance(c.Context)
+		if err != nil {
+			return fmt.Errorf("get account balance: %w", err)
+		}
+
+		t := table{headers: []string{"balance", "available", "currency"}}
+		t.append(bal.AvailableBalance.Decimal(), bal.AvailableToAllocate.Decimal(), bal.AvailableBalance.Currency())
+		return render(c, t)
+	})
+}
+
+func listTransactionsAction(c *cli.Context) error {
+	return withCardService(c, func(cardSvc acme.CardService) error {
+		resp, err := cardSvc.ListTransactions(c.Context, acme.ListTransactionsParams{
+			DateRange: dateRange(c),
+			Limit:     c.Int("limit"),
+		})
+		if err != nil {
+			return fmt.Errorf("list transactions: %w", err)
+		}
+
+		return render(c, transactionsTable(resp.Transactions))
+	})
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+)
+
+// cardSpec is a card entry in the create cards file, field names
+// follow the create card http request.
+type cardSpec struct {
+	FirstName string `json:"firstName" yaml:"firstName"`
+	LastName  string `json:"lastName" yaml:"lastName"`
+	DOB       string `json:"dob" yaml:"dob"`
+	Address   struct {
+		Line1   string `json:"line1" yaml:"line1"`
+		Line2   string `json:"line2" yaml:"line2"`
+		City    string `json:"city" yaml:"city"`
+		Country string `json:"country" yaml:"country"`
+	} `json:"address" yaml:"address"`
+	IDDocument struct {
+		IDType   string `json:"idType" yaml:"idType"`
+		IDNumber string `json:"idNumber" yaml:"idNumber"`
+	} `json:"idDocument" yaml:"idDocument"`
+	OTP struct {
+		Email       string `json:"email" yaml:"email"`
+		DialCode    int    `json:"dialCode" yaml:"dialCode"`
+		PhoneNumber string `json:"phoneNumber" yaml:"phoneNumber"`
+	} `json:"otp" yaml:"otp"`
+}
+
+// readCardFile reads a json or yaml file containing either a single
+// card or a list of cards.
+func readCardFile(name string) ([]acme.CreateCardParams, error) {
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("read file: %w", err)
+	}
+
+	var specs []cardSpec
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".json":
+		specs, err = decodeJSONCardSpecs(b)
+	case ".yaml", ".yml":
+		specs, err = decodeYAMLCardSpecs(b)
+	default:
+		return nil, fmt.Errorf("unsupported file extension %q", filepath.Ext(name))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	params := make([]acme.CreateCardParams, 0, len(specs))
+	for _, spec := range specs {
+		params = append(params, spec.toCreateCardParams())
+	}
+
+	return params, nil
+}
+
+func decodeJSONCardSpecs(b []byte) ([]cardSpec, error) {
+	b = bytes.TrimSpace(b)
+	if bytes.HasPrefix(b, []byte("[")) {
+		var specs []cardSpec
+		if err := json.Unmarshal(b, &specs); err != nil {
+			return nil, fmt.Errorf("json decode: %w", err)
+		}
+		return specs, nil
+	}
+
+	var spec cardSpec
+	if err := json.Unmarshal(b, &spec); err != nil {
+		return nil, fmt.Errorf("json decode: %w", err)
+	}
+	return []cardSpec{spec}, nil
+}
+
+func decodeYAMLCardSpecs(b []byte) ([]cardSpec, error) {
+	var node yaml.Node
+	if err := yaml.Unmarshal(b, &node); err != nil {
+		return nil, fmt.Errorf("yaml decode: %w", err)
+	}
+
+	if len(node.Content) > 0 && node.Content[0].Kind == yaml.SequenceNode {
+		var specs []cardSpec
+		if err := node.Decode(&specs); err != nil {
+			return nil, fmt.Errorf("yaml decode: %w", err)
+		}
+		return specs, nil
+	}
+
+	var spec cardSpec
+	if err := node.Decode(&spec); err != nil {
+		return nil, fmt.Errorf("yaml decode: %w", err)
+	}
+	return []cardSpec{spec}, nil
+}
+
+func (s cardSpec) toCreateCardParams() acme.CreateCardParams {
+	return acme.CreateCardParams{
+		FirstName: s.FirstName,
+		LastName:  s.LastName,
+		DOB:       s.DOB,
+		Address: acme.Address{
+			Line1:       s.Address.Line1,
+			Line2:       s.Address.Line2,
+			City:        s.Address.City,
+			CountryCode: s.Address.Country,
+		},
+		ContactInfo: acme.ContactInfo{
+			Email:       s.OTP.Email,
+			DialCode:    s.OTP.DialCode,
+			PhoneNumber: s.OTP.PhoneNumber,
+		},
+		IDDocument: acme.IDDocument{
+			Type:   s.IDDocument.IDType,
+			Number: s.IDDocument.IDNumber,
+		},
+	}
+}
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+)
+
+func cardsCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "cards",
+		Usage: "manage cards",
+		Subcommands: []*cli.Command{
+			{
+				Name:      "create",
+				Usage:     "create cards from a json or yaml file",
+				ArgsUsage: "<file>",
+				Action:    createCardsAction,
+			},
+			{
+				Name:   "list",
+				Usage:  "list cards",
+				Action: listCardsAction,
+			},
+			{
+				Name:      "get",
+				Usage:     "get a card",
+				ArgsUsage: "<card-id>",
+				Action:    getCardAction,
+			},
+			{
+				Name:      "freeze",
+				Usage:     "freeze a card",
+				ArgsUsage: "<card-id>",
+				Action:    updateCardStatusAction(acme.CardStatusFrozen),
+			},
+			{
+				Name:      "unfreeze",
+				Usage:     "unfreeze a card",
+				ArgsUsage: "<card-id>",
+				Action:    updateCardStatusAction(acme.CardStatusActive),
+			},
+			{
+				Name:      "topup",
+				Usage:     "top up a card",
+				ArgsUsage: "<card-id> <amount>",
+				Flags:     []cli.Flag{currencyFlag()},
+				Action:    adjustCardBalanceAction(acme.BalanceAdjustmentTopUp),
+			},
+			{
+				Name:      "withdraw",
+				Usage:     "withdraw from a card",
+				ArgsUsage: "<card-id> <amount>",
+				Flags:     []cli.Flag{currencyFlag()},
+				Action:    adjustCardBalanceAction(acme.BalanceAdjustmentWithdraw),
+			},
+			{
+				Name:      "transactions",
+				Usage:     "list card transactions",
+				ArgsUsage: "<card-id>",
+				Flags:     dateRangeFlags(),
+				Action:    listCardTransactionsAction,
+			},
+			{
+				Name:      "export",
+				Usage:     "export card transactions to a csv or xlsx file",
+				ArgsUsage: "<card-id>",
+				Flags:     exportFlags(),
+				Action:    exportCardTransactionsAction,
+			},
+			{
+				Name:      "balance-history",
+				Usage:     "list card balance history",
+				ArgsUsage: "<card-id>",
+				Flags:     dateRangeFlags(),
+				Action:    listCardBalanceHistoryAction,
+			},
+		},
+	}
+}
+
+func createCardsAction(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return fmt.Errorf("expecting a card file argument")
+	}
+
+	params, err := readCardFile(c.Args().First())
+	if err != nil {
+		return fmt.Errorf("read card file: %w", err)
+	}
+
+	return withCardService(c, func(cardSvc acme.CardService) error {
+		t := table{headers: []string{"cardId", "name", "email"}}
+		for _, p := range params {
+			resp, err := cardSvc.CreateCard(c.Context, p)
+			if err != nil {
+				return fmt.Errorf("create card for %s %s: %w", p.FirstName, p.LastName, err)
+			}
+
+			t.append(resp.CardID, p.FirstName+" "+p.LastName, p.ContactInfo.Email)
+		}
+
+		return render(c, t)
+	})
+}
+
+func listCardsAction(c *cli.Context) error {
+	return withCardService(c, func(cardSvc acme.CardService) error {
+		resp, err := cardSvc.ListCards(c.Context, acme.ListCardsParams{})
+		if err != nil {
+			return fmt.Errorf("list cards: %w", err)
+		}
+
+		return render(c, cardsTable(resp.Cards...))
+	})
+}
+
+func getCardAction(c *cli.Context) error {
+	cardID, err := cardIDArg(c)
+	if err != nil {
+		return err
+	}
+
+	return withCardService(c, func(cardSvc acme.CardService) error {
+		card, err := cardSvc.GetCard(c.Context, cardID)
+		if err != nil {
+			return fmt.Errorf("get card: %w", err)
+		}
+
+		return render(c, cardsTable(*card))
+	})
+}
+
+func updateCardStatusAction(status string) cli.ActionFunc {
+	return func(c *cli.Context) error {
+		cardID, err := cardIDArg(c)
+		if err != nil {
+			return err
+		}
+
+		return withCardService(c, func(cardSvc acme.CardService) error {
+			err := cardSvc.UpdateCardStatus(c.Context, cardID, status)
+			if err != nil {
+				return fmt.Errorf("update card status: %w", err)
+			}
+
+			t := table{headers: []string{"cardId", "status"}}
+			t.append(cardID, status)
+			return render(c, t)
+		})
+	}
+}
+
+func adjustCardBalanceAction(adjustmentType string) cli.ActionFunc {
+	return func(c *cli.Context) error {
+		if c.NArg() != 2 {
+			return fmt.Errorf("expecting card ID and amount arguments")
+		}
+
+		cardID := c.Args().Get(0)
+		amount, err := acme.ParseMoney(c.Args().Get(1), c.String("currency"))
+		if err != nil {
+			return fmt.Errorf("parse amount: %w", err)
+		}
+
+		return withCardService(c, func(cardSvc acme.CardService) error {
+			resp, err := cardSvc.AdjustCardBalance(c.Context, cardID, acme.AdjustCardBalanceParams{
+				Type:   adjustmentType,
+				Amount: amount,
+			})
+			if err != nil {
+				return fmt.Errorf("adjust card balance: %w", err)
+			}
+
+			t := table{headers: []string{"id", "cardId", "availableCredit", "currency"}}
+			t.append(resp.ID, cardID, resp.AvailableCredit.Decimal(), resp.AvailableCredit.Currency())
+			return render(c, t)
+		})
+	}
+}
+
+func listCardTransactionsAction(c *cli.Context) error {
+	cardID, err := cardIDArg(c)
+	if err != nil {
+		return err
+	}
+
+	return withCardService(c, func(cardSvc acme.CardService) error {
+		resp, err := cardSvc.ListCardTransactions(c.Context, cardID, acme.ListCardTransactionsParams{
+			DateRange: dateRange(c),
+			Limit:     c.Int("limit"),
+		})
+		if err != nil {
+			return fmt.Errorf("list card transactions: %w", err)
+		}
+
+		return render(c, transactionsTable(resp.Transactions))
+	})
+}
+
+func listCardBalanceHistoryAction(c *cli.Context) error {
+	cardID, err := cardIDArg(c)
+	if err != nil {
+		return err
+	}
+
+	return withCardService(c, func(cardSvc acme.CardService) error {
+		resp, err := cardSvc.ListCardBalanceHistory(c.Context, cardID, acme.ListCardBalanceHistoryParams{
+			DateRange: dateRange(c),
+			Limit:     c.Int("limit"),
+		})
+		if err != nil {
+			return fmt.Errorf("list card balance history: %w", err)
+		}
+
+		t := table{headers: []string{"id", "date", "type", "status", "amount", "currency"}}
+		for _, bc := range resp.BalanceChanges {
+			t.append(bc.ID, bc.Date.Format(dateTimeLayout), bc.Type, bc.Status, bc.Amount.Decimal(), bc.Amount.Currency())
+		}
+
+		return render(c, t)
+	})
+}
+
+func cardIDArg(c *cli.Context) (string, error) {
+	if c.NArg() != 1 {
+		return "", fmt.Errorf("expecting a card ID argument")
+	}
+
+	return c.Args().First(), nil
+}
+
+func cardsTable(cards ...acme.Card) table {
+	t := table{headers: []string{"id", "name", "last4", "status", "availableCredit", "currency", "email"}}
+	for _, card := range cards {
+		t.append(
+			card.ID, card.Name, card.Last4, card.Status,
+			card.AvailableCredit.Decimal(), card.AvailableCredit.Currency(), card.ContactInfo.Email,
+		)
+	}
+
+	return t
+}
+
+func transactionsTable(transactions []acme.Transaction) table {
+	t := table{headers: []string{
+		"id", "cardId", "date", "category", "status",
+		"amount", "currency", "transactionAmount", "transactionCurrency", "conversionRate",
+		"merchant", "merchantCountry", "mccCode", "mccCategory",
+	}}
+	for _, tx := range transactions {
+		t.append(
+			tx.ID, tx.CardID, tx.CreatedAt.Format(dateTimeLayout), tx.Category, tx.Status,
+			tx.Amount.Decimal(), tx.Amount.Currency(),
+			tx.TransactionAmount.Decimal(), tx.TransactionAmount.Currency(), tx.ConversionRate,
+			tx.Merchant.Name, tx.Merchant.Country, tx.Merchant.MCCCode, tx.Merchant.MCCCategory,
+		)
+	}
+
+	return t
+}
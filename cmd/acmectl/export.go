@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/acme/export"
+)
+
+func exportTransactionsAction(c *cli.Context) error {
+	return withReapCardService(c, func(cardSvc *acme.ReapCardService) error {
+		return exportTransactions(c, func(fn func(acme.Transaction) error) error {
+			return cardSvc.EachTransaction(c.Context, dateRange(c), fn)
+		})
+	})
+}
+
+func exportCardTransactionsAction(c *cli.Context) error {
+	cardID, err := cardIDArg(c)
+	if err != nil {
+		return err
+	}
+
+	return withReapCardService(c, func(cardSvc *acme.ReapCardService) error {
+		return exportTransactions(c, func(fn func(acme.Transaction) error) error {
+			return cardSvc.EachCardTransaction(c.Context, cardID, dateRange(c), fn)
+		})
+	})
+}
+
+// exportTransactions writes the transactions walked by each to the file,
+// the file is removed if the export fails.
+func exportTransactions(c *cli.Context, each func(fn func(acme.Transaction) error) error) (err error) {
+	columns, err := export.ParseTransactionColumns(c.String("columns"))
+	if err != nil {
+		return fmt.Errorf("parse columns: %w", err)
+	}
+
+	path := c.String("file")
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("create file: %w", err)
+	}
+	defer func() {
+		closeErr := f.Close()
+		if err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(path)
+		}
+	}()
+
+	tw, err := export.NewTransactionWriter(f, c.String("format"), columns)
+	if err != nil {
+		return fmt.Errorf("new transaction writer: %w", err)
+	}
+
+	var count int
+	err = each(func(t acme.Transaction) error {
+		count++
+		return tw.Write(t)
+	})
+	if err != nil {
+		return fmt.Errorf("export transactions: %w", err)
+	}
+
+	err = tw.Close()
+	if err != nil {
+		return fmt.Errorf("close transaction writer: %w", err)
+	}
+
+	fmt.Fprintf(c.App.ErrWriter, "exported %d transactions to %s\n", count, path)
+	return nil
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/acme/export"
+	"github.com/stevenferrer/acme-cards-api/reap"
+)
+
+const (
+	dateLayout     = "2006-01-02"
+	dateTimeLayout = "2006-01-02 15:04:05"
+)
+
+func dateRangeFlags() []cli.Flag {
+	return append(dateFlags(),
+		&cli.IntFlag{
+			Name:  "limit",
+			Usage: "maximum number of items",
+			Value: 10,
+		},
+	)
+}
+
+func dateFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.TimestampFlag{
+			Name:   "from",
+			Usage:  "start date (YYYY-MM-DD), defaults to today",
+			Layout: dateLayout,
+		},
+		&cli.TimestampFlag{
+			Name:   "to",
+			Usage:  "end date (YYYY-MM-DD)",
+			Layout: dateLayout,
+		},
+	}
+}
+
+func dateRange(c *cli.Context) acme.DateRange {
+	var r acme.DateRange
+	if from := c.Timestamp("from"); from != nil {
+		r.From = *from
+	}
+	if to := c.Timestamp("to"); to != nil {
+		r.To = *to
+	}
+
+	return r
+}
+
+func exportFlags() []cli.Flag {
+	return append(dateFlags(),
+		&cli.StringFlag{
+			Name:  "format",
+			Usage: "file format: csv or xlsx",
+			Value: export.FormatCSV,
+		},
+		&cli.StringFlag{
+			Name:  "columns",
+			Usage: "comma separated columns, defaults to all: " + strings.Join(export.TransactionColumns(), ","),
+		},
+		&cli.StringFlag{
+			Name:     "file",
+			Aliases:  []string{"f"},
+			Usage:    "output file",
+			Required: true,
+		},
+	)
+}
+
+func currencyFlag() cli.Flag {
+	return &cli.StringFlag{
+		Name:  "currency",
+		Usage: "ISO 4217 currency of the amount",
+		Value: reap.AccountCurrency,
+	}
+}
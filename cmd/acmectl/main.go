@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/acme/postgres"
+	"github.com/stevenferrer/acme-cards-api/acme/sqlite"
+	"github.com/stevenferrer/acme-cards-api/reap"
+	"github.com/stevenferrer/acme-cards-api/x/xsql"
+)
+
+func main() {
+	app := &cli.App{
+		Name:  "acmectl",
+		Usage: "acme cards admin tool",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "output",
+				Aliases: []string{"o"},
+				Usage:   "output format: table, json or csv",
+				Value:   outputTable,
+			},
+			&cli.StringFlag{
+				Name:    "dsn",
+				Usage:   "database connection string, postgres:// or sqlite://",
+				EnvVars: []string{"DATABASE_DSN", "POSTGRES_DSN"},
+			},
+			&cli.StringFlag{
+				Name:    "reap-api-key",
+				Usage:   "reap api key",
+				EnvVars: []string{"REAP_API_KEY"},
+			},
+			&cli.StringFlag{
+				Name:    "reap-sandbox-url",
+				Usage:   "reap sandbox url",
+				EnvVars: []string{"REAP_SANDBOX_URL"},
+			},
+		},
+		Before: func(c *cli.Context) error {
+			return validateOutputFormat(c.String("output"))
+		},
+		Commands: []*cli.Command{
+			accountCommand(),
+			cardsCommand(),
+		},
+	}
+
+	sort.Sort(cli.FlagsByName(app.Flags))
+	sort.Sort(cli.CommandsByName(app.Commands))
+
+	err := app.Run(os.Args)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// withCardService opens the database, builds the card service and
+// passes it to fn, the database is closed after fn returns.
+func withCardService(c *cli.Context, fn func(acme.CardService) error) error {
+	return withReapCardService(c, func(cardSvc *acme.ReapCardService) error {
+		return fn(cardSvc)
+	})
+}
+
+// withReapCardService is like withCardService for commands that need more
+// than acme.CardService.
+func withReapCardService(c *cli.Context, fn func(*acme.ReapCardService) error) error {
+	db, dialect, err := xsql.Open(c.String("dsn"))
+	if err != nil {
+		return fmt.Errorf("open db: %w", err)
+	}
+	defer db.Close()
+
+	err = db.PingContext(c.Context)
+	if err != nil {
+		return fmt.Errorf("ping db: %w", err)
+	}
+
+	reapClient := reap.NewClient(reap.ClientConfig{
+		APIKey:     c.String("reap-api-key"),
+		SandboxURL: c.String("reap-sandbox-url"),
+	})
+
+	var cardRepo acme.CardRepository = postgres.NewCardRepository(db)
+	var opts []acme.ReapCardServiceOption
+	if dialect == xsql.DialectSQLite {
+		cardRepo = sqlite.NewCardRepository(db)
+	} else {
+		// the top-ups are limited to the card group budgets like on the server
+		opts = append(opts, acme.WithCardGroupRepository(postgres.NewCardGroupRepository(db)))
+	}
+
+	cardSvc := acme.NewReapCardService(reapClient, cardRepo, opts...)
+	return fn(cardSvc)
+}
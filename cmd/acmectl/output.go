@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/urfave/cli/v2"
+)
+
+// Output formats
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputCSV   = "csv"
+)
+
+func validateOutputFormat(format string) error {
+	switch format {
+	case outputTable, outputJSON, outputCSV:
+		return nil
+	}
+
+	return fmt.Errorf("unsupported output format %q", format)
+}
+
+// table is the tabular representation of a command result
+type table struct {
+	headers []string
+	rows    [][]string
+}
+
+func (t *table) append(row ...string) {
+	t.rows = append(t.rows, row)
+}
+
+// records converts the rows into objects keyed by header
+func (t *table) records() []map[string]string {
+	records := make([]map[string]string, 0, len(t.rows))
+	for _, row := range t.rows {
+		record := make(map[string]string, len(t.headers))
+		for i, header := range t.headers {
+			record[header] = row[i]
+		}
+		records = append(records, record)
+	}
+
+	return records
+}
+
+// render writes t as a table, json or csv depending on the output flag
+func render(c *cli.Context, t table) error {
+	w := c.App.Writer
+
+	switch c.String("output") {
+	case outputJSON:
+		return renderJSON(w, t.records())
+	case outputCSV:
+		return renderCSV(w, t)
+	default:
+		return renderTable(w, t)
+	}
+}
+
+func renderJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	err := enc.Encode(v)
+	if err != nil {
+		return fmt.Errorf("json encode: %w", err)
+	}
+
+	return nil
+}
+
+func renderCSV(w io.Writer, t table) error {
+	cw := csv.NewWriter(w)
+
+	err := cw.Write(t.headers)
+	if err != nil {
+		return fmt.Errorf("write csv header: %w", err)
+	}
+
+	err = cw.WriteAll(t.rows)
+	if err != nil {
+		return fmt.Errorf("write csv rows: %w", err)
+	}
+
+	return nil
+}
+
+func renderTable(w io.Writer, t table) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, strings.ToUpper(strings.Join(t.headers, "\t")))
+	for _, row := range t.rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+
+	err := tw.Flush()
+	if err != nil {
+		return fmt.Errorf("flush table: %w", err)
+	}
+
+	return nil
+}
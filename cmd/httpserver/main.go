@@ -2,31 +2,102 @@ package main
 
 import (
 	"context"
-	"database/sql"
 	"log/slog"
 	"os"
 	"os/signal"
+	"strconv"
 	"time"
 
+	"github.com/stevenferrer/acme-cards-api/acme"
+	"github.com/stevenferrer/acme-cards-api/acme/smtp"
+	"github.com/stevenferrer/acme-cards-api/acme/sqlite"
 	"github.com/stevenferrer/acme-cards-api/httpserver"
+	"github.com/stevenferrer/acme-cards-api/reap"
+	"github.com/stevenferrer/acme-cards-api/x/xsql"
 )
 
 func main() {
 	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
 
-	db, err := sql.Open("postgres", os.Getenv("POSTGRES_DSN"))
+	dsn := os.Getenv("DATABASE_DSN")
+	if dsn == "" {
+		dsn = os.Getenv("POSTGRES_DSN")
+	}
+
+	db, dialect, err := xsql.Open(dsn)
 	if err != nil {
 		fatalError(logger, "sql open", err)
 	}
 	defer db.Close()
 
+	// sqlite is meant for single-binary local deployments,
+	// migrate on startup so no separate migration step is needed
+	if dialect == xsql.DialectSQLite {
+		if err := sqlite.Migrate(db); err != nil {
+			fatalError(logger, "sqlite migrate", err)
+		}
+	}
+
+	// the embedded notification templates are used unless overridden
+	var notificationRenderer acme.NotificationRenderer
+	if dir := os.Getenv("NOTIFICATION_TEMPLATES_DIR"); dir != "" {
+		notificationRenderer, err = acme.NewTemplateNotificationRenderer(os.DirFS(dir))
+		if err != nil {
+			fatalError(logger, "notification templates", err)
+		}
+	}
+
+	var cardExpiryNoticeDays int
+	if days := os.Getenv("CARD_EXPIRY_NOTICE_DAYS"); days != "" {
+		cardExpiryNoticeDays, err = strconv.Atoi(days)
+		if err != nil {
+			fatalError(logger, "card expiry notice days", err)
+		}
+	}
+
+	var spendRequestTwoPersonLimit acme.Money
+	if limit := os.Getenv("SPEND_REQUEST_TWO_PERSON_LIMIT"); limit != "" {
+		spendRequestTwoPersonLimit, err = acme.ParseMoney(limit, reap.AccountCurrency)
+		if err != nil {
+			fatalError(logger, "spend request two-person limit", err)
+		}
+	}
+
+	var cardApplicationExpiryDays int
+	if days := os.Getenv("CARD_APPLICATION_EXPIRY_DAYS"); days != "" {
+		cardApplicationExpiryDays, err = strconv.Atoi(days)
+		if err != nil {
+			fatalError(logger, "card application expiry days", err)
+		}
+	}
+
 	srvr := httpserver.New(httpserver.Config{
-		ReapAPIKey:    os.Getenv("REAP_API_KEY"),
-		ReapSandoxURL: os.Getenv("REAP_SANDBOX_URL"),
-		DB:            db,
-		Logger:        logger,
+		ReapAPIKey:                 os.Getenv("REAP_API_KEY"),
+		ReapSandoxURL:              os.Getenv("REAP_SANDBOX_URL"),
+		ReapWebhookSecret:          os.Getenv("REAP_WEBHOOK_SECRET"),
+		DB:                         db,
+		DSN:                        dsn,
+		Dialect:                    dialect,
+		Logger:                     logger,
+		Camt053AccountID:           os.Getenv("CAMT053_ACCOUNT_ID"),
+		Camt053Dir:                 os.Getenv("CAMT053_DIR"),
+		CardExpiryNoticeDays:       cardExpiryNoticeDays,
+		SpendRequestTwoPersonLimit: spendRequestTwoPersonLimit,
+		CardIssuanceApproval:       os.Getenv("CARD_ISSUANCE_APPROVAL") == "true",
+		CardApplicationExpiryDays:  cardApplicationExpiryDays,
+		SMTP: smtp.Config{
+			Addr:     os.Getenv("SMTP_ADDR"),
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     os.Getenv("SMTP_FROM"),
+		},
+		NotificationRenderer: notificationRenderer,
 	})
 
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+	srvr.StartWorkers(workersCtx)
+
 	// start server
 	go func() {
 		logger.Info("listening...", "addr", srvr.Addr)
@@ -44,6 +115,7 @@ func main() {
 	<-sigChan
 
 	logger.Info("shutting down...")
+	stopWorkers()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -2,9 +2,12 @@ package main
 
 import (
 	"database/sql"
+	"fmt"
 	"log"
 	"os"
 	"sort"
+	"strconv"
+	"text/tabwriter"
 	"time"
 
 	_ "github.com/lib/pq"
@@ -12,36 +15,129 @@ import (
 	"github.com/urfave/cli/v2"
 
 	"github.com/stevenferrer/acme-cards-api/acme/postgres"
+	"github.com/stevenferrer/acme-cards-api/x/xmigrate"
 )
 
 func main() {
 	app := &cli.App{
 		Name:  "migrate",
 		Usage: "db migration tool",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "dsn",
+				Usage: "postgres connection string",
+				// DSN is kept for backward compatibility
+				EnvVars: []string{"POSTGRES_DSN", "DSN"},
+			},
+		},
 		Commands: []*cli.Command{
 			{
 				Name:  "up",
 				Usage: "migrate up",
 				Action: func(c *cli.Context) error {
-					dsn := os.Getenv("DSN")
-
-					db, err := sql.Open("postgres", dsn)
+					return withDB(c, func(db *sql.DB) error {
+						return postgres.Migrate(db, migrator.WithLogger(newLogger()))
+					})
+				},
+			},
+			{
+				Name:      "down",
+				Usage:     "roll back the last N migrations",
+				ArgsUsage: "[N]",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "dry-run",
+						Usage: "print the sql without executing it",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					n, err := stepsArg(c)
 					if err != nil {
 						return err
 					}
-					defer db.Close()
 
-					err = db.Ping()
-					if err != nil {
-						return err
+					return withDB(c, func(db *sql.DB) error {
+						if c.Bool("dry-run") {
+							rollbacks, err := postgres.RollbackMigrations(db, n)
+							if err != nil {
+								return err
+							}
+
+							for _, m := range rollbacks {
+								fmt.Fprintf(c.App.Writer, "-- %s.down.sql\n%s\n", m, m.Down)
+							}
+							return nil
+						}
+
+						return postgres.Rollback(db, n, newLogger())
+					})
+				},
+			},
+			{
+				Name:  "status",
+				Usage: "show applied and pending migrations",
+				Action: func(c *cli.Context) error {
+					return withDB(c, func(db *sql.DB) error {
+						statuses, err := postgres.MigrationStatuses(db)
+						if err != nil {
+							return err
+						}
+
+						tw := tabwriter.NewWriter(c.App.Writer, 0, 0, 2, ' ', 0)
+						fmt.Fprintln(tw, "VERSION\tNAME\tSTATUS")
+						for _, s := range statuses {
+							status := "pending"
+							if s.Applied {
+								status = "applied"
+							}
+							fmt.Fprintf(tw, "%04d\t%s\t%s\n", s.Version, s.Name, status)
+						}
+
+						return tw.Flush()
+					})
+				},
+			},
+			{
+				Name:  "dry-run",
+				Usage: "print the sql of pending migrations without executing it",
+				Action: func(c *cli.Context) error {
+					return withDB(c, func(db *sql.DB) error {
+						pending, err := postgres.PendingMigrations(db)
+						if err != nil {
+							return err
+						}
+
+						for _, m := range pending {
+							fmt.Fprintf(c.App.Writer, "-- %s.up.sql\n%s\n", m, m.Up)
+						}
+						return nil
+					})
+				},
+			},
+			{
+				Name:      "create",
+				Usage:     "scaffold a new migration",
+				ArgsUsage: "<name>",
+				Flags: []cli.Flag{
+					&cli.PathFlag{
+						Name:  "dir",
+						Usage: "migrations directory",
+						Value: postgres.MigrationsDir,
+					},
+				},
+				Action: func(c *cli.Context) error {
+					if c.NArg() != 1 {
+						return fmt.Errorf("expecting a migration name argument")
 					}
 
-					l := log.New(os.Stdout, "", 0)
-					l.SetPrefix(time.Now().Format("2006-01-02 15:04:05") + " [migrate] ")
-					if err := postgres.Migrate(db, migrator.WithLogger(l)); err != nil {
+					paths, err := xmigrate.Create(c.Path("dir"), c.Args().First())
+					if err != nil {
 						return err
 					}
 
+					for _, path := range paths {
+						fmt.Fprintf(c.App.Writer, "created %s\n", path)
+					}
 					return nil
 				},
 			},
@@ -56,3 +152,37 @@ func main() {
 		log.Fatal(err)
 	}
 }
+
+func withDB(c *cli.Context, fn func(*sql.DB) error) error {
+	db, err := sql.Open("postgres", c.String("dsn"))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	err = db.Ping()
+	if err != nil {
+		return err
+	}
+
+	return fn(db)
+}
+
+func stepsArg(c *cli.Context) (int, error) {
+	if c.NArg() == 0 {
+		return 1, nil
+	}
+
+	n, err := strconv.Atoi(c.Args().First())
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("invalid number of migrations %q", c.Args().First())
+	}
+
+	return n, nil
+}
+
+func newLogger() *log.Logger {
+	l := log.New(os.Stdout, "", 0)
+	l.SetPrefix(time.Now().Format("2006-01-02 15:04:05") + " [migrate] ")
+	return l
+}
@@ -0,0 +1,49 @@
+package httpserver
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/stevenferrer/acme-cards-api/acme"
+)
+
+// logAlerter writes the alerts to the server log
+type logAlerter struct {
+	logger *slog.Logger
+}
+
+var _ acme.Alerter = (*logAlerter)(nil)
+
+func (a *logAlerter) AlertMerchantControlViolation(ctx context.Context, v acme.MerchantControlViolation) error {
+	a.logger.WarnContext(ctx, "merchant control violation",
+		"card_id", v.CardID,
+		"transaction_id", v.TransactionID,
+		"reason", v.Reason,
+		"merchant", v.MerchantName,
+		"amount", v.Amount.String(),
+		"card_frozen", v.CardFrozen,
+	)
+
+	return nil
+}
+
+func (a *logAlerter) AlertFraudDecision(ctx context.Context, d acme.FraudDecision) error {
+	a.logger.WarnContext(ctx, "fraud rule fired",
+		"rule", d.RuleName,
+		"card_id", d.CardID,
+		"transaction_id", d.TransactionID,
+		"reason", d.Reason,
+	)
+
+	return nil
+}
+
+func (a *logAlerter) AlertLowBalance(ctx context.Context, e acme.BalanceRuleExecution) error {
+	a.logger.WarnContext(ctx, "card balance is low",
+		"card_id", e.CardID,
+		"rule_id", e.RuleID,
+		"available_credit", e.AvailableCredit.String(),
+	)
+
+	return nil
+}
@@ -0,0 +1,59 @@
+package httpserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/stevenferrer/acme-cards-api/acme/export"
+)
+
+// writeYesterdayCamt053 writes the statement of the previous day to dir
+// unless it already exists, the file is renamed into place once complete.
+func writeYesterdayCamt053(generator *export.Camt053Generator, dir string) func(context.Context) error {
+	return func(ctx context.Context) error {
+		date := time.Now().UTC().AddDate(0, 0, -1)
+		path := filepath.Join(dir, fmt.Sprintf("camt053-%s.xml", date.Format(time.DateOnly)))
+
+		_, err := os.Stat(path)
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("stat statement: %w", err)
+		}
+
+		statement, err := generator.GenerateAccountStatement(ctx, date)
+		if err != nil {
+			return fmt.Errorf("generate account statement: %w", err)
+		}
+
+		f, err := os.CreateTemp(dir, ".camt053-*.xml")
+		if err != nil {
+			return fmt.Errorf("create temp file: %w", err)
+		}
+		defer os.Remove(f.Name())
+
+		err = export.WriteCamt053(f, *statement)
+		if err != nil {
+			f.Close()
+			return fmt.Errorf("write camt053: %w", err)
+		}
+
+		err = f.Close()
+		if err != nil {
+			return fmt.Errorf("close temp file: %w", err)
+		}
+
+		err = os.Rename(f.Name(), path)
+		if err != nil {
+			return fmt.Errorf("rename statement: %w", err)
+		}
+
+		return nil
+	}
+}
@@ -1,6 +1,7 @@
 package httpserver
 
 import (
+	"context"
 	"database/sql"
 	"log/slog"
 	"net/http"
@@ -12,35 +13,332 @@ import (
 
 	"github.com/stevenferrer/acme-cards-api/acme"
 	"github.com/stevenferrer/acme-cards-api/acme/acmehttp"
+	"github.com/stevenferrer/acme-cards-api/acme/export"
 	"github.com/stevenferrer/acme-cards-api/acme/postgres"
+	"github.com/stevenferrer/acme-cards-api/acme/smtp"
+	"github.com/stevenferrer/acme-cards-api/acme/sqlite"
 	"github.com/stevenferrer/acme-cards-api/reap"
+	"github.com/stevenferrer/acme-cards-api/x/xsql"
+)
+
+const (
+	// webhookDeliveryInterval is how often the due webhook deliveries are sent
+	webhookDeliveryInterval = 10 * time.Second
+	webhookTimeout          = 10 * time.Second
+
+	// eventHubSize is the number of events a stream can resume from
+	eventHubSize = 1000
+	// streamEventRetention keeps the published events to dedupe them
+	streamEventRetention = 24 * time.Hour
+	// streamListenerRestart is the delay before a failed listener restarts
+	streamListenerRestart = 5 * time.Second
+
+	// notificationInterval is how often the due notifications are sent
+	notificationInterval = 30 * time.Second
+	// allowanceInterval is how often the due allowances are run, the
+	// cadences have a minute resolution
+	allowanceInterval = time.Minute
+	// defaultSpendRequestTwoPersonLimit is the amount above which two
+	// approvers must approve a spend request
+	defaultSpendRequestTwoPersonLimit = "1000.00"
+
+	// cardExpiryInterval is how often the card expiries are checked
+	cardExpiryInterval = time.Minute
+	// cardApplicationExpiryInterval is how often the stale card
+	// applications are expired
+	cardApplicationExpiryInterval = time.Hour
+	// cardApplicationRecoveryInterval is how often the cards of the
+	// applications stuck approved are issued
+	cardApplicationRecoveryInterval = time.Minute
+	// spendRequestRecoveryInterval is how often the spend requests stuck
+	// approved or funding are recovered
+	spendRequestRecoveryInterval = time.Minute
 )
 
 type Config struct {
 	ReapAPIKey    string
 	ReapSandoxURL string
-	DB            *sql.DB
-	Logger        *slog.Logger
+	// ReapWebhookSecret verifies the Reap webhook signatures, the webhook is
+	// not mounted without it
+	ReapWebhookSecret string
+	DB                *sql.DB
+	// DSN is the postgres connection string of DB, the event stream
+	// listener opens its own connection with it
+	DSN string
+	// Dialect selects the repository implementation, defaults to postgres
+	Dialect xsql.Dialect
+	Logger  *slog.Logger
+
+	// CardSnapshotSyncInterval defaults to 5 minutes
+	CardSnapshotSyncInterval time.Duration
+	// CardExpiryNoticeDays is how many days before its expiry the card
+	// owner is notified, defaults to 7
+	CardExpiryNoticeDays int
+	// SpendRequestTwoPersonLimit defaults to 1000.00 USD
+	SpendRequestTwoPersonLimit acme.Money
+
+	// CardIssuanceApproval turns the card creations into card applications
+	// that a different user must approve (postgres only)
+	CardIssuanceApproval bool
+	// CardApplicationExpiryDays is how long an application stays pending,
+	// defaults to 7
+	CardApplicationExpiryDays int
+
+	// Camt053AccountID identifies the Reap account in the camt.053
+	// statements, defaults to REAP
+	Camt053AccountID string
+	// Camt053Dir receives the statement of the previous day when set
+	Camt053Dir string
+
+	// SMTP sends the cardholder notifications when its Addr is set
+	SMTP smtp.Config
+	// NotificationRenderer defaults to the embedded notification templates
+	NotificationRenderer acme.NotificationRenderer
+}
+
+// Server is the http server and its background workers
+type Server struct {
+	*http.Server
+
+	logger  *slog.Logger
+	workers []worker
 }
 
-func New(cfg Config) *http.Server {
+func New(cfg Config) *Server {
 	logger := cfg.Logger
 	if logger == nil {
 		logger = slog.Default()
 	}
 
-	var cardHTTPHandler, accountHTTPHandler http.Handler
+	syncInterval := cfg.CardSnapshotSyncInterval
+	if syncInterval <= 0 {
+		syncInterval = 5 * time.Minute
+	}
+
+	var workers []worker
+	var cardHTTPHandler, accountHTTPHandler, analyticsHTTPHandler, reapWebhookHTTPHandler http.Handler
+	// journal and merchant control handlers are only available on postgres
+	var journalHTTPHandler, merchantControlHTTPHandler, fraudHTTPHandler, webhookHTTPHandler, eventStreamHTTPHandler, notificationHTTPHandler, balanceRuleHTTPHandler, allowanceHTTPHandler, burnerCardHTTPHandler, spendRequestHTTPHandler, cardApplicationHTTPHandler, cardGroupHTTPHandler http.Handler
 	{
-		cardRepo := postgres.NewCardRepository(cfg.DB)
+		cardRepo, snapshotRepo := newCardRepositories(cfg.DB, cfg.Dialect)
 
 		reapClient := reap.NewClient(reap.ClientConfig{
 			APIKey:     cfg.ReapAPIKey,
 			SandboxURL: cfg.ReapSandoxURL,
 		})
 
-		cardSvc := acme.NewReapCardService(reapClient, cardRepo)
-		cardHTTPHandler = acmehttp.NewHTTPHandler(cardSvc)
-		accountHTTPHandler = acmehttp.NewAccountHTTPHandler(cardSvc)
+		cardSvcOpts := []acme.ReapCardServiceOption{
+			acme.WithCardSnapshotRepository(snapshotRepo),
+			acme.WithLogger(logger),
+		}
+		var notificationRepo acme.NotificationRepository
+		var burnerRepo acme.BurnerCardRepository
+		var groupRepo acme.CardGroupRepository
+		if cfg.CardExpiryNoticeDays > 0 {
+			cardSvcOpts = append(cardSvcOpts, acme.WithCardExpiryNotice(cfg.CardExpiryNoticeDays))
+		}
+
+		// webhooks and event streams are only available on postgres
+		if cfg.Dialect != xsql.DialectSQLite {
+			webhookSvc := acme.NewHTTPWebhookService(
+				postgres.NewWebhookRepository(cfg.DB),
+				&http.Client{Timeout: webhookTimeout},
+			)
+			webhookHTTPHandler = acmehttp.NewWebhookHTTPHandler(webhookSvc)
+			cardSvcOpts = append(cardSvcOpts, acme.WithEventPublisher(webhookSvc))
+			workers = append(workers, worker{
+				name:     "webhook delivery",
+				interval: webhookDeliveryInterval,
+				run:      webhookSvc.DeliverWebhooks,
+			})
+
+			// the instances fan out the events with postgres notifications
+			streamRepo := postgres.NewStreamEventRepository(cfg.DB)
+			eventHub := acme.NewEventHub(eventHubSize)
+			eventStreamHTTPHandler = acmehttp.NewEventStreamHTTPHandler(eventHub)
+			cardSvcOpts = append(cardSvcOpts, acme.WithEventPublisher(acme.NewEventStreamPublisher(streamRepo)))
+			workers = append(workers, worker{
+				name:     "event stream listener",
+				interval: streamListenerRestart,
+				run:      postgres.NewStreamEventListener(cfg.DSN, streamRepo, eventHub).Listen,
+			}, worker{
+				name:     "stream event pruning",
+				interval: time.Hour,
+				run: func(ctx context.Context) error {
+					return streamRepo.DeleteStreamEventsBefore(ctx, time.Now().UTC().Add(-streamEventRetention))
+				},
+			})
+
+			// the notifications are only recorded when they can be sent
+			notificationRepo = postgres.NewNotificationRepository(cfg.DB)
+			if cfg.SMTP.Addr != "" {
+				cardSvcOpts = append(cardSvcOpts, acme.WithEventPublisher(acme.NewNotificationPublisher(notificationRepo)))
+			}
+
+			burnerRepo = postgres.NewBurnerCardRepository(cfg.DB)
+			cardSvcOpts = append(cardSvcOpts, acme.WithBurnerCardRepository(burnerRepo))
+
+			// the top-ups of the member cards are limited to the group budgets
+			groupRepo = postgres.NewCardGroupRepository(cfg.DB)
+			cardSvcOpts = append(cardSvcOpts, acme.WithCardGroupRepository(groupRepo))
+		}
+
+		cardSvc := acme.NewReapCardService(reapClient, cardRepo, cardSvcOpts...)
+		camt053AccountID := cfg.Camt053AccountID
+		if camt053AccountID == "" {
+			camt053AccountID = "REAP"
+		}
+		camt053Generator := export.NewCamt053Generator(cardSvc, cardSvc, cardSvc, camt053AccountID)
+
+		// monthly statements are only saved on postgres
+		var statementRepo export.MonthlyStatementRepository
+		if cfg.Dialect != xsql.DialectSQLite {
+			statementRepo = postgres.NewMonthlyStatementRepository(cfg.DB)
+		}
+
+		// card creations only need an approval when enabled, the
+		// applications can be reviewed either way
+		var appSvc acme.CardApplicationService
+		if cfg.Dialect != xsql.DialectSQLite {
+			applicationSvc := acme.NewMakerCheckerCardApplicationService(
+				cardSvc,
+				postgres.NewCardApplicationRepository(cfg.DB),
+				cfg.CardApplicationExpiryDays,
+			)
+			cardApplicationHTTPHandler = acmehttp.NewCardApplicationHTTPHandler(applicationSvc)
+			workers = append(workers, worker{
+				name:     "card application expiry",
+				interval: cardApplicationExpiryInterval,
+				run:      applicationSvc.ExpireCardApplications,
+			}, worker{
+				name:     "card application recovery",
+				interval: cardApplicationRecoveryInterval,
+				run:      applicationSvc.RecoverCardApplications,
+			})
+			if cfg.CardIssuanceApproval {
+				appSvc = applicationSvc
+			}
+		}
+
+		cardHTTPHandler = acmehttp.NewHTTPHandler(cardSvc, cardSvc, cardSvc, statementRepo, appSvc)
+		accountHTTPHandler = acmehttp.NewAccountHTTPHandler(cardSvc, cardSvc, camt053Generator)
+		if cfg.ReapWebhookSecret != "" {
+			reapWebhookHTTPHandler = acmehttp.NewReapWebhookHTTPHandler(cardSvc, cfg.ReapWebhookSecret)
+		} else {
+			logger.Warn("the reap webhook is disabled, set REAP_WEBHOOK_SECRET to enable it")
+		}
+
+		analyticsSvc := acme.NewTransactionSpendAnalyticsService(cardSvc, cardSvc)
+		analyticsHTTPHandler = acmehttp.NewAnalyticsHTTPHandler(analyticsSvc)
+
+		if cfg.Dialect != xsql.DialectSQLite {
+			journalSvc := acme.NewTransactionJournalService(cardSvc, postgres.NewJournalRepository(cfg.DB))
+			journalHTTPHandler = acmehttp.NewJournalHTTPHandler(journalSvc)
+
+			controlSvc := acme.NewTransactionMerchantControlService(
+				cardSvc, cardSvc,
+				postgres.NewMerchantControlRepository(cfg.DB),
+				groupRepo,
+				&logAlerter{logger: logger},
+			)
+			merchantControlHTTPHandler = acmehttp.NewMerchantControlHTTPHandler(controlSvc)
+			workers = append(workers, worker{
+				name:     "merchant control enforcement",
+				interval: syncInterval,
+				run:      controlSvc.EnforceMerchantControls,
+			})
+
+			burnerSvc := acme.NewTransactionBurnerCardService(cardSvc, cardSvc, burnerRepo)
+			burnerCardHTTPHandler = acmehttp.NewBurnerCardHTTPHandler(burnerSvc)
+			workers = append(workers, worker{
+				name:     "burner card enforcement",
+				interval: syncInterval,
+				run:      burnerSvc.EnforceBurnerCards,
+			})
+
+			fraudSvc := acme.NewTransactionFraudService(
+				cardSvc, cardSvc,
+				postgres.NewFraudRepository(cfg.DB),
+				&logAlerter{logger: logger},
+			)
+			fraudHTTPHandler = acmehttp.NewFraudHTTPHandler(fraudSvc)
+			workers = append(workers, worker{
+				name:     "fraud rules",
+				interval: syncInterval,
+				run:      fraudSvc.EvaluateTransactions,
+			}, worker{
+				name:     "transaction events",
+				interval: syncInterval,
+				run:      cardSvc.PublishTransactionEvents,
+			})
+
+			balanceRuleSvc := acme.NewCardBalanceRuleService(
+				cardSvc,
+				postgres.NewBalanceRuleRepository(cfg.DB),
+				&logAlerter{logger: logger},
+			)
+			balanceRuleHTTPHandler = acmehttp.NewBalanceRuleHTTPHandler(balanceRuleSvc)
+			workers = append(workers, worker{
+				name:     "balance rules",
+				interval: syncInterval,
+				run:      balanceRuleSvc.EvaluateBalanceRules,
+			})
+
+			allowanceSvc := acme.NewCardAllowanceService(cardSvc, cardSvc, postgres.NewAllowanceRepository(cfg.DB))
+			allowanceHTTPHandler = acmehttp.NewAllowanceHTTPHandler(allowanceSvc)
+			workers = append(workers, worker{
+				name:     "allowances",
+				interval: allowanceInterval,
+				run:      allowanceSvc.RunAllowances,
+			})
+
+			twoPersonLimit := cfg.SpendRequestTwoPersonLimit
+			if twoPersonLimit.IsZero() {
+				twoPersonLimit = acme.MustParseMoney(defaultSpendRequestTwoPersonLimit, reap.AccountCurrency)
+			}
+			spendRequestSvc := acme.NewCardSpendRequestService(cardSvc, cardSvc, postgres.NewSpendRequestRepository(cfg.DB), twoPersonLimit)
+			spendRequestHTTPHandler = acmehttp.NewSpendRequestHTTPHandler(spendRequestSvc)
+			workers = append(workers, worker{
+				name:     "spend request funding recovery",
+				interval: spendRequestRecoveryInterval,
+				run:      spendRequestSvc.RecoverSpendRequestFunding,
+			})
+
+			groupSvc := acme.NewTransactionCardGroupService(cardSvc, cardSvc, groupRepo)
+			cardGroupHTTPHandler = acmehttp.NewCardGroupHTTPHandler(groupSvc)
+
+			renderer := cfg.NotificationRenderer
+			if renderer == nil {
+				renderer = mustDefaultNotificationRenderer()
+			}
+			notificationSvc := acme.NewCardNotificationService(cardSvc, notificationRepo, renderer, smtp.NewNotifier(cfg.SMTP))
+			notificationHTTPHandler = acmehttp.NewNotificationHTTPHandler(notificationSvc)
+			if cfg.SMTP.Addr != "" {
+				workers = append(workers, worker{
+					name:     "notification delivery",
+					interval: notificationInterval,
+					run:      notificationSvc.SendNotifications,
+				})
+			}
+		}
+
+		workers = append(workers, worker{
+			name:     "card snapshot sync",
+			interval: syncInterval,
+			run:      cardSvc.SyncCardSnapshots,
+		}, worker{
+			name:     "card expiry",
+			interval: cardExpiryInterval,
+			run:      cardSvc.ExpireCards,
+		})
+
+		if cfg.Camt053Dir != "" {
+			workers = append(workers, worker{
+				name:     "camt053 statement",
+				interval: time.Hour,
+				run:      writeYesterdayCamt053(camt053Generator, cfg.Camt053Dir),
+			})
+		}
 	}
 
 	mux := chi.NewMux()
@@ -52,12 +350,82 @@ func New(cfg Config) *http.Server {
 
 	mux.Mount("/account", accountHTTPHandler)
 	mux.Mount("/cards", cardHTTPHandler)
+	mux.Mount("/analytics", analyticsHTTPHandler)
+	if reapWebhookHTTPHandler != nil {
+		mux.Mount("/reap/webhooks", reapWebhookHTTPHandler)
+	}
+	if journalHTTPHandler != nil {
+		mux.Mount("/journal", journalHTTPHandler)
+	}
+	if merchantControlHTTPHandler != nil {
+		mux.Mount("/merchant-controls", merchantControlHTTPHandler)
+	}
+	if burnerCardHTTPHandler != nil {
+		mux.Mount("/burner-cards", burnerCardHTTPHandler)
+	}
+	if fraudHTTPHandler != nil {
+		mux.Mount("/fraud", fraudHTTPHandler)
+	}
+	if webhookHTTPHandler != nil {
+		mux.Mount("/webhook-subscriptions", webhookHTTPHandler)
+	}
+	if eventStreamHTTPHandler != nil {
+		mux.Mount("/events", eventStreamHTTPHandler)
+	}
+	if balanceRuleHTTPHandler != nil {
+		mux.Mount("/balance-rules", balanceRuleHTTPHandler)
+	}
+	if notificationHTTPHandler != nil {
+		mux.Mount("/notifications", notificationHTTPHandler)
+	}
+	if allowanceHTTPHandler != nil {
+		mux.Mount("/allowance-schedules", allowanceHTTPHandler)
+	}
+	if cardApplicationHTTPHandler != nil {
+		mux.Mount("/card-applications", cardApplicationHTTPHandler)
+	}
+	if spendRequestHTTPHandler != nil {
+		mux.Mount("/spend-requests", spendRequestHTTPHandler)
+	}
+	if cardGroupHTTPHandler != nil {
+		mux.Mount("/card-groups", cardGroupHTTPHandler)
+	}
 
-	return &http.Server{
-		Addr:           ":9000",
-		Handler:        mux,
-		ReadTimeout:    10 * time.Second,
-		WriteTimeout:   10 * time.Second,
-		MaxHeaderBytes: 1 << 20,
+	return &Server{
+		Server: &http.Server{
+			Addr:           ":9000",
+			Handler:        mux,
+			ReadTimeout:    10 * time.Second,
+			WriteTimeout:   10 * time.Second,
+			MaxHeaderBytes: 1 << 20,
+		},
+		logger:  logger,
+		workers: workers,
 	}
 }
+
+// StartWorkers starts the background workers, they stop when ctx is done.
+func (s *Server) StartWorkers(ctx context.Context) {
+	for _, wk := range s.workers {
+		go wk.runEvery(ctx, s.logger)
+	}
+}
+
+func newCardRepositories(db *sql.DB, dialect xsql.Dialect) (acme.CardRepository, acme.CardSnapshotRepository) {
+	if dialect == xsql.DialectSQLite {
+		return sqlite.NewCardRepository(db), sqlite.NewCardSnapshotRepository(db)
+	}
+
+	return postgres.NewCardRepository(db), postgres.NewCardSnapshotRepository(db)
+}
+
+// mustDefaultNotificationRenderer parses the embedded notification templates,
+// they are covered by the acme tests so it only panics on a broken build
+func mustDefaultNotificationRenderer() acme.NotificationRenderer {
+	renderer, err := acme.NewTemplateNotificationRenderer(acme.DefaultNotificationTemplates())
+	if err != nil {
+		panic(err)
+	}
+
+	return renderer
+}
@@ -0,0 +1,34 @@
+package httpserver
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// worker is a background job run periodically while the server is running
+type worker struct {
+	name     string
+	interval time.Duration
+	run      func(context.Context) error
+}
+
+// runEvery runs the worker immediately and then on every interval until
+// ctx is done, errors are logged and do not stop the worker.
+func (wk worker) runEvery(ctx context.Context, logger *slog.Logger) {
+	ticker := time.NewTicker(wk.interval)
+	defer ticker.Stop()
+
+	for {
+		err := wk.run(ctx)
+		if err != nil && ctx.Err() == nil {
+			logger.Error("worker error", "worker", wk.name, "err", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
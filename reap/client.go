@@ -2,6 +2,7 @@ package reap
 
 import (
 	"context"
+	"encoding/json"
 	"time"
 )
 
@@ -25,6 +26,11 @@ type Client interface {
 		GetCardParams,
 	) (*GetCardResponse, error)
 
+	UpdateCardStatus(
+		context.Context,
+		UpdateCardStatusParams,
+	) (*UpdateCardStatusResponse, error)
+
 	AdjustCardBalance(
 		context.Context,
 		AdjustCardBalanceParams,
@@ -48,18 +54,21 @@ type Client interface {
 
 // TODO: Define separate request/response for http calls
 
+// Amounts sent as json numbers are kept as json.Number, decoding them
+// into float64 would lose precision.
+
 type GetAccountBalanceResponse struct {
-	AvailableBalance    float64 `json:"availableBalance"`
-	AvailableToAllocate float64 `json:"availableToAllocate"`
+	AvailableBalance    json.Number `json:"availableBalance"`
+	AvailableToAllocate json.Number `json:"availableToAllocate"`
 }
 
 type CreateCardParams struct {
-	CardType          string  `json:"cardType"`
-	SpendLimit        float64 `json:"spendLimit"`
-	CustomerType      string  `json:"customerType"`
-	KYC               KYC     `json:"kyc"`
-	PreferredCardName string  `json:"preferredCardName"`
-	Meta              Meta    `json:"meta"`
+	CardType          string      `json:"cardType"`
+	SpendLimit        json.Number `json:"spendLimit"`
+	CustomerType      string      `json:"customerType"`
+	KYC               KYC         `json:"kyc"`
+	PreferredCardName string      `json:"preferredCardName"`
+	Meta              Meta        `json:"meta"`
 }
 type CreateCardResponse struct {
 	CardID string `json:"id"`
@@ -75,19 +84,52 @@ type GetCardResponse struct {
 type GetCardsParams struct {
 	Status      string
 	MetadataIDs []string
+	Page        int
+	Limit       int
 }
 type GetCardsResponse struct {
 	Items []Card     `json:"items"`
 	Meta  Pagination `json:"meta"`
 }
 
-type AdjustCardBalanceParams struct{}
-type AdjustCardBalanceResponse struct{}
+// Card statuses
+const (
+	CardStatusActive     = "ACTIVE"
+	CardStatusFrozen     = "FROZEN"
+	CardStatusTerminated = "TERMINATED"
+)
+
+type UpdateCardStatusParams struct {
+	CardID string `json:"-"`
+	Status string `json:"status"`
+}
+type UpdateCardStatusResponse struct {
+	Status string `json:"status"`
+}
+
+// Balance adjustment types
+const (
+	BalanceAdjustmentTopUp    = "TOPUP"
+	BalanceAdjustmentWithdraw = "WITHDRAW"
+)
+
+type AdjustCardBalanceParams struct {
+	CardID string      `json:"-"`
+	Type   string      `json:"type"`
+	Amount json.Number `json:"amount"`
+}
+type AdjustCardBalanceResponse struct {
+	ID              string `json:"id"`
+	AvailableCredit string `json:"availableCredit"`
+}
 
 type GetCardBalanceHistoryParams struct {
 	CardID   string
 	FromDate string
+	ToDate   string
 	Limit    int
+	// Page starts at 1, zero means the first page
+	Page int
 }
 type GetCardBalanceHistoryResponse struct {
 	BalanceChanges []BalanceChange `json:"items"`
@@ -97,7 +139,10 @@ type GetCardBalanceHistoryResponse struct {
 type GetCardTransactionsParams struct {
 	CardID   string
 	FromDate string
+	ToDate   string
 	Limit    int
+	// Page starts at 1, zero means the first page
+	Page int
 }
 type GetCardTransactionsResponse struct {
 	Transactions []Transaction `json:"items"`
@@ -106,7 +151,10 @@ type GetCardTransactionsResponse struct {
 
 type GetAllTransactionsParams struct {
 	FromDate string
+	ToDate   string
 	Limit    int
+	// Page starts at 1, zero means the first page
+	Page int
 }
 type GetAllTransactionsResponse struct {
 	Transactions []Transaction `json:"items"`
@@ -120,6 +168,8 @@ type Error struct {
 }
 
 type Card struct {
+	// ID is the Reap card ID, it is only returned when listing the cards
+	ID                 string       `json:"id"`
 	CardName           string       `json:"cardName"`
 	SecondaryCardName  string       `json:"secondaryCardName"`
 	Last4              string       `json:"last4"`
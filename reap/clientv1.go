@@ -150,6 +150,12 @@ func (c *ClientV1) GetCards(ctx context.Context, params GetCardsParams) (*GetCar
 	if len(params.MetadataIDs) > 0 {
 		q.Add("metadataId", strings.Join(params.MetadataIDs, ","))
 	}
+	if params.Page > 0 {
+		q.Add("page", strconv.Itoa(params.Page))
+	}
+	if params.Limit > 0 {
+		q.Add("limit", strconv.Itoa(params.Limit))
+	}
 
 	req, err := c.newRequest(ctx, http.MethodGet, "cards", q, nil)
 	if err != nil {
@@ -183,10 +189,14 @@ func (c *ClientV1) GetCards(ctx context.Context, params GetCardsParams) (*GetCar
 func (c *ClientV1) GetCardTransactions(ctx context.Context, params GetCardTransactionsParams) (*GetCardTransactionsResponse, error) {
 	requestBody := struct {
 		FromDate string `json:"fromDate"`
+		ToDate   string `json:"toDate,omitempty"`
 		Limit    int    `json:"limit"`
+		Page     int    `json:"page,omitempty"`
 	}{
 		FromDate: params.FromDate,
+		ToDate:   params.ToDate,
 		Limit:    params.Limit,
+		Page:     params.Page,
 	}
 
 	buf := &bytes.Buffer{}
@@ -229,10 +239,14 @@ func (c *ClientV1) GetAllTransactions(ctx context.Context, params GetAllTransact
 
 	requestBody := struct {
 		FromDate string `json:"fromDate"`
+		ToDate   string `json:"toDate,omitempty"`
 		Limit    int    `json:"limit"`
+		Page     int    `json:"page,omitempty"`
 	}{
 		FromDate: params.FromDate,
+		ToDate:   params.ToDate,
 		Limit:    params.Limit,
+		Page:     params.Page,
 	}
 
 	buf := &bytes.Buffer{}
@@ -280,11 +294,17 @@ func mustHaveStatusOrError(expectStatus int, gotStatus int, e Error) error {
 func (c *ClientV1) GetCardBalanceHistory(ctx context.Context, params GetCardBalanceHistoryParams) (*GetCardBalanceHistoryResponse, error) {
 	requestBody := struct {
 		FromDate string `json:"fromDate"`
+		ToDate   string `json:"toDate,omitempty"`
 		Limit    string `json:"limit"`
+		Page     string `json:"page,omitempty"`
 	}{
 		FromDate: params.FromDate,
+		ToDate:   params.ToDate,
 		Limit:    strconv.Itoa(params.Limit),
 	}
+	if params.Page > 0 {
+		requestBody.Page = strconv.Itoa(params.Page)
+	}
 
 	buf := &bytes.Buffer{}
 	err := json.NewEncoder(buf).Encode(requestBody)
@@ -321,9 +341,76 @@ func (c *ClientV1) GetCardBalanceHistory(ctx context.Context, params GetCardBala
 	return &body.GetCardBalanceHistoryResponse, nil
 }
 
+// UpdateCardStatus implements Client.
+func (c *ClientV1) UpdateCardStatus(ctx context.Context, params UpdateCardStatusParams) (*UpdateCardStatusResponse, error) {
+	buf := &bytes.Buffer{}
+	err := json.NewEncoder(buf).Encode(params)
+	if err != nil {
+		return nil, fmt.Errorf("encode request: %w", err)
+	}
+
+	path := fmt.Sprintf("cards/%s/status", params.CardID)
+	req, err := c.newRequest(ctx, http.MethodPut, path, nil, buf)
+	if err != nil {
+		return nil, fmt.Errorf("new request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		UpdateCardStatusResponse
+		Error
+	}
+	err = json.NewDecoder(resp.Body).Decode(&body)
+	if err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+
+	if err := mustHaveStatusOrError(http.StatusOK, resp.StatusCode, body.Error); err != nil {
+		return nil, err
+	}
+
+	return &body.UpdateCardStatusResponse, nil
+}
+
 // AdjustCardBalance implements Client.
-func (c *ClientV1) AdjustCardBalance(context.Context, AdjustCardBalanceParams) (*AdjustCardBalanceResponse, error) {
-	panic("unimplemented")
+func (c *ClientV1) AdjustCardBalance(ctx context.Context, params AdjustCardBalanceParams) (*AdjustCardBalanceResponse, error) {
+	buf := &bytes.Buffer{}
+	err := json.NewEncoder(buf).Encode(params)
+	if err != nil {
+		return nil, fmt.Errorf("encode request: %w", err)
+	}
+
+	path := fmt.Sprintf("cards/%s/balance", params.CardID)
+	req, err := c.newRequest(ctx, http.MethodPost, path, nil, buf)
+	if err != nil {
+		return nil, fmt.Errorf("new request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		AdjustCardBalanceResponse
+		Error
+	}
+	err = json.NewDecoder(resp.Body).Decode(&body)
+	if err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+
+	if err := mustHaveStatusOrError(http.StatusCreated, resp.StatusCode, body.Error); err != nil {
+		return nil, err
+	}
+
+	return &body.AdjustCardBalanceResponse, nil
 }
 
 func (c *ClientV1) newRequest(
@@ -37,7 +37,7 @@ func TestClientV1(t *testing.T) {
 		CardType:          "Virtual",
 		CustomerType:      "Consumer",
 		PreferredCardName: "Hua Liang",
-		SpendLimit:        5000,
+		SpendLimit:        "5000",
 		KYC: reap.KYC{
 			reap.ConsumerInfo{
 				FirstName: "Hua",
@@ -177,8 +177,10 @@ func TestClientV1(t *testing.T) {
 		})
 		require.NoError(t, err)
 
+		card := chinZengCard
+		card.ID = "22e6338b-9e45-4b34-85db-4035a38fa46f"
 		expect := &reap.GetCardsResponse{
-			Items: []reap.Card{chinZengCard},
+			Items: []reap.Card{card},
 			Meta: reap.Pagination{
 				TotalItems:   1,
 				ItemCount:    1,
@@ -189,6 +191,73 @@ func TestClientV1(t *testing.T) {
 		}
 		assert.Equal(t, expect, resp)
 	})
+
+	t.Run("Update card status ok", func(t *testing.T) {
+		cardID := "1234"
+		httpmock.RegisterResponder(
+			http.MethodPut,
+			fmt.Sprintf("%s/cards/%s/status", sandboxURL, cardID),
+			newResponderWithStatus(
+				t, http.StatusOK,
+				"update_card_status_request.json",
+				"update_card_status_response.json",
+			),
+		)
+
+		resp, err := client.UpdateCardStatus(context.TODO(), reap.UpdateCardStatusParams{
+			CardID: cardID,
+			Status: reap.CardStatusFrozen,
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, &reap.UpdateCardStatusResponse{Status: reap.CardStatusFrozen}, resp)
+	})
+
+	t.Run("Adjust card balance ok", func(t *testing.T) {
+		cardID := "1234"
+		httpmock.RegisterResponder(
+			http.MethodPost,
+			fmt.Sprintf("%s/cards/%s/balance", sandboxURL, cardID),
+			newResponderWithStatus(
+				t, http.StatusCreated,
+				"adjust_card_balance_request.json",
+				"adjust_card_balance_response.json",
+			),
+		)
+
+		resp, err := client.AdjustCardBalance(context.TODO(), reap.AdjustCardBalanceParams{
+			CardID: cardID,
+			Type:   reap.BalanceAdjustmentTopUp,
+			Amount: "250.50",
+		})
+		require.NoError(t, err)
+
+		expect := &reap.AdjustCardBalanceResponse{
+			ID:              "a3c1e0f2-4b4e-4d8e-9d61-1f2f2c1b7a10",
+			AvailableCredit: "5250.50",
+		}
+		assert.Equal(t, expect, resp)
+	})
+
+	t.Run("Adjust card balance error", func(t *testing.T) {
+		cardID := "1234"
+		httpmock.RegisterResponder(
+			http.MethodPost,
+			fmt.Sprintf("%s/cards/%s/balance", sandboxURL, cardID),
+			newResponderWithStatus(
+				t, http.StatusBadRequest,
+				"adjust_card_balance_request.json",
+				"create_card_response_error.json",
+			),
+		)
+
+		_, err := client.AdjustCardBalance(context.TODO(), reap.AdjustCardBalanceParams{
+			CardID: cardID,
+			Type:   reap.BalanceAdjustmentTopUp,
+			Amount: "250.50",
+		})
+		assert.ErrorContains(t, err, "expecting status 201, but got 400")
+	})
 }
 
 func newResponderWithStatus(
@@ -1 +1,6 @@
+// Package reap is the client of the Reap card issuing API.
 package reap
+
+// AccountCurrency is the currency of the Reap account, card balances
+// and spend amounts are in this currency.
+const AccountCurrency = "USD"
@@ -1,6 +1,7 @@
 package xhttp
 
 import (
+	"errors"
 	"log/slog"
 	"net/http"
 )
@@ -27,10 +28,30 @@ func WrapXHTTP(h Handler) http.Handler {
 	})
 }
 
+// Error is an error with an http status code.
+type Error struct {
+	Status int
+	Err    error
+}
+
+// NewError wraps err with the http status code.
+func NewError(status int, err error) *Error {
+	return &Error{Status: status, Err: err}
+}
+
+func (e *Error) Error() string { return e.Err.Error() }
+
+func (e *Error) Unwrap() error { return e.Err }
+
 func handleError(w http.ResponseWriter, _ *http.Request, err error) {
 	// TODO: Inject logger into context
 	slog.Error("http handler error", "err", err)
 
-	// TODO: Send appropriate error status based on error
-	w.WriteHeader(http.StatusInternalServerError)
+	status := http.StatusInternalServerError
+	var httpErr *Error
+	if errors.As(err, &httpErr) {
+		status = httpErr.Status
+	}
+
+	w.WriteHeader(status)
 }
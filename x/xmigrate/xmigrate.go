@@ -0,0 +1,276 @@
+// Package xmigrate loads versioned sql migrations with paired up and down
+// scripts and complements github.com/lopezator/migrator with status,
+// rollback and scaffolding.
+package xmigrate
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/lopezator/migrator"
+)
+
+// TableName is the table where migrator records applied migrations.
+const TableName = "migrations"
+
+// Migration is a versioned migration with paired up and down scripts.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// String returns the migration file name without direction and extension.
+func (m Migration) String() string {
+	return fmt.Sprintf("%04d_%s", m.Version, m.Name)
+}
+
+// Status is the migration status.
+type Status struct {
+	Migration
+	Applied bool
+}
+
+var fileNameRe = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Load reads migrations from the root of fsys, every migration must have
+// both the up and down scripts and versions must be sequential.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("read dir: %w", err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		matches := fileNameRe.FindStringSubmatch(entry.Name())
+		if matches == nil {
+			return nil, fmt.Errorf("invalid migration file name %q", entry.Name())
+		}
+
+		version, err := strconv.Atoi(matches[1])
+		if err != nil {
+			return nil, fmt.Errorf("parse version %q: %w", matches[1], err)
+		}
+
+		b, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("read file %q: %w", entry.Name(), err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: matches[2]}
+			byVersion[version] = m
+		}
+		if m.Name != matches[2] {
+			return nil, fmt.Errorf("version %d has conflicting names %q and %q", version, m.Name, matches[2])
+		}
+
+		if matches[3] == "up" {
+			m.Up = string(b)
+		} else {
+			m.Down = string(b)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %s must have both up and down scripts", m)
+		}
+		migrations = append(migrations, *m)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			return nil, fmt.Errorf("migration %s is out of sequence, expecting version %d", m, i+1)
+		}
+	}
+
+	return migrations, nil
+}
+
+// MustLoad is like Load but panics if an error occurs.
+func MustLoad(fsys fs.FS) []Migration {
+	migrations, err := Load(fsys)
+	if err != nil {
+		panic(err)
+	}
+
+	return migrations
+}
+
+// Migrations converts the up scripts into a migrator option.
+func Migrations(migrations []Migration) migrator.Option {
+	ms := make([]any, 0, len(migrations))
+	for _, m := range migrations {
+		ms = append(ms, &migrator.Migration{
+			Name: m.String(),
+			Func: func(tx *sql.Tx) error {
+				_, err := tx.Exec(m.Up)
+				return err
+			},
+		})
+	}
+
+	return migrator.Migrations(ms...)
+}
+
+// Applied returns the number of applied migrations.
+func Applied(db *sql.DB) (int, error) {
+	// same definition as migrator so status works on a fresh database
+	stmnt := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		id INT8 NOT NULL,
+		version VARCHAR(255) NOT NULL,
+		PRIMARY KEY (id)
+	)`, TableName)
+	if _, err := db.Exec(stmnt); err != nil {
+		return 0, fmt.Errorf("create migrations table: %w", err)
+	}
+
+	var count int
+	err := db.QueryRow(fmt.Sprintf(`SELECT count(*) FROM %s`, TableName)).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("count applied: %w", err)
+	}
+
+	return count, nil
+}
+
+// Statuses returns the status of each migration.
+func Statuses(db *sql.DB, migrations []Migration) ([]Status, error) {
+	applied, err := Applied(db)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(migrations))
+	for i, m := range migrations {
+		statuses = append(statuses, Status{Migration: m, Applied: i < applied})
+	}
+
+	return statuses, nil
+}
+
+// Pending returns the migrations that are not yet applied.
+func Pending(db *sql.DB, migrations []Migration) ([]Migration, error) {
+	applied, err := Applied(db)
+	if err != nil {
+		return nil, err
+	}
+
+	if applied > len(migrations) {
+		return nil, errors.New("applied migrations cannot be greater than the defined migrations")
+	}
+
+	return migrations[applied:], nil
+}
+
+// Rollbacks returns the last n applied migrations in rollback order.
+func Rollbacks(db *sql.DB, migrations []Migration, n int) ([]Migration, error) {
+	applied, err := Applied(db)
+	if err != nil {
+		return nil, err
+	}
+
+	if applied > len(migrations) {
+		return nil, errors.New("applied migrations cannot be greater than the defined migrations")
+	}
+
+	n = min(n, applied)
+	rollbacks := make([]Migration, 0, n)
+	for i := applied - 1; i >= applied-n; i-- {
+		rollbacks = append(rollbacks, migrations[i])
+	}
+
+	return rollbacks, nil
+}
+
+// Down rolls back the last n applied migrations, each in its own transaction.
+func Down(db *sql.DB, migrations []Migration, n int, logger migrator.Logger) error {
+	rollbacks, err := Rollbacks(db, migrations, n)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range rollbacks {
+		logger.Printf("rolling back migration named '%s'...", m)
+		if err := down(db, m); err != nil {
+			return fmt.Errorf("roll back migration %s: %w", m, err)
+		}
+		logger.Printf("rolled back migration named '%s'", m)
+	}
+
+	return nil
+}
+
+func down(db *sql.DB, m Migration) (err error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if _, err = tx.Exec(m.Down); err != nil {
+		return fmt.Errorf("exec down script: %w", err)
+	}
+
+	// migrator ids are zero based
+	stmnt := fmt.Sprintf(`DELETE FROM %s WHERE id = %d`, TableName, m.Version-1)
+	if _, err = tx.Exec(stmnt); err != nil {
+		return fmt.Errorf("delete migration version: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// Create scaffolds the up and down scripts of a new migration in dir and
+// returns the created file paths.
+func Create(dir string, name string) ([]string, error) {
+	name = strings.ToLower(strings.Join(strings.Fields(name), "_"))
+	if !regexp.MustCompile(`^[a-z0-9_]+$`).MatchString(name) {
+		return nil, fmt.Errorf("invalid migration name %q", name)
+	}
+
+	migrations, err := Load(os.DirFS(dir))
+	if err != nil {
+		return nil, fmt.Errorf("load migrations: %w", err)
+	}
+
+	m := Migration{Version: len(migrations) + 1, Name: name}
+	paths := make([]string, 0, 2)
+	for _, direction := range []string{"up", "down"} {
+		path := filepath.Join(dir, fmt.Sprintf("%s.%s.sql", m, direction))
+		content := fmt.Sprintf("-- %s %s\n", m, direction)
+
+		err := os.WriteFile(path, []byte(content), 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("write file: %w", err)
+		}
+		paths = append(paths, path)
+	}
+
+	return paths, nil
+}
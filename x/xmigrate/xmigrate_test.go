@@ -0,0 +1,81 @@
+package xmigrate_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/acme-cards-api/x/xmigrate"
+)
+
+func TestLoad(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"0002_add_status.up.sql":     {Data: []byte("alter table cards add status text;")},
+			"0002_add_status.down.sql":   {Data: []byte("alter table cards drop status;")},
+			"0001_create_cards.up.sql":   {Data: []byte("create table cards (id text);")},
+			"0001_create_cards.down.sql": {Data: []byte("drop table cards;")},
+		}
+
+		migrations, err := xmigrate.Load(fsys)
+		require.NoError(t, err)
+
+		expect := []xmigrate.Migration{
+			{Version: 1, Name: "create_cards", Up: "create table cards (id text);", Down: "drop table cards;"},
+			{Version: 2, Name: "add_status", Up: "alter table cards add status text;", Down: "alter table cards drop status;"},
+		}
+		assert.Equal(t, expect, migrations)
+		assert.Equal(t, "0002_add_status", migrations[1].String())
+	})
+
+	t.Run("missing down script", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"0001_create_cards.up.sql": {Data: []byte("create table cards (id text);")},
+		}
+
+		_, err := xmigrate.Load(fsys)
+		assert.ErrorContains(t, err, "must have both up and down scripts")
+	})
+
+	t.Run("out of sequence", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"0002_create_cards.up.sql":   {Data: []byte("create table cards (id text);")},
+			"0002_create_cards.down.sql": {Data: []byte("drop table cards;")},
+		}
+
+		_, err := xmigrate.Load(fsys)
+		assert.ErrorContains(t, err, "out of sequence")
+	})
+
+	t.Run("invalid file name", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"create_cards.sql": {Data: []byte("create table cards (id text);")},
+		}
+
+		_, err := xmigrate.Load(fsys)
+		assert.ErrorContains(t, err, "invalid migration file name")
+	})
+}
+
+func TestCreate(t *testing.T) {
+	dir := t.TempDir()
+
+	paths, err := xmigrate.Create(dir, "Create cards")
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(dir, "0001_create_cards.up.sql"),
+		filepath.Join(dir, "0001_create_cards.down.sql"),
+	}, paths)
+
+	paths, err = xmigrate.Create(dir, "add_status")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "0002_add_status.up.sql"), paths[0])
+
+	migrations, err := xmigrate.Load(os.DirFS(dir))
+	require.NoError(t, err)
+	assert.Len(t, migrations, 2)
+}
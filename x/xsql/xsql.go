@@ -0,0 +1,72 @@
+// Package xsql opens databases from url style connection strings.
+package xsql
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	// postgres driver
+	_ "github.com/lib/pq"
+	// sqlite driver
+	_ "modernc.org/sqlite"
+)
+
+// Dialect is the sql dialect of the database.
+type Dialect string
+
+// Supported dialects
+const (
+	DialectPostgres Dialect = "postgres"
+	DialectSQLite   Dialect = "sqlite"
+)
+
+// sqlitePragmas enables foreign keys and makes concurrent access wait
+// instead of failing with SQLITE_BUSY.
+const sqlitePragmas = "_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+
+// Open opens the database and selects the driver by the dsn scheme,
+// postgres:// or postgresql:// for PostgreSQL and sqlite:// for SQLite
+// e.g. sqlite://acme.db, sqlite:///var/lib/acme.db or sqlite://:memory:.
+func Open(dsn string) (*sql.DB, Dialect, error) {
+	driver, driverDSN, dialect, err := parseDSN(dsn)
+	if err != nil {
+		return nil, "", err
+	}
+
+	db, err := sql.Open(driver, driverDSN)
+	if err != nil {
+		return nil, "", fmt.Errorf("sql open: %w", err)
+	}
+
+	if dialect == DialectSQLite && strings.Contains(driverDSN, ":memory:") {
+		// every connection would otherwise get its own database
+		db.SetMaxOpenConns(1)
+	}
+
+	return db, dialect, nil
+}
+
+func parseDSN(dsn string) (driver, driverDSN string, dialect Dialect, err error) {
+	scheme, rest, ok := strings.Cut(dsn, "://")
+	if !ok {
+		return "", "", "", fmt.Errorf("dsn must have a scheme e.g. postgres:// or sqlite://")
+	}
+
+	switch strings.ToLower(scheme) {
+	case "postgres", "postgresql":
+		return "postgres", dsn, DialectPostgres, nil
+	case "sqlite", "sqlite3":
+		if rest == "" {
+			return "", "", "", fmt.Errorf("sqlite dsn must have a path")
+		}
+
+		sep := "?"
+		if strings.Contains(rest, "?") {
+			sep = "&"
+		}
+		return "sqlite", "file:" + rest + sep + sqlitePragmas, DialectSQLite, nil
+	}
+
+	return "", "", "", fmt.Errorf("unsupported dsn scheme %q", scheme)
+}